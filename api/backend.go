@@ -19,19 +19,32 @@ import (
 	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/holiman/uint256"
+	tmproto "github.com/tendermint/tendermint/proto/tendermint/types"
+	tmtypes "github.com/tendermint/tendermint/types"
 
 	"github.com/smartbch/moeingevm/types"
 	"github.com/smartbch/smartbch/app"
 	"github.com/smartbch/smartbch/crosschain"
 	cctypes "github.com/smartbch/smartbch/crosschain/types"
+	"github.com/smartbch/smartbch/freeze"
+	"github.com/smartbch/smartbch/internal/multisig"
 	"github.com/smartbch/smartbch/param"
 	"github.com/smartbch/smartbch/staking"
 	stakingtypes "github.com/smartbch/smartbch/staking/types"
+	"github.com/smartbch/smartbch/txcodec"
+	"github.com/smartbch/smartbch/watcher"
 	watchertypes "github.com/smartbch/smartbch/watcher/types"
 )
 
 var _ BackendService = &apiBackend{}
 
+var (
+	ErrNotLocalTx  = errors.New("the transaction was not submitted through this node")
+	ErrTxCommitted = errors.New("the transaction is already committed")
+	ErrNotTxSender = errors.New("the cancellation is not signed by the sender of the transaction")
+	ErrSafeMode    = errors.New("the node is in safe mode, it does not accept transactions")
+)
+
 const (
 	// Ethereum Wire Protocol
 	// https://github.com/ethereum/devp2p/blob/master/caps/eth.md
@@ -56,12 +69,17 @@ type apiBackend struct {
 
 	rpcPrivateKeyLock sync.RWMutex
 	rpcPrivateKey     *ecdsa.PrivateKey
+
+	localTxs  *localTxs
+	txDecoder *txcodec.Decoder
 }
 
 func NewBackend(node ITmNode, app app.IApp) BackendService {
 	return &apiBackend{
-		node: node,
-		app:  app,
+		node:      node,
+		app:       app,
+		localTxs:  newLocalTxs(),
+		txDecoder: txcodec.NewDecoder(txcodec.DefaultConfig(app.ChainID().ToBig())),
 	}
 }
 
@@ -96,6 +114,22 @@ func (backend *apiBackend) GetCode(contract common.Address, height int64) (bytec
 	return
 }
 
+func (backend *apiBackend) GetCodes(contracts []common.Address, height int64) (bytecodes [][]byte, codeHashes [][]byte) {
+	ctx := backend.app.GetRpcContextAtHeight(height)
+	defer ctx.Close(false)
+
+	bytecodes = make([][]byte, len(contracts))
+	codeHashes = make([][]byte, len(contracts))
+	for i, contract := range contracts {
+		info := ctx.GetCode(contract)
+		if info != nil {
+			bytecodes[i] = info.BytecodeSlice()
+			codeHashes[i] = info.CodeHashSlice()
+		}
+	}
+	return
+}
+
 func (backend *apiBackend) GetBalance(owner common.Address, height int64) (*big.Int, error) {
 	ctx := backend.app.GetRpcContextAtHeight(height)
 	defer ctx.Close(false)
@@ -142,7 +176,13 @@ func (backend *apiBackend) BlockByHash(hash common.Hash) (*types.Block, error) {
 func (backend *apiBackend) BlockByNumber(number int64) (*types.Block, error) {
 	ctx := backend.app.GetHistoryOnlyContext()
 	defer ctx.Close(false)
-	return ctx.GetBlockByHeight(uint64(number))
+	block, err := ctx.GetBlockByHeight(uint64(number))
+	if err != nil {
+		if coldBlock, coldErr := backend.app.GetColdBlock(number); coldErr == nil {
+			block, _, _, err = coldBlock.Unpack()
+		}
+	}
+	return block, err
 }
 
 func (backend *apiBackend) ProtocolVersion() int {
@@ -168,7 +208,61 @@ func (backend *apiBackend) CurrentBlock() (*types.Block, error) {
 }
 
 func (backend *apiBackend) SendRawTx(signedTx []byte) (common.Hash, error) {
-	return backend.node.BroadcastTxSync(signedTx)
+	tmTxHash, err := backend.node.BroadcastTxSync(signedTx)
+	if err != nil {
+		return tmTxHash, err
+	}
+	if tx, err := backend.txDecoder.Decode(signedTx); err == nil {
+		backend.localTxs.add(tx.Hash(), signedTx)
+	}
+	return tmTxHash, nil
+}
+
+// ReapBlockTxs returns the txs in the mempool which this node would include in the block it
+// proposes now, with the block limits set in InitChain
+func (backend *apiBackend) ReapBlockTxs() [][]byte {
+	maxDataBytes := tmtypes.MaxDataBytesNoEvidence(param.BlockMaxBytes, 1)
+	txs := backend.node.ReapMaxBytesMaxGas(maxDataBytes, param.BlockMaxGas)
+	result := make([][]byte, len(txs))
+	for i, tx := range txs {
+		result[i] = tx
+	}
+	return result
+}
+
+// PendingTxsOf returns the txs sent by sender in the mempool of this node, in the mempool order
+func (backend *apiBackend) PendingTxsOf(sender common.Address) []*gethtypes.Transaction {
+	var txs []*gethtypes.Transaction
+	for _, rawTx := range backend.node.ReapMaxTxs(-1) {
+		tx, from, err := backend.txDecoder.DecodeAndVerify(rawTx)
+		if err == nil && from == sender {
+			txs = append(txs, tx)
+		}
+	}
+	return txs
+}
+
+// CancelTx drops a pending transaction submitted through this node from its mempool, sig is the
+// personal_sign signature of CancelTxMessage(txHash) by the sender of the transaction
+func (backend *apiBackend) CancelTx(txHash common.Hash, sig []byte) (*gethtypes.Transaction, common.Address, error) {
+	rawTx, ok := backend.localTxs.get(txHash)
+	if !ok {
+		return nil, common.Address{}, ErrNotLocalTx
+	}
+	tx, sender, err := backend.txDecoder.DecodeAndVerify(rawTx)
+	if err != nil {
+		return nil, common.Address{}, err
+	}
+	if signer, err := recoverCancelTxSigner(txHash, sig); err != nil || signer != sender {
+		return nil, common.Address{}, ErrNotTxSender
+	}
+	// a tx which is neither committed nor in the mempool any more is canceled anyway, so it is not
+	// accepted again when it is gossiped back from the peers
+	if committed, _, _ := backend.GetTransaction(txHash); committed != nil {
+		return nil, common.Address{}, ErrTxCommitted
+	}
+	backend.app.CancelTx(txHash)
+	return tx, sender, nil
 }
 
 // CallForSbch use app.RunTxForSbchRpc and returns more detailed result info
@@ -235,7 +329,25 @@ func (backend *apiBackend) GetTxListByHeightWithRange(height uint32, start, end
 	ctx := backend.app.GetHistoryOnlyContext()
 	defer ctx.Close(false)
 
-	return ctx.GetTxListByHeightWithRange(height, start, end)
+	tx, sigs, err = ctx.GetTxListByHeightWithRange(height, start, end)
+	if err != nil || len(tx) == 0 {
+		// an empty list may be a block pruned from moeingdb
+		if coldBlock, coldErr := backend.app.GetColdBlock(int64(height)); coldErr == nil {
+			_, tx, sigs, err = coldBlock.Unpack()
+			tx, sigs = txRange(tx, sigs, start, end)
+		}
+	}
+	return
+}
+
+func txRange(txs []*types.Transaction, sigs [][65]byte, start, end int) ([]*types.Transaction, [][65]byte) {
+	if end > len(txs) {
+		end = len(txs)
+	}
+	if start > end {
+		start = end
+	}
+	return txs[start:end], sigs[start:end]
 }
 
 func (backend *apiBackend) GetToAddressCount(addr common.Address) int64 {
@@ -263,10 +375,14 @@ func (backend *apiBackend) GetSep20FromAddressCount(contract common.Address, add
 	return ctx.GetSep20FromAddressCount(contract, addr)
 }
 
-func (backend *apiBackend) GetCurrEpoch() *stakingtypes.Epoch {
+func (backend *apiBackend) GetCurrEpoch() (*stakingtypes.Epoch, error) {
 	return backend.app.GetCurrEpoch()
 }
 
+func (backend *apiBackend) GetNominationStatus(pubkey [32]byte) (*staking.NominationStatus, error) {
+	return backend.app.GetNominationStatus(pubkey)
+}
+
 //[start, end)
 func (backend *apiBackend) GetVoteInfos(start, end uint64) ([]*watchertypes.VoteInfo, error) {
 	if start >= end {
@@ -297,7 +413,7 @@ func (backend *apiBackend) GetVoteInfos(start, end uint64) ([]*watchertypes.Vote
 func (backend *apiBackend) GetEpochList(from string) ([]*stakingtypes.Epoch, error) {
 	switch from {
 	case "watcher":
-		return backend.app.GetWatcherEpochList(), nil
+		return backend.app.GetWatcherEpochList()
 	case "app":
 		return backend.app.GetAppEpochList(), nil
 	case "storage":
@@ -442,6 +558,10 @@ func (backend *apiBackend) ValidatorOnlineInfos() stakingtypes.ValidatorOnlineIn
 	return staking.LoadOnlineInfo(ctx)
 }
 
+func (backend *apiBackend) SubscribeStakingEvent(ch chan<- *app.StakingEvent) event.Subscription {
+	return backend.app.SubscribeStakingEvent(ch)
+}
+
 func (backend *apiBackend) IsArchiveMode() bool {
 	return backend.app.IsArchiveMode()
 }
@@ -471,6 +591,30 @@ func (backend *apiBackend) GetRpcMaxLogResults() int {
 	return backend.app.GetRpcMaxLogResults()
 }
 
+func (backend *apiBackend) GetModbIndexes() param.ModbIndexes {
+	return backend.app.GetModbIndexes()
+}
+
+func (backend *apiBackend) RecordAudit(action, source string, details map[string]string) {
+	backend.app.RecordAudit(action, source, details)
+}
+
+func (backend *apiBackend) GetTimeInfo() app.TimeInfo {
+	return backend.app.GetTimeInfo()
+}
+
+func (backend *apiBackend) AdminOpsRequireSigs() bool {
+	return backend.app.AdminOpsRequireSigs()
+}
+
+func (backend *apiBackend) VerifyAdminOp(payload []byte, sigs [][]byte) (*multisig.Op, []common.Address, error) {
+	return backend.app.VerifyAdminOp(payload, sigs)
+}
+
+func (backend *apiBackend) GetAdminOpNonce() (uint64, error) {
+	return backend.app.GetAdminOpNonce()
+}
+
 func (backend *apiBackend) IsCrossChainPaused() bool {
 	ctx := backend.app.GetRpcContext()
 	defer ctx.Close(false)
@@ -500,6 +644,25 @@ func (backend *apiBackend) GetLostAndFoundUTXOs() []*cctypes.UTXORecord {
 	return loadUtxoRecords(ctx, utxoIds)
 }
 
+// GetPegInRefunds returns the lost-and-found UTXOs which can be refunded, and their refund records
+func (backend *apiBackend) GetPegInRefunds() ([]*cctypes.UTXORecord, []*cctypes.RefundRecord) {
+	ctx := backend.app.GetRpcContext()
+	defer ctx.Close(false)
+
+	var utxoRecords []*cctypes.UTXORecord
+	var refundRecords []*cctypes.RefundRecord
+	for _, r := range loadUtxoRecords(ctx, backend.app.GetLostAndFoundUtxoIds()) {
+		if r.IsRedeemed {
+			continue
+		}
+		if refund := crosschain.LoadRefundRecord(ctx, r.Txid, r.Index); refund != nil {
+			utxoRecords = append(utxoRecords, r)
+			refundRecords = append(refundRecords, refund)
+		}
+	}
+	return utxoRecords, refundRecords
+}
+
 func (backend *apiBackend) GetRedeemingUTXOs() []*cctypes.UTXORecord {
 	ctx := backend.app.GetRpcContext()
 	defer ctx.Close(false)
@@ -636,3 +799,131 @@ func (backend *apiBackend) WaitRpcKeySet() {
 func (backend *apiBackend) GetWatcherHeight() int64 {
 	return backend.app.GetWatcherHeight()
 }
+
+func (backend *apiBackend) GetCcCollectStatus() watchertypes.CcCollectStatus {
+	return backend.app.GetCcCollectStatus()
+}
+
+func (backend *apiBackend) GetWatcherStatus() watcher.Status {
+	return backend.app.GetWatcherStatus()
+}
+
+func (backend *apiBackend) GetCcTiming() watcher.CcTiming {
+	return backend.app.GetCcTiming()
+}
+
+func (backend *apiBackend) ForceCcRescan(begin, end int64) error {
+	return backend.app.ForceCcRescan(begin, end)
+}
+
+func (backend *apiBackend) SetCcCollectPaused(paused bool) bool {
+	return backend.app.SetCcCollectPaused(paused)
+}
+
+func (backend *apiBackend) GetPendingCcDeposits() ([]watchertypes.PendingCcDeposit, bool) {
+	return backend.app.GetPendingCcDeposits()
+}
+
+func (backend *apiBackend) GetWatcherMonitorVoteInfos() ([]*cctypes.MonitorVoteInfo, *cctypes.MonitorVoteInfo, error) {
+	return backend.app.GetWatcherMonitorVoteInfos()
+}
+
+func (backend *apiBackend) GetWatcherAnalytics(limit int) watcher.AnalyticsReport {
+	return backend.app.GetWatcherAnalytics(limit)
+}
+
+func (backend *apiBackend) GetArchivedEpochs(start, end int64) ([]*watcher.ArchivedEpoch, bool) {
+	return backend.app.GetArchivedEpochs(start, end)
+}
+
+func (backend *apiBackend) GetArchivedEpochByHeight(height int64) (*watcher.ArchivedEpoch, bool) {
+	return backend.app.GetArchivedEpochByHeight(height)
+}
+
+func (backend *apiBackend) GetSignedVoteInfos(startHeight int64) (*watchertypes.SignedVoteInfos, error) {
+	return backend.app.GetSignedVoteInfos(startHeight)
+}
+
+func (backend *apiBackend) IsDevMode() bool {
+	return backend.app.IsDevMode()
+}
+
+func (backend *apiBackend) IncreaseTime(seconds int64) (int64, error) {
+	return backend.app.IncreaseTime(seconds)
+}
+
+func (backend *apiBackend) SetNextBlockTimestamp(timestamp int64) error {
+	return backend.app.SetNextBlockTimestamp(timestamp)
+}
+
+func (backend *apiBackend) GetPeerScores() []*app.PeerScore {
+	return backend.app.GetPeerScores()
+}
+
+func (backend *apiBackend) AddTracedAddress(addr common.Address) bool {
+	return backend.app.AddTracedAddress(addr)
+}
+
+func (backend *apiBackend) RemoveTracedAddress(addr common.Address) bool {
+	return backend.app.RemoveTracedAddress(addr)
+}
+
+func (backend *apiBackend) GetTracedAddresses() []common.Address {
+	return backend.app.GetTracedAddresses()
+}
+
+func (backend *apiBackend) GetFrozenAddresses() []*freeze.FrozenAddress {
+	return backend.app.GetFrozenAddresses()
+}
+
+func (backend *apiBackend) GetCreate2Contract(addr common.Address) *app.Create2Contract {
+	return backend.app.GetCreate2Contract(addr)
+}
+
+func (backend *apiBackend) GetCreate2ContractsByDeployer(deployer common.Address) []*app.Create2Contract {
+	return backend.app.GetCreate2ContractsByDeployer(deployer)
+}
+
+func (backend *apiBackend) GetTxFeeRecord(txHash common.Hash) *app.TxFeeRecord {
+	return backend.app.GetTxFeeRecord(txHash)
+}
+
+func (backend *apiBackend) GetBlockFeeRecords(startHeight, endHeight int64) []*app.BlockFeeRecord {
+	return backend.app.GetBlockFeeRecords(startHeight, endHeight)
+}
+
+func (backend *apiBackend) GetGasGrants(height int64) []*app.GasGrant {
+	return backend.app.GetGasGrants(height)
+}
+
+func (backend *apiBackend) GetBlockWitness(height int64) *app.BlockWitness {
+	return backend.app.GetBlockWitness(height)
+}
+
+func (backend *apiBackend) GetIdleStateReport(idleBlocks int64, top int) *app.StateAccessReport {
+	return backend.app.GetIdleStateReport(idleBlocks, top)
+}
+
+func (backend *apiBackend) SimulateStateRent(policies []app.StateRentPolicy) []*app.StateRentResult {
+	return backend.app.SimulateStateRent(policies)
+}
+
+func (backend *apiBackend) GetProposerInfo(consAddr common.Address) *app.ProposerInfo {
+	return backend.app.GetProposerInfo(consAddr)
+}
+
+func (backend *apiBackend) GetProposerInfos() []*app.ProposerInfo {
+	return backend.app.GetProposerInfos()
+}
+
+func (backend *apiBackend) GetAddressTraces(addr common.Address, startHeight, endHeight int64) []*app.TracedTx {
+	return backend.app.GetAddressTraces(addr, startHeight, endHeight)
+}
+
+func (backend *apiBackend) ConsensusParams() (tmproto.ConsensusParams, int64) {
+	return backend.node.GetConsensusParams()
+}
+
+func (backend *apiBackend) GetParamChanges() []*app.ParamChange {
+	return backend.app.GetParamChanges()
+}
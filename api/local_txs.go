@@ -0,0 +1,68 @@
+package api
+
+import (
+	"errors"
+	"sync"
+
+	gethacc "github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// The max number of the transactions recorded by localTxs, the oldest ones are forgotten first
+const maxLocalTxs = 10000
+
+// localTxs records the raw transactions submitted through the RPC of this node, only they can be
+// canceled by sbch_cancelTransaction
+type localTxs struct {
+	mtx    sync.Mutex
+	txs    map[common.Hash][]byte
+	hashes []common.Hash // in the order of submission
+}
+
+func newLocalTxs() *localTxs {
+	return &localTxs{
+		txs: make(map[common.Hash][]byte),
+	}
+}
+
+func (l *localTxs) add(hash common.Hash, rawTx []byte) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	if _, ok := l.txs[hash]; ok {
+		return
+	}
+	if len(l.hashes) >= maxLocalTxs {
+		delete(l.txs, l.hashes[0])
+		l.hashes = l.hashes[1:]
+	}
+	l.txs[hash] = rawTx
+	l.hashes = append(l.hashes, hash)
+}
+
+func (l *localTxs) get(hash common.Hash) ([]byte, bool) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	rawTx, ok := l.txs[hash]
+	return rawTx, ok
+}
+
+// CancelTxMessage is the message signed with personal_sign by the sender to cancel the tx of txHash
+func CancelTxMessage(txHash common.Hash) []byte {
+	return []byte("cancel transaction " + txHash.Hex())
+}
+
+func recoverCancelTxSigner(txHash common.Hash, sig []byte) (common.Address, error) {
+	if len(sig) != crypto.SignatureLength {
+		return common.Address{}, errors.New("invalid signature length")
+	}
+	sig = common.CopyBytes(sig)
+	if sig[64] >= 27 {
+		sig[64] -= 27 // the V of personal_sign is 27 or 28
+	}
+	pubkey, err := crypto.SigToPub(gethacc.TextHash(CancelTxMessage(txHash)), sig)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return crypto.PubkeyToAddress(*pubkey), nil
+}
@@ -0,0 +1,28 @@
+package api
+
+import (
+	"testing"
+
+	gethacc "github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecoverCancelTxSigner(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	txHash := common.Hash{0x01}
+	sig, err := crypto.Sign(gethacc.TextHash(CancelTxMessage(txHash)), key)
+	require.NoError(t, err)
+	sig[64] += 27
+
+	signer, err := recoverCancelTxSigner(txHash, sig)
+	require.NoError(t, err)
+	require.Equal(t, crypto.PubkeyToAddress(key.PublicKey), signer)
+
+	// the signature cannot be used to cancel another tx
+	signer, _ = recoverCancelTxSigner(common.Hash{0x02}, sig)
+	require.NotEqual(t, crypto.PubkeyToAddress(key.PublicKey), signer)
+	_, err = recoverCancelTxSigner(txHash, sig[:64])
+	require.Error(t, err)
+}
@@ -11,12 +11,18 @@ import (
 	gethtypes "github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/rpc"
+	tmproto "github.com/tendermint/tendermint/proto/tendermint/types"
 
 	motypes "github.com/smartbch/moeingevm/types"
 	"github.com/smartbch/smartbch/app"
 	"github.com/smartbch/smartbch/crosschain"
 	cctypes "github.com/smartbch/smartbch/crosschain/types"
+	"github.com/smartbch/smartbch/freeze"
+	"github.com/smartbch/smartbch/internal/multisig"
+	"github.com/smartbch/smartbch/param"
+	"github.com/smartbch/smartbch/staking"
 	"github.com/smartbch/smartbch/staking/types"
+	"github.com/smartbch/smartbch/watcher"
 	watchertypes "github.com/smartbch/smartbch/watcher/types"
 )
 
@@ -80,6 +86,9 @@ type BackendService interface {
 
 	// Transaction pool API
 	SendRawTx(signedTx []byte) (common.Hash, error)
+	CancelTx(txHash common.Hash, sig []byte) (tx *gethtypes.Transaction, sender common.Address, err error)
+	ReapBlockTxs() [][]byte
+	PendingTxsOf(sender common.Address) []*gethtypes.Transaction
 	GetTransaction(txHash common.Hash) (tx *motypes.Transaction, sig [65]byte, err error)
 	//GetPoolTransactions() (types.Transactions, error)
 	//GetPoolTransaction(txHash common.Hash) *types.Transaction
@@ -105,6 +114,8 @@ type BackendService interface {
 	GetNonce(address common.Address, height int64) (uint64, error)
 	GetBalance(address common.Address, height int64) (*big.Int, error)
 	GetCode(contract common.Address, height int64) (bytecode []byte, codeHash []byte)
+	// GetCodes is GetCode of many contracts, reading the same state
+	GetCodes(contracts []common.Address, height int64) (bytecodes [][]byte, codeHashes [][]byte)
 	GetStorageAt(address common.Address, key string, height int64) []byte
 	Call(tx *gethtypes.Transaction, from common.Address, height int64) (statusCode int, retData []byte)
 	CallForSbch(tx *gethtypes.Transaction, sender common.Address, height int64) *CallDetail
@@ -122,15 +133,22 @@ type BackendService interface {
 	GetSep20FromAddressCount(contract common.Address, addr common.Address) int64
 	GetVoteInfos(start, end uint64) ([]*watchertypes.VoteInfo, error)
 	GetEpochList(from string) ([]*types.Epoch, error)
-	GetCurrEpoch() *types.Epoch
+	GetCurrEpoch() (*types.Epoch, error)
+	GetNominationStatus(pubkey [32]byte) (*staking.NominationStatus, error)
 	GetSeq(address common.Address) uint64
 	GetPosVotes() map[[32]byte]*big.Int
 	GetSyncBlock(height int64) (blk []byte, err error)
 	GetRpcMaxLogResults() int
+	GetModbIndexes() param.ModbIndexes
+	RecordAudit(action, source string, details map[string]string)
+	AdminOpsRequireSigs() bool
+	VerifyAdminOp(payload []byte, sigs [][]byte) (*multisig.Op, []common.Address, error)
+	GetAdminOpNonce() (uint64, error)
 	IsCrossChainPaused() bool
 	GetAllOperatorsInfo() []*crosschain.OperatorInfo
 	GetAllMonitorsInfo() []*crosschain.MonitorInfo
 	GetLostAndFoundUTXOs() []*cctypes.UTXORecord
+	GetPegInRefunds() ([]*cctypes.UTXORecord, []*cctypes.RefundRecord)
 	GetRedeemingUTXOs() []*cctypes.UTXORecord
 	GetRedeemableUtxos() []*cctypes.UTXORecord
 	GetToBeConvertedUTXOs() ([]*cctypes.UTXORecord, int64)
@@ -140,11 +158,45 @@ type BackendService interface {
 	GetCcContext() *cctypes.CCContext
 	GetCcInfosForTest() *cctypes.CCInfosForTest
 	GetWatcherHeight() int64
+	AddTracedAddress(addr common.Address) bool
+	RemoveTracedAddress(addr common.Address) bool
+	GetTracedAddresses() []common.Address
+	GetAddressTraces(addr common.Address, startHeight, endHeight int64) []*app.TracedTx
+	GetFrozenAddresses() []*freeze.FrozenAddress
+	GetCreate2Contract(addr common.Address) *app.Create2Contract
+	GetCreate2ContractsByDeployer(deployer common.Address) []*app.Create2Contract
+	GetTxFeeRecord(txHash common.Hash) *app.TxFeeRecord
+	GetBlockFeeRecords(startHeight, endHeight int64) []*app.BlockFeeRecord
+	GetGasGrants(height int64) []*app.GasGrant
+	GetBlockWitness(height int64) *app.BlockWitness
+	GetIdleStateReport(idleBlocks int64, top int) *app.StateAccessReport
+	SimulateStateRent(policies []app.StateRentPolicy) []*app.StateRentResult
+	GetProposerInfo(consAddr common.Address) *app.ProposerInfo
+	GetProposerInfos() []*app.ProposerInfo
+	ConsensusParams() (params tmproto.ConsensusParams, lastChangedHeight int64)
+	GetParamChanges() []*app.ParamChange
+	GetTimeInfo() app.TimeInfo
+	GetCcCollectStatus() watchertypes.CcCollectStatus
+	GetWatcherStatus() watcher.Status
+	GetCcTiming() watcher.CcTiming
+	ForceCcRescan(begin, end int64) error
+	SetCcCollectPaused(paused bool) bool
+	GetPendingCcDeposits() ([]watchertypes.PendingCcDeposit, bool)
+	GetWatcherMonitorVoteInfos() ([]*cctypes.MonitorVoteInfo, *cctypes.MonitorVoteInfo, error)
+	GetWatcherAnalytics(limit int) watcher.AnalyticsReport
+	GetArchivedEpochs(start, end int64) ([]*watcher.ArchivedEpoch, bool)
+	GetArchivedEpochByHeight(height int64) (*watcher.ArchivedEpoch, bool)
+	GetSignedVoteInfos(startHeight int64) (*watchertypes.SignedVoteInfos, error)
+	IsDevMode() bool
+	IncreaseTime(seconds int64) (int64, error)
+	SetNextBlockTimestamp(timestamp int64) error
+	GetPeerScores() []*app.PeerScore
 
 	//tendermint info
 	NodeInfo() Info
 	ValidatorsInfo() app.ValidatorsInfo
 	ValidatorOnlineInfos() types.ValidatorOnlineInfos
+	SubscribeStakingEvent(ch chan<- *app.StakingEvent) event.Subscription
 
 	IsArchiveMode() bool
 
@@ -3,13 +3,14 @@ package api
 import (
 	"bytes"
 	"encoding/json"
-	"errors"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	abci "github.com/tendermint/tendermint/abci/types"
 	"github.com/tendermint/tendermint/mempool"
 	"github.com/tendermint/tendermint/node"
+	tmproto "github.com/tendermint/tendermint/proto/tendermint/types"
+	sm "github.com/tendermint/tendermint/state"
 	tmtypes "github.com/tendermint/tendermint/types"
 
 	"github.com/smartbch/smartbch/app"
@@ -37,6 +38,9 @@ type Info struct {
 
 type ITmNode interface {
 	BroadcastTxSync(tx tmtypes.Tx) (common.Hash, error)
+	ReapMaxBytesMaxGas(maxBytes, maxGas int64) tmtypes.Txs
+	ReapMaxTxs(max int) tmtypes.Txs
+	GetConsensusParams() (params tmproto.ConsensusParams, lastChangedHeight int64)
 	GetNodeInfo() Info
 }
 
@@ -51,6 +55,17 @@ func NewTmNode(node *node.Node) ITmNode {
 	return &tmNode{node: node}
 }
 
+// CheckTxError is returned by BroadcastTxSync when the tx is rejected by CheckTx, Code is one of
+// the CheckTx codes of the app
+type CheckTxError struct {
+	Code uint32
+	Info string
+}
+
+func (e *CheckTxError) Error() string {
+	return e.Info
+}
+
 func (tmNode *tmNode) BroadcastTxSync(tx tmtypes.Tx) (common.Hash, error) {
 	resCh := make(chan *abci.Response, 1)
 	err := tmNode.node.Mempool().CheckTx(tx, func(res *abci.Response) {
@@ -62,11 +77,29 @@ func (tmNode *tmNode) BroadcastTxSync(tx tmtypes.Tx) (common.Hash, error) {
 	res := <-resCh
 	r := res.GetCheckTx()
 	if r.Code != abci.CodeTypeOK {
-		return common.Hash{}, errors.New(r.String())
+		return common.Hash{}, &CheckTxError{Code: r.Code, Info: r.Info}
 	}
 	return common.BytesToHash(tx.Hash()), nil
 }
 
+// ReapMaxBytesMaxGas returns the txs in the mempool which fit in a block, in the same way the
+// proposer reaps them
+func (tmNode *tmNode) ReapMaxBytesMaxGas(maxBytes, maxGas int64) tmtypes.Txs {
+	return tmNode.node.Mempool().ReapMaxBytesMaxGas(maxBytes, maxGas)
+}
+
+// ReapMaxTxs returns at most max txs in the mempool, all of them if max is negative
+func (tmNode *tmNode) ReapMaxTxs(max int) tmtypes.Txs {
+	return tmNode.node.Mempool().ReapMaxTxs(max)
+}
+
+// GetConsensusParams returns the consensus params of tendermint's latest state, and the height
+// since which they take effect
+func (tmNode *tmNode) GetConsensusParams() (tmproto.ConsensusParams, int64) {
+	state := tmNode.node.ConsensusState().GetState()
+	return state.ConsensusParams, state.LastHeightConsensusParamsChanged
+}
+
 func (tmNode *tmNode) GetNodeInfo() Info {
 	i := Info{}
 	i.Height = tmNode.node.BlockStore().Height()
@@ -93,3 +126,39 @@ func (tmNode *tmNode) GetNodeInfo() Info {
 	//i.NextBlock.Hash = bi.Hash
 	return i
 }
+
+/*-----------------------safe mode----------------------------*/
+
+// safeModeNode stands in for the tendermint node in safe mode, in which the node serves the
+// committed local state without joining the consensus. It has no mempool and accepts no txs.
+type safeModeNode struct {
+	genesis *tmtypes.GenesisDoc
+	state   sm.State // the tendermint state when the node started
+}
+
+func NewSafeModeTmNode(genesis *tmtypes.GenesisDoc, state sm.State) ITmNode {
+	return &safeModeNode{genesis: genesis, state: state}
+}
+
+func (n *safeModeNode) BroadcastTxSync(_ tmtypes.Tx) (common.Hash, error) {
+	return common.Hash{}, ErrSafeMode
+}
+
+func (n *safeModeNode) ReapMaxBytesMaxGas(_, _ int64) tmtypes.Txs {
+	return nil
+}
+
+func (n *safeModeNode) ReapMaxTxs(_ int) tmtypes.Txs {
+	return nil
+}
+
+func (n *safeModeNode) GetConsensusParams() (tmproto.ConsensusParams, int64) {
+	return n.state.ConsensusParams, n.state.LastHeightConsensusParamsChanged
+}
+
+func (n *safeModeNode) GetNodeInfo() Info {
+	return Info{
+		Height:   n.state.LastBlockHeight,
+		AppState: n.genesis.AppState,
+	}
+}
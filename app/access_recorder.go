@@ -0,0 +1,126 @@
+package app
+
+import (
+	"sort"
+	"sync"
+
+	storetypes "github.com/smartbch/moeingads/store/types"
+)
+
+// stateAccesses are the entries of MoeingADS read and written by the transactions of a block, keyed
+// by their short keys. A read records the first content seen, nil if the entry did not exist, and a
+// write records the last content written, nil for a deletion. A content is a rabbit.CachedValue in
+// bytes, with the original key and value.
+type stateAccesses struct {
+	reads  map[string][]byte
+	writes map[string][]byte
+}
+
+func (a *stateAccesses) sortedReads() []string {
+	keys := make([]string, 0, len(a.reads))
+	for k := range a.reads {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// accessRecorder records the state accessed by the engine while it executes a block. ebp records
+// the read and write lists of the transactions only if it is built with EnableRWList, which is off
+// in the version we use, so the trunk under the context of the engine is watched instead.
+type accessRecorder struct {
+	mtx      sync.Mutex
+	accesses *stateAccesses // nil when not recording
+}
+
+func newAccessRecorder() *accessRecorder {
+	return &accessRecorder{}
+}
+
+// start records the accesses made from now on
+func (r *accessRecorder) start() {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.accesses = &stateAccesses{
+		reads:  make(map[string][]byte),
+		writes: make(map[string][]byte),
+	}
+}
+
+// stop returns the accesses recorded since start
+func (r *accessRecorder) stop() *stateAccesses {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	accesses := r.accesses
+	r.accesses = nil
+	return accesses
+}
+
+// isRabbitKey returns whether key is a short key of a rabbit store, the other keys of the trunk,
+// such as the ones of the standby tx queue, are used by the engine itself
+func isRabbitKey(key []byte) bool {
+	return len(key) == 8 && key[0] >= 64 && key[0] < 192
+}
+
+func (r *accessRecorder) read(key, content []byte) {
+	if !isRabbitKey(key) {
+		return
+	}
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	if r.accesses == nil {
+		return
+	}
+	if _, ok := r.accesses.reads[string(key)]; !ok {
+		r.accesses.reads[string(key)] = append([]byte(nil), content...)
+	}
+}
+
+func (r *accessRecorder) write(key, content []byte) {
+	if !isRabbitKey(key) {
+		return
+	}
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	if r.accesses == nil {
+		return
+	}
+	r.accesses.writes[string(key)] = append([]byte(nil), content...)
+}
+
+// wrap returns a store reading and writing through trunk, whose accesses are recorded
+func (r *accessRecorder) wrap(trunk storetypes.BaseStoreI) storetypes.BaseStoreI {
+	return &recordedStore{BaseStoreI: trunk, recorder: r}
+}
+
+type recordedStore struct {
+	storetypes.BaseStoreI
+	recorder *accessRecorder
+}
+
+func (s *recordedStore) Get(key []byte) []byte {
+	content := s.BaseStoreI.Get(key)
+	s.recorder.read(key, content)
+	return content
+}
+
+func (s *recordedStore) Update(updater func(db storetypes.SetDeleter)) {
+	s.BaseStoreI.Update(func(db storetypes.SetDeleter) {
+		updater(&recordedSetDeleter{SetDeleter: db, recorder: s.recorder})
+	})
+}
+
+type recordedSetDeleter struct {
+	storetypes.SetDeleter
+	recorder *accessRecorder
+}
+
+func (d *recordedSetDeleter) Set(key, value []byte) {
+	d.SetDeleter.Set(key, value)
+	d.recorder.write(key, value)
+}
+
+func (d *recordedSetDeleter) Delete(key []byte) {
+	d.SetDeleter.Delete(key)
+	d.recorder.write(key, nil)
+}
@@ -0,0 +1,147 @@
+package app
+
+import (
+	"sync"
+
+	gethcmn "github.com/ethereum/go-ethereum/common"
+	modbtypes "github.com/smartbch/moeingdb/types"
+	"github.com/smartbch/moeingevm/types"
+)
+
+// The max number of traced transactions kept in memory, the oldest ones are dropped first
+const MaxTracedTxCount = 10000
+
+// TracedTx is a committed transaction which touches at least one of the traced addresses.
+// It contains the internal calls and (if enabled in the engine) the read/write lists.
+type TracedTx struct {
+	Height  int64
+	Tx      *types.Transaction
+	Touched []gethcmn.Address
+}
+
+// addressTracer records the transactions touching the addresses registered at runtime.
+// When no address is registered, it costs nothing.
+type addressTracer struct {
+	mtx   sync.RWMutex
+	addrs map[gethcmn.Address]struct{}
+	txs   []*TracedTx // a ring buffer
+	next  int
+}
+
+func newAddressTracer() *addressTracer {
+	return &addressTracer{
+		addrs: make(map[gethcmn.Address]struct{}),
+	}
+}
+
+func (t *addressTracer) add(addr gethcmn.Address) bool {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	if _, ok := t.addrs[addr]; ok {
+		return false
+	}
+	t.addrs[addr] = struct{}{}
+	return true
+}
+
+func (t *addressTracer) remove(addr gethcmn.Address) bool {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	if _, ok := t.addrs[addr]; !ok {
+		return false
+	}
+	delete(t.addrs, addr)
+	return true
+}
+
+func (t *addressTracer) addresses() []gethcmn.Address {
+	t.mtx.RLock()
+	defer t.mtx.RUnlock()
+	addrs := make([]gethcmn.Address, 0, len(t.addrs))
+	for addr := range t.addrs {
+		addrs = append(addrs, addr)
+	}
+	return addrs
+}
+
+func (t *addressTracer) isActive() bool {
+	t.mtx.RLock()
+	defer t.mtx.RUnlock()
+	return len(t.addrs) != 0
+}
+
+// collect checks the transactions in a committed block
+func (t *addressTracer) collect(blk *modbtypes.Block) {
+	if !t.isActive() {
+		return
+	}
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	for _, mdbTx := range blk.TxList {
+		tx := &types.Transaction{}
+		if _, err := tx.UnmarshalMsg(mdbTx.Content); err != nil {
+			continue
+		}
+		touched := t.touchedAddresses(tx)
+		if len(touched) == 0 {
+			continue
+		}
+		tracedTx := &TracedTx{Height: blk.Height, Tx: tx, Touched: touched}
+		if len(t.txs) < MaxTracedTxCount {
+			t.txs = append(t.txs, tracedTx)
+		} else {
+			t.txs[t.next] = tracedTx
+			t.next = (t.next + 1) % MaxTracedTxCount
+		}
+	}
+}
+
+func (t *addressTracer) touchedAddresses(tx *types.Transaction) []gethcmn.Address {
+	var touched []gethcmn.Address
+	seen := make(map[gethcmn.Address]struct{})
+	check := func(addr gethcmn.Address) {
+		if _, ok := t.addrs[addr]; !ok {
+			return
+		}
+		if _, ok := seen[addr]; !ok {
+			seen[addr] = struct{}{}
+			touched = append(touched, addr)
+		}
+	}
+	check(tx.From)
+	check(tx.To)
+	check(tx.ContractAddress)
+	for _, call := range tx.InternalTxCalls {
+		check(call.Sender)
+		check(call.Destination)
+	}
+	for _, log := range tx.Logs {
+		check(log.Address)
+	}
+	if tx.RwLists != nil {
+		for _, op := range tx.RwLists.AccountWList {
+			check(op.Addr)
+		}
+	}
+	return touched
+}
+
+// query returns the traced transactions touching addr in [startHeight, endHeight], in the order of height
+func (t *addressTracer) query(addr gethcmn.Address, startHeight, endHeight int64) []*TracedTx {
+	t.mtx.RLock()
+	defer t.mtx.RUnlock()
+	var result []*TracedTx
+	for i := 0; i < len(t.txs); i++ {
+		tracedTx := t.txs[(t.next+i)%len(t.txs)]
+		if tracedTx.Height < startHeight || tracedTx.Height > endHeight {
+			continue
+		}
+		for _, touched := range tracedTx.Touched {
+			if touched == addr {
+				result = append(result, tracedTx)
+				break
+			}
+		}
+	}
+	return result
+}
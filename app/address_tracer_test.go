@@ -0,0 +1,56 @@
+package app
+
+import (
+	"testing"
+
+	gethcmn "github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+
+	modbtypes "github.com/smartbch/moeingdb/types"
+	"github.com/smartbch/moeingevm/types"
+)
+
+func newBlockForTracer(height int64, txs ...*types.Transaction) *modbtypes.Block {
+	blk := &modbtypes.Block{Height: height}
+	for _, tx := range txs {
+		bz, _ := tx.MarshalMsg(nil)
+		blk.TxList = append(blk.TxList, modbtypes.Tx{HashId: tx.Hash, Content: bz})
+	}
+	return blk
+}
+
+func TestAddressTracer(t *testing.T) {
+	alice := gethcmn.Address{0x01}
+	bob := gethcmn.Address{0x02}
+	contract := gethcmn.Address{0x03}
+
+	tracer := newAddressTracer()
+	tx1 := &types.Transaction{Hash: [32]byte{0x11}, From: alice, To: bob}
+	tracer.collect(newBlockForTracer(1, tx1))
+	require.Len(t, tracer.query(alice, 0, 100), 0) // nothing registered
+
+	require.True(t, tracer.add(contract))
+	require.False(t, tracer.add(contract))
+	tx2 := &types.Transaction{Hash: [32]byte{0x22}, From: alice, To: bob,
+		InternalTxCalls: []types.InternalTxCall{{Sender: bob, Destination: contract}}}
+	tx3 := &types.Transaction{Hash: [32]byte{0x33}, From: bob, To: alice}
+	tx4 := &types.Transaction{Hash: [32]byte{0x44}, From: alice, To: bob,
+		Logs: []types.Log{{Address: contract}}}
+	tracer.collect(newBlockForTracer(2, tx2, tx3))
+	tracer.collect(newBlockForTracer(3, tx4))
+
+	traces := tracer.query(contract, 0, 100)
+	require.Len(t, traces, 2)
+	require.Equal(t, int64(2), traces[0].Height)
+	require.Equal(t, tx2.Hash, traces[0].Tx.Hash)
+	require.Equal(t, []gethcmn.Address{contract}, traces[0].Touched)
+	require.Equal(t, int64(3), traces[1].Height)
+	require.Len(t, tracer.query(contract, 3, 3), 1)
+	require.Len(t, tracer.query(alice, 0, 100), 0)
+
+	require.True(t, tracer.remove(contract))
+	require.False(t, tracer.remove(contract))
+	require.Len(t, tracer.addresses(), 0)
+	tracer.collect(newBlockForTracer(4, tx2))
+	require.Len(t, tracer.query(contract, 0, 100), 2)
+}
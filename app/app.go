@@ -11,6 +11,7 @@ import (
 	"os"
 	"path"
 	"strconv"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -19,10 +20,10 @@ import (
 	gethcore "github.com/ethereum/go-ethereum/core"
 	gethtypes "github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/event"
-	"github.com/ethereum/go-ethereum/rlp"
 
 	"github.com/holiman/uint256"
 	abcitypes "github.com/tendermint/tendermint/abci/types"
+	tmcrypto "github.com/tendermint/tendermint/crypto"
 	"github.com/tendermint/tendermint/crypto/ed25519"
 	cryptoenc "github.com/tendermint/tendermint/crypto/encoding"
 	"github.com/tendermint/tendermint/libs/log"
@@ -30,6 +31,7 @@ import (
 	"github.com/smartbch/moeingads"
 	"github.com/smartbch/moeingads/store"
 	"github.com/smartbch/moeingads/store/rabbit"
+	storetypes "github.com/smartbch/moeingads/store/types"
 	"github.com/smartbch/moeingdb/modb"
 	"github.com/smartbch/moeingdb/syncdb"
 	modbtypes "github.com/smartbch/moeingdb/types"
@@ -38,11 +40,20 @@ import (
 
 	"github.com/smartbch/smartbch/crosschain"
 	cctypes "github.com/smartbch/smartbch/crosschain/types"
+	"github.com/smartbch/smartbch/freeze"
+	"github.com/smartbch/smartbch/internal/audit"
+	"github.com/smartbch/smartbch/internal/coldstore"
+	"github.com/smartbch/smartbch/internal/diag"
 	"github.com/smartbch/smartbch/internal/ethutils"
+	"github.com/smartbch/smartbch/internal/exporter"
+	"github.com/smartbch/smartbch/internal/multisig"
 	"github.com/smartbch/smartbch/param"
 	"github.com/smartbch/smartbch/staking"
 	stakingtypes "github.com/smartbch/smartbch/staking/types"
+	"github.com/smartbch/smartbch/txcodec"
+	"github.com/smartbch/smartbch/txhook"
 	"github.com/smartbch/smartbch/watcher"
+	watchertypes "github.com/smartbch/smartbch/watcher/types"
 )
 
 var (
@@ -61,11 +72,17 @@ const (
 	HasPendingTx         uint32 = 108
 	MempoolBusy          uint32 = 109
 	GasLimitTooSmall     uint32 = 110
+	SenderFrozen         uint32 = 111
+	TxCanceled           uint32 = 112
+	TxVetoed             uint32 = 113
 )
 
 var (
 	errNoSyncDB    = errors.New("syncdb is not open")
 	errNoSyncBlock = errors.New("syncdb block is not ready")
+	errNoColdStore = errors.New("cold store is not enabled")
+	errNoAdminOps  = errors.New("admin operations signed by the operators are not enabled")
+	errNotPruned   = errors.New("the block is not pruned from moeingdb")
 )
 
 const (
@@ -80,21 +97,63 @@ type IApp interface {
 	GetHistoryOnlyContext() *types.Context
 	RunTxForRpc(gethTx *gethtypes.Transaction, sender gethcmn.Address, estimateGas bool, height int64) (*ebp.TxRunner, int64)
 	RunTxForSbchRpc(gethTx *gethtypes.Transaction, sender gethcmn.Address, height int64) (*ebp.TxRunner, int64)
-	GetCurrEpoch() *stakingtypes.Epoch
-	GetWatcherEpochList() []*stakingtypes.Epoch
+	GetCurrEpoch() (*stakingtypes.Epoch, error)
+	GetWatcherEpochList() ([]*stakingtypes.Epoch, error)
 	GetAppEpochList() []*stakingtypes.Epoch
 	GetLatestBlockNum() int64
 	SubscribeChainEvent(ch chan<- types.ChainEvent) event.Subscription
 	SubscribeLogsEvent(ch chan<- []*gethtypes.Log) event.Subscription
+	SubscribeValidatorPowerEvent(ch chan<- []*ValidatorPowerChangeEvent) event.Subscription
+	SubscribeStakingEvent(ch chan<- *StakingEvent) event.Subscription
+	AddTracedAddress(addr gethcmn.Address) bool
+	RemoveTracedAddress(addr gethcmn.Address) bool
+	GetTracedAddresses() []gethcmn.Address
+	GetAddressTraces(addr gethcmn.Address, startHeight, endHeight int64) []*TracedTx
+	GetFrozenAddresses() []*freeze.FrozenAddress
+	GetCreate2Contract(addr gethcmn.Address) *Create2Contract
+	GetCreate2ContractsByDeployer(deployer gethcmn.Address) []*Create2Contract
+	GetTxFeeRecord(txHash gethcmn.Hash) *TxFeeRecord
+	GetBlockFeeRecords(startHeight, endHeight int64) []*BlockFeeRecord
+	GetGasGrants(height int64) []*GasGrant
+	GetBlockWitness(height int64) *BlockWitness
+	GetIdleStateReport(idleBlocks int64, top int) *StateAccessReport
+	SimulateStateRent(policies []StateRentPolicy) []*StateRentResult
+	GetParamChanges() []*ParamChange
+	GetProposerInfo(consAddr gethcmn.Address) *ProposerInfo
+	GetProposerInfos() []*ProposerInfo
+	CancelTx(txid gethcmn.Hash)
 	LoadBlockInfo() *types.BlockInfo
 	GetValidatorsInfo() ValidatorsInfo
 	IsArchiveMode() bool
 	GetBlockForSync(height int64) (blk []byte, err error)
+	GetColdBlock(height int64) (*coldstore.Block, error)
 	GetRpcMaxLogResults() int
+	GetModbIndexes() param.ModbIndexes
+	RecordAudit(action, source string, details map[string]string)
+	AdminOpsRequireSigs() bool
+	VerifyAdminOp(payload []byte, sigs [][]byte) (*multisig.Op, []gethcmn.Address, error)
+	GetAdminOpNonce() (uint64, error)
 	GetRedeemingUtxoIds() [][36]byte
 	GetLostAndFoundUtxoIds() [][36]byte
 	GetRedeemableUtxoIdsByCovenantAddr(addr [20]byte) [][36]byte
 	GetWatcherHeight() int64
+	GetNominationStatus(pubkey [32]byte) (*staking.NominationStatus, error)
+	GetTimeInfo() TimeInfo
+	GetCcCollectStatus() watchertypes.CcCollectStatus
+	GetWatcherStatus() watcher.Status
+	GetCcTiming() watcher.CcTiming
+	ForceCcRescan(begin, end int64) error
+	SetCcCollectPaused(paused bool) bool
+	GetPendingCcDeposits() ([]watchertypes.PendingCcDeposit, bool)
+	GetWatcherMonitorVoteInfos() ([]*cctypes.MonitorVoteInfo, *cctypes.MonitorVoteInfo, error)
+	GetWatcherAnalytics(limit int) watcher.AnalyticsReport
+	GetArchivedEpochs(start, end int64) ([]*watcher.ArchivedEpoch, bool)
+	GetArchivedEpochByHeight(height int64) (*watcher.ArchivedEpoch, bool)
+	GetSignedVoteInfos(startHeight int64) (*watchertypes.SignedVoteInfos, error)
+	IsDevMode() bool
+	IncreaseTime(seconds int64) (int64, error)
+	SetNextBlockTimestamp(timestamp int64) error
+	GetPeerScores() []*PeerScore
 }
 
 type App struct {
@@ -109,10 +168,28 @@ type App struct {
 	root         *store.RootStore
 	historyStore modbtypes.DB
 	syncDB       *syncdb.SyncDB
+	coldTier     *coldstore.Tier
+	exporter     *exporter.Exporter
 
 	currHeight int64
 	trunk      *store.TrunkStore
 	checkTrunk *store.TrunkStore
+
+	// the height set to root, the trunk is written back under it in the next Commit
+	rootHeight int64
+	// the last root height whose version of moeingads is no longer written, used by the RPC
+	// snapshot reads in archive mode
+	snapshotHeight int64
+	// the results of the calls at the recent immutable heights, nil if disabled
+	callResultCache *callResultCache
+	// adjusts the parallel number of txEngine, nil if disabled
+	parallelTuner *parallelTuner
+	determinism   *determinismChecker
+
+	// the time in the Tendermint header of the current block, with nanoseconds
+	headerTime          time.Time
+	committedHeaderTime atomic.Value // to store time.Time, copied from headerTime in Commit
+
 	// 'block' contains some meta information of a block. It is collected during BeginBlock&DeliverTx,
 	// and save to world state in Commit.
 	block *types.Block
@@ -128,13 +205,39 @@ type App struct {
 	lastGasFee      uint256.Int // updated in last block's postCommit, used in current block's refresh
 	lastMinGasPrice uint64      // updated in refresh, used in next block's CheckTx and Commit. It needs
 	// to be reloaded in NewApp
-	txid2sigMap map[[32]byte][65]byte //updated in DeliverTx, flushed in refresh
+	txid2sigMap  map[[32]byte][65]byte    //updated in DeliverTx, flushed in refresh
+	deliveredTxs []*gethtypes.Transaction // updated in DeliverTx, handed to txEngine in Commit
+	// the failed receipts of the txs of frozen senders, made in Commit and written with the txs
+	// executed in postCommit by the next Commit, when they move to lastFrozenTxs
+	frozenTxs     []*types.Transaction
+	lastFrozenTxs []*types.Transaction
 
 	// feeds
-	chainFeed event.Feed // For pub&sub new blocks
-	logsFeed  event.Feed // For pub&sub new logs
+	chainFeed event.Feed    // For pub&sub new blocks
+	logsFeed  event.Feed    // For pub&sub new logs
+	powerFeed *droppingFeed // For pub&sub validators' voting power changes, never blocks Commit
+	stakeFeed *droppingFeed // For pub&sub epoch switches and validator set changes, never blocks Commit
 	scope     event.SubscriptionScope
 
+	webhookNotifier *WebhookNotifier
+	addressTracer   *addressTracer
+	create2Index    *create2Index
+	feeAccounting   *feeAccounting
+	gasGrants       *gasGrants
+	feeDistribution *staking.FeeDistribution // made in the current Commit, for the txs of the previous block
+	devClock        *devClock                // nil if not on the dev chain
+	peerScorer      *peerScorer              // nil if peer-ban-invalid-txs is zero
+	witnesses       *witnessRecorder
+	accessRecorder  *accessRecorder // nil if no feature needs the state accessed by the blocks
+	blockAccesses   *stateAccesses  // recorded in postCommit, used in the next Commit
+	stateAccess     *stateAccessTracker
+	canceledTxs     *canceledTxs
+	paramHistory    *paramHistory
+	txHooks         []txhook.TxHook
+	txResultEvents  []abcitypes.Event // the events of the txs executed in the last Commit, emitted by BeginBlock
+	auditLog        *audit.Log
+	adminGuard      *multisig.Guard // nil if the privileged RPC does not require signatures
+
 	//engine
 	txEngine    ebp.TxExecutor
 	reorderSeed int64        // recorded in BeginBlock, used in Commit
@@ -144,10 +247,12 @@ type App struct {
 	watcher             *watcher.Watcher
 	epochList           []*stakingtypes.Epoch      // caches the epochs collected by the watcher
 	monitorVoteInfoList []*cctypes.MonitorVoteInfo // caches the monitor vote infos collected by the watcher
+	epochGossipKey      tmcrypto.PrivKey           // signs the epochs served to the other validators, nil if not served
 
 	//util
-	signer gethtypes.Signer
-	logger log.Logger
+	signer    gethtypes.Signer
+	txDecoder *txcodec.Decoder
+	logger    log.Logger
 
 	//for amber
 	currValidators []*stakingtypes.Validator
@@ -174,28 +279,94 @@ func NewApp(config *param.ChainConfig, chainId *uint256.Int, genesisWatcherHeigh
 	/*------set config------*/
 	app.config = config
 	app.chainId = chainId
+	// the BCH network is selected by "start" from genesis.json
+	if err := param.CheckBchNetwork(param.CurrBchNetwork().Name, chainId.Uint64()); err != nil {
+		panic(err)
+	}
 	/*------signature cache------*/
 	app.sigCache = make(map[gethcmn.Hash]SenderAndHeight, config.AppConfig.SigCacheSize)
 	/*------set util------*/
 	app.signer = gethtypes.NewEIP155Signer(app.chainId.ToBig())
+	app.addressTracer = newAddressTracer()
+	app.create2Index = newCreate2Index()
+	app.feeAccounting = newFeeAccounting()
+	app.gasGrants = newGasGrants()
+	if config.AppConfig.PeerBanInvalidTxs > 0 {
+		app.peerScorer = newPeerScorer(config.AppConfig.PeerBanInvalidTxs)
+	}
+	app.witnesses = newWitnessRecorder(config.AppConfig.WitnessKeptBlocks)
+	app.powerFeed = newDroppingFeed("validator_power")
+	app.stakeFeed = newDroppingFeed("staking")
+	stateAccess, err := newStateAccessTracker(config.AppConfig.StateAccessTrackingPath)
+	if err != nil {
+		panic(err)
+	}
+	app.stateAccess = stateAccess
+	if app.witnesses.isActive() || app.stateAccess.isActive() {
+		app.accessRecorder = newAccessRecorder()
+	}
+	app.canceledTxs = newCanceledTxs()
+	app.paramHistory = newParamHistory()
+	app.txHooks = txhook.Hooks()
+	app.txDecoder = txcodec.NewDecoder(txcodec.DefaultConfig(app.chainId.ToBig()))
 	app.logger = logger.With("module", "app")
+	for _, h := range app.txHooks {
+		app.logger.Info("tx hook registered", "name", h.Name())
+	}
 	/*------set store------*/
 	app.root, app.mads = CreateRootStore(config.AppConfig.AppDataPath, config.AppConfig.ArchiveMode)
 	app.historyStore = CreateHistoryStore(config.AppConfig.ModbDataPath, config.AppConfig.UseLiteDB, config.AppConfig.RpcEthGetLogsMaxResults,
 		app.logger.With("module", "modb"))
+	if indexes := config.AppConfig.ModbIndexes(); !indexes.IsFull() {
+		entries, fullEntries := estimateIndexEntries(indexes)
+		app.logger.Info("some moeingdb indexes are disabled", "txFrom", indexes.TxFrom, "txTo", indexes.TxTo,
+			"logTopics", indexes.LogTopics, "indexEntriesPerTypicalTx", entries, "withAllIndexes", fullEntries)
+	}
+	if config.AppConfig.AuditLogPath != "" {
+		auditLog, err := audit.Open(config.AppConfig.AuditLogPath)
+		if err != nil {
+			panic(err)
+		}
+		app.auditLog = auditLog
+	}
+	if config.AppConfig.AdminSigners != "" {
+		app.adminGuard = newAdminGuard(config.AppConfig, chainId.Uint64())
+	}
 	if config.AppConfig.WithSyncDB {
 		app.syncDB = syncdb.NewSyncDB(config.AppConfig.SyncdbDataPath)
 	}
+	if config.AppConfig.ColdStoreUrl != "" {
+		coldStore, err := coldstore.Open(config.AppConfig.ColdStoreUrl)
+		if err != nil {
+			panic(err)
+		}
+		app.coldTier = coldstore.NewTier(coldStore, config.AppConfig.ColdStoreCacheBlocks, app.logger.With("module", "coldstore"))
+	}
+	if config.AppConfig.ExporterUrl != "" {
+		sink, prefix, err := exporter.Open(config.AppConfig.ExporterUrl)
+		if err != nil {
+			panic(err)
+		}
+		offsetPath := path.Join(config.AppConfig.ModbDataPath, "exporter.offset")
+		app.exporter, err = exporter.New(sink, prefix, app.readExportedBlock, offsetPath, app.logger.With("module", "exporter"))
+		if err != nil {
+			panic(err)
+		}
+	}
+	if config.AppConfig.ArchiveMode && config.AppConfig.CallResultCacheBlocks > 0 {
+		app.callResultCache = newCallResultCache(config.AppConfig.CallResultCacheBlocks, config.AppConfig.CallResultCacheSize)
+	}
 	app.trunk = app.root.GetTrunkStore(config.AppConfig.TrunkCacheSize).(*store.TrunkStore)
 	app.checkTrunk = app.root.GetReadOnlyTrunkStore(config.AppConfig.TrunkCacheSize).(*store.TrunkStore)
 	/*------set engine------*/
-	app.txEngine = ebp.NewEbpTxExec(
-		param.EbpExeRoundCount,
-		param.EbpRunnerNumber,
-		param.EbpParallelNum,
-		5000, /*not consensus relevant*/
-		app.signer,
-		app.logger.With("module", "engine"))
+	parallelNum := param.EbpParallelNum
+	if config.AppConfig.AdaptiveParallelism {
+		app.parallelTuner = newParallelTuner()
+		parallelNum = app.parallelTuner.current
+	}
+	app.txEngine = app.newTxEngine(parallelNum)
+	app.determinism = newDeterminismChecker(config.AppConfig.DeterminismCheckRatio, config.AppConfig.DeterminismReportPath,
+		config.AppConfig.DeterminismCheckHalt, app.logger.With("module", "determinism"))
 	//ebp.AdjustGasUsed = false
 
 	// must refresh ctx.Height when app.currHeight set later
@@ -220,8 +391,9 @@ func NewApp(config *param.ChainConfig, chainId *uint256.Int, genesisWatcherHeigh
 
 	ctx.SetCurrentHeight(app.currHeight)
 	app.root.SetHeight(app.currHeight)
+	app.rootHeight = app.currHeight
 	ctx.SetCurrentHeight(app.currHeight)
-	app.txEngine.SetContext(app.GetRunTxContext())
+	app.txEngine.SetContext(app.engineContext())
 	/*------set stakingInfo------*/
 	stakingInfo := staking.LoadStakingInfo(ctx)
 	currValidators := staking.GetActiveValidators(ctx, stakingInfo.Validators)
@@ -243,20 +415,48 @@ func NewApp(config *param.ChainConfig, chainId *uint256.Int, genesisWatcherHeigh
 	if ctx.IsShaGateFork() {
 		ebp.RegisterPredefinedContract(ctx, crosschain.CCContractAddress, ccExecutor)
 	}
+	app.registerFreezeContract(ctx)
 	/*------set watcher------*/
-	lastEpochEndHeight := stakingInfo.GenesisMainnetBlockHeight + param.StakingNumBlocksInEpoch*stakingInfo.CurrEpochNum
+	numBlocksInEpoch := param.CurrBchNetwork().NumBlocksInEpoch
+	lastEpochEndHeight := stakingInfo.GenesisMainnetBlockHeight + numBlocksInEpoch*stakingInfo.CurrEpochNum
+	if err := checkEpochConsistency(ctx, stakingInfo, lastEpochEndHeight, numBlocksInEpoch); err != nil {
+		if !skipSanityCheck {
+			panic("Epoch consistency check failed: " + err.Error())
+		}
+		app.logger.Error("Epoch consistency check failed", "err", err.Error())
+	}
+	blockFinalizeNumber := config.AppConfig.GetBlockFinalizeNumber(chainId.Uint64())
+	if err := param.CheckBlockFinalizeNumber(blockFinalizeNumber, chainId.Uint64()); err != nil {
+		panic(err)
+	}
 	app.watcher = watcher.NewWatcher(app.logger.With("module", "watcher"), app.historyStore, lastEpochEndHeight, stakingInfo.CurrEpochNum, app.config)
-	app.logger.Debug(fmt.Sprintf("New watcher: mainnet url(%s), epochNum(%d), lastEpochEndHeight:(%d), speedUp(%v)\n",
-		config.AppConfig.MainnetRPCUrl, stakingInfo.CurrEpochNum, lastEpochEndHeight, config.AppConfig.Speedup))
+	app.watcher.SetBlockFinalizeNumber(blockFinalizeNumber)
+	app.logger.Debug(fmt.Sprintf("New watcher: mainnet urls(%d), epochNum(%d), lastEpochEndHeight:(%d), speedUp(%v), blockFinalizeNumber(%d)\n",
+		len(config.AppConfig.MainnetRPCEndpoints()), stakingInfo.CurrEpochNum, lastEpochEndHeight, config.AppConfig.Speedup, blockFinalizeNumber))
 	app.watcher.SetCCExecutor(ccExecutor)
 	app.watcher.CheckSanity(skipSanityCheck)
 	app.watcher.SetContextGetter(app)
-	go app.watcher.Run()
-	if ctx.IsShaGateFork() {
-		crosschain.WaitUTXOCollectDone(ctx, app.watcher.CcContractExecutor.UTXOInitCollectDoneChan)
+	diag.RegisterChannel("watcher.EpochChan", func() (int, int) { return len(app.watcher.EpochChan), cap(app.watcher.EpochChan) })
+	diag.RegisterChannel("watcher.MonitorVoteChan", func() (int, int) {
+		return len(app.watcher.MonitorVoteChan), cap(app.watcher.MonitorVoteChan)
+	})
+	diag.Start(app.logger.With("module", "diag"))
+	if config.AppConfig.SafeMode {
+		// no block is committed in safe mode, which needs not the epochs or the cc infos
+		app.logger.Info("safe mode, the watcher is not started")
+	} else {
+		go app.watcher.Run()
+		if ctx.IsShaGateFork() {
+			crosschain.WaitUTXOCollectDone(ctx, app.watcher.GetCCExecutor().UTXOInitCollectDoneChan)
+		}
+		app.watcher.WaitCatchup()
 	}
-	app.watcher.WaitCatchup()
 	app.lastMinGasPrice = staking.LoadMinGasPrice(ctx, true)
+	app.paramHistory.record(app.currHeight, ParamMinGasPrice, strconv.FormatUint(app.lastMinGasPrice, 10))
+	if config.AppConfig.ValidatorWebhookUrl != "" {
+		app.webhookNotifier = NewWebhookNotifier(config.AppConfig.ValidatorWebhookUrl, app.logger.With("module", "webhook"))
+		app.webhookNotifier.Start(app)
+	}
 	if app.currHeight != 0 { // restart postCommit
 		app.mtx.Lock()
 		app.postCommit(app.syncBlockInfo())
@@ -265,6 +465,17 @@ func NewApp(config *param.ChainConfig, chainId *uint256.Int, genesisWatcherHeigh
 	return app
 }
 
+// registerFreezeContract registers the freeze contract when the block after currHeight reaches the fork
+func (app *App) registerFreezeContract(ctx *types.Context) {
+	if app.currHeight+1 < param.FreezeForkHeight {
+		return
+	}
+	if ebp.PredefinedContractManager[freeze.FreezeContractAddress] == nil {
+		ebp.RegisterPredefinedContract(ctx, freeze.FreezeContractAddress,
+			freeze.NewFreezeContractExecutor(app.logger.With("module", "freeze")))
+	}
+}
+
 func CreateRootStore(dataPath string, isArchiveMode bool) (*store.RootStore, *moeingads.MoeingADS) {
 	first := [8]byte{0, 0, 0, 0, 0, 0, 0, 0}
 	last := [8]byte{255, 255, 255, 255, 255, 255, 255, 255}
@@ -309,6 +520,9 @@ func (app *App) SetOption(option abcitypes.RequestSetOption) abcitypes.ResponseS
 }
 
 func (app *App) Query(req abcitypes.RequestQuery) abcitypes.ResponseQuery {
+	if strings.HasPrefix(req.Path, peerFilterIdPath) {
+		return app.queryPeerFilter(req.Path)
+	}
 	return abcitypes.ResponseQuery{Code: abcitypes.CodeTypeOK} // take it as a nop
 }
 
@@ -336,18 +550,20 @@ func (app *App) CheckTx(req abcitypes.RequestCheckTx) abcitypes.ResponseCheckTx
 		// Refuse to accept new TXs on P2P to drain the remain TXs in mempool
 		return abcitypes.ResponseCheckTx{Code: MempoolBusy, Info: "mempool is too busy"}
 	}
-	tx := &gethtypes.Transaction{}
-	err := tx.DecodeRLP(rlp.NewStream(bytes.NewReader(req.Tx), 0))
+	tx, err := app.txDecoder.Decode(req.Tx)
 	if err != nil {
-		return abcitypes.ResponseCheckTx{Code: CannotDecodeTx}
+		return abcitypes.ResponseCheckTx{Code: CannotDecodeTx, Info: "cannot decode tx: " + err.Error()}
 	}
 	txid := tx.Hash()
+	if app.canceledTxs.has(txid) {
+		return abcitypes.ResponseCheckTx{Code: TxCanceled, Info: "tx is canceled on this node"}
+	}
 	var sender gethcmn.Address
 	senderAndHeight, ok := app.sigCache[txid]
 	if ok { // cache hit
 		sender = senderAndHeight.Sender
 	} else { // cache miss
-		sender, err = app.signer.Sender(tx)
+		sender, err = app.txDecoder.Verify(tx)
 		if err != nil {
 			return abcitypes.ResponseCheckTx{Code: CannotRecoverSender, Info: "invalid sender: " + err.Error()}
 		}
@@ -356,6 +572,9 @@ func (app *App) CheckTx(req abcitypes.RequestCheckTx) abcitypes.ResponseCheckTx
 	if sender == ebp.BlockedAddress {
 		return abcitypes.ResponseCheckTx{Code: CannotRecoverSender, Info: "invalid sender: " + sender.String()}
 	}
+	if name, err := txhook.Check(app.txHooks, tx, sender); err != nil {
+		return abcitypes.ResponseCheckTx{Code: TxVetoed, Info: "vetoed by " + name + ": " + err.Error()}
+	}
 	return app.checkTxWithContext(tx, sender, req.Type)
 }
 
@@ -369,6 +588,10 @@ func (app *App) checkTxWithContext(tx *gethtypes.Transaction, sender gethcmn.Add
 	if acc == nil {
 		return abcitypes.ResponseCheckTx{Code: SenderNotFound, Info: types.ErrAccountNotExist.Error()}
 	}
+	// only a mempool filter, the frozen senders are rejected in Commit by collectDeliveredTxs
+	if app.currHeight+1 >= param.FreezeForkHeight && freeze.IsFrozen(ctx, sender) {
+		return abcitypes.ResponseCheckTx{Code: SenderFrozen, Info: "sender is frozen by governance: " + sender.String()}
+	}
 	targetNonce, exist := app.frontier.GetLatestNonce(sender)
 	if !exist {
 		app.frontier.SetLatestBalance(sender, acc.Balance().Clone())
@@ -444,7 +667,14 @@ func (app *App) InitChain(req abcitypes.RequestInitChain) abcitypes.ResponseInit
 	if err != nil {
 		panic(err)
 	}
+	if err = genesisData.CheckBchNetwork(); err != nil {
+		panic(err)
+	}
 	app.createGenesisAccounts(genesisData.Alloc)
+	if err = genesisData.ValidatePredeploys(); err != nil {
+		panic(err)
+	}
+	app.createGenesisPredeploys(genesisData.Predeploys)
 	genesisValidators := genesisData.StakingValidators()
 	if len(genesisValidators) == 0 {
 		panic("no genesis validator in genesis.json")
@@ -494,14 +724,45 @@ func (app *App) createGenesisAccounts(alloc gethcore.GenesisAlloc) {
 	rbt.WriteBack()
 }
 
+func (app *App) createGenesisPredeploys(predeploys []*Predeploy) {
+	if len(predeploys) == 0 {
+		return
+	}
+	ctx := app.GetRunTxContext()
+	for i, p := range predeploys {
+		seq := predeploySequence(i)
+		acc := types.ZeroAccountInfo()
+		acc.UpdateSequence(seq)
+		if p.Balance != nil {
+			amt, _ := uint256.FromBig(p.Balance.ToInt())
+			acc.UpdateBalance(amt)
+		}
+		ctx.SetAccount(p.Address, acc)
+		ctx.Rbt.Set(types.GetBytecodeKey(p.Address), types.NewBytecodeInfo(predeployBytecodeInfo(p.Code)).Bytes())
+		for k, v := range p.Storage {
+			if v != (gethcmn.Hash{}) {
+				ctx.SetStorageAt(seq, string(k[:]), v[:])
+			}
+		}
+		app.logger.Info("predeploy", "name", p.Name, "address", p.Address.Hex(), "codeSize", len(p.Code))
+	}
+	ctx.Close(true)
+}
+
 func (app *App) BeginBlock(req abcitypes.RequestBeginBlock) abcitypes.ResponseBeginBlock {
-	for app.block.Timestamp > app.watcher.GetCurrMainnetBlockTimestamp()+12*3600 {
+	for app.block.Timestamp > app.watcher.GetCurrMainnetBlockTimestamp()+MaxBchTimeLag {
 		app.logger.Debug("waiting BCH node catchup...", "smartBCH block timestamp", app.block.Timestamp, "BCH block timestamp", app.watcher.GetCurrMainnetBlockTimestamp())
 		time.Sleep(30 * time.Second)
 	}
+	timestamp := req.Header.Time.Unix()
+	if app.devClock != nil {
+		timestamp = app.devClock.blockTimestamp(timestamp, app.block.Timestamp)
+	}
+	app.checkTimestamp(req.Header.Height, app.block.Timestamp, timestamp)
+	app.headerTime = req.Header.Time
 	app.block = &types.Block{
 		Number:    req.Header.Height,
-		Timestamp: req.Header.Time.Unix(),
+		Timestamp: timestamp,
 		Size:      int64(req.Size()),
 	}
 	copy(app.block.Miner[:], req.Header.ProposerAddress)
@@ -530,19 +791,140 @@ func (app *App) BeginBlock(req abcitypes.RequestBeginBlock) abcitypes.ResponseBe
 			app.slashValidators = append(app.slashValidators, addr)
 		}
 	}
-	return abcitypes.ResponseBeginBlock{}
+	events := app.txResultEvents
+	app.txResultEvents = nil
+	return abcitypes.ResponseBeginBlock{Events: events}
 }
 
 func (app *App) DeliverTx(req abcitypes.RequestDeliverTx) abcitypes.ResponseDeliverTx {
 	app.block.Size += int64(req.Size())
 	tx, err := ethutils.DecodeTx(req.Tx)
 	if err == nil {
-		app.txEngine.CollectTx(tx)
+		app.deliveredTxs = append(app.deliveredTxs, tx)
 		app.txid2sigMap[tx.Hash()] = ethutils.EncodeVRS(tx)
+		app.observeTxs(func(h txhook.TxHook) { h.DeliverTx(app.block.Number, tx) })
+		return abcitypes.ResponseDeliverTx{
+			Code:   abcitypes.CodeTypeOK,
+			Events: deliverTxEvents(tx, app.config.AppConfig.TxEventVerbosity),
+		}
 	}
 	return abcitypes.ResponseDeliverTx{Code: abcitypes.CodeTypeOK}
 }
 
+// collectDeliveredTxs hands the transactions of the block to txEngine, except the ones whose senders
+// are frozen, which get failed receipts. It runs in Commit after the last block has been executed by
+// postCommit, such that all the nodes read the same freeze state, which DeliverTx cannot.
+func (app *App) collectDeliveredTxs() {
+	ctx := app.GetRunTxContext()
+	defer ctx.Close(true) // the charges of the frozen txs must be written back for txEngine's Prepare
+	for _, tx := range app.deliveredTxs {
+		if app.senderIsFrozen(ctx, tx) {
+			if gasUsed, ok := chargeFrozenTx(ctx, app.signer, tx); ok {
+				app.recordFrozenTx(tx, gasUsed)
+			}
+			continue
+		}
+		app.txEngine.CollectTx(tx)
+	}
+	app.deliveredTxs = nil
+}
+
+// chargeFrozenTx consumes the nonce of a tx which is not executed because its sender is frozen, and
+// charges its intrinsic gas as the gas fee, which goes to the system account as the fees prepaid in
+// txEngine's Prepare. So the tx cannot be included again, or executed after the sender is unfrozen,
+// and the block space it takes is paid. Like txEngine, it drops the tx if the nonce is not the next
+// one of the sender or the fee cannot be paid, then no receipt is made.
+func chargeFrozenTx(ctx *types.Context, signer gethtypes.Signer, tx *gethtypes.Transaction) (gasUsed uint64, ok bool) {
+	sender, err := gethtypes.Sender(signer, tx)
+	if err != nil {
+		return 0, false
+	}
+	acc := ctx.GetAccount(sender)
+	if acc == nil || acc.Nonce() != tx.Nonce() {
+		return 0, false
+	}
+	gasUsed, err = gethcore.IntrinsicGas(tx.Data(), nil, tx.To() == nil, true, true)
+	if err != nil || tx.Gas() < gasUsed {
+		return 0, false
+	}
+	gasPrice, overflow := uint256.FromBig(tx.GasPrice())
+	if overflow || gasPrice.GtUint64(ebp.MaxGasPrice) {
+		gasPrice = uint256.NewInt(ebp.MaxGasPrice)
+	}
+	gasFee := uint256.NewInt(0).Mul(gasPrice, uint256.NewInt(gasUsed))
+	balance := acc.Balance()
+	if balance.Lt(gasFee) {
+		return 0, false
+	}
+	acc.UpdateBalance(balance.Sub(balance, gasFee))
+	acc.UpdateNonce(tx.Nonce() + 1)
+	ctx.SetAccount(sender, acc)
+	if err = ebp.AddSystemAccBalance(ctx, gasFee); err != nil {
+		panic(err)
+	}
+	return gasUsed, true
+}
+
+// recordFrozenTx makes the failed receipt of a tx which is not executed because its sender is frozen,
+// after chargeFrozenTx charged gasUsed
+func (app *App) recordFrozenTx(tx *gethtypes.Transaction, gasUsed uint64) {
+	sender, _ := gethtypes.Sender(app.signer, tx)
+	var txToRun types.TxToRun
+	txToRun.FromGethTx(tx, sender, uint64(app.currHeight))
+	app.frozenTxs = append(app.frozenTxs, &types.Transaction{
+		Hash:        txToRun.HashID,
+		Nonce:       txToRun.Nonce,
+		BlockNumber: app.currHeight,
+		From:        txToRun.From,
+		To:          txToRun.To,
+		Value:       txToRun.Value,
+		GasPrice:    txToRun.GasPrice,
+		Gas:         txToRun.Gas,
+		GasUsed:     gasUsed,
+		Input:       txToRun.Data,
+		Status:      gethtypes.ReceiptStatusFailed,
+		StatusStr:   freeze.StatusStrSenderFrozen,
+	})
+	app.txid2sigMap[tx.Hash()] = ethutils.EncodeVRS(tx)
+}
+
+// appendFrozenTxs appends the failed receipts of the txs of frozen senders in blkInfo to the txs
+// executed by txEngine
+func appendFrozenTxs(blkInfo *types.Block, blk *modbtypes.Block, executed []*types.Transaction, frozenTxs []*types.Transaction) {
+	var cumulativeGasUsed uint64
+	if len(executed) != 0 {
+		cumulativeGasUsed = executed[len(executed)-1].CumulativeGasUsed
+	}
+	for i, tx := range frozenTxs {
+		cumulativeGasUsed += tx.GasUsed
+		tx.TransactionIndex = int64(len(executed) + i)
+		tx.BlockHash = blkInfo.Hash
+		tx.CumulativeGasUsed = cumulativeGasUsed
+		content, err := tx.MarshalMsg(nil)
+		if err != nil {
+			panic(err)
+		}
+		blkInfo.Transactions = append(blkInfo.Transactions, tx.Hash)
+		blk.TxList = append(blk.TxList, modbtypes.Tx{HashId: tx.Hash, SrcAddr: tx.From, DstAddr: tx.To, Content: content})
+	}
+}
+
+// senderIsFrozen is the execution-time check of the frozen senders, CheckTx only keeps them out of the mempool
+func (app *App) senderIsFrozen(ctx *types.Context, tx *gethtypes.Transaction) bool {
+	if ctx.Height < param.FreezeForkHeight {
+		return false
+	}
+	sender, err := gethtypes.Sender(app.signer, tx)
+	if err != nil { // dropped by txEngine
+		return false
+	}
+	if freeze.IsFrozen(ctx, sender) {
+		app.logger.Info("do not execute the tx of a frozen sender", "tx", tx.Hash().Hex(), "sender", sender.Hex())
+		return true
+	}
+	return false
+}
+
 func (app *App) EndBlock(req abcitypes.RequestEndBlock) abcitypes.ResponseEndBlock {
 	// hardcode for 8000000 staking fork come early bug, never change this.
 	if app.currHeight == customValidatorUpdateBeginHeight {
@@ -583,9 +965,11 @@ func (app *App) EndBlock(req abcitypes.RequestEndBlock) abcitypes.ResponseEndBlo
 }
 
 func (app *App) Commit() abcitypes.ResponseCommit {
-	app.logger.Debug("Enter commit!", "collected txs", app.txEngine.CollectedTxsCount())
+	app.logger.Debug("Enter commit!", "delivered txs", len(app.deliveredTxs))
 	app.mtx.Lock()
+	app.collectDeliveredTxs()
 	app.updateValidatorsAndStakingInfo()
+	app.grantGas()
 	app.frontier = app.txEngine.Prepare(app.reorderSeed, 0, param.MaxTxGasLimit)
 	appHash := app.refresh()
 	go app.postCommit(app.syncBlockInfo())
@@ -639,9 +1023,13 @@ func (app *App) updateValidatorsAndStakingInfo() {
 	defer ctx.Close(true) // context must be written back such that txEngine can read it in 'Prepare'
 	blkBalance := ebp.GetBlackHoleBalance(ctx)
 	fmt.Printf("blackhole balance:%d\n", blkBalance)
-	currValidators, newValidators, currEpochNum := staking.SlashAndReward(ctx, app.slashValidators, app.block.Miner,
+	currValidators, newValidators, currEpochNum, feeDist := staking.SlashAndReward(ctx, app.slashValidators, app.block.Miner,
 		app.lastProposer, app.lastVoters, app.getBlockRewardAndUpdateSysAcc(ctx))
+	app.feeDistribution = feeDist
+	slashedValidators := append([][20]byte{}, app.slashValidators...)
 	app.slashValidators = app.slashValidators[:0]
+	epochSwitched := false
+	var appliedEpoch *stakingtypes.Epoch
 
 	if param.IsAmber && ctx.IsXHedgeFork() {
 		//make fake epoch after xHedgeFork, change amber to pure pos
@@ -676,16 +1064,16 @@ func (app *App) updateValidatorsAndStakingInfo() {
 
 	if len(app.epochList) != 0 {
 		//epoch switch delay time should bigger than 10 mainnet block interval as of block finalization need
-		epochSwitchDelay := param.StakingEpochSwitchDelay
+		epochSwitchDelay := param.CurrBchNetwork().EpochSwitchDelay
 		// this 20 is hardcode to fix the 20220520 bch node not upgrade error. don't modify it ever.
 		if currEpochNum == 20 {
 			// make epoch switch delay in epoch 20th 50% longer.
-			epochSwitchDelay = param.StakingEpochSwitchDelay * 10
+			epochSwitchDelay = param.CurrBchNetwork().EpochSwitchDelay * 10
 		}
 		// this 37 is hardcode to fix the 20230113 StakingForkHeight hit error. don't modify it ever.
 		if currEpochNum == 37 {
 			// make epoch switch delay in epoch 37th 50% longer.
-			epochSwitchDelay = param.StakingEpochSwitchDelay * 10
+			epochSwitchDelay = param.CurrBchNetwork().EpochSwitchDelay * 10
 		}
 		if app.block.Timestamp > app.epochList[0].EndTime+epochSwitchDelay {
 			app.logger.Debug(fmt.Sprintf("Switch epoch at block(%d), eppchNum(%d)",
@@ -698,6 +1086,8 @@ func (app *App) updateValidatorsAndStakingInfo() {
 			}
 			newEpoch := app.epochList[0]
 			newValidators = staking.SwitchEpoch(ctx, newEpoch, posVotes, app.logger)
+			epochSwitched = true
+			appliedEpoch = newEpoch
 			app.epochList = app.epochList[1:] // possible memory leak here, but the length would not be very large
 			if ctx.IsXHedgeFork() {
 				staking.CreateInitVotes(ctx, xHedgeSequence, newValidators)
@@ -715,9 +1105,11 @@ func (app *App) updateValidatorsAndStakingInfo() {
 	}
 
 	// hardcode for sync block meet appHash error on 4435201 in amber.
+	oldValidators := currValidators
 	if param.IsAmber && app.currHeight == 4435201 {
 		app.validatorUpdate = nil
 	} else if param.IsAmber || ctx.IsShaGateFork() {
+		oldValidators = app.currValidators
 		app.validatorUpdate = stakingtypes.GetUpdateValidatorSet(app.currValidators, newValidators)
 	} else {
 		app.validatorUpdate = stakingtypes.GetUpdateValidatorSet(currValidators, newValidators)
@@ -727,8 +1119,17 @@ func (app *App) updateValidatorsAndStakingInfo() {
 			gethcmn.Address(v.Address).String(), ed25519.PubKey(v.Pubkey[:]), v.VotingPower))
 	}
 	newInfo := staking.LoadStakingInfo(ctx)
+	powerEvents := buildPowerChangeEvents(app.currHeight, oldValidators, app.validatorUpdate,
+		newInfo.Validators, slashedValidators, epochSwitched)
+	if len(powerEvents) != 0 {
+		app.powerFeed.Send(powerEvents)
+	}
+	if e := newStakingEvent(app.currHeight, appliedEpoch, newValidators, powerEvents); e != nil {
+		app.stakeFeed.Send(e)
+	}
 	newInfo.ValidatorsUpdate = app.validatorUpdate
 	staking.SaveStakingInfo(ctx, newInfo)
+	app.recordProposers(ctx, newInfo.Validators)
 	//only amber need this
 	app.currValidators = newValidators
 	//log all validators info when validator set update
@@ -742,6 +1143,21 @@ func (app *App) updateValidatorsAndStakingInfo() {
 	}
 }
 
+// grantGas tops up the elected cc operators and monitors from the gas grant pool, the grants are
+// recorded for the block summary because they are not made by any tx
+func (app *App) grantGas() {
+	if app.currHeight < param.GasGrantForkHeight {
+		return
+	}
+	ctx := app.GetRunTxContext()
+	defer ctx.Close(true)
+	granted := crosschain.GrantGas(ctx, app.block.Timestamp)
+	for addr, amount := range granted {
+		app.logger.Debug("gas granted", "address", gethcmn.Address(addr).Hex(), "amount", amount.String())
+	}
+	app.gasGrants.record(app.currHeight, granted)
+}
+
 func (app *App) syncBlockInfo() *types.BlockInfo {
 	bi := &types.BlockInfo{
 		Coinbase:  app.block.Miner,
@@ -751,6 +1167,9 @@ func (app *App) syncBlockInfo() *types.BlockInfo {
 		Hash:      app.block.Hash,
 	}
 	app.blockInfo.Store(bi)
+	if !app.headerTime.IsZero() {
+		app.committedHeaderTime.Store(app.headerTime)
+	}
 	app.logger.Debug(fmt.Sprintf("blockInfo: [height:%d, hash:%s]", bi.Number, gethcmn.Hash(bi.Hash).Hex()))
 	return bi
 }
@@ -769,8 +1188,17 @@ func (app *App) postCommit(bi *types.BlockInfo) {
 			}
 		}
 	}
+	if app.accessRecorder != nil {
+		app.accessRecorder.start()
+	}
 	app.txEngine.Execute(bi)
+	if app.accessRecorder != nil {
+		app.blockAccesses = app.accessRecorder.stop()
+	}
 	app.lastGasUsed, app.lastGasRefund, app.lastGasFee = app.txEngine.GasUsedInfo()
+	if bi != nil && app.determinism.sampled(bi.Number) {
+		app.reExecuteSerially(bi)
+	}
 }
 
 func (app *App) refresh() (appHash []byte) {
@@ -788,22 +1216,35 @@ func (app *App) refresh() (appHash []byte) {
 	mGP := staking.LoadMinGasPrice(ctx, false) // load current block's gas price
 	staking.SaveMinGasPrice(ctx, mGP, true)    // save it as last block's gas price
 	app.lastMinGasPrice = mGP
+	app.paramHistory.record(app.currHeight, ParamMinGasPrice, strconv.FormatUint(mGP, 10))
+	ackCCWindow := func() {}
 	if ctx.IsShaGateFork() {
 		ccExecutor := ebp.PredefinedContractManager[crosschain.CCContractAddress]
 		if ccExecutor == nil {
-			if app.watcher.CcContractExecutor != nil {
-				ebp.RegisterPredefinedContract(ctx, crosschain.CCContractAddress, app.watcher.CcContractExecutor)
+			if executor := app.watcher.GetCCExecutor(); executor != nil {
+				ebp.RegisterPredefinedContract(ctx, crosschain.CCContractAddress, executor)
 			} else {
 				executor := crosschain.NewCcContractExecutor(app.logger.With("module", "crosschain"), crosschain.VoteContract{})
 				app.watcher.SetCCExecutor(executor)
 				ebp.RegisterPredefinedContract(ctx, crosschain.CCContractAddress, executor)
 			}
 		}
+		// the txs of the last block are executed, the window they handled is acknowledged after
+		// their state is written back
+		if executor := app.watcher.GetCCExecutor(); executor != nil {
+			ccContext := crosschain.LoadCCContext(ctx)
+			ackCCWindow = func() { executor.AckHandledWindow(ccContext) }
+		}
 	}
+	app.registerFreezeContract(ctx)
 	ctx.Close(true)
+	if prevBlkInfo != nil {
+		app.collectWitness(prevBlkInfo.Number, app.blockAccesses)
+	}
 	lastCacheSize := app.trunk.CacheSize() // predict the next truck's cache size with the last one
 	updateOfADS := app.trunk.GetCacheContent()
 	app.trunk.Close(true) //write cached KVs back to app.root
+	ackCCWindow()
 	if !app.config.AppConfig.ArchiveMode && prevBlkInfo != nil &&
 		prevBlkInfo.Number%app.config.AppConfig.PruneEveryN == 0 &&
 		prevBlkInfo.Number > app.config.AppConfig.NumKeptBlocks {
@@ -819,38 +1260,92 @@ func (app *App) refresh() (appHash []byte) {
 			Height: prevBlkInfo.Number,
 		}
 		prevBlkInfo.Transactions = app.txEngine.CommittedTxIds()
+		prevBlk4MoDB.TxList = app.txEngine.CommittedTxsForMoDB()
+		appendFrozenTxs(prevBlkInfo, &prevBlk4MoDB, app.txEngine.CommittedTxs(), app.lastFrozenTxs)
 		blkInfo, err := prevBlkInfo.MarshalMsg(nil)
 		if err != nil {
 			panic(err)
 		}
 		copy(prevBlk4MoDB.BlockHash[:], prevBlkInfo.Hash[:])
 		prevBlk4MoDB.BlockInfo = blkInfo
-		prevBlk4MoDB.TxList = app.txEngine.CommittedTxsForMoDB()
 		//if ctx.IsShaGateFork() {
 		app.historyStore.SetOpListsForCcUtxo(crosschain.CollectOpList(&prevBlk4MoDB))
 		//}
+		blk4Index := withoutUnindexed(&prevBlk4MoDB, app.config.AppConfig.ModbIndexes())
 		if app.config.AppConfig.NumKeptBlocksInMoDB > 0 && app.currHeight > app.config.AppConfig.NumKeptBlocksInMoDB {
-			app.historyStore.AddBlock(&prevBlk4MoDB, app.currHeight-app.config.AppConfig.NumKeptBlocksInMoDB, app.txid2sigMap)
+			app.historyStore.AddBlock(blk4Index, app.currHeight-app.config.AppConfig.NumKeptBlocksInMoDB, app.txid2sigMap)
 		} else {
-			app.historyStore.AddBlock(&prevBlk4MoDB, -1, app.txid2sigMap) // do not prune moeingdb
+			app.historyStore.AddBlock(blk4Index, -1, app.txid2sigMap) // do not prune moeingdb
+		}
+		if app.parallelTuner != nil {
+			app.parallelTuner.observe(blockTxs(&prevBlk4MoDB))
 		}
 		if app.syncDB != nil {
 			app.syncDB.AddBlock(prevBlk4MoDB.Height, &prevBlk4MoDB, app.txid2sigMap, updateOfADS)
 		}
+		if app.coldTier != nil {
+			app.coldTier.Archive(&prevBlk4MoDB, app.txid2sigMap)
+		}
+		if app.exporter != nil {
+			app.exporter.Notify(prevBlk4MoDB.Height)
+		}
 		app.txid2sigMap = make(map[[32]byte][65]byte) // clear its content after flushing into historyStore
+		app.addressTracer.collect(&prevBlk4MoDB)
+		app.create2Index.collect(&prevBlk4MoDB)
+		app.feeAccounting.collect(&prevBlk4MoDB, app.feeDistribution)
+		if err := app.stateAccess.collect(prevBlk4MoDB.Height, app.blockAccesses); err != nil {
+			app.logger.Error("cannot save the state access heights", "error", err.Error())
+		}
+		if len(app.txHooks) != 0 {
+			txs := blockTxs(&prevBlk4MoDB)
+			app.observeTxs(func(h txhook.TxHook) { h.TxResults(prevBlk4MoDB.Height, txs) })
+		}
+		app.txResultEvents = blockTxResultEvents(&prevBlk4MoDB, app.config.AppConfig.TxEventVerbosity)
 		app.publishNewBlock(&prevBlk4MoDB)
 	}
 	//make new
+	app.lastFrozenTxs, app.frozenTxs = app.frozenTxs, nil
+	app.canceledTxs.prune(app.currHeight)
 	app.recheckCounter = 0 // reset counter before counting the remained TXs which need rechecking
 	app.lastProposer = app.block.Miner
 	app.lastVoters = app.lastVoters[:0]
+	// the trunk has been written back under rootHeight, so its version is immutable from now on
+	atomic.StoreInt64(&app.snapshotHeight, app.rootHeight)
+	if app.callResultCache != nil {
+		app.callResultCache.prune(app.rootHeight)
+	}
+	app.rootHeight = app.currHeight
 	app.root.SetHeight(app.currHeight)
 	app.trunk = app.root.GetTrunkStore(lastCacheSize).(*store.TrunkStore)
 	app.checkTrunk = app.root.GetReadOnlyTrunkStore(app.config.AppConfig.TrunkCacheSize).(*store.TrunkStore)
-	app.txEngine.SetContext(app.GetRunTxContext())
+	if app.parallelTuner != nil {
+		// the collected txs have been taken by Prepare and the committed ones have been written
+		// to moeingdb, so the engine holds nothing and can be replaced before Execute
+		if n, ok := app.parallelTuner.next(); ok {
+			app.logger.Info("change the parallel number of the engine", "parallelNum", n)
+			app.txEngine = app.newTxEngine(n)
+		}
+	}
+	app.txEngine.SetContext(app.engineContext())
 	return
 }
 
+func (app *App) newTxEngine(parallelNum int) ebp.TxExecutor {
+	return ebp.NewEbpTxExec(
+		param.EbpExeRoundCount,
+		param.EbpRunnerNumber,
+		parallelNum,
+		5000, /*not consensus relevant*/
+		app.signer,
+		app.logger.With("module", "engine"))
+}
+
+func (app *App) observeTxs(fn func(h txhook.TxHook)) {
+	txhook.Observe(app.txHooks, fn, func(h txhook.TxHook, r interface{}) {
+		app.logger.Error("tx hook panicked", "name", h.Name(), "panic", r)
+	})
+}
+
 func (app *App) publishNewBlock(mdbBlock *modbtypes.Block) {
 	if mdbBlock == nil {
 		return
@@ -878,7 +1373,23 @@ func (app *App) ApplySnapshotChunk(chunk abcitypes.RequestApplySnapshotChunk) ab
 	return abcitypes.ResponseApplySnapshotChunk{}
 }
 
+// StopWatcher stops the goroutines of the BCH watcher, the blocks committed later get no new epochs
+func (app *App) StopWatcher() {
+	app.watcher.Stop()
+}
+
 func (app *App) Stop() {
+	app.StopWatcher()
+	_ = app.auditLog.Close()
+	if app.stateAccess.isActive() {
+		_ = app.stateAccess.save()
+	}
+	if app.coldTier != nil {
+		app.coldTier.Close()
+	}
+	if app.exporter != nil {
+		app.exporter.Close()
+	}
 	app.historyStore.Close()
 	app.root.Close()
 	app.scope.Close()
@@ -886,7 +1397,7 @@ func (app *App) Stop() {
 
 func (app *App) GetRpcContext() *types.Context {
 	c := types.NewContext(nil, nil)
-	r := rabbit.NewReadOnlyRabbitStore(app.root)
+	r := app.newRpcRabbitStore()
 	c = c.WithRbt(&r)
 	c = c.WithDb(app.historyStore)
 	c.SetShaGateForkBlock(param.ShaGateForkBlock)
@@ -896,6 +1407,20 @@ func (app *App) GetRpcContext() *types.Context {
 	c.SetType(types.RpcType)
 	return c
 }
+
+// newRpcRabbitStore returns the store of the latest state for RPC. With rpc-snapshot-reads in archive
+// mode, it reads the version of the last committed state, which is never written again, so the queries
+// do not share the root store with the writer of Commit. Without archive mode, moeingads does not
+// keep the old versions and the root store must be shared.
+func (app *App) newRpcRabbitStore() rabbit.RabbitStore {
+	if app.config.AppConfig.ArchiveMode && app.config.AppConfig.RpcSnapshotReads {
+		if height := atomic.LoadInt64(&app.snapshotHeight); height > 0 {
+			return rabbit.NewReadOnlyRabbitStoreAtHeight(app.root, uint64(height))
+		}
+	}
+	return rabbit.NewReadOnlyRabbitStore(app.root)
+}
+
 func (app *App) GetRpcContextAtHeight(height int64) *types.Context {
 	if !app.config.AppConfig.ArchiveMode || height < 0 {
 		return app.GetRpcContext()
@@ -912,8 +1437,20 @@ func (app *App) GetRpcContextAtHeight(height int64) *types.Context {
 	return c
 }
 func (app *App) GetRunTxContext() *types.Context {
+	return app.runTxContextOf(app.trunk)
+}
+
+// engineContext returns the context of the engine, whose accesses to the trunk are recorded if needed
+func (app *App) engineContext() *types.Context {
+	if app.accessRecorder == nil {
+		return app.GetRunTxContext()
+	}
+	return app.runTxContextOf(app.accessRecorder.wrap(app.trunk))
+}
+
+func (app *App) runTxContextOf(trunk storetypes.BaseStoreI) *types.Context {
 	c := types.NewContext(nil, nil)
-	r := rabbit.NewRabbitStore(app.trunk)
+	r := rabbit.NewRabbitStore(trunk)
 	c = c.WithRbt(&r)
 	c = c.WithDb(app.historyStore)
 	c.SetShaGateForkBlock(param.ShaGateForkBlock)
@@ -946,6 +1483,22 @@ func (app *App) GetCheckTxContext() *types.Context {
 }
 
 func (app *App) RunTxForRpc(gethTx *gethtypes.Transaction, sender gethcmn.Address, estimateGas bool, height int64) (*ebp.TxRunner, int64) {
+	if app.callResultCache == nil || !app.callResultCache.covers(height, atomic.LoadInt64(&app.snapshotHeight)) ||
+		!isCacheableCall(gethTx.To()) {
+		return app.runTxForRpc(gethTx, sender, estimateGas, height)
+	}
+	key := callKey{txHash: gethTx.Hash(), sender: sender, estimateGas: estimateGas}
+	if r, ok := app.callResultCache.get(height, key); ok {
+		return r.runner, r.estimateResult
+	}
+	runner, estimateResult := app.runTxForRpc(gethTx, sender, estimateGas, height)
+	if runner != nil {
+		app.callResultCache.put(height, key, runner, estimateResult)
+	}
+	return runner, estimateResult
+}
+
+func (app *App) runTxForRpc(gethTx *gethtypes.Transaction, sender gethcmn.Address, estimateGas bool, height int64) (*ebp.TxRunner, int64) {
 	txToRun := &types.TxToRun{}
 	txToRun.FromGethTx(gethTx, sender, uint64(app.currHeight))
 	ctx := app.GetRpcContextAtHeight(height)
@@ -965,10 +1518,23 @@ func (app *App) RunTxForRpc(gethTx *gethtypes.Transaction, sender gethcmn.Addres
 			Hash:      blk.Hash,
 		}
 	}
-	estimateResult := ebp.RunTxForRpc(bi, estimateGas, runner)
+	estimateResult := runRpcRunner(bi, estimateGas, runner)
 	return runner, estimateResult
 }
 
+// rpcRunnerSlots is taken by every eth_call and eth_estimateGas before it borrows one of the global
+// RPC runners of ebp. The peg-in calls made by the cc contract borrow a runner too, both when the
+// engine executes a block in postCommit and when an RPC call simulates the cc contract while holding
+// its own runner. The slots are one less than the runners, so the RPC calls never hold them all, and
+// the peg-in calls, which hold no runner while they wait, always get the one left eventually.
+var rpcRunnerSlots = make(chan struct{}, ebp.RpcRunnersCount-1)
+
+func runRpcRunner(bi *types.BlockInfo, estimateGas bool, runner *ebp.TxRunner) int64 {
+	rpcRunnerSlots <- struct{}{}
+	defer func() { <-rpcRunnerSlots }()
+	return ebp.RunTxForRpc(bi, estimateGas, runner)
+}
+
 // RunTxForSbchRpc is like RunTxForRpc, with two differences:
 // 1. estimateGas is always false
 // 2. run under context of block#height-1
@@ -992,15 +1558,92 @@ func (app *App) RunTxForSbchRpc(gethTx *gethtypes.Transaction, sender gethcmn.Ad
 		ChainId:   app.chainId.Bytes32(),
 		Hash:      blk.Hash,
 	}
-	estimateResult := ebp.RunTxForRpc(bi, false, runner)
+	estimateResult := runRpcRunner(bi, false, runner)
 	return runner, estimateResult
 }
 
+func (app *App) AddTracedAddress(addr gethcmn.Address) bool {
+	return app.addressTracer.add(addr)
+}
+
+func (app *App) RemoveTracedAddress(addr gethcmn.Address) bool {
+	return app.addressTracer.remove(addr)
+}
+
+func (app *App) GetTracedAddresses() []gethcmn.Address {
+	return app.addressTracer.addresses()
+}
+
+func (app *App) GetAddressTraces(addr gethcmn.Address, startHeight, endHeight int64) []*TracedTx {
+	return app.addressTracer.query(addr, startHeight, endHeight)
+}
+
+func (app *App) GetCreate2Contract(addr gethcmn.Address) *Create2Contract {
+	return app.create2Index.get(addr)
+}
+
+func (app *App) GetCreate2ContractsByDeployer(deployer gethcmn.Address) []*Create2Contract {
+	return app.create2Index.getByDeployer(deployer)
+}
+
+// GetTxFeeRecord returns nil if the tx is not committed in the blocks kept by the fee accounting
+func (app *App) GetTxFeeRecord(txHash gethcmn.Hash) *TxFeeRecord {
+	return app.feeAccounting.getTx(txHash)
+}
+
+func (app *App) GetBlockFeeRecords(startHeight, endHeight int64) []*BlockFeeRecord {
+	return app.feeAccounting.getBlocks(startHeight, endHeight)
+}
+
+// GetGasGrants returns the gas grants made in the block at height, nil if there is none or the
+// block is not committed in the recent blocks since this node started
+func (app *App) GetGasGrants(height int64) []*GasGrant {
+	return app.gasGrants.get(height)
+}
+
+// GetParamChanges returns the changes of the on-chain consensus parameters since the node started
+func (app *App) GetParamChanges() []*ParamChange {
+	return app.paramHistory.all()
+}
+
+// GetBlockWitness returns nil if the witness of the block is not recorded
+func (app *App) GetBlockWitness(height int64) *BlockWitness {
+	return app.witnesses.get(height)
+}
+
+// GetIdleStateReport returns nil if state-access-tracking-path is not configured
+func (app *App) GetIdleStateReport(idleBlocks int64, top int) *StateAccessReport {
+	if !app.stateAccess.isActive() {
+		return nil
+	}
+	return app.stateAccess.report(idleBlocks, top)
+}
+
+// SimulateStateRent returns nil if state-access-tracking-path is not configured
+func (app *App) SimulateStateRent(policies []StateRentPolicy) []*StateRentResult {
+	if !app.stateAccess.isActive() {
+		return nil
+	}
+	return app.stateAccess.simulate(policies)
+}
+
 // SubscribeChainEvent registers a subscription of ChainEvent.
 func (app *App) SubscribeChainEvent(ch chan<- types.ChainEvent) event.Subscription {
 	return app.scope.Track(app.chainFeed.Subscribe(ch))
 }
 
+// SubscribeValidatorPowerEvent registers a subscription of the validators' voting power changes,
+// the events which do not fit in the buffer of ch are dropped
+func (app *App) SubscribeValidatorPowerEvent(ch chan<- []*ValidatorPowerChangeEvent) event.Subscription {
+	return app.scope.Track(app.powerFeed.Subscribe(ch))
+}
+
+// SubscribeStakingEvent registers a subscription of the epoch switches and validator set changes,
+// the events which do not fit in the buffer of ch are dropped
+func (app *App) SubscribeStakingEvent(ch chan<- *StakingEvent) event.Subscription {
+	return app.scope.Track(app.stakeFeed.Subscribe(ch))
+}
+
 // SubscribeLogsEvent registers a subscription of []*types.Log.
 func (app *App) SubscribeLogsEvent(ch chan<- []*gethtypes.Log) event.Subscription {
 	return app.scope.Track(app.logsFeed.Subscribe(ch))
@@ -1036,14 +1679,31 @@ func (app *App) IsArchiveMode() bool {
 	return app.config.AppConfig.ArchiveMode
 }
 
-func (app *App) GetCurrEpoch() *stakingtypes.Epoch {
+func (app *App) GetCurrEpoch() (*stakingtypes.Epoch, error) {
 	return app.watcher.GetCurrEpoch()
 }
 
+func (app *App) GetNominationStatus(pubkey [32]byte) (*staking.NominationStatus, error) {
+	epoch, err := app.watcher.GetCurrEpoch()
+	if err != nil {
+		return nil, err
+	}
+	ctx := app.GetRpcContext()
+	defer ctx.Close(false)
+	blocksScanned, numBlocksInEpoch := app.watcher.GetEpochProgress()
+	return staking.GetNominationStatus(ctx, epoch, pubkey, blocksScanned, numBlocksInEpoch), nil
+}
+
+func (app *App) GetFrozenAddresses() []*freeze.FrozenAddress {
+	ctx := app.GetRpcContext()
+	defer ctx.Close(false)
+	return freeze.GetFrozenAddresses(ctx)
+}
+
 func (app *App) GetAppEpochList() []*stakingtypes.Epoch {
 	return stakingtypes.CopyEpochs(app.epochList)
 }
-func (app *App) GetWatcherEpochList() []*stakingtypes.Epoch {
+func (app *App) GetWatcherEpochList() ([]*stakingtypes.Epoch, error) {
 	return app.watcher.GetEpochList()
 }
 
@@ -1058,10 +1718,91 @@ func (app *App) GetBlockForSync(height int64) (blk []byte, err error) {
 	return
 }
 
+// GetColdBlock returns the block at height from the cold store, which keeps the blocks pruned
+// from moeingdb. The blocks which are not pruned yet are not read from the cold store.
+func (app *App) GetColdBlock(height int64) (*coldstore.Block, error) {
+	if app.coldTier == nil {
+		return nil, errNoColdStore
+	}
+	kept := app.config.AppConfig.NumKeptBlocksInMoDB
+	if kept <= 0 || height > app.GetLatestBlockNum()-kept {
+		return nil, errNotPruned
+	}
+	return app.coldTier.GetBlock(height)
+}
+
+// readExportedBlock reads a block and its transactions committed into moeingdb for the exporter
+func (app *App) readExportedBlock(height int64) (*types.Block, []*types.Transaction, error) {
+	ctx := app.GetHistoryOnlyContext()
+	defer ctx.Close(false)
+	blk, err := ctx.GetBlockByHeight(uint64(height))
+	if err != nil {
+		return nil, nil, err
+	}
+	txs, _, err := ctx.GetTxListByHeightWithRange(uint32(height), 0, math.MaxInt32)
+	if err != nil {
+		return nil, nil, err
+	}
+	return blk, txs, nil
+}
+
 func (app *App) GetRpcMaxLogResults() int {
 	return app.config.AppConfig.RpcEthGetLogsMaxResults
 }
 
+func (app *App) GetModbIndexes() param.ModbIndexes {
+	return app.config.AppConfig.ModbIndexes()
+}
+
+// RecordAudit appends a privileged operation to the audit log, the operation has been taken
+// when it is called, so a failure is logged instead of being returned
+func (app *App) RecordAudit(action, source string, details map[string]string) {
+	if err := app.auditLog.Record(action, source, details); err != nil {
+		app.logger.Error("failed to record audit log", "action", action, "error", err.Error())
+	}
+}
+
+func newAdminGuard(config *param.AppConfig, chainId uint64) *multisig.Guard {
+	// the nonces used are recovered from the audit log, so the operations can not be replayed
+	// after restarting
+	if config.AuditLogPath == "" {
+		panic("admin-signers requires audit_log_path")
+	}
+	signers, err := multisig.ParseSigners(config.AdminSigners)
+	if err != nil {
+		panic(err)
+	}
+	entries, err := audit.ReadAll(config.AuditLogPath)
+	if err != nil && !os.IsNotExist(err) {
+		panic(err)
+	}
+	guard, err := multisig.NewGuard(signers, config.AdminThreshold, chainId, multisig.LastNonce(entries))
+	if err != nil {
+		panic(err)
+	}
+	return guard
+}
+
+// AdminOpsRequireSigs returns whether the privileged RPC requires the signatures of the operators
+func (app *App) AdminOpsRequireSigs() bool {
+	return app.adminGuard != nil
+}
+
+func (app *App) VerifyAdminOp(payload []byte, sigs [][]byte) (*multisig.Op, []gethcmn.Address, error) {
+	if app.adminGuard == nil {
+		return nil, nil, errNoAdminOps
+	}
+	return app.adminGuard.Verify(payload, sigs, time.Now().Unix())
+}
+
+// GetAdminOpNonce returns the nonce of the last accepted admin operation
+func (app *App) GetAdminOpNonce() (uint64, error) {
+	if app.adminGuard == nil {
+		return 0, errNoAdminOps
+	}
+	return app.adminGuard.LastNonce(), nil
+}
+
 func (app *App) GetLostAndFoundUtxoIds() [][36]byte {
 	return app.historyStore.GetLostAndFoundUtxoIds()
 }
@@ -1078,6 +1819,62 @@ func (app *App) GetWatcherHeight() int64 {
 	return app.watcher.GetLatestFinalizedHeight()
 }
 
+func (app *App) GetWatcherStatus() watcher.Status {
+	return app.watcher.GetStatus()
+}
+
+func (app *App) GetCcTiming() watcher.CcTiming {
+	return app.watcher.GetCcTiming()
+}
+
+func (app *App) GetCcCollectStatus() watchertypes.CcCollectStatus {
+	return app.watcher.GetCcCollectStatus()
+}
+
+func (app *App) ForceCcRescan(begin, end int64) error {
+	return app.watcher.ForceCcRescan(begin, end)
+}
+
+func (app *App) SetCcCollectPaused(paused bool) bool {
+	return app.watcher.SetCcCollectPaused(paused)
+}
+
+func (app *App) GetPendingCcDeposits() ([]watchertypes.PendingCcDeposit, bool) {
+	return app.watcher.GetPendingCcDeposits()
+}
+
+func (app *App) GetWatcherMonitorVoteInfos() ([]*cctypes.MonitorVoteInfo, *cctypes.MonitorVoteInfo, error) {
+	return app.watcher.GetMonitorVoteInfos()
+}
+
+func (app *App) GetWatcherAnalytics(limit int) watcher.AnalyticsReport {
+	return app.watcher.GetAnalytics(limit)
+}
+
+func (app *App) GetArchivedEpochs(start, end int64) ([]*watcher.ArchivedEpoch, bool) {
+	return app.watcher.GetArchivedEpochs(start, end)
+}
+
+func (app *App) GetArchivedEpochByHeight(height int64) (*watcher.ArchivedEpoch, bool) {
+	return app.watcher.GetArchivedEpochByHeight(height)
+}
+
+// SetEpochGossipKey sets the consensus key of this validator, with which the epochs published by
+// its watcher are signed and served to the other validators
+func (app *App) SetEpochGossipKey(key tmcrypto.PrivKey) {
+	app.epochGossipKey = key
+}
+
+// GetSignedVoteInfos returns the vote infos published by the watcher from the epoch starting at the
+// BCH height startHeight on, signed with the consensus key
+func (app *App) GetSignedVoteInfos(startHeight int64) (*watchertypes.SignedVoteInfos, error) {
+	if app.epochGossipKey == nil {
+		return nil, watcher.ErrEpochGossipNotServed
+	}
+	infos := app.watcher.GetPublishedVoteInfos(startHeight, watcher.MaxGossipVoteInfos)
+	return watcher.SignVoteInfos(infos, app.epochGossipKey)
+}
+
 //nolint
 // for ((i=10; i<80000; i+=50)); do RANDPANICHEIGHT=$i ./smartbchd start; done | tee a.log
 func (app *App) randomPanic(baseNumber, primeNumber int64) { // breaks normal function, only used in test
@@ -9,11 +9,14 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/ethereum/go-ethereum/common"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
 	abcitypes "github.com/tendermint/tendermint/abci/types"
 	"github.com/tendermint/tendermint/libs/log"
 
-	"github.com/smartbch/moeingevm/evmwrap/testcase"
+	modbtypes "github.com/smartbch/moeingdb/types"
 	"github.com/smartbch/moeingevm/types"
+	"github.com/smartbch/smartbch/freeze"
 	"github.com/smartbch/smartbch/internal/ethutils"
 	"github.com/smartbch/smartbch/param"
 )
@@ -24,6 +27,7 @@ func init() {
 	p = param.DefaultConfig()
 	p.AppConfig.ModbDataPath = "./testDb"
 	p.AppConfig.AppDataPath = "./testAppDb"
+	p.AppConfig.AuditLogPath = ""
 }
 
 func removeTestDB(_app *App) {
@@ -73,13 +77,13 @@ func TestAppInfo(t *testing.T) {
 
 func TestCheckTx(t *testing.T) {
 	_app := NewApp(p, uint256.NewInt(1), 0, 0, log.NewNopLogger(), true)
-	_app.signer = &testcase.DumbSigner{}
 	defer removeTestDB(_app)
 
 	//test sigCache
-	addr := common.Address{0x01}
+	key, _ := crypto.GenerateKey()
+	addr := crypto.PubkeyToAddress(key.PublicKey)
 	tx := ethutils.NewTx(0, &addr, big.NewInt(100), 100000, big.NewInt(10), nil)
-	signedTx, _ := tx.WithSignature(_app.signer, addr.Bytes())
+	signedTx, _ := ethutils.SignTx(tx, _app.chainId.ToBig(), key)
 	data, _ := ethutils.EncodeTx(signedTx)
 	r := abcitypes.RequestCheckTx{
 		Tx:   data,
@@ -102,7 +106,7 @@ func TestCheckTx(t *testing.T) {
 	//test sigCache clear
 	_app.config.AppConfig.SigCacheSize = 0
 	tx = ethutils.NewTx(1, &addr, big.NewInt(100), 100000, big.NewInt(10), nil)
-	signedTx, _ = tx.WithSignature(_app.signer, addr.Bytes())
+	signedTx, _ = ethutils.SignTx(tx, _app.chainId.ToBig(), key)
 	data, _ = ethutils.EncodeTx(signedTx)
 	r.Tx = data
 	_app.CheckTx(r)
@@ -111,9 +115,81 @@ func TestCheckTx(t *testing.T) {
 	//test gas too large
 	_app.config.AppConfig.RecheckThreshold = 10
 	tx = ethutils.NewTx(2, &addr, big.NewInt(100), param.MaxTxGasLimit+1, big.NewInt(10), nil)
-	signedTx, _ = tx.WithSignature(_app.signer, addr.Bytes())
+	signedTx, _ = ethutils.SignTx(tx, _app.chainId.ToBig(), key)
 	data, _ = ethutils.EncodeTx(signedTx)
 	r.Tx = data
 	res = _app.CheckTx(r)
 	require.Equal(t, GasLimitInvalid, res.Code)
 }
+
+func TestCollectDeliveredTxs(t *testing.T) {
+	_app := NewApp(p, uint256.NewInt(1), 0, 0, log.NewNopLogger(), true)
+	defer removeTestDB(_app)
+
+	frozenKey, _ := crypto.GenerateKey()
+	frozen := crypto.PubkeyToAddress(frozenKey.PublicKey)
+	key, _ := crypto.GenerateKey()
+	to := common.Address{0x01}
+	tx1, _ := ethutils.SignTx(ethutils.NewTx(0, &to, big.NewInt(100), 100000, big.NewInt(10), nil), _app.chainId.ToBig(), frozenKey)
+	tx2, _ := ethutils.SignTx(ethutils.NewTx(0, &to, big.NewInt(100), 100000, big.NewInt(10), nil), _app.chainId.ToBig(), key)
+	deliver := func() {
+		for _, tx := range []*gethtypes.Transaction{tx1, tx2} {
+			data, _ := ethutils.EncodeTx(tx)
+			_app.DeliverTx(abcitypes.RequestDeliverTx{Tx: data})
+		}
+	}
+
+	ctx := _app.GetRunTxContext()
+	freeze.SaveFrozen(ctx, frozen, 1, 1)
+	acc := types.ZeroAccountInfo()
+	acc.UpdateBalance(uint256.NewInt(1000_000))
+	ctx.SetAccount(frozen, acc)
+	ctx.Close(true)
+
+	// the freeze state is not read before the fork
+	deliver()
+	_app.collectDeliveredTxs()
+	require.Equal(t, 2, _app.txEngine.CollectedTxsCount())
+	require.Empty(t, _app.deliveredTxs)
+
+	_app.txEngine.Prepare(0, 0, param.MaxTxGasLimit) // clear the collected txs
+	_app.currHeight = param.FreezeForkHeight
+	deliver()
+	_app.collectDeliveredTxs()
+	require.Equal(t, 1, _app.txEngine.CollectedTxsCount())
+	require.Contains(t, _app.txid2sigMap, [32]byte(tx1.Hash()))
+	require.Contains(t, _app.txid2sigMap, [32]byte(tx2.Hash()))
+	require.Len(t, _app.frozenTxs, 1)
+
+	// the nonce of the frozen sender is consumed and the intrinsic gas is charged
+	ctx = _app.GetRunTxContext()
+	acc = ctx.GetAccount(frozen)
+	ctx.Close(false)
+	require.Equal(t, uint64(1), acc.Nonce())
+	require.Equal(t, uint64(1000_000-21000*10), acc.Balance().Uint64())
+
+	// the same tx is dropped when it is included again
+	_app.txEngine.Prepare(0, 0, param.MaxTxGasLimit)
+	deliver()
+	_app.collectDeliveredTxs()
+	require.Len(t, _app.frozenTxs, 1)
+
+	// the failed receipt follows the executed txs of the block
+	blkInfo := &types.Block{Hash: [32]byte{0xbb}}
+	blk := &modbtypes.Block{}
+	executed := []*types.Transaction{{Hash: tx2.Hash(), CumulativeGasUsed: 21000}}
+	appendFrozenTxs(blkInfo, blk, executed, _app.frozenTxs)
+	require.Equal(t, [][32]byte{tx1.Hash()}, blkInfo.Transactions)
+	require.Len(t, blk.TxList, 1)
+	require.Equal(t, [20]byte(frozen), blk.TxList[0].SrcAddr)
+	receipt := &types.Transaction{}
+	_, err := receipt.UnmarshalMsg(blk.TxList[0].Content)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), receipt.TransactionIndex)
+	require.Equal(t, param.FreezeForkHeight, receipt.BlockNumber)
+	require.Equal(t, [32]byte{0xbb}, receipt.BlockHash)
+	require.Equal(t, uint64(21000), receipt.GasUsed)
+	require.Equal(t, uint64(42000), receipt.CumulativeGasUsed)
+	require.Equal(t, uint64(gethtypes.ReceiptStatusFailed), receipt.Status)
+	require.Equal(t, freeze.StatusStrSenderFrozen, receipt.StatusStr)
+}
@@ -1,11 +1,14 @@
 package app_test
 
 import (
+	"bytes"
+	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"math/big"
 	"math/rand"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -18,12 +21,15 @@ import (
 	abci "github.com/tendermint/tendermint/abci/types"
 	"github.com/tendermint/tendermint/crypto/ed25519"
 
+	"github.com/smartbch/moeingads/datatree"
 	"github.com/smartbch/moeingevm/ebp"
+	"github.com/smartbch/moeingevm/types"
 	"github.com/smartbch/smartbch/app"
 	"github.com/smartbch/smartbch/internal/ethutils"
 	"github.com/smartbch/smartbch/internal/testutils"
 	"github.com/smartbch/smartbch/param"
 	"github.com/smartbch/smartbch/staking"
+	"github.com/smartbch/smartbch/txcodec"
 )
 
 //func TestMain(m *testing.M) {
@@ -114,13 +120,41 @@ func TestCheckTx_basics(t *testing.T) {
 	})
 	require.Equal(t, app.CannotDecodeTx, res.Code)
 
-	//sender decode failed
+	//trailing bytes
 	tx = ethutils.NewTx(1, &addr1, big.NewInt(100), 100000, big.NewInt(1), nil)
 	res = _app.CheckTx(abci.RequestCheckTx{
 		Tx:   append(testutils.MustEncodeTx(tx), 0x01),
 		Type: abci.CheckTxType_New,
 	})
+	require.Equal(t, app.CannotDecodeTx, res.Code)
+
+	//sender decode failed
+	tx = ethutils.NewTx(1, &addr1, big.NewInt(100), 100000, big.NewInt(1), nil)
+	res = _app.CheckTx(abci.RequestCheckTx{
+		Tx:   testutils.MustEncodeTx(tx),
+		Type: abci.CheckTxType_New,
+	})
+	require.Equal(t, app.CannotRecoverSender, res.Code)
+
+	//unprotected (pre-EIP155) tx
+	tx = ethutils.NewTx(0, &addr1, big.NewInt(100), 100000, big.NewInt(10), nil)
+	tx, _ = gethtypes.SignTx(tx, gethtypes.HomesteadSigner{}, testutils.MustHexToPrivKey(key1))
+	res = _app.CheckTx(abci.RequestCheckTx{
+		Tx:   testutils.MustEncodeTx(tx),
+		Type: abci.CheckTxType_New,
+	})
 	require.Equal(t, app.CannotRecoverSender, res.Code)
+	require.Contains(t, res.Info, txcodec.ErrUnprotectedTx.Error())
+
+	//tx too large
+	tx = ethutils.NewTx(0, &addr1, big.NewInt(100), 100000, big.NewInt(10), make([]byte, txcodec.DefaultMaxTxSize))
+	tx = testutils.MustSignTx(tx, _app.ChainID().ToBig(), key1)
+	res = _app.CheckTx(abci.RequestCheckTx{
+		Tx:   testutils.MustEncodeTx(tx),
+		Type: abci.CheckTxType_New,
+	})
+	require.Equal(t, app.CannotDecodeTx, res.Code)
+	require.Contains(t, res.Info, txcodec.ErrTxTooLarge.Error())
 
 	//tx nonce mismatch
 	tx = ethutils.NewTx(1, &addr1, big.NewInt(100), 100000, big.NewInt(1), nil)
@@ -404,6 +438,69 @@ func TestGasRefund_AdjustGasUsed(t *testing.T) {
 //	_app.EnsureTxSuccess(tx.Hash())
 //}
 
+// the init code stores 1 to slot 0, and the runtime code increases it: PUSH1 0 SLOAD PUSH1 1 ADD PUSH1 0 SSTORE STOP
+var counterCreationBytecode = common.FromHex("600160005560" + "0a601160003960" + "0a6000f3" + "600054600101600055" + "00")
+
+func TestBlockWitness(t *testing.T) {
+	key, _ := testutils.GenKeyAndAddr()
+	_app := testutils.CreateTestAppWithArgs(testutils.TestAppInitArgs{
+		PrivKeys:          []string{key},
+		WitnessKeptBlocks: 100,
+	})
+	defer _app.Destroy()
+
+	_, _, contract := _app.DeployContractInBlock(key, counterCreationBytecode)
+	require.Equal(t, []byte{1}, bytes.TrimLeft(_app.GetStorageAt(contract, make([]byte, 32)), "\x00"))
+	tx, h := _app.MakeAndExecTxInBlock(key, contract, 0, nil)
+	_app.EnsureTxSuccess(tx.Hash())
+
+	w := _app.GetBlockWitness(h)
+	require.NotNil(t, w)
+	slot := types.GetValueKey(_app.GetSeq(contract), string(make([]byte, 32)))
+	found := false
+	for _, e := range w.Entries {
+		path, err := datatree.BytesToProofPath(e.Proof)
+		require.NoError(t, err)
+		require.NoError(t, path.Check(true))
+		require.Equal(t, sha256.Sum256(e.EntryBytes), path.LeftOfTwig[0].SelfHash)
+		if bytes.Equal(e.Key, slot) {
+			require.Equal(t, []byte{1}, bytes.TrimLeft(e.Value, "\x00")) // the value before the tx
+			found = true
+		}
+	}
+	require.True(t, found)
+}
+
+func TestStateAccessTracking(t *testing.T) {
+	key, _ := testutils.GenKeyAndAddr()
+	_app := testutils.CreateTestAppWithArgs(testutils.TestAppInitArgs{
+		PrivKeys:                []string{key},
+		StateAccessTrackingPath: filepath.Join(t.TempDir(), "state-access"),
+	})
+	defer _app.Destroy()
+
+	_, _, contract := _app.DeployContractInBlock(key, counterCreationBytecode)
+	seq := _app.GetSeq(contract)
+	tx, _ := _app.MakeAndExecTxInBlock(key, contract, 0, nil)
+	_app.EnsureTxSuccess(tx.Hash())
+
+	// the counter was written by the tx
+	report := _app.GetIdleStateReport(5, 10)
+	require.NotNil(t, report)
+	require.Equal(t, int64(1), report.Storage.Entries)
+	require.Equal(t, int64(40+32), report.Storage.Bytes)
+	require.Zero(t, report.Storage.IdleEntries)
+	require.NotZero(t, report.Accounts.Entries)
+	require.NotZero(t, report.Bytecodes.Entries)
+
+	for i := 0; i < 3; i++ {
+		_app.ExecTxsInBlock()
+	}
+	report = _app.GetIdleStateReport(5, 10)
+	require.Equal(t, int64(1), report.Storage.IdleEntries)
+	require.Equal(t, []*app.IdleContractStorage{{Seq: seq, IdleSlots: 1, IdleBytes: 40 + 32}}, report.TopIdleContracts)
+}
+
 func TestJson(t *testing.T) {
 	//str := []byte("\"validators\":[\"PupuoOdnaRYJQUSzCsV5B6gBfkWiaI4Jmq8giG/KL0M=\",\"G0IgOw0f4hqpR0TX+ld5TzOyPI2+BuaYhjlHv6IiCHw=\",\"YdrD918WSVISQes6g5v5xI0x580OM2LMNUIRIS8EXjA=\",\"/opEYWd8xnLK95QN34+mrE666sSt/GARmJYgRUYnvb0=\",\"gM4A5vTY9vTgHOd00TTXPo7HyEHBkuIpvbUBw28DxrI=\",\"4kFUm8nRR2Tg3YCl55lOWbAGYi4fPQnHiCrWHWnEd3k=\",\"yb/5/EsybQ2rI9XkRQoJBAixvAoivV0mb9jqsEVSUj8=\",\"8MfS5Y24qXoACl45f3otSyOB1sCCgrXGX/SIPTuaC9Y=\",\"BAsO38HaA7XyMB8tAkI8ests8jdOeFe03j3QROKFVsg=\",\"We2gXsEqww2Q+NdVGbaWhR0nyrxP/FBv4TzJxNKMwb4=\"]}")
 
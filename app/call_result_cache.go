@@ -0,0 +1,100 @@
+package app
+
+import (
+	"sync"
+
+	gethcmn "github.com/ethereum/go-ethereum/common"
+	"github.com/smartbch/moeingevm/ebp"
+)
+
+type callKey struct {
+	txHash      gethcmn.Hash // the hash of the unsigned tx covers to, data, value, gas, gasPrice and nonce
+	sender      gethcmn.Address
+	estimateGas bool
+}
+
+type callResult struct {
+	runner         *ebp.TxRunner
+	estimateResult int64
+}
+
+// callResultCache caches the results of eth_call and eth_estimateGas at the recent heights in
+// memory. It is not a snapshot of the state: the first run of a call still reads moeingads, and
+// only the frontends which pin a recent block number and poll the same calls are served from
+// memory. It is used in archive mode only, where the state of a committed height is immutable, so
+// the same call at the same height gets the same result, except for the calls to the predefined
+// system contracts, which are never cached, see isCacheableCall. There is one layer of results for
+// each of the last `blocks` heights, and the layers below them are dropped when a new block is
+// committed.
+type callResultCache struct {
+	mtx    sync.RWMutex
+	blocks int64
+	size   int // the max number of results in a layer
+	layers map[int64]map[callKey]callResult
+}
+
+func newCallResultCache(blocks int64, size int) *callResultCache {
+	return &callResultCache{
+		blocks: blocks,
+		size:   size,
+		layers: make(map[int64]map[callKey]callResult),
+	}
+}
+
+// covers returns whether the results at height are kept, given the latest immutable height
+func (c *callResultCache) covers(height, latest int64) bool {
+	return height > 0 && height <= latest && height > latest-c.blocks
+}
+
+func (c *callResultCache) get(height int64, key callKey) (callResult, bool) {
+	c.mtx.RLock()
+	defer c.mtx.RUnlock()
+	r, ok := c.layers[height][key]
+	return r, ok
+}
+
+// put only keeps the fields of the runner read by the RPC, so the context of the run can be freed
+func (c *callResultCache) put(height int64, key callKey, runner *ebp.TxRunner, estimateResult int64) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	layer := c.layers[height]
+	if layer == nil {
+		layer = make(map[callKey]callResult)
+		c.layers[height] = layer
+	}
+	if len(layer) >= c.size {
+		return
+	}
+	layer[key] = callResult{
+		runner: &ebp.TxRunner{
+			Status:                 runner.Status,
+			GasUsed:                runner.GasUsed,
+			OutData:                runner.OutData,
+			Logs:                   runner.Logs,
+			CreatedContractAddress: runner.CreatedContractAddress,
+		},
+		estimateResult: estimateResult,
+	}
+}
+
+// prune drops the layers which are no longer covered after latest becomes immutable
+func (c *callResultCache) prune(latest int64) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	for height := range c.layers {
+		if height <= latest-c.blocks {
+			delete(c.layers, height)
+		}
+	}
+}
+
+// isCacheableCall returns false for the calls to the predefined system contracts, whose results may
+// depend on the memory of the node besides the state at the height, such as the cc contract, which
+// reads the cc infos queued by the watcher
+func isCacheableCall(to *gethcmn.Address) bool {
+	if to == nil {
+		return true
+	}
+	_, ok := ebp.PredefinedContractManager[*to]
+	return !ok
+}
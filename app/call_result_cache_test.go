@@ -0,0 +1,52 @@
+package app
+
+import (
+	"testing"
+
+	gethcmn "github.com/ethereum/go-ethereum/common"
+	"github.com/smartbch/moeingevm/ebp"
+	"github.com/smartbch/moeingevm/types"
+	"github.com/stretchr/testify/require"
+
+	"github.com/smartbch/smartbch/crosschain"
+)
+
+func TestCallResultCache(t *testing.T) {
+	c := newCallResultCache(2, 1)
+	require.False(t, c.covers(-1, 10))
+	require.False(t, c.covers(8, 10))
+	require.True(t, c.covers(9, 10))
+	require.True(t, c.covers(10, 10))
+	require.False(t, c.covers(11, 10))
+
+	key := callKey{txHash: gethcmn.Hash{1}, sender: gethcmn.Address{2}}
+	c.put(10, key, &ebp.TxRunner{Status: 1, OutData: []byte{3}}, 0)
+	r, ok := c.get(10, key)
+	require.True(t, ok)
+	require.Equal(t, []byte{3}, r.runner.OutData)
+	_, ok = c.get(10, callKey{txHash: gethcmn.Hash{1}, sender: gethcmn.Address{2}, estimateGas: true})
+	require.False(t, ok)
+
+	// the layer is full
+	c.put(10, callKey{txHash: gethcmn.Hash{4}}, &ebp.TxRunner{}, 0)
+	_, ok = c.get(10, callKey{txHash: gethcmn.Hash{4}})
+	require.False(t, ok)
+
+	c.prune(11)
+	_, ok = c.get(10, key)
+	require.True(t, ok)
+	c.prune(12)
+	_, ok = c.get(10, key)
+	require.False(t, ok)
+}
+
+func TestIsCacheableCall(t *testing.T) {
+	systemContract := gethcmn.Address(crosschain.CCContractAddress)
+	prev := ebp.PredefinedContractManager
+	ebp.PredefinedContractManager = map[gethcmn.Address]types.SystemContractExecutor{systemContract: nil}
+	defer func() { ebp.PredefinedContractManager = prev }()
+
+	require.True(t, isCacheableCall(nil))
+	require.True(t, isCacheableCall(&gethcmn.Address{0x01}))
+	require.False(t, isCacheableCall(&systemContract))
+}
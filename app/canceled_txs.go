@@ -0,0 +1,56 @@
+package app
+
+import (
+	"sync"
+
+	gethcmn "github.com/ethereum/go-ethereum/common"
+)
+
+// The canceled transactions are rejected by CheckTx in the recheck after the next block, which
+// drops them from the mempool. The records are kept a few more blocks such that the canceled
+// transactions are not accepted again when they are gossiped back from the peers.
+const CanceledTxKeptBlocks = 20
+
+// canceledTxs records the transactions canceled by sbch_cancelTransaction
+type canceledTxs struct {
+	mtx     sync.Mutex
+	heights map[gethcmn.Hash]int64 // the heights at which the transactions are canceled
+}
+
+func newCanceledTxs() *canceledTxs {
+	return &canceledTxs{
+		heights: make(map[gethcmn.Hash]int64),
+	}
+}
+
+func (c *canceledTxs) add(txid gethcmn.Hash, height int64) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.heights[txid] = height
+}
+
+func (c *canceledTxs) has(txid gethcmn.Hash) bool {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	_, ok := c.heights[txid]
+	return ok
+}
+
+// prune removes the records older than CanceledTxKeptBlocks
+func (c *canceledTxs) prune(currHeight int64) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	for txid, h := range c.heights {
+		if h+CanceledTxKeptBlocks < currHeight {
+			delete(c.heights, txid)
+		}
+	}
+}
+
+// CancelTx makes CheckTx reject the transaction, it is dropped from this node's mempool in the
+// recheck after the next block. It cannot be removed from the blocks which are being proposed,
+// nor from the mempools of the other nodes.
+func (app *App) CancelTx(txid gethcmn.Hash) {
+	app.canceledTxs.add(txid, app.currHeight)
+	app.logger.Info("tx canceled", "txid", txid.Hex(), "height", app.currHeight)
+}
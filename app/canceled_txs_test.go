@@ -0,0 +1,22 @@
+package app
+
+import (
+	"testing"
+
+	gethcmn "github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCanceledTxs(t *testing.T) {
+	c := newCanceledTxs()
+	c.add(gethcmn.Hash{1}, 100)
+	c.add(gethcmn.Hash{2}, 110)
+	require.True(t, c.has(gethcmn.Hash{1}))
+	require.False(t, c.has(gethcmn.Hash{3}))
+
+	c.prune(100 + CanceledTxKeptBlocks)
+	require.True(t, c.has(gethcmn.Hash{1}))
+	c.prune(101 + CanceledTxKeptBlocks)
+	require.False(t, c.has(gethcmn.Hash{1}))
+	require.True(t, c.has(gethcmn.Hash{2}))
+}
@@ -49,7 +49,7 @@ func TestCC(t *testing.T) {
 	index1 := big.NewInt(1)
 	value1 := uint256.NewInt(11)
 
-	w.CcContractExecutor.Infos = []*types.CCTransferInfo{
+	w.GetCCExecutor().Queue.SetWindow(0, []*types.CCTransferInfo{
 		{
 			Type: types.TransferType,
 			UTXO: types.UTXO{
@@ -70,7 +70,7 @@ func TestCC(t *testing.T) {
 			Receiver:        alice,
 			CovenantAddress: covenantAddress,
 		},
-	}
+	})
 	// set cc context
 	ctx := _app.GetRunTxContext()
 	ccCtx := types.CCContext{
@@ -133,7 +133,7 @@ func TestCC(t *testing.T) {
 	value2 := uint256.NewInt(10)
 	covenantAddress1 := [20]byte{0x2}
 
-	w.CcContractExecutor.Infos = []*types.CCTransferInfo{
+	w.GetCCExecutor().Queue.SetWindow(0, []*types.CCTransferInfo{
 		{
 			Type: types.ConvertType,
 			UTXO: types.UTXO{
@@ -156,7 +156,7 @@ func TestCC(t *testing.T) {
 				Amount: value.Bytes32(),
 			},
 		},
-	}
+	})
 	txData = ccabi.PackHandleUTXOsFunc()
 	tx, _ = _app.MakeAndExecTxInBlock(key, crosschain.CCContractAddress, 0, txData)
 	_app.EnsureTxFailedWithOutData(tx.Hash(), "failure", crosschain.ErrUTXOAlreadyHandled.Error())
@@ -0,0 +1,126 @@
+package app
+
+import (
+	"sync"
+
+	gethcmn "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	modbtypes "github.com/smartbch/moeingdb/types"
+	"github.com/smartbch/moeingevm/types"
+)
+
+const (
+	// The max number of CREATE2 contracts kept in memory, the oldest ones are dropped first
+	MaxCreate2IndexSize = 100000
+
+	callKindCreate2 = 4
+)
+
+// Create2Contract is a contract created by the CREATE2 opcode. The salt is not included because
+// the EVM traces do not record it, but it can be checked with Deployer and InitCodeHash.
+type Create2Contract struct {
+	Address      gethcmn.Address
+	Deployer     gethcmn.Address
+	InitCodeHash gethcmn.Hash
+	TxHash       gethcmn.Hash
+	Height       int64
+}
+
+// create2Index records the contracts created by CREATE2 in the blocks committed since the node started
+type create2Index struct {
+	mtx       sync.RWMutex
+	byAddr    map[gethcmn.Address]*Create2Contract
+	contracts []*Create2Contract // a ring buffer
+	next      int
+}
+
+func newCreate2Index() *create2Index {
+	return &create2Index{
+		byAddr: make(map[gethcmn.Address]*Create2Contract),
+	}
+}
+
+// collect finds the successful CREATE2 calls in a committed block
+func (idx *create2Index) collect(blk *modbtypes.Block) {
+	for _, mdbTx := range blk.TxList {
+		tx := &types.Transaction{}
+		if _, err := tx.UnmarshalMsg(mdbTx.Content); err != nil {
+			continue
+		}
+		returns := matchInternalTxReturns(tx.InternalTxCalls, tx.InternalTxReturns)
+		for i, call := range tx.InternalTxCalls {
+			if call.Kind != callKindCreate2 || returns[i] == nil || returns[i].CreateAddress == [20]byte{} {
+				continue
+			}
+			idx.add(&Create2Contract{
+				Address:      returns[i].CreateAddress,
+				Deployer:     call.Sender,
+				InitCodeHash: crypto.Keccak256Hash(call.Input),
+				TxHash:       tx.Hash,
+				Height:       blk.Height,
+			})
+		}
+	}
+}
+
+// matchInternalTxReturns finds the return of each internal call. The returns are recorded in
+// the order of finishing, so a call returns when a following call is not deeper than it.
+func matchInternalTxReturns(calls []types.InternalTxCall, rets []types.InternalTxReturn) []*types.InternalTxReturn {
+	result := make([]*types.InternalTxReturn, len(calls))
+	var stack []int
+	next := 0
+	pop := func() {
+		if next < len(rets) {
+			result[stack[len(stack)-1]] = &rets[next]
+			next++
+		}
+		stack = stack[:len(stack)-1]
+	}
+	for i, call := range calls {
+		for len(stack) > 0 && calls[stack[len(stack)-1]].Depth >= call.Depth {
+			pop()
+		}
+		stack = append(stack, i)
+	}
+	for len(stack) > 0 {
+		pop()
+	}
+	return result
+}
+
+func (idx *create2Index) add(c *Create2Contract) {
+	idx.mtx.Lock()
+	defer idx.mtx.Unlock()
+	if len(idx.contracts) < MaxCreate2IndexSize {
+		idx.contracts = append(idx.contracts, c)
+	} else {
+		old := idx.contracts[idx.next]
+		if idx.byAddr[old.Address] == old {
+			delete(idx.byAddr, old.Address)
+		}
+		idx.contracts[idx.next] = c
+		idx.next = (idx.next + 1) % MaxCreate2IndexSize
+	}
+	// a self-destructed contract can be created again at the same address
+	idx.byAddr[c.Address] = c
+}
+
+func (idx *create2Index) get(addr gethcmn.Address) *Create2Contract {
+	idx.mtx.RLock()
+	defer idx.mtx.RUnlock()
+	return idx.byAddr[addr]
+}
+
+// getByDeployer returns the contracts created by deployer, in the order of creation
+func (idx *create2Index) getByDeployer(deployer gethcmn.Address) []*Create2Contract {
+	idx.mtx.RLock()
+	defer idx.mtx.RUnlock()
+	var result []*Create2Contract
+	for i := 0; i < len(idx.contracts); i++ {
+		c := idx.contracts[(idx.next+i)%len(idx.contracts)]
+		if c.Deployer == deployer {
+			result = append(result, c)
+		}
+	}
+	return result
+}
@@ -0,0 +1,46 @@
+package app
+
+import (
+	"testing"
+
+	gethcmn "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+
+	"github.com/smartbch/moeingevm/types"
+)
+
+func TestMatchInternalTxReturns(t *testing.T) {
+	// 0 -> (1 -> 2), 3
+	calls := []types.InternalTxCall{{Depth: 1}, {Depth: 2}, {Depth: 3}, {Depth: 2}}
+	rets := []types.InternalTxReturn{{GasLeft: 2}, {GasLeft: 1}, {GasLeft: 3}, {GasLeft: 0}}
+	matched := matchInternalTxReturns(calls, rets)
+	for i, ret := range matched {
+		require.Equal(t, int64(i), ret.GasLeft)
+	}
+}
+
+func TestCreate2Index(t *testing.T) {
+	deployer := gethcmn.Address{0x01}
+	created := gethcmn.Address{0x02}
+	initCode := []byte{0x60, 0x00}
+	tx := &types.Transaction{Hash: [32]byte{0x11},
+		InternalTxCalls: []types.InternalTxCall{
+			{Kind: callKindCreate2, Depth: 1, Sender: deployer, Input: initCode},
+			{Kind: callKindCreate2, Depth: 1, Sender: deployer, Input: initCode}, // failed
+		},
+		InternalTxReturns: []types.InternalTxReturn{{CreateAddress: created}, {}},
+	}
+	idx := newCreate2Index()
+	idx.collect(newBlockForTracer(5, tx))
+
+	c := idx.get(created)
+	require.NotNil(t, c)
+	require.Equal(t, deployer, c.Deployer)
+	require.Equal(t, crypto.Keccak256Hash(initCode), c.InitCodeHash)
+	require.Equal(t, gethcmn.Hash(tx.Hash), c.TxHash)
+	require.Equal(t, int64(5), c.Height)
+	require.Len(t, idx.getByDeployer(deployer), 1)
+	require.Len(t, idx.getByDeployer(created), 0)
+	require.Nil(t, idx.get(deployer))
+}
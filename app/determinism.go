@@ -0,0 +1,186 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"time"
+
+	gethcmn "github.com/ethereum/go-ethereum/common"
+	"github.com/holiman/uint256"
+	"github.com/tendermint/tendermint/libs/log"
+
+	"github.com/smartbch/moeingads/store"
+	modbtypes "github.com/smartbch/moeingdb/types"
+	"github.com/smartbch/moeingevm/ebp"
+	"github.com/smartbch/moeingevm/types"
+
+	"github.com/smartbch/smartbch/crosschain"
+)
+
+// Divergence is a difference between the serial and the parallel executions of a block, TxIndex
+// is -1 for the differences of the whole block
+type Divergence struct {
+	TxIndex  int    `json:"txIndex"`
+	TxHash   string `json:"txHash,omitempty"`
+	Field    string `json:"field"`
+	Parallel string `json:"parallel"`
+	Serial   string `json:"serial"`
+}
+
+// DeterminismReport is written when the serial re-execution of a block differs from the parallel one
+type DeterminismReport struct {
+	Height      int64         `json:"height"`
+	Time        int64         `json:"time"`
+	Divergences []*Divergence `json:"divergences"`
+}
+
+// executionResult is what an engine produced for a block
+type executionResult struct {
+	gasUsed   uint64
+	gasRefund uint256.Int
+	gasFee    uint256.Int
+	txs       []*types.Transaction
+}
+
+func engineResult(engine ebp.TxExecutor) *executionResult {
+	r := &executionResult{txs: blockTxs(&modbtypes.Block{TxList: engine.CommittedTxsForMoDB()})}
+	r.gasUsed, r.gasRefund, r.gasFee = engine.GasUsedInfo()
+	return r
+}
+
+// determinismChecker re-executes a fraction of the blocks with a single goroutine, and compares the
+// committed txs and their results with the ones of the parallel execution. The engine commits the
+// same txs with the same results whatever its parallel number is, so a difference means the
+// parallel execution is not deterministic on this node, whose app hash would diverge from the
+// other nodes' sooner or later. The difference is reported before that happens, such that the
+// operator can stop the node and look into it, instead of finding it out from a consensus failure.
+// The state written by the re-execution is discarded, so the differences of the state which do not
+// show in the results are not found.
+type determinismChecker struct {
+	ratio      float64
+	reportPath string // the reports are appended to it as JSON lines
+	halt       bool   // panic on a divergence, such that the node does not commit more blocks
+	logger     log.Logger
+}
+
+// newDeterminismChecker returns nil if ratio is not positive, which disables the check
+func newDeterminismChecker(ratio float64, reportPath string, halt bool, logger log.Logger) *determinismChecker {
+	if ratio <= 0 {
+		return nil
+	}
+	return &determinismChecker{ratio: ratio, reportPath: reportPath, halt: halt, logger: logger}
+}
+
+// sampled returns whether the block at height is re-executed, the sampled blocks are evenly spread
+func (c *determinismChecker) sampled(height int64) bool {
+	if c == nil {
+		return false
+	}
+	return math.Floor(float64(height)*c.ratio) > math.Floor(float64(height-1)*c.ratio)
+}
+
+// check compares the results of the two executions of the block at height. The blocks calling the
+// cross chain contract are not re-executed by the caller, because its executor keeps the infos
+// collected by the watcher in memory, which are consumed by the first execution.
+func (c *determinismChecker) check(height int64, parallel, serial *executionResult) {
+	divergences := compareExecutions(parallel, serial)
+	if len(divergences) == 0 {
+		c.logger.Debug("serial re-execution matches", "height", height, "txs", len(parallel.txs))
+		return
+	}
+	report := &DeterminismReport{Height: height, Time: time.Now().Unix(), Divergences: divergences}
+	if err := c.save(report); err != nil {
+		c.logger.Error("cannot save the determinism report", "error", err.Error())
+	}
+	c.logger.Error("NON-DETERMINISTIC EXECUTION: the serial re-execution differs from the parallel one, "+
+		"the app hash of this node may diverge from the other nodes'",
+		"height", height, "divergences", len(divergences), "first", divergences[0].Field, "report", c.reportPath)
+	if c.halt {
+		panic(fmt.Sprintf("non-deterministic execution at height %d, see %s", height, c.reportPath))
+	}
+}
+
+func (c *determinismChecker) save(report *DeterminismReport) error {
+	if c.reportPath == "" {
+		return nil
+	}
+	bz, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(c.reportPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err = f.Write(append(bz, '\n')); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// reExecuteSerially executes the block again with a single goroutine and checks the results. The
+// parallel execution only writes to app.trunk, so app.root still has the state before the block. It
+// runs before postCommit releases app.mtx, because app.root is written by the next Commit, so the
+// next block is delayed by the time it takes.
+func (app *App) reExecuteSerially(bi *types.BlockInfo) {
+	parallel := engineResult(app.txEngine)
+	if callsCrossChain(parallel) {
+		app.logger.Debug("the block calling the cross chain contract is not re-executed", "height", bi.Number)
+		return
+	}
+	trunk := app.root.GetReadOnlyTrunkStore(app.config.AppConfig.TrunkCacheSize).(*store.TrunkStore)
+	engine := app.newTxEngine(1)
+	engine.SetContext(app.runTxContextOf(trunk))
+	engine.Execute(bi)
+	serial := engineResult(engine)
+	engine.Context().Close(false)
+	trunk.Close(false)
+	app.determinism.check(bi.Number, parallel, serial)
+}
+
+// callsCrossChain returns whether a tx of the result calls the cross chain contract
+func callsCrossChain(r *executionResult) bool {
+	for _, tx := range r.txs {
+		if tx.To == crosschain.CCContractAddress {
+			return true
+		}
+	}
+	return false
+}
+
+// compareExecutions lists the differences between the parallel and the serial results
+func compareExecutions(parallel, serial *executionResult) []*Divergence {
+	var divergences []*Divergence
+	diff := func(txIndex int, txHash, field, p, s string) {
+		if p != s {
+			divergences = append(divergences, &Divergence{TxIndex: txIndex, TxHash: txHash, Field: field, Parallel: p, Serial: s})
+		}
+	}
+	diff(-1, "", "txCount", strconv.Itoa(len(parallel.txs)), strconv.Itoa(len(serial.txs)))
+	diff(-1, "", "gasUsed", strconv.FormatUint(parallel.gasUsed, 10), strconv.FormatUint(serial.gasUsed, 10))
+	diff(-1, "", "gasRefund", parallel.gasRefund.ToBig().String(), serial.gasRefund.ToBig().String())
+	diff(-1, "", "gasFee", parallel.gasFee.ToBig().String(), serial.gasFee.ToBig().String())
+	n := len(parallel.txs)
+	if len(serial.txs) < n {
+		n = len(serial.txs)
+	}
+	for i := 0; i < n; i++ {
+		p, s := parallel.txs[i], serial.txs[i]
+		hash := gethcmn.Hash(p.Hash).Hex()
+		if p.Hash != s.Hash {
+			// the txs are committed in a different order, the results of the others are meaningless
+			diff(i, hash, "txHash", hash, gethcmn.Hash(s.Hash).Hex())
+			break
+		}
+		diff(i, hash, "status", strconv.FormatUint(uint64(p.Status), 10), strconv.FormatUint(uint64(s.Status), 10))
+		diff(i, hash, "gasUsed", strconv.FormatUint(p.GasUsed, 10), strconv.FormatUint(s.GasUsed, 10))
+		diff(i, hash, "contractAddress", gethcmn.Address(p.ContractAddress).Hex(), gethcmn.Address(s.ContractAddress).Hex())
+		diff(i, hash, "logs", logsHash(p.Logs).Hex(), logsHash(s.Logs).Hex())
+		diff(i, hash, "outData", gethcmn.Bytes2Hex(p.OutData), gethcmn.Bytes2Hex(s.OutData))
+	}
+	return divergences
+}
@@ -0,0 +1,75 @@
+package app
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/libs/log"
+
+	"github.com/smartbch/moeingevm/types"
+)
+
+func TestDeterminismSampled(t *testing.T) {
+	require.False(t, newDeterminismChecker(0, "", false, log.NewNopLogger()).sampled(1))
+	c := newDeterminismChecker(0.25, "", false, log.NewNopLogger())
+	var heights []int64
+	for h := int64(1); h <= 12; h++ {
+		if c.sampled(h) {
+			heights = append(heights, h)
+		}
+	}
+	require.Equal(t, []int64{4, 8, 12}, heights)
+	c = newDeterminismChecker(1, "", false, log.NewNopLogger())
+	require.True(t, c.sampled(1))
+	require.True(t, c.sampled(2))
+}
+
+func TestCompareExecutions(t *testing.T) {
+	newResult := func() *executionResult {
+		r := &executionResult{gasUsed: 42000}
+		for i := 0; i < 2; i++ {
+			tx := &types.Transaction{GasUsed: 21000, Status: 1}
+			tx.Hash[0] = byte(i + 1)
+			r.txs = append(r.txs, tx)
+		}
+		return r
+	}
+	parallel, serial := newResult(), newResult()
+	require.Empty(t, compareExecutions(parallel, serial))
+
+	serial.txs[1].Status = 0
+	serial.txs[1].Logs = []types.Log{{Data: []byte{1}}}
+	divergences := compareExecutions(parallel, serial)
+	require.Len(t, divergences, 2)
+	require.Equal(t, "status", divergences[0].Field)
+	require.Equal(t, 1, divergences[0].TxIndex)
+	require.Equal(t, "1", divergences[0].Parallel)
+	require.Equal(t, "0", divergences[0].Serial)
+	require.Equal(t, "logs", divergences[1].Field)
+
+	// the results after the txs committed in a different order are not compared
+	serial.txs[0], serial.txs[1] = serial.txs[1], serial.txs[0]
+	divergences = compareExecutions(parallel, serial)
+	require.Len(t, divergences, 1)
+	require.Equal(t, "txHash", divergences[0].Field)
+
+	serial = newResult()
+	serial.gasUsed = 21000
+	serial.txs = serial.txs[:1]
+	divergences = compareExecutions(parallel, serial)
+	require.Len(t, divergences, 2)
+	require.Equal(t, "txCount", divergences[0].Field)
+	require.Equal(t, "gasUsed", divergences[1].Field)
+	require.Equal(t, -1, divergences[1].TxIndex)
+}
+
+func TestDeterminismCheck(t *testing.T) {
+	reportPath := filepath.Join(t.TempDir(), "reports.jsonl")
+	c := newDeterminismChecker(1, reportPath, true, log.NewNopLogger())
+	parallel := &executionResult{gasUsed: 21000}
+	c.check(1, parallel, &executionResult{gasUsed: 21000})
+	require.NoFileExists(t, reportPath)
+	require.Panics(t, func() { c.check(2, parallel, &executionResult{gasUsed: 1}) })
+	require.FileExists(t, reportPath)
+}
@@ -0,0 +1,86 @@
+package app
+
+import (
+	"errors"
+	"sync"
+)
+
+var (
+	ErrNotDevMode           = errors.New("only available on the dev chain")
+	ErrNegativeTimeIncrease = errors.New("time can not be decreased")
+	ErrTimestampTooSmall    = errors.New("the timestamp must be greater than the one of the latest block")
+)
+
+// devClock shifts the block timestamps of the dev chain, for the tests depending on time to be
+// run without waiting. The Tendermint header times are not changed, only the EVM-visible
+// block.timestamp is. The shift is kept in memory, so it is lost when the node restarts.
+type devClock struct {
+	mtx           sync.Mutex
+	offset        int64 // seconds added to the Tendermint header time
+	nextTimestamp int64 // the timestamp of the next block, zero if not set
+	lastTimestamp int64
+}
+
+// blockTimestamp returns block.timestamp of the block whose header time is headerTimestamp, it
+// never decreases from prevTimestamp
+func (c *devClock) blockTimestamp(headerTimestamp, prevTimestamp int64) int64 {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if c.nextTimestamp != 0 {
+		// the following blocks keep the same offset, like hardhat does
+		c.offset = c.nextTimestamp - headerTimestamp
+		c.nextTimestamp = 0
+	}
+	ts := headerTimestamp + c.offset
+	if ts < prevTimestamp {
+		ts = prevTimestamp
+	}
+	c.lastTimestamp = ts
+	return ts
+}
+
+// increaseTime shifts the timestamps of the following blocks by seconds more, and returns the
+// total shift
+func (c *devClock) increaseTime(seconds int64) (int64, error) {
+	if seconds < 0 {
+		return 0, ErrNegativeTimeIncrease
+	}
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.offset += seconds
+	return c.offset, nil
+}
+
+func (c *devClock) setNextBlockTimestamp(timestamp int64) error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if timestamp <= c.lastTimestamp {
+		return ErrTimestampTooSmall
+	}
+	c.nextTimestamp = timestamp
+	return nil
+}
+
+// EnableDevClock lets the block timestamps be changed with IncreaseTime and SetNextBlockTimestamp,
+// it must only be called on the dev chain, before the node starts
+func (app *App) EnableDevClock() {
+	app.devClock = &devClock{}
+}
+
+func (app *App) IsDevMode() bool {
+	return app.devClock != nil
+}
+
+func (app *App) IncreaseTime(seconds int64) (int64, error) {
+	if app.devClock == nil {
+		return 0, ErrNotDevMode
+	}
+	return app.devClock.increaseTime(seconds)
+}
+
+func (app *App) SetNextBlockTimestamp(timestamp int64) error {
+	if app.devClock == nil {
+		return ErrNotDevMode
+	}
+	return app.devClock.setNextBlockTimestamp(timestamp)
+}
@@ -0,0 +1,43 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDevClock(t *testing.T) {
+	c := &devClock{}
+	require.Equal(t, int64(100), c.blockTimestamp(100, 99))
+
+	_, err := c.increaseTime(-1)
+	require.Equal(t, ErrNegativeTimeIncrease, err)
+	total, err := c.increaseTime(3600)
+	require.NoError(t, err)
+	require.Equal(t, int64(3600), total)
+	total, _ = c.increaseTime(60)
+	require.Equal(t, int64(3660), total)
+	require.Equal(t, int64(3761), c.blockTimestamp(101, 100))
+
+	require.Equal(t, ErrTimestampTooSmall, c.setNextBlockTimestamp(3761))
+	require.NoError(t, c.setNextBlockTimestamp(5000))
+	require.Equal(t, int64(5000), c.blockTimestamp(102, 3761))
+	// the offset is kept for the following blocks
+	require.Equal(t, int64(5003), c.blockTimestamp(105, 5000))
+
+	// never decreases
+	require.NoError(t, c.setNextBlockTimestamp(5004))
+	require.Equal(t, int64(5004), c.blockTimestamp(200, 5003))
+	require.Equal(t, int64(5004), c.blockTimestamp(199, 5004))
+}
+
+func TestDevClockNotEnabled(t *testing.T) {
+	app := &App{}
+	require.False(t, app.IsDevMode())
+	_, err := app.IncreaseTime(10)
+	require.Equal(t, ErrNotDevMode, err)
+	require.Equal(t, ErrNotDevMode, app.SetNextBlockTimestamp(10))
+	app.EnableDevClock()
+	require.True(t, app.IsDevMode())
+	require.NoError(t, app.SetNextBlockTimestamp(10))
+}
@@ -0,0 +1,42 @@
+package app
+
+import (
+	"fmt"
+
+	"github.com/smartbch/moeingevm/types"
+
+	"github.com/smartbch/smartbch/param"
+	"github.com/smartbch/smartbch/staking"
+	stakingtypes "github.com/smartbch/smartbch/staking/types"
+)
+
+// checkEpochConsistency makes sure the last epoch recorded by the staking module is the one ending at
+// lastEpochEndHeight, from which the watcher resumes scanning the mainnet blocks. Otherwise the watcher
+// would build the following epochs from wrong block ranges, and the validator sets would silently diverge.
+func checkEpochConsistency(ctx *types.Context, info stakingtypes.StakingInfo, lastEpochEndHeight, numBlocksInEpoch int64) error {
+	if info.CurrEpochNum == 0 {
+		return nil
+	}
+	if param.IsAmber && ctx.IsXHedgeFork() {
+		return nil // the fake epochs after XHedgeFork do not follow the mainnet heights
+	}
+	if _, ok := staking.LoadEpoch(ctx, info.CurrEpochNum+1); ok {
+		return fmt.Errorf("epoch %d is stored while the staking module's current epoch is %d, "+
+			"the state may be written by a newer or modified binary; restore the data directory from a "+
+			"snapshot taken by an official release, or resync from genesis", info.CurrEpochNum+1, info.CurrEpochNum)
+	}
+	epoch, ok := staking.LoadEpoch(ctx, info.CurrEpochNum)
+	if !ok {
+		return fmt.Errorf("the staking module's current epoch %d is not stored, the state is corrupted; "+
+			"restore the data directory from a snapshot, or resync from genesis", info.CurrEpochNum)
+	}
+	expectedStart := lastEpochEndHeight - numBlocksInEpoch + 1
+	if epoch.StartHeight != expectedStart {
+		return fmt.Errorf("epoch %d starts at mainnet height %d, but the watcher expects %d "+
+			"(genesis mainnet height %d + %d blocks * %d epochs); check that --mainnet-genesis-height "+
+			"and the binary (mainnet, amber or test build) match the ones used to create the data directory",
+			info.CurrEpochNum, epoch.StartHeight, expectedStart,
+			info.GenesisMainnetBlockHeight, numBlocksInEpoch, info.CurrEpochNum)
+	}
+	return nil
+}
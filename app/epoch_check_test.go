@@ -0,0 +1,30 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/smartbch/moeingads/store"
+	"github.com/smartbch/moeingads/store/rabbit"
+	"github.com/smartbch/moeingevm/types"
+	"github.com/stretchr/testify/require"
+
+	"github.com/smartbch/smartbch/staking"
+	stakingtypes "github.com/smartbch/smartbch/staking/types"
+)
+
+func TestCheckEpochConsistency(t *testing.T) {
+	r := rabbit.NewRabbitStore(store.NewMockRootStore())
+	ctx := types.NewContext(&r, nil)
+	info := stakingtypes.StakingInfo{GenesisMainnetBlockHeight: 1000}
+	require.NoError(t, checkEpochConsistency(ctx, info, 1000, 100))
+
+	info.CurrEpochNum = 2
+	require.Error(t, checkEpochConsistency(ctx, info, 1200, 100)) // epoch 2 is not stored
+
+	staking.SaveEpoch(ctx, &stakingtypes.Epoch{Number: 2, StartHeight: 1101})
+	require.NoError(t, checkEpochConsistency(ctx, info, 1200, 100))
+	require.Error(t, checkEpochConsistency(ctx, info, 1300, 100)) // wrong genesis height or epoch length
+
+	staking.SaveEpoch(ctx, &stakingtypes.Epoch{Number: 3, StartHeight: 1201})
+	require.Error(t, checkEpochConsistency(ctx, info, 1200, 100))
+}
@@ -0,0 +1,77 @@
+package app
+
+import (
+	"reflect"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var droppedEvents = func() *prometheus.CounterVec {
+	c := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "smartbch",
+		Subsystem: "app",
+		Name:      "dropped_events_total",
+		Help:      "The number of events dropped because the channel of a subscriber was full.",
+	}, []string{"feed"})
+	prometheus.MustRegister(c)
+	return c
+}()
+
+// droppingFeed is like event.Feed, but Send never blocks: it is called in Commit, which must not
+// wait for slow subscribers. An event which does not fit in the buffer of a subscriber's channel is
+// dropped for that subscriber and counted, so the subscribers should use buffered channels.
+type droppingFeed struct {
+	name    string
+	mtx     sync.Mutex
+	nextId  int
+	chans   map[int]reflect.Value
+	dropped uint64
+}
+
+func newDroppingFeed(name string) *droppingFeed {
+	return &droppingFeed{name: name, chans: make(map[int]reflect.Value)}
+}
+
+func (f *droppingFeed) Subscribe(channel interface{}) event.Subscription {
+	ch := reflect.ValueOf(channel)
+	if ch.Kind() != reflect.Chan || ch.Type().ChanDir()&reflect.SendDir == 0 {
+		panic("droppingFeed: Subscribe argument must be a sendable channel")
+	}
+	f.mtx.Lock()
+	id := f.nextId
+	f.nextId++
+	f.chans[id] = ch
+	f.mtx.Unlock()
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		<-quit
+		f.mtx.Lock()
+		delete(f.chans, id)
+		f.mtx.Unlock()
+		return nil
+	})
+}
+
+// Send delivers value to the subscribers whose channels are not full, and returns the number of them
+func (f *droppingFeed) Send(value interface{}) (nsent int) {
+	v := reflect.ValueOf(value)
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	for _, ch := range f.chans {
+		if ch.TrySend(v) {
+			nsent++
+		} else {
+			f.dropped++
+			droppedEvents.WithLabelValues(f.name).Inc()
+		}
+	}
+	return
+}
+
+// Dropped returns the number of events dropped since the node started
+func (f *droppingFeed) Dropped() uint64 {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	return f.dropped
+}
@@ -0,0 +1,31 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDroppingFeed(t *testing.T) {
+	feed := newDroppingFeed("test")
+	ch1 := make(chan int, 1)
+	ch2 := make(chan int, 2)
+	sub1 := feed.Subscribe(ch1)
+	sub2 := feed.Subscribe(ch2)
+
+	require.Equal(t, 2, feed.Send(1))
+	require.Equal(t, 1, feed.Send(2)) // ch1 is full, the event is dropped instead of blocking
+	require.EqualValues(t, 1, feed.Dropped())
+	require.Equal(t, 1, <-ch1)
+	require.Equal(t, 1, <-ch2)
+	require.Equal(t, 2, <-ch2)
+
+	sub1.Unsubscribe()
+	<-sub1.Err()
+	require.Equal(t, 1, feed.Send(3))
+	require.Equal(t, 3, <-ch2)
+	sub2.Unsubscribe()
+	require.Equal(t, 0, feed.Send(4))
+
+	require.Panics(t, func() { feed.Subscribe(make(<-chan int)) })
+}
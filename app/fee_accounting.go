@@ -0,0 +1,132 @@
+package app
+
+import (
+	"sync"
+
+	gethcmn "github.com/ethereum/go-ethereum/common"
+	"github.com/holiman/uint256"
+	modbtypes "github.com/smartbch/moeingdb/types"
+	"github.com/smartbch/moeingevm/types"
+
+	"github.com/smartbch/smartbch/staking"
+)
+
+// The max number of blocks whose fee accounting records are kept in memory, the oldest ones are dropped first
+const MaxFeeAccountingBlocks = 20000
+
+// TxFeeRecord is the gas fee paid by a transaction. The sender prepays gas*gasPrice, and the
+// unused part is refunded after execution.
+type TxFeeRecord struct {
+	Height   int64
+	TxHash   gethcmn.Hash
+	From     gethcmn.Address
+	GasUsed  uint64
+	GasPrice *uint256.Int
+	Fee      *uint256.Int // gasUsed*gasPrice
+	Refund   *uint256.Int // (gas-gasUsed)*gasPrice
+	// half of the fee is burned, the other half goes to the validators. The exact shares are only
+	// known for the whole block, because of the rounding.
+	BurnedShare *uint256.Int
+}
+
+// BlockFeeRecord is the fee accounting of a block. The fee of a block is distributed when the
+// next block is committed, so Distribution is the one made in the block at Height+1, and it may
+// include the coins sent to the system account by other means.
+type BlockFeeRecord struct {
+	Height       int64
+	Txs          []*TxFeeRecord
+	TotalFee     *uint256.Int
+	TotalRefund  *uint256.Int
+	Distribution *staking.FeeDistribution // nil if nothing was distributed
+}
+
+// feeAccounting records the fees of the blocks committed since the node started
+type feeAccounting struct {
+	mtx    sync.RWMutex
+	blocks []*BlockFeeRecord // a ring buffer
+	next   int
+	byTx   map[gethcmn.Hash]*TxFeeRecord
+}
+
+func newFeeAccounting() *feeAccounting {
+	return &feeAccounting{
+		byTx: make(map[gethcmn.Hash]*TxFeeRecord),
+	}
+}
+
+// collect builds the records of a committed block, with the distribution of its fee
+func (fa *feeAccounting) collect(blk *modbtypes.Block, dist *staking.FeeDistribution) {
+	rec := &BlockFeeRecord{
+		Height:       blk.Height,
+		TotalFee:     uint256.NewInt(0),
+		TotalRefund:  uint256.NewInt(0),
+		Distribution: dist,
+	}
+	for _, mdbTx := range blk.TxList {
+		tx := &types.Transaction{}
+		if _, err := tx.UnmarshalMsg(mdbTx.Content); err != nil {
+			continue
+		}
+		txRec := buildTxFeeRecord(blk.Height, tx)
+		rec.Txs = append(rec.Txs, txRec)
+		rec.TotalFee.Add(rec.TotalFee, txRec.Fee)
+		rec.TotalRefund.Add(rec.TotalRefund, txRec.Refund)
+	}
+	fa.add(rec)
+}
+
+func buildTxFeeRecord(height int64, tx *types.Transaction) *TxFeeRecord {
+	gasPrice := uint256.NewInt(0).SetBytes32(tx.GasPrice[:])
+	fee := uint256.NewInt(0).Mul(gasPrice, uint256.NewInt(tx.GasUsed))
+	refund := uint256.NewInt(0)
+	if tx.Gas > tx.GasUsed {
+		refund.Mul(gasPrice, uint256.NewInt(tx.Gas-tx.GasUsed))
+	}
+	return &TxFeeRecord{
+		Height:      height,
+		TxHash:      tx.Hash,
+		From:        tx.From,
+		GasUsed:     tx.GasUsed,
+		GasPrice:    gasPrice,
+		Fee:         fee,
+		Refund:      refund,
+		BurnedShare: uint256.NewInt(0).Rsh(fee, 1),
+	}
+}
+
+func (fa *feeAccounting) add(rec *BlockFeeRecord) {
+	fa.mtx.Lock()
+	defer fa.mtx.Unlock()
+	if len(fa.blocks) < MaxFeeAccountingBlocks {
+		fa.blocks = append(fa.blocks, rec)
+	} else {
+		for _, tx := range fa.blocks[fa.next].Txs {
+			delete(fa.byTx, tx.TxHash)
+		}
+		fa.blocks[fa.next] = rec
+		fa.next = (fa.next + 1) % MaxFeeAccountingBlocks
+	}
+	for _, tx := range rec.Txs {
+		fa.byTx[tx.TxHash] = tx
+	}
+}
+
+func (fa *feeAccounting) getTx(txHash gethcmn.Hash) *TxFeeRecord {
+	fa.mtx.RLock()
+	defer fa.mtx.RUnlock()
+	return fa.byTx[txHash]
+}
+
+// getBlocks returns the records of the kept blocks in [startHeight, endHeight], in the order of height
+func (fa *feeAccounting) getBlocks(startHeight, endHeight int64) []*BlockFeeRecord {
+	fa.mtx.RLock()
+	defer fa.mtx.RUnlock()
+	var result []*BlockFeeRecord
+	for i := 0; i < len(fa.blocks); i++ {
+		rec := fa.blocks[(fa.next+i)%len(fa.blocks)]
+		if rec.Height >= startHeight && rec.Height <= endHeight {
+			result = append(result, rec)
+		}
+	}
+	return result
+}
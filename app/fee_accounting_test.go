@@ -0,0 +1,41 @@
+package app
+
+import (
+	"testing"
+
+	gethcmn "github.com/ethereum/go-ethereum/common"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/require"
+
+	"github.com/smartbch/moeingevm/types"
+	"github.com/smartbch/smartbch/staking"
+)
+
+func TestFeeAccounting(t *testing.T) {
+	gasPrice := uint256.NewInt(10).Bytes32()
+	tx1 := &types.Transaction{Hash: [32]byte{0x11}, From: [20]byte{0x01}, GasPrice: gasPrice, Gas: 30000, GasUsed: 21000}
+	tx2 := &types.Transaction{Hash: [32]byte{0x12}, From: [20]byte{0x02}, GasPrice: gasPrice, Gas: 50001, GasUsed: 50001}
+	dist := &staking.FeeDistribution{Collected: uint256.NewInt(710010)}
+	fa := newFeeAccounting()
+	fa.collect(newBlockForTracer(5, tx1, tx2), dist)
+	fa.collect(newBlockForTracer(6), nil)
+
+	rec := fa.getTx(tx1.Hash)
+	require.NotNil(t, rec)
+	require.Equal(t, int64(5), rec.Height)
+	require.Equal(t, uint64(210000), rec.Fee.Uint64())
+	require.Equal(t, uint64(90000), rec.Refund.Uint64())
+	require.Equal(t, uint64(105000), rec.BurnedShare.Uint64())
+	require.Equal(t, uint64(0), fa.getTx(tx2.Hash).Refund.Uint64())
+	require.Equal(t, uint64(250005), fa.getTx(tx2.Hash).BurnedShare.Uint64())
+	require.Nil(t, fa.getTx(gethcmn.Hash{0x13}))
+
+	blocks := fa.getBlocks(5, 10)
+	require.Len(t, blocks, 2)
+	require.Len(t, blocks[0].Txs, 2)
+	require.Equal(t, uint64(710010), blocks[0].TotalFee.Uint64())
+	require.Equal(t, uint64(90000), blocks[0].TotalRefund.Uint64())
+	require.Same(t, dist, blocks[0].Distribution)
+	require.Nil(t, blocks[1].Distribution)
+	require.Len(t, fa.getBlocks(6, 6), 1)
+}
@@ -0,0 +1,55 @@
+package app
+
+import (
+	"bytes"
+	"sort"
+	"sync"
+
+	gethcmn "github.com/ethereum/go-ethereum/common"
+	"github.com/holiman/uint256"
+)
+
+// The max number of blocks whose gas grants are kept in memory, the oldest ones are dropped first
+const MaxGasGrantBlocks = 20000
+
+// GasGrant is a top-up of an elected cc operator or monitor from the gas grant pool. It is made
+// in Commit instead of by a tx, so it has no log, and it is returned in the block summary instead.
+type GasGrant struct {
+	Address gethcmn.Address
+	Amount  *uint256.Int
+}
+
+// gasGrants records the gas grants of the blocks committed since the node started
+type gasGrants struct {
+	mtx      sync.RWMutex
+	byHeight map[int64][]*GasGrant
+}
+
+func newGasGrants() *gasGrants {
+	return &gasGrants{
+		byHeight: make(map[int64][]*GasGrant),
+	}
+}
+
+// record is called for every block, so the records older than MaxGasGrantBlocks are dropped one by one
+func (g *gasGrants) record(height int64, granted map[[20]byte]*uint256.Int) {
+	grants := make([]*GasGrant, 0, len(granted))
+	for addr, amount := range granted {
+		grants = append(grants, &GasGrant{Address: addr, Amount: amount})
+	}
+	sort.Slice(grants, func(i, j int) bool {
+		return bytes.Compare(grants[i].Address[:], grants[j].Address[:]) < 0
+	})
+	g.mtx.Lock()
+	defer g.mtx.Unlock()
+	delete(g.byHeight, height-MaxGasGrantBlocks)
+	if len(grants) != 0 {
+		g.byHeight[height] = grants
+	}
+}
+
+func (g *gasGrants) get(height int64) []*GasGrant {
+	g.mtx.RLock()
+	defer g.mtx.RUnlock()
+	return g.byHeight[height]
+}
@@ -0,0 +1,25 @@
+package app
+
+import (
+	"testing"
+
+	gethcmn "github.com/ethereum/go-ethereum/common"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGasGrants(t *testing.T) {
+	g := newGasGrants()
+	a, b := [20]byte{0x01}, [20]byte{0x02}
+	g.record(10, map[[20]byte]*uint256.Int{b: uint256.NewInt(2), a: uint256.NewInt(1)})
+	g.record(11, nil)
+	grants := g.get(10)
+	require.Len(t, grants, 2)
+	require.Equal(t, gethcmn.Address(a), grants[0].Address)
+	require.EqualValues(t, 1, grants[0].Amount.Uint64())
+	require.Equal(t, gethcmn.Address(b), grants[1].Address)
+	require.Nil(t, g.get(11))
+
+	g.record(10+MaxGasGrantBlocks, nil)
+	require.Nil(t, g.get(10))
+}
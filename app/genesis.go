@@ -1,11 +1,18 @@
 package app
 
 import (
+	"bytes"
+	"fmt"
+	"math"
+
 	gethcmn "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	gethcore "github.com/ethereum/go-ethereum/core"
+	gethcrypto "github.com/ethereum/go-ethereum/crypto"
 	"github.com/tendermint/tendermint/crypto"
 	"github.com/tendermint/tendermint/crypto/ed25519"
 
+	"github.com/smartbch/smartbch/param"
 	stakingtypes "github.com/smartbch/smartbch/staking/types"
 )
 
@@ -20,9 +27,89 @@ type Validator struct {
 	MinerAddress crypto.Address  `json:"miner_address"`
 }
 
+const (
+	// EIP-170
+	MaxPredeployCodeSize = 24576
+	// the sequences of the system contracts count down from math.MaxUint64, and the ones of the
+	// contracts created by txs count up from 0, the predeploys take the ones below this
+	predeploySequenceStart uint64 = math.MaxUint64 - 1000
+)
+
 type GenesisData struct {
 	Validators []*Validator          `json:"validators"`
 	Alloc      gethcore.GenesisAlloc `json:"alloc"`
+	Predeploys []*Predeploy          `json:"predeploys,omitempty"`
+	// the BCH network followed by the watchers, see param.BchNetwork, empty means param.CcBchNetwork
+	BchNetwork string `json:"bch_network,omitempty"`
+}
+
+// Predeploy is a contract which exists from block 0, such as multicall, a create2 deployer or WBCH
+type Predeploy struct {
+	Name    string                        `json:"name"`
+	Address gethcmn.Address               `json:"address"`
+	Code    hexutil.Bytes                 `json:"code"` // the runtime bytecode, not the creation code
+	Storage map[gethcmn.Hash]gethcmn.Hash `json:"storage,omitempty"`
+	Balance *hexutil.Big                  `json:"balance,omitempty"`
+}
+
+func predeploySequence(i int) uint64 {
+	return predeploySequenceStart - uint64(i)
+}
+
+// predeployBytecodeInfo returns the stored form of the runtime bytecode, the same as the one written
+// by ebp for the created contracts: a zero version byte, its hash and itself
+func predeployBytecodeInfo(code []byte) []byte {
+	info := append([]byte{0}, gethcrypto.Keccak256(code)...)
+	return append(info, code...)
+}
+
+// CheckBchNetwork refuses the genesis whose BCH network is not the one selected from genesis.json
+// when the node started
+func (g GenesisData) CheckBchNetwork() error {
+	n, err := param.GetBchNetwork(g.BchNetwork)
+	if err != nil {
+		return err
+	}
+	if curr := param.CurrBchNetwork(); n.Name != curr.Name {
+		return fmt.Errorf("bch_network of genesis is %s, but %s is selected", n.Name, curr.Name)
+	}
+	return nil
+}
+
+// ValidatePredeploys checks that the predeploys can be created at genesis
+func (g GenesisData) ValidatePredeploys() error {
+	seen := make(map[gethcmn.Address]bool, len(g.Predeploys))
+	for _, p := range g.Predeploys {
+		if p == nil {
+			return fmt.Errorf("empty predeploy")
+		}
+		name := fmt.Sprintf("predeploy %s (%s)", p.Name, p.Address.Hex())
+		// the precompiled and system contracts, such as staking at 0x2710, are below 0x10000
+		if bytes.Equal(p.Address[:18], make([]byte, 18)) {
+			return fmt.Errorf("%s: the address is reserved for the precompiled and system contracts", name)
+		}
+		if seen[p.Address] {
+			return fmt.Errorf("%s: duplicated address", name)
+		}
+		seen[p.Address] = true
+		if _, ok := g.Alloc[p.Address]; ok {
+			return fmt.Errorf("%s: the address is also in alloc, set its balance in the predeploy", name)
+		}
+		if len(p.Code) == 0 {
+			return fmt.Errorf("%s: empty code", name)
+		}
+		if len(p.Code) > MaxPredeployCodeSize {
+			return fmt.Errorf("%s: code size %d exceeds %d", name, len(p.Code), MaxPredeployCodeSize)
+		}
+		// EIP-3541
+		if p.Code[0] == 0xef {
+			return fmt.Errorf("%s: code starts with 0xEF", name)
+		}
+		if p.Balance != nil && p.Balance.ToInt().Sign() < 0 {
+			return fmt.Errorf("%s: negative balance", name)
+		}
+	}
+	return nil
 }
 
 func (g GenesisData) StakingValidators() []*stakingtypes.Validator {
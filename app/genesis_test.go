@@ -0,0 +1,62 @@
+package app
+
+import (
+	"math/big"
+	"testing"
+
+	gethcmn "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	gethcore "github.com/ethereum/go-ethereum/core"
+	gethcrypto "github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+
+	"github.com/smartbch/moeingevm/types"
+
+	"github.com/smartbch/smartbch/param"
+)
+
+func TestValidatePredeploys(t *testing.T) {
+	multicall := gethcmn.HexToAddress("0xcA11bde05977b3631167028862bE2a173976CA11")
+	g := GenesisData{
+		Predeploys: []*Predeploy{{Name: "multicall", Address: multicall, Code: hexutil.Bytes{0x60, 0x80}}},
+	}
+	require.NoError(t, g.ValidatePredeploys())
+
+	g.Alloc = gethcore.GenesisAlloc{multicall: {Balance: big.NewInt(1)}}
+	require.Error(t, g.ValidatePredeploys())
+	g.Alloc = nil
+
+	invalid := []*Predeploy{
+		{Name: "staking", Address: gethcmn.HexToAddress("0x2710"), Code: hexutil.Bytes{0x60}},
+		{Name: "dup", Address: multicall, Code: hexutil.Bytes{0x60}},
+		{Name: "empty", Address: gethcmn.HexToAddress("0x0100000000000000000000000000000000000001")},
+		{Name: "ef", Address: gethcmn.HexToAddress("0x0100000000000000000000000000000000000002"), Code: hexutil.Bytes{0xef}},
+		{Name: "big", Address: gethcmn.HexToAddress("0x0100000000000000000000000000000000000003"), Code: make([]byte, MaxPredeployCodeSize+1)},
+	}
+	for _, p := range invalid {
+		g.Predeploys = []*Predeploy{g.Predeploys[0], p}
+		require.Error(t, g.ValidatePredeploys(), p.Name)
+	}
+}
+
+func TestGenesisBchNetwork(t *testing.T) {
+	require.NoError(t, GenesisData{}.CheckBchNetwork())
+	require.NoError(t, GenesisData{BchNetwork: param.CcBchNetwork}.CheckBchNetwork())
+	require.Error(t, GenesisData{BchNetwork: param.RegtestBchNetwork}.CheckBchNetwork())
+	require.Error(t, GenesisData{BchNetwork: "fakenet"}.CheckBchNetwork())
+
+	require.NoError(t, param.SelectBchNetwork(param.RegtestBchNetwork))
+	defer func() { require.NoError(t, param.SelectBchNetwork("")) }()
+	require.NoError(t, GenesisData{BchNetwork: param.RegtestBchNetwork}.CheckBchNetwork())
+	require.Error(t, GenesisData{}.CheckBchNetwork())
+}
+
+func TestPredeployBytecodeInfo(t *testing.T) {
+	code := []byte{0x12, 0x34}
+	info := types.NewBytecodeInfo(predeployBytecodeInfo(code))
+	require.Len(t, info.Bytes(), 35)
+	require.Equal(t, byte(0), info.Bytes()[0])
+	require.Equal(t, gethcrypto.Keccak256(code), info.CodeHashSlice())
+	require.Equal(t, code, info.BytecodeSlice())
+	require.NotEqual(t, predeploySequence(0), predeploySequence(1))
+}
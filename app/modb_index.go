@@ -0,0 +1,97 @@
+package app
+
+import (
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+
+	modbtypes "github.com/smartbch/moeingdb/types"
+	"github.com/smartbch/moeingevm/types"
+
+	"github.com/smartbch/smartbch/param"
+)
+
+// withoutUnindexed returns a copy of blk whose fields for the disabled indexes are cleared, so
+// moeingdb does not maintain these indexes. The contents of transactions are left untouched, so
+// the transactions, receipts and logs can still be read in full. blk is returned directly if all
+// the indexes are enabled.
+func withoutUnindexed(blk *modbtypes.Block, indexes param.ModbIndexes) *modbtypes.Block {
+	if indexes.IsFull() {
+		return blk
+	}
+	result := *blk
+	result.TxList = make([]modbtypes.Tx, len(blk.TxList))
+	for i, tx := range blk.TxList {
+		if !indexes.TxFrom {
+			tx.SrcAddr = [20]byte{}
+		}
+		if !indexes.TxTo {
+			tx.DstAddr = [20]byte{}
+		}
+		logs := make([]modbtypes.Log, len(tx.LogList))
+		for j, log := range tx.LogList {
+			if len(log.Topics) > indexes.LogTopics {
+				log.Topics = log.Topics[:indexes.LogTopics]
+			}
+			logs[j] = log
+		}
+		tx.LogList = logs
+		result.TxList[i] = tx
+	}
+	return &result
+}
+
+// estimateIndexEntries returns the number of index entries added by a typical transaction, which
+// sends a token and emits one log with 3 topics, when indexes or all the indexes are enabled.
+// It is logged at startup to show how much indexing work is shed.
+func estimateIndexEntries(indexes param.ModbIndexes) (entries, fullEntries int) {
+	const typicalTopics = 3
+	entries = 2 // the tx hash and the log address
+	if indexes.TxFrom {
+		entries++
+	}
+	if indexes.TxTo {
+		entries++
+	}
+	if indexes.LogTopics < typicalTopics {
+		entries += indexes.LogTopics
+	} else {
+		entries += typicalTopics
+	}
+	return entries, 2 + 2 + typicalTopics
+}
+
+// RebuildMdbBlock returns the block added to moeingdb when block and its transactions were
+// committed, with the logs bloom recomputed from the logs. It is used to re-index the blocks whose
+// index entries are found to be corrupted.
+func RebuildMdbBlock(block *types.Block, txs []*types.Transaction, indexes param.ModbIndexes) (*modbtypes.Block, error) {
+	var bloom gethtypes.Bloom
+	txList := make([]modbtypes.Tx, len(txs))
+	for i, tx := range txs {
+		content, err := tx.MarshalMsg(nil)
+		if err != nil {
+			return nil, err
+		}
+		logs := make([]modbtypes.Log, len(tx.Logs))
+		for j, log := range tx.Logs {
+			logs[j] = modbtypes.Log{Address: log.Address, Topics: log.Topics}
+			bloom.Add(log.Address[:])
+			for _, topic := range log.Topics {
+				bloom.Add(topic[:])
+			}
+		}
+		txList[i] = modbtypes.Tx{HashId: tx.Hash, Content: content, LogList: logs}
+		copy(txList[i].SrcAddr[:], tx.From[:])
+		copy(txList[i].DstAddr[:], tx.To[:])
+	}
+	blk := *block
+	blk.LogsBloom = bloom
+	blockInfo, err := blk.MarshalMsg(nil)
+	if err != nil {
+		return nil, err
+	}
+	return withoutUnindexed(&modbtypes.Block{
+		Height:    blk.Number,
+		BlockHash: blk.Hash,
+		BlockInfo: blockInfo,
+		TxList:    txList,
+	}, indexes), nil
+}
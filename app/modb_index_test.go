@@ -0,0 +1,64 @@
+package app
+
+import (
+	"testing"
+
+	gethcmn "github.com/ethereum/go-ethereum/common"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/require"
+
+	modbtypes "github.com/smartbch/moeingdb/types"
+	"github.com/smartbch/moeingevm/types"
+
+	"github.com/smartbch/smartbch/param"
+)
+
+func TestWithoutUnindexed(t *testing.T) {
+	blk := &modbtypes.Block{Height: 1, TxList: []modbtypes.Tx{{
+		HashId:  [32]byte{0x01},
+		SrcAddr: [20]byte{0x02},
+		DstAddr: [20]byte{0x03},
+		Content: []byte{0x04},
+		LogList: []modbtypes.Log{{Address: [20]byte{0x05}, Topics: [][32]byte{{0x06}, {0x07}, {0x08}}}},
+	}}}
+
+	full := param.DefaultAppConfig().ModbIndexes()
+	require.True(t, full.IsFull())
+	require.Same(t, blk, withoutUnindexed(blk, full))
+
+	stripped := withoutUnindexed(blk, param.ModbIndexes{TxTo: true, LogTopics: 1})
+	require.Equal(t, [20]byte{}, stripped.TxList[0].SrcAddr)
+	require.Equal(t, [20]byte{0x03}, stripped.TxList[0].DstAddr)
+	require.Equal(t, []byte{0x04}, stripped.TxList[0].Content)
+	require.Equal(t, [][32]byte{{0x06}}, stripped.TxList[0].LogList[0].Topics)
+	// the original block is still used by the others
+	require.Equal(t, [20]byte{0x02}, blk.TxList[0].SrcAddr)
+	require.Len(t, blk.TxList[0].LogList[0].Topics, 3)
+
+	entries, fullEntries := estimateIndexEntries(param.ModbIndexes{TxTo: true, LogTopics: 1})
+	require.Equal(t, 4, entries)
+	require.Equal(t, 7, fullEntries)
+}
+
+func TestRebuildMdbBlock(t *testing.T) {
+	tx := &types.Transaction{
+		Hash: gethcmn.Hash{0x01},
+		From: gethcmn.Address{0x02},
+		To:   gethcmn.Address{0x03},
+		Logs: []types.Log{{Address: gethcmn.Address{0x05}, Topics: [][32]byte{{0x06}, {0x07}}}},
+	}
+	block := &types.Block{Number: 9, Hash: gethcmn.Hash{0x09}}
+	blk, err := RebuildMdbBlock(block, []*types.Transaction{tx}, param.ModbIndexes{TxFrom: true, LogTopics: 1})
+	require.NoError(t, err)
+	require.Equal(t, int64(9), blk.Height)
+	require.Equal(t, [20]byte{0x02}, blk.TxList[0].SrcAddr)
+	require.Equal(t, [20]byte{}, blk.TxList[0].DstAddr)
+	require.Equal(t, [][32]byte{{0x06}}, blk.TxList[0].LogList[0].Topics)
+
+	var info types.Block
+	_, err = info.UnmarshalMsg(blk.BlockInfo)
+	require.NoError(t, err)
+	require.True(t, gethtypes.Bloom(info.LogsBloom).Test(gethcmn.Hash{0x07}.Bytes()))
+	// the block given is not changed
+	require.Equal(t, gethtypes.Bloom{}, gethtypes.Bloom(block.LogsBloom))
+}
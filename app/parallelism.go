@@ -0,0 +1,99 @@
+package app
+
+import (
+	"math"
+	"runtime"
+
+	"github.com/smartbch/moeingevm/types"
+)
+
+const (
+	// the parallel number is re-evaluated once every parallelTuneInterval blocks
+	parallelTuneInterval = 100
+	// the blocks with fewer txs say little about the conflicts and are not measured
+	minTxsToMeasure = 16
+	// the weight of a newly measured block in the moving average of the conflict rate
+	conflictRateAlpha = 0.05
+	// the parallel number is changed only if the new one differs by at least 1/parallelHysteresis
+	parallelHysteresis = 4
+	minParallelNum     = 2
+)
+
+// parallelTuner adjusts the number of the goroutines driving the runners of the execution engine,
+// according to the CPUs available to this process and the measured conflict rate among the txs of
+// a block. The conflicting txs are executed in the later rounds one after another, so the more
+// conflicts there are, the fewer goroutines can be kept busy. Only the goroutine number is a
+// per-node parameter of the engine, the round count and the runner number (the batch size of a
+// round) decide which txs are committed in a block, so they are consensus parameters and never
+// tuned.
+type parallelTuner struct {
+	min, max     int
+	current      int
+	conflictRate float64
+	measured     bool
+	blocks       int
+}
+
+func newParallelTuner() *parallelTuner {
+	// twice the CPUs, such that the goroutines waiting for the disk do not leave a CPU idle
+	max := 2 * runtime.GOMAXPROCS(0)
+	if max < minParallelNum {
+		max = minParallelNum
+	}
+	return &parallelTuner{min: minParallelNum, max: max, current: max}
+}
+
+// observe measures the conflict rate of the txs committed in a block
+func (t *parallelTuner) observe(txs []*types.Transaction) {
+	if len(txs) < minTxsToMeasure {
+		return
+	}
+	rate := conflictRate(txs)
+	if !t.measured {
+		t.conflictRate, t.measured = rate, true
+	} else {
+		t.conflictRate = (1-conflictRateAlpha)*t.conflictRate + conflictRateAlpha*rate
+	}
+}
+
+// next returns the new parallel number and true if it should be changed
+func (t *parallelTuner) next() (int, bool) {
+	t.blocks++
+	if t.blocks%parallelTuneInterval != 0 || !t.measured {
+		return t.current, false
+	}
+	target := int(math.Ceil(float64(t.max) * (1 - t.conflictRate)))
+	if target < t.min {
+		target = t.min
+	}
+	diff := target - t.current
+	if diff < 0 {
+		diff = -diff
+	}
+	if diff == 0 || diff*parallelHysteresis < t.current {
+		return t.current, false
+	}
+	t.current = target
+	return target, true
+}
+
+// conflictRate returns the fraction of the txs which touch an account touched by an earlier tx
+// of the same block. It only looks at the senders and the recipients, which is a lower bound of
+// the conflicts the engine sees, because the storage slots shared by the txs are unknown unless
+// the rw lists are kept.
+func conflictRate(txs []*types.Transaction) float64 {
+	touched := make(map[[20]byte]struct{}, 2*len(txs))
+	conflicts := 0
+	for _, tx := range txs {
+		_, fromTouched := touched[tx.From]
+		_, toTouched := touched[tx.To]
+		if fromTouched || (tx.To != [20]byte{} && toTouched) {
+			conflicts++
+		}
+		touched[tx.From] = struct{}{}
+		if tx.To != [20]byte{} {
+			touched[tx.To] = struct{}{}
+		}
+	}
+	return float64(conflicts) / float64(len(txs))
+}
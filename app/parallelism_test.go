@@ -0,0 +1,73 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/smartbch/moeingevm/types"
+)
+
+func txsWithConflicts(n, conflicts int) []*types.Transaction {
+	txs := make([]*types.Transaction, n)
+	for i := range txs {
+		tx := &types.Transaction{}
+		tx.From[0], tx.From[1] = byte(i), 1
+		tx.To[0], tx.To[1] = byte(i), 2
+		if i > 0 && i <= conflicts {
+			tx.To = txs[0].From // sends to the sender of the first tx
+		}
+		txs[i] = tx
+	}
+	return txs
+}
+
+func TestConflictRate(t *testing.T) {
+	require.Equal(t, 0.0, conflictRate(txsWithConflicts(20, 0)))
+	require.Equal(t, 0.5, conflictRate(txsWithConflicts(20, 10)))
+
+	// contract creations do not conflict with each other
+	txs := txsWithConflicts(20, 0)
+	for _, tx := range txs {
+		tx.To = [20]byte{}
+	}
+	require.Equal(t, 0.0, conflictRate(txs))
+}
+
+func TestParallelTuner(t *testing.T) {
+	tuner := &parallelTuner{min: 2, max: 32, current: 32}
+	// not measured yet
+	for i := 0; i < parallelTuneInterval; i++ {
+		_, ok := tuner.next()
+		require.False(t, ok)
+	}
+
+	// the small blocks are ignored
+	tuner.observe(txsWithConflicts(minTxsToMeasure-1, minTxsToMeasure-2))
+	require.False(t, tuner.measured)
+
+	tuner.observe(txsWithConflicts(20, 15))
+	for i := 1; i < parallelTuneInterval; i++ {
+		_, ok := tuner.next()
+		require.False(t, ok)
+	}
+	n, ok := tuner.next()
+	require.True(t, ok)
+	require.Equal(t, 8, n)
+
+	// within the hysteresis
+	tuner.conflictRate = 0.72
+	for i := 1; i < parallelTuneInterval; i++ {
+		tuner.next()
+	}
+	_, ok = tuner.next()
+	require.False(t, ok)
+
+	tuner.conflictRate = 1
+	for i := 1; i < parallelTuneInterval; i++ {
+		tuner.next()
+	}
+	n, ok = tuner.next()
+	require.True(t, ok)
+	require.Equal(t, 2, n)
+}
@@ -0,0 +1,56 @@
+package app
+
+import (
+	"sync"
+)
+
+// the max number of changes kept by paramHistory, the oldest ones are dropped
+const maxParamChanges = 1000
+
+// The names of the recorded parameters
+const (
+	ParamMinGasPrice = "minGasPrice"
+)
+
+// ParamChange is a change of a consensus parameter observed by this node, OldValue is empty for
+// the value found when the node started
+type ParamChange struct {
+	Height   int64
+	Name     string
+	OldValue string
+	NewValue string
+}
+
+// paramHistory records the changes of the consensus parameters which can be changed on-chain,
+// such as the min gas price voted by the validators. It only knows the changes since the node
+// started.
+type paramHistory struct {
+	mtx     sync.RWMutex
+	values  map[string]string
+	changes []*ParamChange
+}
+
+func newParamHistory() *paramHistory {
+	return &paramHistory{values: make(map[string]string)}
+}
+
+func (h *paramHistory) record(height int64, name, value string) {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	old, ok := h.values[name]
+	if ok && old == value {
+		return
+	}
+	h.values[name] = value
+	h.changes = append(h.changes, &ParamChange{Height: height, Name: name, OldValue: old, NewValue: value})
+	if len(h.changes) > maxParamChanges {
+		h.changes = h.changes[len(h.changes)-maxParamChanges:]
+	}
+}
+
+// all returns the changes in the order they were observed
+func (h *paramHistory) all() []*ParamChange {
+	h.mtx.RLock()
+	defer h.mtx.RUnlock()
+	return append([]*ParamChange(nil), h.changes...)
+}
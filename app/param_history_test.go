@@ -0,0 +1,25 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParamHistory(t *testing.T) {
+	h := newParamHistory()
+	h.record(10, "minGasPrice", "100")
+	h.record(11, "minGasPrice", "100")
+	h.record(12, "minGasPrice", "200")
+	require.Equal(t, []*ParamChange{
+		{Height: 10, Name: "minGasPrice", NewValue: "100"},
+		{Height: 12, Name: "minGasPrice", OldValue: "100", NewValue: "200"},
+	}, h.all())
+
+	for i := 0; i < maxParamChanges; i++ {
+		h.record(int64(13+i), "minGasPrice", string(rune('a'+i%2)))
+	}
+	changes := h.all()
+	require.Len(t, changes, maxParamChanges)
+	require.EqualValues(t, 13, changes[0].Height)
+}
@@ -0,0 +1,176 @@
+package app
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	abcitypes "github.com/tendermint/tendermint/abci/types"
+)
+
+const (
+	peerScoreWindow = time.Minute
+	PeerBanDuration = time.Hour
+	// the records of the peers which are neither banned nor active in the current window are
+	// dropped when there are more than this many
+	maxScoredPeers = 1000
+
+	// Tendermint asks the app whether to accept a peer with this query path, when
+	// p2p.filter_peers is enabled
+	peerFilterIdPath = "/p2p/filter/id/"
+	PeerBanned       = uint32(1)
+)
+
+// invalidTxCodes are the CheckTx codes counted against the peer which sent the tx. An honest peer
+// only gossips the txs accepted by its own mempool, so it seldom sends the ones which are invalid
+// no matter what the state is. The nonce mismatches and the other codes depending on the mempool
+// of this node are not counted.
+var invalidTxCodes = map[uint32]bool{
+	CannotDecodeTx:      true,
+	CannotRecoverSender: true,
+	SenderNotFound:      true,
+	CannotPayGasFee:     true,
+	InvalidMinGasPrice:  true,
+	GasLimitTooSmall:    true,
+}
+
+// PeerScore is the record of the invalid txs sent by a peer since the node started
+type PeerScore struct {
+	PeerID      string
+	InvalidTxs  int64 // in total
+	RecentTxs   int   // in the current window
+	BannedUntil int64 // unix timestamp, zero if never banned
+	BannedTimes int
+}
+
+// peerScorer counts the invalid txs sent by each peer, and bans the peers sending too many of
+// them in a window. The signals come from the mempool log of Tendermint, because the ABCI
+// CheckTx request does not tell which peer sent the tx.
+type peerScorer struct {
+	mtx       sync.Mutex
+	threshold int
+	peers     map[string]*peerRecord
+	onBan     func(peerID, reason string) // set after Tendermint starts
+}
+
+type peerRecord struct {
+	score       PeerScore
+	windowStart time.Time
+	bannedUntil time.Time
+}
+
+func newPeerScorer(threshold int) *peerScorer {
+	return &peerScorer{
+		threshold: threshold,
+		peers:     make(map[string]*peerRecord),
+	}
+}
+
+// reportInvalidTx records that the tx sent by peerID is rejected with code, it returns true if
+// the peer gets banned by this tx
+func (s *peerScorer) reportInvalidTx(peerID string, code uint32, now time.Time) bool {
+	if peerID == "" || !invalidTxCodes[code] {
+		return false
+	}
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	rec := s.peers[peerID]
+	if rec == nil {
+		if len(s.peers) >= maxScoredPeers {
+			s.dropIdle(now)
+		}
+		rec = &peerRecord{score: PeerScore{PeerID: peerID}}
+		s.peers[peerID] = rec
+	}
+	rec.score.InvalidTxs++
+	if now.Sub(rec.windowStart) > peerScoreWindow {
+		rec.windowStart = now
+		rec.score.RecentTxs = 0
+	}
+	rec.score.RecentTxs++
+	if rec.score.RecentTxs < s.threshold || now.Before(rec.bannedUntil) {
+		return false
+	}
+	rec.bannedUntil = now.Add(PeerBanDuration)
+	rec.score.BannedUntil = rec.bannedUntil.Unix()
+	rec.score.BannedTimes++
+	return true
+}
+
+func (s *peerScorer) dropIdle(now time.Time) {
+	for id, rec := range s.peers {
+		if now.Sub(rec.windowStart) > peerScoreWindow && !now.Before(rec.bannedUntil) {
+			delete(s.peers, id)
+		}
+	}
+}
+
+func (s *peerScorer) isBanned(peerID string, now time.Time) bool {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	rec := s.peers[peerID]
+	return rec != nil && now.Before(rec.bannedUntil)
+}
+
+// scores returns the records of the peers, the ones with the most invalid txs first
+func (s *peerScorer) scores() []*PeerScore {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	result := make([]*PeerScore, 0, len(s.peers))
+	for _, rec := range s.peers {
+		score := rec.score
+		result = append(result, &score)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].InvalidTxs != result[j].InvalidTxs {
+			return result[i].InvalidTxs > result[j].InvalidTxs
+		}
+		return result[i].PeerID < result[j].PeerID
+	})
+	return result
+}
+
+func (s *peerScorer) banHandler() func(peerID, reason string) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return s.onBan
+}
+
+// SetPeerBanHandler sets the function which disconnects a banned peer, it is called in a new goroutine
+func (app *App) SetPeerBanHandler(handler func(peerID, reason string)) {
+	if app.peerScorer == nil {
+		return
+	}
+	app.peerScorer.mtx.Lock()
+	defer app.peerScorer.mtx.Unlock()
+	app.peerScorer.onBan = handler
+}
+
+// ReportPeerCheckTx records the result of CheckTx for a tx received from peerID
+func (app *App) ReportPeerCheckTx(peerID string, code uint32) {
+	if app.peerScorer == nil || !app.peerScorer.reportInvalidTx(peerID, code, time.Now()) {
+		return
+	}
+	app.logger.Info("ban peer for sending invalid txs", "peer", peerID, "duration", PeerBanDuration)
+	if handler := app.peerScorer.banHandler(); handler != nil {
+		go handler(peerID, "too many invalid txs")
+	}
+}
+
+// GetPeerScores returns nil if peer scoring is disabled
+func (app *App) GetPeerScores() []*PeerScore {
+	if app.peerScorer == nil {
+		return nil
+	}
+	return app.peerScorer.scores()
+}
+
+// queryPeerFilter answers whether Tendermint may connect to a peer
+func (app *App) queryPeerFilter(path string) abcitypes.ResponseQuery {
+	peerID := strings.TrimPrefix(path, peerFilterIdPath)
+	if app.peerScorer != nil && app.peerScorer.isBanned(peerID, time.Now()) {
+		return abcitypes.ResponseQuery{Code: PeerBanned, Log: "peer is banned for sending invalid txs"}
+	}
+	return abcitypes.ResponseQuery{Code: abcitypes.CodeTypeOK}
+}
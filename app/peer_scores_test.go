@@ -0,0 +1,44 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPeerScorer(t *testing.T) {
+	s := newPeerScorer(3)
+	now := time.Unix(1600000000, 0)
+	require.False(t, s.reportInvalidTx("", CannotDecodeTx, now))
+	require.False(t, s.reportInvalidTx("p1", AccountNonceMismatch, now))
+	require.False(t, s.reportInvalidTx("p1", CannotDecodeTx, now))
+	require.False(t, s.reportInvalidTx("p1", CannotRecoverSender, now.Add(10*time.Second)))
+	// the window has passed, so the count starts again
+	require.False(t, s.reportInvalidTx("p1", CannotDecodeTx, now.Add(2*time.Minute)))
+	require.False(t, s.reportInvalidTx("p1", CannotPayGasFee, now.Add(2*time.Minute)))
+	require.False(t, s.isBanned("p1", now.Add(2*time.Minute)))
+	require.True(t, s.reportInvalidTx("p1", InvalidMinGasPrice, now.Add(2*time.Minute)))
+	require.False(t, s.reportInvalidTx("p1", InvalidMinGasPrice, now.Add(2*time.Minute)))
+	require.True(t, s.isBanned("p1", now.Add(time.Hour)))
+	require.False(t, s.isBanned("p1", now.Add(2*time.Hour)))
+	require.False(t, s.isBanned("p2", now))
+
+	require.False(t, s.reportInvalidTx("p2", SenderNotFound, now))
+	scores := s.scores()
+	require.Len(t, scores, 2)
+	require.Equal(t, "p1", scores[0].PeerID)
+	require.EqualValues(t, 6, scores[0].InvalidTxs)
+	require.EqualValues(t, 1, scores[0].BannedTimes)
+	require.Equal(t, now.Add(2*time.Minute+PeerBanDuration).Unix(), scores[0].BannedUntil)
+	require.Equal(t, "p2", scores[1].PeerID)
+}
+
+func TestPeerFilterQuery(t *testing.T) {
+	app := &App{}
+	require.Equal(t, uint32(0), app.queryPeerFilter(peerFilterIdPath+"p1").Code)
+	app.peerScorer = newPeerScorer(1)
+	require.True(t, app.peerScorer.reportInvalidTx("p1", CannotDecodeTx, time.Now()))
+	require.Equal(t, PeerBanned, app.queryPeerFilter(peerFilterIdPath+"p1").Code)
+	require.Equal(t, uint32(0), app.queryPeerFilter(peerFilterIdPath+"p2").Code)
+}
@@ -0,0 +1,21 @@
+package app
+
+import (
+	"fmt"
+	"time"
+)
+
+// ReadyToPropose returns an error if the watcher lags the BCH mainnet by more than
+// propose-max-watcher-lag blocks. The blocks proposed by such a validator would miss the cross
+// chain transfers and the epochs found by the other validators' watchers, so it had better let the
+// next proposer propose.
+func (app *App) ReadyToPropose() error {
+	maxLag := app.config.AppConfig.ProposeMaxWatcherLag
+	if maxLag <= 0 {
+		return nil
+	}
+	if lag := app.watcher.GetLag(time.Now().Unix()); lag > maxLag {
+		return fmt.Errorf("the watcher lags the BCH mainnet by %d blocks, more than propose-max-watcher-lag %d", lag, maxLag)
+	}
+	return nil
+}
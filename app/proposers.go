@@ -0,0 +1,80 @@
+package app
+
+import (
+	"sort"
+
+	gethcmn "github.com/ethereum/go-ethereum/common"
+
+	"github.com/smartbch/moeingevm/types"
+
+	"github.com/smartbch/smartbch/param"
+	"github.com/smartbch/smartbch/staking"
+	stakingtypes "github.com/smartbch/smartbch/staking/types"
+)
+
+// ProposerInfo links the consensus address of a validator, which is the miner of the blocks it
+// proposes, to its addresses in smartBCH
+type ProposerInfo struct {
+	ConsAddress gethcmn.Address
+	Address     gethcmn.Address // the validator's operator address
+	RewardTo    gethcmn.Address
+	Pubkey      gethcmn.Hash
+}
+
+func newProposerInfo(p staking.Proposer) *ProposerInfo {
+	return &ProposerInfo{
+		ConsAddress: p.ConsAddress,
+		Address:     p.Address,
+		RewardTo:    p.RewardTo,
+		Pubkey:      p.Pubkey,
+	}
+}
+
+// recordProposers records the validators in the state since ProposerRecordForkHeight, because the
+// retired validators are removed from the staking info
+func (app *App) recordProposers(ctx *types.Context, validators []*stakingtypes.Validator) {
+	if app.currHeight < param.ProposerRecordForkHeight {
+		return
+	}
+	staking.SaveProposers(ctx, validators)
+}
+
+// loadProposers returns the recorded validators and the ones in the staking info, which are all
+// the known validators before ProposerRecordForkHeight
+func loadProposers(ctx *types.Context) map[[20]byte]staking.Proposer {
+	proposers := make(map[[20]byte]staking.Proposer)
+	for _, p := range staking.LoadProposers(ctx) {
+		proposers[p.ConsAddress] = p
+	}
+	for _, v := range staking.LoadStakingInfo(ctx).Validators {
+		p := staking.NewProposer(v)
+		proposers[p.ConsAddress] = p
+	}
+	return proposers
+}
+
+// GetProposerInfo returns nil if consAddr is not the consensus address of a validator in the staking
+// info, or of a retired validator recorded since ProposerRecordForkHeight
+func (app *App) GetProposerInfo(consAddr gethcmn.Address) *ProposerInfo {
+	ctx := app.GetRpcContext()
+	defer ctx.Close(false)
+	if p, ok := loadProposers(ctx)[consAddr]; ok {
+		return newProposerInfo(p)
+	}
+	return nil
+}
+
+// GetProposerInfos returns the proposers sorted by their consensus addresses
+func (app *App) GetProposerInfos() []*ProposerInfo {
+	ctx := app.GetRpcContext()
+	defer ctx.Close(false)
+	proposers := loadProposers(ctx)
+	result := make([]*ProposerInfo, 0, len(proposers))
+	for _, p := range proposers {
+		result = append(result, newProposerInfo(p))
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return string(result[i].ConsAddress[:]) < string(result[j].ConsAddress[:])
+	})
+	return result
+}
@@ -0,0 +1,50 @@
+package app
+
+import (
+	"testing"
+
+	gethcmn "github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/crypto/ed25519"
+
+	"github.com/smartbch/moeingads/store"
+	"github.com/smartbch/moeingads/store/rabbit"
+	"github.com/smartbch/moeingevm/types"
+
+	"github.com/smartbch/smartbch/staking"
+	stakingtypes "github.com/smartbch/smartbch/staking/types"
+)
+
+func TestLoadProposers(t *testing.T) {
+	r := rabbit.NewRabbitStore(store.NewMockRootStore())
+	ctx := types.NewContext(&r, nil)
+	v1 := &stakingtypes.Validator{Address: [20]byte{1}, Pubkey: [32]byte{1}, RewardTo: [20]byte{0x11}}
+	v2 := &stakingtypes.Validator{Address: [20]byte{2}, Pubkey: [32]byte{2}, RewardTo: [20]byte{0x12}}
+	consAddr1 := gethcmn.BytesToAddress(ed25519.PubKey(v1.Pubkey[:]).Address())
+	consAddr2 := gethcmn.BytesToAddress(ed25519.PubKey(v2.Pubkey[:]).Address())
+
+	// before the fork, only the validators in the staking info are known
+	staking.SaveStakingInfo(ctx, stakingtypes.StakingInfo{Validators: []*stakingtypes.Validator{v1, v2}})
+	proposers := loadProposers(ctx)
+	require.Len(t, proposers, 2)
+	require.Equal(t, [20]byte{0x11}, proposers[consAddr1].RewardTo)
+
+	// v2 is recorded, and removed from the staking info after retiring
+	staking.SaveProposers(ctx, []*stakingtypes.Validator{v1, v2})
+	staking.SaveStakingInfo(ctx, stakingtypes.StakingInfo{Validators: []*stakingtypes.Validator{v1}})
+	proposers = loadProposers(ctx)
+	require.Len(t, proposers, 2)
+	require.Equal(t, [20]byte{2}, proposers[consAddr2].Address)
+	require.Equal(t, [32]byte{2}, proposers[consAddr2].Pubkey)
+
+	// v1 edits its reward address, which is updated in the staking info before it is recorded again
+	v1.RewardTo = [20]byte{0x21}
+	staking.SaveStakingInfo(ctx, stakingtypes.StakingInfo{Validators: []*stakingtypes.Validator{v1}})
+	require.Equal(t, [20]byte{0x21}, loadProposers(ctx)[consAddr1].RewardTo)
+	staking.SaveProposers(ctx, []*stakingtypes.Validator{v1})
+	recorded := staking.LoadProposers(ctx)
+	require.Len(t, recorded, 2)
+	require.Equal(t, [20]byte{0x21}, recorded[0].RewardTo)
+	_, ok := loadProposers(ctx)[[20]byte{1}]
+	require.False(t, ok)
+}
@@ -0,0 +1,35 @@
+package app
+
+import (
+	stakingtypes "github.com/smartbch/smartbch/staking/types"
+)
+
+// validatorUpdateDelay is the number of blocks from the one whose Commit changes the voting powers to
+// the first one signed by the new validator set: the update is returned by EndBlock of the next
+// block, and tendermint applies the updates returned at height h from h+2
+const validatorUpdateDelay = 3
+
+// StakingEvent is emitted at the blocks switching to a new epoch or changing the voting powers
+type StakingEvent struct {
+	Height          int64
+	EffectiveHeight int64               // the first block signed by Validators
+	Epoch           *stakingtypes.Epoch // the epoch applied, nil if no epoch is switched
+	Validators      []*stakingtypes.Validator
+	PowerChanges    []*ValidatorPowerChangeEvent
+}
+
+// newStakingEvent returns nil if neither an epoch is switched nor a voting power is changed
+func newStakingEvent(height int64, epoch *stakingtypes.Epoch, validators []*stakingtypes.Validator,
+	powerChanges []*ValidatorPowerChangeEvent) *StakingEvent {
+
+	if epoch == nil && len(powerChanges) == 0 {
+		return nil
+	}
+	return &StakingEvent{
+		Height:          height,
+		EffectiveHeight: height + validatorUpdateDelay,
+		Epoch:           epoch,
+		Validators:      validators,
+		PowerChanges:    powerChanges,
+	}
+}
@@ -0,0 +1,25 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	stakingtypes "github.com/smartbch/smartbch/staking/types"
+)
+
+func TestNewStakingEvent(t *testing.T) {
+	vals := []*stakingtypes.Validator{{VotingPower: 1}}
+	require.Nil(t, newStakingEvent(100, nil, vals, nil))
+
+	changes := []*ValidatorPowerChangeEvent{{Height: 100, OldPower: 2, NewPower: 1, Reason: PowerChangeBySlash}}
+	e := newStakingEvent(100, nil, vals, changes)
+	require.Nil(t, e.Epoch)
+	require.EqualValues(t, 103, e.EffectiveHeight)
+	require.Len(t, e.PowerChanges, 1)
+
+	// an epoch is reported even if it changes no voting power
+	e = newStakingEvent(100, &stakingtypes.Epoch{Number: 5}, vals, nil)
+	require.EqualValues(t, 5, e.Epoch.Number)
+	require.Empty(t, e.PowerChanges)
+}
@@ -0,0 +1,307 @@
+package app
+
+import (
+	"encoding/binary"
+	"encoding/gob"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/smartbch/moeingads/store/rabbit"
+	"github.com/smartbch/moeingevm/types"
+)
+
+// the tracked access heights are saved every this many blocks, and when the node stops
+const stateAccessSaveInterval = 1000
+
+const (
+	accountState uint8 = iota
+	bytecodeState
+	storageState
+)
+
+type stateKey struct {
+	Kind uint8
+	Addr [20]byte // of an account or a bytecode
+	Seq  uint64   // the sequence of the contract owning a storage slot
+	Slot string
+}
+
+type stateEntry struct {
+	LastAccess int64
+	Size       int64
+}
+
+// StateUsage counts the tracked entries of a kind of state, and the ones not accessed recently
+type StateUsage struct {
+	Entries     int64 `json:"entries"`
+	Bytes       int64 `json:"bytes"`
+	IdleEntries int64 `json:"idleEntries"`
+	IdleBytes   int64 `json:"idleBytes"`
+}
+
+// IdleContractStorage is the idle storage of a contract, which is identified by its sequence
+type IdleContractStorage struct {
+	Seq       uint64 `json:"seq"`
+	IdleSlots int64  `json:"idleSlots"`
+	IdleBytes int64  `json:"idleBytes"`
+}
+
+// StateAccessReport lists the state not accessed in the last IdleBlocks blocks. The state not
+// accessed at all since TrackedSince is unknown to the tracker, so IdleBlocks should be much
+// smaller than Height-TrackedSince.
+type StateAccessReport struct {
+	TrackedSince     int64                  `json:"trackedSince"`
+	Height           int64                  `json:"height"`
+	IdleBlocks       int64                  `json:"idleBlocks"`
+	Accounts         StateUsage             `json:"accounts"`
+	Bytecodes        StateUsage             `json:"bytecodes"`
+	Storage          StateUsage             `json:"storage"`
+	TopIdleContracts []*IdleContractStorage `json:"topIdleContracts"`
+}
+
+// StateRentPolicy evicts the state of the chosen kinds which is not accessed for IdleBlocks blocks,
+// the entries smaller than MinBytes are exempt
+type StateRentPolicy struct {
+	Name       string `json:"name"`
+	IdleBlocks int64  `json:"idleBlocks"`
+	Accounts   bool   `json:"accounts"`
+	Bytecodes  bool   `json:"bytecodes"`
+	Storage    bool   `json:"storage"`
+	MinBytes   int64  `json:"minBytes"`
+}
+
+// StateRentResult is the state a policy would have evicted at the current height
+type StateRentResult struct {
+	Policy         StateRentPolicy `json:"policy"`
+	EvictedEntries int64           `json:"evictedEntries"`
+	EvictedBytes   int64           `json:"evictedBytes"`
+	// EvictedBytes out of all the tracked bytes
+	Savings float64 `json:"savings"`
+}
+
+// stateAccessTracker records the height at which each account, bytecode and storage slot was last
+// read or written, to study the state rent policies with the real access patterns. It is built
+// from the state accesses recorded while the blocks are executed, and saved to path.
+type stateAccessTracker struct {
+	mtx     sync.RWMutex
+	path    string
+	since   int64
+	height  int64
+	entries map[stateKey]*stateEntry
+}
+
+// stateAccessFile is the content saved to the path of the tracker
+type stateAccessFile struct {
+	Since   int64
+	Height  int64
+	Entries map[stateKey]*stateEntry
+}
+
+// newStateAccessTracker loads the saved tracker from path, an empty path means disabled
+func newStateAccessTracker(path string) (*stateAccessTracker, error) {
+	t := &stateAccessTracker{path: path, entries: make(map[stateKey]*stateEntry)}
+	if path == "" {
+		return t, nil
+	}
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return t, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var saved stateAccessFile
+	if err = gob.NewDecoder(f).Decode(&saved); err != nil {
+		return nil, err
+	}
+	t.since, t.height = saved.Since, saved.Height
+	if saved.Entries != nil {
+		t.entries = saved.Entries
+	}
+	return t, nil
+}
+
+func (t *stateAccessTracker) isActive() bool {
+	return t.path != ""
+}
+
+// collect records the state accessed by a committed block
+func (t *stateAccessTracker) collect(height int64, accesses *stateAccesses) error {
+	if !t.isActive() || accesses == nil {
+		return nil
+	}
+	t.touch(height, accesses)
+	if height%stateAccessSaveInterval == 0 {
+		return t.save()
+	}
+	return nil
+}
+
+// decodeStateKey returns the tracked key of an account, a bytecode or a storage slot, whose size
+// is the size of the original key without the prefix byte, plus the size of the value
+func decodeStateKey(key []byte) (stateKey, bool) {
+	switch {
+	case len(key) == 1+20 && key[0] == types.ACCOUNT_KEY:
+		return stateKey{Kind: accountState, Addr: *(*[20]byte)(key[1:])}, true
+	case len(key) == 1+20 && key[0] == types.BYTECODE_KEY:
+		return stateKey{Kind: bytecodeState, Addr: *(*[20]byte)(key[1:])}, true
+	case len(key) == 1+8+32 && key[0] == types.VALUE_KEY:
+		return stateKey{Kind: storageState, Seq: binary.BigEndian.Uint64(key[1:9]), Slot: string(key[9:])}, true
+	}
+	return stateKey{}, false
+}
+
+func (t *stateAccessTracker) touch(height int64, accesses *stateAccesses) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	if t.since == 0 {
+		t.since = height
+	}
+	t.height = height
+	// reading an empty entry, which does not exist, is not tracked
+	for _, content := range accesses.reads {
+		cv := rabbit.BytesToCachedValue(content)
+		if cv == nil || cv.IsEmpty() {
+			continue
+		}
+		if key, ok := decodeStateKey(cv.GetKey()); ok {
+			t.set(key, height, len(cv.GetKey())-1+len(cv.GetValue()))
+		}
+	}
+	for shortKey, content := range accesses.writes {
+		cv := rabbit.BytesToCachedValue(content)
+		deleted := cv == nil || cv.IsEmpty()
+		if content == nil { // the original key of a deleted entry is known from the read before
+			cv = rabbit.BytesToCachedValue(accesses.reads[shortKey])
+		}
+		if cv == nil {
+			continue
+		}
+		if key, ok := decodeStateKey(cv.GetKey()); ok && deleted {
+			t.write(key, height, 0, 0)
+		} else if ok {
+			t.write(key, height, len(cv.GetValue()), len(cv.GetKey())-1+len(cv.GetValue()))
+		}
+	}
+}
+
+// write deletes the entry when the written content is empty
+func (t *stateAccessTracker) write(key stateKey, height int64, contentLen, size int) {
+	if contentLen == 0 {
+		delete(t.entries, key)
+		return
+	}
+	t.set(key, height, size)
+}
+
+func (t *stateAccessTracker) set(key stateKey, height int64, size int) {
+	e := t.entries[key]
+	if e == nil {
+		e = &stateEntry{}
+		t.entries[key] = e
+	}
+	e.LastAccess = height
+	e.Size = int64(size)
+}
+
+func (t *stateAccessTracker) save() error {
+	t.mtx.RLock()
+	defer t.mtx.RUnlock()
+	tmp := t.path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	err = gob.NewEncoder(f).Encode(stateAccessFile{Since: t.since, Height: t.height, Entries: t.entries})
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp, t.path)
+}
+
+// report counts the state not accessed in the last idleBlocks blocks, and lists the top contracts
+// with the most idle storage bytes
+func (t *stateAccessTracker) report(idleBlocks int64, top int) *StateAccessReport {
+	t.mtx.RLock()
+	defer t.mtx.RUnlock()
+	r := &StateAccessReport{TrackedSince: t.since, Height: t.height, IdleBlocks: idleBlocks}
+	contracts := make(map[uint64]*IdleContractStorage)
+	for key, e := range t.entries {
+		usage := &r.Accounts
+		switch key.Kind {
+		case bytecodeState:
+			usage = &r.Bytecodes
+		case storageState:
+			usage = &r.Storage
+		}
+		usage.Entries++
+		usage.Bytes += e.Size
+		if t.height-e.LastAccess < idleBlocks {
+			continue
+		}
+		usage.IdleEntries++
+		usage.IdleBytes += e.Size
+		if key.Kind == storageState {
+			c := contracts[key.Seq]
+			if c == nil {
+				c = &IdleContractStorage{Seq: key.Seq}
+				contracts[key.Seq] = c
+			}
+			c.IdleSlots++
+			c.IdleBytes += e.Size
+		}
+	}
+	for _, c := range contracts {
+		r.TopIdleContracts = append(r.TopIdleContracts, c)
+	}
+	sort.Slice(r.TopIdleContracts, func(i, j int) bool {
+		a, b := r.TopIdleContracts[i], r.TopIdleContracts[j]
+		return a.IdleBytes > b.IdleBytes || (a.IdleBytes == b.IdleBytes && a.Seq < b.Seq)
+	})
+	if len(r.TopIdleContracts) > top {
+		r.TopIdleContracts = r.TopIdleContracts[:top]
+	}
+	return r
+}
+
+// simulate returns the state each policy would have evicted at the current height
+func (t *stateAccessTracker) simulate(policies []StateRentPolicy) []*StateRentResult {
+	t.mtx.RLock()
+	defer t.mtx.RUnlock()
+	results := make([]*StateRentResult, len(policies))
+	for i, p := range policies {
+		results[i] = &StateRentResult{Policy: p}
+	}
+	var totalBytes int64
+	for key, e := range t.entries {
+		totalBytes += e.Size
+		for i, p := range policies {
+			if !p.appliesTo(key.Kind) || e.Size < p.MinBytes || t.height-e.LastAccess < p.IdleBlocks {
+				continue
+			}
+			results[i].EvictedEntries++
+			results[i].EvictedBytes += e.Size
+		}
+	}
+	if totalBytes != 0 {
+		for _, r := range results {
+			r.Savings = float64(r.EvictedBytes) / float64(totalBytes)
+		}
+	}
+	return results
+}
+
+func (p StateRentPolicy) appliesTo(kind uint8) bool {
+	switch kind {
+	case accountState:
+		return p.Accounts
+	case bytecodeState:
+		return p.Bytecodes
+	default:
+		return p.Storage
+	}
+}
@@ -0,0 +1,90 @@
+package app
+
+import (
+	"path/filepath"
+	"testing"
+
+	gethcmn "github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+
+	"github.com/smartbch/moeingads/store"
+	"github.com/smartbch/moeingads/store/rabbit"
+	"github.com/smartbch/moeingevm/types"
+)
+
+func stateSlot(b byte) string {
+	var slot [32]byte
+	slot[31] = b
+	return string(slot[:])
+}
+
+// runRecorded runs fn on a rabbit store over root, and returns the state accesses it made
+func runRecorded(root *store.MockRootStore, fn func(r rabbit.RabbitStore)) *stateAccesses {
+	recorder := newAccessRecorder()
+	recorder.start()
+	r := rabbit.NewRabbitStore(recorder.wrap(root))
+	fn(r)
+	r.CloseAndWriteBack(true)
+	return recorder.stop()
+}
+
+func TestStateAccessTracker(t *testing.T) {
+	a1, a2, a3, a9 := gethcmn.Address{1}, gethcmn.Address{2}, gethcmn.Address{3}, gethcmn.Address{9}
+	root := store.NewMockRootStore()
+	runRecorded(root, func(r rabbit.RabbitStore) {
+		r.Set(types.GetAccountKey(a1), []byte{1})
+		r.Set(types.GetBytecodeKey(a2), []byte{1, 2})
+		r.Set(types.GetValueKey(2, stateSlot(1)), []byte{1, 2, 3})
+		r.Set(types.GetCreationCounterKey(0), []byte{1}) // not tracked
+	})
+
+	path := filepath.Join(t.TempDir(), "state-access")
+	tracker, err := newStateAccessTracker(path)
+	require.NoError(t, err)
+	tracker.touch(10, runRecorded(root, func(r rabbit.RabbitStore) {
+		r.Get(types.GetAccountKey(a1))
+		r.Get(types.GetAccountKey(a9)) // does not exist
+		r.Get(types.GetBytecodeKey(a2))
+		r.Get(types.GetCreationCounterKey(0))
+		r.Set(types.GetValueKey(1, stateSlot(1)), []byte{1})
+		r.Set(types.GetValueKey(1, stateSlot(2)), []byte{2})
+	}))
+	tracker.touch(100, runRecorded(root, func(r rabbit.RabbitStore) {
+		r.Set(types.GetAccountKey(a3), []byte{1})
+		r.Get(types.GetValueKey(2, stateSlot(1)))
+		r.Get(types.GetValueKey(2, stateSlot(3))) // does not exist
+		r.Delete(types.GetValueKey(1, stateSlot(2)))
+	}))
+
+	r := tracker.report(50, 10)
+	require.Equal(t, int64(10), r.TrackedSince)
+	require.Equal(t, int64(100), r.Height)
+	require.Equal(t, StateUsage{Entries: 2, Bytes: 42, IdleEntries: 1, IdleBytes: 21}, r.Accounts)
+	require.Equal(t, StateUsage{Entries: 1, Bytes: 22, IdleEntries: 1, IdleBytes: 22}, r.Bytecodes)
+	require.Equal(t, StateUsage{Entries: 2, Bytes: 84, IdleEntries: 1, IdleBytes: 41}, r.Storage)
+	require.Equal(t, []*IdleContractStorage{{Seq: 1, IdleSlots: 1, IdleBytes: 41}}, r.TopIdleContracts)
+	require.Empty(t, tracker.report(50, 0).TopIdleContracts)
+
+	results := tracker.simulate([]StateRentPolicy{
+		{Name: "all", IdleBlocks: 50, Accounts: true, Bytecodes: true, Storage: true},
+		{Name: "storage", IdleBlocks: 50, Storage: true},
+		{Name: "large", IdleBlocks: 50, Accounts: true, Bytecodes: true, Storage: true, MinBytes: 22},
+		{Name: "never", IdleBlocks: 1000, Accounts: true, Bytecodes: true, Storage: true},
+	})
+	require.Equal(t, int64(3), results[0].EvictedEntries)
+	require.Equal(t, int64(84), results[0].EvictedBytes)
+	require.InDelta(t, 84.0/148, results[0].Savings, 1e-9)
+	require.Equal(t, int64(41), results[1].EvictedBytes)
+	require.Equal(t, int64(63), results[2].EvictedBytes)
+	require.Zero(t, results[3].EvictedEntries)
+
+	require.NoError(t, tracker.save())
+	loaded, err := newStateAccessTracker(path)
+	require.NoError(t, err)
+	require.Equal(t, r, loaded.report(50, 10))
+
+	disabled, err := newStateAccessTracker("")
+	require.NoError(t, err)
+	require.NoError(t, disabled.collect(1000, &stateAccesses{}))
+	require.Empty(t, disabled.entries)
+}
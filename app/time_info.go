@@ -0,0 +1,52 @@
+package app
+
+import (
+	"time"
+)
+
+// BeginBlock waits for the BCH node when the smartBCH block is later than the latest BCH block
+// by more than MaxBchTimeLag seconds
+const MaxBchTimeLag = 12 * 3600
+
+// TimeInfo compares the clocks seen by the different parts of a node. The EVM-visible timestamp
+// of a block is the time in its Tendermint header truncated to seconds. Tendermint's BFT time
+// makes the header times strictly increasing, so block.timestamp never decreases, but consecutive
+// blocks may share the same one.
+type TimeInfo struct {
+	Height int64
+	// the time in the header of the latest block, zero if no block is committed after startup
+	TendermintTime time.Time
+	// block.timestamp of the latest block
+	BlockTimestamp int64
+	// the timestamp of the latest finalized BCH block seen by the watcher, and its height
+	BchBlockTimestamp int64
+	BchHeight         int64
+	// BlockTimestamp minus BchBlockTimestamp, new blocks wait for the BCH node if it exceeds
+	// MaxBchTimeLag
+	BchTimeLag int64
+	NodeTime   time.Time
+}
+
+func (app *App) GetTimeInfo() TimeInfo {
+	bi := app.LoadBlockInfo()
+	tmTime, _ := app.committedHeaderTime.Load().(time.Time)
+	bchTimestamp := app.watcher.GetCurrMainnetBlockTimestamp()
+	return TimeInfo{
+		Height:            bi.Number,
+		TendermintTime:    tmTime,
+		BlockTimestamp:    bi.Timestamp,
+		BchBlockTimestamp: bchTimestamp,
+		BchHeight:         app.watcher.GetLatestFinalizedHeight(),
+		BchTimeLag:        bi.Timestamp - bchTimestamp,
+		NodeTime:          time.Now(),
+	}
+}
+
+// checkTimestamp logs an error if the timestamp of the new block is earlier than the previous one,
+// which never happens with the BFT time of Tendermint
+func (app *App) checkTimestamp(height, prevTimestamp, timestamp int64) {
+	if timestamp < prevTimestamp {
+		app.logger.Error("block timestamp decreased", "height", height,
+			"prevTimestamp", prevTimestamp, "timestamp", timestamp)
+	}
+}
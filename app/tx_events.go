@@ -0,0 +1,121 @@
+package app
+
+import (
+	"encoding/json"
+	"strconv"
+
+	gethcmn "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	abcitypes "github.com/tendermint/tendermint/abci/types"
+
+	modbtypes "github.com/smartbch/moeingdb/types"
+	"github.com/smartbch/moeingevm/types"
+	"github.com/smartbch/smartbch/param"
+)
+
+// The types of the events emitted to tendermint for the transactions
+const (
+	EventTypeTx       = "ethereum_tx"        // emitted by DeliverTx
+	EventTypeTxResult = "ethereum_tx_result" // emitted by BeginBlock, for the transactions executed in the last Commit
+)
+
+type eventLog struct {
+	Address gethcmn.Address `json:"address"`
+	Topics  []gethcmn.Hash  `json:"topics"`
+	Data    hexutil.Bytes   `json:"data"`
+}
+
+func attr(key, value string, index bool) abcitypes.EventAttribute {
+	return abcitypes.EventAttribute{Key: []byte(key), Value: []byte(value), Index: index}
+}
+
+// deliverTxEvents returns the events of a delivered transaction, whose result is not known yet
+// because the transactions are executed after the block is committed
+func deliverTxEvents(tx *gethtypes.Transaction, verbosity string) []abcitypes.Event {
+	if verbosity != param.TxEventsCompact && verbosity != param.TxEventsFull {
+		return nil
+	}
+	attrs := []abcitypes.EventAttribute{
+		attr("hash", tx.Hash().Hex(), true),
+		attr("nonce", strconv.FormatUint(tx.Nonce(), 10), false),
+	}
+	if tx.To() != nil {
+		attrs = append(attrs, attr("to", tx.To().Hex(), true))
+	}
+	return []abcitypes.Event{{Type: EventTypeTx, Attributes: attrs}}
+}
+
+// txResultEvents returns the events of the transactions executed in a block. With the "compact"
+// verbosity, the logs of a transaction are summarized by the keccak256 hash of the concatenated
+// addresses, topics and data of them.
+func txResultEvents(height int64, txs []*types.Transaction, verbosity string) []abcitypes.Event {
+	if verbosity != param.TxEventsCompact && verbosity != param.TxEventsFull {
+		return nil
+	}
+	events := make([]abcitypes.Event, 0, len(txs))
+	for _, tx := range txs {
+		attrs := []abcitypes.EventAttribute{
+			attr("height", strconv.FormatInt(height, 10), true),
+			attr("hash", gethcmn.Hash(tx.Hash).Hex(), true),
+			attr("from", gethcmn.Address(tx.From).Hex(), true),
+			attr("to", gethcmn.Address(tx.To).Hex(), true),
+			attr("status", strconv.FormatUint(uint64(tx.Status), 10), false),
+			attr("gasUsed", strconv.FormatUint(tx.GasUsed, 10), false),
+			attr("logCount", strconv.Itoa(len(tx.Logs)), false),
+		}
+		if tx.ContractAddress != [20]byte{} {
+			attrs = append(attrs, attr("contractAddress", gethcmn.Address(tx.ContractAddress).Hex(), true))
+		}
+		if verbosity == param.TxEventsFull {
+			for _, l := range tx.Logs {
+				bz, _ := json.Marshal(eventLog{
+					Address: l.Address,
+					Topics:  types.ToGethHashes(l.Topics),
+					Data:    l.Data,
+				})
+				attrs = append(attrs, attr("log", string(bz), false))
+			}
+		} else if len(tx.Logs) != 0 {
+			attrs = append(attrs, attr("logsHash", logsHash(tx.Logs).Hex(), false))
+		}
+		events = append(events, abcitypes.Event{Type: EventTypeTxResult, Attributes: attrs})
+	}
+	return events
+}
+
+// blockTxResultEvents returns the events of the transactions in a committed block
+func blockTxResultEvents(blk *modbtypes.Block, verbosity string) []abcitypes.Event {
+	if verbosity != param.TxEventsCompact && verbosity != param.TxEventsFull {
+		return nil
+	}
+	return txResultEvents(blk.Height, blockTxs(blk), verbosity)
+}
+
+// blockTxs decodes the executed transactions in a committed block
+func blockTxs(blk *modbtypes.Block) []*types.Transaction {
+	txs := make([]*types.Transaction, 0, len(blk.TxList))
+	for _, mdbTx := range blk.TxList {
+		tx := &types.Transaction{}
+		if _, err := tx.UnmarshalMsg(mdbTx.Content); err != nil {
+			continue
+		}
+		txs = append(txs, tx)
+	}
+	return txs
+}
+
+func logsHash(logs []types.Log) gethcmn.Hash {
+	hasher := crypto.NewKeccakState()
+	for _, l := range logs {
+		hasher.Write(l.Address[:])
+		for _, topic := range l.Topics {
+			hasher.Write(topic[:])
+		}
+		hasher.Write(l.Data)
+	}
+	var h gethcmn.Hash
+	hasher.Read(h[:])
+	return h
+}
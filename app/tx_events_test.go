@@ -0,0 +1,44 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	abcitypes "github.com/tendermint/tendermint/abci/types"
+
+	"github.com/smartbch/moeingevm/types"
+	"github.com/smartbch/smartbch/param"
+)
+
+func eventAttrs(event abcitypes.Event) map[string][]string {
+	attrs := make(map[string][]string)
+	for _, a := range event.Attributes {
+		attrs[string(a.Key)] = append(attrs[string(a.Key)], string(a.Value))
+	}
+	return attrs
+}
+
+func TestTxResultEvents(t *testing.T) {
+	txs := []*types.Transaction{
+		{Hash: [32]byte{1}, Status: 1, GasUsed: 21000},
+		{Hash: [32]byte{2}, Status: 1, GasUsed: 50000, Logs: []types.Log{
+			{Address: [20]byte{3}, Topics: [][32]byte{{4}}, Data: []byte{5}},
+			{Address: [20]byte{3}, Topics: [][32]byte{{6}}},
+		}},
+	}
+	require.Empty(t, txResultEvents(10, txs, param.TxEventsNone))
+
+	compact := txResultEvents(10, txs, param.TxEventsCompact)
+	require.Len(t, compact, 2)
+	require.Equal(t, EventTypeTxResult, compact[0].Type)
+	require.Equal(t, []string{"10"}, eventAttrs(compact[0])["height"])
+	require.Nil(t, eventAttrs(compact[0])["logsHash"])
+	require.Equal(t, []string{"2"}, eventAttrs(compact[1])["logCount"])
+	require.Equal(t, []string{logsHash(txs[1].Logs).Hex()}, eventAttrs(compact[1])["logsHash"])
+	require.Nil(t, eventAttrs(compact[1])["log"])
+	require.NotEqual(t, logsHash(txs[1].Logs[:1]), logsHash(txs[1].Logs))
+
+	full := txResultEvents(10, txs, param.TxEventsFull)
+	require.Len(t, eventAttrs(full[1])["log"], 2)
+	require.Nil(t, eventAttrs(full[1])["logsHash"])
+}
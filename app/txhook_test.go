@@ -0,0 +1,56 @@
+package app_test
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/require"
+
+	"github.com/smartbch/smartbch/app"
+	"github.com/smartbch/smartbch/internal/ethutils"
+	"github.com/smartbch/smartbch/internal/testutils"
+	"github.com/smartbch/smartbch/txhook"
+)
+
+var blockedAddr = common.Address{0xb1, 0x0c, 0x4e, 0xd0}
+
+// blockingHook vetoes the txs to blockedAddr, it stays registered for the other tests, which
+// never send txs to blockedAddr
+type blockingHook struct {
+	txhook.BaseTxHook
+}
+
+func (blockingHook) Name() string { return "app-test-blocking" }
+
+func (blockingHook) CheckTx(tx *gethtypes.Transaction, _ common.Address) error {
+	if to := tx.To(); to != nil && *to == blockedAddr {
+		return errors.New("blocked")
+	}
+	return nil
+}
+
+func TestTxHookRegisteredBeforeNewApp(t *testing.T) {
+	registered := false
+	for _, h := range txhook.Hooks() {
+		registered = registered || h.Name() == blockingHook{}.Name()
+	}
+	if !registered {
+		txhook.Register(blockingHook{})
+	}
+	key, addr := testutils.GenKeyAndAddr()
+	_app := testutils.CreateTestApp(key)
+	defer _app.Destroy()
+
+	tx := ethutils.NewTx(0, &blockedAddr, big.NewInt(100), 100000, big.NewInt(10), nil)
+	tx = testutils.MustSignTx(tx, _app.ChainID().ToBig(), key)
+	code, info := _app.CheckTxABCI(tx, true)
+	require.Equal(t, app.TxVetoed, code)
+	require.Contains(t, info, "vetoed by app-test-blocking")
+
+	tx = ethutils.NewTx(0, &addr, big.NewInt(100), 100000, big.NewInt(10), nil)
+	tx = testutils.MustSignTx(tx, _app.ChainID().ToBig(), key)
+	require.Equal(t, uint32(0), _app.CheckNewTxABCI(tx))
+}
@@ -0,0 +1,73 @@
+package app
+
+import (
+	gethcmn "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/tendermint/tendermint/crypto/ed25519"
+
+	stakingtypes "github.com/smartbch/smartbch/staking/types"
+)
+
+// The reasons why a validator's voting power may change
+const (
+	PowerChangeByEpoch  = "epoch"  // a new epoch is applied
+	PowerChangeBySlash  = "slash"  // the validator is slashed for duplicate signing
+	PowerChangeByRetire = "retire" // the validator retired itself
+	PowerChangeByOther  = "other"  // other reasons, such as the hardcoded fixes
+)
+
+// ValidatorPowerChangeEvent is emitted for each validator whose voting power changes in a block
+type ValidatorPowerChangeEvent struct {
+	Height   int64           `json:"height"`
+	Address  gethcmn.Address `json:"address"`
+	Pubkey   hexutil.Bytes   `json:"pubkey"`
+	OldPower int64           `json:"oldPower"`
+	NewPower int64           `json:"newPower"`
+	Reason   string          `json:"reason"`
+}
+
+// buildPowerChangeEvents compares 'updates' (the output of GetUpdateValidatorSet) against the
+// validator set before this block and explains every change with a reason
+func buildPowerChangeEvents(height int64, oldValidators, updates []*stakingtypes.Validator,
+	allValidators []*stakingtypes.Validator, slashedConsAddrs [][20]byte, epochSwitched bool) []*ValidatorPowerChangeEvent {
+
+	if len(updates) == 0 {
+		return nil
+	}
+	oldPowers := make(map[[20]byte]int64, len(oldValidators))
+	for _, v := range oldValidators {
+		oldPowers[v.Address] = v.VotingPower
+	}
+	retiring := make(map[[20]byte]bool, len(allValidators))
+	for _, v := range allValidators {
+		if v.IsRetiring {
+			retiring[v.Address] = true
+		}
+	}
+	slashed := make(map[[20]byte]bool, len(slashedConsAddrs))
+	for _, addr := range slashedConsAddrs {
+		slashed[addr] = true
+	}
+	events := make([]*ValidatorPowerChangeEvent, 0, len(updates))
+	var consAddr [20]byte
+	for _, v := range updates {
+		copy(consAddr[:], ed25519.PubKey(v.Pubkey[:]).Address().Bytes())
+		reason := PowerChangeByOther
+		if slashed[consAddr] {
+			reason = PowerChangeBySlash
+		} else if v.VotingPower == 0 && retiring[v.Address] {
+			reason = PowerChangeByRetire
+		} else if epochSwitched {
+			reason = PowerChangeByEpoch
+		}
+		events = append(events, &ValidatorPowerChangeEvent{
+			Height:   height,
+			Address:  v.Address,
+			Pubkey:   append([]byte{}, v.Pubkey[:]...),
+			OldPower: oldPowers[v.Address],
+			NewPower: v.VotingPower,
+			Reason:   reason,
+		})
+	}
+	return events
+}
@@ -0,0 +1,43 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/crypto/ed25519"
+
+	stakingtypes "github.com/smartbch/smartbch/staking/types"
+)
+
+func TestBuildPowerChangeEvents(t *testing.T) {
+	v1 := &stakingtypes.Validator{Address: [20]byte{0x01}, Pubkey: [32]byte{0x01}, VotingPower: 10}
+	v2 := &stakingtypes.Validator{Address: [20]byte{0x02}, Pubkey: [32]byte{0x02}, VotingPower: 20}
+	v3 := &stakingtypes.Validator{Address: [20]byte{0x03}, Pubkey: [32]byte{0x03}, VotingPower: 30, IsRetiring: true}
+	oldVals := []*stakingtypes.Validator{v1, v2, v3}
+
+	v1New := *v1
+	v1New.VotingPower = 5
+	v2New := *v2
+	v2New.VotingPower = 25
+	v3New := *v3
+	v3New.VotingPower = 0
+	updates := []*stakingtypes.Validator{&v1New, &v2New, &v3New}
+
+	var slashed [20]byte
+	copy(slashed[:], ed25519.PubKey(v1.Pubkey[:]).Address().Bytes())
+
+	events := buildPowerChangeEvents(100, oldVals, updates, oldVals, [][20]byte{slashed}, true)
+	require.Len(t, events, 3)
+	require.Equal(t, PowerChangeBySlash, events[0].Reason)
+	require.Equal(t, int64(10), events[0].OldPower)
+	require.Equal(t, int64(5), events[0].NewPower)
+	require.Equal(t, PowerChangeByEpoch, events[1].Reason)
+	require.Equal(t, PowerChangeByRetire, events[2].Reason)
+	require.Equal(t, int64(100), events[2].Height)
+
+	events = buildPowerChangeEvents(101, oldVals, []*stakingtypes.Validator{&v2New}, oldVals, nil, false)
+	require.Len(t, events, 1)
+	require.Equal(t, PowerChangeByOther, events[0].Reason)
+
+	require.Nil(t, buildPowerChangeEvents(102, oldVals, nil, oldVals, nil, true))
+}
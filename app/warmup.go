@@ -0,0 +1,85 @@
+package app
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	gethcmn "github.com/ethereum/go-ethereum/common"
+)
+
+// WarmUpStats summarizes the data read by WarmUp
+type WarmUpStats struct {
+	Blocks    int
+	Txs       int
+	Contracts int
+	Duration  time.Duration
+}
+
+type contractHeat struct {
+	addr gethcmn.Address
+	txs  int
+}
+
+// WarmUp reads the recent numBlocks blocks and the state touched by them, such that the hot data
+// are loaded into the caches of the OS and the databases before RPC is opened. The accounts and
+// bytecodes of the numContracts contracts called by the most transactions are read. Their storage
+// slots are not, because the transactions in moeingdb carry no read lists.
+func (app *App) WarmUp(numBlocks int64, numContracts int) WarmUpStats {
+	start := time.Now()
+	stats := WarmUpStats{}
+	ctx := app.GetRpcContext()
+	defer ctx.Close(false)
+
+	heats := make(map[gethcmn.Address]*contractHeat)
+	latest := ctx.GetLatestHeight()
+	for h := latest; h > 0 && h > latest-numBlocks; h-- {
+		if _, err := ctx.GetBlockByHeight(uint64(h)); err != nil {
+			break
+		}
+		txs, _, err := ctx.GetTxListByHeightWithRange(uint32(h), 0, math.MaxInt32)
+		if err != nil {
+			break
+		}
+		stats.Blocks++
+		stats.Txs += len(txs)
+		for _, tx := range txs {
+			heat := heats[tx.To]
+			if heat == nil {
+				heat = &contractHeat{addr: tx.To}
+				heats[tx.To] = heat
+			}
+			heat.txs++
+		}
+	}
+
+	for _, heat := range hottestContracts(heats, numContracts) {
+		if ctx.GetAccount(heat.addr) == nil {
+			continue
+		}
+		if ctx.GetCode(heat.addr) == nil {
+			continue // not a contract
+		}
+		stats.Contracts++
+	}
+	stats.Duration = time.Since(start)
+	return stats
+}
+
+// hottestContracts returns the n addresses called by the most transactions
+func hottestContracts(heats map[gethcmn.Address]*contractHeat, n int) []*contractHeat {
+	result := make([]*contractHeat, 0, len(heats))
+	for _, heat := range heats {
+		result = append(result, heat)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].txs != result[j].txs {
+			return result[i].txs > result[j].txs
+		}
+		return string(result[i].addr[:]) < string(result[j].addr[:])
+	})
+	if len(result) > n {
+		result = result[:n]
+	}
+	return result
+}
@@ -0,0 +1,23 @@
+package app
+
+import (
+	"testing"
+
+	gethcmn "github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHottestContracts(t *testing.T) {
+	heats := map[gethcmn.Address]*contractHeat{
+		{1}: {addr: gethcmn.Address{1}, txs: 3},
+		{2}: {addr: gethcmn.Address{2}, txs: 5},
+		{3}: {addr: gethcmn.Address{3}, txs: 3},
+		{4}: {addr: gethcmn.Address{4}, txs: 1},
+	}
+	hottest := hottestContracts(heats, 3)
+	require.Len(t, hottest, 3)
+	require.Equal(t, gethcmn.Address{2}, hottest[0].addr)
+	require.Equal(t, gethcmn.Address{1}, hottest[1].addr)
+	require.Equal(t, gethcmn.Address{3}, hottest[2].addr)
+	require.Len(t, hottestContracts(heats, 10), 4)
+}
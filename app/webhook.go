@@ -0,0 +1,83 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/tendermint/tendermint/libs/log"
+)
+
+const (
+	webhookTimeout    = 10 * time.Second
+	webhookRetryCount = 3
+	webhookQueueSize  = 100
+)
+
+// WebhookNotifier POSTs the validator voting-power change events to a configured URL
+// in JSON format, such that operators can get paged on unexpected power changes.
+type WebhookNotifier struct {
+	url    string
+	client *http.Client
+	logger log.Logger
+	sub    event.Subscription
+	ch     chan []*ValidatorPowerChangeEvent
+}
+
+func NewWebhookNotifier(url string, logger log.Logger) *WebhookNotifier {
+	return &WebhookNotifier{
+		url:    url,
+		client: &http.Client{Timeout: webhookTimeout},
+		logger: logger,
+		ch:     make(chan []*ValidatorPowerChangeEvent, webhookQueueSize),
+	}
+}
+
+// Start subscribes to the app's voting-power change feed and posts events in background.
+// The subscription is closed together with the app's subscription scope.
+func (n *WebhookNotifier) Start(app *App) {
+	n.sub = app.SubscribeValidatorPowerEvent(n.ch)
+	go n.loop()
+}
+
+func (n *WebhookNotifier) loop() {
+	for {
+		select {
+		case events := <-n.ch:
+			n.post(events)
+		case <-n.sub.Err():
+			return
+		}
+	}
+}
+
+func (n *WebhookNotifier) post(events []*ValidatorPowerChangeEvent) {
+	body, err := json.Marshal(events)
+	if err != nil {
+		n.logger.Error("cannot marshal validator power change events", "err", err)
+		return
+	}
+	for i := 0; i < webhookRetryCount; i++ {
+		err = n.postOnce(body)
+		if err == nil {
+			return
+		}
+		n.logger.Error("post to webhook failed", "url", n.url, "try", i+1, "err", err)
+		time.Sleep(time.Duration(i+1) * time.Second)
+	}
+}
+
+func (n *WebhookNotifier) postOnce(body []byte) error {
+	resp, err := n.client.Post(n.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package app
+
+import (
+	"sync"
+
+	"github.com/smartbch/moeingads/store/rabbit"
+)
+
+// BlockWitness is the state read by the transactions of a block, with the content before the
+// block was executed, so the execution of the block can be verified without the full state.
+// Every entry of MoeingADS read by the block is listed with its merkle proof against
+// ParentStateRoot, the app hash of the parent block. The block hashes read by BLOCKHASH are kept
+// by moeingdb instead of the state, so they are not included.
+type BlockWitness struct {
+	Height          int64
+	ParentStateRoot [32]byte
+	Entries         []*WitnessEntry
+}
+
+// WitnessEntry is an entry of MoeingADS read by a block. A rabbit store keeps the original Key and
+// Value under ShortKey, and Value is nil if the entry is a hole passed by the lookups of other keys.
+// Proof proves EntryBytes, the entry as it is hashed by MoeingADS.
+type WitnessEntry struct {
+	ShortKey   [rabbit.KeySize]byte
+	Key        []byte
+	Value      []byte
+	EntryBytes []byte
+	Proof      []byte
+}
+
+// entryProver returns the content of the parent state under shortKey and its proof, or a nil
+// content if no such entry exists
+type entryProver func(shortKey []byte) (content, entryBytes, proof []byte, err error)
+
+// Size returns the approximate size of the witness in bytes, with the merkle proofs
+func (w *BlockWitness) Size() int {
+	size := 0
+	for _, e := range w.Entries {
+		size += rabbit.KeySize + len(e.Key) + len(e.Value) + len(e.EntryBytes) + len(e.Proof)
+	}
+	return size
+}
+
+// buildWitness lists the entries read by a block which exist in the parent state. The entries
+// inserted by the block, and the empty slots probed by the lookups, have nothing to prove.
+func buildWitness(height int64, parentStateRoot []byte, accesses *stateAccesses, prove entryProver) (*BlockWitness, error) {
+	w := &BlockWitness{Height: height}
+	copy(w.ParentStateRoot[:], parentStateRoot)
+	for _, k := range accesses.sortedReads() {
+		content, entryBytes, proof, err := prove([]byte(k))
+		if err != nil {
+			return nil, err
+		}
+		if content == nil {
+			continue
+		}
+		e := &WitnessEntry{EntryBytes: entryBytes, Proof: proof}
+		copy(e.ShortKey[:], k)
+		if cv := rabbit.BytesToCachedValue(content); cv != nil {
+			e.Key = cv.GetKey()
+			if !cv.IsEmpty() {
+				e.Value = cv.GetValue()
+			}
+		}
+		w.Entries = append(w.Entries, e)
+	}
+	return w, nil
+}
+
+// witnessRecorder keeps the witnesses of the recent blocks committed since the node started
+type witnessRecorder struct {
+	mtx       sync.RWMutex
+	keep      int64
+	witnesses map[int64]*BlockWitness
+}
+
+func newWitnessRecorder(keep int64) *witnessRecorder {
+	return &witnessRecorder{
+		keep:      keep,
+		witnesses: make(map[int64]*BlockWitness),
+	}
+}
+
+func (r *witnessRecorder) isActive() bool {
+	return r.keep > 0
+}
+
+func (r *witnessRecorder) add(w *BlockWitness) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.witnesses[w.Height] = w
+	delete(r.witnesses, w.Height-r.keep)
+}
+
+func (r *witnessRecorder) get(height int64) *BlockWitness {
+	r.mtx.RLock()
+	defer r.mtx.RUnlock()
+	return r.witnesses[height]
+}
+
+// collectWitness records the witness of the block at height, whose state accesses were recorded
+// when the engine executed it. It must be called before the trunk is written back to the root,
+// which still holds the parent state then.
+func (app *App) collectWitness(height int64, accesses *stateAccesses) {
+	if !app.witnesses.isActive() || accesses == nil {
+		return
+	}
+	prove := func(shortKey []byte) (content, entryBytes, proof []byte, err error) {
+		content = app.root.Get(shortKey)
+		if content == nil {
+			return nil, nil, nil, nil
+		}
+		entryBytes, proof, err = app.mads.GetProof(shortKey)
+		return
+	}
+	w, err := buildWitness(height, app.root.GetRootHash(), accesses, prove)
+	if err != nil {
+		app.logger.Error("cannot build the witness", "height", height, "error", err.Error())
+		return
+	}
+	app.witnesses.add(w)
+}
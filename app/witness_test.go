@@ -0,0 +1,61 @@
+package app
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/smartbch/moeingads/store"
+	"github.com/smartbch/moeingads/store/rabbit"
+)
+
+func TestBuildWitness(t *testing.T) {
+	// prepare the parent state with a rabbit store, and read it through the recorder
+	root := store.NewMockRootStore()
+	r := rabbit.NewRabbitStore(root)
+	r.Set([]byte("a"), []byte{1})
+	r.CloseAndWriteBack(true)
+	recorder := newAccessRecorder()
+	recorder.start()
+	r = rabbit.NewRabbitStore(recorder.wrap(root))
+	require.Equal(t, []byte{1}, r.Get([]byte("a")))
+	require.Nil(t, r.Get([]byte("b")))
+	r.Close()
+	accesses := recorder.stop()
+	require.Len(t, accesses.reads, 2)
+
+	prove := func(shortKey []byte) (content, entryBytes, proof []byte, err error) {
+		content = root.Get(shortKey)
+		if content == nil {
+			return nil, nil, nil, nil
+		}
+		return content, []byte{2}, []byte{3, 4}, nil
+	}
+	w, err := buildWitness(10, []byte{0xab}, accesses, prove)
+	require.NoError(t, err)
+	require.Equal(t, int64(10), w.Height)
+	require.Equal(t, byte(0xab), w.ParentStateRoot[0])
+	require.Len(t, w.Entries, 1) // "b" does not exist
+	require.Equal(t, []byte("a"), w.Entries[0].Key)
+	require.Equal(t, []byte{1}, w.Entries[0].Value)
+	require.Equal(t, rabbit.KeySize+1+1+1+2, w.Size())
+
+	_, err = buildWitness(10, nil, accesses, func(shortKey []byte) ([]byte, []byte, []byte, error) {
+		return nil, nil, nil, errors.New("no proof")
+	})
+	require.Error(t, err)
+}
+
+func TestWitnessRecorder(t *testing.T) {
+	r := newWitnessRecorder(0)
+	require.False(t, r.isActive())
+
+	r = newWitnessRecorder(2)
+	for h := int64(1); h <= 3; h++ {
+		r.add(&BlockWitness{Height: h})
+	}
+	require.Nil(t, r.get(1))
+	require.NotNil(t, r.get(2))
+	require.NotNil(t, r.get(3))
+}
@@ -0,0 +1,267 @@
+package main
+
+import (
+	"encoding/binary"
+	"errors"
+	"math/big"
+	"sync"
+
+	gethcmn "github.com/ethereum/go-ethereum/common"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+const (
+	txBaseGas    = 21000
+	txDataGas    = 16 // per byte of the input, zero bytes are not discounted
+	mockBlockGas = 1_000_000_000
+)
+
+var (
+	errNonceTooLow         = errors.New("nonce too low")
+	errNonceTooHigh        = errors.New("nonce too high")
+	errInsufficientBalance = errors.New("insufficient balance for gas * price + value")
+	errGasTooLow           = errors.New("intrinsic gas too low")
+	errGasPriceTooLow      = errors.New("gas price is lower than the min gas price")
+	errKnownTx             = errors.New("tx already in mempool")
+)
+
+// mockTx is a tx sent to the mock chain, which is pending until it is included in a block
+type mockTx struct {
+	tx     *gethtypes.Transaction
+	from   gethcmn.Address
+	sentAt int64 // the height of the chain when the tx was sent
+
+	// set after it is included in a block
+	height   int64
+	index    int
+	gasUsed  uint64
+	contract gethcmn.Address
+}
+
+type mockBlock struct {
+	height    int64
+	hash      gethcmn.Hash
+	parent    gethcmn.Hash
+	timestamp int64
+	txs       []*mockTx
+	gasUsed   uint64
+}
+
+type mockAccount struct {
+	balance *big.Int
+	nonce   uint64
+	code    []byte
+}
+
+// mockChain produces the blocks deterministically: the same scenario and the same txs sent at
+// the same heights always lead to the same blocks, hashes and receipts
+type mockChain struct {
+	mtx      sync.RWMutex
+	scenario *Scenario
+	signer   gethtypes.Signer
+	blocks   []*mockBlock // blocks[h] is the block at height h
+	pending  []*mockTx
+	txs      map[gethcmn.Hash]*mockTx
+	accounts map[gethcmn.Address]*mockAccount
+	branch   uint64 // increased by every reorg, so the replaced blocks get new hashes
+}
+
+func newMockChain(s *Scenario) *mockChain {
+	c := &mockChain{
+		scenario: s,
+		signer:   gethtypes.NewEIP155Signer(new(big.Int).SetUint64(s.ChainId)),
+		txs:      make(map[gethcmn.Hash]*mockTx),
+	}
+	c.blocks = []*mockBlock{c.newBlock(0, gethcmn.Hash{})}
+	c.resetAccounts()
+	return c
+}
+
+func (c *mockChain) newBlock(height int64, parent gethcmn.Hash) *mockBlock {
+	var bz [16]byte
+	binary.BigEndian.PutUint64(bz[:8], uint64(height))
+	binary.BigEndian.PutUint64(bz[8:], c.branch)
+	return &mockBlock{
+		height:    height,
+		hash:      crypto.Keccak256Hash([]byte("sbch-mockrpc"), bz[:]),
+		parent:    parent,
+		timestamp: c.scenario.GenesisTime + height*c.scenario.blockTime(),
+	}
+}
+
+func (c *mockChain) resetAccounts() {
+	c.accounts = make(map[gethcmn.Address]*mockAccount)
+	for addr, balance := range c.scenario.Balances {
+		c.account(addr).balance.Set(balance.ToInt())
+	}
+	for addr, code := range c.scenario.Codes {
+		c.account(addr).code = code
+	}
+}
+
+func (c *mockChain) account(addr gethcmn.Address) *mockAccount {
+	acc, ok := c.accounts[addr]
+	if !ok {
+		acc = &mockAccount{balance: new(big.Int)}
+		c.accounts[addr] = acc
+	}
+	return acc
+}
+
+func (c *mockChain) latest() *mockBlock {
+	return c.blocks[len(c.blocks)-1]
+}
+
+func txGas(tx *gethtypes.Transaction) uint64 {
+	return txBaseGas + txDataGas*uint64(len(tx.Data()))
+}
+
+// sendTx adds a signed tx to the pending ones, after the checks done by CheckTx of smartBCH
+func (c *mockChain) sendTx(tx *gethtypes.Transaction) error {
+	from, err := gethtypes.Sender(c.signer, tx)
+	if err != nil {
+		return err
+	}
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if _, ok := c.txs[tx.Hash()]; ok {
+		return errKnownTx
+	}
+	if tx.Gas() < txGas(tx) {
+		return errGasTooLow
+	}
+	if tx.GasPrice().Cmp(c.scenario.GasPrice.ToInt()) < 0 {
+		return errGasPriceTooLow
+	}
+	nonce := c.pendingNonce(from)
+	if tx.Nonce() < nonce {
+		return errNonceTooLow
+	} else if tx.Nonce() > nonce {
+		return errNonceTooHigh
+	}
+	cost := new(big.Int).Mul(tx.GasPrice(), new(big.Int).SetUint64(tx.Gas()))
+	cost.Add(cost, tx.Value())
+	if c.pendingBalance(from).Cmp(cost) < 0 {
+		return errInsufficientBalance
+	}
+	mtx := &mockTx{tx: tx, from: from, sentAt: c.latest().height}
+	c.pending = append(c.pending, mtx)
+	c.txs[tx.Hash()] = mtx
+	return nil
+}
+
+// the nonce of the next tx sent by addr, counting the pending ones
+func (c *mockChain) pendingNonce(addr gethcmn.Address) uint64 {
+	nonce := c.account(addr).nonce
+	for _, p := range c.pending {
+		if p.from == addr {
+			nonce++
+		}
+	}
+	return nonce
+}
+
+// the balance of addr after the pending txs it sent pay the max fees
+func (c *mockChain) pendingBalance(addr gethcmn.Address) *big.Int {
+	balance := new(big.Int).Set(c.account(addr).balance)
+	for _, p := range c.pending {
+		if p.from == addr {
+			balance.Sub(balance, new(big.Int).Mul(p.tx.GasPrice(), new(big.Int).SetUint64(p.tx.Gas())))
+			balance.Sub(balance, p.tx.Value())
+		}
+	}
+	return balance
+}
+
+// mine appends a block including the pending txs sent at least scenario.Confirmations blocks ago
+func (c *mockChain) mine() *mockBlock {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return c.mineLocked(false)
+}
+
+func (c *mockChain) mineLocked(includeAll bool) *mockBlock {
+	prev := c.latest()
+	blk := c.newBlock(prev.height+1, prev.hash)
+	var rest []*mockTx
+	for _, p := range c.pending {
+		if !includeAll && blk.height < p.sentAt+c.scenario.confirmations() {
+			rest = append(rest, p)
+			continue
+		}
+		c.applyTx(blk, p)
+	}
+	c.pending = rest
+	c.blocks = append(c.blocks, blk)
+	return blk
+}
+
+func (c *mockChain) applyTx(blk *mockBlock, p *mockTx) {
+	p.height, p.index, p.gasUsed = blk.height, len(blk.txs), txGas(p.tx)
+	sender := c.account(p.from)
+	fee := new(big.Int).Mul(p.tx.GasPrice(), new(big.Int).SetUint64(p.gasUsed))
+	sender.balance.Sub(sender.balance, fee)
+	sender.balance.Sub(sender.balance, p.tx.Value())
+	if p.tx.To() == nil {
+		p.contract = crypto.CreateAddress(p.from, p.tx.Nonce())
+		c.account(p.contract).code = p.tx.Data()
+		c.account(p.contract).balance.Add(c.account(p.contract).balance, p.tx.Value())
+	} else {
+		to := c.account(*p.tx.To())
+		to.balance.Add(to.balance, p.tx.Value())
+	}
+	sender.nonce++
+	blk.txs = append(blk.txs, p)
+	blk.gasUsed += p.gasUsed
+}
+
+// reorg replaces the latest depth blocks with a new branch of the same length. The txs in the
+// replaced blocks are pending again, and included in the first block of the new branch.
+func (c *mockChain) reorg(depth int64) error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if depth <= 0 || depth >= int64(len(c.blocks)) {
+		return errors.New("invalid reorg depth")
+	}
+	fork := int64(len(c.blocks)) - depth
+	var orphaned []*mockTx
+	for _, blk := range c.blocks[fork:] {
+		for _, p := range blk.txs {
+			p.height, p.index, p.gasUsed, p.contract = 0, 0, 0, gethcmn.Address{}
+			orphaned = append(orphaned, p)
+		}
+	}
+	c.blocks = c.blocks[:fork]
+	c.pending = append(orphaned, c.pending...)
+	// replay the kept blocks to undo the state changes of the replaced ones
+	c.resetAccounts()
+	for _, blk := range c.blocks {
+		txs := blk.txs
+		blk.txs, blk.gasUsed = nil, 0
+		for _, p := range txs {
+			c.applyTx(blk, p)
+		}
+	}
+	c.branch++
+	for i := int64(0); i < depth; i++ {
+		c.mineLocked(i == 0)
+	}
+	return nil
+}
+
+func (c *mockChain) blockByHeight(height int64) *mockBlock {
+	if height < 0 || height >= int64(len(c.blocks)) {
+		return nil
+	}
+	return c.blocks[height]
+}
+
+func (c *mockChain) blockByHash(hash gethcmn.Hash) *mockBlock {
+	for _, blk := range c.blocks {
+		if blk.hash == hash {
+			return blk
+		}
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/tendermint/tendermint/libs/cli"
+)
+
+const version = "v0.1.0"
+
+const (
+	flagAddr          = "addr"
+	flagScenario      = "scenario"
+	flagBlockInterval = "block-interval"
+)
+
+func main() {
+	rootCmd := createMockRpcCmd()
+	executor := cli.Executor{Command: rootCmd, Exit: os.Exit}
+	err := executor.Execute()
+	if err != nil {
+		panic(err)
+	}
+}
+
+func createMockRpcCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "sbch-mockrpc",
+		Short: "Serve deterministic eth_ and sbch_ responses for testing wallets and dapps",
+		Long: `sbch-mockrpc serves a mock smartBCH chain over JSON-RPC. The sent txs are pending for the
+configured number of blocks before they are included, and the scenario can replace the latest
+blocks with a new branch at given heights. The blocks are produced every block-interval seconds,
+or only by the mock_mine method if it is 0.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := viper.BindPFlags(cmd.Flags()); err != nil {
+				return err
+			}
+			scenario := defaultScenario()
+			if path := viper.GetString(flagScenario); path != "" {
+				var err error
+				if scenario, err = loadScenario(path); err != nil {
+					return err
+				}
+			}
+			srv := newMockServer(scenario)
+			if interval := viper.GetInt64(flagBlockInterval); interval > 0 {
+				go func() {
+					for range time.Tick(time.Duration(interval) * time.Second) {
+						srv.produceBlock()
+					}
+				}()
+			}
+			addr := viper.GetString(flagAddr)
+			fmt.Printf("sbch-mockrpc %s listening on %s, chain id %d\n", version, addr, scenario.ChainId)
+			return http.ListenAndServe(addr, srv)
+		},
+	}
+	cmd.Flags().String(flagAddr, "127.0.0.1:8545", "the address to listen on")
+	cmd.Flags().String(flagScenario, "", "the JSON file of the scenario, the default chain has no balances")
+	cmd.Flags().Int64(flagBlockInterval, 5, "the seconds between the blocks, 0 to produce them only by mock_mine")
+	return cmd
+}
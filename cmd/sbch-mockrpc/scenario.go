@@ -0,0 +1,115 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+
+	gethcmn "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+const (
+	defaultChainId     = 10001 // the chain id of the smartBCH testnet
+	defaultGasPrice    = 1_050_000_000
+	defaultGenesisTime = 1_600_000_000
+	defaultBlockTime   = 5
+)
+
+// Scenario describes the mock chain and how it evolves, it is loaded from a JSON file such as
+//
+//	{
+//	  "chainId": 10001,
+//	  "balances": {"0x09f236e4067f5fca5b3cd7ab4b2e7d0ab89b7ba8": "0xde0b6b3a7640000"},
+//	  "confirmations": 3,
+//	  "events": [{"height": 20, "reorg": 2}],
+//	  "responses": {"sbch_getCcInfo": {"currCovenantAddress": "0x..."}}
+//	}
+type Scenario struct {
+	ChainId  uint64       `json:"chainId"`
+	GasPrice *hexutil.Big `json:"gasPrice"` // the min gas price
+	// the timestamp of the genesis block, the block at height h is produced blockTime*h later
+	GenesisTime int64 `json:"genesisTime"`
+	BlockTime   int64 `json:"blockTime"`
+	// the number of blocks a sent tx stays pending, 1 means it is included in the next block
+	Confirmations int64                             `json:"confirmations"`
+	Balances      map[gethcmn.Address]*hexutil.Big  `json:"balances"`
+	Codes         map[gethcmn.Address]hexutil.Bytes `json:"codes"`
+	// the events applied when the chain reaches their heights
+	Events []ScenarioEvent `json:"events"`
+	// the canned results of the methods which are not simulated, or which override the simulated
+	// ones, keyed by the method name
+	Responses map[string]json.RawMessage `json:"responses"`
+}
+
+// ScenarioEvent happens after the block at Height is produced
+type ScenarioEvent struct {
+	Height int64 `json:"height"`
+	// replaces the latest Reorg blocks with a new branch
+	Reorg int64 `json:"reorg"`
+}
+
+func defaultScenario() *Scenario {
+	s := &Scenario{}
+	s.fillDefaults()
+	return s
+}
+
+func loadScenario(path string) (*Scenario, error) {
+	bz, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	s := &Scenario{}
+	if err = json.Unmarshal(bz, s); err != nil {
+		return nil, fmt.Errorf("invalid scenario %s: %w", path, err)
+	}
+	s.fillDefaults()
+	for _, e := range s.Events {
+		if e.Height <= 0 || e.Reorg < 0 || e.Reorg >= e.Height {
+			return nil, fmt.Errorf("invalid event at height %d of scenario %s", e.Height, path)
+		}
+	}
+	return s, nil
+}
+
+func (s *Scenario) fillDefaults() {
+	if s.ChainId == 0 {
+		s.ChainId = defaultChainId
+	}
+	if s.GasPrice == nil {
+		s.GasPrice = (*hexutil.Big)(new(big.Int).SetUint64(defaultGasPrice))
+	}
+	if s.GenesisTime == 0 {
+		s.GenesisTime = defaultGenesisTime
+	}
+	if s.Responses == nil {
+		s.Responses = make(map[string]json.RawMessage)
+	}
+}
+
+func (s *Scenario) blockTime() int64 {
+	if s.BlockTime <= 0 {
+		return defaultBlockTime
+	}
+	return s.BlockTime
+}
+
+func (s *Scenario) confirmations() int64 {
+	if s.Confirmations <= 0 {
+		return 1
+	}
+	return s.Confirmations
+}
+
+// eventsAt returns the events to apply after the block at height is produced
+func (s *Scenario) eventsAt(height int64) []ScenarioEvent {
+	var events []ScenarioEvent
+	for _, e := range s.Events {
+		if e.Height == height {
+			events = append(events, e)
+		}
+	}
+	return events
+}
@@ -0,0 +1,710 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strconv"
+	"sync"
+
+	gethcmn "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+)
+
+const (
+	errCodeParse          = -32700
+	errCodeMethodNotFound = -32601
+	errCodeInvalidParams  = -32602
+	errCodeServer         = -32000
+
+	maxRequestBytes = 5 * 1024 * 1024
+)
+
+type rpcRequest struct {
+	JsonRpc string            `json:"jsonrpc"`
+	Id      json.RawMessage   `json:"id"`
+	Method  string            `json:"method"`
+	Params  []json.RawMessage `json:"params"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *rpcError) Error() string {
+	return e.Message
+}
+
+type rpcResponse struct {
+	JsonRpc string          `json:"jsonrpc"`
+	Id      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type handler func(params []json.RawMessage) (interface{}, error)
+
+// mockServer answers the JSON-RPC requests from the mock chain, or with the canned responses of
+// the scenario
+type mockServer struct {
+	chain    *mockChain
+	handlers map[string]handler
+	mtx      sync.Mutex // serializes the block production
+}
+
+func newMockServer(s *Scenario) *mockServer {
+	srv := &mockServer{
+		chain: newMockChain(s),
+	}
+	srv.handlers = map[string]handler{
+		"web3_clientVersion": func([]json.RawMessage) (interface{}, error) { return "sbch-mockrpc/" + version, nil },
+		"net_version":        func([]json.RawMessage) (interface{}, error) { return strconv.FormatUint(s.ChainId, 10), nil },
+		"net_listening":      func([]json.RawMessage) (interface{}, error) { return true, nil },
+		"net_peerCount":      func([]json.RawMessage) (interface{}, error) { return hexutil.Uint(0), nil },
+		"eth_chainId":        func([]json.RawMessage) (interface{}, error) { return hexutil.Uint64(s.ChainId), nil },
+		"eth_gasPrice":       func([]json.RawMessage) (interface{}, error) { return s.GasPrice, nil },
+		"eth_syncing":        func([]json.RawMessage) (interface{}, error) { return false, nil },
+		"eth_mining":         func([]json.RawMessage) (interface{}, error) { return false, nil },
+		"eth_hashrate":       func([]json.RawMessage) (interface{}, error) { return hexutil.Uint64(0), nil },
+		"eth_accounts":       func([]json.RawMessage) (interface{}, error) { return []gethcmn.Address{}, nil },
+		"eth_getLogs":        func([]json.RawMessage) (interface{}, error) { return []interface{}{}, nil },
+		"eth_getStorageAt":   func([]json.RawMessage) (interface{}, error) { return gethcmn.Hash{}, nil },
+		"eth_call":           func([]json.RawMessage) (interface{}, error) { return hexutil.Bytes{}, nil },
+
+		"eth_getUncleCountByBlockNumber": func([]json.RawMessage) (interface{}, error) { return hexutil.Uint(0), nil },
+		"eth_getUncleCountByBlockHash":   func([]json.RawMessage) (interface{}, error) { return hexutil.Uint(0), nil },
+
+		"eth_blockNumber":                         srv.blockNumber,
+		"eth_getBalance":                          srv.getBalance,
+		"eth_getTransactionCount":                 srv.getTransactionCount,
+		"eth_getCode":                             srv.getCode,
+		"eth_estimateGas":                         srv.estimateGas,
+		"eth_sendRawTransaction":                  srv.sendRawTransaction,
+		"eth_getTransactionByHash":                srv.getTransactionByHash,
+		"eth_getTransactionReceipt":               srv.getTransactionReceipt,
+		"eth_getBlockByNumber":                    srv.getBlockByNumber,
+		"eth_getBlockByHash":                      srv.getBlockByHash,
+		"eth_getBlockTransactionCountByNumber":    srv.getBlockTxCountByNumber,
+		"eth_getBlockTransactionCountByHash":      srv.getBlockTxCountByHash,
+		"eth_getTransactionByBlockNumberAndIndex": srv.getTxByBlockNumberAndIndex,
+		"eth_getTransactionByBlockHashAndIndex":   srv.getTxByBlockHashAndIndex,
+
+		"sbch_getTxListByHeight":      srv.getTxListByHeight,
+		"sbch_getTransactionReceipt":  srv.getTransactionReceipt,
+		"sbch_queryTxBySrc":           srv.queryTxBySrc,
+		"sbch_queryTxByDst":           srv.queryTxByDst,
+		"sbch_queryTxByAddr":          srv.queryTxByAddr,
+		"sbch_getAddressCount":        srv.getAddressCount,
+		"sbch_healthCheck":            srv.healthCheck,
+		"sbch_getPendingTransactions": srv.getPendingTransactions,
+
+		// drive the mock chain from the tests of the frontends
+		"mock_mine":        srv.mockMine,
+		"mock_reorg":       srv.mockReorg,
+		"mock_setBalance":  srv.mockSetBalance,
+		"mock_setResponse": srv.mockSetResponse,
+	}
+	return srv
+}
+
+func (srv *mockServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// the frontends are served from other origins
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+	if r.Method == http.MethodOptions {
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "only POST is supported", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxRequestBytes))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(srv.handleBody(body))
+}
+
+func (srv *mockServer) handleBody(body []byte) interface{} {
+	body = bytes.TrimSpace(body)
+	if len(body) != 0 && body[0] == '[' {
+		var reqs []rpcRequest
+		if err := json.Unmarshal(body, &reqs); err != nil {
+			return parseErrorResponse(err)
+		}
+		resps := make([]*rpcResponse, len(reqs))
+		for i := range reqs {
+			resps[i] = srv.handle(&reqs[i])
+		}
+		return resps
+	}
+	var req rpcRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		return parseErrorResponse(err)
+	}
+	return srv.handle(&req)
+}
+
+func parseErrorResponse(err error) *rpcResponse {
+	return &rpcResponse{JsonRpc: "2.0", Id: json.RawMessage("null"),
+		Error: &rpcError{Code: errCodeParse, Message: err.Error()}}
+}
+
+func (srv *mockServer) handle(req *rpcRequest) *rpcResponse {
+	resp := &rpcResponse{JsonRpc: "2.0", Id: req.Id}
+	result, err := srv.call(req.Method, req.Params)
+	if err != nil {
+		var e *rpcError
+		if !errors.As(err, &e) {
+			e = &rpcError{Code: errCodeServer, Message: err.Error()}
+		}
+		resp.Error = e
+		return resp
+	}
+	if result == nil {
+		result = json.RawMessage("null")
+	}
+	resp.Result = result
+	return resp
+}
+
+// call returns the canned response of the method if any, otherwise the simulated one
+func (srv *mockServer) call(method string, params []json.RawMessage) (interface{}, error) {
+	srv.chain.mtx.RLock()
+	canned, ok := srv.chain.scenario.Responses[method]
+	srv.chain.mtx.RUnlock()
+	if ok {
+		return canned, nil
+	}
+	h, ok := srv.handlers[method]
+	if !ok {
+		return nil, &rpcError{Code: errCodeMethodNotFound,
+			Message: fmt.Sprintf("the method %s is not mocked, add its result to the responses of the scenario", method)}
+	}
+	return h(params)
+}
+
+// produceBlock mines a block and applies the events of the scenario at its height
+func (srv *mockServer) produceBlock() {
+	srv.mtx.Lock()
+	defer srv.mtx.Unlock()
+	blk := srv.chain.mine()
+	for _, e := range srv.chain.scenario.eventsAt(blk.height) {
+		if e.Reorg > 0 {
+			_ = srv.chain.reorg(e.Reorg)
+		}
+	}
+}
+
+/*------ the arguments ------*/
+
+func invalidParams(format string, a ...interface{}) error {
+	return &rpcError{Code: errCodeInvalidParams, Message: fmt.Sprintf(format, a...)}
+}
+
+func parseArg(params []json.RawMessage, i int, v interface{}) error {
+	if i >= len(params) {
+		return invalidParams("missing value for required argument %d", i)
+	}
+	if err := json.Unmarshal(params[i], v); err != nil {
+		return invalidParams("invalid argument %d: %s", i, err.Error())
+	}
+	return nil
+}
+
+// parseOptionalArg leaves v unchanged if the argument is omitted
+func parseOptionalArg(params []json.RawMessage, i int, v interface{}) error {
+	if i >= len(params) || string(params[i]) == "null" {
+		return nil
+	}
+	return parseArg(params, i, v)
+}
+
+// parseBlockTag returns the height of a block number or tag, chain.mtx must be held by the caller
+func (srv *mockServer) parseBlockTag(params []json.RawMessage, i int) (int64, error) {
+	tag := "latest"
+	if err := parseOptionalArg(params, i, &tag); err != nil {
+		return 0, err
+	}
+	switch tag {
+	case "latest", "pending", "safe", "finalized":
+		return srv.chain.latest().height, nil
+	case "earliest":
+		return 0, nil
+	}
+	n, err := hexutil.DecodeUint64(tag)
+	if err != nil {
+		return 0, invalidParams("invalid block number %s", tag)
+	}
+	return int64(n), nil
+}
+
+/*------ the simulated methods ------*/
+
+func (srv *mockServer) blockNumber([]json.RawMessage) (interface{}, error) {
+	srv.chain.mtx.RLock()
+	defer srv.chain.mtx.RUnlock()
+	return hexutil.Uint64(srv.chain.latest().height), nil
+}
+
+// the state is only kept for the latest block, the historical queries get the latest state
+func (srv *mockServer) getBalance(params []json.RawMessage) (interface{}, error) {
+	var addr gethcmn.Address
+	if err := parseArg(params, 0, &addr); err != nil {
+		return nil, err
+	}
+	srv.chain.mtx.Lock()
+	defer srv.chain.mtx.Unlock()
+	return (*hexutil.Big)(new(big.Int).Set(srv.chain.account(addr).balance)), nil
+}
+
+func (srv *mockServer) getTransactionCount(params []json.RawMessage) (interface{}, error) {
+	var addr gethcmn.Address
+	if err := parseArg(params, 0, &addr); err != nil {
+		return nil, err
+	}
+	tag := "latest"
+	if err := parseOptionalArg(params, 1, &tag); err != nil {
+		return nil, err
+	}
+	srv.chain.mtx.Lock()
+	defer srv.chain.mtx.Unlock()
+	if tag == "pending" {
+		return hexutil.Uint64(srv.chain.pendingNonce(addr)), nil
+	}
+	return hexutil.Uint64(srv.chain.account(addr).nonce), nil
+}
+
+func (srv *mockServer) getCode(params []json.RawMessage) (interface{}, error) {
+	var addr gethcmn.Address
+	if err := parseArg(params, 0, &addr); err != nil {
+		return nil, err
+	}
+	srv.chain.mtx.Lock()
+	defer srv.chain.mtx.Unlock()
+	return hexutil.Bytes(srv.chain.account(addr).code), nil
+}
+
+func (srv *mockServer) estimateGas(params []json.RawMessage) (interface{}, error) {
+	var args struct {
+		Data  hexutil.Bytes `json:"data"`
+		Input hexutil.Bytes `json:"input"`
+	}
+	if err := parseArg(params, 0, &args); err != nil {
+		return nil, err
+	}
+	data := args.Input
+	if len(data) == 0 {
+		data = args.Data
+	}
+	return hexutil.Uint64(txBaseGas + txDataGas*uint64(len(data))), nil
+}
+
+func (srv *mockServer) sendRawTransaction(params []json.RawMessage) (interface{}, error) {
+	var raw hexutil.Bytes
+	if err := parseArg(params, 0, &raw); err != nil {
+		return nil, err
+	}
+	tx := new(gethtypes.Transaction)
+	if err := tx.UnmarshalBinary(raw); err != nil {
+		return nil, err
+	}
+	if err := srv.chain.sendTx(tx); err != nil {
+		return nil, err
+	}
+	return tx.Hash(), nil
+}
+
+func (srv *mockServer) getTransactionByHash(params []json.RawMessage) (interface{}, error) {
+	var hash gethcmn.Hash
+	if err := parseArg(params, 0, &hash); err != nil {
+		return nil, err
+	}
+	srv.chain.mtx.RLock()
+	defer srv.chain.mtx.RUnlock()
+	p, ok := srv.chain.txs[hash]
+	if !ok {
+		return nil, nil
+	}
+	return srv.txResp(p), nil
+}
+
+func (srv *mockServer) getTransactionReceipt(params []json.RawMessage) (interface{}, error) {
+	var hash gethcmn.Hash
+	if err := parseArg(params, 0, &hash); err != nil {
+		return nil, err
+	}
+	srv.chain.mtx.RLock()
+	defer srv.chain.mtx.RUnlock()
+	p, ok := srv.chain.txs[hash]
+	if !ok || p.height == 0 {
+		return nil, nil // not found or pending
+	}
+	return srv.receiptResp(p), nil
+}
+
+func (srv *mockServer) getBlockByNumber(params []json.RawMessage) (interface{}, error) {
+	srv.chain.mtx.RLock()
+	defer srv.chain.mtx.RUnlock()
+	height, err := srv.parseBlockTag(params, 0)
+	if err != nil {
+		return nil, err
+	}
+	var fullTx bool
+	if err = parseOptionalArg(params, 1, &fullTx); err != nil {
+		return nil, err
+	}
+	if blk := srv.chain.blockByHeight(height); blk != nil {
+		return srv.blockResp(blk, fullTx), nil
+	}
+	return nil, nil
+}
+
+func (srv *mockServer) getBlockByHash(params []json.RawMessage) (interface{}, error) {
+	var hash gethcmn.Hash
+	if err := parseArg(params, 0, &hash); err != nil {
+		return nil, err
+	}
+	var fullTx bool
+	if err := parseOptionalArg(params, 1, &fullTx); err != nil {
+		return nil, err
+	}
+	srv.chain.mtx.RLock()
+	defer srv.chain.mtx.RUnlock()
+	if blk := srv.chain.blockByHash(hash); blk != nil {
+		return srv.blockResp(blk, fullTx), nil
+	}
+	return nil, nil
+}
+
+func (srv *mockServer) getBlockTxCountByNumber(params []json.RawMessage) (interface{}, error) {
+	srv.chain.mtx.RLock()
+	defer srv.chain.mtx.RUnlock()
+	height, err := srv.parseBlockTag(params, 0)
+	if err != nil {
+		return nil, err
+	}
+	if blk := srv.chain.blockByHeight(height); blk != nil {
+		return hexutil.Uint(len(blk.txs)), nil
+	}
+	return nil, nil
+}
+
+func (srv *mockServer) getBlockTxCountByHash(params []json.RawMessage) (interface{}, error) {
+	var hash gethcmn.Hash
+	if err := parseArg(params, 0, &hash); err != nil {
+		return nil, err
+	}
+	srv.chain.mtx.RLock()
+	defer srv.chain.mtx.RUnlock()
+	if blk := srv.chain.blockByHash(hash); blk != nil {
+		return hexutil.Uint(len(blk.txs)), nil
+	}
+	return nil, nil
+}
+
+func (srv *mockServer) getTxByBlockNumberAndIndex(params []json.RawMessage) (interface{}, error) {
+	var idx hexutil.Uint
+	if err := parseArg(params, 1, &idx); err != nil {
+		return nil, err
+	}
+	srv.chain.mtx.RLock()
+	defer srv.chain.mtx.RUnlock()
+	height, err := srv.parseBlockTag(params, 0)
+	if err != nil {
+		return nil, err
+	}
+	return srv.txInBlock(srv.chain.blockByHeight(height), int(idx)), nil
+}
+
+func (srv *mockServer) getTxByBlockHashAndIndex(params []json.RawMessage) (interface{}, error) {
+	var hash gethcmn.Hash
+	var idx hexutil.Uint
+	if err := parseArg(params, 0, &hash); err != nil {
+		return nil, err
+	}
+	if err := parseArg(params, 1, &idx); err != nil {
+		return nil, err
+	}
+	srv.chain.mtx.RLock()
+	defer srv.chain.mtx.RUnlock()
+	return srv.txInBlock(srv.chain.blockByHash(hash), int(idx)), nil
+}
+
+func (srv *mockServer) txInBlock(blk *mockBlock, idx int) interface{} {
+	if blk == nil || idx >= len(blk.txs) {
+		return nil
+	}
+	return srv.txResp(blk.txs[idx])
+}
+
+func (srv *mockServer) getTxListByHeight(params []json.RawMessage) (interface{}, error) {
+	srv.chain.mtx.RLock()
+	defer srv.chain.mtx.RUnlock()
+	height, err := srv.parseBlockTag(params, 0)
+	if err != nil {
+		return nil, err
+	}
+	receipts := []map[string]interface{}{}
+	if blk := srv.chain.blockByHeight(height); blk != nil {
+		for _, p := range blk.txs {
+			receipts = append(receipts, srv.receiptResp(p))
+		}
+	}
+	return receipts, nil
+}
+
+func (srv *mockServer) queryTxBySrc(params []json.RawMessage) (interface{}, error) {
+	return srv.queryTxs(params, true, false)
+}
+
+func (srv *mockServer) queryTxByDst(params []json.RawMessage) (interface{}, error) {
+	return srv.queryTxs(params, false, true)
+}
+
+func (srv *mockServer) queryTxByAddr(params []json.RawMessage) (interface{}, error) {
+	return srv.queryTxs(params, true, true)
+}
+
+// queryTxs returns the txs sent from or to addr in the blocks [start, end]
+func (srv *mockServer) queryTxs(params []json.RawMessage, bySrc, byDst bool) (interface{}, error) {
+	var addr gethcmn.Address
+	if err := parseArg(params, 0, &addr); err != nil {
+		return nil, err
+	}
+	var limit hexutil.Uint64
+	if err := parseOptionalArg(params, 3, &limit); err != nil {
+		return nil, err
+	}
+	srv.chain.mtx.RLock()
+	defer srv.chain.mtx.RUnlock()
+	start, err := srv.parseBlockTag(params, 1)
+	if err != nil {
+		return nil, err
+	}
+	end, err := srv.parseBlockTag(params, 2)
+	if err != nil {
+		return nil, err
+	}
+	txs := []map[string]interface{}{}
+	for h := start; h <= end; h++ {
+		blk := srv.chain.blockByHeight(h)
+		if blk == nil {
+			break
+		}
+		for _, p := range blk.txs {
+			if (bySrc && p.from == addr) || (byDst && p.tx.To() != nil && *p.tx.To() == addr) {
+				txs = append(txs, srv.txResp(p))
+			}
+		}
+		if limit != 0 && len(txs) >= int(limit) {
+			return txs[:limit], nil
+		}
+	}
+	return txs, nil
+}
+
+func (srv *mockServer) getAddressCount(params []json.RawMessage) (interface{}, error) {
+	var kind string
+	var addr gethcmn.Address
+	if err := parseArg(params, 0, &kind); err != nil {
+		return nil, err
+	}
+	if err := parseArg(params, 1, &addr); err != nil {
+		return nil, err
+	}
+	srv.chain.mtx.RLock()
+	defer srv.chain.mtx.RUnlock()
+	var count uint64
+	for _, blk := range srv.chain.blocks {
+		for _, p := range blk.txs {
+			isFrom, isTo := p.from == addr, p.tx.To() != nil && *p.tx.To() == addr
+			if (kind == "from" && isFrom) || (kind == "to" && isTo) || (kind == "both" && (isFrom || isTo)) {
+				count++
+			}
+		}
+	}
+	return hexutil.Uint64(count), nil
+}
+
+func (srv *mockServer) healthCheck([]json.RawMessage) (interface{}, error) {
+	srv.chain.mtx.RLock()
+	defer srv.chain.mtx.RUnlock()
+	latest := srv.chain.latest()
+	return map[string]interface{}{
+		"ok":                   true,
+		"latestBlockHeight":    hexutil.Uint64(latest.height),
+		"latestBlockTimestamp": hexutil.Uint64(latest.timestamp),
+	}, nil
+}
+
+func (srv *mockServer) getPendingTransactions([]json.RawMessage) (interface{}, error) {
+	srv.chain.mtx.RLock()
+	defer srv.chain.mtx.RUnlock()
+	txs := []map[string]interface{}{}
+	for _, p := range srv.chain.pending {
+		txs = append(txs, srv.txResp(p))
+	}
+	return txs, nil
+}
+
+/*------ the mock_ namespace ------*/
+
+// mockMine produces the given number of blocks at once, 1 if omitted
+func (srv *mockServer) mockMine(params []json.RawMessage) (interface{}, error) {
+	n := hexutil.Uint64(1)
+	if err := parseOptionalArg(params, 0, &n); err != nil {
+		return nil, err
+	}
+	for i := hexutil.Uint64(0); i < n; i++ {
+		srv.produceBlock()
+	}
+	return srv.blockNumber(nil)
+}
+
+func (srv *mockServer) mockReorg(params []json.RawMessage) (interface{}, error) {
+	var depth hexutil.Uint64
+	if err := parseArg(params, 0, &depth); err != nil {
+		return nil, err
+	}
+	srv.mtx.Lock()
+	defer srv.mtx.Unlock()
+	if err := srv.chain.reorg(int64(depth)); err != nil {
+		return nil, err
+	}
+	return true, nil
+}
+
+func (srv *mockServer) mockSetBalance(params []json.RawMessage) (interface{}, error) {
+	var addr gethcmn.Address
+	var balance hexutil.Big
+	if err := parseArg(params, 0, &addr); err != nil {
+		return nil, err
+	}
+	if err := parseArg(params, 1, &balance); err != nil {
+		return nil, err
+	}
+	srv.chain.mtx.Lock()
+	defer srv.chain.mtx.Unlock()
+	srv.chain.account(addr).balance.Set(balance.ToInt())
+	return true, nil
+}
+
+// mockSetResponse sets the canned result of a method, a null result removes it
+func (srv *mockServer) mockSetResponse(params []json.RawMessage) (interface{}, error) {
+	var method string
+	if err := parseArg(params, 0, &method); err != nil {
+		return nil, err
+	}
+	if len(params) < 2 {
+		return nil, invalidParams("missing value for required argument 1")
+	}
+	srv.chain.mtx.Lock()
+	defer srv.chain.mtx.Unlock()
+	if string(params[1]) == "null" {
+		delete(srv.chain.scenario.Responses, method)
+	} else {
+		srv.chain.scenario.Responses[method] = params[1]
+	}
+	return true, nil
+}
+
+/*------ the responses, in the format of smartbchd ------*/
+
+func (srv *mockServer) blockResp(blk *mockBlock, fullTx bool) map[string]interface{} {
+	hashes := make([]gethcmn.Hash, len(blk.txs))
+	txs := make([]map[string]interface{}, len(blk.txs))
+	for i, p := range blk.txs {
+		hashes[i] = p.tx.Hash()
+		txs[i] = srv.txResp(p)
+	}
+	result := map[string]interface{}{
+		"number":           hexutil.Uint64(blk.height),
+		"hash":             blk.hash,
+		"parentHash":       blk.parent,
+		"nonce":            hexutil.Bytes(make([]byte, 8)),
+		"sha3Uncles":       gethcmn.Hash{},
+		"logsBloom":        gethtypes.Bloom{},
+		"transactionsRoot": gethcmn.Hash{},
+		"stateRoot":        gethcmn.Hash{},
+		"miner":            gethcmn.Address{},
+		"proposer":         hexutil.Bytes(make([]byte, 20)),
+		"mixHash":          gethcmn.Hash{},
+		"difficulty":       hexutil.Uint64(0),
+		"totalDifficulty":  hexutil.Uint64(0),
+		"extraData":        hexutil.Bytes(nil),
+		"size":             hexutil.Uint64(0),
+		"gasLimit":         hexutil.Uint64(mockBlockGas),
+		"gasUsed":          hexutil.Uint64(blk.gasUsed),
+		"timestamp":        hexutil.Uint64(blk.timestamp),
+		"transactions":     hashes,
+		"uncles":           []string{},
+		"receiptsRoot":     gethcmn.Hash{},
+	}
+	if fullTx {
+		result["transactions"] = txs
+	}
+	return result
+}
+
+// txResp has null blockHash, blockNumber and transactionIndex while the tx is pending
+func (srv *mockServer) txResp(p *mockTx) map[string]interface{} {
+	v, r, s := p.tx.RawSignatureValues()
+	resp := map[string]interface{}{
+		"blockHash":        nil,
+		"blockNumber":      nil,
+		"from":             p.from,
+		"gas":              hexutil.Uint64(p.tx.Gas()),
+		"gasPrice":         (*hexutil.Big)(p.tx.GasPrice()),
+		"hash":             p.tx.Hash(),
+		"input":            hexutil.Bytes(p.tx.Data()),
+		"nonce":            hexutil.Uint64(p.tx.Nonce()),
+		"to":               p.tx.To(),
+		"transactionIndex": nil,
+		"value":            (*hexutil.Big)(p.tx.Value()),
+		"v":                (*hexutil.Big)(v),
+		"r":                (*hexutil.Big)(r),
+		"s":                (*hexutil.Big)(s),
+	}
+	if p.height != 0 {
+		resp["blockHash"] = srv.chain.blocks[p.height].hash
+		resp["blockNumber"] = (*hexutil.Big)(big.NewInt(p.height))
+		resp["transactionIndex"] = hexutil.Uint64(p.index)
+	}
+	return resp
+}
+
+func (srv *mockServer) receiptResp(p *mockTx) map[string]interface{} {
+	blk := srv.chain.blocks[p.height]
+	var cumulative uint64
+	for _, q := range blk.txs[:p.index+1] {
+		cumulative += q.gasUsed
+	}
+	resp := map[string]interface{}{
+		"transactionHash":      p.tx.Hash(),
+		"transactionIndex":     hexutil.Uint64(p.index),
+		"blockHash":            blk.hash,
+		"blockNumber":          hexutil.Uint64(p.height),
+		"from":                 p.from,
+		"to":                   p.tx.To(),
+		"cumulativeGasUsed":    hexutil.Uint64(cumulative),
+		"contractAddress":      nil,
+		"gasUsed":              hexutil.Uint64(p.gasUsed),
+		"logs":                 []interface{}{},
+		"logsBloom":            gethtypes.Bloom{},
+		"status":               hexutil.Uint(gethtypes.ReceiptStatusSuccessful),
+		"internalTransactions": []interface{}{},
+	}
+	if p.tx.To() == nil {
+		resp["contractAddress"] = p.contract
+	}
+	return resp
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	gethcmn "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+func callMock(t *testing.T, srv *mockServer, method string, params ...interface{}) *rpcResponse {
+	var raw []json.RawMessage
+	for _, p := range params {
+		bz, err := json.Marshal(p)
+		require.NoError(t, err)
+		raw = append(raw, bz)
+	}
+	return srv.handle(&rpcRequest{JsonRpc: "2.0", Id: json.RawMessage("1"), Method: method, Params: raw})
+}
+
+func TestPendingTxConfirms(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	s := defaultScenario()
+	s.Confirmations = 2
+	s.Balances = map[gethcmn.Address]*hexutil.Big{from: (*hexutil.Big)(big.NewInt(1e18))}
+	srv := newMockServer(s)
+
+	to := gethcmn.HexToAddress("0x1234")
+	tx := gethtypes.NewTransaction(0, to, big.NewInt(1000), txBaseGas, s.GasPrice.ToInt(), nil)
+	tx, err := gethtypes.SignTx(tx, srv.chain.signer, key)
+	require.NoError(t, err)
+	raw, _ := tx.MarshalBinary()
+	resp := callMock(t, srv, "eth_sendRawTransaction", hexutil.Bytes(raw))
+	require.Nil(t, resp.Error)
+	require.Equal(t, tx.Hash(), resp.Result)
+	resp = callMock(t, srv, "eth_sendRawTransaction", hexutil.Bytes(raw))
+	require.Equal(t, errKnownTx.Error(), resp.Error.Message)
+	require.Equal(t, hexutil.Uint64(1), callMock(t, srv, "eth_getTransactionCount", from, "pending").Result)
+
+	// pending for 2 blocks
+	srv.produceBlock()
+	require.Nil(t, callMock(t, srv, "eth_getTransactionByHash", tx.Hash()).Result.(map[string]interface{})["blockNumber"])
+	require.Equal(t, json.RawMessage("null"), callMock(t, srv, "eth_getTransactionReceipt", tx.Hash()).Result)
+	srv.produceBlock()
+	receipt := callMock(t, srv, "eth_getTransactionReceipt", tx.Hash()).Result.(map[string]interface{})
+	require.Equal(t, hexutil.Uint64(2), receipt["blockNumber"])
+	require.Equal(t, (*hexutil.Big)(big.NewInt(1000)), callMock(t, srv, "eth_getBalance", to, "latest").Result)
+}
+
+func TestReorgEvent(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	s := defaultScenario()
+	s.Balances = map[gethcmn.Address]*hexutil.Big{from: (*hexutil.Big)(big.NewInt(1e18))}
+	s.Events = []ScenarioEvent{{Height: 4, Reorg: 2}}
+	srv := newMockServer(s)
+
+	srv.produceBlock()
+	srv.produceBlock()
+	tx := gethtypes.NewTransaction(0, gethcmn.HexToAddress("0x1234"), big.NewInt(1000), txBaseGas, s.GasPrice.ToInt(), nil)
+	tx, err := gethtypes.SignTx(tx, srv.chain.signer, key)
+	require.NoError(t, err)
+	require.NoError(t, srv.chain.sendTx(tx))
+	srv.produceBlock()
+	require.EqualValues(t, 3, srv.chain.txs[tx.Hash()].height)
+	oldHash := srv.chain.blocks[3].hash
+
+	// the blocks 3 and 4 are replaced, and the tx is included in the new block 3
+	srv.produceBlock()
+	require.Equal(t, hexutil.Uint64(4), callMock(t, srv, "eth_blockNumber").Result)
+	require.NotEqual(t, oldHash, srv.chain.blocks[3].hash)
+	require.Equal(t, srv.chain.blocks[2].hash, srv.chain.blocks[3].parent)
+	require.EqualValues(t, 3, srv.chain.txs[tx.Hash()].height)
+	require.Equal(t, uint64(1), srv.chain.account(from).nonce)
+
+	// canned responses override the simulated ones
+	require.Equal(t, errCodeMethodNotFound, callMock(t, srv, "sbch_getCcInfo").Error.Code)
+	require.Nil(t, callMock(t, srv, "mock_setResponse", "eth_blockNumber", "0x64").Error)
+	require.Equal(t, json.RawMessage(`"0x64"`), callMock(t, srv, "eth_blockNumber").Result)
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/smartbch/smartbch/internal/multisig"
+	"github.com/smartbch/smartbch/rpc/client"
+)
+
+const (
+	flagAdminParam   = "param"
+	flagAdminNonce   = "nonce"
+	flagAdminTtl     = "ttl"
+	flagAdminPayload = "payload"
+	flagAdminSigs    = "sigs"
+)
+
+func AdminOpCmd(ctx *Context) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "admin-op",
+		Short: "build, sign offline and submit the privileged operations which require the signatures of the operators",
+	}
+	cmd.AddCommand(AdminOpNewCmd(ctx))
+	cmd.AddCommand(AdminOpSignCmd(ctx))
+	cmd.AddCommand(AdminOpSubmitCmd(ctx))
+	return cmd
+}
+
+func AdminOpNewCmd(_ *Context) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "new",
+		Short: "print the payload of an admin operation, which is signed by the operators with 'admin-op sign'",
+		Example: `
+smartbchd admin-op new --action=add-traced-address --param=address=0x... --chain-id=0x2710 --nonce=8 --ttl=2h > op.json
+`,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			chainID, err := parseChainID(viper.GetString(flagChainId))
+			if err != nil {
+				return err
+			}
+			op := multisig.Op{
+				Action:  viper.GetString(flagAction),
+				Params:  make(map[string]string),
+				ChainId: chainID.Uint64(),
+				Nonce:   viper.GetUint64(flagAdminNonce),
+				Expiry:  time.Now().Add(viper.GetDuration(flagAdminTtl)).Unix(),
+			}
+			if op.Action == "" {
+				return errors.New(flagAction + " is missing")
+			}
+			for _, kv := range viper.GetStringSlice(flagAdminParam) {
+				k, v, ok := strings.Cut(kv, "=")
+				if !ok {
+					return fmt.Errorf("invalid param %q, it must be key=value", kv)
+				}
+				op.Params[k] = v
+			}
+			bz, _ := json.Marshal(op)
+			fmt.Println(string(bz))
+			return nil
+		},
+	}
+	cmd.Flags().String(flagAction, "", "set-rpc-key, add-traced-address, remove-traced-address, rescan-cc, pause-cc-collect or resume-cc-collect")
+	cmd.Flags().StringSlice(flagAdminParam, nil, "the parameters of the operation, key=value, such as address=0x..., key=<hex private key>, begin=<BCH height> or end=<BCH height>")
+	cmd.Flags().String(flagChainId, "0x2710", "the chain id of the node")
+	cmd.Flags().Uint64(flagAdminNonce, 0, "must be larger than the nonce of the last operation accepted by the node (sbch_getAdminOpNonce)")
+	cmd.Flags().Duration(flagAdminTtl, time.Hour, "the operation expires after this duration, at most 24h")
+	return cmd
+}
+
+func AdminOpSignCmd(_ *Context) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "sign",
+		Short: "sign the payload of an admin operation offline with personal_sign and print the signature",
+		Example: `
+smartbchd admin-op sign --payload=op.json --signer-key=<hex private key of an operator>
+`,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			payload, err := readAdminOpPayload(viper.GetString(flagAdminPayload))
+			if err != nil {
+				return err
+			}
+			key, err := hex.DecodeString(strings.TrimPrefix(viper.GetString(flagSignerKey), "0x"))
+			if err != nil {
+				return errors.New(flagSignerKey + " is invalid")
+			}
+			sig, err := multisig.Sign(payload, key)
+			if err != nil {
+				return err
+			}
+			fmt.Println(hexutil.Encode(sig))
+			return nil
+		},
+	}
+	cmd.Flags().String(flagAdminPayload, "op.json", "the file of the payload printed by 'admin-op new'")
+	cmd.Flags().String(flagSignerKey, "", "hex private key of an operator")
+	return cmd
+}
+
+func AdminOpSubmitCmd(_ *Context) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "submit",
+		Short: "submit an admin operation with the signatures of the operators to a node",
+		Example: `
+smartbchd admin-op submit --payload=op.json --sigs=0x...,0x... --rpc-url=http://127.0.0.1:8545
+`,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			payload, err := readAdminOpPayload(viper.GetString(flagAdminPayload))
+			if err != nil {
+				return err
+			}
+			var sigs []hexutil.Bytes
+			for _, s := range viper.GetStringSlice(flagAdminSigs) {
+				sig, err := hexutil.Decode(s)
+				if err != nil {
+					return fmt.Errorf("invalid signature %s: %w", s, err)
+				}
+				sigs = append(sigs, sig)
+			}
+			c, err := client.Dial(viper.GetString(flagNodeRpcUrl))
+			if err != nil {
+				return err
+			}
+			defer c.Close()
+			reqCtx, cancel := context.WithTimeout(context.Background(), time.Minute)
+			defer cancel()
+			done, err := c.SubmitAdminOp(reqCtx, string(payload), sigs)
+			if err != nil {
+				return err
+			}
+			fmt.Println("accepted, changed:", done)
+			return nil
+		},
+	}
+	cmd.Flags().String(flagAdminPayload, "op.json", "the file of the payload printed by 'admin-op new'")
+	cmd.Flags().StringSlice(flagAdminSigs, nil, "the signatures printed by 'admin-op sign', separated by commas")
+	cmd.Flags().String(flagNodeRpcUrl, "http://127.0.0.1:8545", "smartBCH RPC URL")
+	return cmd
+}
+
+// readAdminOpPayload reads the payload without the trailing newline, so the bytes signed and the
+// ones submitted are the same
+func readAdminOpPayload(path string) ([]byte, error) {
+	bz, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.TrimSpace(bz), nil
+}
@@ -0,0 +1,305 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	gethcmn "github.com/ethereum/go-ethereum/common"
+	"github.com/gcash/bchd/chaincfg"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/tendermint/tendermint/libs/log"
+	rpchttp "github.com/tendermint/tendermint/rpc/client/http"
+
+	"github.com/smartbch/smartbch/crosschain/anchor"
+	"github.com/smartbch/smartbch/crosschain/bchtx"
+	"github.com/smartbch/smartbch/watcher"
+)
+
+const (
+	flagTmRpcUrl        = "tm-rpc-url"
+	flagBchNet          = "bch-net"
+	flagSignerWif       = "signer-wif"
+	flagAnchorUtxo      = "utxo"
+	flagAnchorState     = "state-file"
+	flagAnchorMinerFee  = "miner-fee"
+	flagAnchorEvery     = "every"
+	flagOperatorPubkeys = "operator-pubkeys"
+)
+
+// anchorState is the change output of the last anchor transaction, which pays the next one
+type anchorState struct {
+	Txid       string `json:"txid"`
+	Vout       uint32 `json:"vout"`
+	Amount     int64  `json:"amount"`
+	LastHeight int64  `json:"lastHeight"`
+}
+
+// AnchorVerification is the result of anchor verify
+type AnchorVerification struct {
+	BchTxid           string `json:"bchTxid"`
+	Height            int64  `json:"height"`
+	BlockHash         string `json:"blockHash"`
+	ValidatorsHash    string `json:"validatorsHash"`
+	Signer            string `json:"signer"`
+	BlockHashOK       bool   `json:"blockHashOK"`
+	ValidatorsHashOK  bool   `json:"validatorsHashOK"`
+	SignerIsOperator  bool   `json:"signerIsOperator"`
+	OperatorsProvided bool   `json:"operatorsProvided"`
+}
+
+func AnchorCmd(ctx *Context) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "anchor",
+		Short: "notarize the smartBCH blocks and validator sets on BCH with OP_RETURN transactions, and verify them",
+	}
+	cmd.AddCommand(AnchorSendCmd(ctx))
+	cmd.AddCommand(AnchorVerifyCmd(ctx))
+	return cmd
+}
+
+func AnchorSendCmd(ctx *Context) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "send",
+		Short: "send the anchor of the latest smartBCH block to BCH, once or periodically",
+		Example: `
+smartbchd anchor send \
+--tm-rpc-url=http://127.0.0.1:26657 \
+--mainnet-rpc-url=http://127.0.0.1:8332 --mainnet-rpc-username=user --mainnet-rpc-password=pass \
+--signer-wif=<operator WIF> --utxo=<txid>:<vout>:<satoshi> --state-file=anchor.json --every=1h
+`,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			net, err := getAnchorBchNet()
+			if err != nil {
+				return err
+			}
+			wif := viper.GetString(flagSignerWif)
+			if wif == "" {
+				return errors.New(flagSignerWif + " is missing")
+			}
+			statePath := viper.GetString(flagAnchorState)
+			state, err := loadAnchorState(statePath)
+			if err != nil {
+				return err
+			}
+			if s := viper.GetString(flagAnchorUtxo); s != "" {
+				if state, err = parseAnchorUtxo(s); err != nil {
+					return err
+				}
+			}
+			if state == nil {
+				return fmt.Errorf("no utxo to pay the miner fee, please set --%s", flagAnchorUtxo)
+			}
+			tmClient, err := rpchttp.New(viper.GetString(flagTmRpcUrl), "/websocket")
+			if err != nil {
+				return err
+			}
+			bchClient := watcher.NewRpcClient(viper.GetString(flagMainnetUrl), viper.GetString(flagMainnetRpcUser),
+				viper.GetString(flagMainnetRpcPassword), "text/plain;", log.NewNopLogger())
+
+			every := viper.GetDuration(flagAnchorEvery)
+			for {
+				err = sendAnchor(ctx, tmClient, bchClient, state, wif, viper.GetInt64(flagAnchorMinerFee), net)
+				if err == nil {
+					err = saveAnchorState(statePath, state)
+				}
+				if every == 0 {
+					return err
+				}
+				if err != nil {
+					ctx.Logger.Error("failed to send anchor", "error", err)
+				}
+				time.Sleep(every)
+			}
+		},
+	}
+	cmd.Flags().String(flagTmRpcUrl, "http://127.0.0.1:26657", "tendermint RPC URL of a smartBCH node")
+	cmd.Flags().String(flagMainnetUrl, "http://127.0.0.1:8332", "BCH Mainnet RPC URL")
+	cmd.Flags().String(flagMainnetRpcUser, "user", "BCH Mainnet RPC user name")
+	cmd.Flags().String(flagMainnetRpcPassword, "88888888", "BCH Mainnet RPC user password")
+	cmd.Flags().String(flagBchNet, "mainnet", "BCH network, mainnet or testnet3")
+	cmd.Flags().String(flagSignerWif, "", "WIF of the operator's key, which signs the anchor transactions")
+	cmd.Flags().String(flagAnchorUtxo, "", "the P2PKH utxo of the signer paying the first anchor, <txid>:<vout>:<satoshi>")
+	cmd.Flags().String(flagAnchorState, "anchor.json", "the file recording the change of the last anchor, which pays the next one")
+	cmd.Flags().Int64(flagAnchorMinerFee, 500, "miner fee of each anchor transaction in satoshi")
+	cmd.Flags().Duration(flagAnchorEvery, 0, "send an anchor every this interval, zero means only once")
+	return cmd
+}
+
+func sendAnchor(ctx *Context, tmClient *rpchttp.HTTP, bchClient *watcher.RpcClient, state *anchorState,
+	wif string, minerFee int64, net *chaincfg.Params) error {
+
+	reqCtx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	status, err := tmClient.Status(reqCtx)
+	if err != nil {
+		return err
+	}
+	height := status.SyncInfo.LatestBlockHeight
+	if height <= state.LastHeight {
+		ctx.Logger.Info("no new block since the last anchor", "height", height)
+		return nil
+	}
+	a, err := getAnchor(reqCtx, tmClient, height)
+	if err != nil {
+		return err
+	}
+	txid, err := hex.DecodeString(state.Txid)
+	if err != nil {
+		return err
+	}
+	utxo := anchor.Utxo{Txid: txid, Vout: state.Vout, Amount: state.Amount}
+	tx, err := anchor.BuildTx(a, utxo, wif, minerFee, net)
+	if err != nil {
+		return err
+	}
+	sentTxid, err := bchClient.SendRawTx(bchtx.MsgTxToBytes(tx))
+	if err != nil {
+		return err
+	}
+	ctx.Logger.Info("anchor sent", "height", height, "blockHash", gethcmn.Hash(a.BlockHash).Hex(), "bchTxid", sentTxid)
+	*state = anchorState{Txid: tx.TxHash().String(), Vout: 1, Amount: tx.TxOut[1].Value, LastHeight: height}
+	return nil
+}
+
+func getAnchor(ctx context.Context, tmClient *rpchttp.HTTP, height int64) (*anchor.Anchor, error) {
+	res, err := tmClient.Block(ctx, &height)
+	if err != nil {
+		return nil, err
+	}
+	a := &anchor.Anchor{Height: height}
+	copy(a.BlockHash[:], res.BlockID.Hash)
+	copy(a.ValidatorsHash[:], res.Block.Header.ValidatorsHash)
+	return a, nil
+}
+
+func AnchorVerifyCmd(_ *Context) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "verify <bch txid>",
+		Short: "check an anchor transaction on BCH against the smartBCH block it commits",
+		Example: `
+smartbchd anchor verify <bch txid> \
+--tm-rpc-url=http://127.0.0.1:26657 \
+--mainnet-rpc-url=http://127.0.0.1:8332 --mainnet-rpc-username=user --mainnet-rpc-password=pass \
+--operator-pubkeys=<hex pubkey1>,<hex pubkey2>
+`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			bchClient := watcher.NewRpcClient(viper.GetString(flagMainnetUrl), viper.GetString(flagMainnetRpcUser),
+				viper.GetString(flagMainnetRpcPassword), "text/plain;", log.NewNopLogger())
+			rawTx, err := bchClient.GetRawTx(args[0])
+			if err != nil {
+				return err
+			}
+			tx, err := bchtx.MsgTxFromBytes(rawTx)
+			if err != nil {
+				return err
+			}
+			a, signer, err := anchor.ParseTx(tx)
+			if err != nil {
+				return err
+			}
+			tmClient, err := rpchttp.New(viper.GetString(flagTmRpcUrl), "/websocket")
+			if err != nil {
+				return err
+			}
+			reqCtx, cancel := context.WithTimeout(context.Background(), time.Minute)
+			defer cancel()
+			expected, err := getAnchor(reqCtx, tmClient, a.Height)
+			if err != nil {
+				return err
+			}
+			var operators [][]byte
+			for _, s := range viper.GetStringSlice(flagOperatorPubkeys) {
+				operators = append(operators, gethcmn.FromHex(s))
+			}
+			result := verifyAnchor(a, expected, signer, operators)
+			result.BchTxid = args[0]
+			out, _ := json.MarshalIndent(result, "", "  ")
+			fmt.Println(string(out))
+			if !result.BlockHashOK || !result.ValidatorsHashOK || (result.OperatorsProvided && !result.SignerIsOperator) {
+				return errors.New("the anchor does not match")
+			}
+			return nil
+		},
+	}
+	cmd.Flags().String(flagTmRpcUrl, "http://127.0.0.1:26657", "tendermint RPC URL of a smartBCH node")
+	cmd.Flags().String(flagMainnetUrl, "http://127.0.0.1:8332", "BCH Mainnet RPC URL, the node must have txindex enabled")
+	cmd.Flags().String(flagMainnetRpcUser, "user", "BCH Mainnet RPC user name")
+	cmd.Flags().String(flagMainnetRpcPassword, "88888888", "BCH Mainnet RPC user password")
+	cmd.Flags().StringSlice(flagOperatorPubkeys, nil, "the pubkeys of the operators, if set, the anchor must be signed by one of them")
+	return cmd
+}
+
+func verifyAnchor(a, expected *anchor.Anchor, signer []byte, operators [][]byte) *AnchorVerification {
+	result := &AnchorVerification{
+		Height:            a.Height,
+		BlockHash:         gethcmn.Hash(a.BlockHash).Hex(),
+		ValidatorsHash:    gethcmn.Hash(a.ValidatorsHash).Hex(),
+		Signer:            hex.EncodeToString(signer),
+		BlockHashOK:       a.BlockHash == expected.BlockHash,
+		ValidatorsHashOK:  a.ValidatorsHash == expected.ValidatorsHash,
+		OperatorsProvided: len(operators) != 0,
+	}
+	for _, pk := range operators {
+		if len(signer) != 0 && bytes.Equal(pk, signer) {
+			result.SignerIsOperator = true
+		}
+	}
+	return result
+}
+
+func getAnchorBchNet() (*chaincfg.Params, error) {
+	switch s := viper.GetString(flagBchNet); s {
+	case "mainnet":
+		return &chaincfg.MainNetParams, nil
+	case "testnet3":
+		return &chaincfg.TestNet3Params, nil
+	default:
+		return nil, fmt.Errorf("unknown BCH network: %s", s)
+	}
+}
+
+func parseAnchorUtxo(s string) (*anchorState, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("invalid utxo: %s", s)
+	}
+	if _, err := hex.DecodeString(parts[0]); err != nil || len(parts[0]) != 64 {
+		return nil, fmt.Errorf("invalid utxo txid: %s", parts[0])
+	}
+	vout, err := strconv.ParseUint(parts[1], 10, 32)
+	if err != nil {
+		return nil, err
+	}
+	amount, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	return &anchorState{Txid: parts[0], Vout: uint32(vout), Amount: amount}, nil
+}
+
+// loadAnchorState returns nil if the file does not exist
+func loadAnchorState(path string) (*anchorState, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	state := &anchorState{}
+	return state, json.Unmarshal(data, state)
+}
+
+func saveAnchorState(path string, state *anchorState) error {
+	data, _ := json.MarshalIndent(state, "", "  ")
+	return os.WriteFile(path, data, 0600)
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/smartbch/smartbch/internal/audit"
+)
+
+const (
+	flagAction = "action"
+	flagSince  = "since"
+)
+
+func AuditLogCmd(ctx *Context) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "audit-log",
+		Short: "verify the audit log of the privileged operations and print its entries",
+		Example: `
+smartbchd audit-log --action=set-rpc-key --since=2022-01-01T00:00:00Z
+`,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			action, _ := cmd.Flags().GetString(flagAction)
+			sinceStr, _ := cmd.Flags().GetString(flagSince)
+			var since time.Time
+			if sinceStr != "" {
+				var err error
+				if since, err = time.Parse(time.RFC3339, sinceStr); err != nil {
+					return err
+				}
+			}
+
+			path := ctx.Config.AppConfig.AuditLogPath
+			entries, err := audit.ReadAll(path)
+			if err != nil {
+				return err
+			}
+			if err := audit.Verify(entries); err != nil {
+				return fmt.Errorf("%s is broken: %w", path, err)
+			}
+			for _, e := range entries {
+				if (action != "" && e.Action != action) || e.Time < since.Unix() {
+					continue
+				}
+				bz, _ := json.Marshal(e)
+				fmt.Println(string(bz))
+			}
+			return nil
+		},
+	}
+	cmd.Flags().String(flagAction, "", "only print the entries of this action")
+	cmd.Flags().String(flagSince, "", "only print the entries recorded after this time (RFC3339)")
+	return cmd
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/tendermint/tendermint/libs/log"
+
+	"github.com/smartbch/smartbch/param"
+	"github.com/smartbch/smartbch/watcher"
+)
+
+const (
+	flagFromHeight          = "from"
+	flagToHeight            = "to"
+	flagBlocksDir           = "dir"
+	flagFullBlocks          = "full"
+	flagBlocksInEpoch       = "blocks-in-epoch"
+	flagBlockFinalizeNumber = "block-finalize-number"
+	flagLastEpochEndHeight  = "last-epoch-end-height"
+)
+
+func ExportBchBlocksCmd(_ *Context) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "export-bch-blocks",
+		Short: "save the BCH blocks in a height range to a directory, which can be replayed by the watcher offline",
+		Example: `
+smartbchd export-bch-blocks \
+--mainnet-rpc-url=http://127.0.0.1:8332 --mainnet-rpc-username=user --mainnet-rpc-password=pass \
+--from=1528000 --to=1532032 --dir=./bch-blocks
+`,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			from, to := viper.GetInt64(flagFromHeight), viper.GetInt64(flagToHeight)
+			if from <= 0 || to < from {
+				return errors.New("invalid height range")
+			}
+			dir := viper.GetString(flagBlocksDir)
+			if err := os.MkdirAll(dir, 0755); err != nil {
+				return err
+			}
+			bchClient := watcher.NewRpcClient(viper.GetString(flagMainnetUrl), viper.GetString(flagMainnetRpcUser),
+				viper.GetString(flagMainnetRpcPassword), "text/plain;", log.NewNopLogger())
+			full := viper.GetBool(flagFullBlocks)
+			for h := from; h <= to; h++ {
+				bi := bchClient.GetBlockInfoByHeight(h, true)
+				if bi == nil || bi.Height != h {
+					return fmt.Errorf("cannot get the block at height %d", h)
+				}
+				if err := watcher.ExportBlock(dir, bi, full); err != nil {
+					return err
+				}
+				if (h-from+1)%1000 == 0 {
+					fmt.Printf("exported %d blocks\n", h-from+1)
+				}
+			}
+			fmt.Printf("exported the blocks in [%d, %d] to %s\n", from, to, dir)
+			return nil
+		},
+	}
+	cmd.Flags().String(flagMainnetUrl, "http://127.0.0.1:8332", "BCH Mainnet RPC URL")
+	cmd.Flags().String(flagMainnetRpcUser, "user", "BCH Mainnet RPC user name")
+	cmd.Flags().String(flagMainnetRpcPassword, "88888888", "BCH Mainnet RPC user password")
+	cmd.Flags().Int64(flagFromHeight, 0, "the first height to export")
+	cmd.Flags().Int64(flagToHeight, 0, "the last height to export")
+	cmd.Flags().String(flagBlocksDir, "bch-blocks", "the directory to save the blocks")
+	cmd.Flags().Bool(flagFullBlocks, false, "keep all the txs, which are needed to replay the cross-chain transfers, instead of only the coinbase txs")
+	return cmd
+}
+
+type replayedNomination struct {
+	Pubkey string `json:"pubkey"`
+	Count  int64  `json:"count"`
+}
+
+type replayedEpoch struct {
+	Type        string               `json:"type"` // "epoch" or "monitorVote"
+	Number      int64                `json:"number"`
+	StartHeight int64                `json:"startHeight"`
+	EndTime     int64                `json:"endTime"`
+	Nominations []replayedNomination `json:"nominations"`
+}
+
+func ReplayWatcherCmd(_ *Context) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "replay-watcher",
+		Short: "run the watcher on the BCH blocks saved by export-bch-blocks, and print the epochs and the monitor votes it builds",
+		Example: `
+smartbchd replay-watcher --dir=./bch-blocks --blocks-in-epoch=2016 --block-finalize-number=9
+`,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			dir := viper.GetString(flagBlocksDir)
+			replayClient, err := watcher.NewReplayClient(dir, log.NewNopLogger())
+			if err != nil {
+				return err
+			}
+			lastEpochEndHeight := replayClient.FirstHeight() - 1
+			if viper.IsSet(flagLastEpochEndHeight) {
+				lastEpochEndHeight = viper.GetInt64(flagLastEpochEndHeight)
+			}
+			if lastEpochEndHeight < replayClient.FirstHeight()-1 {
+				return errors.New(flagLastEpochEndHeight + " is before the first exported block")
+			}
+			config := param.DefaultConfig()
+			config.AppConfig.WatcherReplayDir = dir
+			config.AppConfig.WatcherEpochSpillPath = ""
+			w := watcher.NewWatcher(log.NewNopLogger(), nil, lastEpochEndHeight, 0, config)
+			w.SetNumBlocksInEpoch(viper.GetInt64(flagBlocksInEpoch))
+			w.SetBlockFinalizeNumber(viper.GetInt64(flagBlockFinalizeNumber))
+
+			printed := make(chan struct{})
+			go func() {
+				defer close(printed)
+				for {
+					select {
+					case e := <-w.EpochChan:
+						r := replayedEpoch{Type: "epoch", Number: e.Number, StartHeight: e.StartHeight, EndTime: e.EndTime}
+						for _, n := range e.Nominations {
+							r.Nominations = append(r.Nominations, replayedNomination{hex.EncodeToString(n.Pubkey[:]), n.NominatedCount})
+						}
+						printReplayed(r)
+					case v := <-w.MonitorVoteChan:
+						r := replayedEpoch{Type: "monitorVote", Number: v.Number, StartHeight: v.StartHeight, EndTime: v.EndTime}
+						for _, n := range v.Nominations {
+							r.Nominations = append(r.Nominations, replayedNomination{hex.EncodeToString(n.Pubkey[:]), n.NominatedCount})
+						}
+						printReplayed(r)
+					case <-time.After(time.Second):
+						s := w.GetStatus()
+						if s.LatestFinalizedHeight+viper.GetInt64(flagBlockFinalizeNumber) >= replayClient.GetLatestHeight(false) &&
+							s.EpochChanBacklog == 0 && s.SpilledEpochs == 0 && s.MonitorVoteChanBacklog == 0 {
+							return
+						}
+					}
+				}
+			}()
+			go w.Run()
+			w.WaitCatchup()
+			<-printed
+			w.Stop()
+			s := w.GetStatus()
+			fmt.Fprintf(os.Stderr, "replayed to height %d, the blocks after %d are not in an epoch yet\n",
+				s.LatestFinalizedHeight, s.LastEpochEndHeight)
+			return nil
+		},
+	}
+	cmd.Flags().String(flagBlocksDir, "bch-blocks", "the directory of the exported blocks")
+	cmd.Flags().Int64(flagBlocksInEpoch, param.StakingNumBlocksInEpoch, "the number of the BCH blocks in an epoch")
+	cmd.Flags().Int64(flagBlockFinalizeNumber, param.DefaultMainnetBlockFinalizeNumber, "the number of the blocks on top of a block before it is finalized")
+	cmd.Flags().Int64(flagLastEpochEndHeight, 0, "the height where the last epoch ends, the first exported height minus 1 by default")
+	return cmd
+}
+
+func printReplayed(r replayedEpoch) {
+	out, _ := json.Marshal(r)
+	fmt.Println(string(out))
+}
@@ -2,14 +2,23 @@ package main
 
 import (
 	"fmt"
+	"net/url"
 	"os"
 	"path"
 	"strconv"
+	"strings"
 
 	"github.com/pelletier/go-toml"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"github.com/tendermint/tendermint/libs/cli"
+
+	"github.com/smartbch/smartbch/internal/audit"
+	"github.com/smartbch/smartbch/internal/coldstore"
+	"github.com/smartbch/smartbch/internal/exporter"
+	"github.com/smartbch/smartbch/internal/multisig"
+	"github.com/smartbch/smartbch/param"
+	"github.com/smartbch/smartbch/watcher"
 )
 
 func ConfigCmd(defaultCLIHome string) *cobra.Command {
@@ -70,10 +79,99 @@ func runConfigCmd(cmd *cobra.Command, args []string) error {
 		}
 	} else /*update app.toml*/ {
 		switch key {
-		case "mainnet-rpc-url", "mainnet-rpc-username", "mainnet-rpc-password", "smartbch-rpc-url":
+		case "mainnet-rpc-url", "mainnet-rpc-username", "mainnet-rpc-password", "smartbch-rpc-url",
+			"mainnet-archive-rpc-url", "watcher-epoch-spill-path", "watcher-replay-dir", "watcher-snapshot-path", "watcher-snapshot-hash", "state-access-tracking-path", "watcher-speedup-cursor-path", "cc-info-journal-path",
+			"watcher-analytics-path", "watcher-epoch-archive-path", "determinism-report-path":
+			tree.Set(key, value)
+		case "mainnet-rpc-urls", "epoch-gossip-peers":
+			var urls []string
+			for _, u := range strings.Split(value, ",") {
+				if u = strings.TrimSpace(u); u == "" {
+					continue
+				}
+				if _, err := url.Parse(u); err != nil {
+					return err
+				}
+				urls = append(urls, u)
+			}
+			tree.Set(key, urls)
+		case "profile":
+			if _, err := param.GetProfile(value); err != nil {
+				return err
+			}
+			tree.Set(key, value)
+		case "watcher-proxy":
+			if value != "" {
+				if _, err := watcher.NewProxiedHttpClient(value); err != nil {
+					return err
+				}
+			}
+			tree.Set(key, value)
+		case "cold-store-url":
+			if value != "" {
+				if err := coldstore.CheckUrl(value); err != nil {
+					return err
+				}
+			}
+			tree.Set(key, value)
+		case "exporter-url":
+			if value != "" {
+				if err := exporter.CheckUrl(value); err != nil {
+					return err
+				}
+			}
+			tree.Set(key, value)
+		case "admin-signers":
+			if _, err := multisig.ParseSigners(value); err != nil {
+				return err
+			}
+			tree.Set(key, value)
+		case "block-finalize-number":
+			n, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return err
+			}
+			// zero selects the default of the network, the check on mainnet is done by "start"
+			if n != 0 {
+				if err := param.CheckBlockFinalizeNumber(n, 0); err != nil {
+					return err
+				}
+			}
+			tree.Set(key, n)
+		case "mainnet-zmq-url":
+			if value != "" {
+				if _, err := watcher.CheckZmqUrl(value); err != nil {
+					return err
+				}
+			}
+			tree.Set(key, value)
+		case "mainnet-rpc-rate-limit":
+			rateLimit, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return err
+			}
+			if rateLimit < 0 {
+				return fmt.Errorf("invalid mainnet-rpc-rate-limit: %s", value)
+			}
+			tree.Set(key, rateLimit)
+		case "determinism-check-ratio":
+			ratio, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return err
+			}
+			if ratio < 0 || ratio > 1 {
+				return fmt.Errorf("invalid determinism-check-ratio: %s", value)
+			}
+			tree.Set(key, ratio)
+		case "tx-event-verbosity":
+			if !param.IsValidTxEventVerbosity(value) {
+				return fmt.Errorf("invalid tx-event-verbosity: %s", value)
+			}
 			tree.Set(key, value)
 
-		case "watcher-speedup", "use_litedb", "log-validators":
+		case "watcher-speedup", "use_litedb", "log-validators", "archive-mode", "with-syncdb",
+			"no-tx-from-index", "no-tx-to-index", "rpc-snapshot-reads", "adaptive-parallelism",
+			"watcher-scan-mempool", "epoch-gossip-serve", "determinism-check-halt":
 			boolVal, err := strconv.ParseBool(value)
 			if err != nil {
 				return err
@@ -81,7 +179,13 @@ func runConfigCmd(cmd *cobra.Command, args []string) error {
 			tree.Set(key, boolVal)
 		case "retain-blocks", "retain_interval_blocks", "get_logs_max_results",
 			"blocks_kept_ads", "blocks_kept_modb", "prune_every_n",
-			"recheck_threshold", "sig_cache_size", "trunk_cache_size":
+			"recheck_threshold", "sig_cache_size", "trunk_cache_size", "indexed-log-topics",
+			"witness-kept-blocks", "warmup-blocks", "warmup-contracts",
+			"epoch-gap-threshold", "cold-store-cache-blocks", "call-result-cache-blocks", "call-result-cache-size",
+			"admin-threshold", "peer-ban-invalid-txs", "mainnet-rpc-max-retry-interval", "watcher-speedup-batch-size",
+			"cc-collect-interval", "cc-collect-max-blocks-per-round", "mempool-scan-interval",
+			"epoch-gossip-fallback-after", "watcher-analytics-retain-days",
+			"watcher-epoch-archive-keep", "propose-max-watcher-lag":
 			uintVal, err := strconv.ParseUint(value, 10, 64)
 			if err != nil {
 				return err
@@ -97,7 +201,36 @@ func runConfigCmd(cmd *cobra.Command, args []string) error {
 		return err
 	}
 	_, _ = fmt.Fprintf(os.Stderr, "configuration saved to %s\n", cfgFile)
-	return nil
+	return recordConfigUpdate(viper.GetString(cli.HomeFlag), args[0], key, value)
+}
+
+// recordConfigUpdate writes the update to the audit log used by the node, the values of
+// passwords are not recorded
+func recordConfigUpdate(home, configType, key, value string) error {
+	appConf, err := loadConfigFile(path.Join(home, "config", "app.toml"))
+	if err != nil {
+		return err
+	}
+	auditLogPath := param.DefaultAppConfigWithHome(home).AuditLogPath
+	if p, ok := appConf.Get("audit_log_path").(string); ok {
+		auditLogPath = p
+	}
+	if auditLogPath == "" {
+		return nil
+	}
+	if strings.Contains(key, "password") {
+		value = "******"
+	}
+	auditLog, err := audit.Open(auditLogPath)
+	if err != nil {
+		return err
+	}
+	defer auditLog.Close()
+	return auditLog.Record(audit.ActionUpdateConfig, audit.SourceCli, map[string]string{
+		"file":  configType,
+		"key":   key,
+		"value": value,
+	})
 }
 
 func ensureConfFile(rootDir, configType string) (string, error) {
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/spf13/viper"
+
+	"github.com/tendermint/tendermint/types"
+
+	"github.com/smartbch/smartbch/app"
+	"github.com/smartbch/smartbch/internal/bigutils"
+	"github.com/smartbch/smartbch/internal/ethutils"
+	"github.com/smartbch/smartbch/internal/testutils"
+	"github.com/smartbch/smartbch/staking"
+)
+
+const (
+	flagDev         = "dev"
+	flagDevPeriod   = "dev.period"
+	flagDevAccounts = "dev.accounts"
+	flagDevBalance  = "dev.balance"
+
+	// the chain id of the dev chain, same as the local networks of hardhat, so that the wallets
+	// can use their presets
+	devChainID = "0x7a69"
+	// 10000 BCH
+	defaultDevBalance = "10000000000000000000000"
+	// the transactions are executed after their block is committed, and their receipts become
+	// available after the next block, which is made at most this interval after the last one
+	devReceiptBlockInterval = time.Second
+)
+
+// devKeys returns n deterministic private keys, so the dev accounts are the same across restarts
+// and can be imported into the wallets once
+func devKeys(n int) []string {
+	keys := make([]string, n)
+	for i := range keys {
+		keys[i] = hex.EncodeToString(crypto.Keccak256([]byte(fmt.Sprintf("smartbch dev account %d", i))))
+	}
+	return keys
+}
+
+// setupDevMode prepares a single-validator chain without the BCH watcher. The genesis file is only
+// generated when it does not exist, so the chain in the home directory is resumed on restarts.
+func setupDevMode(ctx *Context) error {
+	nodeCfg := ctx.Config.NodeConfig
+	keys := devKeys(viper.GetInt(flagDevAccounts))
+	if len(keys) == 0 {
+		return errors.New("dev mode needs at least one account")
+	}
+	if !FileExists(nodeCfg.GenesisFile()) {
+		if err := writeDevGenesis(ctx, keys); err != nil {
+			return err
+		}
+	}
+
+	// no BCH watcher, no peers
+	ctx.Config.AppConfig.MainnetRPCUrl = ""
+	ctx.Config.AppConfig.MainnetRPCUrls = nil
+	ctx.Config.AppConfig.SmartBchRPCUrl = ""
+	ctx.Config.AppConfig.Speedup = false
+	viper.Set(flagSkipSanityCheck, true)
+	nodeCfg.P2P.Seeds = ""
+	nodeCfg.P2P.PersistentPeers = ""
+	nodeCfg.P2P.PexReactor = false
+
+	if period := time.Duration(viper.GetUint(flagDevPeriod)) * time.Second; period > 0 {
+		nodeCfg.Consensus.TimeoutCommit = period
+		nodeCfg.Consensus.SkipTimeoutCommit = false
+		nodeCfg.Consensus.CreateEmptyBlocks = true
+		nodeCfg.Consensus.CreateEmptyBlocksInterval = 0
+	} else {
+		// mine a block as soon as a transaction is received
+		nodeCfg.Consensus.TimeoutCommit = 0
+		nodeCfg.Consensus.SkipTimeoutCommit = true
+		nodeCfg.Consensus.CreateEmptyBlocks = true
+		nodeCfg.Consensus.CreateEmptyBlocksInterval = devReceiptBlockInterval
+	}
+
+	// the ganache/hardhat test methods
+	for _, flag := range []string{flagRpcAPI, flagWsAPI} {
+		if apis := viper.GetString(flag); !strings.Contains(","+apis+",", ",evm,") {
+			viper.Set(flag, apis+",evm")
+		}
+	}
+	if viper.GetString(flagUnlock) == "" {
+		viper.Set(flagUnlock, strings.Join(keys, ","))
+	}
+	for _, key := range keys {
+		privKey, _, err := ethutils.HexToPrivKey(key)
+		if err != nil {
+			return err
+		}
+		ctx.Logger.Info("dev account", "address", ethutils.PrivKeyToAddr(privKey).Hex(), "key", key)
+	}
+	return nil
+}
+
+func writeDevGenesis(ctx *Context, keys []string) error {
+	nodeCfg := ctx.Config.NodeConfig
+	_, valPubKey, err := InitializeNodeValidatorFiles(nodeCfg)
+	if err != nil {
+		return err
+	}
+	if valPubKey == nil {
+		return errors.New("failed to load the consensus key")
+	}
+	balance, ok := bigutils.ParseU256(viper.GetString(flagDevBalance))
+	if !ok {
+		return errors.New("invalid dev balance")
+	}
+	privKey, _, err := ethutils.HexToPrivKey(keys[0])
+	if err != nil {
+		return err
+	}
+	val := &app.Validator{
+		Address:      ethutils.PrivKeyToAddr(privKey),
+		RewardTo:     ethutils.PrivKeyToAddr(privKey),
+		VotingPower:  1,
+		Introduction: "dev validator",
+		MinerAddress: valPubKey.Address(),
+	}
+	copy(val.Pubkey[:], valPubKey.Bytes())
+	val.StakedCoins = staking.MinimumStakingAmount.Bytes32()
+	genData := app.GenesisData{
+		Validators: []*app.Validator{val},
+		Alloc:      testutils.KeysToGenesisAlloc(balance, keys),
+	}
+	genDoc := &types.GenesisDoc{ChainID: devChainID}
+	genDoc.AppState, err = json.Marshal(genData)
+	if err != nil {
+		return err
+	}
+	ctx.Logger.Info("generating the genesis file of the dev chain", "chainId", devChainID, "accounts", len(keys))
+	return ExportGenesisFile(genDoc, nodeCfg.GenesisFile())
+}
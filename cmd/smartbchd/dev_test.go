@@ -0,0 +1,25 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/smartbch/smartbch/internal/ethutils"
+)
+
+func TestDevKeys(t *testing.T) {
+	keys := devKeys(3)
+	require.Len(t, keys, 3)
+	require.Equal(t, keys, devKeys(3))
+	require.Equal(t, keys[:2], devKeys(2))
+	require.NotEqual(t, keys[0], keys[1])
+	for _, key := range keys {
+		_, _, err := ethutils.HexToPrivKey(key)
+		require.NoError(t, err)
+	}
+
+	id, err := parseChainID(devChainID)
+	require.NoError(t, err)
+	require.Equal(t, uint64(31337), id.Uint64())
+}
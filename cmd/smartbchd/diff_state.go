@@ -0,0 +1,97 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/smartbch/smartbch/app"
+	"github.com/smartbch/smartbch/internal/statediff"
+)
+
+const (
+	flagDiffLimit   = "limit"
+	flagDiffJSON    = "json"
+	flagStateOutput = "output"
+)
+
+func ExportStateCmd(ctx *Context) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "export-state",
+		Short: "save the accounts, the bytecode hashes and the storage in moeingads at the last committed height to a snapshot file, which diff-state compares, the node must be stopped",
+		Example: `
+smartbchd export-state --home=./node_a --output=state_a.json
+`,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			appConf := ctx.Config.AppConfig
+			root, mads := app.CreateRootStore(appConf.AppDataPath, appConf.ArchiveMode)
+			defer root.Close()
+
+			exporter := statediff.NewExporter(mads.GetCurrHeight())
+			mads.ScanAll(exporter.Add)
+			snap := exporter.Snapshot()
+			if err := statediff.WriteSnapshot(viper.GetString(flagStateOutput), snap); err != nil {
+				return err
+			}
+			fmt.Printf("exported %d accounts at height %d\n", len(snap.Accounts), snap.Height)
+			return nil
+		},
+	}
+	cmd.Flags().String(flagStateOutput, "state.json", "the snapshot file to write")
+	return cmd
+}
+
+func DiffStateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "diff-state snapshotA snapshotB",
+		Short: "Compare two state snapshots saved by export-state and report differing accounts, storage slots and balances",
+		Args:  cobra.ExactArgs(2),
+		Example: `
+smartbchd diff-state state_a.json state_b.json --limit=100
+`,
+		RunE: func(_ *cobra.Command, args []string) error {
+			snapA, err := statediff.LoadSnapshot(args[0])
+			if err != nil {
+				return fmt.Errorf("cannot load %s: %w", args[0], err)
+			}
+			snapB, err := statediff.LoadSnapshot(args[1])
+			if err != nil {
+				return fmt.Errorf("cannot load %s: %w", args[1], err)
+			}
+			diffs := statediff.Diff(snapA, snapB)
+			fmt.Printf("snapshot A: height %d, %d accounts\n", snapA.Height, len(snapA.Accounts))
+			fmt.Printf("snapshot B: height %d, %d accounts\n", snapB.Height, len(snapB.Accounts))
+			if snapA.Height != snapB.Height {
+				fmt.Println("warning: the snapshots are taken at different heights")
+			}
+			fmt.Printf("%d differences found\n", len(diffs))
+
+			limit := viper.GetInt(flagDiffLimit)
+			if limit > 0 && len(diffs) > limit {
+				diffs = diffs[:limit]
+			}
+			if viper.GetBool(flagDiffJSON) {
+				out, _ := json.MarshalIndent(diffs, "", "  ")
+				fmt.Println(string(out))
+				return nil
+			}
+			for _, d := range diffs {
+				if d.Seq != nil {
+					fmt.Printf("seq %d %s slot %s: %s => %s\n", *d.Seq, d.Kind, d.Slot.Hex(), d.A, d.B)
+				} else if d.Key != "" {
+					fmt.Printf("%s %s: %s => %s\n", d.Key, d.Kind, d.A, d.B)
+				} else if d.Slot != nil {
+					fmt.Printf("%s %s slot %s: %s => %s\n", d.Address.Hex(), d.Kind, d.Slot.Hex(), d.A, d.B)
+				} else {
+					fmt.Printf("%s %s: %s => %s\n", d.Address.Hex(), d.Kind, d.A, d.B)
+				}
+			}
+			return nil
+		},
+	}
+	cmd.Flags().Int(flagDiffLimit, 0, "max number of differences to print, 0 means no limit")
+	cmd.Flags().Bool(flagDiffJSON, false, "print the differences in JSON format")
+	return cmd
+}
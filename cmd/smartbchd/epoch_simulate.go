@@ -0,0 +1,62 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/smartbch/smartbch/watcher"
+)
+
+const flagNominationRules = "rules"
+
+func SimulateEpochsCmd(_ *Context) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "simulate-epochs",
+		Short: "recompute the validators of the epochs in a snapshot under alternative nomination rules, and print how they differ from the current rule",
+		Long: `The rules file is a JSON array of the rules, for example:
+[
+  {"name": "min-5%", "minShare": 0.05},
+  {"name": "top-30", "maxValidators": 30},
+  {"name": "at-least-10-blocks-desc", "minNominatedCount": 10, "tieBreak": "pubkeyDesc"}
+]
+Only the PoW nominations are simulated, the PoS votes are not taken into account.`,
+		Example: `
+smartbchd simulate-epochs --snapshot=./epochs.json --rules=./rules.json
+`,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			infos, err := watcher.ReadEpochSnapshot(viper.GetString(flagSnapshotPath))
+			if err != nil {
+				return err
+			}
+			bz, err := os.ReadFile(viper.GetString(flagNominationRules))
+			if err != nil {
+				return err
+			}
+			var rules []watcher.NominationRule
+			if err = json.Unmarshal(bz, &rules); err != nil {
+				return err
+			}
+			if len(rules) == 0 {
+				return errors.New("no rule to simulate")
+			}
+			outcomes, err := watcher.SimulateNominationRules(infos, rules)
+			if err != nil {
+				return err
+			}
+			out, err := json.MarshalIndent(outcomes, "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(out))
+			return nil
+		},
+	}
+	cmd.Flags().String(flagSnapshotPath, "epochs.json", "the snapshot file exported by export-epoch-snapshot")
+	cmd.Flags().String(flagNominationRules, "rules.json", "the JSON file of the rules to simulate")
+	return cmd
+}
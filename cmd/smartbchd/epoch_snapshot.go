@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/smartbch/smartbch/rpc/client"
+	"github.com/smartbch/smartbch/watcher"
+	watchertypes "github.com/smartbch/smartbch/watcher/types"
+)
+
+const (
+	flagSnapshotPath = "snapshot"
+	flagFirstEpoch   = "first-epoch"
+
+	voteInfosPerCall = 100
+)
+
+func ExportEpochSnapshotCmd(_ *Context) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "export-epoch-snapshot",
+		Short: "save the epochs and the monitor votes of a synced node to a snapshot file, which the watcher of another node can load at startup",
+		Example: `
+smartbchd export-epoch-snapshot --rpc-url=http://127.0.0.1:8545 --snapshot=./epochs.json
+`,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			c, err := client.Dial(viper.GetString(flagNodeRpcUrl))
+			if err != nil {
+				return err
+			}
+			defer c.Close()
+
+			var infos []*watchertypes.VoteInfo
+			for start := viper.GetUint64(flagFirstEpoch); ; start += voteInfosPerCall {
+				ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+				batch, err := c.VoteInfos(ctx, start, start+voteInfosPerCall)
+				cancel()
+				if err != nil {
+					return err
+				}
+				infos = append(infos, batch...)
+				if len(batch) < voteInfosPerCall {
+					break
+				}
+			}
+			if len(infos) == 0 {
+				return errors.New("no epoch is found on the node")
+			}
+			hash, err := watcher.WriteEpochSnapshot(viper.GetString(flagSnapshotPath), infos)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("exported the epochs %d to %d, set these in app.toml of the node to load it:\n",
+				infos[0].Epoch.Number, infos[len(infos)-1].Epoch.Number)
+			fmt.Printf("watcher-snapshot-path = \"%s\"\nwatcher-snapshot-hash = \"%s\"\n",
+				viper.GetString(flagSnapshotPath), hex.EncodeToString(hash[:]))
+			return nil
+		},
+	}
+	cmd.Flags().String(flagNodeRpcUrl, "http://127.0.0.1:8545", "smartBCH RPC URL, whose debug namespace is enabled")
+	cmd.Flags().String(flagSnapshotPath, "epochs.json", "the snapshot file")
+	cmd.Flags().Uint64(flagFirstEpoch, 1, "the number of the first epoch to export")
+	return cmd
+}
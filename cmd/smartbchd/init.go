@@ -20,6 +20,7 @@ import (
 	"github.com/smartbch/smartbch/app"
 	"github.com/smartbch/smartbch/internal/bigutils"
 	"github.com/smartbch/smartbch/internal/testutils"
+	"github.com/smartbch/smartbch/param"
 )
 
 const (
@@ -28,6 +29,8 @@ const (
 	flagTestKeys     = "test-keys"
 	flagTestKeysFile = "test-keys-file"
 	flagInitBal      = "init-balance"
+	flagInitProfile  = "profile"
+	flagBchNetwork   = "bch-network"
 )
 
 type printInfo struct {
@@ -70,6 +73,10 @@ func InitCmd(ctx *Context, defaultNodeHome string) *cobra.Command { // nolint: g
 				return err
 			}
 			config.Moniker = args[0]
+			profile, err := param.GetProfile(viper.GetString(flagInitProfile))
+			if err != nil {
+				return err
+			}
 			genFile := config.GenesisFile()
 			if !viper.GetBool(flagOverwrite) && FileExists(genFile) {
 				return fmt.Errorf("genesis.json file already exists: %v", genFile)
@@ -97,6 +104,11 @@ func InitCmd(ctx *Context, defaultNodeHome string) *cobra.Command { // nolint: g
 			}
 			toPrint := newPrintInfo(config.Moniker, chainID, nodeID)
 			cfg.WriteConfigFile(filepath.Join(config.RootDir, "config", "config.toml"), config)
+			if profile != nil {
+				appConfig := ctx.Config.AppConfig
+				profile.ApplyTo(appConfig)
+				param.WriteConfigFile(filepath.Join(config.RootDir, "config", "app.toml"), appConfig)
+			}
 			return displayInfo(toPrint)
 		},
 	}
@@ -106,6 +118,10 @@ func InitCmd(ctx *Context, defaultNodeHome string) *cobra.Command { // nolint: g
 	cmd.Flags().String(flagTestKeys, "", "comma separated list of hex private keys used for test")
 	cmd.Flags().String(flagTestKeysFile, "", "file contains hex private keys, one key per line")
 	cmd.Flags().String(flagInitBal, "1000000000000000000", "initial balance for test accounts")
+	cmd.Flags().String(flagInitProfile, "", "write app.toml with the defaults of a profile: "+
+		strings.Join(param.ProfileNames(), ", "))
+	cmd.Flags().String(flagBchNetwork, "", "the BCH network written in genesis.json, followed by the watchers of all the nodes: "+
+		strings.Join(param.BchNetworkNames(), ", ")+", empty means "+param.CcBchNetwork)
 	return cmd
 }
 
@@ -116,11 +132,16 @@ func getAppState() ([]byte, error) {
 		return nil, errors.New("invalid init balance")
 	}
 
+	bchNetwork := viper.GetString(flagBchNetwork)
+	if _, err := param.GetBchNetwork(bchNetwork); err != nil {
+		return nil, err
+	}
+
 	testKeys := getTestKeys()
 
 	fmt.Println("preparing genesis file ...")
 	alloc := testutils.KeysToGenesisAlloc(initBal, testKeys)
-	genData := app.GenesisData{Alloc: alloc}
+	genData := app.GenesisData{Alloc: alloc, BchNetwork: bchNetwork}
 	appState, err := json.Marshal(genData)
 	if err != nil {
 		return nil, err
@@ -38,7 +38,23 @@ func createSmartbchdCmd() *cobra.Command {
 	rootCmd.AddCommand(GenerateConsensusKeyInfoCmd(ctx))
 	rootCmd.AddCommand(GenerateGenesisValidatorCmd(ctx))
 	rootCmd.AddCommand(AddGenesisValidatorCmd(ctx))
+	rootCmd.AddCommand(PredeployCmd(ctx))
 	rootCmd.AddCommand(StakingCmd(ctx))
+	rootCmd.AddCommand(ValidatorCmd(ctx))
+	rootCmd.AddCommand(ReserveAttestationCmd(ctx))
+	rootCmd.AddCommand(AnchorCmd(ctx))
+	rootCmd.AddCommand(PegInRefundCmd(ctx))
+	rootCmd.AddCommand(WatcherSelfTestCmd(ctx))
+	rootCmd.AddCommand(ExportBchBlocksCmd(ctx))
+	rootCmd.AddCommand(ReplayWatcherCmd(ctx))
+	rootCmd.AddCommand(ExportEpochSnapshotCmd(ctx))
+	rootCmd.AddCommand(SimulateEpochsCmd(ctx))
+	rootCmd.AddCommand(AuditLogCmd(ctx))
+	rootCmd.AddCommand(AdminOpCmd(ctx))
+	rootCmd.AddCommand(RpcReplayCmd(ctx))
+	rootCmd.AddCommand(ExportStateCmd(ctx))
+	rootCmd.AddCommand(DiffStateCmd())
+	rootCmd.AddCommand(VerifyIndexCmd(ctx))
 	rootCmd.AddCommand(VersionCmd())
 	return rootCmd
 }
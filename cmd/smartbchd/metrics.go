@@ -0,0 +1,33 @@
+package main
+
+import (
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	tmlog "github.com/tendermint/tendermint/libs/log"
+)
+
+// startMetricsServer serves the metrics in the default registry of prometheus, such as the ones
+// of the watcher, at http://addr/metrics. It works without enabling instrumentation.prometheus of
+// tendermint, whose own metrics are only registered when it is enabled.
+func startMetricsServer(addr string, logger tmlog.Logger) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	server := &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	logger.Info("serving metrics", "addr", listener.Addr().String())
+	go func() {
+		if err := server.Serve(listener); err != nil {
+			logger.Error("metrics server stopped", "err", err)
+		}
+	}()
+	return nil
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+
+	abci "github.com/tendermint/tendermint/abci/types"
+	tmlog "github.com/tendermint/tendermint/libs/log"
+	"github.com/tendermint/tendermint/node"
+	"github.com/tendermint/tendermint/p2p"
+
+	"github.com/smartbch/smartbch/app"
+)
+
+// the debug message logged by the mempool of Tendermint when CheckTx rejects a new tx, it is the
+// only place where the peer sending the tx is known
+const mempoolRejectedTxMsg = "rejected bad transaction"
+
+// peerSignalLogger passes the peers of the txs rejected by CheckTx to the app, and the messages
+// to the wrapped logger. It must wrap the logger before the level filter, for the debug messages
+// to be seen.
+type peerSignalLogger struct {
+	tmlog.Logger
+	app *app.App
+}
+
+func newPeerSignalLogger(logger tmlog.Logger, appImpl *app.App) tmlog.Logger {
+	return &peerSignalLogger{Logger: logger, app: appImpl}
+}
+
+func (l *peerSignalLogger) Debug(msg string, keyvals ...interface{}) {
+	if msg == mempoolRejectedTxMsg {
+		l.reportRejectedTx(keyvals)
+	}
+	l.Logger.Debug(msg, keyvals...)
+}
+
+func (l *peerSignalLogger) With(keyvals ...interface{}) tmlog.Logger {
+	return &peerSignalLogger{Logger: l.Logger.With(keyvals...), app: l.app}
+}
+
+func (l *peerSignalLogger) reportRejectedTx(keyvals []interface{}) {
+	var peerID string
+	var code uint32
+	var hasCode bool
+	for i := 0; i+1 < len(keyvals); i += 2 {
+		switch keyvals[i] {
+		case "peerID":
+			peerID = fmt.Sprint(keyvals[i+1])
+		case "res":
+			if res, ok := keyvals[i+1].(*abci.Response_CheckTx); ok && res.CheckTx != nil {
+				code, hasCode = res.CheckTx.Code, true
+			}
+		}
+	}
+	if hasCode {
+		l.app.ReportPeerCheckTx(peerID, code)
+	}
+}
+
+// banPeerHandler disconnects a banned peer, Tendermint does not reconnect to it before the ban
+// expires because it is rejected by the peer filter of the app
+func banPeerHandler(tmNode *node.Node, logger tmlog.Logger) func(peerID, reason string) {
+	return func(peerID, reason string) {
+		sw := tmNode.Switch()
+		if peer := sw.Peers().Get(p2p.ID(peerID)); peer != nil {
+			logger.Info("disconnect banned peer", "peer", peerID, "reason", reason)
+			sw.StopPeerForError(peer, reason)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/smartbch/smartbch/crosschain"
+	ccabi "github.com/smartbch/smartbch/crosschain/abi"
+	"github.com/smartbch/smartbch/internal/ethutils"
+	"github.com/smartbch/smartbch/rpc/client"
+)
+
+const (
+	flagDryRun = "dry-run"
+)
+
+func PegInRefundCmd(_ *Context) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "peg-in-refund",
+		Short: "send the refundable lost-and-found deposits back to their senders on BCH, by calling refund() of the cc contract",
+		Example: `
+smartbchd peg-in-refund --rpc-url=http://127.0.0.1:8545 --signer-key=<hex private key> --gas-price=1050000000
+smartbchd peg-in-refund --rpc-url=http://127.0.0.1:8545 --dry-run
+`,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			c, err := client.Dial(viper.GetString(flagNodeRpcUrl))
+			if err != nil {
+				return err
+			}
+			defer c.Close()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+			defer cancel()
+			refunds, err := c.PegInRefunds(ctx)
+			if err != nil {
+				return err
+			}
+			header, err := c.HeaderByNumber(ctx, nil)
+			if err != nil {
+				return err
+			}
+			now := int64(header.Time)
+			dryRun := viper.GetBool(flagDryRun)
+			for _, r := range refunds {
+				fmt.Printf("%s:%d amount=%d target=%s refundable-time=%d\n",
+					r.Txid, r.Index, r.Amount, r.RefundTarget, r.RefundableTime)
+			}
+			if dryRun {
+				return nil
+			}
+
+			key, err := crypto.HexToECDSA(viper.GetString(flagSignerKey))
+			if err != nil {
+				return errors.New(flagSignerKey + " is missing or invalid")
+			}
+			chainID, err := c.ChainID(ctx)
+			if err != nil {
+				return err
+			}
+			nonce, err := c.PendingNonceAt(ctx, crypto.PubkeyToAddress(key.PublicKey))
+			if err != nil {
+				return err
+			}
+			to := common.Address(crosschain.CCContractAddress)
+			for _, r := range refunds {
+				if now < r.RefundableTime {
+					continue
+				}
+				if r.RefundTarget == (common.Address{}) {
+					// a deposit without receiver, a monitor must call setRefundTarget() first
+					fmt.Printf("skip %s:%d without refund target\n", r.Txid, r.Index)
+					continue
+				}
+				tx := ethutils.NewTx(nonce, &to, big.NewInt(0), crosschain.GasOfLostAndFoundRedeem,
+					big.NewInt(viper.GetInt64(flagGasPrice)), ccabi.PackRefundFunc(r.Txid.Big(), big.NewInt(int64(r.Index))))
+				if tx, err = ethutils.SignTx(tx, chainID, key); err != nil {
+					return err
+				}
+				if err = c.SendTransaction(ctx, tx); err != nil {
+					return fmt.Errorf("failed to refund %s:%d: %w", r.Txid, r.Index, err)
+				}
+				fmt.Printf("refund %s:%d in tx %s\n", r.Txid, r.Index, tx.Hash())
+				nonce++
+			}
+			return nil
+		},
+	}
+	cmd.Flags().String(flagNodeRpcUrl, "http://127.0.0.1:8545", "smartBCH RPC URL")
+	cmd.Flags().String(flagSignerKey, "", "hex private key of the account paying the gas")
+	cmd.Flags().Uint64(flagGasPrice, 1050000000, "gas price of the refund transactions")
+	cmd.Flags().Bool(flagDryRun, false, "only list the refundable deposits")
+	return cmd
+}
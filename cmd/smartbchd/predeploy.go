@@ -0,0 +1,147 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/tendermint/tendermint/libs/cli"
+	"github.com/tendermint/tendermint/types"
+
+	"github.com/smartbch/smartbch/app"
+)
+
+const (
+	flagPredeployName    = "name"
+	flagPredeployAddress = "address"
+	flagPredeployCode    = "code"
+	flagPredeployStorage = "storage"
+	flagPredeployBalance = "balance"
+)
+
+func PredeployCmd(ctx *Context) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "predeploy",
+		Short: "declare and validate the contracts deployed at genesis in genesis.json",
+	}
+	cmd.AddCommand(AddPredeployCmd(ctx))
+	cmd.AddCommand(ValidatePredeploysCmd(ctx))
+	return cmd
+}
+
+func AddPredeployCmd(ctx *Context) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "add",
+		Short: "add a contract with its runtime bytecode and storage to genesis.json",
+		Example: `
+smartbchd predeploy add --name=multicall --address=0xcA11bde05977b3631167028862bE2a173976CA11 --code=@multicall.hex
+smartbchd predeploy add --name=WBCH --address=0x... --code=0x6080... --storage=@wbch-storage.json
+`,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			p := &app.Predeploy{Name: viper.GetString(flagPredeployName)}
+			addr := viper.GetString(flagPredeployAddress)
+			if !common.IsHexAddress(addr) {
+				return errors.New(flagPredeployAddress + " is missing or invalid")
+			}
+			p.Address = common.HexToAddress(addr)
+			code, err := readArgOrFile(viper.GetString(flagPredeployCode))
+			if err != nil {
+				return err
+			}
+			if p.Code, err = hexutil.Decode(strings.TrimSpace(string(code))); err != nil {
+				return fmt.Errorf("invalid %s: %w", flagPredeployCode, err)
+			}
+			if s := viper.GetString(flagPredeployStorage); s != "" {
+				bz, err := readArgOrFile(s)
+				if err != nil {
+					return err
+				}
+				if err = json.Unmarshal(bz, &p.Storage); err != nil {
+					return fmt.Errorf("invalid %s: %w", flagPredeployStorage, err)
+				}
+			}
+			if s := viper.GetString(flagPredeployBalance); s != "" {
+				balance, err := hexutil.DecodeBig(s)
+				if err != nil {
+					return fmt.Errorf("invalid %s: %w", flagPredeployBalance, err)
+				}
+				p.Balance = (*hexutil.Big)(balance)
+			}
+			return updateGenesisData(ctx, func(gData *app.GenesisData) {
+				gData.Predeploys = append(gData.Predeploys, p)
+			})
+		},
+	}
+	cmd.Flags().String(flagPredeployName, "", "the name of the contract, only for the logs")
+	cmd.Flags().String(flagPredeployAddress, "", "the address of the contract")
+	cmd.Flags().String(flagPredeployCode, "", "the hex runtime bytecode, or @file to read it from a file")
+	cmd.Flags().String(flagPredeployStorage, "", "the JSON object of the storage slots, or @file to read it from a file")
+	cmd.Flags().String(flagPredeployBalance, "", "the hex balance in wei")
+	return cmd
+}
+
+func ValidatePredeploysCmd(ctx *Context) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "validate",
+		Short: "check the predeploys in genesis.json",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			gData, _, err := loadGenesisData(ctx)
+			if err != nil {
+				return err
+			}
+			if err = gData.ValidatePredeploys(); err != nil {
+				return err
+			}
+			for _, p := range gData.Predeploys {
+				fmt.Printf("%s %s code:%d bytes storage:%d slots\n", p.Address.Hex(), p.Name, len(p.Code), len(p.Storage))
+			}
+			fmt.Printf("%d predeploys are valid\n", len(gData.Predeploys))
+			return nil
+		},
+	}
+	return cmd
+}
+
+// readArgOrFile returns the content of the file if s is @file, otherwise s itself
+func readArgOrFile(s string) ([]byte, error) {
+	if strings.HasPrefix(s, "@") {
+		return os.ReadFile(s[1:])
+	}
+	return []byte(s), nil
+}
+
+func loadGenesisData(ctx *Context) (*app.GenesisData, *types.GenesisDoc, error) {
+	config := ctx.Config
+	config.NodeConfig.SetRoot(viper.GetString(cli.HomeFlag))
+	genDoc, err := types.GenesisDocFromFile(config.NodeConfig.GenesisFile())
+	if err != nil {
+		return nil, nil, err
+	}
+	gData := &app.GenesisData{}
+	if err = json.Unmarshal(genDoc.AppState, gData); err != nil {
+		return nil, nil, err
+	}
+	return gData, genDoc, nil
+}
+
+// updateGenesisData changes the app state of genesis.json, which must still be valid after that
+func updateGenesisData(ctx *Context, update func(gData *app.GenesisData)) error {
+	gData, genDoc, err := loadGenesisData(ctx)
+	if err != nil {
+		return err
+	}
+	update(gData)
+	if err = gData.ValidatePredeploys(); err != nil {
+		return err
+	}
+	if genDoc.AppState, err = json.Marshal(gData); err != nil {
+		return err
+	}
+	return ExportGenesisFile(genDoc, ctx.Config.NodeConfig.GenesisFile())
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	tmlog "github.com/tendermint/tendermint/libs/log"
+	tmproto "github.com/tendermint/tendermint/proto/tendermint/types"
+	tmtypes "github.com/tendermint/tendermint/types"
+)
+
+// gatedPrivValidator refuses to sign the proposals while ready returns an error, so tendermint does
+// not propose a block in this validator's turn and the round moves on to the next proposer after
+// timeout_propose. The votes are still signed, such that the chain is not halted by the validators
+// whose BCH nodes are down.
+type gatedPrivValidator struct {
+	tmtypes.PrivValidator
+	ready  func() error
+	logger tmlog.Logger
+	gated  int32 // accessed atomically, 1 while the proposals are refused
+}
+
+func newGatedPrivValidator(pv tmtypes.PrivValidator, ready func() error, logger tmlog.Logger) *gatedPrivValidator {
+	return &gatedPrivValidator{PrivValidator: pv, ready: ready, logger: logger}
+}
+
+func (pv *gatedPrivValidator) SignProposal(chainID string, proposal *tmproto.Proposal) error {
+	if err := pv.ready(); err != nil {
+		if atomic.CompareAndSwapInt32(&pv.gated, 0, 1) {
+			pv.logger.Error("stop proposing blocks", "reason", err.Error())
+		}
+		return fmt.Errorf("not ready to propose: %w", err)
+	}
+	if atomic.CompareAndSwapInt32(&pv.gated, 1, 0) {
+		pv.logger.Info("start proposing blocks again")
+	}
+	return pv.PrivValidator.SignProposal(chainID, proposal)
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	tmlog "github.com/tendermint/tendermint/libs/log"
+	tmproto "github.com/tendermint/tendermint/proto/tendermint/types"
+	tmtypes "github.com/tendermint/tendermint/types"
+)
+
+func TestGatedPrivValidator(t *testing.T) {
+	var notReady error
+	pv := newGatedPrivValidator(tmtypes.NewMockPV(), func() error { return notReady }, tmlog.NewNopLogger())
+	proposal := &tmproto.Proposal{Type: tmproto.ProposalType, Height: 1}
+	require.NoError(t, pv.SignProposal("smartbch", proposal))
+	require.NotEmpty(t, proposal.Signature)
+
+	notReady = errors.New("lagging")
+	proposal = &tmproto.Proposal{Type: tmproto.ProposalType, Height: 2}
+	require.Error(t, pv.SignProposal("smartbch", proposal))
+	require.Empty(t, proposal.Signature)
+	// the votes are still signed
+	vote := &tmproto.Vote{Type: tmproto.PrevoteType, Height: 2}
+	require.NoError(t, pv.SignVote("smartbch", vote))
+	require.NotEmpty(t, vote.Signature)
+
+	notReady = nil
+	require.NoError(t, pv.SignProposal("smartbch", proposal))
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"time"
+
+	gethcmn "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/tendermint/tendermint/libs/log"
+
+	"github.com/smartbch/smartbch/rpc/client"
+	rpctypes "github.com/smartbch/smartbch/rpc/types"
+	"github.com/smartbch/smartbch/watcher"
+	watchertypes "github.com/smartbch/smartbch/watcher/types"
+)
+
+const (
+	flagSignerKey = "signer-key"
+	flagOutput    = "output"
+)
+
+// ReserveAttestation compares the BCH which smartBCH owes to its users (the cc UTXOs recorded
+// in smartBCH's state) with the BCH actually locked in the covenants on the BCH mainnet
+type ReserveAttestation struct {
+	SmartBchHeight    uint64            `json:"smartbchHeight"`
+	BchHeight         int64             `json:"bchHeight"`
+	BchBlockHash      string            `json:"bchBlockHash"`
+	Timestamp         int64             `json:"timestamp"`
+	CovenantAddresses []gethcmn.Address `json:"covenantAddresses"`
+	UtxoCount         int               `json:"utxoCount"`
+	Liabilities       hexutil.Uint64    `json:"liabilities"` // in satoshi
+	Reserves          hexutil.Uint64    `json:"reserves"`    // in satoshi
+	Missing           []*MissingCcUtxo  `json:"missing"`
+	Signer            gethcmn.Address   `json:"signer"`
+	Signature         hexutil.Bytes     `json:"signature"`
+}
+
+type MissingCcUtxo struct {
+	Txid   gethcmn.Hash   `json:"txid"`
+	Index  uint32         `json:"index"`
+	Amount hexutil.Uint64 `json:"amount"`
+	Reason string         `json:"reason"`
+}
+
+func ReserveAttestationCmd(_ *Context) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "reserve-attestation",
+		Short: "compare the cc UTXOs recorded on smartBCH with the ones held by the covenants on BCH, and sign the result",
+		Example: `
+smartbchd reserve-attestation \
+--rpc-url=http://127.0.0.1:8545 \
+--mainnet-rpc-url=http://127.0.0.1:8332 --mainnet-rpc-username=user --mainnet-rpc-password=pass \
+--signer-key=<hex private key> --output=attestation.json
+`,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			key, err := crypto.HexToECDSA(viper.GetString(flagSignerKey))
+			if err != nil {
+				return errors.New(flagSignerKey + " is missing or invalid")
+			}
+			c, err := client.Dial(viper.GetString(flagNodeRpcUrl))
+			if err != nil {
+				return err
+			}
+			defer c.Close()
+			bchClient := watcher.NewRpcClient(viper.GetString(flagMainnetUrl), viper.GetString(flagMainnetRpcUser),
+				viper.GetString(flagMainnetRpcPassword), "text/plain;", log.NewNopLogger())
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+			defer cancel()
+			sbchHeight, err := c.BlockNumber(ctx)
+			if err != nil {
+				return err
+			}
+			utxos, err := getAllCcUtxos(ctx, c)
+			if err != nil {
+				return err
+			}
+			// the utxo sets are read at the latest heights, so the smartBCH height is checked again
+			// to make sure they all belong to sbchHeight
+			if h, err := c.BlockNumber(ctx); err != nil {
+				return err
+			} else if h != sbchHeight {
+				return fmt.Errorf("new block %d was committed while reading the utxos, please retry", h)
+			}
+
+			bchHeight := bchClient.GetLatestHeight(false)
+			if bchHeight < 0 {
+				return errors.New("cannot get the BCH mainnet height")
+			}
+			bchHash, err := bchClient.GetBlockHash(bchHeight)
+			if err != nil {
+				return err
+			}
+			att, err := buildReserveAttestation(utxos, bchClient.GetTxOut)
+			if err != nil {
+				return err
+			}
+			att.SmartBchHeight = sbchHeight
+			att.BchHeight = bchHeight
+			att.BchBlockHash = bchHash
+			att.Timestamp = time.Now().Unix()
+			if err := att.sign(key); err != nil {
+				return err
+			}
+
+			out, _ := json.MarshalIndent(att, "", "  ")
+			if path := viper.GetString(flagOutput); path != "" {
+				return os.WriteFile(path, out, 0644)
+			}
+			fmt.Println(string(out))
+			return nil
+		},
+	}
+	cmd.Flags().String(flagNodeRpcUrl, "http://127.0.0.1:8545", "smartBCH RPC URL")
+	cmd.Flags().String(flagMainnetUrl, "http://127.0.0.1:8332", "BCH Mainnet RPC URL")
+	cmd.Flags().String(flagMainnetRpcUser, "user", "BCH Mainnet RPC user name")
+	cmd.Flags().String(flagMainnetRpcPassword, "88888888", "BCH Mainnet RPC user password")
+	cmd.Flags().String(flagSignerKey, "", "hex private key used to sign the attestation")
+	cmd.Flags().String(flagOutput, "", "output file, print to stdout if empty")
+	return cmd
+}
+
+// getAllCcUtxos returns the redeemable, redeeming and lost-and-found utxos, the to-be-converted
+// ones overlap with the redeemable ones, so they are deduplicated
+func getAllCcUtxos(ctx context.Context, c *client.Client) ([]*rpctypes.UtxoInfo, error) {
+	getters := []func(context.Context) (*rpctypes.UtxoInfos, error){
+		c.RedeemableUtxos,
+		c.RedeemingUtxosForMonitors,
+		c.LostAndFoundUtxos,
+		c.ToBeConvertedUtxosForMonitors,
+	}
+	seen := make(map[[36]byte]bool)
+	var utxos []*rpctypes.UtxoInfo
+	for _, getter := range getters {
+		infos, err := getter(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, info := range infos.Infos {
+			var id [36]byte
+			copy(id[:32], info.Txid[:])
+			binary.BigEndian.PutUint32(id[32:], info.Index)
+			if !seen[id] {
+				seen[id] = true
+				utxos = append(utxos, info)
+			}
+		}
+	}
+	return utxos, nil
+}
+
+func buildReserveAttestation(utxos []*rpctypes.UtxoInfo,
+	getTxOut func(txid string, vout uint32) (*watchertypes.TxOut, error)) (*ReserveAttestation, error) {
+
+	att := &ReserveAttestation{UtxoCount: len(utxos), Missing: []*MissingCcUtxo{}}
+	covenants := make(map[gethcmn.Address]bool)
+	for _, utxo := range utxos {
+		if !covenants[utxo.CovenantAddr] {
+			covenants[utxo.CovenantAddr] = true
+			att.CovenantAddresses = append(att.CovenantAddresses, utxo.CovenantAddr)
+		}
+		att.Liabilities += utxo.Amount
+		txOut, err := getTxOut(hex.EncodeToString(utxo.Txid[:]), utxo.Index)
+		if err != nil {
+			return nil, err
+		}
+		missing := &MissingCcUtxo{Txid: utxo.Txid, Index: utxo.Index, Amount: utxo.Amount}
+		switch {
+		case txOut == nil:
+			missing.Reason = "spent or not found"
+			att.Missing = append(att.Missing, missing)
+		case hexutil.Uint64(math.Round(txOut.Value*1e8)) != utxo.Amount:
+			missing.Reason = fmt.Sprintf("amount mismatch, %v BCH on mainnet", txOut.Value)
+			att.Missing = append(att.Missing, missing)
+		default:
+			att.Reserves += utxo.Amount
+		}
+	}
+	return att, nil
+}
+
+func (att *ReserveAttestation) hash() [32]byte {
+	sig := att.Signature
+	att.Signature = nil
+	bz, _ := json.Marshal(att)
+	att.Signature = sig
+	return sha256.Sum256(bz)
+}
+
+// sign signs the sha256 hash of the JSON document without the signature, like sbch_getCcInfo
+func (att *ReserveAttestation) sign(key *ecdsa.PrivateKey) error {
+	att.Signer = crypto.PubkeyToAddress(key.PublicKey)
+	hash := att.hash()
+	sig, err := crypto.Sign(hash[:], key)
+	if err != nil {
+		return err
+	}
+	att.Signature = sig
+	return nil
+}
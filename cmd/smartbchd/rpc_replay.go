@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/smartbch/smartbch/rpc"
+)
+
+const (
+	flagReplayFile     = "file"
+	flagReplayCompare  = "compare"
+	flagReplayRealtime = "realtime"
+)
+
+func RpcReplayCmd(_ *Context) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "rpc-replay",
+		Short: "re-issue the RPC requests recorded with --rpc.record-file against a node, and report the responses differing from the recorded ones",
+		Example: `
+smartbchd rpc-replay --file=rpc-session.jsonl --rpc-url=http://127.0.0.1:8545 --compare
+`,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			calls, err := rpc.ReadRecords(viper.GetString(flagReplayFile))
+			if err != nil {
+				return err
+			}
+			return replayCalls(calls, viper.GetString(flagNodeRpcUrl),
+				viper.GetBool(flagReplayCompare), viper.GetBool(flagReplayRealtime))
+		},
+	}
+	cmd.Flags().String(flagReplayFile, "", "the file recorded by a node started with --rpc.record-file")
+	cmd.Flags().String(flagNodeRpcUrl, "http://127.0.0.1:8545", "smartBCH RPC URL of the test node")
+	cmd.Flags().Bool(flagReplayCompare, false, "compare the responses with the recorded ones, and fail if any differs")
+	cmd.Flags().Bool(flagReplayRealtime, false, "keep the intervals between the recorded requests")
+	return cmd
+}
+
+func replayCalls(calls []*rpc.RecordedCall, rpcUrl string, compare, realtime bool) error {
+	httpClient := &http.Client{Timeout: time.Minute}
+	var skipped, differed int
+	for i, call := range calls {
+		if call.Redacted {
+			skipped++
+			continue
+		}
+		if realtime && i > 0 {
+			time.Sleep(time.Duration(call.Time-calls[i-1].Time) * time.Millisecond)
+		}
+		resp, err := httpClient.Post(rpcUrl, "application/json", bytes.NewReader(requestBody(call.Request)))
+		if err != nil {
+			return fmt.Errorf("request #%d: %w", i, err)
+		}
+		bz, err := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		if err != nil {
+			return fmt.Errorf("request #%d: %w", i, err)
+		}
+		if compare && (resp.StatusCode != call.Status || !rpc.SameResponse(call.Response, bz)) {
+			differed++
+			fmt.Printf("#%d differs\nrequest:  %s\nrecorded: %d %s\nreplayed: %d %s\n\n",
+				i, call.Request, call.Status, call.Response, resp.StatusCode, bytes.TrimSpace(bz))
+		}
+	}
+	fmt.Printf("replayed %d requests, skipped %d redacted ones\n", len(calls)-skipped, skipped)
+	if differed > 0 {
+		return fmt.Errorf("%d responses differ from the recorded ones", differed)
+	}
+	return nil
+}
+
+// requestBody returns the bytes sent by the client, the malformed requests are recorded as JSON strings
+func requestBody(request json.RawMessage) []byte {
+	var s string
+	if json.Unmarshal(request, &s) == nil {
+		return []byte(s)
+	}
+	return request
+}
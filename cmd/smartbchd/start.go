@@ -20,10 +20,12 @@ import (
 	pvm "github.com/tendermint/tendermint/privval"
 	"github.com/tendermint/tendermint/proxy"
 	tmrpcserver "github.com/tendermint/tendermint/rpc/jsonrpc/server"
+	sm "github.com/tendermint/tendermint/state"
 	tmtypes "github.com/tendermint/tendermint/types"
 
 	"github.com/smartbch/smartbch/api"
 	"github.com/smartbch/smartbch/app"
+	"github.com/smartbch/smartbch/param"
 	"github.com/smartbch/smartbch/rpc"
 )
 
@@ -40,6 +42,11 @@ const (
 	flagWriteTimeout           = "rpc.write-timeout"
 	flagMaxBodyBytes           = "rpc.max-body-bytes"
 	flagMaxHeaderBytes         = "rpc.max-header-bytes"
+	flagReadHeaderTimeout      = "rpc.read-header-timeout"
+	flagIdleTimeout            = "rpc.idle-timeout"
+	flagMaxBatchSize           = "rpc.max-batch-size"
+	flagRecordFile             = "rpc.record-file"
+	flagRecordDuration         = "rpc.record-duration"
 	flagRetainBlocks           = "retain-blocks"
 	flagUnlock                 = "unlock"
 	flagGenesisMainnetHeight   = "mainnet-genesis-height"
@@ -53,6 +60,10 @@ const (
 	flagArchiveMode            = "archive-mode"
 	flagSkipSanityCheck        = "skip-sanity-check"
 	flagWithSyncDB             = "with-syncdb"
+	flagValidatorWebhookUrl    = "validator-webhook-url"
+	flagProfile                = "profile"
+	flagMetricsAddr            = "metrics.addr"
+	flagSafeMode               = "safe-mode"
 )
 
 func StartCmd(ctx *Context, appCreator AppCreator) *cobra.Command {
@@ -62,7 +73,7 @@ func StartCmd(ctx *Context, appCreator AppCreator) *cobra.Command {
 
 		RunE: func(cmd *cobra.Command, args []string) error {
 			ctx.Logger.Info("starting SmartBCH Chain with Tendermint")
-			_, err := startInProcess(ctx, appCreator)
+			_, err := startInProcess(ctx, appCreator, cmd)
 			return err
 		},
 	}
@@ -88,6 +99,11 @@ func StartCmd(ctx *Context, appCreator AppCreator) *cobra.Command {
 	cmd.Flags().Uint(flagWriteTimeout, 10, "write timeout (in seconds) of RPC server")
 	cmd.Flags().Uint(flagMaxHeaderBytes, uint(defaultRpcCfg.MaxHeaderBytes), "max header bytes of RPC server")
 	cmd.Flags().Uint(flagMaxBodyBytes, uint(defaultRpcCfg.MaxBodyBytes), "max body bytes of RPC server")
+	cmd.Flags().Uint(flagReadHeaderTimeout, uint(rpc.DefaultReadHeaderTimeout/time.Second), "read header timeout (in seconds) of RPC server")
+	cmd.Flags().Uint(flagIdleTimeout, uint(rpc.DefaultIdleTimeout/time.Second), "idle timeout (in seconds) of keep-alive connections of RPC server")
+	cmd.Flags().Uint(flagMaxBatchSize, rpc.DefaultMaxBatchSize, "max number of requests in a batch, 0 means no limit")
+	cmd.Flags().String(flagRecordFile, "", "record the HTTP-RPC requests and responses into this file, to be replayed with 'smartbchd rpc-replay'")
+	cmd.Flags().Duration(flagRecordDuration, 10*time.Minute, "the time window of recording the HTTP-RPC requests")
 	cmd.Flags().String(flagUnlock, "", "Comma separated list of private keys to unlock (only for testing)")
 	cmd.Flags().String(flagMainnetUrl, "tcp://:8432", "BCH Mainnet RPC URL")
 	cmd.Flags().String(flagMainnetRpcUser, "user", "BCH Mainnet RPC user name")
@@ -95,26 +111,68 @@ func StartCmd(ctx *Context, appCreator AppCreator) *cobra.Command {
 	cmd.Flags().String(flagSmartBchUrl, "tcp://:8545", "SmartBch RPC URL")
 	cmd.Flags().Bool(flagWatcherSpeedup, false, "Watcher Speedup")
 	cmd.Flags().Bool(flagRpcOnly, false, "Start RPC server even tmnode is not started correctly, only useful for debug purpose")
+	cmd.Flags().Bool(flagSafeMode, false, "serve the read-only RPC queries from the committed local state without joining the consensus, "+
+		"accepting txs or starting the watcher, for incident investigations and coordinated halts")
 	cmd.Flags().String(flagRpcAPI, "eth,web3,net,txpool,sbch,tm", "API's offered over the HTTP-RPC interface")
 	cmd.Flags().String(flagWsAPI, "eth,web3,net,txpool,sbch,tm", "API's offered over the WS-RPC interface")
 	cmd.Flags().Bool(flagArchiveMode, false, "enable archive-mode")
 	cmd.Flags().Bool(flagSkipSanityCheck, false, "skip sanity check when node start")
 	cmd.Flags().Bool(flagWithSyncDB, false, "enable syncdb")
+	cmd.Flags().String(flagValidatorWebhookUrl, "", "URL to which validator voting power change events are POSTed")
+	cmd.Flags().String(flagMetricsAddr, "", "listen address of the /metrics endpoint for prometheus, such as 127.0.0.1:9090, disabled if empty")
+	cmd.Flags().String(flagProfile, "", "node profile: "+strings.Join(param.ProfileNames(), ", ")+
+		", overrides the one in app.toml")
+	cmd.Flags().Bool(flagDev, false, "run a single-validator chain without the BCH watcher, with pre-funded and unlocked accounts")
+	cmd.Flags().Uint(flagDevPeriod, 0, "block interval (in seconds) of the dev chain, 0 means mining a block as soon as a tx is received")
+	cmd.Flags().Uint(flagDevAccounts, 10, "number of the pre-funded accounts of the dev chain")
+	cmd.Flags().String(flagDevBalance, defaultDevBalance, "initial balance of the pre-funded accounts of the dev chain")
 
 	return cmd
 }
 
-func startInProcess(ctx *Context, appCreator AppCreator) (*node.Node, error) {
+func startInProcess(ctx *Context, appCreator AppCreator, cmd *cobra.Command) (*node.Node, error) {
+	if viper.GetBool(flagDev) {
+		if err := setupDevMode(ctx); err != nil {
+			return nil, err
+		}
+	}
+	profile, err := param.GetProfile(ctx.Config.AppConfig.Profile)
+	if err != nil {
+		return nil, err
+	}
+	mempoolSize := param.DefaultMempoolSize
+	if profile != nil {
+		if err := profile.Check(ctx.Config.AppConfig); err != nil {
+			return nil, err
+		}
+		mempoolSize = profile.MempoolSize
+		ctx.Logger.Info("using profile " + profile.Name)
+	}
+
+	safeMode := viper.GetBool(flagSafeMode)
+	ctx.Config.AppConfig.SafeMode = safeMode
 	nodeCfg := ctx.Config.NodeConfig
 	nodeCfg.TxIndex.Indexer = "null"
-	nodeCfg.Mempool.Size = 10000
+	nodeCfg.Mempool.Size = mempoolSize
 	nodeCfg.Mempool.MaxTxsBytes = 4 * 1024 * 1024 * 1024
-	chainID, err := getChainID(ctx)
+	chainID, err := selectBchNetwork(ctx)
 	if err != nil {
 		return nil, err
 	}
+	blockFinalizeNumber := ctx.Config.AppConfig.GetBlockFinalizeNumber(chainID.Uint64())
+	if err := param.CheckBlockFinalizeNumber(blockFinalizeNumber, chainID.Uint64()); err != nil {
+		return nil, err
+	}
+	if addr := viper.GetString(flagMetricsAddr); addr != "" {
+		if err := startMetricsServer(addr, ctx.Logger.With("module", "metrics")); err != nil {
+			return nil, err
+		}
+	}
 	_app := appCreator(ctx.Logger, chainID, ctx.Config)
 	appImpl := _app.(*app.App)
+	if viper.GetBool(flagDev) {
+		appImpl.EnableDevClock()
+	}
 
 	nodeKey, err := p2p.LoadOrGenNodeKey(nodeCfg.NodeKeyFile())
 	if err != nil {
@@ -122,14 +180,43 @@ func startInProcess(ctx *Context, appCreator AppCreator) (*node.Node, error) {
 	}
 	fmt.Printf("This Node ID: %s\n", nodeKey.ID())
 
-	rpcOnly := viper.GetBool(flagRpcOnly)
-	tmNode, err := startTmNode(nodeCfg, nodeKey, _app,
-		ctx.Logger.With("module", "node"))
-	if err != nil {
-		if !rpcOnly {
+	nodeLogger := ctx.Logger.With("module", "node")
+	if ctx.Config.AppConfig.PeerBanInvalidTxs > 0 {
+		// the app decides whether the banned peers can connect again
+		nodeCfg.FilterPeers = true
+		nodeLogger = newPeerSignalLogger(nodeLogger, appImpl)
+	}
+
+	var tmNode *node.Node
+	var rpcNode api.ITmNode
+	if safeMode {
+		rpcNode, err = newSafeModeTmNode(nodeCfg)
+		if err != nil {
 			return nil, err
 		}
-		ctx.Logger.Info("tmnode not started: " + err.Error())
+		ctx.Logger.Info("safe mode, serving the committed state without joining the consensus")
+	} else {
+		rpcOnly := viper.GetBool(flagRpcOnly)
+		var privVal tmtypes.PrivValidator = pvm.LoadOrGenFilePV(nodeCfg.PrivValidatorKeyFile(), nodeCfg.PrivValidatorStateFile())
+		if ctx.Config.AppConfig.ProposeMaxWatcherLag > 0 {
+			privVal = newGatedPrivValidator(privVal, appImpl.ReadyToPropose, ctx.Logger.With("module", "propose-gate"))
+		}
+		tmNode, err = startTmNode(nodeCfg, privVal, nodeKey, _app, nodeLogger)
+		if err != nil {
+			if !rpcOnly {
+				return nil, err
+			}
+			ctx.Logger.Info("tmnode not started: " + err.Error())
+		} else {
+			if ctx.Config.AppConfig.PeerBanInvalidTxs > 0 {
+				appImpl.SetPeerBanHandler(banPeerHandler(tmNode, ctx.Logger.With("module", "p2p")))
+			}
+			if ctx.Config.AppConfig.EpochGossipServe {
+				pv := pvm.LoadFilePVEmptyState(nodeCfg.PrivValidatorKeyFile(), "")
+				appImpl.SetEpochGossipKey(pv.Key.PrivKey)
+			}
+		}
+		rpcNode = api.NewTmNode(tmNode)
 	}
 
 	serverCfg := tmrpcserver.DefaultConfig()
@@ -152,7 +239,16 @@ func startInProcess(ctx *Context, appCreator AppCreator) (*node.Node, error) {
 	rpcServerCfgJSON, _ := json.Marshal(serverCfg)
 	ctx.Logger.Info("rpc server nodeCfg: " + string(rpcServerCfgJSON))
 
-	rpcBackend := api.NewBackend(api.NewTmNode(tmNode), appImpl)
+	limits := rpc.DefaultLimits()
+	if n := viper.GetUint(flagReadHeaderTimeout); n > 0 {
+		limits.ReadHeaderTimeout = time.Duration(n) * time.Second
+	}
+	if n := viper.GetUint(flagIdleTimeout); n > 0 {
+		limits.IdleTimeout = time.Duration(n) * time.Second
+	}
+	limits.MaxBatchSize = int(viper.GetUint(flagMaxBatchSize))
+
+	rpcBackend := api.NewBackend(rpcNode, appImpl)
 	rpcAddr := viper.GetString(flagRpcAddr)
 	wsAddr := viper.GetString(flagWsAddr)
 	rpcAddrSecure := viper.GetString(flagRpcAddrSecure)
@@ -162,17 +258,39 @@ func startInProcess(ctx *Context, appCreator AppCreator) (*node.Node, error) {
 	certfileDir := filepath.Join(nodeCfg.RootDir, "nodeCfg/cert.pem")
 	keyfileDir := filepath.Join(nodeCfg.RootDir, "nodeCfg/key.pem")
 	httpAPI := viper.GetString(flagRpcAPI)
+	if profile != nil && !cmd.Flags().Changed(flagRpcAPI) {
+		httpAPI = profile.RpcAPI
+	}
 	wsAPI := viper.GetString(flagWsAPI)
+	if profile != nil && !cmd.Flags().Changed(flagWsAPI) {
+		wsAPI = profile.RpcAPI
+	}
+	var recorder *rpc.Recorder
+	if recordFile := viper.GetString(flagRecordFile); recordFile != "" {
+		recorder, err = rpc.NewRecorder(recordFile, viper.GetDuration(flagRecordDuration), ctx.Logger)
+		if err != nil {
+			return nil, err
+		}
+	}
 	rpcServer := rpc.NewServer(rpcAddr, wsAddr, rpcAddrSecure, wsAddrSecure, corsDomain, certfileDir, keyfileDir,
-		serverCfg, rpcBackend, ctx.Logger, strings.Split(unlockedKeys, ","), httpAPI, wsAPI)
+		serverCfg, limits, recorder, rpcBackend, ctx.Logger, strings.Split(unlockedKeys, ","), httpAPI, wsAPI)
 
+	if n := ctx.Config.AppConfig.WarmUpBlocks; n > 0 {
+		ctx.Logger.Info("warming up the caches before opening RPC", "blocks", n)
+		stats := appImpl.WarmUp(n, ctx.Config.AppConfig.WarmUpContracts)
+		ctx.Logger.Info("warm-up finished", "blocks", stats.Blocks, "txs", stats.Txs,
+			"contracts", stats.Contracts, "duration", stats.Duration)
+	}
 	if err := rpcServer.Start(); err != nil {
 		return nil, err
 	}
 	TrapSignal(func() {
-		if tmNode.IsRunning() {
+		if safeMode {
+			_ = rpcServer.Stop()
+		} else if tmNode.IsRunning() {
 			_ = rpcServer.Stop()
 			_ = tmNode.Stop()
+			appImpl.StopWatcher()
 			//appImpl.Stop()
 		}
 		ctx.Logger.Info("exiting...")
@@ -183,13 +301,14 @@ func startInProcess(ctx *Context, appCreator AppCreator) (*node.Node, error) {
 }
 
 func startTmNode(nodeCfg *tmcfg.Config,
+	privVal tmtypes.PrivValidator,
 	nodeKey *p2p.NodeKey,
 	_app abci.Application,
 	logger tmlog.Logger) (*node.Node, error) {
 
 	tmNode, err := node.NewNode(
 		nodeCfg,
-		pvm.LoadOrGenFilePV(nodeCfg.PrivValidatorKeyFile(), nodeCfg.PrivValidatorStateFile()),
+		privVal,
 		nodeKey,
 		proxy.NewLocalClientCreator(_app),
 		node.DefaultGenesisDocProviderFunc(nodeCfg),
@@ -208,7 +327,27 @@ func startTmNode(nodeCfg *tmcfg.Config,
 	return tmNode, nil
 }
 
-func getChainID(ctx *Context) (*uint256.Int, error) {
+// newSafeModeTmNode loads the tendermint state committed locally, without starting the node
+func newSafeModeTmNode(nodeCfg *tmcfg.Config) (api.ITmNode, error) {
+	genesis, err := tmtypes.GenesisDocFromFile(nodeCfg.GenesisFile())
+	if err != nil {
+		return nil, err
+	}
+	stateDB, err := node.DefaultDBProvider(&node.DBContext{ID: "state", Config: nodeCfg})
+	if err != nil {
+		return nil, err
+	}
+	defer stateDB.Close()
+	state, err := sm.NewStore(stateDB).LoadFromDBOrGenesisDoc(genesis)
+	if err != nil {
+		return nil, err
+	}
+	return api.NewSafeModeTmNode(genesis, state), nil
+}
+
+// selectBchNetwork selects the BCH network written in the app state of genesis.json, which is
+// shared by all the nodes of the chain, and returns the chain id
+func selectBchNetwork(ctx *Context) (*uint256.Int, error) {
 	gDoc, err := tmtypes.GenesisDocFromFile(ctx.Config.NodeConfig.GenesisFile())
 	if err != nil {
 		return nil, err
@@ -219,5 +358,15 @@ func getChainID(ctx *Context) (*uint256.Int, error) {
 		return nil, err
 	}
 
+	var genesisData app.GenesisData
+	if err := json.Unmarshal(gDoc.AppState, &genesisData); err != nil {
+		return nil, err
+	}
+	if err := param.CheckBchNetwork(genesisData.BchNetwork, chainID.Uint64()); err != nil {
+		return nil, err
+	}
+	if err := param.SelectBchNetwork(genesisData.BchNetwork); err != nil {
+		return nil, err
+	}
 	return chainID, nil
 }
@@ -0,0 +1,6 @@
+package main
+
+// The modules registering tx hooks (see package txhook) are linked into smartbchd by blank
+// imports in this file, for example:
+//
+//	import _ "example.com/compliance/txfilter"
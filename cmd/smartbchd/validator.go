@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/smartbch/smartbch/internal/ethutils"
+	"github.com/smartbch/smartbch/rpc/client"
+	"github.com/smartbch/smartbch/rpc/types"
+)
+
+const (
+	flagNodeRpcUrl = "rpc-url"
+)
+
+func ValidatorCmd(ctx *Context) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "validator",
+		Short: "validator operation helpers",
+	}
+	cmd.AddCommand(NominateStatusCmd(ctx))
+	cmd.AddCommand(ValidatorSetupCmd(ctx))
+	return cmd
+}
+
+func NominateStatusCmd(_ *Context) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "nominate-status",
+		Short: "show how many more nominations are needed before a validator gets elected in current epoch",
+		Example: `
+smartbchd validator nominate-status \
+--consensus-pubkey=f7847ca2afd06fedcd2c404c6e99db4d5475e0746154a550fe72990657675dc9 \
+--rpc-url=http://127.0.0.1:8545
+`,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			pubKeyHex := viper.GetString(flagConsPubKey)
+			if pubKeyHex == "" {
+				return errors.New(flagConsPubKey + " is missing")
+			}
+			pk, _, err := ethutils.HexToPubKey(pubKeyHex)
+			if err != nil {
+				return err
+			}
+			c, err := client.Dial(viper.GetString(flagNodeRpcUrl))
+			if err != nil {
+				return err
+			}
+			defer c.Close()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+			status, err := c.NominationStatus(ctx, common.BytesToHash(pk))
+			if err != nil {
+				return err
+			}
+			if viper.GetBool(flagVerbose) {
+				out, _ := json.MarshalIndent(status, "", "  ")
+				fmt.Println(string(out))
+			}
+
+			printNominationStatus(os.Stdout, status)
+			return nil
+		},
+	}
+	cmd.Flags().String(flagConsPubKey, "", "consensus pubkey")
+	cmd.Flags().String(flagNodeRpcUrl, "http://127.0.0.1:8545", "the JSON-RPC endpoint of a smartBCH node")
+	cmd.Flags().Bool(flagVerbose, false, "display verbose information")
+	return cmd
+}
+
+// printNominationStatus tells how many more nominations are needed before the validator gets
+// elected in current epoch
+func printNominationStatus(w io.Writer, status *types.NominationStatus) {
+	switch {
+	case !status.IsValidator:
+		fmt.Fprintln(w, "this pubkey is not registered as a validator, nominations to it are ignored")
+		return
+	case status.IsRetiring:
+		fmt.Fprintln(w, "this validator is retiring and cannot be elected")
+		return
+	case !status.HasEnoughStake:
+		fmt.Fprintln(w, "warning: this validator does not have enough staked coins to get voting power")
+	}
+	fmt.Fprintf(w, "nominations: %d, rank: %d, election threshold: %d\n",
+		status.NominatedCount, status.Rank, status.ElectionThreshold)
+	fmt.Fprintf(w, "scanned %d BCH blocks in current epoch, %d blocks left\n",
+		status.BlocksScanned, status.BlocksLeft)
+	if status.NominationsNeeded == 0 {
+		fmt.Fprintln(w, "this validator will be elected if current epoch ends now")
+	} else {
+		fmt.Fprintf(w, "%d more nominations are needed to be elected\n", status.NominationsNeeded)
+		if uint64(status.NominationsNeeded) > uint64(status.BlocksLeft) {
+			fmt.Fprintln(w, "warning: not enough blocks are left in current epoch")
+		}
+	}
+	if status.TotalValidNominations < status.MinTotalNominations {
+		fmt.Fprintf(w, "warning: current epoch is invalid unless %d more valid nominations are collected\n",
+			status.MinTotalNominations-status.TotalValidNominations)
+	}
+}
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/tendermint/tendermint/libs/cli"
+
+	"github.com/smartbch/smartbch/internal/bigutils"
+	"github.com/smartbch/smartbch/internal/ethutils"
+	"github.com/smartbch/smartbch/rpc/client"
+	sbchrpctypes "github.com/smartbch/smartbch/rpc/types"
+	"github.com/smartbch/smartbch/staking"
+	watchertypes "github.com/smartbch/smartbch/watcher/types"
+)
+
+const (
+	flagBroadcast = "broadcast"
+	flagYes       = "yes"
+)
+
+func ValidatorSetupCmd(ctx *Context) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "setup",
+		Short: "walk through the steps to become a validator",
+		Long: `Walk through the steps to become a validator:
+  1. load or generate the consensus key of this node
+  2. create the staking tx registering the validator, and optionally broadcast it
+  3. print the nomination payload which the BCH miners put in their coinbase txs
+  4. check whether the validator is going to be elected in current epoch
+The answers can be given by the flags, with --yes the questions not answered by them are skipped.
+Run it again after the miners start nominating to check the election, the steps done are skipped.`,
+		Example: `
+smartbchd validator setup --home=$HOME/.smartbchd --rpc-url=http://127.0.0.1:8545
+`,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			c := ctx.Config.NodeConfig
+			c.SetRoot(viper.GetString(cli.HomeFlag))
+			w := newSetupWizard(os.Stdin, os.Stdout, viper.GetBool(flagYes))
+
+			fmt.Fprintln(w.out, "== step 1: consensus key")
+			_, valPubKey, err := InitializeNodeValidatorFiles(c)
+			if err != nil {
+				return err
+			}
+			if valPubKey == nil {
+				return errors.New("cannot load or generate " + c.PrivValidatorKeyFile())
+			}
+			var pubkey [32]byte
+			copy(pubkey[:], valPubKey.Bytes())
+			fmt.Fprintf(w.out, "consensus key: %s\nconsensus pubkey: %s\n", c.PrivValidatorKeyFile(), hex.EncodeToString(pubkey[:]))
+
+			rpc, err := client.Dial(viper.GetString(flagNodeRpcUrl))
+			if err != nil {
+				return err
+			}
+			defer rpc.Close()
+			status, err := getNominationStatus(rpc, pubkey)
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintln(w.out, "== step 2: staking tx")
+			if status.IsValidator {
+				fmt.Fprintln(w.out, "already registered as a validator, skipped")
+			} else if err = w.createValidator(rpc, pubkey); err != nil {
+				return err
+			}
+
+			fmt.Fprintln(w.out, "== step 3: nomination payload for the BCH miners")
+			fmt.Fprintf(w.out, "ask the miners to add an output with this script to their coinbase txs:\n%s\n(asm: %s)\n",
+				nominationScript(pubkey), nominationAsm(pubkey))
+
+			fmt.Fprintln(w.out, "== step 4: election")
+			if status, err = getNominationStatus(rpc, pubkey); err != nil {
+				return err
+			}
+			printNominationStatus(w.out, status)
+			return nil
+		},
+	}
+	cmd.Flags().String(flagNodeRpcUrl, "http://127.0.0.1:8545", "the JSON-RPC endpoint of a smartBCH node")
+	cmd.Flags().String(flagValKey, "", "the private key of the validator address, which pays the staking coins")
+	cmd.Flags().String(flagStakingCoin, "", "the staking coins in wei")
+	cmd.Flags().String(flagRewardTo, "", "the address receiving the rewards, the validator address if empty")
+	cmd.Flags().String(flagIntroduction, "", "introduction")
+	cmd.Flags().Uint64(flagGasPrice, 0, "the gas price of the staking tx, zero means the one suggested by the node")
+	cmd.Flags().Bool(flagBroadcast, false, "broadcast the staking tx without asking")
+	cmd.Flags().Bool(flagYes, false, "do not ask, the questions not answered by the flags are skipped")
+	return cmd
+}
+
+func getNominationStatus(rpc *client.Client, pubkey [32]byte) (*sbchrpctypes.NominationStatus, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	return rpc.NominationStatus(ctx, common.Hash(pubkey))
+}
+
+// setupWizard asks the operator the questions not answered by the flags
+type setupWizard struct {
+	in  *bufio.Reader
+	out io.Writer
+	yes bool // the questions are not asked, and the default answers are taken
+}
+
+func newSetupWizard(in io.Reader, out io.Writer, yes bool) *setupWizard {
+	return &setupWizard{in: bufio.NewReader(in), out: out, yes: yes}
+}
+
+// ask returns the flag if it is set, otherwise it asks the question and returns the answer, or
+// def if the answer is empty
+func (w *setupWizard) ask(flag, question, def string) string {
+	if v := viper.GetString(flag); v != "" {
+		return v
+	}
+	if w.yes {
+		return def
+	}
+	if def != "" {
+		fmt.Fprintf(w.out, "%s [%s]: ", question, def)
+	} else {
+		fmt.Fprintf(w.out, "%s: ", question)
+	}
+	line, _ := w.in.ReadString('\n')
+	if line = strings.TrimSpace(line); line != "" {
+		return line
+	}
+	return def
+}
+
+// confirm asks a yes/no question, whose answer is no by default
+func (w *setupWizard) confirm(question string) bool {
+	if w.yes {
+		return false
+	}
+	fmt.Fprintf(w.out, "%s [y/N]: ", question)
+	line, _ := w.in.ReadString('\n')
+	line = strings.ToLower(strings.TrimSpace(line))
+	return line == "y" || line == "yes"
+}
+
+// createValidator signs the tx calling createValidator of the staking contract, and broadcasts it
+// if the operator agrees, otherwise it is printed to be sent later
+func (w *setupWizard) createValidator(rpc *client.Client, pubkey [32]byte) error {
+	keyHex := w.ask(flagValKey, "the private key of the validator address (hex)", "")
+	if keyHex == "" {
+		fmt.Fprintln(w.out, "no private key given, skipped. Create the validator later with \"smartbchd staking --type=create\"")
+		return nil
+	}
+	priKey, _, err := ethutils.HexToPrivKey(keyHex)
+	if err != nil {
+		return fmt.Errorf("private key parse error: %w", err)
+	}
+	from := ethutils.PrivKeyToAddr(priKey)
+	coin, ok := bigutils.ParseU256(w.ask(flagStakingCoin, "the staking coins in wei", "0"))
+	if !ok {
+		return errors.New("staking coin parse failed")
+	}
+	rewardTo := common.HexToAddress(w.ask(flagRewardTo, "the address receiving the rewards", from.Hex()))
+	var intro [32]byte
+	copy(intro[:], w.ask(flagIntroduction, "introduction (at most 32 bytes)", ""))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	chainID, err := rpc.ChainID(ctx)
+	if err != nil {
+		return err
+	}
+	nonce, err := rpc.PendingNonceAt(ctx, from)
+	if err != nil {
+		return err
+	}
+	gasPrice := new(big.Int).SetUint64(viper.GetUint64(flagGasPrice))
+	if gasPrice.Sign() == 0 {
+		if gasPrice, err = rpc.SuggestGasPrice(ctx); err != nil {
+			return err
+		}
+	}
+	balance, err := rpc.BalanceAt(ctx, from, nil)
+	if err != nil {
+		return err
+	}
+	cost := new(big.Int).Mul(gasPrice, new(big.Int).SetUint64(staking.GasOfValidatorOp))
+	if cost.Add(cost, coin.ToBig()).Cmp(balance) > 0 {
+		fmt.Fprintf(w.out, "warning: %s has %s wei, less than the staking coins plus the gas fee\n", from.Hex(), balance)
+	}
+
+	to := common.Address(staking.StakingContractAddress)
+	tx := gethtypes.NewTx(&gethtypes.LegacyTx{
+		Nonce:    nonce,
+		GasPrice: gasPrice,
+		Gas:      staking.GasOfValidatorOp,
+		To:       &to,
+		Value:    coin.ToBig(),
+		Data:     staking.PackCreateValidator(rewardTo, intro, pubkey),
+	})
+	if tx, err = ethutils.SignTx(tx, chainID, priKey); err != nil {
+		return fmt.Errorf("sign tx error: %w", err)
+	}
+	txBytes, err := ethutils.EncodeTx(tx)
+	if err != nil {
+		return fmt.Errorf("encode tx error: %w", err)
+	}
+	fmt.Fprintf(w.out, "staking tx from %s with nonce %d: 0x%s\n", from.Hex(), nonce, hex.EncodeToString(txBytes))
+	if !viper.GetBool(flagBroadcast) && !w.confirm("broadcast it now?") {
+		fmt.Fprintln(w.out, "not broadcast, send it later with eth_sendRawTransaction")
+		return nil
+	}
+	if err = rpc.SendTransaction(ctx, tx); err != nil {
+		return err
+	}
+	fmt.Fprintf(w.out, "broadcast, tx hash: %s\n", tx.Hash().Hex())
+	return nil
+}
+
+// nominationScript returns the hex of the OP_RETURN script nominating pubkey, which is recognized
+// by GetValidatorPubKey of the watcher
+func nominationScript(pubkey [32]byte) string {
+	payload := watchertypes.Identifier + watchertypes.Validator + hex.EncodeToString(pubkey[:])
+	return fmt.Sprintf("6a%02x%s", len(payload)/2, payload)
+}
+
+func nominationAsm(pubkey [32]byte) string {
+	return "OP_RETURN " + watchertypes.Identifier + watchertypes.Validator + hex.EncodeToString(pubkey[:])
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNominationScript(t *testing.T) {
+	var pubkey [32]byte
+	pubkey[0], pubkey[31] = 0xab, 0xcd
+	script := nominationScript(pubkey)
+	require.Equal(t, "6a25"+"73424348"+"00"+"ab"+strings.Repeat("00", 30)+"cd", script)
+	require.Equal(t, "OP_RETURN "+script[4:], nominationAsm(pubkey))
+}
+
+func TestSetupWizard(t *testing.T) {
+	out := &bytes.Buffer{}
+	w := newSetupWizard(strings.NewReader("\nanswer\ny\n\n"), out, false)
+	require.Equal(t, "def", w.ask("setup-test-unset", "question", "def"))
+	require.Equal(t, "answer", w.ask("setup-test-unset", "question", "def"))
+	require.True(t, w.confirm("ok?"))
+	require.False(t, w.confirm("ok?"))
+	require.Contains(t, out.String(), "question [def]: ")
+
+	// nothing is asked with --yes
+	out.Reset()
+	w = newSetupWizard(strings.NewReader("answer\ny\n"), out, true)
+	require.Equal(t, "def", w.ask("setup-test-unset", "question", "def"))
+	require.False(t, w.confirm("ok?"))
+	require.Empty(t, out.String())
+}
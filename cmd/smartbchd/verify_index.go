@@ -0,0 +1,266 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	gethcmn "github.com/ethereum/go-ethereum/common"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	modbtypes "github.com/smartbch/moeingdb/types"
+	"github.com/smartbch/moeingevm/types"
+
+	"github.com/smartbch/smartbch/app"
+	"github.com/smartbch/smartbch/param"
+)
+
+const (
+	flagVerifyFrom   = "from"
+	flagVerifyTo     = "to"
+	flagVerifyRepair = "repair"
+)
+
+const (
+	IndexIssueMissingBlock = "missing-block"
+	IndexIssueBloom        = "bloom"
+	IndexIssueTxHash       = "tx-hash"
+	IndexIssueTxFrom       = "tx-from"
+	IndexIssueTxTo         = "tx-to"
+	IndexIssueLogAddress   = "log-address"
+	IndexIssueLogTopic     = "log-topic"
+)
+
+// indexReader is the part of the history-only context used to verify the indexes of moeingdb
+type indexReader interface {
+	GetBlockByHeight(height uint64) (*types.Block, error)
+	GetTxListByHeight(height uint32) (tx []*types.Transaction, sigs [][65]byte, err error)
+	GetTxByHash(txHash gethcmn.Hash) (tx *types.Transaction, sig [65]byte, err error)
+	QueryLogs(addresses []gethcmn.Address, topics [][]gethcmn.Hash, startHeight, endHeight uint32, filter types.FilterFunc) ([]types.Log, error)
+	QueryTxBySrc(addr gethcmn.Address, startHeight, endHeight, limit uint32) (tx []*types.Transaction, sigs [][65]byte, err error)
+	QueryTxByDst(addr gethcmn.Address, startHeight, endHeight, limit uint32) (tx []*types.Transaction, sigs [][65]byte, err error)
+}
+
+// IndexIssue is an index entry of moeingdb which is missing or differs from the one recomputed
+// from the transactions stored in the block
+type IndexIssue struct {
+	Height int64
+	Kind   string
+	Detail string
+}
+
+func (issue IndexIssue) String() string {
+	return fmt.Sprintf("block %d %s: %s", issue.Height, issue.Kind, issue.Detail)
+}
+
+func VerifyIndexCmd(ctx *Context) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "verify-index",
+		Short: "recompute the logs and the tx indexes from the blocks stored in moeingdb, and report (or repair) the index entries which are missing or differ, the node must be stopped",
+		Example: `
+smartbchd verify-index --from=1000000 --to=1001000
+smartbchd verify-index --from=1000000 --to=1001000 --repair
+`,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			from, to := viper.GetInt64(flagVerifyFrom), viper.GetInt64(flagVerifyTo)
+			if from < 1 || to < from {
+				return fmt.Errorf("invalid block range [%d, %d]", from, to)
+			}
+			appConf := ctx.Config.AppConfig
+			historyStore := app.CreateHistoryStore(appConf.ModbDataPath, appConf.UseLiteDB,
+				appConf.RpcEthGetLogsMaxResults, ctx.Logger.With("module", "modb"))
+			defer historyStore.Close()
+			reader := types.NewContext(nil, nil).WithDb(historyStore)
+			reader.SetType(types.HistoryOnlyType)
+			defer reader.Close(false)
+
+			indexes := appConf.ModbIndexes()
+			var issues []IndexIssue
+			var badHeights []int64
+			var skipped int
+			for h := from; h <= to; h++ {
+				blockIssues := verifyBlockIndex(reader, h, indexes)
+				for _, issue := range blockIssues {
+					fmt.Println(issue)
+				}
+				issues = append(issues, blockIssues...)
+				if len(blockIssues) == 1 && blockIssues[0].Kind == IndexIssueMissingBlock {
+					skipped++
+				} else if len(blockIssues) != 0 {
+					badHeights = append(badHeights, h)
+				}
+			}
+			fmt.Printf("verified blocks %d to %d, %d blocks are not stored, %d blocks have %d issues\n",
+				from, to, skipped, len(badHeights), len(issues)-skipped)
+			if len(badHeights) == 0 {
+				return nil
+			}
+			if !viper.GetBool(flagVerifyRepair) {
+				return errors.New("the index is inconsistent, run again with --repair to re-index the blocks")
+			}
+			for _, h := range badHeights {
+				if err := reindexBlock(reader, historyStore, h, indexes); err != nil {
+					return fmt.Errorf("cannot re-index block %d: %w", h, err)
+				}
+			}
+			historyStore.AddBlock(nil, -1, nil) // to flush
+			fmt.Printf("re-indexed %d blocks\n", len(badHeights))
+			return nil
+		},
+	}
+	cmd.Flags().Int64(flagVerifyFrom, 1, "the first block to verify")
+	cmd.Flags().Int64(flagVerifyTo, 1, "the last block to verify")
+	cmd.Flags().Bool(flagVerifyRepair, false, "re-index the blocks with issues by adding them to moeingdb again")
+	return cmd
+}
+
+// verifyBlockIndex compares the index entries of the block at height with the ones recomputed from
+// its transactions, the entries of the indexes disabled by indexes are not expected
+func verifyBlockIndex(reader indexReader, height int64, indexes param.ModbIndexes) (issues []IndexIssue) {
+	addIssue := func(kind, format string, args ...interface{}) {
+		issues = append(issues, IndexIssue{Height: height, Kind: kind, Detail: fmt.Sprintf(format, args...)})
+	}
+	block, err := reader.GetBlockByHeight(uint64(height))
+	if err != nil || block == nil {
+		addIssue(IndexIssueMissingBlock, "not stored, it may be pruned")
+		return
+	}
+	txs, _, err := reader.GetTxListByHeight(uint32(height))
+	if err != nil {
+		addIssue(IndexIssueMissingBlock, "cannot read the transactions: %s", err)
+		return
+	}
+	start, end := uint32(height), uint32(height+1)
+
+	var bloom gethtypes.Bloom
+	for _, tx := range txs {
+		for _, log := range tx.Logs {
+			bloom.Add(log.Address[:])
+			for _, topic := range log.Topics {
+				bloom.Add(topic[:])
+			}
+		}
+	}
+	if bloom != gethtypes.Bloom(block.LogsBloom) {
+		addIssue(IndexIssueBloom, "the logs bloom differs from the one of the logs")
+	}
+
+	for _, tx := range txs {
+		hash := gethcmn.Hash(tx.Hash)
+		if found, _, err := reader.GetTxByHash(hash); err != nil || found == nil || found.BlockNumber != height {
+			addIssue(IndexIssueTxHash, "tx %s is not found by hash", hash.Hex())
+		}
+		if indexes.TxFrom && !containsTx(reader.QueryTxBySrc, tx.From, start, end, tx.Hash) {
+			addIssue(IndexIssueTxFrom, "tx %s is not found by its sender %s", hash.Hex(), gethcmn.Address(tx.From).Hex())
+		}
+		// contract creations are not looked up by the zero address
+		if indexes.TxTo && tx.To != (gethcmn.Address{}) && !containsTx(reader.QueryTxByDst, tx.To, start, end, tx.Hash) {
+			addIssue(IndexIssueTxTo, "tx %s is not found by its recipient %s", hash.Hex(), gethcmn.Address(tx.To).Hex())
+		}
+	}
+
+	// the same address or topic is queried once for all the logs of the block
+	byAddr := make(map[gethcmn.Address][]types.Log)
+	byTopic := make(map[int]map[gethcmn.Hash][]types.Log)
+	for _, tx := range txs {
+		for _, log := range tx.Logs {
+			log.TxHash = tx.Hash
+			byAddr[log.Address] = append(byAddr[log.Address], log)
+			for pos, topic := range log.Topics {
+				if pos >= indexes.LogTopics {
+					break
+				}
+				if byTopic[pos] == nil {
+					byTopic[pos] = make(map[gethcmn.Hash][]types.Log)
+				}
+				byTopic[pos][topic] = append(byTopic[pos][topic], log)
+			}
+		}
+	}
+	for addr, logs := range byAddr {
+		found, err := reader.QueryLogs([]gethcmn.Address{addr}, nil, start, end, acceptAllLogs)
+		for _, log := range missingLogs(logs, found, err) {
+			addIssue(IndexIssueLogAddress, "log %d of tx %s is not found by its address %s",
+				log.Index, gethcmn.Hash(log.TxHash).Hex(), addr.Hex())
+		}
+	}
+	for pos, topics := range byTopic {
+		for topic, logs := range topics {
+			query := make([][]gethcmn.Hash, pos+1)
+			query[pos] = []gethcmn.Hash{topic}
+			found, err := reader.QueryLogs(nil, query, start, end, acceptAllLogs)
+			for _, log := range missingLogs(logs, found, err) {
+				addIssue(IndexIssueLogTopic, "log %d of tx %s is not found by its topic %d %s",
+					log.Index, gethcmn.Hash(log.TxHash).Hex(), pos, topic.Hex())
+			}
+		}
+	}
+	return
+}
+
+type queryTxFunc func(addr gethcmn.Address, startHeight, endHeight, limit uint32) ([]*types.Transaction, [][65]byte, error)
+
+func containsTx(query queryTxFunc, addr gethcmn.Address, start, end uint32, hash gethcmn.Hash) bool {
+	txs, _, err := query(addr, start, end, 0)
+	if err != nil {
+		return false
+	}
+	for _, tx := range txs {
+		if tx.Hash == hash {
+			return true
+		}
+	}
+	return false
+}
+
+// missingLogs returns the logs which are not in found, all of them if the query failed
+func missingLogs(logs, found []types.Log, err error) []types.Log {
+	if err != nil {
+		return logs
+	}
+	type logId struct {
+		txHash gethcmn.Hash
+		index  uint
+	}
+	set := make(map[logId]struct{}, len(found))
+	for _, log := range found {
+		set[logId{log.TxHash, log.Index}] = struct{}{}
+	}
+	var missing []types.Log
+	for _, log := range logs {
+		if _, ok := set[logId{log.TxHash, log.Index}]; !ok {
+			missing = append(missing, log)
+		}
+	}
+	return missing
+}
+
+// acceptAllLogs keeps all the logs returned by the index, the ones not expected are ignored later
+func acceptAllLogs(_ gethcmn.Address, _ []gethcmn.Hash, _ []gethcmn.Address, _ [][]gethcmn.Hash) bool {
+	return true
+}
+
+// reindexBlock adds the block at height to moeingdb again, which rewrites its index entries
+func reindexBlock(reader indexReader, historyStore modbtypes.DB, height int64, indexes param.ModbIndexes) error {
+	block, err := reader.GetBlockByHeight(uint64(height))
+	if err != nil {
+		return err
+	}
+	txs, sigs, err := reader.GetTxListByHeight(uint32(height))
+	if err != nil {
+		return err
+	}
+	blk, err := app.RebuildMdbBlock(block, txs, indexes)
+	if err != nil {
+		return err
+	}
+	txid2sigMap := make(map[[32]byte][65]byte, len(txs))
+	for i, tx := range txs {
+		if i < len(sigs) {
+			txid2sigMap[tx.Hash] = sigs[i]
+		}
+	}
+	historyStore.AddBlock(blk, -1, txid2sigMap)
+	return nil
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	gethcmn "github.com/ethereum/go-ethereum/common"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/require"
+
+	"github.com/smartbch/moeingevm/types"
+
+	"github.com/smartbch/smartbch/param"
+)
+
+// fakeIndex serves one block, the entries of its indexes can be dropped
+type fakeIndex struct {
+	block *types.Block
+	txs   []*types.Transaction
+
+	noTxHash  map[gethcmn.Hash]bool
+	noTxFrom  map[gethcmn.Hash]bool
+	noLogAddr map[gethcmn.Address]bool
+	noTopic   map[gethcmn.Hash]bool
+}
+
+func (idx *fakeIndex) GetBlockByHeight(height uint64) (*types.Block, error) {
+	if int64(height) != idx.block.Number {
+		return nil, errors.New("not found")
+	}
+	return idx.block, nil
+}
+
+func (idx *fakeIndex) GetTxListByHeight(height uint32) ([]*types.Transaction, [][65]byte, error) {
+	return idx.txs, make([][65]byte, len(idx.txs)), nil
+}
+
+func (idx *fakeIndex) GetTxByHash(txHash gethcmn.Hash) (*types.Transaction, [65]byte, error) {
+	for _, tx := range idx.txs {
+		if tx.Hash == txHash && !idx.noTxHash[txHash] {
+			return tx, [65]byte{}, nil
+		}
+	}
+	return nil, [65]byte{}, nil
+}
+
+func (idx *fakeIndex) QueryLogs(addresses []gethcmn.Address, topics [][]gethcmn.Hash, _, _ uint32, _ types.FilterFunc) ([]types.Log, error) {
+	var logs []types.Log
+	for _, tx := range idx.txs {
+		for _, log := range tx.Logs {
+			if len(addresses) != 0 && (log.Address != addresses[0] || idx.noLogAddr[log.Address]) {
+				continue
+			}
+			if len(topics) != 0 {
+				pos := len(topics) - 1
+				if len(log.Topics) <= pos || log.Topics[pos] != topics[pos][0] || idx.noTopic[topics[pos][0]] {
+					continue
+				}
+			}
+			log.TxHash = tx.Hash
+			logs = append(logs, log)
+		}
+	}
+	return logs, nil
+}
+
+func (idx *fakeIndex) QueryTxBySrc(addr gethcmn.Address, _, _, _ uint32) ([]*types.Transaction, [][65]byte, error) {
+	var txs []*types.Transaction
+	for _, tx := range idx.txs {
+		if tx.From == addr && !idx.noTxFrom[tx.Hash] {
+			txs = append(txs, tx)
+		}
+	}
+	return txs, nil, nil
+}
+
+func (idx *fakeIndex) QueryTxByDst(addr gethcmn.Address, _, _, _ uint32) ([]*types.Transaction, [][65]byte, error) {
+	var txs []*types.Transaction
+	for _, tx := range idx.txs {
+		if tx.To == addr {
+			txs = append(txs, tx)
+		}
+	}
+	return txs, nil, nil
+}
+
+func newFakeIndex() *fakeIndex {
+	block := &types.Block{Number: 10, Hash: gethcmn.Hash{0xB1}}
+	txs := []*types.Transaction{
+		{Hash: gethcmn.Hash{0xC1}, BlockNumber: 10, From: gethcmn.Address{0x01}, To: gethcmn.Address{0x02},
+			Logs: []types.Log{{Address: gethcmn.Address{0x02}, Topics: [][32]byte{{0xE1}, {0xE2}}}}},
+		// a contract creation
+		{Hash: gethcmn.Hash{0xC2}, BlockNumber: 10, From: gethcmn.Address{0x01},
+			Logs: []types.Log{{Address: gethcmn.Address{0x03}, Topics: [][32]byte{{0xE1}}}}},
+	}
+	var bloom gethtypes.Bloom
+	for _, tx := range txs {
+		for _, log := range tx.Logs {
+			bloom.Add(log.Address[:])
+			for _, topic := range log.Topics {
+				bloom.Add(topic[:])
+			}
+		}
+	}
+	block.LogsBloom = bloom
+	return &fakeIndex{block: block, txs: txs}
+}
+
+func issueKinds(issues []IndexIssue) []string {
+	kinds := make([]string, 0, len(issues))
+	for _, issue := range issues {
+		kinds = append(kinds, issue.Kind)
+	}
+	return kinds
+}
+
+func TestVerifyBlockIndex(t *testing.T) {
+	full := param.DefaultAppConfig().ModbIndexes()
+	idx := newFakeIndex()
+	require.Empty(t, verifyBlockIndex(idx, 10, full))
+	require.Equal(t, []string{IndexIssueMissingBlock}, issueKinds(verifyBlockIndex(idx, 11, full)))
+
+	idx.noTxHash = map[gethcmn.Hash]bool{{0xC2}: true}
+	idx.noTxFrom = map[gethcmn.Hash]bool{{0xC1}: true}
+	idx.noLogAddr = map[gethcmn.Address]bool{{0x03}: true}
+	issues := verifyBlockIndex(idx, 10, full)
+	require.ElementsMatch(t, []string{IndexIssueTxFrom, IndexIssueTxHash, IndexIssueLogAddress}, issueKinds(issues))
+	// the entries of the disabled indexes are not expected
+	issues = verifyBlockIndex(idx, 10, param.ModbIndexes{TxTo: true, LogTopics: 2})
+	require.ElementsMatch(t, []string{IndexIssueTxHash, IndexIssueLogAddress}, issueKinds(issues))
+
+	idx = newFakeIndex()
+	idx.noTopic = map[gethcmn.Hash]bool{{0xE1}: true}
+	issues = verifyBlockIndex(idx, 10, full)
+	require.Equal(t, []string{IndexIssueLogTopic, IndexIssueLogTopic}, issueKinds(issues))
+	require.Empty(t, verifyBlockIndex(idx, 10, param.ModbIndexes{TxFrom: true, TxTo: true}))
+
+	idx = newFakeIndex()
+	idx.block.LogsBloom = gethtypes.Bloom{}
+	require.Equal(t, []string{IndexIssueBloom}, issueKinds(verifyBlockIndex(idx, 10, full)))
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/tendermint/tendermint/libs/log"
+
+	"github.com/smartbch/smartbch/watcher"
+)
+
+const (
+	flagHeights = "heights"
+	flagRecent  = "recent"
+	flagVectors = "vectors"
+)
+
+func WatcherSelfTestCmd(_ *Context) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "watcher-selftest",
+		Short: "check that the watcher parses the blocks of the BCH node correctly, by comparing the verbose and the raw blocks",
+		Example: `
+smartbchd watcher-selftest \
+--mainnet-rpc-url=http://127.0.0.1:8332 --mainnet-rpc-username=user --mainnet-rpc-password=pass \
+--heights=1534893,1534900 --recent=6 --vectors=vectors.json
+`,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			bchClient := watcher.NewRpcClient(viper.GetString(flagMainnetUrl), viper.GetString(flagMainnetRpcUser),
+				viper.GetString(flagMainnetRpcPassword), "text/plain;", log.NewNopLogger())
+
+			vectors := make(map[int64]*watcher.SelfTestVector)
+			var heights []int64
+			if path := viper.GetString(flagVectors); path != "" {
+				bz, err := os.ReadFile(path)
+				if err != nil {
+					return err
+				}
+				var list []*watcher.SelfTestVector
+				if err = json.Unmarshal(bz, &list); err != nil {
+					return fmt.Errorf("invalid vectors file: %w", err)
+				}
+				for _, v := range list {
+					vectors[v.Height] = v
+					heights = append(heights, v.Height)
+				}
+			}
+			for _, h := range viper.GetIntSlice(flagHeights) {
+				heights = append(heights, int64(h))
+			}
+			if recent := viper.GetInt64(flagRecent); recent > 0 {
+				latest := bchClient.GetLatestHeight(false)
+				if latest < 0 {
+					return errors.New("cannot get the BCH mainnet height")
+				}
+				for h := latest - recent + 1; h <= latest; h++ {
+					heights = append(heights, h)
+				}
+			}
+			if len(heights) == 0 {
+				return errors.New("no block to test, please set --heights, --recent or --vectors")
+			}
+
+			failed := 0
+			for _, h := range heights {
+				result := bchClient.SelfTest(h, vectors[h])
+				out, _ := json.Marshal(result)
+				fmt.Println(string(out))
+				if len(result.Errors) != 0 {
+					failed++
+				}
+			}
+			if failed != 0 {
+				return fmt.Errorf("%d of %d blocks failed", failed, len(heights))
+			}
+			fmt.Printf("all %d blocks passed\n", len(heights))
+			return nil
+		},
+	}
+	cmd.Flags().String(flagMainnetUrl, "http://127.0.0.1:8332", "BCH Mainnet RPC URL")
+	cmd.Flags().String(flagMainnetRpcUser, "user", "BCH Mainnet RPC user name")
+	cmd.Flags().String(flagMainnetRpcPassword, "88888888", "BCH Mainnet RPC user password")
+	cmd.Flags().IntSlice(flagHeights, nil, "heights of the BCH blocks to test, such as the ones with nominations or cc transfers")
+	cmd.Flags().Int64(flagRecent, 6, "also test the latest N BCH blocks")
+	cmd.Flags().String(flagVectors, "", "JSON file with the expected results: [{height, hash, validatorPubkeys, monitorPubkeys}]")
+	return cmd
+}
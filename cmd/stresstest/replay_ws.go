@@ -10,10 +10,9 @@ import (
 	"strings"
 	"time"
 
-	gethtypes "github.com/ethereum/go-ethereum/core/types"
 	"github.com/gorilla/websocket"
 
-	"github.com/smartbch/smartbch/internal/ethutils"
+	"github.com/smartbch/smartbch/txcodec"
 )
 
 const (
@@ -465,14 +464,10 @@ func sendRawTxSubList(c *websocket.Conn, txList [][]byte, idxList []int, logsMsg
 		}
 	}
 	remainList := make([]int, 0, len(idxList)/3)
-	signer := gethtypes.NewEIP155Signer(chainId.ToBig())
+	txDecoder := txcodec.NewDecoder(txcodec.DefaultConfig(chainId.ToBig()))
 	// Now we make sure the on-chain nonce has already been updated
 	for _, idx := range checkList {
-		tx, err := ethutils.DecodeTx(txList[idx])
-		if err != nil {
-			panic(err)
-		}
-		sender, err := signer.Sender(tx)
+		tx, sender, err := txDecoder.DecodeAndVerify(txList[idx])
 		if err != nil {
 			panic(err)
 		}
@@ -351,6 +351,7 @@ func CreateTestApp(testInitAmt *uint256.Int, keys []string) *testutils.TestApp {
 	params := param.DefaultConfig()
 	params.AppConfig.AppDataPath = adsDir
 	params.AppConfig.ModbDataPath = modbDir
+	params.AppConfig.AuditLogPath = ""
 	params.AppConfig.UseLiteDB = true
 	params.AppConfig.NumKeptBlocks = 5
 	testValidatorPubKey := ed25519.GenPrivKeyFromSecret([]byte("stress")).PubKey()
@@ -26,6 +26,8 @@ contract CC {
     function pause() external {}
     function resume() external {}
     function handleUTXOs() external {}
+    function refund(uint256 txid, uint256 index) external {}
+    function setRefundTarget(uint256 txid, uint256 index, address target) external {}
 }
 */
 
@@ -242,6 +244,24 @@ var ABI = ethutils.MustParseABI(`
 		"stateMutability": "nonpayable",
 		"type": "function"
 	},
+	{
+		"inputs": [
+			{
+				"internalType": "uint256",
+				"name": "txid",
+				"type": "uint256"
+			},
+			{
+				"internalType": "uint256",
+				"name": "index",
+				"type": "uint256"
+			}
+		],
+		"name": "refund",
+		"outputs": [],
+		"stateMutability": "nonpayable",
+		"type": "function"
+	},
 	{
 		"inputs": [],
 		"name": "resume",
@@ -249,6 +269,29 @@ var ABI = ethutils.MustParseABI(`
 		"stateMutability": "nonpayable",
 		"type": "function"
 	},
+	{
+		"inputs": [
+			{
+				"internalType": "uint256",
+				"name": "txid",
+				"type": "uint256"
+			},
+			{
+				"internalType": "uint256",
+				"name": "index",
+				"type": "uint256"
+			},
+			{
+				"internalType": "address",
+				"name": "target",
+				"type": "address"
+			}
+		],
+		"name": "setRefundTarget",
+		"outputs": [],
+		"stateMutability": "nonpayable",
+		"type": "function"
+	},
 	{
 		"inputs": [
 			{
@@ -284,3 +327,11 @@ func PackResumeFunc() []byte {
 func PackHandleUTXOsFunc() []byte {
 	return ABI.MustPack("handleUTXOs")
 }
+
+func PackRefundFunc(txid, index *big.Int) []byte {
+	return ABI.MustPack("refund", txid, index)
+}
+
+func PackSetRefundTargetFunc(txid, index *big.Int, target gethcmn.Address) []byte {
+	return ABI.MustPack("setRefundTarget", txid, index, target)
+}
@@ -0,0 +1,152 @@
+// Package anchor commits the smartBCH block hashes and validator sets into OP_RETURN outputs of
+// BCH transactions, such that anyone can audit the history of smartBCH from the BCH chain. The
+// anchors are sent by the operators, and the input of an anchor transaction is signed by an
+// operator's key, which identifies the operator who notarized the block.
+package anchor
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/gcash/bchd/chaincfg"
+	"github.com/gcash/bchd/txscript"
+	"github.com/gcash/bchd/wire"
+	"github.com/gcash/bchutil"
+
+	"github.com/smartbch/smartbch/crosschain/bchtx"
+)
+
+const (
+	Magic   = "SBCA"
+	Version = 1
+	// magic, version, height, block hash and validators hash
+	PayloadLen = 4 + 1 + 8 + 32 + 32
+	// the change output cannot be smaller than the dust limit
+	DustLimit = 546
+)
+
+var (
+	ErrNoAnchor     = errors.New("no anchor found in the transaction")
+	ErrInsufficient = errors.New("the utxo cannot pay the miner fee")
+)
+
+// Anchor commits a smartBCH block, the hashes are the ones in the tendermint block header, the
+// smartBCH block hash is the tendermint block hash, and ValidatorsHash commits to the validator
+// set which signed the block
+type Anchor struct {
+	Height         int64
+	BlockHash      [32]byte
+	ValidatorsHash [32]byte
+}
+
+func (a *Anchor) Payload() []byte {
+	var buf bytes.Buffer
+	buf.WriteString(Magic)
+	buf.WriteByte(Version)
+	_ = binary.Write(&buf, binary.BigEndian, a.Height)
+	buf.Write(a.BlockHash[:])
+	buf.Write(a.ValidatorsHash[:])
+	return buf.Bytes()
+}
+
+func DecodePayload(data []byte) (*Anchor, error) {
+	if len(data) != PayloadLen || string(data[:4]) != Magic {
+		return nil, ErrNoAnchor
+	}
+	if data[4] != Version {
+		return nil, fmt.Errorf("unsupported anchor version: %d", data[4])
+	}
+	a := &Anchor{Height: int64(binary.BigEndian.Uint64(data[5:13]))}
+	copy(a.BlockHash[:], data[13:45])
+	copy(a.ValidatorsHash[:], data[45:])
+	return a, nil
+}
+
+// Utxo is a P2PKH output of the signer, which pays the miner fee of an anchor transaction
+type Utxo struct {
+	Txid   []byte // in the byte order shown by the block explorers
+	Vout   uint32
+	Amount int64
+}
+
+// BuildTx returns a signed transaction with the anchor in output 0, and the change paid back to
+// the signer in output 1, which can be spent by the next anchor
+func BuildTx(a *Anchor, utxo Utxo, wifStr string, minerFee int64, net *chaincfg.Params) (*wire.MsgTx, error) {
+	if utxo.Amount-minerFee < DustLimit {
+		return nil, ErrInsufficient
+	}
+	wif, err := bchutil.DecodeWIF(wifStr)
+	if err != nil {
+		return nil, err
+	}
+	signerAddr, err := SignerAddress(wif, net)
+	if err != nil {
+		return nil, err
+	}
+	lockingScript, err := txscript.PayToAddrScript(signerAddr)
+	if err != nil {
+		return nil, err
+	}
+	nullData, err := txscript.NullDataScript(a.Payload())
+	if err != nil {
+		return nil, err
+	}
+
+	builder := bchtx.NewTxBuilder(net)
+	if err = builder.AddInput(utxo.Txid, utxo.Vout); err != nil {
+		return nil, err
+	}
+	tx := builder.MsgTx()
+	tx.AddTxOut(wire.NewTxOut(0, nullData))
+	tx.AddTxOut(wire.NewTxOut(utxo.Amount-minerFee, lockingScript))
+
+	sigHash, err := bchtx.CalcSigHash(tx, 0, lockingScript, utxo.Amount, bchtx.SigHashAllForkID)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := bchtx.Sign(wif.PrivKey, sigHash, bchtx.SigHashAllForkID)
+	if err != nil {
+		return nil, err
+	}
+	tx.TxIn[0].SignatureScript, err = bchtx.BuildP2PKHUnlockingScript(sig, wif.SerializePubKey())
+	if err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+// SignerAddress returns the P2PKH address of wif, which receives the change of the anchors
+func SignerAddress(wif *bchutil.WIF, net *chaincfg.Params) (*bchutil.AddressPubKeyHash, error) {
+	return bchutil.NewAddressPubKeyHash(bchutil.Hash160(wif.SerializePubKey()), net)
+}
+
+// ParseTx returns the anchor of tx and the pubkey which signed its first input. The signature
+// is not verified, the transactions read from the BCH chain are verified by the BCH nodes.
+func ParseTx(tx *wire.MsgTx) (a *Anchor, signerPubkey []byte, err error) {
+	for _, out := range tx.TxOut {
+		if txscript.GetScriptClass(out.PkScript) != txscript.NullDataTy {
+			continue
+		}
+		pushes, err := txscript.PushedData(out.PkScript)
+		if err != nil || len(pushes) != 1 {
+			continue
+		}
+		if a, err = DecodePayload(pushes[0]); err == nil {
+			break
+		} else if !errors.Is(err, ErrNoAnchor) {
+			return nil, nil, err
+		}
+	}
+	if a == nil {
+		return nil, nil, ErrNoAnchor
+	}
+	if len(tx.TxIn) != 0 {
+		pushes, err := txscript.PushedData(tx.TxIn[0].SignatureScript)
+		if err == nil && len(pushes) == 2 {
+			signerPubkey = pushes[1]
+		}
+	}
+	return a, signerPubkey, nil
+}
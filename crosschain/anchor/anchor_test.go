@@ -0,0 +1,67 @@
+package anchor
+
+import (
+	"encoding/hex"
+	"testing"
+
+	gethcmn "github.com/ethereum/go-ethereum/common"
+	"github.com/gcash/bchd/chaincfg"
+	"github.com/gcash/bchd/txscript"
+	"github.com/gcash/bchutil"
+	"github.com/stretchr/testify/require"
+
+	"github.com/smartbch/smartbch/crosschain/bchtx"
+)
+
+const wifStr = "L482yD31EhZopxRD3V19QEANQaYkcUZfgNKYY2TV4RTCXa6izAKo"
+
+func TestPayload(t *testing.T) {
+	a := &Anchor{Height: 0x0102, BlockHash: [32]byte{0xaa}, ValidatorsHash: [32]byte{0xbb}}
+	payload := a.Payload()
+	require.Len(t, payload, PayloadLen)
+	require.Equal(t, "53424341"+"01"+"0000000000000102"+"aa", hex.EncodeToString(payload[:14]))
+	decoded, err := DecodePayload(payload)
+	require.NoError(t, err)
+	require.Equal(t, a, decoded)
+
+	_, err = DecodePayload(payload[1:])
+	require.ErrorIs(t, err, ErrNoAnchor)
+	payload[4] = 2
+	_, err = DecodePayload(payload)
+	require.Error(t, err)
+}
+
+func TestBuildAndParseTx(t *testing.T) {
+	net := &chaincfg.TestNet3Params
+	a := &Anchor{Height: 100, BlockHash: [32]byte{1}, ValidatorsHash: [32]byte{2}}
+	utxo := Utxo{Txid: gethcmn.FromHex("afdbc7038bc97c737dc24fe28b50495505810a2e7d0a3950610877f198f8b765"), Amount: 10000}
+	_, err := BuildTx(a, utxo, wifStr, 9500, net)
+	require.ErrorIs(t, err, ErrInsufficient)
+
+	tx, err := BuildTx(a, utxo, wifStr, 1000, net)
+	require.NoError(t, err)
+	require.Len(t, tx.TxOut, 2)
+	require.EqualValues(t, 9000, tx.TxOut[1].Value)
+
+	parsed, signer, err := ParseTx(tx)
+	require.NoError(t, err)
+	require.Equal(t, a, parsed)
+	wif, err := bchutil.DecodeWIF(wifStr)
+	require.NoError(t, err)
+	require.Equal(t, wif.SerializePubKey(), signer)
+
+	// the input is signed correctly
+	addr, err := SignerAddress(wif, net)
+	require.NoError(t, err)
+	lockingScript, err := txscript.PayToAddrScript(addr)
+	require.NoError(t, err)
+	sigHash, err := bchtx.CalcSigHash(tx, 0, lockingScript, utxo.Amount, bchtx.SigHashAllForkID)
+	require.NoError(t, err)
+	pushes, err := txscript.PushedData(tx.TxIn[0].SignatureScript)
+	require.NoError(t, err)
+	require.True(t, bchtx.VerifySig(signer, sigHash, pushes[0]))
+
+	tx.TxOut = tx.TxOut[1:]
+	_, _, err = ParseTx(tx)
+	require.ErrorIs(t, err, ErrNoAnchor)
+}
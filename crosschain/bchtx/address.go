@@ -0,0 +1,93 @@
+package bchtx
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/gcash/bchd/chaincfg"
+	"github.com/gcash/bchutil"
+)
+
+// The formats of the BCH addresses
+const (
+	FormatCashAddr = "cashaddr"
+	FormatLegacy   = "legacy"
+)
+
+var (
+	ErrEmptyAddress    = errors.New("empty address")
+	ErrP2SHNotAllowed  = errors.New("P2SH address is not allowed, the covenant only pays to P2PKH addresses")
+	ErrZeroPubkeyHash  = errors.New("the pubkey hash of the address is zero")
+	ErrWrongNetAddress = errors.New("the address is not for the BCH network of this chain")
+)
+
+// RedeemTarget is a decoded BCH address which the covenant can pay to
+type RedeemTarget struct {
+	PubkeyHash [20]byte // the target address passed to redeem() of the cc contract
+	Format     string
+	CashAddr   string
+	Legacy     string
+}
+
+// DecodeRedeemTarget strictly decodes a P2PKH address of net, in CashAddr (with or without the
+// prefix) or legacy format. The covenant pays to P2PKH(targetAddress) when redeeming, so the
+// addresses of the other types and the other networks are rejected, the BCH sent to them would
+// be burnt.
+func DecodeRedeemTarget(addr string, net *chaincfg.Params) (*RedeemTarget, error) {
+	addr = strings.TrimSpace(addr)
+	if addr == "" {
+		return nil, ErrEmptyAddress
+	}
+	if prefix, ok := cashAddrPrefix(addr); ok && !strings.EqualFold(prefix, net.CashAddressPrefix) {
+		return nil, fmt.Errorf("%w: prefix %s, expected %s", ErrWrongNetAddress, prefix, net.CashAddressPrefix)
+	}
+	decoded, err := bchutil.DecodeAddress(addr, net)
+	if err != nil {
+		return nil, err
+	}
+	if !decoded.IsForNet(net) {
+		return nil, ErrWrongNetAddress
+	}
+	target := &RedeemTarget{}
+	switch decoded.(type) {
+	case *bchutil.AddressPubKeyHash:
+		target.Format = FormatCashAddr
+	case *bchutil.LegacyAddressPubKeyHash:
+		target.Format = FormatLegacy
+	case *bchutil.AddressScriptHash, *bchutil.LegacyAddressScriptHash:
+		return nil, ErrP2SHNotAllowed
+	default:
+		return nil, fmt.Errorf("unsupported address type %T", decoded)
+	}
+	copy(target.PubkeyHash[:], decoded.ScriptAddress())
+	if target.PubkeyHash == [20]byte{} {
+		return nil, ErrZeroPubkeyHash
+	}
+	if err = encodeTarget(target, net); err != nil {
+		return nil, err
+	}
+	return target, nil
+}
+
+func encodeTarget(target *RedeemTarget, net *chaincfg.Params) error {
+	cashAddr, err := bchutil.NewAddressPubKeyHash(target.PubkeyHash[:], net)
+	if err != nil {
+		return err
+	}
+	target.CashAddr = net.CashAddressPrefix + ":" + cashAddr.EncodeAddress()
+	legacy, err := bchutil.NewLegacyAddressPubKeyHash(target.PubkeyHash[:], net)
+	if err != nil {
+		return err
+	}
+	target.Legacy = legacy.EncodeAddress()
+	return nil
+}
+
+func cashAddrPrefix(addr string) (string, bool) {
+	idx := strings.IndexByte(addr, ':')
+	if idx < 0 {
+		return "", false
+	}
+	return addr[:idx], true
+}
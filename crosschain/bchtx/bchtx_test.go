@@ -0,0 +1,230 @@
+package bchtx
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	gethcmn "github.com/ethereum/go-ethereum/common"
+	"github.com/gcash/bchd/chaincfg"
+	"github.com/gcash/bchutil"
+	"github.com/stretchr/testify/require"
+)
+
+// the vectors are the same as the ones in crosschain/covenant, and were checked independently
+var (
+	scriptWithoutArgs = gethcmn.FromHex("5279009c635a795c797e5d797e5e797e5f797e60797e0111797e0112797e0113797e0114797ea97b8800537a717c567a577a587a597a575b7a5c7a5d7a5e7a5f7a607a01117a01127a01137a01147a5aafc3519dc4519d00cc00c602204e94a2695279827700a05479827700a09b635279827701149d5379827701149d011454797e01147e53797ec1012a7f777e02a91478a97e01877e00cd78886d686d6d51677b519d547956797e57797ea98800727c52557a567a577a53afc0009d00cc00c69d03008700b27501147b7ec101157f777e02a9147ca97e01877e00cd877768")
+
+	operatorPks = [][]byte{
+		gethcmn.FromHex("02d86b49e3424e557beebf67bd06842cdb88e314c44887f3f265b7f81107dd6994"),
+		gethcmn.FromHex("035c0a0cb8987290ea0a7a926e8aa8978ac042b4c0be8553eb4422461ce1a17cd8"),
+		gethcmn.FromHex("03fdec69ef6ec640264045229ca7cf0f170927b87fc8d2047844f8a766ead467e4"),
+		gethcmn.FromHex("038fd3d33474e1bd453614f85d8fb1edecae92255867d18a9048669119fb710af5"),
+		gethcmn.FromHex("0394ec324d59305638ead14b4f4da9a50c793f1e328e180f92c04a4990bb573af1"),
+		gethcmn.FromHex("0271ea0c254ebbb7ed78668ba8653abe222b9f7177642d3a75709d95912a8d9d2c"),
+		gethcmn.FromHex("02fbbc3870035c2ee30cfa3102aff15e58bdfc0d0f95998cd7e1eeebc09cdb6873"),
+		gethcmn.FromHex("0386f450b1bee3b220c6a9a25515f15f05bd80a23e5f707873dfbac52db933b27d"),
+		gethcmn.FromHex("03bfe6f6ecb5e10662481aeb6f6408db2a32b9b86a660acbb8c5374dbb976e53ca"),
+		gethcmn.FromHex("03883b732620e238e74041e5fab900234dc80f7a48d56a1bf41e8523c4661f8243"),
+	}
+	operatorPks2 = [][]byte{
+		gethcmn.FromHex("03b027f1a8faa455ba42b2e8d898c28530835b33985e0ec45fdb6a4a9ce2eba06e"),
+		gethcmn.FromHex("02036a24560d3892a68a2a839952e41eea9499223e45032a1f1f0eb06cb33b5b0b"),
+		gethcmn.FromHex("02d452825042d8b86d038c39143521dbecd6d3c4d102d69bf16e6f2feb28526866"),
+		gethcmn.FromHex("037e38fc889954f0916a53f33badb8bc9f02d64eac516d0822fc440fa3d52041aa"),
+		gethcmn.FromHex("02f03ad456b4f6b71037fe668f704e4d0ea4c29b72a913a8a3cc0601e937ca73a7"),
+		gethcmn.FromHex("02adf4bd0fa7413b0e79e5e49c0a239de768a400b58d25d856178fd1c640c26302"),
+		gethcmn.FromHex("021a4c0ac1a3198172f0855d6d8cd278cd6a111816dbcc8de5331b60142ee1babf"),
+		gethcmn.FromHex("037ab9aebb2ebfc2aef39f67952ce63a3f264eea76f22e986e573d338eca27a8cc"),
+		gethcmn.FromHex("024841324c9007d6dd3f641862aa2fbc981db444797369e32bc16ae8df0166d5c1"),
+		gethcmn.FromHex("02516d07ed1eadf9a19ac5b723c7030d913c01475e923c7d08c805312491debab3"),
+	}
+	monitorPks = [][]byte{
+		gethcmn.FromHex("024a899d685daf6b1999a5c8f2fd3c9ed640d58e92fd0e00cf87cacee8ff1504b8"),
+		gethcmn.FromHex("0374ac9ab3415253dbb7e29f46a69a3e51b5d2d66f125b0c9f2dc990b1d2e87e17"),
+		gethcmn.FromHex("024cc911ba9d2c7806a217774618b7ba4848ccd33fe664414fc3144d144cdebf7b"),
+	}
+
+	alice = "bchtest:qp5vev8yjxzyf0wmqhwvkvfa3jtear397gwsfxg7sa"
+	net   = &chaincfg.TestNet3Params
+)
+
+func TestRedeemScriptAndAddress(t *testing.T) {
+	require.Equal(t, "553fac4027a7a3c4e8a3eaea75aab173d3c8144b", hex.EncodeToString(PubkeysHash(operatorPks)))
+	require.Equal(t, "27c4ca4766591e6bb8cd71b83143946c53eaf9a3", hex.EncodeToString(PubkeysHash(monitorPks)))
+
+	redeemScript, err := BuildRedeemScript(scriptWithoutArgs, operatorPks, monitorPks)
+	require.NoError(t, err)
+	require.Len(t, redeemScript, 266)
+	require.Equal(t, "14553fac4027a7a3c4e8a3eaea75aab173d3c8144b1427c4ca4766591e6bb8cd71b83143946c53eaf9a3",
+		hex.EncodeToString(redeemScript[:42]))
+	require.Equal(t, scriptWithoutArgs, redeemScript[42:])
+
+	addr, err := P2SHAddress(redeemScript, net)
+	require.NoError(t, err)
+	require.Equal(t, "bchtest:pp4d87q4y0y84gtlrhaqsfcu74akya7f3c54m3nhzk", addr)
+	addr20 := P2SHAddress20(redeemScript)
+	require.Equal(t, "6ad3f81523c87aa17f1dfa08271cf57b6277c98e", hex.EncodeToString(addr20[:]))
+
+	redeemScript2, err := BuildRedeemScript(scriptWithoutArgs, operatorPks2, monitorPks)
+	require.NoError(t, err)
+	addr, err = P2SHAddress(redeemScript2, net)
+	require.NoError(t, err)
+	require.Equal(t, "bchtest:pzlduymn0t2ftyqr4x7c7njtgmzw7768gg8welvegf", addr)
+}
+
+func TestPayToAddrScript(t *testing.T) {
+	script, err := PayToAddrScript(alice, net)
+	require.NoError(t, err)
+	require.Equal(t, "76a91468ccb0e4918444bddb05dccb313d8c979e8e25f288ac", hex.EncodeToString(script))
+	script, err = PayToAddrScript("bchtest:pzlduymn0t2ftyqr4x7c7njtgmzw7768gg8welvegf", net)
+	require.NoError(t, err)
+	require.Equal(t, "a914bede13737ad4959003a9bd8f4e4b46c4ef7b474287", hex.EncodeToString(script))
+
+	_, err = PayToAddrScript(alice, &chaincfg.MainNetParams)
+	require.Error(t, err)
+}
+
+func TestDecodeRedeemTarget(t *testing.T) {
+	pkh := "68ccb0e4918444bddb05dccb313d8c979e8e25f2"
+	for _, addr := range []string{alice, alice[len("bchtest:"):], " " + alice + " ", "mq55nBbgXSk3t9vgfaeNVM3Xb2uU9NutZq"} {
+		target, err := DecodeRedeemTarget(addr, net)
+		require.NoError(t, err, addr)
+		require.Equal(t, pkh, hex.EncodeToString(target.PubkeyHash[:]))
+		require.Equal(t, alice, target.CashAddr)
+		require.Equal(t, "mq55nBbgXSk3t9vgfaeNVM3Xb2uU9NutZq", target.Legacy)
+	}
+	target, _ := DecodeRedeemTarget("mq55nBbgXSk3t9vgfaeNVM3Xb2uU9NutZq", net)
+	require.Equal(t, FormatLegacy, target.Format)
+	target, _ = DecodeRedeemTarget(alice, net)
+	require.Equal(t, FormatCashAddr, target.Format)
+
+	_, err := DecodeRedeemTarget("", net)
+	require.ErrorIs(t, err, ErrEmptyAddress)
+	_, err = DecodeRedeemTarget("bchtest:pzlduymn0t2ftyqr4x7c7njtgmzw7768gg8welvegf", net)
+	require.ErrorIs(t, err, ErrP2SHNotAllowed)
+	_, err = DecodeRedeemTarget("2NAeSQqkgbrQTZaL9GtAdukuNogfU8pRoX9", net)
+	require.ErrorIs(t, err, ErrP2SHNotAllowed)
+	_, err = DecodeRedeemTarget(alice, &chaincfg.MainNetParams)
+	require.ErrorIs(t, err, ErrWrongNetAddress)
+	_, err = DecodeRedeemTarget("1AZ8V8WhiRJo73T4x1fzfRqCj3JmEJSoQ9", net)
+	require.Error(t, err)
+	_, err = DecodeRedeemTarget(alice[:len(alice)-1]+"b", net) // bad checksum
+	require.Error(t, err)
+}
+
+func TestRedeemTx(t *testing.T) {
+	redeemScript, err := BuildRedeemScript(scriptWithoutArgs, operatorPks, monitorPks)
+	require.NoError(t, err)
+
+	builder := NewTxBuilder(net)
+	require.NoError(t, builder.AddInput(gethcmn.FromHex("afdbc7038bc97c737dc24fe28b50495505810a2e7d0a3950610877f198f8b765"), 0))
+	require.NoError(t, builder.AddOutput(alice, 3003))
+	tx := builder.MsgTx()
+	require.Equal(t, "020000000165b7f898f177086150390a7d2e0a81055549508be24fc27d737cc98b03c7dbaf0000000000ffffffff01bb0b0000000000001976a91468ccb0e4918444bddb05dccb313d8c979e8e25f288ac00000000",
+		hex.EncodeToString(MsgTxToBytes(tx)))
+
+	sigHash, err := CalcSigHash(tx, 0, redeemScript, 5003, SigHashAllForkID)
+	require.NoError(t, err)
+	require.Equal(t, "fce3d3e95ca05c9989738105f53b83af17d5b2dff2a11b1c895b3b576fac6fbd", hex.EncodeToString(sigHash))
+
+	// the amount is committed
+	sigHash2, err := CalcSigHash(tx, 0, redeemScript, 5004, SigHashAllForkID)
+	require.NoError(t, err)
+	require.NotEqual(t, sigHash, sigHash2)
+
+	_, err = CalcSigHash(tx, 1, redeemScript, 5003, SigHashAllForkID)
+	require.Error(t, err)
+}
+
+func TestConvertByMonitorsTx(t *testing.T) {
+	redeemScript, err := BuildRedeemScript(scriptWithoutArgs, operatorPks, monitorPks)
+	require.NoError(t, err)
+
+	builder := NewTxBuilder(net)
+	require.NoError(t, builder.AddInput(gethcmn.FromHex("d025ad9428530ef952822d769fc1078dca2e0b51dd6998faedd6ae83c3b4cc76"), 0))
+	builder.SetSequence(0, 34560)
+	require.NoError(t, builder.AddOutput("bchtest:pzlduymn0t2ftyqr4x7c7njtgmzw7768gg8welvegf", 10000))
+	tx := builder.MsgTx()
+	require.Equal(t, "020000000176ccb4c383aed6edfa9869dd510b2eca8d07c19f762d8252f90e532894ad25d000000000000087000001102700000000000017a914bede13737ad4959003a9bd8f4e4b46c4ef7b47428700000000",
+		hex.EncodeToString(MsgTxToBytes(tx)))
+
+	sigHash, err := CalcSigHash(tx, 0, redeemScript, 10000, SigHashSingleAnyOneCanPayForkID)
+	require.NoError(t, err)
+	require.Equal(t, "a982706f65eae1b5150c31e92ca4d6ee813e324cbc98e369f81afcf73028acb6", hex.EncodeToString(sigHash))
+
+	// the miner fee is paid by another input and the change goes to another output,
+	// which must not change the digest signed by the monitors
+	feeBuilder := WrapMsgTx(tx, net)
+	require.NoError(t, feeBuilder.AddInput(gethcmn.FromHex("03a6a2c9ea5f2faf82a8c2f9035a44e6de2e51154fe08f17c9d3978c2dce9c64"), 0))
+	require.NoError(t, feeBuilder.AddOutput(alice, 7000))
+	sigHash, err = CalcSigHash(tx, 0, redeemScript, 10000, SigHashSingleAnyOneCanPayForkID)
+	require.NoError(t, err)
+	require.Equal(t, "a982706f65eae1b5150c31e92ca4d6ee813e324cbc98e369f81afcf73028acb6", hex.EncodeToString(sigHash))
+
+	sigHash, err = CalcP2PKHSigHash(tx, 1, 10001, alice, net)
+	require.NoError(t, err)
+	require.Equal(t, "e9516fbd8012f3addce193c820de1fb79fd7575f143b17a62e7063648e93b2a6", hex.EncodeToString(sigHash))
+}
+
+func TestBuildMultisigUnlockingScript(t *testing.T) {
+	pubkeys := [][]byte{
+		append([]byte{0x02}, bytes.Repeat([]byte{0x01}, 32)...),
+		append([]byte{0x02}, bytes.Repeat([]byte{0x02}, 32)...),
+		append([]byte{0x02}, bytes.Repeat([]byte{0x03}, 32)...),
+	}
+	sigs := [][]byte{{0x30, 0x01}, {0x30, 0x02}}
+	args := [][]byte{nil, bytes.Repeat([]byte{0xaa}, 20)}
+	script, err := BuildMultisigUnlockingScript(args, pubkeys, sigs, 1, []byte{0x51, 0x52})
+	require.NoError(t, err)
+	require.Equal(t, "00"+ // empty arg
+		"14aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"+
+		"21020303030303030303030303030303030303030303030303030303030303030303"+ // pubkeys, reversed
+		"21020202020202020202020202020202020202020202020202020202020202020202"+
+		"21020101010101010101010101010101010101010101010101010101010101010101"+
+		"02300202300151"+ // sigs, reversed, and the selector
+		"025152", // redeem script
+		hex.EncodeToString(script))
+
+	script, err = BuildP2PKHUnlockingScript([]byte{0x30, 0x01, 0x41}, pubkeys[0])
+	require.NoError(t, err)
+	require.Equal(t, "0330014121020101010101010101010101010101010101010101010101010101010101010101",
+		hex.EncodeToString(script))
+}
+
+func TestSign(t *testing.T) {
+	wifStr := "L482yD31EhZopxRD3V19QEANQaYkcUZfgNKYY2TV4RTCXa6izAKo"
+	wif, err := bchutil.DecodeWIF(wifStr)
+	require.NoError(t, err)
+	pubkey := wif.PrivKey.PubKey().SerializeCompressed()
+	hash := gethcmn.FromHex("fce3d3e95ca05c9989738105f53b83af17d5b2dff2a11b1c895b3b576fac6fbd")
+
+	sig, err := SignWithWIF(wifStr, hash, SigHashAllForkID)
+	require.NoError(t, err)
+	require.Equal(t, byte(SigHashAllForkID), sig[len(sig)-1])
+	require.True(t, VerifySig(pubkey, hash, sig))
+	sig2, err := Sign(wif.PrivKey, hash, SigHashAllForkID)
+	require.NoError(t, err)
+	require.Equal(t, sig, sig2) // RFC6979
+
+	sig, err = Sign(wif.PrivKey, hash, SigHashSingleAnyOneCanPayForkID)
+	require.NoError(t, err)
+	require.Equal(t, byte(0xc3), sig[len(sig)-1])
+	require.False(t, VerifySig(operatorPks[1], hash, sig))
+	require.False(t, VerifySig(pubkey, hash[1:], sig))
+
+	_, err = SignWithWIF("bad", hash, SigHashAllForkID)
+	require.Error(t, err)
+}
+
+func TestMsgTxBytes(t *testing.T) {
+	raw := gethcmn.FromHex("020000000165b7f898f177086150390a7d2e0a81055549508be24fc27d737cc98b03c7dbaf0000000000ffffffff01bb0b0000000000001976a91468ccb0e4918444bddb05dccb313d8c979e8e25f288ac00000000")
+	tx, err := MsgTxFromBytes(raw)
+	require.NoError(t, err)
+	require.Equal(t, "afdbc7038bc97c737dc24fe28b50495505810a2e7d0a3950610877f198f8b765", tx.TxIn[0].PreviousOutPoint.Hash.String())
+	require.Equal(t, int64(3003), tx.TxOut[0].Value)
+	require.Equal(t, raw, MsgTxToBytes(tx))
+
+	_, err = MsgTxFromBytes(raw[:10])
+	require.Error(t, err)
+}
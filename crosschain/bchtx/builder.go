@@ -0,0 +1,73 @@
+package bchtx
+
+import (
+	"encoding/hex"
+
+	"github.com/gcash/bchd/chaincfg"
+	"github.com/gcash/bchd/chaincfg/chainhash"
+	"github.com/gcash/bchd/txscript"
+	"github.com/gcash/bchd/wire"
+	"github.com/gcash/bchutil"
+)
+
+// TxBuilder builds the version 2 transactions which spend the covenant UTXOs
+type TxBuilder struct {
+	msgTx *wire.MsgTx
+	net   *chaincfg.Params
+}
+
+func NewTxBuilder(net *chaincfg.Params) *TxBuilder {
+	return &TxBuilder{
+		msgTx: wire.NewMsgTx(2),
+		net:   net,
+	}
+}
+
+// WrapMsgTx is used to add more inputs and outputs to an existing transaction
+func WrapMsgTx(msgTx *wire.MsgTx, net *chaincfg.Params) *TxBuilder {
+	return &TxBuilder{
+		msgTx: msgTx,
+		net:   net,
+	}
+}
+
+func (builder *TxBuilder) MsgTx() *wire.MsgTx {
+	return builder.msgTx
+}
+
+// AddInput adds an input with an empty unlocking script, txid is in the byte order shown by the block explorers
+func (builder *TxBuilder) AddInput(txid []byte, vout uint32) error {
+	// use NewHashFromStr() to byte-reverse txid !!!
+	utxoHash, err := chainhash.NewHashFromStr(hex.EncodeToString(txid))
+	if err != nil {
+		return err
+	}
+	outPoint := wire.NewOutPoint(utxoHash, vout)
+	txIn := wire.NewTxIn(outPoint, nil)
+	builder.msgTx.AddTxIn(txIn)
+	return nil
+}
+
+// SetSequence sets the sequence of the input at inputIdx, which is the relative lock time in blocks
+func (builder *TxBuilder) SetSequence(inputIdx int, sequence uint32) {
+	builder.msgTx.TxIn[inputIdx].Sequence = sequence
+}
+
+// AddOutput adds an output paying outAmt satoshis to a P2PKH or P2SH address
+func (builder *TxBuilder) AddOutput(toAddr string, outAmt int64) error {
+	lockingScript, err := PayToAddrScript(toAddr, builder.net)
+	if err != nil {
+		return err
+	}
+	txOut := wire.NewTxOut(outAmt, lockingScript)
+	builder.msgTx.AddTxOut(txOut)
+	return nil
+}
+
+func PayToAddrScript(addr string, net *chaincfg.Params) ([]byte, error) {
+	decodedAddr, err := bchutil.DecodeAddress(addr, net)
+	if err != nil {
+		return nil, err
+	}
+	return txscript.PayToAddrScript(decodedAddr)
+}
@@ -0,0 +1,65 @@
+package bchtx
+
+import (
+	"bytes"
+
+	"github.com/gcash/bchd/chaincfg"
+	"github.com/gcash/bchd/txscript"
+	"github.com/gcash/bchutil"
+)
+
+// PubkeysHash is the hash160 of the concatenated pubkeys, which is how the covenant commits to
+// the operator and monitor sets
+func PubkeysHash(pubkeys [][]byte) []byte {
+	return bchutil.Hash160(bytes.Join(pubkeys, nil))
+}
+
+// BuildRedeemScript prepends the constructor arguments to the compiled covenant
+func BuildRedeemScript(scriptWithoutConstructorArgs []byte, operatorPks, monitorPks [][]byte) ([]byte, error) {
+	builder := txscript.NewScriptBuilder()
+	builder.AddData(PubkeysHash(operatorPks))
+	builder.AddData(PubkeysHash(monitorPks))
+	builder.AddOps(scriptWithoutConstructorArgs)
+	return builder.Script()
+}
+
+func P2SHAddress20(redeemScript []byte) (addr [20]byte) {
+	copy(addr[:], bchutil.Hash160(redeemScript))
+	return
+}
+
+// P2SHAddress returns the cash address with prefix, such as "bitcoincash:p..."
+func P2SHAddress(redeemScript []byte, net *chaincfg.Params) (string, error) {
+	addr, err := bchutil.NewAddressScriptHashFromHash(bchutil.Hash160(redeemScript), net)
+	if err != nil {
+		return "", err
+	}
+	return net.CashAddressPrefix + ":" + addr.EncodeAddress(), nil
+}
+
+// BuildMultisigUnlockingScript builds the unlocking script of a covenant branch guarded by
+// OP_CHECKMULTISIG. The pushes are, from bottom to top: args, pubkeys and sigs in reversed
+// order, selector and redeemScript. So pubkeys[0] and sigs[0] are the nearest to the top of
+// the stack, and sigs must be in the same order as the pubkeys which created them.
+func BuildMultisigUnlockingScript(args [][]byte, pubkeys [][]byte, sigs [][]byte,
+	selector int64, redeemScript []byte) ([]byte, error) {
+
+	builder := txscript.NewScriptBuilder()
+	for _, arg := range args {
+		builder.AddData(arg)
+	}
+	for i := len(pubkeys) - 1; i >= 0; i-- {
+		builder.AddData(pubkeys[i])
+	}
+	for i := len(sigs) - 1; i >= 0; i-- {
+		builder.AddData(sigs[i])
+	}
+	builder.AddInt64(selector)
+	builder.AddData(redeemScript)
+	return builder.Script()
+}
+
+// BuildP2PKHUnlockingScript builds the unlocking script of a P2PKH input
+func BuildP2PKHUnlockingScript(sig, pubkey []byte) ([]byte, error) {
+	return txscript.NewScriptBuilder().AddData(sig).AddData(pubkey).Script()
+}
@@ -0,0 +1,86 @@
+package bchtx
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/gcash/bchd/bchec"
+	"github.com/gcash/bchd/chaincfg"
+	"github.com/gcash/bchd/txscript"
+	"github.com/gcash/bchd/wire"
+	"github.com/gcash/bchutil"
+)
+
+const (
+	// used by the operators, the signed transaction cannot be changed at all
+	SigHashAllForkID = txscript.SigHashAll | txscript.SigHashForkID
+	// used by the monitors, so the miner fee can be paid with another input and output later
+	SigHashSingleAnyOneCanPayForkID = txscript.SigHashSingle | txscript.SigHashAnyOneCanPay | txscript.SigHashForkID
+)
+
+// CalcSigHash returns the BIP143 (with fork id) digest to be signed for the input at inputIdx,
+// scriptCode is the redeem script for P2SH inputs and the locking script for P2PKH inputs
+func CalcSigHash(tx *wire.MsgTx, inputIdx int, scriptCode []byte, inAmt int64,
+	hashType txscript.SigHashType) ([]byte, error) {
+
+	if inputIdx < 0 || inputIdx >= len(tx.TxIn) {
+		return nil, fmt.Errorf("invalid input index: %d", inputIdx)
+	}
+	sigHashes := txscript.NewTxSigHashes(tx)
+	return txscript.CalcSignatureHash(scriptCode, sigHashes, hashType, tx, inputIdx, inAmt, true)
+}
+
+// CalcP2PKHSigHash returns the SIGHASH_ALL digest for an input which spends a P2PKH UTXO of addr
+func CalcP2PKHSigHash(tx *wire.MsgTx, inputIdx int, inAmt int64, addr string, net *chaincfg.Params) ([]byte, error) {
+	lockingScript, err := PayToAddrScript(addr, net)
+	if err != nil {
+		return nil, err
+	}
+	return CalcSigHash(tx, inputIdx, lockingScript, inAmt, SigHashAllForkID)
+}
+
+// Sign returns a DER encoded ECDSA signature followed by the hash type byte
+func Sign(privKey *bchec.PrivateKey, hash []byte, hashType txscript.SigHashType) ([]byte, error) {
+	signature, err := privKey.SignECDSA(hash)
+	if err != nil {
+		return nil, fmt.Errorf("cannot sign tx input: %s", err)
+	}
+
+	return append(signature.Serialize(), byte(hashType)), nil
+}
+
+func SignWithWIF(wifStr string, hash []byte, hashType txscript.SigHashType) ([]byte, error) {
+	wif, err := bchutil.DecodeWIF(wifStr)
+	if err != nil {
+		return nil, err
+	}
+	return Sign(wif.PrivKey, hash, hashType)
+}
+
+// VerifySig checks a signature returned by Sign
+func VerifySig(pubkey, hash, sig []byte) bool {
+	if len(sig) < 2 {
+		return false
+	}
+	pk, err := bchec.ParsePubKey(pubkey, bchec.S256())
+	if err != nil {
+		return false
+	}
+	signature, err := bchec.ParseDERSignature(sig[:len(sig)-1], bchec.S256())
+	if err != nil {
+		return false
+	}
+	return signature.Verify(hash, pk)
+}
+
+func MsgTxToBytes(tx *wire.MsgTx) []byte {
+	var buf bytes.Buffer
+	_ = tx.Serialize(&buf)
+	return buf.Bytes()
+}
+
+func MsgTxFromBytes(data []byte) (*wire.MsgTx, error) {
+	msg := &wire.MsgTx{}
+	err := msg.Deserialize(bytes.NewReader(data))
+	return msg, err
+}
@@ -7,7 +7,6 @@ import (
 	"errors"
 	"fmt"
 	"math"
-	"sync"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -19,6 +18,7 @@ import (
 	"github.com/tendermint/tendermint/libs/log"
 
 	"github.com/smartbch/smartbch/crosschain/types"
+	"github.com/smartbch/smartbch/freeze"
 	"github.com/smartbch/smartbch/param"
 	"github.com/smartbch/smartbch/staking"
 )
@@ -40,6 +40,7 @@ var (
 	MinPendingBurningLeft uint64 = 1                // 0.0001BCH
 	MatureTime            int64  = 1                // 24h
 	ForceTransferTime     int64  = 60 * 60 * 24 * 3 // 3days
+	PegInRefundDelay      int64  = 60 * 60 * 24 * 3 // 3days, the time the senders have to redeem the lost-and-found deposits themselves
 )
 
 var (
@@ -55,6 +56,8 @@ var (
 	SelectorHandleUTXOs [4]byte = [4]byte{0x9c, 0x44, 0x8e, 0xfe}
 	SelectorPause       [4]byte = [4]byte{0x84, 0x56, 0xcb, 0x59}
 	SelectorResume      [4]byte = [4]byte{0x04, 0x6f, 0x7d, 0xa2}
+	SelectorRefund      [4]byte = [4]byte{0x5a, 0xf3, 0x6e, 0x3e}
+	SelectorSetRefund   [4]byte = [4]byte{0xeb, 0x44, 0x00, 0x6a}
 
 	HashOfEventNewRedeemable   = crypto.Keccak256Hash([]byte("NewRedeemable(uint256,uint32,address)"))
 	HashOfEventNewLostAndFound = crypto.Keccak256Hash([]byte("NewLostAndFound(uint256,uint32,address)"))
@@ -92,14 +95,19 @@ var (
 	ErrNonPayable              = errors.New("not payable")
 	ErrAlreadyPaused           = errors.New("already paused")
 	ErrMustPauseFirst          = errors.New("must pause first")
+	ErrSenderFrozen            = errors.New("sender is frozen")
+	ErrInvalidRedeemTarget     = errors.New("invalid redeem target address")
+	ErrNotRefundable           = errors.New("utxo is not refundable")
+	ErrNotTimeToRefund         = errors.New("not time to refund")
+	ErrNoRefundTarget          = errors.New("refund target is not set")
+	ErrRefundTargetSet         = errors.New("refund target is already set")
 )
 
 type CcContractExecutor struct {
 	Voter IVoteContract
 
-	Lock               sync.RWMutex
-	Infos              []*types.CCTransferInfo
-	LastEndRescanBlock uint64
+	// the infos of the rescan window, collected by the watcher
+	Queue CcInfoQueue
 
 	UTXOInitCollectDoneChan chan bool
 	logger                  log.Logger
@@ -128,7 +136,7 @@ func (c *CcContractExecutor) Init(ctx *mevmtypes.Context) {
 	if ccCtx == nil {
 		context := types.CCContext{
 			RescanTime:            math.MaxInt64,
-			RescanHeight:          uint64(param.StartMainnetHeightForCC),
+			RescanHeight:          uint64(param.CurrBchNetwork().StartHeightForCC),
 			LastRescannedHeight:   uint64(0),
 			UTXOAlreadyHandled:    true,
 			TotalBurntOnMainChain: uint256.NewInt(uint64(param.AlreadyBurntOnMainChain)).Bytes32(),
@@ -169,14 +177,30 @@ func (c *CcContractExecutor) Execute(ctx *mevmtypes.Context, currBlock *mevmtype
 	case SelectorHandleUTXOs:
 		// func handleUTXOs()
 		return c.handleUTXOs(ctx, currBlock, tx)
+	case SelectorRefund:
+		if ctx.Height < param.PegInRefundForkHeight {
+			return invalidSelector(tx)
+		}
+		// func refund(txid uint256, index uint256) external
+		return refund(ctx, currBlock, tx)
+	case SelectorSetRefund:
+		if ctx.Height < param.PegInRefundForkHeight {
+			return invalidSelector(tx)
+		}
+		// func setRefundTarget(txid uint256, index uint256, target address) onlyMonitor
+		return c.setRefundTarget(ctx, tx)
 	default:
-		status = StatusFailed
-		gasUsed = tx.Gas
-		outData = []byte(ErrInvalidSelector.Error())
-		return
+		return invalidSelector(tx)
 	}
 }
 
+func invalidSelector(tx *mevmtypes.TxToRun) (status int, logs []mevmtypes.EvmLog, gasUsed uint64, outData []byte) {
+	status = StatusFailed
+	gasUsed = tx.Gas
+	outData = []byte(ErrInvalidSelector.Error())
+	return
+}
+
 func (_ *CcContractExecutor) RequiredGas(_ []byte) uint64 {
 	return GasOfCCOp
 }
@@ -211,9 +235,17 @@ func redeem(ctx *mevmtypes.Context, block *mevmtypes.BlockInfo, tx *mevmtypes.Tx
 		outData = []byte(ErrCCPaused.Error())
 		return
 	}
+	if ctx.Height >= param.FreezeForkHeight && freeze.IsFrozen(ctx, tx.From) { // frozen funds cannot leave through the bridge
+		outData = []byte(ErrSenderFrozen.Error())
+		return
+	}
 	var txid [32]byte
 	copy(txid[:], callData[:32])
 	index := uint256.NewInt(0).SetBytes32(callData[32:64])
+	if ctx.Height >= param.RedeemTargetCheckForkHeight && !isValidRedeemTarget(callData[64:96]) {
+		outData = []byte(ErrInvalidRedeemTarget.Error())
+		return
+	}
 	var targetAddress [20]byte
 	copy(targetAddress[:], callData[76:96])
 	if amount.IsZero() {
@@ -241,6 +273,15 @@ func redeem(ctx *mevmtypes.Context, block *mevmtypes.BlockInfo, tx *mevmtypes.Tx
 	return
 }
 
+// isValidRedeemTarget checks the ABI-encoded target address, which is the pubkey hash of the P2PKH
+// address receiving the redeemed BCH. The bytes before the address must be zero, otherwise the
+// caller may have passed a malformed address which is silently truncated, and the zero pubkey hash
+// is rejected because nobody can spend the UTXO paid to it.
+func isValidRedeemTarget(word []byte) bool {
+	var zeros [12]byte
+	return bytes.Equal(word[:12], zeros[:]) && !bytes.Equal(word[12:], make([]byte, 20))
+}
+
 // startRescan(uint mainFinalizedBlockHeight) onlyMonitor
 func (c *CcContractExecutor) startRescan(ctx *mevmtypes.Context, currBlock *mevmtypes.BlockInfo, tx *mevmtypes.TxToRun) (status int, logs []mevmtypes.EvmLog, gasUsed uint64, outData []byte) {
 	status = StatusFailed
@@ -294,7 +335,9 @@ func (c *CcContractExecutor) startRescan(ctx *mevmtypes.Context, currBlock *mevm
 	}
 	if !context.UTXOAlreadyHandled {
 		fmt.Printf("context.UTXOAlreadyHandled is false\n")
-		logs = append(logs, c.handleTransferInfos(ctx, currBlock, context)...)
+		meter := newPegInCallMeter(tx)
+		logs = append(logs, c.handleTransferInfos(ctx, currBlock, context, meter)...)
+		gasUsed += meter.gasUsed
 	}
 	context.LastRescannedHeight = context.RescanHeight
 	context.RescanHeight = rescanHeight
@@ -420,7 +463,9 @@ func (c *CcContractExecutor) handleUTXOs(ctx *mevmtypes.Context, currBlock *mevm
 		outData = []byte(ErrUTXOAlreadyHandled.Error())
 		return
 	}
-	logs = append(logs, c.handleTransferInfos(ctx, currBlock, context)...)
+	meter := newPegInCallMeter(tx)
+	logs = append(logs, c.handleTransferInfos(ctx, currBlock, context, meter)...)
+	gasUsed += meter.gasUsed
 	SaveCCContext(ctx, *context)
 	status = StatusSuccess
 	return
@@ -430,19 +475,19 @@ func isPaused(context *types.CCContext) bool {
 	return len(context.MonitorsWithPauseCommand) != 0
 }
 
-func (c *CcContractExecutor) handleTransferInfos(ctx *mevmtypes.Context, block *mevmtypes.BlockInfo, context *types.CCContext) (logs []mevmtypes.EvmLog) {
+func (c *CcContractExecutor) handleTransferInfos(ctx *mevmtypes.Context, block *mevmtypes.BlockInfo, context *types.CCContext, meter *pegInCallMeter) (logs []mevmtypes.EvmLog) {
 	context.UTXOAlreadyHandled = true
+	var infos []*types.CCTransferInfo
 	for {
-		c.Lock.RLock()
-		if c.LastEndRescanBlock == context.RescanHeight {
+		var ok bool
+		if infos, ok = c.Queue.Take(context.RescanHeight); ok {
 			break
 		}
-		fmt.Printf("cc want handle RescanHeight:%d, but watcher now is %d\n", context.RescanHeight, c.LastEndRescanBlock)
-		c.Lock.RUnlock()
+		fmt.Printf("cc want handle RescanHeight:%d, but watcher has not sealed it\n", context.RescanHeight)
 		time.Sleep(500 * time.Millisecond)
 	}
-	fmt.Printf("handleTransferInfos inofs:%d\n", len(c.Infos))
-	for _, info := range c.Infos {
+	fmt.Printf("handleTransferInfos inofs:%d\n", len(infos))
+	for _, info := range infos {
 		fmt.Println("txid:", hex.EncodeToString(info.UTXO.TxID[:]))
 		fmt.Println("vout:", info.UTXO.Index)
 		fmt.Println("amount:", hex.EncodeToString(info.UTXO.Amount[:]))
@@ -450,10 +495,10 @@ func (c *CcContractExecutor) handleTransferInfos(ctx *mevmtypes.Context, block *
 		fmt.Println("receiver:", hex.EncodeToString(info.Receiver[:]))
 		fmt.Println("covenantAddress:", hex.EncodeToString(info.CovenantAddress[:]))
 	}
-	for _, info := range c.Infos {
+	for _, info := range infos {
 		switch info.Type {
 		case types.TransferType:
-			logs = append(logs, handleTransferTypeUTXO(ctx, context, block, info)...)
+			logs = append(logs, handleTransferTypeUTXO(ctx, context, block, info, meter)...)
 		case types.ConvertType:
 			logs = append(logs, handleConvertTypeUTXO(ctx, context, info)...)
 		case types.RedeemOrLostAndFoundType:
@@ -461,20 +506,51 @@ func (c *CcContractExecutor) handleTransferInfos(ctx *mevmtypes.Context, block *
 		default:
 		}
 	}
-	c.Lock.RUnlock()
 	return logs
 }
 
-func handleTransferTypeUTXO(ctx *mevmtypes.Context, context *types.CCContext, block *mevmtypes.BlockInfo, info *types.CCTransferInfo) []mevmtypes.EvmLog {
+// AckHandledWindow acknowledges the window of the infos if the context, which must be committed,
+// tells it is handled. It is called on commit instead of by handleUTXOs, which the RPC simulations
+// of the handling tx also run.
+func (c *CcContractExecutor) AckHandledWindow(context *types.CCContext) {
+	if context != nil && context.UTXOAlreadyHandled {
+		c.Queue.Ack(context.RescanHeight)
+	}
+}
+
+func handleTransferTypeUTXO(ctx *mevmtypes.Context, context *types.CCContext, block *mevmtypes.BlockInfo, info *types.CCTransferInfo, meter *pegInCallMeter) []mevmtypes.EvmLog {
 	r := types.UTXORecord{
 		Txid:         info.UTXO.TxID,
 		Index:        info.UTXO.Index,
 		Amount:       info.UTXO.Amount,
 		CovenantAddr: info.CovenantAddress,
 	}
-	if info.CovenantAddress == context.LastCovenantAddr {
+	if info.NoReceiver {
+		// the watchers dropped these deposits before the fork, leaving them untracked in the covenant
+		if ctx.Height < param.PegInRefundForkHeight {
+			return nil
+		}
+		// kept as a lost-and-found without owner, which is refunded after a monitor sets the target
+		SaveUTXORecord(ctx, r)
+		SaveRefundRecord(ctx, r.Txid, r.Index, types.RefundRecord{
+			RefundableTime: block.Timestamp + PegInRefundDelay,
+		})
+		fmt.Printf("handleTransferTypeUTXO no receiver\n")
+		// todo: for test
+		infos := LoadInternalInfoForTest(ctx)
+		infos.TotalTransferAmountM2S = uint256.NewInt(0).Add(uint256.NewInt(0).SetBytes32(infos.TotalTransferAmountM2S[:]), uint256.NewInt(0).SetBytes32(info.UTXO.Amount[:])).Bytes32()
+		infos.TotalTransferNumsM2S++
+		SaveInternalInfoForTest(ctx, *infos)
+		return []mevmtypes.EvmLog{buildNewLostAndFound(r.Txid, r.Index, r.CovenantAddr)}
+	}
+	// with a malformed memo, the receiver falls back to the address derived from the sender's pubkey,
+	// which is not the one intended by the memo, so the deposit is kept for the sender instead of minted
+	malformed := ctx.Height >= param.PegInRefundForkHeight && info.MalformedMemo && info.RefundTarget != [20]byte{}
+	if malformed || info.CovenantAddress == context.LastCovenantAddr ||
+		(ctx.Height >= param.CovenantGenerationsForkHeight && info.CovenantAddress != context.CurrCovenantAddr) {
 		r.OwnerOfLost = info.Receiver
 		SaveUTXORecord(ctx, r)
+		recordRefund(ctx, block, info)
 		fmt.Printf("handleTransferTypeUTXO info.CovenantAddress == context.LastCovenantAddr\n")
 		// todo: for test
 		infos := LoadInternalInfoForTest(ctx)
@@ -491,6 +567,7 @@ func handleTransferTypeUTXO(ctx *mevmtypes.Context, context *types.CCContext, bl
 	if amount.Gt(maxAmount) {
 		r.OwnerOfLost = info.Receiver
 		SaveUTXORecord(ctx, r)
+		recordRefund(ctx, block, info)
 		fmt.Printf("handleTransferTypeUTXO amount.Gt(maxAmount)\n")
 		// todo: for test
 		infos := LoadInternalInfoForTest(ctx)
@@ -509,6 +586,7 @@ func handleTransferTypeUTXO(ctx *mevmtypes.Context, context *types.CCContext, bl
 		if pendingBurning.Lt(uint256.NewInt(0).Add(minPendingBurningLeft, amount)) {
 			r.OwnerOfLost = info.Receiver
 			SaveUTXORecord(ctx, r)
+			recordRefund(ctx, block, info)
 			fmt.Printf("handleTransferTypeUTXO amount.Lt(minAmount) and lost\n")
 			// todo: for test
 			infos := LoadInternalInfoForTest(ctx)
@@ -548,7 +626,22 @@ func handleTransferTypeUTXO(ctx *mevmtypes.Context, context *types.CCContext, bl
 	infos.TotalTransferAmountM2S = uint256.NewInt(0).Add(uint256.NewInt(0).SetBytes32(infos.TotalTransferAmountM2S[:]), uint256.NewInt(0).SetBytes32(info.UTXO.Amount[:])).Bytes32()
 	infos.TotalTransferNumsM2S++
 	SaveInternalInfoForTest(ctx, *infos)
-	return []mevmtypes.EvmLog{buildNewRedeemable(r.Txid, r.Index, context.CurrCovenantAddr)}
+	logs := []mevmtypes.EvmLog{buildNewRedeemable(r.Txid, r.Index, context.CurrCovenantAddr)}
+	if ctx.Height >= param.PegInCallForkHeight && info.HasCall() {
+		logs = append(logs, executePegInCall(ctx, block, info, amount, meter))
+	}
+	return logs
+}
+
+// recordRefund lets a lost-and-found deposit be refunded to its sender after PegInRefundDelay
+func recordRefund(ctx *mevmtypes.Context, block *mevmtypes.BlockInfo, info *types.CCTransferInfo) {
+	if ctx.Height < param.PegInRefundForkHeight || info.RefundTarget == [20]byte{} {
+		return
+	}
+	SaveRefundRecord(ctx, info.UTXO.TxID, info.UTXO.Index, types.RefundRecord{
+		Target:         info.RefundTarget,
+		RefundableTime: block.Timestamp + PegInRefundDelay,
+	})
 }
 
 func handleConvertTypeUTXO(ctx *mevmtypes.Context, context *types.CCContext, info *types.CCTransferInfo) []mevmtypes.EvmLog {
@@ -647,6 +740,9 @@ func (c *CcContractExecutor) handleOperatorOrMonitorSetChanged(ctx *mevmtypes.Co
 	context.CovenantAddrLastChangeTime = currBlock.Timestamp
 	context.LastCovenantAddr = context.CurrCovenantAddr
 	context.CurrCovenantAddr = newAddress
+	if ctx.Height >= param.CovenantGenerationsForkHeight {
+		recordCovenantChange(ctx, context.LastCovenantAddr, context.CurrCovenantAddr)
+	}
 	fmt.Printf("handleOperatorOrMonitorSetChanged changed:%v,lastCovenantAddr%s,CurrCovenantAddr:%s\n", changed, common.BytesToAddress(context.LastCovenantAddr[:]).String(), common.BytesToAddress(context.CurrCovenantAddr[:]).String())
 	logs = append(logs, buildChangeAddrLog(context.LastCovenantAddr, context.CurrCovenantAddr))
 	return
@@ -722,6 +818,9 @@ func checkAndUpdateLostAndFoundTX(ctx *mevmtypes.Context, block *mevmtypes.Block
 		return nil, ErrAlreadyRedeemed
 	}
 	fmt.Printf("checkAndUpdateLostAndFoundTX passed\n")
+	if ctx.Height >= param.PegInRefundForkHeight {
+		DeleteRefundRecord(ctx, txid, index)
+	}
 	r.IsRedeemed = true
 	r.RedeemTarget = targetAddress
 	r.ExpectedSignTime = block.Timestamp + ExpectedRedeemSignTimeDelay
@@ -735,6 +834,133 @@ func checkAndUpdateLostAndFoundTX(ctx *mevmtypes.Context, block *mevmtypes.Block
 	return &l, nil
 }
 
+// function refund(txid uint256, index uint256) external
+// Anyone can call it, such that the operators can send the lost-and-found deposits back to the
+// senders who have no gas on smartBCH to redeem them
+func refund(ctx *mevmtypes.Context, block *mevmtypes.BlockInfo, tx *mevmtypes.TxToRun) (status int, logs []mevmtypes.EvmLog, gasUsed uint64, outData []byte) {
+	status = StatusFailed
+	gasUsed = GasOfLostAndFoundRedeem // same as the owner's redeem of a lost-and-found utxo
+	if tx.Gas < gasUsed {
+		outData = []byte(ErrOutOfGas.Error())
+		gasUsed = tx.Gas
+		return
+	}
+	if !uint256.NewInt(0).SetBytes32(tx.Value[:]).IsZero() {
+		outData = []byte(ErrNonPayable.Error())
+		return
+	}
+	callData := tx.Data[4:]
+	if len(callData) < 32+32 {
+		outData = []byte(ErrInvalidCallData.Error())
+		return
+	}
+	context := LoadCCContext(ctx)
+	if context == nil {
+		panic("cc context is nil")
+	}
+	if isPaused(context) {
+		outData = []byte(ErrCCPaused.Error())
+		return
+	}
+	var txid [32]byte
+	copy(txid[:], callData[:32])
+	index := uint256.NewInt(0).SetBytes32(callData[32:64])
+	l, err := checkAndUpdateRefundTX(ctx, block, txid, uint32(index.Uint64()))
+	if err != nil {
+		outData = []byte(err.Error())
+		return
+	}
+	logs = append(logs, *l)
+	status = StatusSuccess
+	return
+}
+
+// checkAndUpdateRefundTX redeems a lost-and-found UTXO to the refund target, the operators handle
+// it as if its owner redeemed it
+func checkAndUpdateRefundTX(ctx *mevmtypes.Context, block *mevmtypes.BlockInfo, txid [32]byte, index uint32) (*mevmtypes.EvmLog, error) {
+	r := LoadUTXORecord(ctx, txid, index)
+	if r == nil {
+		return nil, ErrUTXONotExist
+	}
+	refundRecord := LoadRefundRecord(ctx, txid, index)
+	// the deposits without receiver have no owner, but they have refund records
+	if r.OwnerOfLost == [20]byte{} && refundRecord == nil {
+		return nil, ErrNotLostAndFound
+	}
+	if r.IsRedeemed {
+		return nil, ErrAlreadyRedeemed
+	}
+	if refundRecord == nil {
+		return nil, ErrNotRefundable
+	}
+	if refundRecord.Target == [20]byte{} {
+		return nil, ErrNoRefundTarget
+	}
+	if block.Timestamp < refundRecord.RefundableTime {
+		return nil, ErrNotTimeToRefund
+	}
+	DeleteRefundRecord(ctx, txid, index)
+	r.IsRedeemed = true
+	r.RedeemTarget = refundRecord.Target
+	r.ExpectedSignTime = block.Timestamp + ExpectedRedeemSignTimeDelay
+	SaveUTXORecord(ctx, *r)
+	l := buildRedeemLog(r.Txid, index, r.CovenantAddr, types.FromLostAndFound)
+	return &l, nil
+}
+
+// function setRefundTarget(txid uint256, index uint256, target address) onlyMonitor
+// The sender of a deposit without receiver is unknown to the watchers, such as when its inputs are
+// P2SH, the monitors find out where it must be refunded on the main chain, then anyone can refund it
+func (c *CcContractExecutor) setRefundTarget(ctx *mevmtypes.Context, tx *mevmtypes.TxToRun) (status int, logs []mevmtypes.EvmLog, gasUsed uint64, outData []byte) {
+	status = StatusFailed
+	gasUsed = GasOfCCOp
+	if tx.Gas < gasUsed {
+		outData = []byte(ErrOutOfGas.Error())
+		gasUsed = tx.Gas
+		return
+	}
+	if !uint256.NewInt(0).SetBytes32(tx.Value[:]).IsZero() {
+		outData = []byte(ErrNonPayable.Error())
+		return
+	}
+	callData := tx.Data[4:]
+	if len(callData) < 32+32+32 {
+		outData = []byte(ErrInvalidCallData.Error())
+		return
+	}
+	if !c.Voter.IsMonitor(ctx, tx.From) {
+		outData = []byte(ErrMustMonitor.Error())
+		return
+	}
+	var txid [32]byte
+	copy(txid[:], callData[:32])
+	index := uint32(uint256.NewInt(0).SetBytes32(callData[32:64]).Uint64())
+	var target [20]byte
+	copy(target[:], callData[64+12:96])
+	if target == [20]byte{} {
+		outData = []byte(ErrInvalidRedeemTarget.Error())
+		return
+	}
+	r := LoadUTXORecord(ctx, txid, index)
+	if r == nil {
+		outData = []byte(ErrUTXONotExist.Error())
+		return
+	}
+	refundRecord := LoadRefundRecord(ctx, txid, index)
+	if r.IsRedeemed || refundRecord == nil {
+		outData = []byte(ErrNotRefundable.Error())
+		return
+	}
+	if r.OwnerOfLost != [20]byte{} || refundRecord.Target != [20]byte{} {
+		outData = []byte(ErrRefundTargetSet.Error())
+		return
+	}
+	refundRecord.Target = target
+	SaveRefundRecord(ctx, txid, index, *refundRecord)
+	status = StatusSuccess
+	return
+}
+
 func transferBch(ctx *mevmtypes.Context, sender, receiver common.Address, value *uint256.Int) error {
 	senderAcc := ctx.GetAccount(sender)
 	balance := senderAcc.Balance()
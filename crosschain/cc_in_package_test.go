@@ -26,6 +26,8 @@ func TestSelectors(t *testing.T) {
 	require.Equal(t, getSelector("handleUTXOs()"), SelectorHandleUTXOs)
 	require.Equal(t, getSelector("pause()"), SelectorPause)
 	require.Equal(t, getSelector("resume()"), SelectorResume)
+	require.Equal(t, getSelector("refund(uint256,uint256)"), SelectorRefund)
+	require.Equal(t, getSelector("setRefundTarget(uint256,uint256,address)"), SelectorSetRefund)
 }
 
 func getSelector(funcSig string) (sel [4]byte) {
@@ -133,6 +135,135 @@ func TestRedeem(t *testing.T) {
 	require.Equal(t, [20]byte(alice), loadU.RedeemTarget)
 }
 
+func TestRefund(t *testing.T) {
+	r := rabbit.NewRabbitStore(store.NewMockRootStore())
+	ctx := mtypes.NewContext(&r, nil)
+	ctx.SetCurrentHeight(param.PegInRefundForkHeight)
+	SaveCCContext(ctx, types.CCContext{})
+	alice := common.Address{0x01}
+	info := &types.CCTransferInfo{
+		UTXO:         types.UTXO{TxID: [32]byte{0x1}, Index: 1, Amount: uint256.NewInt(10).Bytes32()},
+		Receiver:     alice,
+		RefundTarget: [20]byte{0x2},
+	}
+	SaveUTXORecord(ctx, types.UTXORecord{
+		OwnerOfLost: alice,
+		Txid:        info.UTXO.TxID,
+		Index:       info.UTXO.Index,
+		Amount:      info.UTXO.Amount,
+	})
+	recordRefund(ctx, &mtypes.BlockInfo{Timestamp: 100}, info)
+	require.Equal(t, &types.RefundRecord{Target: info.RefundTarget, RefundableTime: 100 + PegInRefundDelay},
+		LoadRefundRecord(ctx, info.UTXO.TxID, info.UTXO.Index))
+
+	tx := &mtypes.TxToRun{
+		BasicTx: mtypes.BasicTx{
+			From: common.Address{0x03},
+			Data: ccabi.PackRefundFunc(big.NewInt(0).SetBytes(info.UTXO.TxID[:]), big.NewInt(int64(info.UTXO.Index))),
+			Gas:  GasOfLostAndFoundRedeem,
+		},
+	}
+	status, _, _, outdata := refund(ctx, &mtypes.BlockInfo{Timestamp: 101}, tx)
+	require.Equal(t, StatusFailed, status)
+	require.Equal(t, ErrNotTimeToRefund.Error(), string(outdata))
+
+	status, logs, _, _ := refund(ctx, &mtypes.BlockInfo{Timestamp: 100 + PegInRefundDelay}, tx)
+	require.Equal(t, StatusSuccess, status)
+	require.Len(t, logs, 1)
+	record := LoadUTXORecord(ctx, info.UTXO.TxID, info.UTXO.Index)
+	require.True(t, record.IsRedeemed)
+	require.Equal(t, info.RefundTarget, record.RedeemTarget)
+	require.Nil(t, LoadRefundRecord(ctx, info.UTXO.TxID, info.UTXO.Index))
+
+	status, _, _, outdata = refund(ctx, &mtypes.BlockInfo{Timestamp: 100 + PegInRefundDelay}, tx)
+	require.Equal(t, StatusFailed, status)
+	require.Equal(t, ErrAlreadyRedeemed.Error(), string(outdata))
+}
+
+func TestRefundWithoutReceiver(t *testing.T) {
+	r := rabbit.NewRabbitStore(store.NewMockRootStore())
+	ctx := mtypes.NewContext(&r, nil)
+	ctx.SetCurrentHeight(param.PegInRefundForkHeight)
+	SaveCCContext(ctx, types.CCContext{})
+	info := &types.CCTransferInfo{
+		UTXO:       types.UTXO{TxID: [32]byte{0x1}, Index: 1, Amount: uint256.NewInt(10).Bytes32()},
+		NoReceiver: true,
+	}
+	logs := handleTransferTypeUTXO(ctx, LoadCCContext(ctx), &mtypes.BlockInfo{Timestamp: 100}, info, nil)
+	require.Len(t, logs, 1)
+	require.Equal(t, &types.UTXORecord{Txid: info.UTXO.TxID, Index: info.UTXO.Index, Amount: info.UTXO.Amount},
+		LoadUTXORecord(ctx, info.UTXO.TxID, info.UTXO.Index))
+	require.Equal(t, &types.RefundRecord{RefundableTime: 100 + PegInRefundDelay},
+		LoadRefundRecord(ctx, info.UTXO.TxID, info.UTXO.Index))
+
+	refundTx := &mtypes.TxToRun{
+		BasicTx: mtypes.BasicTx{
+			From: common.Address{0x03},
+			Data: ccabi.PackRefundFunc(big.NewInt(0).SetBytes(info.UTXO.TxID[:]), big.NewInt(int64(info.UTXO.Index))),
+			Gas:  GasOfLostAndFoundRedeem,
+		},
+	}
+	status, _, _, outdata := refund(ctx, &mtypes.BlockInfo{Timestamp: 100 + PegInRefundDelay}, refundTx)
+	require.Equal(t, StatusFailed, status)
+	require.Equal(t, ErrNoRefundTarget.Error(), string(outdata))
+
+	target := common.Address{0x02}
+	setTx := &mtypes.TxToRun{
+		BasicTx: mtypes.BasicTx{
+			From: common.Address{0x04},
+			Data: ccabi.PackSetRefundTargetFunc(big.NewInt(0).SetBytes(info.UTXO.TxID[:]), big.NewInt(int64(info.UTXO.Index)), target),
+			Gas:  GasOfCCOp,
+		},
+	}
+	executor := CcContractExecutor{Voter: &MockVoteContract{}}
+	status, _, _, outdata = executor.setRefundTarget(ctx, setTx)
+	require.Equal(t, StatusFailed, status)
+	require.Equal(t, ErrMustMonitor.Error(), string(outdata))
+
+	executor.Voter = &MockVoteContract{IsM: true}
+	status, _, _, _ = executor.setRefundTarget(ctx, setTx)
+	require.Equal(t, StatusSuccess, status)
+	require.Equal(t, [20]byte(target), LoadRefundRecord(ctx, info.UTXO.TxID, info.UTXO.Index).Target)
+	status, _, _, outdata = executor.setRefundTarget(ctx, setTx)
+	require.Equal(t, StatusFailed, status)
+	require.Equal(t, ErrRefundTargetSet.Error(), string(outdata))
+
+	status, logs, _, _ = refund(ctx, &mtypes.BlockInfo{Timestamp: 100 + PegInRefundDelay}, refundTx)
+	require.Equal(t, StatusSuccess, status)
+	require.Len(t, logs, 1)
+	record := LoadUTXORecord(ctx, info.UTXO.TxID, info.UTXO.Index)
+	require.True(t, record.IsRedeemed)
+	require.Equal(t, [20]byte(target), record.RedeemTarget)
+}
+
+func TestRefundSelectorsBeforeFork(t *testing.T) {
+	r := rabbit.NewRabbitStore(store.NewMockRootStore())
+	ctx := mtypes.NewContext(&r, nil)
+	ctx.SetCurrentHeight(param.PegInRefundForkHeight - 1)
+	SaveCCContext(ctx, types.CCContext{})
+	executor := CcContractExecutor{Voter: &MockVoteContract{IsM: true}}
+	for _, data := range [][]byte{
+		ccabi.PackRefundFunc(big.NewInt(1), big.NewInt(0)),
+		ccabi.PackSetRefundTargetFunc(big.NewInt(1), big.NewInt(0), common.Address{0x02}),
+	} {
+		status, _, gasUsed, outdata := executor.Execute(ctx, &mtypes.BlockInfo{}, &mtypes.TxToRun{
+			BasicTx: mtypes.BasicTx{Data: data, Gas: GasOfCCOp},
+		})
+		require.Equal(t, StatusFailed, status)
+		require.EqualValues(t, GasOfCCOp, gasUsed)
+		require.Equal(t, ErrInvalidSelector.Error(), string(outdata))
+	}
+}
+
+func TestIsValidRedeemTarget(t *testing.T) {
+	word := make([]byte, 32)
+	require.False(t, isValidRedeemTarget(word))
+	word[31] = 1
+	require.True(t, isValidRedeemTarget(word))
+	word[11] = 1 // not a 20-byte address
+	require.False(t, isValidRedeemTarget(word))
+}
+
 func TestHandleUTXOs(t *testing.T) {
 	r := rabbit.NewRabbitStore(store.NewMockRootStore())
 	ctx := mtypes.NewContext(&r, nil)
@@ -162,7 +293,7 @@ func TestHandleUTXOs(t *testing.T) {
 	}
 	var infos []*types.CCTransferInfo
 	infos = append(infos, &info)
-	executor.Infos = infos
+	executor.Queue.SetWindow(context.RescanHeight, infos)
 	status, logs, _, outdata := executor.handleUTXOs(ctx, &mtypes.BlockInfo{Timestamp: UTXOHandleDelay + 1}, &mtypes.TxToRun{
 		BasicTx: mtypes.BasicTx{
 			Gas: GasOfCCOp,
@@ -175,6 +306,10 @@ func TestHandleUTXOs(t *testing.T) {
 	require.Equal(t, [20]byte(alice), record.OwnerOfLost)
 	loadCtx := LoadCCContext(ctx)
 	require.Equal(t, true, loadCtx.UTXOAlreadyHandled)
+	// acknowledged on commit only
+	require.False(t, executor.Queue.acked)
+	executor.AckHandledWindow(loadCtx)
+	require.True(t, executor.Queue.acked)
 }
 
 func TestHandleConvertTypeUTXO(t *testing.T) {
@@ -1,17 +1,15 @@
 package covenant
 
 import (
-	"bytes"
 	"encoding/hex"
 	"errors"
 	"fmt"
 	"strings"
 
 	"github.com/gcash/bchd/chaincfg"
-	"github.com/gcash/bchd/txscript"
 	"github.com/gcash/bchd/wire"
-	"github.com/gcash/bchutil"
 
+	"github.com/smartbch/smartbch/crosschain/bchtx"
 	"github.com/smartbch/smartbch/param"
 )
 
@@ -23,9 +21,18 @@ const (
 	minMonitorSigCount  = param.MinMonitorSigCount
 	minerFee            = param.RedeemOrCovertMinerFee
 	monitorsLock        = param.MonitorTransferWaitBlocks
-	bchNetwork          = param.CcBchNetwork
 )
 
+// the params of the BCH networks, whose address prefixes are used to encode the covenant addresses
+var bchNets = map[string]*chaincfg.Params{
+	param.MainnetBchNetwork:  &chaincfg.MainNetParams,
+	param.Testnet3BchNetwork: &chaincfg.TestNet3Params,
+	param.Testnet4BchNetwork: &chaincfg.TestNet4Params,
+	// chipnet shares the address format with testnet4
+	param.ChipnetBchNetwork: &chaincfg.TestNet4Params,
+	param.RegtestBchNetwork: &chaincfg.RegressionNetParams,
+}
+
 type CcCovenant struct {
 	redeemScriptWithoutConstructorArgs []byte
 	operatorPks                        [][]byte
@@ -42,19 +49,24 @@ func NewDefaultCcCovenant(operatorPks, monitorPks [][]byte) (*CcCovenant, error)
 		return nil, err
 	}
 
-	var bchNet *chaincfg.Params
-	if bchNetwork == chaincfg.MainNetParams.Name {
-		bchNet = &chaincfg.MainNetParams
-	} else if bchNetwork == chaincfg.TestNet3Params.Name {
-		bchNet = &chaincfg.TestNet3Params
-	} else {
-		return nil, errors.New("unknown BCH network: " + bchNetwork)
+	bchNet, err := DefaultNet()
+	if err != nil {
+		return nil, err
 	}
 
 	return NewCcCovenant(hexBytes, operatorPks, monitorPks,
 		minerFee, monitorsLock, bchNet)
 }
 
+// DefaultNet returns the BCH network of this chain, which is selected by genesis.json
+func DefaultNet() (*chaincfg.Params, error) {
+	name := param.CurrBchNetwork().Name
+	if net, ok := bchNets[name]; ok {
+		return net, nil
+	}
+	return nil, errors.New("unknown BCH network: " + name)
+}
+
 func NewCcCovenant(
 	redeemScriptWithoutConstructorArgs []byte,
 	operatorPks [][]byte,
@@ -106,22 +118,13 @@ func (c CcCovenant) Net() *chaincfg.Params {
 /* P2SH address */
 
 func (c CcCovenant) BuildFullRedeemScript() ([]byte, error) {
-	operatorPubkeysHash := bchutil.Hash160(bytes.Join(c.operatorPks, nil))
-	monitorPubkeysHash := bchutil.Hash160(bytes.Join(c.monitorPks, nil))
-
-	builder := txscript.NewScriptBuilder()
-	builder.AddData(operatorPubkeysHash)
-	builder.AddData(monitorPubkeysHash)
-	builder.AddOps(c.redeemScriptWithoutConstructorArgs)
-
-	return builder.Script()
+	return bchtx.BuildRedeemScript(c.redeemScriptWithoutConstructorArgs, c.operatorPks, c.monitorPks)
 }
 
 func (c CcCovenant) GetP2SHAddress20() (addr [20]byte, err error) {
 	redeemScript, err := c.BuildFullRedeemScript()
 	if err == nil {
-		redeemHash := bchutil.Hash160(redeemScript)
-		copy(addr[:], redeemHash)
+		addr = bchtx.P2SHAddress20(redeemScript)
 	}
 	return
 }
@@ -131,14 +134,7 @@ func (c CcCovenant) GetP2SHAddress() (string, error) {
 	if err != nil {
 		return "", err
 	}
-
-	redeemHash := bchutil.Hash160(redeemScript)
-	addr, err := bchutil.NewAddressScriptHashFromHash(redeemHash, c.net)
-	if err != nil {
-		return "", err
-	}
-
-	return c.net.CashAddressPrefix + ":" + addr.EncodeAddress(), nil
+	return bchtx.P2SHAddress(redeemScript, c.net)
 }
 
 func (c CcCovenant) GetP2SHAddressNew(newOperatorPks, newMonitorPks [][]byte) (string, error) {
@@ -151,10 +147,10 @@ func (c CcCovenant) GetP2SHAddressNew(newOperatorPks, newMonitorPks [][]byte) (s
 }
 
 func (c CcCovenant) GetOperatorPubkeysHash() string {
-	return "0x" + hex.EncodeToString(bchutil.Hash160(bytes.Join(c.operatorPks, nil)))
+	return "0x" + hex.EncodeToString(bchtx.PubkeysHash(c.operatorPks))
 }
 func (c CcCovenant) GetMonitorPubkeysHash() string {
-	return "0x" + hex.EncodeToString(bchutil.Hash160(bytes.Join(c.monitorPks, nil)))
+	return "0x" + hex.EncodeToString(bchtx.PubkeysHash(c.monitorPks))
 }
 
 /* redeem by user */
@@ -164,15 +160,15 @@ func (c CcCovenant) BuildRedeemByUserUnsignedTx(
 	toAddr string, // output info
 ) (*wire.MsgTx, error) {
 
-	builder := newMsgTxBuilder(c.net)
-	if err := builder.addInput(txid, vout); err != nil {
+	builder := bchtx.NewTxBuilder(c.net)
+	if err := builder.AddInput(txid, vout); err != nil {
 		return nil, err
 	}
-	if err := builder.addOutput(toAddr, inAmt-c.minerFee); err != nil {
+	if err := builder.AddOutput(toAddr, inAmt-c.minerFee); err != nil {
 		return nil, err
 	}
 
-	return builder.msgTx, nil
+	return builder.MsgTx(), nil
 }
 
 func (c CcCovenant) GetRedeemByUserTxSigHash(
@@ -190,10 +186,8 @@ func (c CcCovenant) GetRedeemByUserTxSigHash(
 		return nil, nil, err
 	}
 
-	sigHashes := txscript.NewTxSigHashes(tx)
-	hashType := txscript.SigHashAll | txscript.SigHashForkID
 	inputIdx := 0
-	hash, err := txscript.CalcSignatureHash(redeemScript, sigHashes, hashType, tx, inputIdx, inAmt, true)
+	hash, err := bchtx.CalcSigHash(tx, inputIdx, redeemScript, inAmt, bchtx.SigHashAllForkID)
 	return tx, hash, err
 }
 
@@ -231,18 +225,8 @@ func (c *CcCovenant) buildRedeemOrConvertUnlockingScript(
 		return nil, err
 	}
 
-	builder := txscript.NewScriptBuilder()
-	builder.AddData(newOperatorPubkeysHash)
-	builder.AddData(newMonitorPubkeysHash)
-	for i := len(c.operatorPks) - 1; i >= 0; i-- {
-		builder.AddData(c.operatorPks[i])
-	}
-	for i := len(sigs) - 1; i >= 0; i-- {
-		builder.AddData(sigs[i])
-	}
-	builder.AddInt64(0) // selector
-	builder.AddData(redeemScript)
-	return builder.Script()
+	args := [][]byte{newOperatorPubkeysHash, newMonitorPubkeysHash}
+	return bchtx.BuildMultisigUnlockingScript(args, c.operatorPks, sigs, 0, redeemScript)
 }
 
 /* convert by operators */
@@ -259,15 +243,15 @@ func (c CcCovenant) BuildConvertByOperatorsUnsignedTx(
 		return nil, err
 	}
 
-	builder := newMsgTxBuilder(c.net)
-	if err = builder.addInput(txid, vout); err != nil {
+	builder := bchtx.NewTxBuilder(c.net)
+	if err = builder.AddInput(txid, vout); err != nil {
 		return nil, err
 	}
-	if err = builder.addOutput(toAddr, inAmt-c.minerFee); err != nil {
+	if err = builder.AddOutput(toAddr, inAmt-c.minerFee); err != nil {
 		return nil, err
 	}
 
-	return builder.msgTx, nil
+	return builder.MsgTx(), nil
 }
 
 func (c CcCovenant) GetConvertByOperatorsTxSigHash(
@@ -286,10 +270,8 @@ func (c CcCovenant) GetConvertByOperatorsTxSigHash(
 		return nil, nil, err
 	}
 
-	sigHashes := txscript.NewTxSigHashes(tx)
-	hashType := txscript.SigHashAll | txscript.SigHashForkID
 	inputIdx := 0
-	hash, err := txscript.CalcSignatureHash(redeemScript, sigHashes, hashType, tx, inputIdx, inAmt, true)
+	hash, err := bchtx.CalcSigHash(tx, inputIdx, redeemScript, inAmt, bchtx.SigHashAllForkID)
 	return tx, hash, err
 }
 
@@ -322,9 +304,7 @@ func (c *CcCovenant) BuildConvertByOperatorsUnlockingScript(
 		return nil, err
 	}
 
-	newOperatorPubkeysHash := bchutil.Hash160(bytes.Join(newOperatorPks, nil))
-	newMonitorPubkeysHash := bchutil.Hash160(bytes.Join(newMonitorPks, nil))
-	return c.buildRedeemOrConvertUnlockingScript(newOperatorPubkeysHash, newMonitorPubkeysHash, sigs)
+	return c.buildRedeemOrConvertUnlockingScript(bchtx.PubkeysHash(newOperatorPks), bchtx.PubkeysHash(newMonitorPks), sigs)
 }
 
 /* convert by monitors */
@@ -340,16 +320,16 @@ func (c CcCovenant) BuildConvertByMonitorsUnsignedTx(
 		return nil, err
 	}
 
-	builder := newMsgTxBuilder(c.net)
-	if err = builder.addInput(txid, vout); err != nil {
+	builder := bchtx.NewTxBuilder(c.net)
+	if err = builder.AddInput(txid, vout); err != nil {
 		return nil, err
 	}
-	builder.msgTx.TxIn[0].Sequence = c.monitorLockBlocks
-	if err = builder.addOutput(toAddr, inAmt); err != nil {
+	builder.SetSequence(0, c.monitorLockBlocks)
+	if err = builder.AddOutput(toAddr, inAmt); err != nil {
 		return nil, err
 	}
 
-	return builder.msgTx, nil
+	return builder.MsgTx(), nil
 }
 
 func (c CcCovenant) GetConvertByMonitorsTxSigHash(
@@ -367,10 +347,8 @@ func (c CcCovenant) GetConvertByMonitorsTxSigHash(
 		return nil, nil, err
 	}
 
-	sigHashes := txscript.NewTxSigHashes(tx)
-	hashType := txscript.SigHashSingle | txscript.SigHashAnyOneCanPay | txscript.SigHashForkID
 	inputIdx := 0
-	hash, err := txscript.CalcSignatureHash(redeemScript, sigHashes, hashType, tx, inputIdx, inAmt, true)
+	hash, err := bchtx.CalcSigHash(tx, inputIdx, redeemScript, inAmt, bchtx.SigHashSingleAnyOneCanPayForkID)
 	return tx, hash, err
 }
 
@@ -409,17 +387,8 @@ func (c *CcCovenant) BuildConvertByMonitorsUnlockingScript(
 		return nil, err
 	}
 
-	builder := txscript.NewScriptBuilder()
-	builder.AddData(bchutil.Hash160(bytes.Join(newOperatorPks, nil)))
-	for i := len(c.monitorPks) - 1; i >= 0; i-- {
-		builder.AddData(c.monitorPks[i])
-	}
-	for i := len(sigs) - 1; i >= 0; i-- {
-		builder.AddData(sigs[i])
-	}
-	builder.AddInt64(1) // selector
-	builder.AddData(redeemScript)
-	return builder.Script()
+	args := [][]byte{bchtx.PubkeysHash(newOperatorPks)}
+	return bchtx.BuildMultisigUnlockingScript(args, c.monitorPks, sigs, 1, redeemScript)
 }
 
 func AddConvertByMonitorsTxMinerFee(
@@ -429,12 +398,12 @@ func AddConvertByMonitorsTxMinerFee(
 	net *chaincfg.Params,
 ) (*wire.MsgTx, error) {
 
-	builder := wrapMsgTx(signedTx, net)
-	if err := builder.addInput(txid, vout); err != nil {
+	builder := bchtx.WrapMsgTx(signedTx, net)
+	if err := builder.AddInput(txid, vout); err != nil {
 		return signedTx, err
 	}
 	if inAmt > minerFee {
-		if err := builder.addOutput(changeAddr, inAmt-minerFee); err != nil {
+		if err := builder.AddOutput(changeAddr, inAmt-minerFee); err != nil {
 			return signedTx, err
 		}
 	}
@@ -448,21 +417,8 @@ func GetConvertByMonitorsTxSigHash2(
 	addr string,
 	net *chaincfg.Params,
 ) ([]byte, error) {
-	decodedAddr, err := bchutil.DecodeAddress(addr, net)
-	if err != nil {
-		return nil, err
-	}
-
-	pkScript, err := txscript.PayToAddrScript(decodedAddr) // locking script
-	if err != nil {
-		return nil, err
-	}
-
-	sigHashes := txscript.NewTxSigHashes(txWithMinerFee)
-	hashType := txscript.SigHashAll | txscript.SigHashForkID
 	inputIdx := 1
-	hash, err := txscript.CalcSignatureHash(pkScript, sigHashes, hashType, txWithMinerFee, inputIdx, inAmt, true)
-	return hash, err
+	return bchtx.CalcP2PKHSigHash(txWithMinerFee, inputIdx, inAmt, addr, net)
 }
 
 func AddConvertByMonitorsTxMinerFeeSig(
@@ -470,7 +426,7 @@ func AddConvertByMonitorsTxMinerFeeSig(
 	sig, pkData []byte,
 ) (*wire.MsgTx, error) {
 
-	sigScript, err := txscript.NewScriptBuilder().AddData(sig).AddData(pkData).Script()
+	sigScript, err := bchtx.BuildP2PKHUnlockingScript(sig, pkData)
 	if err != nil {
 		return txWithMinerFee, err
 	}
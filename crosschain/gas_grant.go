@@ -0,0 +1,144 @@
+package crosschain
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"sort"
+
+	"github.com/holiman/uint256"
+
+	mevmtypes "github.com/smartbch/moeingevm/types"
+
+	"github.com/smartbch/smartbch/param"
+)
+
+// The gas grant keeps the accounts of the elected operators and monitors able to pay the gas of
+// their txs, such as startRescan and handleUTXOs of the monitors, so the liveness of the bridge
+// does not depend on them keeping their personal accounts topped up. The gas is sponsored by the
+// pool at GasGrantPoolAddress, which is an ordinary account funded by anyone sending BCH to it.
+//
+// After every block since param.GasGrantForkHeight, an elected operator or monitor whose balance is
+// below param.GasGrantFloor is topped up to it from the pool, but no more than
+// param.GasGrantDailyQuota a day (by the block time) for each account. The accounts are topped up
+// in the order of their addresses until the pool runs out.
+
+const secondsPerDay = 24 * 3600
+
+var (
+	// the gas grant pool, 10008
+	GasGrantPoolAddress [20]byte = [20]byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0x27, 0x18}
+)
+
+// GasGrantUsage is how much an account has been granted in the day
+type GasGrantUsage struct {
+	Day     int64 // the unix time divided by a day
+	Granted *uint256.Int
+}
+
+// buildGasGrantKey differs from buildUTXOKey and buildRefundKey by the prefix
+func buildGasGrantKey(addr [20]byte) string {
+	hash := sha256.Sum256(append([]byte("gasgrant"), addr[:]...))
+	return string(hash[:])
+}
+
+func LoadGasGrantUsage(ctx *mevmtypes.Context, addr [20]byte) GasGrantUsage {
+	bz := ctx.GetStorageAt(ccContractSequence, buildGasGrantKey(addr))
+	if len(bz) != 8+32 {
+		return GasGrantUsage{Granted: uint256.NewInt(0)}
+	}
+	return GasGrantUsage{
+		Day:     int64(binary.BigEndian.Uint64(bz[:8])),
+		Granted: uint256.NewInt(0).SetBytes(bz[8:]),
+	}
+}
+
+func saveGasGrantUsage(ctx *mevmtypes.Context, addr [20]byte, usage GasGrantUsage) {
+	bz := make([]byte, 8, 8+32)
+	binary.BigEndian.PutUint64(bz, uint64(usage.Day))
+	bz = append(bz, usage.Granted.PaddedBytes(32)...)
+	ctx.SetStorageAt(ccContractSequence, buildGasGrantKey(addr), bz)
+}
+
+// GetGasGrantees returns the addresses of the elected operators and monitors, sorted
+func GetGasGrantees(ctx *mevmtypes.Context) [][20]byte {
+	set := make(map[[20]byte]struct{})
+	for _, info := range GetOperatorInfos(ctx) {
+		if info.ElectedTime.Uint64() > 0 {
+			set[info.Addr] = struct{}{}
+		}
+	}
+	for _, info := range GetMonitorInfos(ctx) {
+		if info.ElectedTime.Uint64() > 0 {
+			set[info.Addr] = struct{}{}
+		}
+	}
+	grantees := make([][20]byte, 0, len(set))
+	for addr := range set {
+		grantees = append(grantees, addr)
+	}
+	sort.Slice(grantees, func(i, j int) bool {
+		return bytes.Compare(grantees[i][:], grantees[j][:]) < 0
+	})
+	return grantees
+}
+
+// GrantGas tops up the grantees from the pool at the block time, and returns the amount granted
+// to each of them
+func GrantGas(ctx *mevmtypes.Context, blockTime int64) map[[20]byte]*uint256.Int {
+	if ctx.GetAccount(GasGrantPoolAddress) == nil {
+		return nil
+	}
+	return grantGas(ctx, GetGasGrantees(ctx), blockTime)
+}
+
+func grantGas(ctx *mevmtypes.Context, grantees [][20]byte, blockTime int64) map[[20]byte]*uint256.Int {
+	pool := ctx.GetAccount(GasGrantPoolAddress)
+	if pool == nil {
+		return nil
+	}
+	poolBalance := pool.Balance()
+	floor := uint256.NewInt(param.GasGrantFloor)
+	quota := uint256.NewInt(param.GasGrantDailyQuota)
+	day := blockTime / secondsPerDay
+	granted := make(map[[20]byte]*uint256.Int)
+	for _, addr := range grantees {
+		if poolBalance.IsZero() {
+			break
+		}
+		acc := ctx.GetAccount(addr)
+		if acc == nil {
+			acc = mevmtypes.ZeroAccountInfo()
+		}
+		balance := acc.Balance()
+		if balance.Cmp(floor) >= 0 {
+			continue
+		}
+		usage := LoadGasGrantUsage(ctx, addr)
+		if usage.Day != day {
+			usage = GasGrantUsage{Day: day, Granted: uint256.NewInt(0)}
+		}
+		if usage.Granted.Cmp(quota) >= 0 {
+			continue
+		}
+		amount := uint256.NewInt(0).Sub(floor, balance)
+		if left := uint256.NewInt(0).Sub(quota, usage.Granted); amount.Cmp(left) > 0 {
+			amount = left
+		}
+		if amount.Cmp(poolBalance) > 0 {
+			amount = poolBalance.Clone()
+		}
+		poolBalance.Sub(poolBalance, amount)
+		balance.Add(balance, amount)
+		acc.UpdateBalance(balance)
+		ctx.SetAccount(addr, acc)
+		usage.Granted.Add(usage.Granted, amount)
+		saveGasGrantUsage(ctx, addr, usage)
+		granted[addr] = amount
+	}
+	if len(granted) != 0 {
+		pool.UpdateBalance(poolBalance)
+		ctx.SetAccount(GasGrantPoolAddress, pool)
+	}
+	return granted
+}
@@ -0,0 +1,67 @@
+package crosschain
+
+import (
+	"testing"
+
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/require"
+
+	"github.com/smartbch/moeingads/store"
+	"github.com/smartbch/moeingads/store/rabbit"
+	mtypes "github.com/smartbch/moeingevm/types"
+
+	"github.com/smartbch/smartbch/param"
+)
+
+func setBalance(ctx *mtypes.Context, addr [20]byte, balance uint64) {
+	acc := mtypes.ZeroAccountInfo()
+	acc.UpdateBalance(uint256.NewInt(balance))
+	ctx.SetAccount(addr, acc)
+}
+
+func getBalance(ctx *mtypes.Context, addr [20]byte) uint64 {
+	return ctx.GetAccount(addr).Balance().Uint64()
+}
+
+func TestGrantGas(t *testing.T) {
+	r := rabbit.NewRabbitStore(store.NewMockRootStore())
+	ctx := mtypes.NewContext(&r, nil)
+	floor, quota := param.GasGrantFloor, param.GasGrantDailyQuota
+	require.Zero(t, quota%floor)
+
+	a, b, c := [20]byte{1}, [20]byte{2}, [20]byte{3}
+	require.Nil(t, grantGas(ctx, [][20]byte{a}, 0)) // no pool
+	setBalance(ctx, GasGrantPoolAddress, 5*floor/2)
+	setBalance(ctx, a, floor/4)
+	setBalance(ctx, b, 2*floor)
+	granted := grantGas(ctx, [][20]byte{a, b, c}, 1000)
+	require.Len(t, granted, 2)
+	require.EqualValues(t, floor*3/4, granted[a].Uint64())
+	require.EqualValues(t, floor, granted[c].Uint64())
+	require.EqualValues(t, floor, getBalance(ctx, a))
+	require.EqualValues(t, 2*floor, getBalance(ctx, b))
+	require.EqualValues(t, floor, getBalance(ctx, c))
+	require.EqualValues(t, floor*3/4, getBalance(ctx, GasGrantPoolAddress))
+
+	// a keeps spending its balance, until the quota of today is used up
+	setBalance(ctx, GasGrantPoolAddress, 2*quota)
+	for i := uint64(1); i < quota/floor; i++ {
+		setBalance(ctx, a, 0)
+		require.EqualValues(t, floor, grantGas(ctx, [][20]byte{a}, 2000)[a].Uint64())
+	}
+	setBalance(ctx, a, 0)
+	require.EqualValues(t, floor/4, grantGas(ctx, [][20]byte{a}, 3000)[a].Uint64())
+	require.Empty(t, grantGas(ctx, [][20]byte{a}, 4000))
+	usage := LoadGasGrantUsage(ctx, a)
+	require.EqualValues(t, 0, usage.Day)
+	require.EqualValues(t, quota, usage.Granted.Uint64())
+
+	// the quota is renewed the next day, and the pool is drained in the order of the grantees
+	setBalance(ctx, GasGrantPoolAddress, floor/2)
+	setBalance(ctx, c, 0)
+	granted = grantGas(ctx, [][20]byte{a, b, c}, secondsPerDay+1)
+	require.EqualValues(t, floor/2, granted[a].Uint64())
+	require.NotContains(t, granted, c)
+	require.EqualValues(t, 0, getBalance(ctx, GasGrantPoolAddress))
+	require.EqualValues(t, 1, LoadGasGrantUsage(ctx, a).Day)
+}
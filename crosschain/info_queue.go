@@ -0,0 +1,256 @@
+package crosschain
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"os"
+	"sync"
+
+	"github.com/smartbch/smartbch/crosschain/types"
+)
+
+// CcInfoQueue hands the cc-transfer infos of a rescan window, which covers the BCH blocks in
+// (begin, end], from the watcher to the executor. The watcher appends the infos block by block
+// without blocking the executor, and seals the window after all its blocks are parsed. The
+// executor only handles a sealed window, and acknowledges it afterwards. With a journal file,
+// the infos appended to the window not acknowledged are restored after a restart instead of
+// collected again.
+type CcInfoQueue struct {
+	mtx       sync.Mutex
+	journal   string // empty means disabled
+	begin     int64
+	end       int64
+	collected int64 // the last height whose infos are appended
+	sealed    bool
+	acked     bool
+	byHeight  map[int64][]*types.CCTransferInfo
+	seen      map[infoKey]bool
+}
+
+// ccInfoJournalEntry is a line of the journal, which starts a window, appends the infos of a
+// height or seals the window
+type ccInfoJournalEntry struct {
+	Begin  int64                   `json:"begin,omitempty"`
+	End    int64                   `json:"end,omitempty"`
+	Height int64                   `json:"height,omitempty"`
+	Infos  []*types.CCTransferInfo `json:"infos,omitempty"`
+	// the infos replace the ones of the height, which is re-scanned
+	Replace bool `json:"replace,omitempty"`
+	Sealed  bool `json:"sealed,omitempty"`
+}
+
+// infoKey identifies an info in the window. The redeem and lost-and-found infos only have PrevUTXO,
+// which is the one spent, and the transfer infos only have UTXO, so both of them are keyed.
+type infoKey struct {
+	typ  types.UTXOType
+	prev [36]byte
+	utxo [36]byte
+}
+
+func utxoKey(utxo types.UTXO) (key [36]byte) {
+	copy(key[:32], utxo.TxID[:])
+	binary.BigEndian.PutUint32(key[32:], utxo.Index)
+	return
+}
+
+func keyOfInfo(info *types.CCTransferInfo) infoKey {
+	return infoKey{typ: info.Type, prev: utxoKey(info.PrevUTXO), utxo: utxoKey(info.UTXO)}
+}
+
+// OpenJournal restores the window recorded in the journal file at path, to which the queue is
+// recorded afterwards
+func (q *CcInfoQueue) OpenJournal(path string) error {
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+	q.journal = path
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 64*1024*1024)
+	for scanner.Scan() {
+		var entry ccInfoJournalEntry
+		if json.Unmarshal(scanner.Bytes(), &entry) != nil {
+			break // the last line may be written partially
+		}
+		switch {
+		case entry.End != 0:
+			q.reset(entry.Begin, entry.End)
+		case q.byHeight == nil:
+			continue
+		case entry.Sealed:
+			q.sealed = true
+		case entry.Replace:
+			q.replace(entry.Height, entry.Infos)
+		default:
+			q.append(entry.Height, entry.Infos)
+		}
+	}
+	return nil
+}
+
+// Start begins collecting the window (begin, end], and returns the last height collected before,
+// from which the collection resumes. The infos of another window which is not acknowledged are
+// dropped.
+func (q *CcInfoQueue) Start(begin, end int64) (int64, error) {
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+	if q.begin == begin && q.end == end && q.byHeight != nil {
+		return q.collected, nil
+	}
+	q.reset(begin, end)
+	if q.journal == "" {
+		return begin, nil
+	}
+	if err := os.Remove(q.journal); err != nil && !os.IsNotExist(err) {
+		return begin, err
+	}
+	return begin, q.record(&ccInfoJournalEntry{Begin: begin, End: end})
+}
+
+// Append adds the infos found at height, the ones in the window already are dropped and counted
+// as duplicates
+func (q *CcInfoQueue) Append(height int64, infos []*types.CCTransferInfo) (duplicates int, err error) {
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+	if height <= q.begin || height > q.end || q.byHeight == nil {
+		return 0, nil
+	}
+	added, duplicates := q.append(height, infos)
+	return duplicates, q.record(&ccInfoJournalEntry{Height: height, Infos: added})
+}
+
+// Replace drops the infos of the heights in (begin, end], which are re-scanned, and adds the ones
+// in byHeight instead, at once, so the executor never takes a window re-scanned partially
+func (q *CcInfoQueue) Replace(begin, end int64, byHeight map[int64][]*types.CCTransferInfo) (duplicates int, err error) {
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+	if q.byHeight == nil {
+		return 0, nil
+	}
+	for h := begin + 1; h <= end; h++ {
+		if h <= q.begin || h > q.end {
+			continue
+		}
+		added, dup := q.replace(h, byHeight[h])
+		duplicates += dup
+		if e := q.record(&ccInfoJournalEntry{Height: h, Infos: added, Replace: true}); e != nil {
+			err = e
+		}
+	}
+	return
+}
+
+// Seal marks the window complete, after which the executor can take it
+func (q *CcInfoQueue) Seal() error {
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+	if q.sealed || q.byHeight == nil {
+		return nil
+	}
+	q.sealed = true
+	return q.record(&ccInfoJournalEntry{Sealed: true})
+}
+
+// Take returns the infos of the window ending at end in the order of the blocks, and false if it is
+// not sealed yet
+func (q *CcInfoQueue) Take(end uint64) ([]*types.CCTransferInfo, bool) {
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+	if !q.sealed || q.end != int64(end) {
+		return nil, false
+	}
+	var infos []*types.CCTransferInfo
+	for h := q.begin + 1; h <= q.end; h++ {
+		infos = append(infos, q.byHeight[h]...)
+	}
+	return infos, true
+}
+
+// Ack marks the window ending at end handled in a committed block, so it needs not to be restored
+// after a restart. Its infos are kept until the next window starts, because the same UTXOs may be
+// handled again when the handling tx is simulated by an RPC call.
+func (q *CcInfoQueue) Ack(end uint64) {
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+	if q.end != int64(end) || q.acked {
+		return
+	}
+	q.acked = true
+	if q.journal != "" {
+		_ = os.Remove(q.journal)
+	}
+}
+
+// SetWindow replaces the queue with a sealed window of the given infos, which are known at once,
+// such as in the tests and simulations
+func (q *CcInfoQueue) SetWindow(end uint64, infos []*types.CCTransferInfo) {
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+	q.reset(int64(end)-1, int64(end))
+	q.byHeight[int64(end)] = infos
+	q.collected = int64(end)
+	q.sealed = true
+}
+
+// Len returns the number of the infos in the window
+func (q *CcInfoQueue) Len() int {
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+	return len(q.seen)
+}
+
+func (q *CcInfoQueue) reset(begin, end int64) {
+	q.begin, q.end, q.collected, q.sealed, q.acked = begin, end, begin, false, false
+	q.byHeight = make(map[int64][]*types.CCTransferInfo)
+	q.seen = make(map[infoKey]bool)
+}
+
+func (q *CcInfoQueue) append(height int64, infos []*types.CCTransferInfo) (added []*types.CCTransferInfo, duplicates int) {
+	for _, info := range infos {
+		key := keyOfInfo(info)
+		if q.seen[key] {
+			duplicates++
+			continue
+		}
+		q.seen[key] = true
+		added = append(added, info)
+	}
+	q.byHeight[height] = append(q.byHeight[height], added...)
+	if height > q.collected {
+		q.collected = height
+	}
+	return
+}
+
+func (q *CcInfoQueue) replace(height int64, infos []*types.CCTransferInfo) ([]*types.CCTransferInfo, int) {
+	for _, info := range q.byHeight[height] {
+		delete(q.seen, keyOfInfo(info))
+	}
+	delete(q.byHeight, height)
+	return q.append(height, infos)
+}
+
+func (q *CcInfoQueue) record(entry *ccInfoJournalEntry) error {
+	if q.journal == "" || q.acked {
+		return nil
+	}
+	bz, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(q.journal, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err = f.Write(append(bz, '\n')); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
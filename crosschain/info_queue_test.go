@@ -0,0 +1,127 @@
+package crosschain
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/smartbch/smartbch/crosschain/types"
+)
+
+func ccInfo(txid byte, index uint32) *types.CCTransferInfo {
+	info := &types.CCTransferInfo{}
+	info.UTXO.TxID[0] = txid
+	info.UTXO.Index = index
+	return info
+}
+
+func TestCcInfoQueue(t *testing.T) {
+	q := &CcInfoQueue{}
+	from, err := q.Start(100, 104)
+	require.NoError(t, err)
+	require.EqualValues(t, 100, from)
+
+	dup, _ := q.Append(101, []*types.CCTransferInfo{ccInfo(1, 0), ccInfo(1, 1)})
+	require.Zero(t, dup)
+	_, ok := q.Take(104)
+	require.False(t, ok)
+
+	dup, _ = q.Append(103, []*types.CCTransferInfo{ccInfo(3, 0), ccInfo(1, 1)})
+	require.Equal(t, 1, dup)
+	dup, _ = q.Append(105, []*types.CCTransferInfo{ccInfo(5, 0)}) // out of the window
+	require.Zero(t, dup)
+	require.NoError(t, q.Seal())
+	infos, ok := q.Take(104)
+	require.True(t, ok)
+	require.Equal(t, []*types.CCTransferInfo{ccInfo(1, 0), ccInfo(1, 1), ccInfo(3, 0)}, infos)
+
+	// a re-scan of (101, 103] finds a deposit missed by the old parser, and one emitted again
+	dup, _ = q.Replace(101, 103, map[int64][]*types.CCTransferInfo{
+		102: {ccInfo(2, 0), ccInfo(1, 1)},
+		103: {ccInfo(3, 0)},
+	})
+	require.Equal(t, 1, dup)
+	infos, _ = q.Take(104)
+	require.Equal(t, []*types.CCTransferInfo{ccInfo(1, 0), ccInfo(1, 1), ccInfo(2, 0), ccInfo(3, 0)}, infos)
+
+	// the infos are kept after the acknowledgement until the next window starts
+	q.Ack(104)
+	require.Equal(t, 4, q.Len())
+	from, _ = q.Start(104, 110)
+	require.EqualValues(t, 104, from)
+	require.Zero(t, q.Len())
+}
+
+func redeemInfo(txid byte, index uint32) *types.CCTransferInfo {
+	info := &types.CCTransferInfo{Type: types.RedeemOrLostAndFoundType}
+	info.PrevUTXO.TxID[0] = txid
+	info.PrevUTXO.Index = index
+	return info
+}
+
+func TestCcInfoQueueRedeems(t *testing.T) {
+	q := &CcInfoQueue{}
+	_, err := q.Start(100, 104)
+	require.NoError(t, err)
+
+	// the redeems have no UTXO, they are told apart by the UTXOs spent
+	dup, _ := q.Append(101, []*types.CCTransferInfo{redeemInfo(1, 0), redeemInfo(2, 0), ccInfo(1, 0)})
+	require.Zero(t, dup)
+	dup, _ = q.Append(102, []*types.CCTransferInfo{redeemInfo(3, 1), redeemInfo(2, 0)})
+	require.Equal(t, 1, dup)
+	require.NoError(t, q.Seal())
+	infos, _ := q.Take(104)
+	require.Equal(t, []*types.CCTransferInfo{redeemInfo(1, 0), redeemInfo(2, 0), ccInfo(1, 0), redeemInfo(3, 1)}, infos)
+
+	// a re-scan of (101, 102] finds the same redeems, and one more
+	dup, _ = q.Replace(101, 102, map[int64][]*types.CCTransferInfo{
+		102: {redeemInfo(3, 1), redeemInfo(4, 0), redeemInfo(1, 0)},
+	})
+	require.Equal(t, 1, dup)
+	infos, _ = q.Take(104)
+	require.Equal(t, []*types.CCTransferInfo{redeemInfo(1, 0), redeemInfo(2, 0), ccInfo(1, 0), redeemInfo(3, 1), redeemInfo(4, 0)}, infos)
+	require.Equal(t, 5, q.Len())
+
+	// a re-scan of (100, 102] finding nothing drops them all
+	dup, _ = q.Replace(100, 102, nil)
+	require.Zero(t, dup)
+	infos, _ = q.Take(104)
+	require.Empty(t, infos)
+	require.Zero(t, q.Len())
+}
+
+func TestCcInfoQueueJournal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cc_infos.journal")
+	q := &CcInfoQueue{}
+	require.NoError(t, q.OpenJournal(path))
+	_, err := q.Start(100, 104)
+	require.NoError(t, err)
+	_, err = q.Append(101, []*types.CCTransferInfo{ccInfo(1, 0)})
+	require.NoError(t, err)
+	_, err = q.Append(102, nil)
+	require.NoError(t, err)
+
+	// restarted in the middle of the window
+	q = &CcInfoQueue{}
+	require.NoError(t, q.OpenJournal(path))
+	from, err := q.Start(100, 104)
+	require.NoError(t, err)
+	require.EqualValues(t, 102, from)
+	_, err = q.Append(103, []*types.CCTransferInfo{ccInfo(3, 0)})
+	require.NoError(t, err)
+	require.NoError(t, q.Seal())
+
+	q = &CcInfoQueue{}
+	require.NoError(t, q.OpenJournal(path))
+	infos, ok := q.Take(104)
+	require.True(t, ok)
+	require.Equal(t, []*types.CCTransferInfo{ccInfo(1, 0), ccInfo(3, 0)}, infos)
+
+	// the acknowledged window is not restored
+	q.Ack(104)
+	q = &CcInfoQueue{}
+	require.NoError(t, q.OpenJournal(path))
+	_, ok = q.Take(104)
+	require.False(t, ok)
+}
@@ -0,0 +1,128 @@
+package crosschain
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/holiman/uint256"
+	"github.com/smartbch/moeingevm/ebp"
+	mevmtypes "github.com/smartbch/moeingevm/types"
+
+	"github.com/smartbch/smartbch/crosschain/types"
+	"github.com/smartbch/smartbch/param"
+)
+
+var (
+	//event PegInCall(uint256 txid, uint32 vout, address target, uint8 status)
+	HashOfEventPegInCall = crypto.Keccak256Hash([]byte("PegInCall(uint256,uint32,address,uint8)"))
+)
+
+const (
+	PegInCallSucceeded = uint8(0)
+	PegInCallFailed    = uint8(1)
+	PegInCallSkipped   = uint8(2) // the minted amount cannot pay the fee, or the window cannot pay the gas
+)
+
+func pegInCallFee() *uint256.Int {
+	return uint256.NewInt(0).Mul(uint256.NewInt(param.PegInCallGasLimit), uint256.NewInt(param.PegInCallGasPrice))
+}
+
+// pegInCallMeter meters the peg-in calls made when a rescan window is handled. They are charged to the
+// tx handling the window, startRescan or handleUTXOs, PegInCallGasLimit each, so they are counted in its
+// gas used and bounded by its gas limit, which is within the gas limit of the block. The sender of that
+// tx pays for their gas, so it is paid their fees.
+type pegInCallMeter struct {
+	payer   common.Address
+	gasLeft uint64
+	calls   int
+	gasUsed uint64
+}
+
+// newPegInCallMeter returns the meter of tx, whose own cost is GasOfCCOp
+func newPegInCallMeter(tx *mevmtypes.TxToRun) *pegInCallMeter {
+	m := &pegInCallMeter{payer: tx.From}
+	if tx.Gas > GasOfCCOp {
+		m.gasLeft = tx.Gas - GasOfCCOp
+	}
+	return m
+}
+
+// reserve charges a call, it returns false if the window already made MaxPegInCallsPerWindow calls or
+// the gas left cannot pay for another one
+func (m *pegInCallMeter) reserve() bool {
+	if m == nil || m.calls >= param.MaxPegInCallsPerWindow || m.gasLeft < param.PegInCallGasLimit {
+		return false
+	}
+	m.calls++
+	m.gasLeft -= param.PegInCallGasLimit
+	m.gasUsed += param.PegInCallGasLimit
+	return true
+}
+
+// executePegInCall is called after 'amount' has been minted to info.Receiver. The fee is deducted
+// from the receiver and paid to the payer of meter, then the rest is sent to the target contract along
+// with the calldata, as if the receiver sent a transaction. If the call fails, the receiver keeps the
+// coins sent to the target. The call is skipped if meter cannot afford it.
+func executePegInCall(ctx *mevmtypes.Context, block *mevmtypes.BlockInfo, info *types.CCTransferInfo, amount *uint256.Int, meter *pegInCallMeter) mevmtypes.EvmLog {
+	fee := pegInCallFee()
+	if !amount.Gt(fee) || !meter.reserve() {
+		return buildPegInCallLog(info, PegInCallSkipped)
+	}
+	if err := transferBch(ctx, info.Receiver, meter.payer, fee); err != nil {
+		return buildPegInCallLog(info, PegInCallSkipped)
+	}
+	value := uint256.NewInt(0).Sub(amount, fee)
+	target := common.Address(info.CallTarget)
+	if _, ok := ebp.PredefinedContractManager[target]; ok {
+		// the system contracts, including the cc contract itself, are not called from inside the cc contract
+		return buildPegInCallLog(info, PegInCallFailed)
+	}
+	var nonce uint64
+	if acc := ctx.GetAccount(info.Receiver); acc != nil {
+		nonce = acc.Nonce()
+	}
+	gethTx := gethtypes.NewTx(&gethtypes.LegacyTx{
+		Nonce:    nonce,
+		To:       &target,
+		Value:    value.ToBig(),
+		Gas:      param.PegInCallGasLimit,
+		GasPrice: uint256.NewInt(0).ToBig(), // already paid by the fee
+		Data:     info.CallData,
+	})
+	txToRun := &mevmtypes.TxToRun{}
+	txToRun.FromGethTx(gethTx, info.Receiver, uint64(block.Number))
+	if ebp.StatusIsFailure(runPegInCall(ctx, block, txToRun)) {
+		return buildPegInCallLog(info, PegInCallFailed)
+	}
+	return buildPegInCallLog(info, PegInCallSucceeded)
+}
+
+// runPegInCall runs tx in the EVM and writes its changes to ctx, the context of the tx handling the
+// rescan window. ebp only exposes the EVM through its RPC runners, so the call borrows one of them,
+// while the engine executes the block in postCommit, or while an RPC simulates the handling tx. The
+// app keeps one RPC runner out of the reach of the RPC calls, which hold theirs while the cc contract
+// runs, so the peg-in calls, which hold no other runner while waiting, always get one eventually.
+// The RPC runners skip the nonce check of the block runners and only bump the nonce of a sender which
+// exists, so both are checked here: the nonce of tx.From is bumped exactly once, even if the call
+// fails. They also skip the gas refund, which is zero here, because tx is free and its fee is paid
+// beforehand.
+func runPegInCall(ctx *mevmtypes.Context, block *mevmtypes.BlockInfo, tx *mevmtypes.TxToRun) (status int) {
+	switch _, err := ctx.CheckNonce(tx.From, tx.Nonce); err {
+	case mevmtypes.ErrAccountNotExist:
+		return mevmtypes.ACCOUNT_NOT_EXIST
+	case mevmtypes.ErrNonceTooSmall:
+		return mevmtypes.TX_NONCE_TOO_SMALL
+	case mevmtypes.ErrNonceTooLarge:
+		return mevmtypes.TX_NONCE_TOO_LARGE
+	}
+	runner := ebp.NewTxRunner(ctx, tx)
+	ebp.RunTxForRpc(block, false, runner)
+	return runner.Status
+}
+
+func buildPegInCallLog(info *types.CCTransferInfo, status uint8) mevmtypes.EvmLog {
+	log := buildEvmLogWithTxidVoutAndAddress(HashOfEventPegInCall, info.UTXO.TxID, info.UTXO.Index, info.CallTarget)
+	o := uint256.NewInt(uint64(status)).Bytes32()
+	AddDataToEvmLog(&log, o[:])
+	return log
+}
@@ -0,0 +1,127 @@
+package crosschain
+
+import (
+	"math"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/require"
+
+	"github.com/smartbch/moeingads/store"
+	"github.com/smartbch/moeingads/store/rabbit"
+	"github.com/smartbch/moeingevm/ebp"
+	mtypes "github.com/smartbch/moeingevm/types"
+
+	"github.com/smartbch/smartbch/crosschain/types"
+	"github.com/smartbch/smartbch/param"
+)
+
+var (
+	codeStop   = []byte{0x00}                         // STOP
+	codeRevert = []byte{0x60, 0x00, 0x60, 0x00, 0xfd} // PUSH1 0 PUSH1 0 REVERT
+)
+
+func setCode(ctx *mtypes.Context, addr common.Address, code []byte) {
+	info := append(crypto.Keccak256(code), 0)
+	ctx.Rbt.Set(mtypes.GetBytecodeKey(addr), mtypes.NewBytecodeInfo(append(info, code...)).Bytes())
+}
+
+func getPegInCallStatus(log mtypes.EvmLog) uint8 {
+	return uint8(uint256.NewInt(0).SetBytes(log.Data).Uint64())
+}
+
+// prepares a peg-in of amount whose memo calls a contract with code at target
+func preparePegInCall(amount uint64, target common.Address, code []byte) (*mtypes.Context, *types.CCTransferInfo) {
+	r := rabbit.NewRabbitStore(store.NewMockRootStore())
+	ctx := mtypes.NewContext(&r, nil)
+	info := &types.CCTransferInfo{
+		UTXO:       types.UTXO{TxID: [32]byte{0x1}, Index: 1, Amount: uint256.NewInt(amount).Bytes32()},
+		Receiver:   common.Address{0x01},
+		CallTarget: target,
+		CallData:   []byte{0xd0, 0xe3, 0x0d, 0xb0},
+	}
+	setBalance(ctx, CCContractAddress, 0)
+	setBalance(ctx, info.Receiver, amount) // minted
+	if code != nil {
+		setCode(ctx, target, code)
+	}
+	return ctx, info
+}
+
+func TestExecutePegInCall(t *testing.T) {
+	fee := pegInCallFee().Uint64()
+	block := &mtypes.BlockInfo{Number: 100}
+	target := common.Address{0x0a}
+	payer := common.Address{0x0b}
+	newMeter := func() *pegInCallMeter {
+		return &pegInCallMeter{payer: payer, gasLeft: param.PegInCallGasLimit}
+	}
+
+	// the minted amount cannot pay the fee
+	ctx, info := preparePegInCall(fee, target, codeStop)
+	log := executePegInCall(ctx, block, info, uint256.NewInt(fee), newMeter())
+	require.Equal(t, HashOfEventPegInCall, log.Topics[0])
+	require.Equal(t, PegInCallSkipped, getPegInCallStatus(log))
+	require.EqualValues(t, fee, getBalance(ctx, info.Receiver))
+	require.Zero(t, getBalance(ctx, payer))
+	require.Zero(t, ctx.GetAccount(info.Receiver).Nonce())
+
+	// the fee is paid to the payer of the meter and the rest is sent to the target
+	ctx, info = preparePegInCall(fee+1000, target, codeStop)
+	log = executePegInCall(ctx, block, info, uint256.NewInt(fee+1000), newMeter())
+	require.Equal(t, PegInCallSucceeded, getPegInCallStatus(log))
+	require.EqualValues(t, fee, getBalance(ctx, payer))
+	require.Zero(t, getBalance(ctx, info.Receiver))
+	require.EqualValues(t, 1000, getBalance(ctx, target))
+	require.EqualValues(t, 1, ctx.GetAccount(info.Receiver).Nonce())
+
+	// the receiver keeps the coins if the call fails, the nonce is still bumped once
+	ctx, info = preparePegInCall(fee+1000, target, codeRevert)
+	log = executePegInCall(ctx, block, info, uint256.NewInt(fee+1000), newMeter())
+	require.Equal(t, PegInCallFailed, getPegInCallStatus(log))
+	require.EqualValues(t, fee, getBalance(ctx, payer))
+	require.EqualValues(t, 1000, getBalance(ctx, info.Receiver))
+	require.Nil(t, ctx.GetAccount(target))
+	require.EqualValues(t, 1, ctx.GetAccount(info.Receiver).Nonce())
+
+	// the system contracts are not called
+	ebp.PredefinedContractManager[CCContractAddress] = &CcContractExecutor{}
+	defer delete(ebp.PredefinedContractManager, CCContractAddress)
+	ctx, info = preparePegInCall(fee+1000, CCContractAddress, nil)
+	log = executePegInCall(ctx, block, info, uint256.NewInt(fee+1000), newMeter())
+	require.Equal(t, PegInCallFailed, getPegInCallStatus(log))
+	require.EqualValues(t, fee, getBalance(ctx, payer))
+	require.EqualValues(t, 1000, getBalance(ctx, info.Receiver))
+}
+
+func TestPegInCallMeter(t *testing.T) {
+	fee := pegInCallFee().Uint64()
+	block := &mtypes.BlockInfo{Number: 100}
+	target := common.Address{0x0a}
+	payer := common.Address{0x0b}
+
+	// the gas of the handling tx left after GasOfCCOp pays for one call
+	meter := newPegInCallMeter(&mtypes.TxToRun{BasicTx: mtypes.BasicTx{From: payer, Gas: GasOfCCOp + param.PegInCallGasLimit + 1}})
+	ctx, info := preparePegInCall(fee+1000, target, codeStop)
+	log := executePegInCall(ctx, block, info, uint256.NewInt(fee+1000), meter)
+	require.Equal(t, PegInCallSucceeded, getPegInCallStatus(log))
+	require.Equal(t, param.PegInCallGasLimit, meter.gasUsed)
+
+	// the next call is skipped without charging its fee
+	ctx, info = preparePegInCall(fee+1000, target, codeStop)
+	log = executePegInCall(ctx, block, info, uint256.NewInt(fee+1000), meter)
+	require.Equal(t, PegInCallSkipped, getPegInCallStatus(log))
+	require.EqualValues(t, fee+1000, getBalance(ctx, info.Receiver))
+	require.Zero(t, getBalance(ctx, payer))
+	require.Equal(t, param.PegInCallGasLimit, meter.gasUsed)
+
+	// no more than MaxPegInCallsPerWindow calls are made, whatever the gas
+	meter = newPegInCallMeter(&mtypes.TxToRun{BasicTx: mtypes.BasicTx{From: payer, Gas: math.MaxUint64}})
+	for i := 0; i < param.MaxPegInCallsPerWindow; i++ {
+		require.True(t, meter.reserve())
+	}
+	require.False(t, meter.reserve())
+	require.Equal(t, uint64(param.MaxPegInCallsPerWindow)*param.PegInCallGasLimit, meter.gasUsed)
+}
@@ -0,0 +1,235 @@
+package simulation
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gcash/bchd/bchec"
+	"github.com/gcash/bchd/chaincfg"
+	"github.com/gcash/bchutil"
+
+	mevmtypes "github.com/smartbch/moeingevm/types"
+	"github.com/smartbch/smartbch/crosschain"
+	ccabi "github.com/smartbch/smartbch/crosschain/abi"
+	"github.com/smartbch/smartbch/crosschain/bchtx"
+	"github.com/smartbch/smartbch/crosschain/covenant"
+	"github.com/smartbch/smartbch/param"
+)
+
+type MonitorFault int
+
+const (
+	// rescans up to the finalized BCH height and handles the UTXOs after the delay
+	HonestMonitor MonitorFault = iota
+	// never sends any tx
+	SilentMonitor
+	// rescans up to the BCH tip, ignoring the finalization
+	EagerRescanMonitor
+	// rescans up to the highest height allowed by the contract, which is not mined yet
+	FutureRescanMonitor
+	// sends two startRescan txs with different heights in the same block
+	ConflictingRescanMonitor
+	// pauses cc and never resumes it
+	PausingMonitor
+	// pauses cc and resumes it after Config.PauseBlocks blocks
+	PauseResumeMonitor
+	// is not an elected monitor, but sends the txs which only the monitors can send
+	ImpostorMonitor
+)
+
+type OperatorFault int
+
+const (
+	// signs the redeem txs paying to the redeem targets
+	HonestOperator OperatorFault = iota
+	// never signs
+	WithholdingOperator
+	// signs the redeem txs paying to the attacker instead of the redeem targets
+	ColludingOperator
+)
+
+type monitor struct {
+	fault    MonitorFault
+	addr     common.Address
+	pausedAt int64
+}
+
+// act returns the calldata of the txs sent by m in the current block
+func (m *monitor) act(n *Network) [][]byte {
+	context := crosschain.LoadCCContext(n.ctx)
+	paused := len(context.MonitorsWithPauseCommand) != 0
+	ready := !paused && context.RescanTime+crosschain.UTXOHandleDelay <= n.timestamp
+	maxHeight := context.RescanHeight + crosschain.MaxRescanBlockInterval - 1
+	switch m.fault {
+	case HonestMonitor, EagerRescanMonitor, FutureRescanMonitor:
+		if !ready {
+			return nil
+		}
+		if !context.UTXOAlreadyHandled {
+			return [][]byte{ccabi.PackHandleUTXOsFunc()}
+		}
+		height := n.finalizedHeight()
+		if m.fault == EagerRescanMonitor {
+			height = n.bchHeight
+		} else if m.fault == FutureRescanMonitor {
+			height = maxHeight
+		}
+		if height > maxHeight {
+			height = maxHeight
+		}
+		if height <= context.RescanHeight {
+			return nil
+		}
+		return [][]byte{packStartRescan(height)}
+	case ConflictingRescanMonitor:
+		height := n.finalizedHeight()
+		if !ready || !context.UTXOAlreadyHandled || height <= context.RescanHeight+1 || height > maxHeight {
+			return nil
+		}
+		return [][]byte{packStartRescan(height - 1), packStartRescan(height)}
+	case PausingMonitor, PauseResumeMonitor:
+		if m.pausedAt == 0 {
+			m.pausedAt = n.height
+			return [][]byte{ccabi.PackPauseFunc()}
+		}
+		if m.fault == PauseResumeMonitor && n.height == m.pausedAt+int64(n.conf.PauseBlocks) {
+			return [][]byte{ccabi.PackResumeFunc()}
+		}
+	case ImpostorMonitor:
+		return [][]byte{packStartRescan(n.bchHeight), ccabi.PackPauseFunc()}
+	}
+	return nil
+}
+
+func packStartRescan(height uint64) []byte {
+	return ccabi.PackStartRescanFunc(big.NewInt(0).SetUint64(height))
+}
+
+// monitorSet decides who are the monitors, instead of the monitor election
+type monitorSet struct {
+	monitors     map[common.Address]bool
+	covenantAddr [20]byte
+}
+
+func newMonitorSet(covenantAddr [20]byte) *monitorSet {
+	return &monitorSet{monitors: make(map[common.Address]bool), covenantAddr: covenantAddr}
+}
+
+func (s *monitorSet) add(addr common.Address) {
+	s.monitors[addr] = true
+}
+
+func (s *monitorSet) IsMonitor(_ *mevmtypes.Context, address common.Address) bool {
+	return s.monitors[address]
+}
+
+func (s *monitorSet) IsOperatorOrMonitorChanged(_ *mevmtypes.Context, currAddress [20]byte) (bool, common.Address) {
+	return currAddress != s.covenantAddr, s.covenantAddr
+}
+
+func (s *monitorSet) GetCCCovenantP2SHAddr(_ *mevmtypes.Context) ([20]byte, error) {
+	return s.covenantAddr, nil
+}
+
+var _ crosschain.IVoteContract = &monitorSet{}
+
+// operatorSet signs the redeem txs of the covenant
+type operatorSet struct {
+	faults       []OperatorFault
+	keys         []*bchec.PrivateKey
+	covenant     *covenant.CcCovenant
+	covenantAddr [20]byte
+	attacker     string
+}
+
+func newOperatorSet(faults []OperatorFault) (*operatorSet, error) {
+	s := &operatorSet{faults: faults}
+	var operatorPks, monitorPks [][]byte
+	for i := range faults {
+		key := actorKey("operator", i)
+		s.keys = append(s.keys, key)
+		operatorPks = append(operatorPks, key.PubKey().SerializeCompressed())
+	}
+	for i := 0; i < param.MonitorsCount; i++ {
+		monitorPks = append(monitorPks, actorKey("covenant-monitor", i).PubKey().SerializeCompressed())
+	}
+	cov, err := covenant.NewDefaultCcCovenant(operatorPks, monitorPks)
+	if err != nil {
+		return nil, err
+	}
+	s.covenant = cov
+	if s.covenantAddr, err = cov.GetP2SHAddress20(); err != nil {
+		return nil, err
+	}
+	attackerPkh := bchutil.Hash160(actorKey("attacker", 0).PubKey().SerializeCompressed())
+	if s.attacker, err = p2pkhAddress(attackerPkh, cov.Net()); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// sign collects the operators' signatures of the tx redeeming the UTXO to target. It returns
+// the id of the tx sent to the BCH mainnet, which is nil if neither the genuine tx nor the
+// attacker's tx gets enough signatures, and forged=true if the attacker's tx is sent.
+func (s *operatorSet) sign(utxo [32]byte, amount int64, target [20]byte) (txid *[32]byte, forged bool, err error) {
+	toAddr, err := p2pkhAddress(target[:], s.covenant.Net())
+	if err != nil {
+		return nil, false, err
+	}
+	tx, hash, err := s.covenant.GetRedeemByUserTxSigHash(utxo[:], 0, amount, toAddr)
+	if err != nil {
+		return nil, false, err
+	}
+	forgedTx, forgedHash, err := s.covenant.GetRedeemByUserTxSigHash(utxo[:], 0, amount, s.attacker)
+	if err != nil {
+		return nil, false, err
+	}
+	var sigs [][]byte // indexed by the operators
+	for i, fault := range s.faults {
+		var sig []byte
+		switch fault {
+		case HonestOperator:
+			sig, err = bchtx.Sign(s.keys[i], hash, bchtx.SigHashAllForkID)
+		case ColludingOperator:
+			sig, err = bchtx.Sign(s.keys[i], forgedHash, bchtx.SigHashAllForkID)
+		}
+		if err != nil {
+			return nil, false, err
+		}
+		sigs = append(sigs, sig)
+	}
+	if validSigs := s.validSigs(forgedHash, sigs); len(validSigs) >= param.MinOperatorSigCount {
+		if _, _, err = s.covenant.FinishRedeemByUserTx(forgedTx, validSigs[:param.MinOperatorSigCount]); err != nil {
+			return nil, false, err
+		}
+		id := [32]byte(forgedTx.TxHash())
+		return &id, true, nil
+	}
+	if validSigs := s.validSigs(hash, sigs); len(validSigs) >= param.MinOperatorSigCount {
+		if _, _, err = s.covenant.FinishRedeemByUserTx(tx, validSigs[:param.MinOperatorSigCount]); err != nil {
+			return nil, false, err
+		}
+		id := [32]byte(tx.TxHash())
+		return &id, false, nil
+	}
+	return nil, false, nil
+}
+
+// validSigs returns the signatures of hash in the order of the operators' pubkeys, which is
+// required by OP_CHECKMULTISIG
+func (s *operatorSet) validSigs(hash []byte, sigs [][]byte) (result [][]byte) {
+	for i, sig := range sigs {
+		if sig != nil && bchtx.VerifySig(s.keys[i].PubKey().SerializeCompressed(), hash, sig) {
+			result = append(result, sig)
+		}
+	}
+	return
+}
+
+func p2pkhAddress(pkh []byte, net *chaincfg.Params) (string, error) {
+	addr, err := bchutil.NewAddressPubKeyHash(pkh, net)
+	if err != nil {
+		return "", err
+	}
+	return addr.EncodeAddress(), nil
+}
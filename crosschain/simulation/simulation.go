@@ -0,0 +1,446 @@
+// Package simulation runs the cc contract executor against a scripted BCH mainnet, with
+// monitors and operators which may deviate from the protocol, and checks the safety and
+// liveness properties of the cross chain bridge after every smartBCH block.
+package simulation
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gcash/bchd/bchec"
+	"github.com/holiman/uint256"
+	"github.com/tendermint/tendermint/libs/log"
+
+	"github.com/smartbch/moeingads/store"
+	"github.com/smartbch/moeingads/store/rabbit"
+	mevmtypes "github.com/smartbch/moeingevm/types"
+	"github.com/smartbch/smartbch/crosschain"
+	ccabi "github.com/smartbch/smartbch/crosschain/abi"
+	"github.com/smartbch/smartbch/crosschain/types"
+)
+
+// the amounts of the UTXOs are recorded in wei on smartBCH
+const weiPerSatoshi = 1e10
+
+type ViolationKind string
+
+const (
+	// a successful startRescan did not increase the rescan height
+	NonMonotonicRescan ViolationKind = "non-monotonic-rescan"
+	// a startRescan succeeded with a height which was not finalized on the BCH mainnet
+	UnfinalizedRescan ViolationKind = "unfinalized-rescan"
+	// the balance of a receiver differs from its credited deposits minus its redeems
+	BalanceMismatch ViolationKind = "balance-mismatch"
+	// startRescan, handleUTXOs or redeem succeeded while the cc contract was paused
+	ProgressWhilePaused ViolationKind = "progress-while-paused"
+	// a redeem tx paying to another address than the redeem target got enough operator signatures
+	ForgedRedeem ViolationKind = "forged-redeem"
+)
+
+type Violation struct {
+	Kind   ViolationKind
+	Height int64 // the smartBCH height
+	Detail string
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("%s at height %d: %s", v.Kind, v.Height, v.Detail)
+}
+
+// Deposit is a peg-in tx sending Amount satoshis to the covenant on the BCH mainnet. Amount
+// should be in the range accepted by the cc contract, see MinCCAmount and MaxCCAmount.
+type Deposit struct {
+	BchHeight uint64
+	Receiver  common.Address
+	Amount    int64
+}
+
+type Config struct {
+	Monitors  []MonitorFault
+	Operators []OperatorFault // must have param.OperatorsCount members
+	Deposits  []Deposit
+
+	Blocks         int    // the number of smartBCH blocks to simulate
+	BlockInterval  int64  // the seconds between two smartBCH blocks
+	BchBlockEvery  int    // a BCH block is mined every BchBlockEvery smartBCH blocks
+	StartBchHeight uint64 // the BCH height at which cc is enabled
+	FinalizeDepth  uint64 // a BCH block is finalized when it has FinalizeDepth confirmations
+	PauseBlocks    int    // the number of blocks for which a PauseResumeMonitor keeps cc paused
+}
+
+// Report is the outcome of a simulation. Violations are the broken safety properties, and
+// PendingDeposits and PendingRedeems are the liveness failures.
+type Report struct {
+	Violations      []Violation
+	RescanHeights   []uint64             // the heights of the successful startRescan calls
+	CreditedAt      map[[32]byte]int64   // deposit txid => smartBCH height at which it was credited
+	RedeemedAt      map[[32]byte]uint64  // deposit txid => BCH height at which its UTXO was spent
+	PendingDeposits []Deposit            // the deposits which were not credited
+	PendingRedeems  [][32]byte           // the redeemed deposits whose BCH txs were not signed
+	StalledBlocks   int                  // the blocks which could not be produced because the watcher was behind
+	Rejected        map[MonitorFault]int // the number of failed txs sent by each kind of monitors
+}
+
+func (r *Report) HasViolation(kind ViolationKind) bool {
+	for _, v := range r.Violations {
+		if v.Kind == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// spend is a BCH tx spending a covenant UTXO
+type spend struct {
+	bchHeight uint64
+	prevTxid  [32]byte
+	txid      [32]byte
+}
+
+// redeeming is a redeemed UTXO waiting for the operators' signatures
+type redeeming struct {
+	deposit int
+	target  [20]byte
+}
+
+// Network is a smartBCH node and its view of the BCH mainnet
+type Network struct {
+	conf     Config
+	ctx      *mevmtypes.Context
+	executor *crosschain.CcContractExecutor
+	voter    *monitorSet
+	monitors []*monitor
+	ops      *operatorSet
+
+	height     int64
+	timestamp  int64
+	bchHeight  uint64
+	depositIds [][32]byte
+	spends     []spend
+	redeemings []redeeming
+	redeemed   map[int]bool // deposit index => the redeem tx has been sent on smartBCH
+	balances   map[common.Address]*uint256.Int
+	report     *Report
+}
+
+func NewNetwork(conf Config) (*Network, error) {
+	if conf.BlockInterval <= 0 {
+		conf.BlockInterval = 1
+	}
+	if conf.BchBlockEvery <= 0 {
+		conf.BchBlockEvery = 1
+	}
+	ops, err := newOperatorSet(conf.Operators)
+	if err != nil {
+		return nil, err
+	}
+	r := rabbit.NewRabbitStore(store.NewMockRootStore())
+	n := &Network{
+		conf:      conf,
+		ctx:       mevmtypes.NewContext(&r, nil),
+		voter:     newMonitorSet(ops.covenantAddr),
+		ops:       ops,
+		bchHeight: conf.StartBchHeight,
+		redeemed:  make(map[int]bool),
+		balances:  make(map[common.Address]*uint256.Int),
+		report: &Report{
+			CreditedAt: make(map[[32]byte]int64),
+			RedeemedAt: make(map[[32]byte]uint64),
+			Rejected:   make(map[MonitorFault]int),
+		},
+	}
+	n.executor = crosschain.NewCcContractExecutor(log.NewNopLogger(), n.voter)
+	n.executor.Init(n.ctx) // funds the cc contract
+	crosschain.SaveCCContext(n.ctx, types.CCContext{
+		RescanHeight:       conf.StartBchHeight,
+		UTXOAlreadyHandled: true,
+		CurrCovenantAddr:   ops.covenantAddr,
+	})
+	for i, fault := range conf.Monitors {
+		m := &monitor{fault: fault, addr: actorAddr("monitor", i)}
+		if fault != ImpostorMonitor {
+			n.voter.add(m.addr)
+		}
+		n.monitors = append(n.monitors, m)
+	}
+	for i, d := range conf.Deposits {
+		n.depositIds = append(n.depositIds, sha256.Sum256([]byte(fmt.Sprintf("deposit-%d", i))))
+		if _, ok := n.balances[d.Receiver]; !ok {
+			n.balances[d.Receiver] = uint256.NewInt(0)
+		}
+	}
+	return n, nil
+}
+
+// Run simulates conf.Blocks smartBCH blocks and returns the report
+func Run(conf Config) (*Report, error) {
+	n, err := NewNetwork(conf)
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < conf.Blocks; i++ {
+		if err := n.Step(); err != nil {
+			return nil, err
+		}
+	}
+	return n.Report(), nil
+}
+
+func (n *Network) finalizedHeight() uint64 {
+	if n.bchHeight < n.conf.StartBchHeight+n.conf.FinalizeDepth {
+		return n.conf.StartBchHeight
+	}
+	return n.bchHeight - n.conf.FinalizeDepth
+}
+
+// Step produces one smartBCH block: the monitors send their txs, the depositors redeem their
+// mature UTXOs, and the operators sign the redeem txs on the BCH mainnet
+func (n *Network) Step() error {
+	n.height++
+	n.timestamp += n.conf.BlockInterval
+	if n.height%int64(n.conf.BchBlockEvery) == 0 {
+		n.bchHeight++
+	}
+	for _, m := range n.monitors {
+		for _, data := range m.act(n) {
+			status, stalled := n.execute(m.addr, data, nil)
+			if stalled {
+				n.report.StalledBlocks++
+				return nil
+			}
+			if status != crosschain.StatusSuccess {
+				n.report.Rejected[m.fault]++
+			}
+		}
+	}
+	n.redeemMatureUTXOs()
+	if err := n.signRedeems(); err != nil {
+		return err
+	}
+	n.checkBalances()
+	return nil
+}
+
+// execute runs a tx calling the cc contract. It returns stalled=true without running the tx if
+// the tx would make the executor wait for the watcher to collect a BCH block not mined yet.
+func (n *Network) execute(from common.Address, data []byte, value *uint256.Int) (status int, stalled bool) {
+	tx := &mevmtypes.TxToRun{BasicTx: mevmtypes.BasicTx{
+		From: from,
+		To:   crosschain.CCContractAddress,
+		Data: data,
+		Gas:  crosschain.GasOfCCOp,
+	}}
+	if value != nil {
+		tx.Value = value.Bytes32()
+	}
+	block := &mevmtypes.BlockInfo{Number: n.height, Timestamp: n.timestamp}
+	before := crosschain.LoadCCContext(n.ctx)
+	if n.handlesTransferInfos(before, tx) {
+		if before.RescanHeight > n.bchHeight {
+			return crosschain.StatusFailed, true
+		}
+		n.collect(before)
+	}
+	status, _, _, _ = n.executor.Execute(n.ctx, block, tx)
+	after := crosschain.LoadCCContext(n.ctx)
+	if status == crosschain.StatusSuccess {
+		n.checkTransition(before, after, tx)
+	}
+	n.recordCredits()
+	return status, false
+}
+
+// handlesTransferInfos mirrors the checks done by startRescan and handleUTXOs before they handle
+// the infos collected by the watcher
+func (n *Network) handlesTransferInfos(context *types.CCContext, tx *mevmtypes.TxToRun) bool {
+	if len(tx.Data) < 4 || context.UTXOAlreadyHandled || len(context.MonitorsWithPauseCommand) != 0 ||
+		context.RescanTime+crosschain.UTXOHandleDelay > n.timestamp {
+		return false
+	}
+	var selector [4]byte
+	copy(selector[:], tx.Data[:4])
+	switch selector {
+	case crosschain.SelectorHandleUTXOs:
+		return true
+	case crosschain.SelectorStartRescan:
+		if !n.voter.IsMonitor(n.ctx, tx.From) || len(tx.Data) < 4+32 {
+			return false
+		}
+		h := uint256.NewInt(0).SetBytes32(tx.Data[4:36]).Uint64()
+		return h > context.RescanHeight && h < context.RescanHeight+crosschain.MaxRescanBlockInterval
+	}
+	return false
+}
+
+// collect plays the watcher, which reports the covenant UTXOs created and spent in the BCH blocks
+// (LastRescannedHeight, RescanHeight]
+func (n *Network) collect(context *types.CCContext) {
+	var infos []*types.CCTransferInfo
+	for i, d := range n.conf.Deposits {
+		if d.BchHeight > context.LastRescannedHeight && d.BchHeight <= context.RescanHeight {
+			infos = append(infos, &types.CCTransferInfo{
+				Type:            types.TransferType,
+				UTXO:            types.UTXO{TxID: n.depositIds[i], Amount: toWei(d.Amount).Bytes32()},
+				Receiver:        d.Receiver,
+				CovenantAddress: n.ops.covenantAddr,
+			})
+		}
+	}
+	for _, s := range n.spends {
+		if s.bchHeight > context.LastRescannedHeight && s.bchHeight <= context.RescanHeight {
+			infos = append(infos, &types.CCTransferInfo{
+				Type:     types.RedeemOrLostAndFoundType,
+				PrevUTXO: types.UTXO{TxID: s.prevTxid},
+				UTXO:     types.UTXO{TxID: s.txid},
+			})
+		}
+	}
+	n.executor.Queue.SetWindow(context.RescanHeight, infos)
+}
+
+func (n *Network) checkTransition(before, after *types.CCContext, tx *mevmtypes.TxToRun) {
+	var selector [4]byte
+	copy(selector[:], tx.Data[:4])
+	paused := len(before.MonitorsWithPauseCommand) != 0
+	switch selector {
+	case crosschain.SelectorStartRescan:
+		n.report.RescanHeights = append(n.report.RescanHeights, after.RescanHeight)
+		if after.RescanHeight <= before.RescanHeight {
+			n.violate(NonMonotonicRescan, fmt.Sprintf("%d -> %d", before.RescanHeight, after.RescanHeight))
+		}
+		if after.RescanHeight > n.finalizedHeight() {
+			n.violate(UnfinalizedRescan, fmt.Sprintf("rescan to %d, finalized height is %d",
+				after.RescanHeight, n.finalizedHeight()))
+		}
+		if paused {
+			n.violate(ProgressWhilePaused, "startRescan")
+		}
+	case crosschain.SelectorHandleUTXOs:
+		if paused {
+			n.violate(ProgressWhilePaused, "handleUTXOs")
+		}
+	case crosschain.SelectorRedeem:
+		if paused {
+			n.violate(ProgressWhilePaused, "redeem")
+		}
+	}
+}
+
+// recordCredits finds the deposits which have been credited since the last tx
+func (n *Network) recordCredits() {
+	for i, d := range n.conf.Deposits {
+		id := n.depositIds[i]
+		if _, ok := n.report.CreditedAt[id]; ok {
+			continue
+		}
+		r := crosschain.LoadUTXORecord(n.ctx, id, 0)
+		if r == nil {
+			continue
+		}
+		n.report.CreditedAt[id] = n.height
+		if r.OwnerOfLost == [20]byte{} { // the lost-and-found UTXOs are not paid to the receivers
+			n.balances[d.Receiver].Add(n.balances[d.Receiver], toWei(d.Amount))
+		}
+	}
+}
+
+// redeemMatureUTXOs lets the receivers redeem their deposits as soon as possible
+func (n *Network) redeemMatureUTXOs() {
+	for i, d := range n.conf.Deposits {
+		if n.redeemed[i] {
+			continue
+		}
+		r := crosschain.LoadUTXORecord(n.ctx, n.depositIds[i], 0)
+		if r == nil || r.IsRedeemed || r.BornTime == 0 || r.BornTime+crosschain.MatureTime >= n.timestamp {
+			continue
+		}
+		txid := big.NewInt(0).SetBytes(n.depositIds[i][:])
+		data := ccabi.PackRedeemFunc(txid, big.NewInt(0), d.Receiver)
+		// redeem is sent only when it succeeds unless cc is paused, which is checked before the
+		// amount is transferred, so no state of a failed tx needs to be reverted
+		status, stalled := n.execute(d.Receiver, data, toWei(d.Amount))
+		if stalled || status != crosschain.StatusSuccess {
+			continue
+		}
+		n.redeemed[i] = true
+		n.balances[d.Receiver].Sub(n.balances[d.Receiver], toWei(d.Amount))
+		n.redeemings = append(n.redeemings, redeeming{deposit: i, target: d.Receiver})
+	}
+}
+
+// signRedeems asks the operators to sign the pending redeem txs, a tx is sent to the BCH mainnet
+// once it has enough signatures
+func (n *Network) signRedeems() error {
+	var pending []redeeming
+	for _, r := range n.redeemings {
+		d := n.conf.Deposits[r.deposit]
+		id := n.depositIds[r.deposit]
+		txid, forged, err := n.ops.sign(id, d.Amount, r.target)
+		if err != nil {
+			return err
+		}
+		if forged {
+			n.violate(ForgedRedeem, fmt.Sprintf("UTXO %x is paid to the attacker", id))
+		}
+		if txid == nil {
+			pending = append(pending, r)
+			continue
+		}
+		// the tx is mined in the next BCH block
+		n.spends = append(n.spends, spend{bchHeight: n.bchHeight + 1, prevTxid: id, txid: *txid})
+		n.report.RedeemedAt[id] = n.bchHeight + 1
+	}
+	n.redeemings = pending
+	return nil
+}
+
+func (n *Network) checkBalances() {
+	for addr, expected := range n.balances {
+		acc := n.ctx.GetAccount(addr)
+		balance := uint256.NewInt(0)
+		if acc != nil {
+			balance = acc.Balance()
+		}
+		if !balance.Eq(expected) {
+			n.violate(BalanceMismatch, fmt.Sprintf("%s has %s wei, expected %s wei",
+				addr, balance.String(), expected.String()))
+		}
+	}
+}
+
+func (n *Network) violate(kind ViolationKind, detail string) {
+	n.report.Violations = append(n.report.Violations, Violation{Kind: kind, Height: n.height, Detail: detail})
+}
+
+func (n *Network) Report() *Report {
+	report := *n.report
+	report.PendingDeposits = nil
+	for i, d := range n.conf.Deposits {
+		if _, ok := report.CreditedAt[n.depositIds[i]]; !ok {
+			report.PendingDeposits = append(report.PendingDeposits, d)
+		}
+	}
+	report.PendingRedeems = nil
+	for _, r := range n.redeemings {
+		report.PendingRedeems = append(report.PendingRedeems, n.depositIds[r.deposit])
+	}
+	return &report
+}
+
+func toWei(satoshi int64) *uint256.Int {
+	return uint256.NewInt(0).Mul(uint256.NewInt(uint64(satoshi)), uint256.NewInt(weiPerSatoshi))
+}
+
+// actorKey derives a deterministic key, so that the simulations can be reproduced
+func actorKey(role string, i int) *bchec.PrivateKey {
+	seed := sha256.Sum256([]byte(fmt.Sprintf("%s-%d", role, i)))
+	key, _ := bchec.PrivKeyFromBytes(bchec.S256(), seed[:])
+	return key
+}
+
+func actorAddr(role string, i int) common.Address {
+	seed := sha256.Sum256([]byte(fmt.Sprintf("%s-%d", role, i)))
+	return common.BytesToAddress(seed[:20])
+}
@@ -0,0 +1,113 @@
+package simulation
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+func newConfig(monitors []MonitorFault, operators []OperatorFault) Config {
+	return Config{
+		Monitors:  monitors,
+		Operators: operators,
+		Deposits: []Deposit{
+			{BchHeight: 101, Receiver: common.Address{0x01}, Amount: 50000},
+			{BchHeight: 102, Receiver: common.Address{0x02}, Amount: 20000},
+			{BchHeight: 104, Receiver: common.Address{0x01}, Amount: 80000},
+		},
+		Blocks:         60,
+		BlockInterval:  1,
+		BchBlockEvery:  2,
+		StartBchHeight: 100,
+		FinalizeDepth:  2,
+		PauseBlocks:    10,
+	}
+}
+
+func operators(fault OperatorFault, faulty int) []OperatorFault {
+	result := make([]OperatorFault, 10)
+	for i := 0; i < faulty; i++ {
+		result[i] = fault
+	}
+	return result
+}
+
+func run(t *testing.T, conf Config) *Report {
+	report, err := Run(conf)
+	require.NoError(t, err)
+	for i := 1; i < len(report.RescanHeights); i++ {
+		require.Less(t, report.RescanHeights[i-1], report.RescanHeights[i])
+	}
+	return report
+}
+
+func TestHonestNetwork(t *testing.T) {
+	report := run(t, newConfig([]MonitorFault{HonestMonitor, HonestMonitor, HonestMonitor}, operators(HonestOperator, 0)))
+	require.Empty(t, report.Violations)
+	require.Empty(t, report.PendingDeposits)
+	require.Empty(t, report.PendingRedeems)
+	require.Len(t, report.RedeemedAt, 3)
+	require.Equal(t, 0, report.StalledBlocks)
+}
+
+func TestFaultyMonitors(t *testing.T) {
+	conf := newConfig([]MonitorFault{SilentMonitor, ImpostorMonitor, ConflictingRescanMonitor, HonestMonitor},
+		operators(HonestOperator, 0))
+	report := run(t, conf)
+	require.Empty(t, report.Violations)
+	require.Empty(t, report.PendingDeposits)
+	require.Empty(t, report.PendingRedeems)
+	require.Greater(t, report.Rejected[ImpostorMonitor], 0)
+	require.Greater(t, report.Rejected[ConflictingRescanMonitor], 0)
+	require.Equal(t, 0, report.Rejected[SilentMonitor])
+}
+
+func TestEagerRescan(t *testing.T) {
+	// one monitor can make smartBCH credit the deposits in unfinalized BCH blocks
+	report := run(t, newConfig([]MonitorFault{EagerRescanMonitor, HonestMonitor}, operators(HonestOperator, 0)))
+	require.True(t, report.HasViolation(UnfinalizedRescan))
+	require.False(t, report.HasViolation(BalanceMismatch))
+	require.Empty(t, report.PendingDeposits)
+}
+
+func TestFutureRescan(t *testing.T) {
+	// one monitor can stall smartBCH until the BCH mainnet reaches the rescan height
+	report := run(t, newConfig([]MonitorFault{FutureRescanMonitor, HonestMonitor}, operators(HonestOperator, 0)))
+	require.True(t, report.HasViolation(UnfinalizedRescan))
+	require.Greater(t, report.StalledBlocks, 0)
+	require.Len(t, report.PendingDeposits, 3)
+}
+
+func TestPause(t *testing.T) {
+	report := run(t, newConfig([]MonitorFault{PausingMonitor, HonestMonitor}, operators(HonestOperator, 0)))
+	require.Empty(t, report.Violations)
+	require.Empty(t, report.RescanHeights)
+	require.Len(t, report.PendingDeposits, 3)
+
+	report = run(t, newConfig([]MonitorFault{PauseResumeMonitor, HonestMonitor}, operators(HonestOperator, 0)))
+	require.Empty(t, report.Violations)
+	require.Empty(t, report.PendingDeposits)
+	require.Empty(t, report.PendingRedeems)
+}
+
+func TestWithholdingOperators(t *testing.T) {
+	report := run(t, newConfig([]MonitorFault{HonestMonitor}, operators(WithholdingOperator, 3)))
+	require.Empty(t, report.Violations)
+	require.Empty(t, report.PendingRedeems)
+	require.Len(t, report.RedeemedAt, 3)
+
+	report = run(t, newConfig([]MonitorFault{HonestMonitor}, operators(WithholdingOperator, 4)))
+	require.Empty(t, report.Violations)
+	require.Len(t, report.PendingRedeems, 3)
+	require.Empty(t, report.RedeemedAt)
+}
+
+func TestColludingOperators(t *testing.T) {
+	report := run(t, newConfig([]MonitorFault{HonestMonitor}, operators(ColludingOperator, 3)))
+	require.Empty(t, report.Violations)
+	require.Len(t, report.RedeemedAt, 3)
+
+	report = run(t, newConfig([]MonitorFault{HonestMonitor}, operators(ColludingOperator, 7)))
+	require.True(t, report.HasViolation(ForgedRedeem))
+}
@@ -15,8 +15,13 @@ import (
 var (
 	SlotContext      string = strings.Repeat(string([]byte{0}), 31) + string([]byte{5})
 	SlotInfosForTest string = strings.Repeat(string([]byte{0}), 31) + string([]byte{6})
+	// the covenant generations recorded since CovenantGenerationsForkHeight, the oldest first
+	SlotCovenantHistory string = strings.Repeat(string([]byte{0}), 31) + string([]byte{7})
 )
 
+// the number of the covenant generations kept in SlotCovenantHistory, including the current one
+const MaxCovenantGenerations = 8
+
 func LoadUTXORecord(ctx *mevmtypes.Context, txid [32]byte, index uint32) *types.UTXORecord {
 	bz := ctx.GetStorageAt(ccContractSequence, buildUTXOKey(txid, index))
 	if len(bz) == 0 {
@@ -43,6 +48,29 @@ func DeleteUTXORecord(ctx *mevmtypes.Context, txid [32]byte, index uint32) {
 	ctx.DeleteStorageAt(ccContractSequence, buildUTXOKey(txid, index))
 }
 
+// LoadRefundRecord returns nil if the UTXO cannot be refunded
+func LoadRefundRecord(ctx *mevmtypes.Context, txid [32]byte, index uint32) *types.RefundRecord {
+	bz := ctx.GetStorageAt(ccContractSequence, buildRefundKey(txid, index))
+	if len(bz) != 20+8 {
+		return nil
+	}
+	var r types.RefundRecord
+	copy(r.Target[:], bz[:20])
+	r.RefundableTime = int64(binary.BigEndian.Uint64(bz[20:]))
+	return &r
+}
+
+func SaveRefundRecord(ctx *mevmtypes.Context, txid [32]byte, index uint32, r types.RefundRecord) {
+	bz := make([]byte, 20+8)
+	copy(bz[:20], r.Target[:])
+	binary.BigEndian.PutUint64(bz[20:], uint64(r.RefundableTime))
+	ctx.SetStorageAt(ccContractSequence, buildRefundKey(txid, index), bz)
+}
+
+func DeleteRefundRecord(ctx *mevmtypes.Context, txid [32]byte, index uint32) {
+	ctx.DeleteStorageAt(ccContractSequence, buildRefundKey(txid, index))
+}
+
 func LoadCCContext(ctx *mevmtypes.Context) *types.CCContext {
 	bz := ctx.GetStorageAt(ccContractSequence, SlotContext)
 	if len(bz) == 0 {
@@ -64,6 +92,55 @@ func SaveCCContext(ctx *mevmtypes.Context, ccContext types.CCContext) {
 	ctx.SetStorageAt(ccContractSequence, SlotContext, bz)
 }
 
+func LoadCovenantHistory(ctx *mevmtypes.Context) []types.CovenantGeneration {
+	bz := ctx.GetStorageAt(ccContractSequence, SlotCovenantHistory)
+	history := make([]types.CovenantGeneration, 0, len(bz)/(20+8))
+	for ; len(bz) >= 20+8; bz = bz[20+8:] {
+		var g types.CovenantGeneration
+		copy(g.Addr[:], bz[:20])
+		g.ActivatedAt = int64(binary.BigEndian.Uint64(bz[20:]))
+		history = append(history, g)
+	}
+	return history
+}
+
+func SaveCovenantHistory(ctx *mevmtypes.Context, history []types.CovenantGeneration) {
+	bz := make([]byte, len(history)*(20+8))
+	for i, g := range history {
+		copy(bz[i*(20+8):], g.Addr[:])
+		binary.BigEndian.PutUint64(bz[i*(20+8)+20:], uint64(g.ActivatedAt))
+	}
+	ctx.SetStorageAt(ccContractSequence, SlotCovenantHistory, bz)
+}
+
+// OlderCovenantAddrs returns the covenant addresses before the last one, the newest first. Only the
+// generations recorded since CovenantGenerationsForkHeight are known.
+func OlderCovenantAddrs(ctx *mevmtypes.Context, context *types.CCContext) [][20]byte {
+	var addrs [][20]byte
+	history := LoadCovenantHistory(ctx)
+	for i := len(history) - 1; i >= 0; i-- {
+		addr := history[i].Addr
+		if addr != context.CurrCovenantAddr && addr != context.LastCovenantAddr && addr != [20]byte{} {
+			addrs = append(addrs, addr)
+		}
+	}
+	return addrs
+}
+
+// recordCovenantChange appends the new covenant generation to the history, which starts with the
+// replaced one when it is empty
+func recordCovenantChange(ctx *mevmtypes.Context, oldAddr, newAddr [20]byte) {
+	history := LoadCovenantHistory(ctx)
+	if len(history) == 0 {
+		history = append(history, types.CovenantGeneration{Addr: oldAddr})
+	}
+	history = append(history, types.CovenantGeneration{Addr: newAddr, ActivatedAt: ctx.Height})
+	if len(history) > MaxCovenantGenerations {
+		history = history[len(history)-MaxCovenantGenerations:]
+	}
+	SaveCovenantHistory(ctx, history)
+}
+
 func LoadInternalInfoForTest(ctx *mevmtypes.Context) *types.CCInternalInfosForTest {
 	bz := ctx.GetStorageAt(ccContractSequence, SlotInfosForTest)
 	if len(bz) == 0 {
@@ -92,6 +169,15 @@ func buildUTXOKey(txid [32]byte, index uint32) string {
 	return string(hash[:])
 }
 
+// buildRefundKey differs from buildUTXOKey by the suffix, so the two records never collide
+func buildRefundKey(txid [32]byte, index uint32) string {
+	var v [4 + 6]byte
+	binary.BigEndian.PutUint32(v[:], index)
+	copy(v[4:], "refund")
+	hash := sha256.Sum256(append(txid[:], v[:]...))
+	return string(hash[:])
+}
+
 func LoadMonitorVoteInfo(ctx *mevmtypes.Context, number int64) *types.MonitorVoteInfo {
 	bz := ctx.GetStorageAt(ccContractSequence, getSlotForVoteInfo(number))
 	if len(bz) == 0 {
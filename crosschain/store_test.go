@@ -46,3 +46,28 @@ func TestCCStore(t *testing.T) {
 	require.Equal(t, voteInfo.StartHeight, loadedV.StartHeight)
 	require.Equal(t, len(voteInfo.Nominations), len(loadedV.Nominations))
 }
+
+func TestCovenantHistory(t *testing.T) {
+	r := rabbit.NewRabbitStore(store.NewMockRootStore())
+	ctx := mtypes.NewContext(&r, nil)
+	require.Empty(t, LoadCovenantHistory(ctx))
+
+	for i := 1; i <= MaxCovenantGenerations+1; i++ {
+		ctx.Height = int64(i * 100)
+		recordCovenantChange(ctx, [20]byte{byte(i - 1)}, [20]byte{byte(i)})
+	}
+	history := LoadCovenantHistory(ctx)
+	require.Len(t, history, MaxCovenantGenerations)
+	require.Equal(t, [20]byte{2}, history[0].Addr)
+	require.EqualValues(t, 200, history[0].ActivatedAt)
+	require.Equal(t, [20]byte{MaxCovenantGenerations + 1}, history[len(history)-1].Addr)
+
+	context := &types.CCContext{
+		CurrCovenantAddr: [20]byte{MaxCovenantGenerations + 1},
+		LastCovenantAddr: [20]byte{MaxCovenantGenerations},
+	}
+	older := OlderCovenantAddrs(ctx, context)
+	require.Len(t, older, MaxCovenantGenerations-2)
+	require.Equal(t, [20]byte{MaxCovenantGenerations - 1}, older[0])
+	require.Equal(t, [20]byte{2}, older[len(older)-1])
+}
@@ -1,7 +1,7 @@
 package types
 
 //go:generate msgp
-//msgp:ignore typename UTXO CCTransferInfo CCInfosForTest
+//msgp:ignore typename UTXO CCTransferInfo CCInfosForTest RefundRecord CovenantGeneration
 
 type UTXO struct {
 	TxID   [32]byte
@@ -15,6 +15,21 @@ type CCTransferInfo struct {
 	UTXO            UTXO
 	Receiver        [20]byte
 	CovenantAddress [20]byte
+	// set when the peg-in memo specifies a contract call
+	CallTarget [20]byte
+	CallData   []byte
+	// the pubkey hash of the sender's first P2PKH input, to which the deposit is refunded if
+	// it cannot be minted, zero if unknown
+	RefundTarget [20]byte
+	// set when an OP_RETURN output looks like a peg-in memo but cannot be parsed
+	MalformedMemo bool
+	// set when there is no memo and no P2PKH input, such as when the inputs are P2SH, so the deposit
+	// has neither a receiver nor a refund target
+	NoReceiver bool
+}
+
+func (info *CCTransferInfo) HasCall() bool {
+	return info.CallTarget != [20]byte{}
 }
 
 type UTXOType byte
@@ -37,6 +52,21 @@ type UTXORecord struct {
 	BornTime         int64
 }
 
+// RefundRecord is saved along with a lost-and-found UTXO whose sender is known, after
+// RefundableTime anyone can ask the operators to send it back to Target on the main chain. It is
+// also saved with a zero Target along with a deposit without receiver, the monitors set the Target.
+type RefundRecord struct {
+	Target         [20]byte // the pubkey hash of a P2PKH address
+	RefundableTime int64
+}
+
+// CovenantGeneration is a covenant address and the side chain height since which it is the
+// current one, the UTXOs sent to the older generations are kept as lost-and-found
+type CovenantGeneration struct {
+	Addr        [20]byte
+	ActivatedAt int64
+}
+
 type CCContext struct {
 	MonitorsWithPauseCommand   [][20]byte
 	RescanTime                 int64    // last startRescan block timestamp, init is max int64
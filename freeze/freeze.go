@@ -0,0 +1,324 @@
+package freeze
+
+import (
+	"bytes"
+	"errors"
+	"math"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/holiman/uint256"
+	mevmtypes "github.com/smartbch/moeingevm/types"
+	"github.com/tendermint/tendermint/libs/log"
+
+	"github.com/smartbch/smartbch/staking"
+)
+
+// The freeze contract lets the active validators freeze specific addresses, for example, the addresses
+// holding the funds stolen in a bridge exploit. Nobody can freeze an address alone: a proposal takes
+// effect only after validators with more than 2/3 of the total voting power approved it. Every step
+// emits an event, and the frozen addresses can be queried by anyone.
+//
+// This contract is registered only after param.FreezeForkHeight, which is MaxInt64 by default, so it is
+// fully disabled for the networks not opting in. A frozen address cannot send transactions through the
+// mempool and cannot redeem through the cross chain contract, and its txs in the blocks are not executed
+// but get failed receipts with the status StatusStrSenderFrozen.
+
+const (
+	StatusSuccess int = 0
+	StatusFailed  int = 1
+
+	freezeContractSequence uint64 = math.MaxUint64 - 4 /*uint64(-5)*/
+
+	// a proposal expires if it does not get enough approvals in this duration
+	ProposalDuration uint64 = 3 * 24 * 3600
+
+	// the status of the failed receipt of a tx which is not executed because its sender is frozen
+	StatusStrSenderFrozen = "sender frozen"
+)
+
+var (
+	//contract address, 10006
+	FreezeContractAddress [20]byte = [20]byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0x27, 0x16}
+
+	/*------selector------*/
+	/*interface Freeze {
+		//0x89b3bc84
+		function propose(address target, bool freeze) external returns (uint id);
+		//0xb759f954
+		function approve(uint id) external;
+		//0xe5839836
+		function isFrozen(address addr) external view returns (bool);
+	}*/
+	SelectorPropose  = [4]byte{0x89, 0xb3, 0xbc, 0x84}
+	SelectorApprove  = [4]byte{0xb7, 0x59, 0xf9, 0x54}
+	SelectorIsFrozen = [4]byte{0xe5, 0x83, 0x98, 0x36}
+
+	HashOfEventProposed = crypto.Keccak256Hash([]byte("Proposed(uint256,address,bool,address)"))
+	HashOfEventApproved = crypto.Keccak256Hash([]byte("Approved(uint256,address,uint256,uint256)"))
+	HashOfEventFrozen   = crypto.Keccak256Hash([]byte("Frozen(uint256,address)"))
+	HashOfEventUnfrozen = crypto.Keccak256Hash([]byte("Unfrozen(uint256,address)"))
+
+	GasOfFreezeOp uint64 = 400_000
+	GasOfIsFrozen uint64 = 5_000
+
+	ErrInvalidCallData    = errors.New("invalid call data")
+	ErrInvalidSelector    = errors.New("invalid selector")
+	ErrOutOfGas           = errors.New("out of gas")
+	ErrNonPayable         = errors.New("not payable")
+	ErrNotActiveValidator = errors.New("not an active validator")
+	ErrAlreadyFrozen      = errors.New("already frozen")
+	ErrNotFrozen          = errors.New("not frozen")
+	ErrCannotFreezeSystem = errors.New("cannot freeze system contracts")
+	ErrNoSuchProposal     = errors.New("no such proposal")
+	ErrProposalExpired    = errors.New("proposal expired")
+	ErrAlreadyApproved    = errors.New("already approved")
+)
+
+type FreezeContractExecutor struct {
+	logger log.Logger
+}
+
+func NewFreezeContractExecutor(logger log.Logger) *FreezeContractExecutor {
+	return &FreezeContractExecutor{
+		logger: logger,
+	}
+}
+
+var _ mevmtypes.SystemContractExecutor = &FreezeContractExecutor{}
+
+func (_ *FreezeContractExecutor) Init(ctx *mevmtypes.Context) {
+	acc := ctx.GetAccount(FreezeContractAddress)
+	if acc == nil { // only executed when the fork is activated
+		acc = mevmtypes.ZeroAccountInfo()
+		acc.UpdateSequence(freezeContractSequence)
+		ctx.SetAccount(FreezeContractAddress, acc)
+	}
+}
+
+func (_ *FreezeContractExecutor) IsSystemContract(addr common.Address) bool {
+	return bytes.Equal(addr[:], FreezeContractAddress[:])
+}
+
+func (f *FreezeContractExecutor) Execute(ctx *mevmtypes.Context, currBlock *mevmtypes.BlockInfo, tx *mevmtypes.TxToRun) (status int, logs []mevmtypes.EvmLog, gasUsed uint64, outData []byte) {
+	if len(tx.Data) < 4 {
+		status = StatusFailed
+		gasUsed = tx.Gas
+		outData = []byte(ErrInvalidCallData.Error())
+		return
+	}
+	var selector [4]byte
+	copy(selector[:], tx.Data[:4])
+	switch selector {
+	case SelectorPropose:
+		// function propose(address target, bool freeze) external returns (uint id)
+		return f.propose(ctx, currBlock, tx)
+	case SelectorApprove:
+		// function approve(uint id) external
+		return f.approve(ctx, currBlock, tx)
+	case SelectorIsFrozen:
+		// function isFrozen(address addr) external view returns (bool)
+		return isFrozen(ctx, tx)
+	default:
+		status = StatusFailed
+		gasUsed = tx.Gas
+		outData = []byte(ErrInvalidSelector.Error())
+		return
+	}
+}
+
+func (_ *FreezeContractExecutor) RequiredGas(_ []byte) uint64 {
+	return GasOfFreezeOp
+}
+
+func (_ *FreezeContractExecutor) Run(_ []byte) ([]byte, error) {
+	return nil, nil
+}
+
+// function propose(address target, bool freeze) external returns (uint id)
+// The proposer must be an active validator and its approval is counted in.
+func (f *FreezeContractExecutor) propose(ctx *mevmtypes.Context, currBlock *mevmtypes.BlockInfo, tx *mevmtypes.TxToRun) (status int, logs []mevmtypes.EvmLog, gasUsed uint64, outData []byte) {
+	status = StatusFailed
+	if outData, gasUsed = checkGasAndValue(tx, GasOfFreezeOp); outData != nil {
+		return
+	}
+	callData := tx.Data[4:]
+	if len(callData) != 64 {
+		outData = []byte(ErrInvalidCallData.Error())
+		return
+	}
+	var target common.Address
+	copy(target[:], callData[12:32])
+	freeze := callData[63] != 0
+	if err := checkTarget(ctx, target, freeze); err != nil {
+		outData = []byte(err.Error())
+		return
+	}
+	if !isActiveValidator(ctx, tx.From) {
+		outData = []byte(ErrNotActiveValidator.Error())
+		return
+	}
+	proposal := &Proposal{
+		ID:        LoadNextProposalID(ctx),
+		Target:    target,
+		Freeze:    freeze,
+		Deadline:  uint64(currBlock.Timestamp) + ProposalDuration,
+		Approvers: []common.Address{tx.From},
+	}
+	SaveNextProposalID(ctx, proposal.ID+1)
+	logs = append(logs, buildProposedLog(proposal.ID, target, freeze, tx.From))
+	logs = append(logs, f.tryExecute(ctx, currBlock, proposal, tx.From)...)
+	id := uint256.NewInt(proposal.ID).Bytes32()
+	outData = id[:]
+	status = StatusSuccess
+	return
+}
+
+// function approve(uint id) external
+func (f *FreezeContractExecutor) approve(ctx *mevmtypes.Context, currBlock *mevmtypes.BlockInfo, tx *mevmtypes.TxToRun) (status int, logs []mevmtypes.EvmLog, gasUsed uint64, outData []byte) {
+	status = StatusFailed
+	if outData, gasUsed = checkGasAndValue(tx, GasOfFreezeOp); outData != nil {
+		return
+	}
+	callData := tx.Data[4:]
+	if len(callData) != 32 {
+		outData = []byte(ErrInvalidCallData.Error())
+		return
+	}
+	id := uint256.NewInt(0).SetBytes32(callData)
+	if !id.IsUint64() {
+		outData = []byte(ErrNoSuchProposal.Error())
+		return
+	}
+	proposal := LoadProposal(ctx, id.Uint64())
+	if proposal == nil {
+		outData = []byte(ErrNoSuchProposal.Error())
+		return
+	}
+	if uint64(currBlock.Timestamp) >= proposal.Deadline {
+		outData = []byte(ErrProposalExpired.Error())
+		return
+	}
+	if err := checkTarget(ctx, proposal.Target, proposal.Freeze); err != nil {
+		outData = []byte(err.Error())
+		return
+	}
+	if !isActiveValidator(ctx, tx.From) {
+		outData = []byte(ErrNotActiveValidator.Error())
+		return
+	}
+	for _, approver := range proposal.Approvers {
+		if approver == tx.From {
+			outData = []byte(ErrAlreadyApproved.Error())
+			return
+		}
+	}
+	proposal.Approvers = append(proposal.Approvers, tx.From)
+	logs = f.tryExecute(ctx, currBlock, proposal, tx.From)
+	status = StatusSuccess
+	return
+}
+
+// tryExecute counts the approvals with the current voting power. The proposal is executed and deleted
+// when the approvals exceed 2/3 of the total voting power, otherwise it is saved for more approvals.
+func (f *FreezeContractExecutor) tryExecute(ctx *mevmtypes.Context, currBlock *mevmtypes.BlockInfo, proposal *Proposal, approver common.Address) (logs []mevmtypes.EvmLog) {
+	approvedPower, totalPower := countApprovals(ctx, proposal.Approvers)
+	logs = append(logs, buildApprovedLog(proposal.ID, approver, approvedPower, totalPower))
+	if approvedPower*3 <= totalPower*2 {
+		SaveProposal(ctx, proposal)
+		return
+	}
+	DeleteProposal(ctx, proposal.ID)
+	if proposal.Freeze {
+		SaveFrozen(ctx, proposal.Target, currBlock.Number, proposal.ID)
+		logs = append(logs, buildFrozenLog(HashOfEventFrozen, proposal.ID, proposal.Target))
+		f.logger.Info("address frozen", "address", proposal.Target.String(), "proposal", proposal.ID)
+	} else {
+		DeleteFrozen(ctx, proposal.Target)
+		logs = append(logs, buildFrozenLog(HashOfEventUnfrozen, proposal.ID, proposal.Target))
+		f.logger.Info("address unfrozen", "address", proposal.Target.String(), "proposal", proposal.ID)
+	}
+	return
+}
+
+// function isFrozen(address addr) external view returns (bool)
+func isFrozen(ctx *mevmtypes.Context, tx *mevmtypes.TxToRun) (status int, logs []mevmtypes.EvmLog, gasUsed uint64, outData []byte) {
+	status = StatusFailed
+	gasUsed = GasOfIsFrozen
+	if tx.Gas < gasUsed {
+		outData = []byte(ErrOutOfGas.Error())
+		gasUsed = tx.Gas
+		return
+	}
+	callData := tx.Data[4:]
+	if len(callData) != 32 {
+		outData = []byte(ErrInvalidCallData.Error())
+		return
+	}
+	var addr common.Address
+	copy(addr[:], callData[12:])
+	outData = make([]byte, 32)
+	if IsFrozen(ctx, addr) {
+		outData[31] = 1
+	}
+	status = StatusSuccess
+	return
+}
+
+func checkGasAndValue(tx *mevmtypes.TxToRun, gas uint64) (outData []byte, gasUsed uint64) {
+	gasUsed = gas
+	if tx.Gas < gasUsed {
+		return []byte(ErrOutOfGas.Error()), tx.Gas
+	}
+	if !uint256.NewInt(0).SetBytes32(tx.Value[:]).IsZero() {
+		return []byte(ErrNonPayable.Error()), gasUsed
+	}
+	return nil, gasUsed
+}
+
+// checkTarget makes sure the action changes the state of target
+func checkTarget(ctx *mevmtypes.Context, target common.Address, freeze bool) error {
+	if isSystemAddress(target) {
+		return ErrCannotFreezeSystem
+	}
+	frozen := IsFrozen(ctx, target)
+	if freeze && frozen {
+		return ErrAlreadyFrozen
+	}
+	if !freeze && !frozen {
+		return ErrNotFrozen
+	}
+	return nil
+}
+
+// the system contracts are at 0x2710 ~ 0x27ff
+func isSystemAddress(addr common.Address) bool {
+	var zeros [18]byte
+	return bytes.Equal(addr[:18], zeros[:]) && addr[18] == 0x27
+}
+
+func isActiveValidator(ctx *mevmtypes.Context, addr common.Address) bool {
+	info := staking.LoadStakingInfo(ctx)
+	for _, val := range staking.GetActiveValidators(ctx, info.Validators) {
+		if val.Address == addr {
+			return true
+		}
+	}
+	return false
+}
+
+// countApprovals sums the voting power of the approvers who are still active validators
+func countApprovals(ctx *mevmtypes.Context, approvers []common.Address) (approvedPower, totalPower int64) {
+	info := staking.LoadStakingInfo(ctx)
+	approverSet := make(map[common.Address]bool, len(approvers))
+	for _, approver := range approvers {
+		approverSet[approver] = true
+	}
+	for _, val := range staking.GetActiveValidators(ctx, info.Validators) {
+		totalPower += val.VotingPower
+		if approverSet[val.Address] {
+			approvedPower += val.VotingPower
+		}
+	}
+	return
+}
@@ -0,0 +1,119 @@
+package freeze
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/holiman/uint256"
+	"github.com/smartbch/moeingads/store"
+	"github.com/smartbch/moeingads/store/rabbit"
+	"github.com/smartbch/moeingevm/types"
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/libs/log"
+
+	"github.com/smartbch/smartbch/staking"
+	stakingtypes "github.com/smartbch/smartbch/staking/types"
+)
+
+func buildProposeTx(from, target common.Address, freeze bool) *types.TxToRun {
+	data := append(SelectorPropose[:], common.LeftPadBytes(target[:], 32)...)
+	data = append(data, make([]byte, 32)...)
+	if freeze {
+		data[len(data)-1] = 1
+	}
+	return &types.TxToRun{BasicTx: types.BasicTx{From: from, To: FreezeContractAddress, Gas: GasOfFreezeOp, Data: data}}
+}
+
+func buildApproveTx(from common.Address, id uint64) *types.TxToRun {
+	idBytes := uint256.NewInt(id).Bytes32()
+	data := append(SelectorApprove[:], idBytes[:]...)
+	return &types.TxToRun{BasicTx: types.BasicTx{From: from, To: FreezeContractAddress, Gas: GasOfFreezeOp, Data: data}}
+}
+
+func TestFreezeByGovernance(t *testing.T) {
+	r := rabbit.NewRabbitStore(store.NewMockRootStore())
+	ctx := types.NewContext(&r, nil)
+	ctx.SetCurrentHeight(10)
+	ctx.SetStakingForkBlock(100)
+	val1, val2, val3 := common.Address{0xad, 0x01}, common.Address{0xad, 0x02}, common.Address{0xad, 0x03}
+	staking.SaveStakingInfo(ctx, stakingtypes.StakingInfo{
+		Validators: []*stakingtypes.Validator{
+			{Address: val1, StakedCoins: [32]byte{0x10}, VotingPower: 1},
+			{Address: val2, StakedCoins: [32]byte{0x10}, VotingPower: 1},
+			{Address: val3, StakedCoins: [32]byte{0x10}, VotingPower: 1},
+		},
+	})
+	executor := NewFreezeContractExecutor(log.NewNopLogger())
+	executor.Init(ctx)
+	block := &types.BlockInfo{Number: 10, Timestamp: 1000}
+	hacker := common.Address{0xbb}
+
+	// only active validators can propose
+	status, _, _, outData := executor.Execute(ctx, block, buildProposeTx(hacker, hacker, true))
+	require.Equal(t, StatusFailed, status)
+	require.Equal(t, ErrNotActiveValidator.Error(), string(outData))
+	status, _, _, outData = executor.Execute(ctx, block, buildProposeTx(val1, staking.StakingContractAddress, true))
+	require.Equal(t, StatusFailed, status)
+	require.Equal(t, ErrCannotFreezeSystem.Error(), string(outData))
+
+	status, logs, _, outData := executor.Execute(ctx, block, buildProposeTx(val1, hacker, true))
+	require.Equal(t, StatusSuccess, status)
+	require.Equal(t, uint64(1), uint256.NewInt(0).SetBytes(outData).Uint64())
+	require.Len(t, logs, 2)
+	require.Equal(t, HashOfEventProposed, logs[0].Topics[0])
+	require.Equal(t, HashOfEventApproved, logs[1].Topics[0])
+
+	// 2/3 is not enough
+	status, logs, _, _ = executor.Execute(ctx, block, buildApproveTx(val2, 1))
+	require.Equal(t, StatusSuccess, status)
+	require.Len(t, logs, 1)
+	require.False(t, IsFrozen(ctx, hacker))
+	status, _, _, outData = executor.Execute(ctx, block, buildApproveTx(val2, 1))
+	require.Equal(t, StatusFailed, status)
+	require.Equal(t, ErrAlreadyApproved.Error(), string(outData))
+
+	status, logs, _, _ = executor.Execute(ctx, block, buildApproveTx(val3, 1))
+	require.Equal(t, StatusSuccess, status)
+	require.Len(t, logs, 2)
+	require.Equal(t, HashOfEventFrozen, logs[1].Topics[0])
+	require.True(t, IsFrozen(ctx, hacker))
+	require.Nil(t, LoadProposal(ctx, 1))
+	frozenAddrs := GetFrozenAddresses(ctx)
+	require.Len(t, frozenAddrs, 1)
+	require.Equal(t, hacker, frozenAddrs[0].Address)
+	require.Equal(t, int64(10), frozenAddrs[0].Since)
+	require.Equal(t, uint64(1), frozenAddrs[0].ProposalID)
+
+	status, _, _, outData = executor.Execute(ctx, block, buildProposeTx(val1, hacker, true))
+	require.Equal(t, StatusFailed, status)
+	require.Equal(t, ErrAlreadyFrozen.Error(), string(outData))
+
+	// unfreeze, the proposal expires before getting enough approvals
+	status, _, _, _ = executor.Execute(ctx, block, buildProposeTx(val1, hacker, false))
+	require.Equal(t, StatusSuccess, status)
+	expiredBlock := &types.BlockInfo{Number: 11, Timestamp: 1000 + int64(ProposalDuration)}
+	status, _, _, outData = executor.Execute(ctx, expiredBlock, buildApproveTx(val2, 2))
+	require.Equal(t, StatusFailed, status)
+	require.Equal(t, ErrProposalExpired.Error(), string(outData))
+
+	status, _, _, _ = executor.Execute(ctx, block, buildProposeTx(val1, hacker, false))
+	require.Equal(t, StatusSuccess, status)
+	status, _, _, _ = executor.Execute(ctx, block, buildApproveTx(val2, 3))
+	require.Equal(t, StatusSuccess, status)
+	status, logs, _, _ = executor.Execute(ctx, block, buildApproveTx(val3, 3))
+	require.Equal(t, StatusSuccess, status)
+	require.Equal(t, HashOfEventUnfrozen, logs[1].Topics[0])
+	require.False(t, IsFrozen(ctx, hacker))
+	require.Len(t, GetFrozenAddresses(ctx), 0)
+}
+
+func TestProposalBytes(t *testing.T) {
+	p := &Proposal{
+		ID:        7,
+		Target:    common.Address{0x01},
+		Freeze:    true,
+		Deadline:  12345,
+		Approvers: []common.Address{{0x02}, {0x03}},
+	}
+	require.Equal(t, p, proposalFromBytes(7, p.toBytes()))
+}
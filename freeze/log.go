@@ -0,0 +1,50 @@
+package freeze
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/holiman/uint256"
+	mevmtypes "github.com/smartbch/moeingevm/types"
+)
+
+func buildProposedLog(id uint64, target common.Address, freeze bool, proposer common.Address) mevmtypes.EvmLog {
+	evmLog := mevmtypes.EvmLog{
+		Address: FreezeContractAddress,
+		Topics:  make([]common.Hash, 0, 4),
+	}
+	evmLog.Topics = append(evmLog.Topics, HashOfEventProposed)
+	evmLog.Topics = append(evmLog.Topics, uint256.NewInt(id).Bytes32())
+	evmLog.Topics = append(evmLog.Topics, target.Hash())
+	var data [64]byte
+	if freeze {
+		data[31] = 1
+	}
+	copy(data[44:], proposer[:])
+	evmLog.Data = data[:]
+	return evmLog
+}
+
+func buildApprovedLog(id uint64, approver common.Address, approvedPower, totalPower int64) mevmtypes.EvmLog {
+	evmLog := mevmtypes.EvmLog{
+		Address: FreezeContractAddress,
+		Topics:  make([]common.Hash, 0, 3),
+	}
+	evmLog.Topics = append(evmLog.Topics, HashOfEventApproved)
+	evmLog.Topics = append(evmLog.Topics, uint256.NewInt(id).Bytes32())
+	evmLog.Topics = append(evmLog.Topics, approver.Hash())
+	approved := uint256.NewInt(uint64(approvedPower)).Bytes32()
+	total := uint256.NewInt(uint64(totalPower)).Bytes32()
+	evmLog.Data = append(approved[:], total[:]...)
+	return evmLog
+}
+
+// buildFrozenLog builds the log of event Frozen or Unfrozen
+func buildFrozenLog(eventHash common.Hash, id uint64, target common.Address) mevmtypes.EvmLog {
+	evmLog := mevmtypes.EvmLog{
+		Address: FreezeContractAddress,
+		Topics:  make([]common.Hash, 0, 3),
+	}
+	evmLog.Topics = append(evmLog.Topics, eventHash)
+	evmLog.Topics = append(evmLog.Topics, uint256.NewInt(id).Bytes32())
+	evmLog.Topics = append(evmLog.Topics, target.Hash())
+	return evmLog
+}
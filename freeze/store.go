@@ -0,0 +1,162 @@
+package freeze
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	mevmtypes "github.com/smartbch/moeingevm/types"
+)
+
+var (
+	SlotNextProposalID   string = strings.Repeat(string([]byte{0}), 32)
+	SlotFrozenAddrList   string = strings.Repeat(string([]byte{0}), 31) + string([]byte{1})
+	frozenSlotHashPrefix        = [6]byte{'f', 'r', 'o', 'z', 'e', 'n'}
+)
+
+// Proposal is a pending freeze or unfreeze action waiting for more approvals
+type Proposal struct {
+	ID        uint64
+	Target    common.Address
+	Freeze    bool // false for unfreezing
+	Deadline  uint64
+	Approvers []common.Address
+}
+
+// FrozenAddress records when and by which proposal an address was frozen
+type FrozenAddress struct {
+	Address    common.Address
+	Since      int64 // the height of the block freezing it
+	ProposalID uint64
+}
+
+func (p *Proposal) toBytes() []byte {
+	bz := make([]byte, 0, 20+1+8+20*len(p.Approvers))
+	bz = append(bz, p.Target[:]...)
+	if p.Freeze {
+		bz = append(bz, 1)
+	} else {
+		bz = append(bz, 0)
+	}
+	var deadline [8]byte
+	binary.BigEndian.PutUint64(deadline[:], p.Deadline)
+	bz = append(bz, deadline[:]...)
+	for _, approver := range p.Approvers {
+		bz = append(bz, approver[:]...)
+	}
+	return bz
+}
+
+func proposalFromBytes(id uint64, bz []byte) *Proposal {
+	if len(bz) < 29 || (len(bz)-29)%20 != 0 {
+		panic("invalid proposal bytes")
+	}
+	p := &Proposal{
+		ID:       id,
+		Target:   common.BytesToAddress(bz[:20]),
+		Freeze:   bz[20] != 0,
+		Deadline: binary.BigEndian.Uint64(bz[21:29]),
+	}
+	for i := 29; i < len(bz); i += 20 {
+		p.Approvers = append(p.Approvers, common.BytesToAddress(bz[i:i+20]))
+	}
+	return p
+}
+
+func getSlotForProposal(id uint64) string {
+	var buf [32]byte
+	buf[23] = 1
+	binary.BigEndian.PutUint64(buf[24:], id)
+	return string(buf[:])
+}
+
+func getSlotForFrozen(addr common.Address) string {
+	hash := sha256.Sum256(append(frozenSlotHashPrefix[:], addr[:]...))
+	return string(hash[:])
+}
+
+func LoadNextProposalID(ctx *mevmtypes.Context) uint64 {
+	bz := ctx.GetStorageAt(freezeContractSequence, SlotNextProposalID)
+	if len(bz) == 0 {
+		return 1 // proposal ID starts from 1
+	}
+	return binary.BigEndian.Uint64(bz)
+}
+
+func SaveNextProposalID(ctx *mevmtypes.Context, id uint64) {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], id)
+	ctx.SetStorageAt(freezeContractSequence, SlotNextProposalID, buf[:])
+}
+
+func LoadProposal(ctx *mevmtypes.Context, id uint64) *Proposal {
+	bz := ctx.GetStorageAt(freezeContractSequence, getSlotForProposal(id))
+	if len(bz) == 0 {
+		return nil
+	}
+	return proposalFromBytes(id, bz)
+}
+
+func SaveProposal(ctx *mevmtypes.Context, p *Proposal) {
+	ctx.SetStorageAt(freezeContractSequence, getSlotForProposal(p.ID), p.toBytes())
+}
+
+func DeleteProposal(ctx *mevmtypes.Context, id uint64) {
+	ctx.DeleteStorageAt(freezeContractSequence, getSlotForProposal(id))
+}
+
+func LoadFrozenAddress(ctx *mevmtypes.Context, addr common.Address) *FrozenAddress {
+	bz := ctx.GetStorageAt(freezeContractSequence, getSlotForFrozen(addr))
+	if len(bz) != 16 {
+		return nil
+	}
+	return &FrozenAddress{
+		Address:    addr,
+		Since:      int64(binary.BigEndian.Uint64(bz[:8])),
+		ProposalID: binary.BigEndian.Uint64(bz[8:]),
+	}
+}
+
+// IsFrozen reads the state, so the callers in consensus paths must check param.FreezeForkHeight first
+func IsFrozen(ctx *mevmtypes.Context, addr common.Address) bool {
+	return len(ctx.GetStorageAt(freezeContractSequence, getSlotForFrozen(addr))) != 0
+}
+
+// GetFrozenAddresses returns all the frozen addresses, in the order of being frozen
+func GetFrozenAddresses(ctx *mevmtypes.Context) []*FrozenAddress {
+	bz := ctx.GetStorageAt(freezeContractSequence, SlotFrozenAddrList)
+	result := make([]*FrozenAddress, 0, len(bz)/20)
+	for i := 0; i+20 <= len(bz); i += 20 {
+		if frozen := LoadFrozenAddress(ctx, common.BytesToAddress(bz[i:i+20])); frozen != nil {
+			result = append(result, frozen)
+		}
+	}
+	return result
+}
+
+func SaveFrozen(ctx *mevmtypes.Context, addr common.Address, height int64, proposalID uint64) {
+	var buf [16]byte
+	binary.BigEndian.PutUint64(buf[:8], uint64(height))
+	binary.BigEndian.PutUint64(buf[8:], proposalID)
+	ctx.SetStorageAt(freezeContractSequence, getSlotForFrozen(addr), buf[:])
+	list := ctx.GetStorageAt(freezeContractSequence, SlotFrozenAddrList)
+	list = append(append([]byte{}, list...), addr[:]...)
+	ctx.SetStorageAt(freezeContractSequence, SlotFrozenAddrList, list)
+}
+
+func DeleteFrozen(ctx *mevmtypes.Context, addr common.Address) {
+	ctx.DeleteStorageAt(freezeContractSequence, getSlotForFrozen(addr))
+	list := ctx.GetStorageAt(freezeContractSequence, SlotFrozenAddrList)
+	newList := make([]byte, 0, len(list))
+	for i := 0; i+20 <= len(list); i += 20 {
+		if common.BytesToAddress(list[i:i+20]) != addr {
+			newList = append(newList, list[i:i+20]...)
+		}
+	}
+	if len(newList) == 0 {
+		ctx.DeleteStorageAt(freezeContractSequence, SlotFrozenAddrList)
+	} else {
+		ctx.SetStorageAt(freezeContractSequence, SlotFrozenAddrList, newList)
+	}
+}
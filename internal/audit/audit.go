@@ -0,0 +1,153 @@
+// Package audit records the privileged operations taken on a node into an append-only file.
+// Each entry contains the hash of the previous one, so removing or changing an entry in the
+// middle of the file breaks the chain and can be found by Verify.
+package audit
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	ActionSetRpcKey           = "set-rpc-key"
+	ActionAddTracedAddress    = "add-traced-address"
+	ActionRemoveTracedAddress = "remove-traced-address"
+	ActionUpdateConfig        = "update-config"
+	ActionRescanCc            = "rescan-cc"
+	ActionPauseCcCollect      = "pause-cc-collect"
+	ActionResumeCcCollect     = "resume-cc-collect"
+
+	SourceRpc      = "rpc"
+	SourceCli      = "cli"
+	SourceMultisig = "multisig" // through an admin operation signed by the operators
+)
+
+type Entry struct {
+	Seq      uint64            `json:"seq"`
+	Time     int64             `json:"time"`
+	Action   string            `json:"action"`
+	Source   string            `json:"source"`
+	Details  map[string]string `json:"details,omitempty"`
+	PrevHash string            `json:"prevHash"`
+	Hash     string            `json:"hash"`
+}
+
+// calcHash hashes the JSON encoding of the entry without its Hash field
+func (e *Entry) calcHash() string {
+	e2 := *e
+	e2.Hash = ""
+	bz, _ := json.Marshal(&e2)
+	hash := sha256.Sum256(bz)
+	return hex.EncodeToString(hash[:])
+}
+
+// Log appends entries to an audit file, it is safe for concurrent use. A nil *Log records nothing.
+type Log struct {
+	mtx      sync.Mutex
+	file     *os.File
+	nextSeq  uint64
+	lastHash string
+}
+
+// Open opens or creates the audit file at path. The existing entries are verified before any
+// new entry is appended.
+func Open(path string) (*Log, error) {
+	entries, err := ReadAll(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if err = Verify(entries); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, err
+	}
+	l := &Log{file: file}
+	if n := len(entries); n > 0 {
+		l.nextSeq = entries[n-1].Seq + 1
+		l.lastHash = entries[n-1].Hash
+	}
+	return l, nil
+}
+
+// Record appends an entry and syncs it to the disk before returning
+func (l *Log) Record(action, source string, details map[string]string) error {
+	if l == nil {
+		return nil
+	}
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	e := &Entry{
+		Seq:      l.nextSeq,
+		Time:     time.Now().Unix(),
+		Action:   action,
+		Source:   source,
+		Details:  details,
+		PrevHash: l.lastHash,
+	}
+	e.Hash = e.calcHash()
+	bz, _ := json.Marshal(e)
+	if _, err := l.file.Write(append(bz, '\n')); err != nil {
+		return err
+	}
+	if err := l.file.Sync(); err != nil {
+		return err
+	}
+	l.nextSeq++
+	l.lastHash = e.Hash
+	return nil
+}
+
+func (l *Log) Close() error {
+	if l == nil {
+		return nil
+	}
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	return l.file.Close()
+}
+
+// ReadAll reads all the entries in the audit file without verifying them
+func ReadAll(path string) ([]*Entry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	var entries []*Entry
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		e := &Entry{}
+		if err := json.Unmarshal(scanner.Bytes(), e); err != nil {
+			return nil, fmt.Errorf("invalid entry at line %d: %w", line, err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, scanner.Err()
+}
+
+// Verify checks the sequence numbers and the hash chain of the entries
+func Verify(entries []*Entry) error {
+	prevHash := ""
+	for i, e := range entries {
+		if e.Seq != uint64(i) {
+			return fmt.Errorf("entry #%d has sequence number %d", i, e.Seq)
+		}
+		if e.PrevHash != prevHash {
+			return fmt.Errorf("entry #%d does not follow the previous entry", i)
+		}
+		if e.Hash != e.calcHash() {
+			return fmt.Errorf("entry #%d has been modified", i)
+		}
+		prevHash = e.Hash
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package audit
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	l, err := Open(path)
+	require.NoError(t, err)
+	require.NoError(t, l.Record(ActionSetRpcKey, SourceRpc, map[string]string{"pubkey": "02ab"}))
+	require.NoError(t, l.Record(ActionAddTracedAddress, SourceRpc, nil))
+	require.NoError(t, l.Close())
+
+	// the chain continues after reopening
+	l, err = Open(path)
+	require.NoError(t, err)
+	require.NoError(t, l.Record(ActionUpdateConfig, SourceCli, map[string]string{"key": "archive-mode"}))
+	require.NoError(t, l.Close())
+
+	entries, err := ReadAll(path)
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	require.NoError(t, Verify(entries))
+	require.Equal(t, uint64(2), entries[2].Seq)
+	require.Equal(t, entries[1].Hash, entries[2].PrevHash)
+	require.Equal(t, "archive-mode", entries[2].Details["key"])
+
+	var nilLog *Log
+	require.NoError(t, nilLog.Record(ActionSetRpcKey, SourceRpc, nil))
+	require.NoError(t, nilLog.Close())
+}
+
+func TestTamperedLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	l, err := Open(path)
+	require.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		require.NoError(t, l.Record(ActionAddTracedAddress, SourceRpc, nil))
+	}
+	require.NoError(t, l.Close())
+	bz, err := os.ReadFile(path)
+	require.NoError(t, err)
+	lines := strings.SplitAfter(string(bz), "\n")
+
+	// an entry is removed
+	require.NoError(t, os.WriteFile(path, []byte(lines[0]+lines[2]), 0600))
+	entries, err := ReadAll(path)
+	require.NoError(t, err)
+	require.Error(t, Verify(entries))
+	_, err = Open(path)
+	require.Error(t, err)
+
+	// an entry is modified
+	modified := strings.Replace(lines[1], ActionAddTracedAddress, ActionRemoveTracedAddress, 1)
+	require.NoError(t, os.WriteFile(path, []byte(lines[0]+modified+lines[2]), 0600))
+	entries, err = ReadAll(path)
+	require.NoError(t, err)
+	require.EqualError(t, Verify(entries), "entry #1 has been modified")
+}
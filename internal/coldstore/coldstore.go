@@ -0,0 +1,131 @@
+// Package coldstore keeps a copy of the committed blocks in a cheaper storage tier, such as a
+// S3-compatible object store, so the nodes serving archive RPC can prune the old blocks from
+// moeingdb (blocks_kept_modb) and still serve them. A block is archived as soon as it is added
+// into moeingdb, and read back when it is no longer found there.
+package coldstore
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"net/url"
+	"path/filepath"
+
+	modbtypes "github.com/smartbch/moeingdb/types"
+	motypes "github.com/smartbch/moeingevm/types"
+)
+
+var ErrNotFound = errors.New("block not found in the cold store")
+
+// Store is the object store of the cold tier
+type Store interface {
+	Put(key string, data []byte) error
+	// Get returns ErrNotFound if the key does not exist
+	Get(key string) ([]byte, error)
+}
+
+// Open returns the store of rawUrl, which is "file:///path/to/dir" or
+// "s3://bucket/prefix?endpoint=https://s3.us-east-1.amazonaws.com&region=us-east-1".
+// The credentials of S3 are read from AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY.
+func Open(rawUrl string) (Store, error) {
+	if err := CheckUrl(rawUrl); err != nil {
+		return nil, err
+	}
+	u, _ := url.Parse(rawUrl)
+	if u.Scheme == "file" {
+		return NewFileStore(filepath.FromSlash(u.Path))
+	}
+	return newS3StoreFromUrl(u)
+}
+
+// CheckUrl validates rawUrl without opening the store, the credentials are not checked
+func CheckUrl(rawUrl string) error {
+	u, err := url.Parse(rawUrl)
+	if err != nil {
+		return err
+	}
+	switch u.Scheme {
+	case "file":
+		if u.Path == "" {
+			return errors.New("empty path in the cold store url")
+		}
+	case "s3":
+		if u.Host == "" {
+			return errors.New("missing bucket in the s3 url")
+		}
+		if u.Query().Get("endpoint") == "" {
+			return errors.New("missing endpoint in the s3 url")
+		}
+	default:
+		return fmt.Errorf("unsupported cold store url scheme: %q", u.Scheme)
+	}
+	return nil
+}
+
+// BlockKey returns the key of the block at height, padded such that the keys are listed in order
+func BlockKey(height int64) string {
+	return fmt.Sprintf("blocks/%012d", height)
+}
+
+// Block is a block of moeingdb with the signatures of its transactions
+type Block struct {
+	Block modbtypes.Block
+	Sigs  map[[32]byte][65]byte
+}
+
+// NewBlock copies the signatures of the transactions in blk from txid2sigMap
+func NewBlock(blk *modbtypes.Block, txid2sigMap map[[32]byte][65]byte) *Block {
+	b := &Block{Block: *blk, Sigs: make(map[[32]byte][65]byte, len(blk.TxList))}
+	for _, tx := range blk.TxList {
+		if sig, ok := txid2sigMap[tx.HashId]; ok {
+			b.Sigs[tx.HashId] = sig
+		}
+	}
+	return b
+}
+
+func (b *Block) Encode() ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if err := gob.NewEncoder(zw).Encode(b); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func DecodeBlock(data []byte) (*Block, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	b := &Block{}
+	if err = gob.NewDecoder(zr).Decode(b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// Unpack decodes the block info and the transactions, in the same way as moeingdb
+func (b *Block) Unpack() (*motypes.Block, []*motypes.Transaction, [][65]byte, error) {
+	blk := &motypes.Block{}
+	if _, err := blk.UnmarshalMsg(b.Block.BlockInfo); err != nil {
+		return nil, nil, nil, err
+	}
+	txs := make([]*motypes.Transaction, len(b.Block.TxList))
+	sigs := make([][65]byte, len(b.Block.TxList))
+	for i, t := range b.Block.TxList {
+		tx := &motypes.Transaction{}
+		if _, err := tx.UnmarshalMsg(t.Content); err != nil {
+			return nil, nil, nil, err
+		}
+		txs[i] = tx
+		sigs[i] = b.Sigs[t.HashId]
+	}
+	return blk, txs, sigs, nil
+}
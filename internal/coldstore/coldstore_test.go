@@ -0,0 +1,81 @@
+package coldstore
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/libs/log"
+
+	modbtypes "github.com/smartbch/moeingdb/types"
+)
+
+func TestBlockEncoding(t *testing.T) {
+	blk := &modbtypes.Block{Height: 7, BlockInfo: []byte{1, 2, 3}}
+	blk.TxList = []modbtypes.Tx{{HashId: [32]byte{1}, Content: []byte{4}}, {HashId: [32]byte{2}, Content: []byte{5}}}
+	sigs := map[[32]byte][65]byte{{1}: {9}, {3}: {8}}
+	data, err := NewBlock(blk, sigs).Encode()
+	require.NoError(t, err)
+	decoded, err := DecodeBlock(data)
+	require.NoError(t, err)
+	require.Equal(t, *blk, decoded.Block)
+	require.Equal(t, map[[32]byte][65]byte{{1}: {9}}, decoded.Sigs)
+}
+
+func TestTierWithFileStore(t *testing.T) {
+	store, err := Open("file://" + t.TempDir())
+	require.NoError(t, err)
+	tier := NewTier(store, 1, log.NewNopLogger())
+	tier.Archive(&modbtypes.Block{Height: 1}, nil)
+	tier.Archive(&modbtypes.Block{Height: 2}, nil)
+	tier.Close()
+
+	blk, err := tier.GetBlock(2)
+	require.NoError(t, err)
+	require.EqualValues(t, 2, blk.Block.Height)
+	require.Same(t, blk, tier.cache.get(2))
+	_, err = tier.GetBlock(1)
+	require.NoError(t, err)
+	require.Nil(t, tier.cache.get(2)) // evicted
+	_, err = tier.GetBlock(3)
+	require.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestS3Store(t *testing.T) {
+	var mtx sync.Mutex
+	objects := make(map[string][]byte)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=ak/") {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		mtx.Lock()
+		defer mtx.Unlock()
+		switch r.Method {
+		case http.MethodPut:
+			objects[r.URL.Path], _ = io.ReadAll(r.Body)
+		case http.MethodGet:
+			data, ok := objects[r.URL.Path]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_, _ = w.Write(data)
+		}
+	}))
+	defer server.Close()
+
+	store, err := NewS3Store(server.URL, "bucket", "smartbch", "us-east-1", "ak", "sk")
+	require.NoError(t, err)
+	require.NoError(t, store.Put(BlockKey(5), []byte("blk")))
+	require.Contains(t, objects, "/bucket/smartbch/blocks/000000000005")
+	data, err := store.Get(BlockKey(5))
+	require.NoError(t, err)
+	require.Equal(t, []byte("blk"), data)
+	_, err = store.Get(BlockKey(6))
+	require.ErrorIs(t, err, ErrNotFound)
+}
@@ -0,0 +1,160 @@
+package coldstore
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+const s3RequestTimeout = 30 * time.Second
+
+// S3Store accesses an S3-compatible object store with path-style requests signed by AWS
+// Signature Version 4
+type S3Store struct {
+	endpoint  string // scheme://host, without the trailing slash
+	bucket    string
+	prefix    string
+	region    string
+	accessKey string
+	secretKey string
+	client    *http.Client
+	now       func() time.Time
+}
+
+func newS3StoreFromUrl(u *url.URL) (*S3Store, error) {
+	endpoint := u.Query().Get("endpoint")
+	region := u.Query().Get("region")
+	if region == "" {
+		region = "us-east-1"
+	}
+	return NewS3Store(endpoint, u.Host, strings.Trim(u.Path, "/"), region,
+		os.Getenv("AWS_ACCESS_KEY_ID"), os.Getenv("AWS_SECRET_ACCESS_KEY"))
+}
+
+func NewS3Store(endpoint, bucket, prefix, region, accessKey, secretKey string) (*S3Store, error) {
+	ep, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if ep.Scheme != "http" && ep.Scheme != "https" {
+		return nil, fmt.Errorf("invalid s3 endpoint: %s", endpoint)
+	}
+	if bucket == "" {
+		return nil, errors.New("missing bucket in the s3 url")
+	}
+	if accessKey == "" || secretKey == "" {
+		return nil, errors.New("missing s3 credentials")
+	}
+	return &S3Store{
+		endpoint:  ep.Scheme + "://" + ep.Host,
+		bucket:    bucket,
+		prefix:    prefix,
+		region:    region,
+		accessKey: accessKey,
+		secretKey: secretKey,
+		client:    &http.Client{Timeout: s3RequestTimeout},
+		now:       time.Now,
+	}, nil
+}
+
+func (s *S3Store) objectPath(key string) string {
+	if s.prefix != "" {
+		key = s.prefix + "/" + key
+	}
+	return "/" + s.bucket + "/" + key
+}
+
+func (s *S3Store) Put(key string, data []byte) error {
+	resp, err := s.do(http.MethodPut, key, data)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("s3 put %s: %s", key, resp.Status)
+	}
+	return nil
+}
+
+func (s *S3Store) Get(key string) ([]byte, error) {
+	resp, err := s.do(http.MethodGet, key, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return io.ReadAll(resp.Body)
+	case http.StatusNotFound:
+		return nil, ErrNotFound
+	default:
+		return nil, fmt.Errorf("s3 get %s: %s", key, resp.Status)
+	}
+}
+
+func (s *S3Store) do(method, key string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequest(method, s.endpoint+s.objectPath(key), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	s.sign(req, body)
+	return s.client.Do(req)
+}
+
+// sign adds the headers of AWS Signature Version 4
+func (s *S3Store) sign(req *http.Request, body []byte) {
+	now := s.now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	payloadHash := sha256Hex(body)
+	req.Header.Set("x-amz-date", amzDate)
+	req.Header.Set("x-amz-content-sha256", payloadHash)
+
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		"host:" + req.URL.Host + "\n" +
+			"x-amz-content-sha256:" + payloadHash + "\n" +
+			"x-amz-date:" + amzDate + "\n",
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	scope := date + "/" + s.region + "/s3/aws4_request"
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSha256([]byte("AWS4"+s.secretKey), date)
+	key = hmacSha256(key, s.region)
+	key = hmacSha256(key, "s3")
+	key = hmacSha256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSha256(key, stringToSign))
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.accessKey, scope, signedHeaders, signature))
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSha256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
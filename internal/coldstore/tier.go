@@ -0,0 +1,152 @@
+package coldstore
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/tendermint/tendermint/libs/log"
+
+	modbtypes "github.com/smartbch/moeingdb/types"
+)
+
+const (
+	// the max number of blocks waiting to be uploaded, more blocks are dropped from the cold tier
+	// instead of blocking Commit
+	maxPendingUploads = 1024
+	maxRetryInterval  = time.Minute
+)
+
+var retryInterval = time.Second
+
+type upload struct {
+	height int64
+	data   []byte
+}
+
+// Tier uploads the blocks to the store in the background, and caches the recently read blocks
+type Tier struct {
+	store   Store
+	logger  log.Logger
+	uploads chan upload
+	done    chan struct{}
+
+	mtx   sync.Mutex
+	cache *blockCache
+}
+
+func NewTier(store Store, cacheSize int, logger log.Logger) *Tier {
+	t := &Tier{
+		store:   store,
+		logger:  logger,
+		uploads: make(chan upload, maxPendingUploads),
+		done:    make(chan struct{}),
+		cache:   newBlockCache(cacheSize),
+	}
+	go t.uploadLoop()
+	return t
+}
+
+// Archive queues blk for uploading, the signatures of its transactions are read from txid2sigMap
+// before returning
+func (t *Tier) Archive(blk *modbtypes.Block, txid2sigMap map[[32]byte][65]byte) {
+	data, err := NewBlock(blk, txid2sigMap).Encode()
+	if err != nil {
+		t.logger.Error("failed to encode block for the cold store", "height", blk.Height, "error", err)
+		return
+	}
+	select {
+	case t.uploads <- upload{height: blk.Height, data: data}:
+	default:
+		t.logger.Error("too many blocks waiting for the cold store, block dropped", "height", blk.Height)
+	}
+}
+
+// uploadLoop retries each upload until it succeeds, so the blocks are archived in order
+func (t *Tier) uploadLoop() {
+	defer close(t.done)
+	for u := range t.uploads {
+		interval := retryInterval
+		for {
+			err := t.store.Put(BlockKey(u.height), u.data)
+			if err == nil {
+				break
+			}
+			t.logger.Error("failed to upload block to the cold store", "height", u.height, "error", err)
+			time.Sleep(interval)
+			if interval *= 2; interval > maxRetryInterval {
+				interval = maxRetryInterval
+			}
+		}
+	}
+}
+
+// Close waits until the queued blocks are uploaded
+func (t *Tier) Close() {
+	close(t.uploads)
+	<-t.done
+}
+
+// GetBlock returns ErrNotFound if the block is not archived
+func (t *Tier) GetBlock(height int64) (*Block, error) {
+	t.mtx.Lock()
+	blk := t.cache.get(height)
+	t.mtx.Unlock()
+	if blk != nil {
+		return blk, nil
+	}
+	data, err := t.store.Get(BlockKey(height))
+	if err != nil {
+		return nil, err
+	}
+	blk, err = DecodeBlock(data)
+	if err != nil {
+		return nil, err
+	}
+	t.mtx.Lock()
+	t.cache.add(height, blk)
+	t.mtx.Unlock()
+	return blk, nil
+}
+
+// blockCache is a LRU cache of the decoded blocks
+type blockCache struct {
+	size  int
+	order *list.List // front is the most recently used
+	items map[int64]*list.Element
+}
+
+type cacheEntry struct {
+	height int64
+	blk    *Block
+}
+
+func newBlockCache(size int) *blockCache {
+	return &blockCache{size: size, order: list.New(), items: make(map[int64]*list.Element)}
+}
+
+func (c *blockCache) get(height int64) *Block {
+	elem, ok := c.items[height]
+	if !ok {
+		return nil
+	}
+	c.order.MoveToFront(elem)
+	return elem.Value.(*cacheEntry).blk
+}
+
+func (c *blockCache) add(height int64, blk *Block) {
+	if c.size <= 0 {
+		return
+	}
+	if elem, ok := c.items[height]; ok {
+		elem.Value.(*cacheEntry).blk = blk
+		c.order.MoveToFront(elem)
+		return
+	}
+	c.items[height] = c.order.PushFront(&cacheEntry{height: height, blk: blk})
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*cacheEntry).height)
+	}
+}
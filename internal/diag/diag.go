@@ -0,0 +1,341 @@
+// Package diag samples the goroutines of the node by subsystem and the occupancy of the hot
+// channels, and flags the suspected leaks and stalls. A subsystem is suspected to leak when its
+// goroutines grow in every sample of a window, and a channel is stalled when it is full, or grows,
+// in every sample of a window. The subsystem of a goroutine is the package of the function which
+// created it, so no goroutine has to be annotated.
+package diag
+
+import (
+	"bytes"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/tendermint/tendermint/libs/log"
+)
+
+const (
+	SampleInterval = time.Minute
+	// the number of the consecutive samples showing a growth before a leak or a stall is flagged
+	LeakWindow  = 6
+	StallWindow = 3
+	// a goroutine blocked longer than this is counted as long-blocked, the Go runtime reports the
+	// blocked time in minutes
+	LongBlockedMinutes = 10
+
+	modulePrefix = "github.com/smartbch/smartbch/"
+	maxStackSize = 64 << 20
+)
+
+// knownPrefixes maps the packages of the dependencies to their subsystems
+var knownPrefixes = []struct{ prefix, subsystem string }{
+	{"github.com/tendermint/tendermint/", "tendermint"},
+	{"github.com/ethereum/go-ethereum/", "go-ethereum"},
+	{"github.com/smartbch/moeingevm/", "moeingevm"},
+	{"github.com/smartbch/moeingads/", "moeingads"},
+	{"github.com/smartbch/moeingdb/", "moeingdb"},
+	{"net/http.", "http"},
+}
+
+// SubsystemStat is the goroutines created by the functions of a subsystem
+type SubsystemStat struct {
+	Name          string `json:"name"`
+	Goroutines    int    `json:"goroutines"`
+	LongBlocked   int    `json:"longBlocked"`
+	MaxBlockedMin int    `json:"maxBlockedMinutes"`
+	SuspectedLeak bool   `json:"suspectedLeak"`
+}
+
+// ChannelStat is the occupancy of a registered channel
+type ChannelStat struct {
+	Name    string `json:"name"`
+	Len     int    `json:"len"`
+	Cap     int    `json:"cap"`
+	Stalled bool   `json:"stalled"`
+}
+
+type Report struct {
+	Time         int64           `json:"time"`
+	NumGoroutine int             `json:"numGoroutine"`
+	Subsystems   []SubsystemStat `json:"subsystems"`
+	Channels     []ChannelStat   `json:"channels"`
+}
+
+// ChannelProbe returns the length and the capacity of a channel
+type ChannelProbe func() (length, capacity int)
+
+var (
+	goroutinesGauge = newGaugeVec("goroutines",
+		"The number of goroutines created by the functions of each subsystem.", "subsystem")
+	suspectedLeakGauge = newGaugeVec("suspected_leak",
+		"Whether the goroutines of the subsystem grow in every sample of the leak window, 1 for yes.", "subsystem")
+	channelLenGauge = newGaugeVec("channel_len",
+		"The number of elements queued in the channel.", "channel")
+	channelStalledGauge = newGaugeVec("channel_stalled",
+		"Whether the channel is full or grows in every sample of the stall window, 1 for yes.", "channel")
+)
+
+func newGaugeVec(name, help string, labels ...string) *prometheus.GaugeVec {
+	g := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "smartbch",
+		Subsystem: "diag",
+		Name:      name,
+		Help:      help,
+	}, labels)
+	prometheus.MustRegister(g)
+	return g
+}
+
+// Monitor keeps the recent samples, it is safe for concurrent use
+type Monitor struct {
+	mtx           sync.Mutex
+	channels      map[string]ChannelProbe
+	goroutineHist map[string][]int // the counts in the last LeakWindow+1 samples
+	channelHist   map[string][]int // the lengths in the last StallWindow samples
+	latest        *Report
+	startOnce     sync.Once
+}
+
+func NewMonitor() *Monitor {
+	return &Monitor{
+		channels:      make(map[string]ChannelProbe),
+		goroutineHist: make(map[string][]int),
+		channelHist:   make(map[string][]int),
+	}
+}
+
+var defaultMonitor = NewMonitor()
+
+// RegisterChannel adds a channel to the default monitor, a channel registered again with the same
+// name replaces the old one
+func RegisterChannel(name string, probe ChannelProbe) {
+	defaultMonitor.RegisterChannel(name, probe)
+}
+
+// Start samples with the default monitor every SampleInterval, it only starts once
+func Start(logger log.Logger) {
+	defaultMonitor.Start(logger)
+}
+
+// Latest returns the report of the default monitor
+func Latest() *Report {
+	return defaultMonitor.Latest()
+}
+
+func (m *Monitor) RegisterChannel(name string, probe ChannelProbe) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	m.channels[name] = probe
+	delete(m.channelHist, name)
+}
+
+func (m *Monitor) Start(logger log.Logger) {
+	m.startOnce.Do(func() {
+		go func() {
+			for {
+				m.logFlags(logger, m.Sample(dumpGoroutines()))
+				time.Sleep(SampleInterval)
+			}
+		}()
+	})
+}
+
+// Latest returns the report of the last sample, or a report of the current state which is not
+// added to the history if nothing is sampled yet
+func (m *Monitor) Latest() *Report {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	if m.latest != nil {
+		return m.latest
+	}
+	report := &Report{Time: time.Now().Unix()}
+	stats := parseGoroutines(dumpGoroutines())
+	for _, name := range sortedSubsystems(stats) {
+		report.NumGoroutine += stats[name].Goroutines
+		report.Subsystems = append(report.Subsystems, *stats[name])
+	}
+	for _, name := range sortedChannels(m.channels) {
+		length, capacity := m.channels[name]()
+		report.Channels = append(report.Channels, ChannelStat{Name: name, Len: length, Cap: capacity})
+	}
+	return report
+}
+
+// Sample adds a sample of the goroutine dump and the registered channels to the history, and
+// returns the report with the flags
+func (m *Monitor) Sample(dump []byte) *Report {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	report := &Report{Time: time.Now().Unix()}
+	stats := parseGoroutines(dump)
+	for name := range m.goroutineHist {
+		if stats[name] == nil {
+			stats[name] = &SubsystemStat{Name: name}
+		}
+	}
+	for _, name := range sortedSubsystems(stats) {
+		stat := stats[name]
+		hist := appendSample(m.goroutineHist[name], stat.Goroutines, LeakWindow+1)
+		m.goroutineHist[name] = hist
+		stat.SuspectedLeak = len(hist) == LeakWindow+1 && strictlyIncreasing(hist)
+		report.NumGoroutine += stat.Goroutines
+		report.Subsystems = append(report.Subsystems, *stat)
+		goroutinesGauge.WithLabelValues(name).Set(float64(stat.Goroutines))
+		suspectedLeakGauge.WithLabelValues(name).Set(boolToFloat(stat.SuspectedLeak))
+	}
+	for _, name := range sortedChannels(m.channels) {
+		length, capacity := m.channels[name]()
+		hist := appendSample(m.channelHist[name], length, StallWindow)
+		m.channelHist[name] = hist
+		stat := ChannelStat{Name: name, Len: length, Cap: capacity}
+		stat.Stalled = len(hist) == StallWindow && (allFull(hist, capacity) || strictlyIncreasing(hist))
+		report.Channels = append(report.Channels, stat)
+		channelLenGauge.WithLabelValues(name).Set(float64(length))
+		channelStalledGauge.WithLabelValues(name).Set(boolToFloat(stat.Stalled))
+	}
+	m.latest = report
+	return report
+}
+
+func (m *Monitor) logFlags(logger log.Logger, report *Report) {
+	for _, s := range report.Subsystems {
+		if s.SuspectedLeak {
+			logger.Error("suspected goroutine leak", "subsystem", s.Name, "goroutines", s.Goroutines)
+		}
+	}
+	for _, c := range report.Channels {
+		if c.Stalled {
+			logger.Error("suspected stalled channel", "channel", c.Name, "len", c.Len, "cap", c.Cap)
+		}
+	}
+}
+
+func dumpGoroutines() []byte {
+	buf := make([]byte, 1<<20)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) || len(buf) >= maxStackSize {
+			return buf[:n]
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+}
+
+var headerRegexp = regexp.MustCompile(`^goroutine \d+ \[([^,\]]+)(?:, (\d+) minutes)?`)
+
+// parseGoroutines counts the goroutines in the dump of runtime.Stack by subsystem
+func parseGoroutines(dump []byte) map[string]*SubsystemStat {
+	stats := make(map[string]*SubsystemStat)
+	for _, block := range bytes.Split(dump, []byte("\n\n")) {
+		lines := strings.Split(strings.TrimSpace(string(block)), "\n")
+		match := headerRegexp.FindStringSubmatch(lines[0])
+		if match == nil {
+			continue
+		}
+		creator := ""
+		for _, line := range lines[1:] {
+			if strings.HasPrefix(line, "created by ") {
+				creator = strings.TrimPrefix(line, "created by ")
+				if i := strings.Index(creator, " in goroutine "); i >= 0 {
+					creator = creator[:i]
+				}
+			}
+		}
+		name := subsystemOf(creator)
+		stat := stats[name]
+		if stat == nil {
+			stat = &SubsystemStat{Name: name}
+			stats[name] = stat
+		}
+		stat.Goroutines++
+		if minutes, _ := strconv.Atoi(match[2]); minutes > 0 {
+			if minutes >= LongBlockedMinutes {
+				stat.LongBlocked++
+			}
+			if minutes > stat.MaxBlockedMin {
+				stat.MaxBlockedMin = minutes
+			}
+		}
+	}
+	return stats
+}
+
+// subsystemOf returns the subsystem of the function creating a goroutine, which is the first
+// element of its package path in this module
+func subsystemOf(creator string) string {
+	if creator == "" {
+		return "main"
+	}
+	if strings.HasPrefix(creator, modulePrefix) {
+		pkg := strings.TrimPrefix(creator, modulePrefix)
+		if i := strings.IndexAny(pkg, "/."); i >= 0 {
+			pkg = pkg[:i]
+		}
+		return pkg
+	}
+	for _, p := range knownPrefixes {
+		if strings.HasPrefix(creator, p.prefix) {
+			return p.subsystem
+		}
+	}
+	return "other"
+}
+
+func appendSample(hist []int, v, n int) []int {
+	hist = append(hist, v)
+	if len(hist) > n {
+		hist = hist[len(hist)-n:]
+	}
+	return hist
+}
+
+func strictlyIncreasing(hist []int) bool {
+	for i := 1; i < len(hist); i++ {
+		if hist[i] <= hist[i-1] {
+			return false
+		}
+	}
+	return true
+}
+
+func allFull(hist []int, capacity int) bool {
+	if capacity == 0 {
+		return false
+	}
+	for _, v := range hist {
+		if v < capacity {
+			return false
+		}
+	}
+	return true
+}
+
+func boolToFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func sortedSubsystems(stats map[string]*SubsystemStat) []string {
+	names := make([]string, 0, len(stats))
+	for name := range stats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func sortedChannels(channels map[string]ChannelProbe) []string {
+	names := make([]string, 0, len(channels))
+	for name := range channels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
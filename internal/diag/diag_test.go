@@ -0,0 +1,84 @@
+package diag
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testDump = `goroutine 1 [running]:
+main.main()
+	/src/main.go:10 +0x1d
+
+goroutine 18 [chan receive, 12 minutes]:
+github.com/smartbch/smartbch/watcher.(*Watcher).waitForNewBlock(0xc000)
+	/src/watcher/watcher.go:300 +0x5a
+created by github.com/smartbch/smartbch/watcher.(*Watcher).goRun in goroutine 1
+	/src/watcher/lifecycle.go:70 +0x7a
+
+goroutine 19 [select, 3 minutes]:
+github.com/smartbch/smartbch/rpc/api/filters.(*EventSystem).eventLoop(0xc001)
+	/src/rpc/api/filters/geth_filter_system.go:500 +0x1
+created by github.com/smartbch/smartbch/rpc/api/filters.NewEventSystem
+	/src/rpc/api/filters/geth_filter_system.go:138 +0x2
+
+goroutine 20 [IO wait]:
+internal/poll.runtime_pollWait(0x7f)
+	/go/src/runtime/netpoll.go:343 +0x85
+created by github.com/tendermint/tendermint/p2p.(*Switch).OnStart
+	/pkg/tendermint/p2p/switch.go:230 +0x1
+`
+
+func TestParseGoroutines(t *testing.T) {
+	stats := parseGoroutines([]byte(testDump))
+	require.Len(t, stats, 4)
+	require.Equal(t, 1, stats["main"].Goroutines)
+	require.Equal(t, 1, stats["watcher"].Goroutines)
+	require.Equal(t, 1, stats["watcher"].LongBlocked)
+	require.Equal(t, 12, stats["watcher"].MaxBlockedMin)
+	require.Equal(t, 1, stats["rpc"].Goroutines)
+	require.Equal(t, 0, stats["rpc"].LongBlocked)
+	require.Equal(t, 1, stats["tendermint"].Goroutines)
+	require.Equal(t, "other", subsystemOf("github.com/foo/bar.Run"))
+}
+
+func buildDump(n int) []byte {
+	var sb strings.Builder
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&sb, "goroutine %d [select]:\nfoo()\ncreated by github.com/smartbch/smartbch/app.NewApp\n\n", i+2)
+	}
+	return []byte(sb.String())
+}
+
+func TestSuspectedLeak(t *testing.T) {
+	m := NewMonitor()
+	for i := 1; i <= LeakWindow; i++ {
+		report := m.Sample(buildDump(i))
+		require.False(t, report.Subsystems[0].SuspectedLeak)
+	}
+	report := m.Sample(buildDump(LeakWindow + 1))
+	require.True(t, report.Subsystems[0].SuspectedLeak)
+	require.Equal(t, LeakWindow+1, report.NumGoroutine)
+	report = m.Sample(buildDump(LeakWindow + 1))
+	require.False(t, report.Subsystems[0].SuspectedLeak)
+}
+
+func TestStalledChannel(t *testing.T) {
+	m := NewMonitor()
+	ch := make(chan int, 2)
+	m.RegisterChannel("test", func() (int, int) { return len(ch), cap(ch) })
+	ch <- 1
+	ch <- 2
+	for i := 1; i < StallWindow; i++ {
+		require.False(t, m.Sample(nil).Channels[0].Stalled)
+	}
+	report := m.Sample(nil)
+	require.True(t, report.Channels[0].Stalled)
+	require.Equal(t, ChannelStat{Name: "test", Len: 2, Cap: 2, Stalled: true}, report.Channels[0])
+	require.Equal(t, report, m.Latest())
+
+	<-ch
+	require.False(t, m.Sample(nil).Channels[0].Stalled)
+}
@@ -31,6 +31,8 @@ func EncodeTx(tx *types.Transaction) ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
+// DecodeTx is lenient and only used for the transactions already committed in blocks.
+// Use txcodec to decode and validate the newly received transactions.
 func DecodeTx(data []byte) (*types.Transaction, error) {
 	tx := &types.Transaction{}
 	err := tx.DecodeRLP(rlp.NewStream(bytes.NewReader(data), 0))
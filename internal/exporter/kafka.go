@@ -0,0 +1,79 @@
+package exporter
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// KafkaRestSink publishes the messages through the REST proxy of Kafka (API v2), which returns
+// after the records are written to the topic
+type KafkaRestSink struct {
+	baseUrl string
+	client  *http.Client
+}
+
+func NewKafkaRestSink(baseUrl string) *KafkaRestSink {
+	return &KafkaRestSink{
+		baseUrl: baseUrl,
+		client:  &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+type kafkaRecord struct {
+	Key   []byte `json:"key"` // encoded in base64, as required by the binary embedded format
+	Value []byte `json:"value"`
+}
+
+type kafkaProduceResp struct {
+	Offsets []struct {
+		ErrorCode *int   `json:"error_code"`
+		Error     string `json:"error"`
+	} `json:"offsets"`
+}
+
+func (s *KafkaRestSink) Publish(topic string, msgs []Message) error {
+	if len(msgs) == 0 {
+		return nil
+	}
+	records := make([]kafkaRecord, len(msgs))
+	for i, msg := range msgs {
+		records[i] = kafkaRecord{Key: []byte(msg.Key), Value: msg.Value}
+	}
+	body, _ := json.Marshal(map[string]interface{}{"records": records})
+	req, err := http.NewRequest(http.MethodPost, s.baseUrl+"/topics/"+topic, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/vnd.kafka.binary.v2+json")
+	req.Header.Set("Accept", "application/vnd.kafka.v2+json")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("kafka rest proxy returns %d: %s", resp.StatusCode, respBody)
+	}
+	var produceResp kafkaProduceResp
+	if err = json.Unmarshal(respBody, &produceResp); err != nil {
+		return err
+	}
+	for _, offset := range produceResp.Offsets {
+		if offset.ErrorCode != nil {
+			return fmt.Errorf("kafka error %d: %s", *offset.ErrorCode, offset.Error)
+		}
+	}
+	return nil
+}
+
+func (s *KafkaRestSink) Close() error {
+	return nil
+}
@@ -0,0 +1,88 @@
+package exporter
+
+import (
+	"encoding/json"
+	"strconv"
+
+	gethcmn "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+
+	motypes "github.com/smartbch/moeingevm/types"
+
+	"github.com/smartbch/smartbch/internal/bigutils"
+)
+
+// BlockMessage is published to the blocks topic
+type BlockMessage struct {
+	Number     hexutil.Uint64 `json:"number"`
+	Hash       gethcmn.Hash   `json:"hash"`
+	ParentHash gethcmn.Hash   `json:"parentHash"`
+	StateRoot  gethcmn.Hash   `json:"stateRoot"`
+	Proposer   hexutil.Bytes  `json:"proposer"`
+	Timestamp  hexutil.Uint64 `json:"timestamp"`
+	GasUsed    hexutil.Uint64 `json:"gasUsed"`
+	TxCount    int            `json:"txCount"`
+}
+
+// TxMessage is published to the txs topic, with the hash as its key
+type TxMessage struct {
+	BlockNumber      hexutil.Uint64   `json:"blockNumber"`
+	TransactionIndex hexutil.Uint64   `json:"transactionIndex"`
+	Hash             gethcmn.Hash     `json:"hash"`
+	From             gethcmn.Address  `json:"from"`
+	To               *gethcmn.Address `json:"to"`
+	ContractAddress  *gethcmn.Address `json:"contractAddress"`
+	Value            *hexutil.Big     `json:"value"`
+	Nonce            hexutil.Uint64   `json:"nonce"`
+	GasUsed          hexutil.Uint64   `json:"gasUsed"`
+	Status           hexutil.Uint64   `json:"status"`
+	LogCount         int              `json:"logCount"`
+}
+
+func blockMessage(blk *motypes.Block, txCount int) Message {
+	bz, _ := json.Marshal(BlockMessage{
+		Number:     hexutil.Uint64(blk.Number),
+		Hash:       blk.Hash,
+		ParentHash: blk.ParentHash,
+		StateRoot:  blk.StateRoot,
+		Proposer:   blk.Miner[:],
+		Timestamp:  hexutil.Uint64(blk.Timestamp),
+		GasUsed:    hexutil.Uint64(blk.GasUsed),
+		TxCount:    txCount,
+	})
+	return Message{Key: strconv.FormatInt(blk.Number, 10), Value: bz}
+}
+
+// txMessages returns the messages of the transactions and their logs, the logs are in the format
+// of eth_getLogs and keyed by the hashes of their transactions
+func txMessages(txs []*motypes.Transaction) (txMsgs, logMsgs []Message) {
+	for _, tx := range txs {
+		msg := TxMessage{
+			BlockNumber:      hexutil.Uint64(tx.BlockNumber),
+			TransactionIndex: hexutil.Uint64(tx.TransactionIndex),
+			Hash:             tx.Hash,
+			From:             tx.From,
+			Value:            (*hexutil.Big)(bigutils.U256FromSlice32(tx.Value[:]).ToBig()),
+			Nonce:            hexutil.Uint64(tx.Nonce),
+			GasUsed:          hexutil.Uint64(tx.GasUsed),
+			Status:           hexutil.Uint64(tx.Status),
+			LogCount:         len(tx.Logs),
+		}
+		if tx.To != [20]byte{} {
+			to := gethcmn.Address(tx.To)
+			msg.To = &to
+		}
+		if tx.ContractAddress != [20]byte{} {
+			addr := gethcmn.Address(tx.ContractAddress)
+			msg.ContractAddress = &addr
+		}
+		key := gethcmn.Hash(tx.Hash).Hex()
+		bz, _ := json.Marshal(msg)
+		txMsgs = append(txMsgs, Message{Key: key, Value: bz})
+		for _, l := range motypes.ToGethLogs(tx.Logs) {
+			bz, _ = json.Marshal(l)
+			logMsgs = append(logMsgs, Message{Key: key, Value: bz})
+		}
+	}
+	return
+}
@@ -0,0 +1,124 @@
+package exporter
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const natsTimeout = 10 * time.Second
+
+// NatsSink publishes the messages with the text protocol of NATS. After the messages of a
+// Publish are sent, it waits for the PONG of a PING, which means the server has processed them.
+type NatsSink struct {
+	addr string
+	user string
+	pass string
+
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+func NewNatsSink(u *url.URL) *NatsSink {
+	s := &NatsSink{addr: u.Host}
+	if u.User != nil {
+		s.user = u.User.Username()
+		s.pass, _ = u.User.Password()
+	}
+	return s
+}
+
+func (s *NatsSink) Publish(topic string, msgs []Message) error {
+	if len(msgs) == 0 {
+		return nil
+	}
+	if s.conn == nil {
+		if err := s.connect(); err != nil {
+			return err
+		}
+	}
+	err := s.publish(topic, msgs)
+	if err != nil {
+		// reconnect in the next Publish
+		_ = s.conn.Close()
+		s.conn = nil
+	}
+	return err
+}
+
+func (s *NatsSink) publish(topic string, msgs []Message) error {
+	_ = s.conn.SetDeadline(time.Now().Add(natsTimeout))
+	w := bufio.NewWriter(s.conn)
+	for _, msg := range msgs {
+		fmt.Fprintf(w, "PUB %s %d\r\n", topic, len(msg.Value))
+		_, _ = w.Write(msg.Value)
+		_, _ = w.WriteString("\r\n")
+	}
+	_, _ = w.WriteString("PING\r\n")
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	return s.waitPong()
+}
+
+func (s *NatsSink) connect() error {
+	conn, err := net.DialTimeout("tcp", s.addr, natsTimeout)
+	if err != nil {
+		return err
+	}
+	_ = conn.SetDeadline(time.Now().Add(natsTimeout))
+	s.conn, s.reader = conn, bufio.NewReader(conn)
+	line, err := s.reader.ReadString('\n')
+	if err == nil && !strings.HasPrefix(line, "INFO ") {
+		err = fmt.Errorf("unexpected greeting from nats: %q", line)
+	}
+	if err == nil {
+		options, _ := json.Marshal(map[string]interface{}{
+			"verbose":  false,
+			"pedantic": false,
+			"name":     "smartbchd",
+			"user":     s.user,
+			"pass":     s.pass,
+		})
+		_, err = fmt.Fprintf(conn, "CONNECT %s\r\nPING\r\n", options)
+	}
+	if err == nil {
+		err = s.waitPong()
+	}
+	if err != nil {
+		_ = conn.Close()
+		s.conn = nil
+	}
+	return err
+}
+
+func (s *NatsSink) waitPong() error {
+	for {
+		line, err := s.reader.ReadString('\n')
+		if err != nil {
+			return err
+		}
+		switch {
+		case strings.HasPrefix(line, "PONG"):
+			return nil
+		case strings.HasPrefix(line, "PING"):
+			if _, err = s.conn.Write([]byte("PONG\r\n")); err != nil {
+				return err
+			}
+		case strings.HasPrefix(line, "-ERR"):
+			return errors.New("nats: " + strings.TrimSpace(line))
+		}
+	}
+}
+
+func (s *NatsSink) Close() error {
+	if s.conn == nil {
+		return nil
+	}
+	return s.conn.Close()
+}
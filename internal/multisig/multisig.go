@@ -0,0 +1,182 @@
+// Package multisig guards the privileged RPC of a node with the signatures of its operators.
+// An operation is a JSON payload signed offline by at least M of the N configured operators,
+// with personal_sign (EIP-191), so any wallet can sign it. The payload carries the chain id, a
+// nonce which must be greater than the nonce of any operation accepted before, and an expiry
+// time, such that a submitted operation can not be replayed on this node or on another chain.
+package multisig
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	gethacc "github.com/ethereum/go-ethereum/accounts"
+	gethcmn "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/smartbch/smartbch/internal/audit"
+)
+
+// An operation can not be signed more than MaxLifetime seconds before its expiry, which limits
+// the time a leaked payload with enough signatures is usable
+const MaxLifetime = 24 * 3600
+
+var (
+	ErrExpired          = errors.New("admin operation expired")
+	ErrLifetimeTooLong  = fmt.Errorf("admin operation expires more than %d seconds later", MaxLifetime)
+	ErrWrongChainId     = errors.New("admin operation is for another chain")
+	ErrNonceUsed        = errors.New("admin operation nonce has been used")
+	ErrNotEnoughSigners = errors.New("admin operation is not signed by enough operators")
+)
+
+// Op is the payload of an admin operation
+type Op struct {
+	Action  string            `json:"action"`
+	Params  map[string]string `json:"params,omitempty"`
+	ChainId uint64            `json:"chainId"`
+	Nonce   uint64            `json:"nonce"`
+	Expiry  int64             `json:"expiry"` // unix time in seconds
+}
+
+// SigHash returns the hash signed by personal_sign for payload
+func SigHash(payload []byte) []byte {
+	return gethacc.TextHash(payload)
+}
+
+// Sign signs payload with personal_sign
+func Sign(payload []byte, key []byte) ([]byte, error) {
+	privKey, err := crypto.ToECDSA(key)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := crypto.Sign(SigHash(payload), privKey)
+	if err != nil {
+		return nil, err
+	}
+	sig[64] += 27 // the V of personal_sign is 27 or 28
+	return sig, nil
+}
+
+func recoverSigner(payload, sig []byte) (gethcmn.Address, error) {
+	if len(sig) != crypto.SignatureLength {
+		return gethcmn.Address{}, errors.New("invalid signature length")
+	}
+	sig = gethcmn.CopyBytes(sig)
+	if sig[64] >= 27 {
+		sig[64] -= 27
+	}
+	pubkey, err := crypto.SigToPub(SigHash(payload), sig)
+	if err != nil {
+		return gethcmn.Address{}, err
+	}
+	return crypto.PubkeyToAddress(*pubkey), nil
+}
+
+// ParseSigners parses the comma-separated addresses of the operators
+func ParseSigners(s string) ([]gethcmn.Address, error) {
+	var signers []gethcmn.Address
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		if !gethcmn.IsHexAddress(item) {
+			return nil, fmt.Errorf("invalid admin signer: %s", item)
+		}
+		signers = append(signers, gethcmn.HexToAddress(item))
+	}
+	return signers, nil
+}
+
+// LastNonce returns the largest nonce of the admin operations recorded in the audit log
+func LastNonce(entries []*audit.Entry) uint64 {
+	var last uint64
+	for _, e := range entries {
+		if e.Source != audit.SourceMultisig {
+			continue
+		}
+		nonce, err := strconv.ParseUint(e.Details["nonce"], 10, 64)
+		if err == nil && nonce > last {
+			last = nonce
+		}
+	}
+	return last
+}
+
+// Guard verifies the admin operations, it is safe for concurrent use
+type Guard struct {
+	mtx       sync.Mutex
+	signers   map[gethcmn.Address]bool
+	threshold int
+	chainId   uint64
+	lastNonce uint64
+}
+
+func NewGuard(signers []gethcmn.Address, threshold int, chainId, lastNonce uint64) (*Guard, error) {
+	g := &Guard{
+		signers:   make(map[gethcmn.Address]bool, len(signers)),
+		threshold: threshold,
+		chainId:   chainId,
+		lastNonce: lastNonce,
+	}
+	for _, signer := range signers {
+		g.signers[signer] = true
+	}
+	if threshold <= 0 || threshold > len(g.signers) {
+		return nil, fmt.Errorf("admin threshold %d is not in [1, %d]", threshold, len(g.signers))
+	}
+	return g, nil
+}
+
+// Verify parses payload and checks its signatures, chain id, expiry and nonce. The nonce is used
+// up once the operation is verified, even if it fails to be taken later. The returned signers are
+// in the order of sigs.
+func (g *Guard) Verify(payload []byte, sigs [][]byte, now int64) (*Op, []gethcmn.Address, error) {
+	op := &Op{}
+	if err := json.Unmarshal(payload, op); err != nil {
+		return nil, nil, err
+	}
+	if op.ChainId != g.chainId {
+		return nil, nil, ErrWrongChainId
+	}
+	if op.Expiry <= now {
+		return nil, nil, ErrExpired
+	}
+	if op.Expiry > now+MaxLifetime {
+		return nil, nil, ErrLifetimeTooLong
+	}
+	var signers []gethcmn.Address
+	seen := make(map[gethcmn.Address]bool)
+	for _, sig := range sigs {
+		signer, err := recoverSigner(payload, sig)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !g.signers[signer] || seen[signer] {
+			continue
+		}
+		seen[signer] = true
+		signers = append(signers, signer)
+	}
+	if len(signers) < g.threshold {
+		return nil, nil, ErrNotEnoughSigners
+	}
+
+	g.mtx.Lock()
+	defer g.mtx.Unlock()
+	if op.Nonce <= g.lastNonce {
+		return nil, nil, ErrNonceUsed
+	}
+	g.lastNonce = op.Nonce
+	return op, signers, nil
+}
+
+// LastNonce returns the nonce of the last verified operation
+func (g *Guard) LastNonce() uint64 {
+	g.mtx.Lock()
+	defer g.mtx.Unlock()
+	return g.lastNonce
+}
@@ -0,0 +1,91 @@
+package multisig
+
+import (
+	"encoding/json"
+	"testing"
+
+	gethcmn "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+
+	"github.com/smartbch/smartbch/internal/audit"
+)
+
+func newKeys(t *testing.T, n int) (keys [][]byte, addrs []gethcmn.Address) {
+	for i := 0; i < n; i++ {
+		key, err := crypto.GenerateKey()
+		require.NoError(t, err)
+		keys = append(keys, crypto.FromECDSA(key))
+		addrs = append(addrs, crypto.PubkeyToAddress(key.PublicKey))
+	}
+	return
+}
+
+func signAll(t *testing.T, payload []byte, keys [][]byte) (sigs [][]byte) {
+	for _, key := range keys {
+		sig, err := Sign(payload, key)
+		require.NoError(t, err)
+		sigs = append(sigs, sig)
+	}
+	return
+}
+
+func TestGuard(t *testing.T) {
+	keys, addrs := newKeys(t, 4)
+	_, err := NewGuard(addrs[:3], 4, 10000, 0)
+	require.Error(t, err)
+	guard, err := NewGuard(addrs[:3], 2, 10000, 5)
+	require.NoError(t, err)
+
+	now := int64(1_700_000_000)
+	op := Op{Action: audit.ActionAddTracedAddress, Params: map[string]string{"address": "0x01"},
+		ChainId: 10000, Nonce: 6, Expiry: now + 60}
+	payload, _ := json.Marshal(op)
+
+	// one operator and one outsider
+	_, _, err = guard.Verify(payload, signAll(t, payload, [][]byte{keys[0], keys[3]}), now)
+	require.Equal(t, ErrNotEnoughSigners, err)
+	// the same operator twice
+	_, _, err = guard.Verify(payload, signAll(t, payload, [][]byte{keys[0], keys[0]}), now)
+	require.Equal(t, ErrNotEnoughSigners, err)
+	_, _, err = guard.Verify(payload, signAll(t, payload, keys[:2]), now+60)
+	require.Equal(t, ErrExpired, err)
+
+	verified, signers, err := guard.Verify(payload, signAll(t, payload, keys[:2]), now)
+	require.NoError(t, err)
+	require.Equal(t, op, *verified)
+	require.Equal(t, addrs[:2], signers)
+	require.EqualValues(t, 6, guard.LastNonce())
+
+	// replay
+	_, _, err = guard.Verify(payload, signAll(t, payload, keys[1:3]), now)
+	require.Equal(t, ErrNonceUsed, err)
+
+	op.Nonce = 7
+	op.ChainId = 10001
+	payload, _ = json.Marshal(op)
+	_, _, err = guard.Verify(payload, signAll(t, payload, keys[:2]), now)
+	require.Equal(t, ErrWrongChainId, err)
+
+	op.ChainId = 10000
+	op.Expiry = now + MaxLifetime + 1
+	payload, _ = json.Marshal(op)
+	_, _, err = guard.Verify(payload, signAll(t, payload, keys[:2]), now)
+	require.Equal(t, ErrLifetimeTooLong, err)
+}
+
+func TestParseSignersAndLastNonce(t *testing.T) {
+	signers, err := ParseSigners(" 0x0000000000000000000000000000000000000001,0x0000000000000000000000000000000000000002,")
+	require.NoError(t, err)
+	require.Len(t, signers, 2)
+	_, err = ParseSigners("0x01")
+	require.Error(t, err)
+
+	entries := []*audit.Entry{
+		{Source: audit.SourceMultisig, Details: map[string]string{"nonce": "3"}},
+		{Source: audit.SourceRpc, Details: map[string]string{"nonce": "9"}},
+		{Source: audit.SourceMultisig, Details: map[string]string{"nonce": "5"}},
+		{Source: audit.SourceMultisig, Details: map[string]string{"nonce": "4"}},
+	}
+	require.EqualValues(t, 5, LastNonce(entries))
+}
@@ -0,0 +1,102 @@
+package statediff
+
+import (
+	"encoding/binary"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/smartbch/moeingads/store/rabbit"
+	"github.com/smartbch/moeingevm/types"
+)
+
+const (
+	// the first bytes of the short keys are limited to this range, see moeingads/types.LimitRange
+	rabbitRangeStart = 64
+	rabbitRangeEnd   = 64 + 128
+
+	accountInfoLen = 49
+	valueKeyLen    = 1 + 8 + 32
+)
+
+// Exporter rebuilds a Snapshot from the entries of moeingads, which are the rabbit holes (see
+// moeingads/store/rabbit) holding the full keys and values of the state, at their short keys
+type Exporter struct {
+	snap    *Snapshot
+	storage map[uint64]map[common.Hash]hexutil.Bytes // by the sequence of the contract
+}
+
+func NewExporter(height int64) *Exporter {
+	return &Exporter{
+		snap: &Snapshot{
+			Height:   hexutil.Uint64(height),
+			Accounts: make(map[common.Address]*Account),
+		},
+		storage: make(map[uint64]map[common.Hash]hexutil.Bytes),
+	}
+}
+
+// Add decodes an entry of moeingads. The entries out of the rabbit range, such as the standby tx
+// queue and the guards, and the empty holes, are not a part of the state and skipped.
+func (e *Exporter) Add(shortKey, value []byte) {
+	if len(shortKey) != rabbit.KeySize || shortKey[0] < rabbitRangeStart || shortKey[0] >= rabbitRangeEnd {
+		return
+	}
+	cv := rabbit.BytesToCachedValue(value)
+	if cv == nil || cv.IsEmpty() {
+		return
+	}
+	key, val := cv.GetKey(), append([]byte{}, cv.GetValue()...)
+	switch {
+	case len(key) == 1+common.AddressLength && key[0] == types.ACCOUNT_KEY && len(val) == accountInfoLen:
+		info := types.NewAccountInfo(val)
+		acc := e.account(common.BytesToAddress(key[1:]))
+		acc.Nonce = hexutil.Uint64(info.Nonce())
+		acc.Balance = (*hexutil.Big)(info.Balance().ToBig())
+		acc.Sequence = hexutil.Uint64(info.Sequence())
+	case len(key) == 1+common.AddressLength && key[0] == types.BYTECODE_KEY && len(val) > 33:
+		info := types.NewBytecodeInfo(val)
+		e.account(common.BytesToAddress(key[1:])).CodeHash = common.BytesToHash(info.CodeHashSlice())
+	case len(key) == valueKeyLen && key[0] == types.VALUE_KEY:
+		seq := binary.BigEndian.Uint64(key[1:9])
+		if e.storage[seq] == nil {
+			e.storage[seq] = make(map[common.Hash]hexutil.Bytes)
+		}
+		e.storage[seq][common.BytesToHash(key[9:])] = val
+	default:
+		if e.snap.Others == nil {
+			e.snap.Others = make(map[string]hexutil.Bytes)
+		}
+		e.snap.Others[hexutil.Encode(key)] = val
+	}
+}
+
+func (e *Exporter) account(addr common.Address) *Account {
+	acc := e.snap.Accounts[addr]
+	if acc == nil {
+		acc = &Account{}
+		e.snap.Accounts[addr] = acc
+	}
+	return acc
+}
+
+// Snapshot returns the state added, the storage is moved to the accounts owning the sequences,
+// and the one of the sequences no account owns, such as the system contracts', is kept by sequence
+func (e *Exporter) Snapshot() *Snapshot {
+	owners := make(map[uint64]*Account)
+	for _, acc := range e.snap.Accounts {
+		if acc.CodeHash != (common.Hash{}) {
+			owners[uint64(acc.Sequence)] = acc
+		}
+	}
+	for seq, slots := range e.storage {
+		if acc, ok := owners[seq]; ok {
+			acc.Storage = slots
+			continue
+		}
+		if e.snap.Sequences == nil {
+			e.snap.Sequences = make(map[uint64]map[common.Hash]hexutil.Bytes)
+		}
+		e.snap.Sequences[seq] = slots
+	}
+	return e.snap
+}
@@ -0,0 +1,70 @@
+package statediff
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/require"
+
+	"github.com/smartbch/moeingads/store"
+	"github.com/smartbch/moeingads/store/rabbit"
+	"github.com/smartbch/moeingevm/types"
+)
+
+func TestExporter(t *testing.T) {
+	eoa := common.Address{0x01}
+	contract := common.Address{0x02}
+	slot := common.Hash{0x03}
+	sysSlot := common.Hash{0x04}
+	const seq, sysSeq = 7, 1000
+
+	root := store.NewMockRootStore()
+	r := rabbit.NewRabbitStore(root)
+	ctx := types.NewContext(&r, nil)
+	acc := types.ZeroAccountInfo()
+	acc.UpdateBalance(uint256.NewInt(100))
+	acc.UpdateNonce(2)
+	ctx.SetAccount(eoa, acc)
+	acc = types.ZeroAccountInfo()
+	acc.UpdateSequence(seq)
+	ctx.SetAccount(contract, acc)
+	codeHash := common.Hash{0xcc}
+	ctx.Rbt.Set(types.GetBytecodeKey(contract), append(append([]byte{0}, codeHash[:]...), 0x60, 0x80))
+	ctx.SetStorageAt(seq, string(slot[:]), []byte{0x05})
+	ctx.SetStorageAt(sysSeq, string(sysSlot[:]), []byte{0x06})
+	var shortKeys [][rabbit.KeySize]byte
+	r.ScanAllShortKeys(func(key [rabbit.KeySize]byte, dirty bool) bool {
+		shortKeys = append(shortKeys, key)
+		return false
+	})
+	ctx.Close(true)
+
+	exporter := NewExporter(10)
+	exporter.Add([]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x00}, []byte{0x01}) // standby tx queue
+	for _, key := range shortKeys {
+		exporter.Add(key[:], root.Get(key[:]))
+	}
+	snap := exporter.Snapshot()
+	require.EqualValues(t, 10, snap.Height)
+	require.Len(t, snap.Accounts, 2)
+	require.EqualValues(t, 2, snap.Accounts[eoa].Nonce)
+	require.Equal(t, big.NewInt(100), snap.Accounts[eoa].Balance.ToInt())
+	require.Empty(t, snap.Accounts[eoa].Storage)
+	require.Equal(t, codeHash, snap.Accounts[contract].CodeHash)
+	require.Equal(t, []byte{0x05}, []byte(snap.Accounts[contract].Storage[slot]))
+	require.Equal(t, []byte{0x06}, []byte(snap.Sequences[sysSeq][sysSlot]))
+	require.Empty(t, snap.Others)
+
+	other := NewExporter(10)
+	for _, key := range shortKeys {
+		other.Add(key[:], root.Get(key[:]))
+	}
+	otherSnap := other.Snapshot()
+	otherSnap.Sequences[sysSeq][sysSlot] = []byte{0x07}
+	diffs := Diff(snap, otherSnap)
+	require.Len(t, diffs, 1)
+	require.Equal(t, KindStorage, diffs[0].Kind)
+	require.EqualValues(t, sysSeq, *diffs[0].Seq)
+}
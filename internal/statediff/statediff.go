@@ -0,0 +1,198 @@
+package statediff
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// Account is the exported state of one account
+type Account struct {
+	Nonce    hexutil.Uint64                `json:"nonce"`
+	Balance  *hexutil.Big                  `json:"balance"`
+	Sequence hexutil.Uint64                `json:"sequence"`
+	CodeHash common.Hash                   `json:"codeHash,omitempty"`
+	Storage  map[common.Hash]hexutil.Bytes `json:"storage,omitempty"`
+}
+
+// Snapshot is the state of a node at some height, saved by "export-state"
+type Snapshot struct {
+	Height   hexutil.Uint64              `json:"height"`
+	Accounts map[common.Address]*Account `json:"accounts"`
+	// the storage of the sequences no account owns, such as the system contracts'
+	Sequences map[uint64]map[common.Hash]hexutil.Bytes `json:"sequences,omitempty"`
+	// the other entries, such as the creation counters and the current block, by hex key
+	Others map[string]hexutil.Bytes `json:"others,omitempty"`
+}
+
+func WriteSnapshot(file string, snap *Snapshot) error {
+	bz, err := json.Marshal(snap)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(file, bz, 0600)
+}
+
+func LoadSnapshot(file string) (*Snapshot, error) {
+	bz, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var snap Snapshot
+	if err = json.Unmarshal(bz, &snap); err != nil {
+		return nil, err
+	}
+	return &snap, nil
+}
+
+const (
+	KindMissingInA = "missing-in-a"
+	KindMissingInB = "missing-in-b"
+	KindNonce      = "nonce"
+	KindBalance    = "balance"
+	KindSequence   = "sequence"
+	KindCodeHash   = "code-hash"
+	KindStorage    = "storage"
+	KindOther      = "other"
+)
+
+// Difference records one mismatch between two snapshots. For storage mismatches, Slot is set, and
+// Seq is set instead of Address if no account owns the storage. For the other entries, Key is set.
+type Difference struct {
+	Address common.Address `json:"address"`
+	Kind    string         `json:"kind"`
+	Seq     *uint64        `json:"seq,omitempty"`
+	Slot    *common.Hash   `json:"slot,omitempty"`
+	Key     string         `json:"key,omitempty"`
+	A       string         `json:"a"`
+	B       string         `json:"b"`
+}
+
+// Diff compares two snapshots and returns the differences of the accounts sorted by address, then
+// the ones of the storage kept by sequence, then the ones of the other entries.
+// An absent storage slot or entry is treated as an empty value.
+func Diff(a, b *Snapshot) []*Difference {
+	var diffs []*Difference
+	for _, addr := range sortedAddresses(a, b) {
+		accA, accB := a.Accounts[addr], b.Accounts[addr]
+		if accA == nil {
+			diffs = append(diffs, &Difference{Address: addr, Kind: KindMissingInA, B: "exists"})
+			continue
+		}
+		if accB == nil {
+			diffs = append(diffs, &Difference{Address: addr, Kind: KindMissingInB, A: "exists"})
+			continue
+		}
+		diffs = append(diffs, diffAccount(addr, accA, accB)...)
+	}
+	for _, seq := range sortedSequences(a, b) {
+		seq := seq
+		for _, d := range diffStorage(a.Sequences[seq], b.Sequences[seq]) {
+			d.Seq = &seq
+			diffs = append(diffs, d)
+		}
+	}
+	keys := make([]string, 0, len(a.Others)+len(b.Others))
+	for key := range a.Others {
+		keys = append(keys, key)
+	}
+	for key := range b.Others {
+		if _, ok := a.Others[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		valA, valB := a.Others[key], b.Others[key]
+		if !bytes.Equal(valA, valB) {
+			diffs = append(diffs, &Difference{Kind: KindOther, Key: key, A: valA.String(), B: valB.String()})
+		}
+	}
+	return diffs
+}
+
+func diffAccount(addr common.Address, a, b *Account) (diffs []*Difference) {
+	if a.Nonce != b.Nonce {
+		diffs = append(diffs, &Difference{Address: addr, Kind: KindNonce, A: a.Nonce.String(), B: b.Nonce.String()})
+	}
+	if balanceString(a.Balance) != balanceString(b.Balance) {
+		diffs = append(diffs, &Difference{Address: addr, Kind: KindBalance, A: balanceString(a.Balance), B: balanceString(b.Balance)})
+	}
+	if a.Sequence != b.Sequence {
+		diffs = append(diffs, &Difference{Address: addr, Kind: KindSequence, A: a.Sequence.String(), B: b.Sequence.String()})
+	}
+	if a.CodeHash != b.CodeHash {
+		diffs = append(diffs, &Difference{Address: addr, Kind: KindCodeHash, A: a.CodeHash.Hex(), B: b.CodeHash.Hex()})
+	}
+	for _, d := range diffStorage(a.Storage, b.Storage) {
+		d.Address = addr
+		diffs = append(diffs, d)
+	}
+	return
+}
+
+func diffStorage(a, b map[common.Hash]hexutil.Bytes) (diffs []*Difference) {
+	slots := make([]common.Hash, 0, len(a)+len(b))
+	for slot := range a {
+		slots = append(slots, slot)
+	}
+	for slot := range b {
+		if _, ok := a[slot]; !ok {
+			slots = append(slots, slot)
+		}
+	}
+	sort.Slice(slots, func(i, j int) bool {
+		return bytes.Compare(slots[i][:], slots[j][:]) < 0
+	})
+	for i := range slots {
+		slot := slots[i]
+		valA, valB := a[slot], b[slot]
+		if !bytes.Equal(valA, valB) {
+			diffs = append(diffs, &Difference{Kind: KindStorage, Slot: &slot, A: valA.String(), B: valB.String()})
+		}
+	}
+	return
+}
+
+func balanceString(b *hexutil.Big) string {
+	if b == nil {
+		return "0x0"
+	}
+	return b.String()
+}
+
+func sortedAddresses(a, b *Snapshot) []common.Address {
+	addrs := make([]common.Address, 0, len(a.Accounts)+len(b.Accounts))
+	for addr := range a.Accounts {
+		addrs = append(addrs, addr)
+	}
+	for addr := range b.Accounts {
+		if _, ok := a.Accounts[addr]; !ok {
+			addrs = append(addrs, addr)
+		}
+	}
+	sort.Slice(addrs, func(i, j int) bool {
+		return bytes.Compare(addrs[i][:], addrs[j][:]) < 0
+	})
+	return addrs
+}
+
+func sortedSequences(a, b *Snapshot) []uint64 {
+	seqs := make([]uint64, 0, len(a.Sequences)+len(b.Sequences))
+	for seq := range a.Sequences {
+		seqs = append(seqs, seq)
+	}
+	for seq := range b.Sequences {
+		if _, ok := a.Sequences[seq]; !ok {
+			seqs = append(seqs, seq)
+		}
+	}
+	sort.Slice(seqs, func(i, j int) bool {
+		return seqs[i] < seqs[j]
+	})
+	return seqs
+}
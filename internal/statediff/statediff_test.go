@@ -0,0 +1,49 @@
+package statediff
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiff(t *testing.T) {
+	addr1 := common.Address{0x01}
+	addr2 := common.Address{0x02}
+	addr3 := common.Address{0x03}
+	slot1 := common.Hash{0x01}
+	slot2 := common.Hash{0x02}
+
+	a := &Snapshot{Accounts: map[common.Address]*Account{
+		addr1: {
+			Nonce:   1,
+			Balance: (*hexutil.Big)(big.NewInt(100)),
+			Storage: map[common.Hash]hexutil.Bytes{slot1: {0x01}, slot2: {0x02}},
+		},
+		addr2: {Nonce: 1},
+	}}
+	b := &Snapshot{Accounts: map[common.Address]*Account{
+		addr1: {
+			Nonce:   2,
+			Balance: (*hexutil.Big)(big.NewInt(100)),
+			Storage: map[common.Hash]hexutil.Bytes{slot1: {0x01}},
+		},
+		addr3: {Nonce: 1},
+	}}
+
+	diffs := Diff(a, b)
+	require.Len(t, diffs, 4)
+	require.Equal(t, KindNonce, diffs[0].Kind)
+	require.Equal(t, KindStorage, diffs[1].Kind)
+	require.Equal(t, slot2, *diffs[1].Slot)
+	require.Equal(t, "0x02", diffs[1].A)
+	require.Equal(t, "0x", diffs[1].B)
+	require.Equal(t, KindMissingInB, diffs[2].Kind)
+	require.Equal(t, addr2, diffs[2].Address)
+	require.Equal(t, KindMissingInA, diffs[3].Kind)
+	require.Equal(t, addr3, diffs[3].Address)
+
+	require.Len(t, Diff(a, a), 0)
+}
@@ -69,19 +69,23 @@ type TestAppInitArgs struct {
 	PrivKeys    []string
 	ArchiveMode bool
 	WithSyncDB  bool
+	// the number of recent blocks whose witnesses are kept, zero means disabled
+	WitnessKeptBlocks int64
+	// the file saving the state access heights, empty means disabled
+	StateAccessTrackingPath string
 }
 
 func CreateTestApp(keys ...string) *TestApp {
 	return createTestApp0(0, time.Now(), ed25519.GenPrivKey().PubKey(), bigutils.NewU256(DefaultInitBalance),
-		keys, false, false)
+		keys, false, false, 0, "")
 }
 func CreateTestAppInArchiveMode(keys ...string) *TestApp {
 	return createTestApp0(0, time.Now(), ed25519.GenPrivKey().PubKey(), bigutils.NewU256(DefaultInitBalance),
-		keys, true, false)
+		keys, true, false, 0, "")
 }
 func CreateTestAppWithSyncDB(keys ...string) *TestApp {
 	return createTestApp0(0, time.Now(), ed25519.GenPrivKey().PubKey(), bigutils.NewU256(DefaultInitBalance),
-		keys, true, true)
+		keys, true, true, 0, "")
 }
 
 func CreateTestAppWithArgs(args TestAppInitArgs) *TestApp {
@@ -106,11 +110,11 @@ func CreateTestAppWithArgs(args TestAppInitArgs) *TestApp {
 	}
 
 	return createTestApp0(startHeight, startTime, pubKey, initAmt, args.PrivKeys,
-		args.ArchiveMode, args.WithSyncDB)
+		args.ArchiveMode, args.WithSyncDB, args.WitnessKeptBlocks, args.StateAccessTrackingPath)
 }
 
 func createTestApp0(startHeight int64, startTime time.Time, valPubKey crypto.PubKey, initAmt *uint256.Int, keys []string,
-	archiveMode bool, withSyncDB bool) *TestApp {
+	archiveMode bool, withSyncDB bool, witnessKeptBlocks int64, stateAccessTrackingPath string) *TestApp {
 
 	err := os.RemoveAll(testAdsDir)
 	if err != nil {
@@ -123,9 +127,12 @@ func createTestApp0(startHeight int64, startTime time.Time, valPubKey crypto.Pub
 	params := param.DefaultConfig()
 	params.AppConfig.AppDataPath = testAdsDir
 	params.AppConfig.ModbDataPath = testMoDbDir
+	params.AppConfig.AuditLogPath = ""
 	params.AppConfig.SyncdbDataPath = testSyncDir
 	params.AppConfig.ArchiveMode = archiveMode
 	params.AppConfig.WithSyncDB = withSyncDB
+	params.AppConfig.WitnessKeptBlocks = witnessKeptBlocks
+	params.AppConfig.StateAccessTrackingPath = stateAccessTrackingPath
 	_app := app.NewApp(params, bigutils.NewU256(0x2711), 0, 0, nopLogger, true)
 	//_app.Init(nil)
 	//_app.txEngine = ebp.NewEbpTxExec(10, 100, 1, 100, _app.signer)
@@ -184,6 +191,7 @@ func (_app *TestApp) ReloadApp() *TestApp {
 	params := param.DefaultConfig()
 	params.AppConfig.AppDataPath = testAdsDir
 	params.AppConfig.ModbDataPath = testMoDbDir
+	params.AppConfig.AuditLogPath = ""
 	newApp := app.NewApp(params, bigutils.NewU256(1), 0, 0, nopLogger, true)
 	allBalance := uint256.NewInt(0)
 	if checkAllBalance {
@@ -9,8 +9,9 @@ import (
 )
 
 type MdbBlockBuilder struct {
-	block types.Block
-	txs   []types.Transaction
+	block    types.Block
+	txs      []types.Transaction
+	logCount int
 }
 
 func NewMdbBlockBuilder() *MdbBlockBuilder {
@@ -29,10 +30,13 @@ func (bb *MdbBlockBuilder) Hash(hash gethcmn.Hash) *MdbBlockBuilder {
 func (bb *MdbBlockBuilder) Tx(txHash gethcmn.Hash, logs ...types.Log) *MdbBlockBuilder {
 	bb.block.Transactions = append(bb.block.Transactions, txHash)
 
-	for _, log := range logs {
-		log.BlockNumber = uint64(bb.block.Number)
-		log.BlockHash = bb.block.Hash
-		log.TxHash = txHash
+	// like the executor, the index of a log is its position in the block
+	for i := range logs {
+		logs[i].BlockNumber = uint64(bb.block.Number)
+		logs[i].BlockHash = bb.block.Hash
+		logs[i].TxHash = txHash
+		logs[i].Index = uint(bb.logCount)
+		bb.logCount++
 	}
 	tx := types.Transaction{
 		BlockHash:   bb.block.Hash,
@@ -1,6 +1,7 @@
 package param
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 
@@ -17,17 +18,59 @@ const (
 	DefaultTrunkCacheSize          = 200
 	DefaultChangeRetainEveryN      = 100
 	DefaultPruneEveryN             = 10
+	DefaultIndexedLogTopics        = 4
+	DefaultWarmUpContracts         = 100
+	DefaultEpochGapThreshold       = 2
+	DefaultColdStoreCacheBlocks    = 1000
+	DefaultCallResultCacheSize     = 4096
+
+	DefaultMainnetRPCMaxRetryInterval = 60
+	DefaultWatcherSpeedupBatchSize    = 100
+	DefaultCcCollectInterval          = 1
+	DefaultMempoolScanInterval        = 10
+	DefaultEpochGossipFallbackAfter   = 600
+	DefaultWatcherAnalyticsRetainDays = 30
+
+	// the watcher regards a BCH block as finalized when it is buried under this number of blocks.
+	// The epochs are delivered later with a larger number, which must be much smaller than the
+	// blocks mined in StakingEpochSwitchDelay, otherwise the nodes may not switch the epochs at the
+	// same height.
+	DefaultBlockFinalizeNumber        = 1
+	DefaultMainnetBlockFinalizeNumber = 9
+	MinMainnetBlockFinalizeNumber     = 6
+	MaxBlockFinalizeNumber            = 50
+
+	MainnetChainId = 0x2710
 
 	AppDataPath    = "app"
 	ModbDataPath   = "modb"
 	SyncdbDataPath = "syncdb"
+	AuditLogPath   = "audit.log"
+
+	WatcherEpochSpillPath    = "watcher_epochs.spill"
+	WatcherSpeedupCursorPath = "watcher_speedup.cursor"
+	CcInfoJournalPath        = "cc_infos.journal"
+	WatcherAnalyticsPath     = "watcher_analytics.jsonl"
+	WatcherEpochArchivePath  = "watcher_epochs.archive"
+	DeterminismReportPath    = "determinism_reports.jsonl"
+)
+
+// The verbosity of the events emitted to tendermint for the transactions
+const (
+	TxEventsNone    = "none"    // no events
+	TxEventsCompact = "compact" // the hashes and results of the transactions, with the hashes of their logs
+	TxEventsFull    = "full"    // the hashes and results of the transactions, with all their logs
 )
 
 type AppConfig struct {
+	// the name of the profile used by init, see profile.go
+	Profile string `mapstructure:"profile"`
 	//app config:
 	AppDataPath    string `mapstructure:"app_data_path"`
 	ModbDataPath   string `mapstructure:"modb_data_path"`
 	SyncdbDataPath string `mapstructure:"syncdb_data_path"`
+	// the append-only log of the privileged operations, empty means disabled
+	AuditLogPath string `mapstructure:"audit_log_path"`
 	// rpc config
 	RpcEthGetLogsMaxResults int `mapstructure:"get_logs_max_results"`
 	// tm db config
@@ -52,12 +95,151 @@ type AppConfig struct {
 	MainnetRPCPassword string `mapstructure:"mainnet-rpc-password"`
 	SmartBchRPCUrl     string `mapstructure:"smartbch-rpc-url"`
 	Speedup            bool   `mapstructure:"watcher-speedup"`
+	// the max number of epochs fetched from smartbch-rpc-url in a request of the speedup
+	WatcherSpeedupBatchSize int64 `mapstructure:"watcher-speedup-batch-size"`
+	// the file keeping the epochs fetched by the speedup, from which a restarted speedup resumes,
+	// empty means the speedup always starts over
+	WatcherSpeedupCursorPath string `mapstructure:"watcher-speedup-cursor-path"`
+	// the file keeping the cc-transfer infos collected by the watcher which are not handled yet, from
+	// which a restarted node resumes the collection, empty means the collection always starts over
+	CcInfoJournalPath string `mapstructure:"cc-info-journal-path"`
+	// the seconds between the rounds collecting the cc-transfer infos of the rescan window
+	CcCollectInterval uint64 `mapstructure:"cc-collect-interval"`
+	// the max number of BCH blocks parsed in a round, the rest of the rescan window is left to the
+	// next rounds, zero means no limit
+	CcCollectMaxBlocksPerRound uint64 `mapstructure:"cc-collect-max-blocks-per-round"`
+	// the watcher also scans the mempool of the BCH node for the deposits to the covenant, which are
+	// served by sbch_getPendingCcDeposits before they are mined
+	WatcherScanMempool bool `mapstructure:"watcher-scan-mempool"`
+	// the seconds between the scans of the mempool
+	MempoolScanInterval uint64 `mapstructure:"mempool-scan-interval"`
+	// a validator serves the epochs published by its watcher, signed with its consensus key, to the
+	// validators whose BCH nodes are unreachable
+	EpochGossipServe bool `mapstructure:"epoch-gossip-serve"`
+	// the smartBCH rpc urls of the validators serving the signed epochs, from which the epochs
+	// signed by more than 2/3 of the voting power are taken when the BCH node is unreachable
+	EpochGossipPeers []string `mapstructure:"epoch-gossip-peers"`
+	// the seconds the BCH node is unreachable before the gossiped epochs are taken
+	EpochGossipFallbackAfter uint64 `mapstructure:"epoch-gossip-fallback-after"`
+	// if not empty, the watcher balances its requests among these urls and mainnet-rpc-url is ignored
+	MainnetRPCUrls []string `mapstructure:"mainnet-rpc-urls"`
+
+	// the ZMQ publisher of the BCH node, such as tcp://127.0.0.1:28332, empty means the watcher
+	// polls the BCH node for new blocks
+	MainnetZmqUrl string `mapstructure:"mainnet-zmq-url"`
+	// the BCH node keeping all the blocks, from which the watcher reads the blocks pruned by the
+	// nodes of mainnet-rpc-url(s), empty means these nodes are not pruned
+	MainnetArchiveRPCUrl string `mapstructure:"mainnet-archive-rpc-url"`
+
+	// the max number of requests sent to each BCH node per second, zero means unlimited
+	MainnetRPCRateLimit float64 `mapstructure:"mainnet-rpc-rate-limit"`
+	// the failed requests to the BCH nodes are retried with exponential backoff up to this interval, in seconds
+	MainnetRPCMaxRetryInterval int64 `mapstructure:"mainnet-rpc-max-retry-interval"`
+	// the proxy for the watcher's connections, http://, https:// or socks5:// (e.g. Tor)
+	WatcherProxy string `mapstructure:"watcher-proxy"`
+	// the file to which the watcher spills the epochs not read by the app in time, empty means
+	// they are kept in memory
+	WatcherEpochSpillPath string `mapstructure:"watcher-epoch-spill-path"`
+	// the file keeping the latencies of the BCH blocks seen by the watcher and the reorgs, for
+	// sbch_getWatcherAnalytics, empty means they are kept in memory
+	WatcherAnalyticsPath string `mapstructure:"watcher-analytics-path"`
+	// the days the watcher analytics are kept, zero means forever
+	WatcherAnalyticsRetainDays uint64 `mapstructure:"watcher-analytics-retain-days"`
+	// the file keeping all the epochs and monitor votes delivered by the watcher, empty means only
+	// the recent ones are kept in memory
+	WatcherEpochArchivePath string `mapstructure:"watcher-epoch-archive-path"`
+	// the number of the latest epochs kept in the archive, zero means all
+	WatcherEpochArchiveKeep uint64 `mapstructure:"watcher-epoch-archive-keep"`
+	// the directory of the BCH blocks exported by "smartbchd export-bch-blocks", which the watcher
+	// replays instead of connecting the BCH nodes, empty means disabled
+	WatcherReplayDir string `mapstructure:"watcher-replay-dir"`
+	// the epoch snapshot exported by "smartbchd export-epoch-snapshot" and its sha256 hash, which the
+	// watcher loads at startup instead of building the epochs in it from the BCH blocks
+	WatcherSnapshotPath string `mapstructure:"watcher-snapshot-path"`
+	WatcherSnapshotHash string `mapstructure:"watcher-snapshot-hash"`
 
 	FrontierGasLimit uint64 `mapstructure:"frontier-gaslimit"`
 
 	ArchiveMode bool `mapstructure:"archive-mode"`
 
+	// in safe mode the node serves the RPC queries from the committed local state without joining the
+	// consensus, accepting txs or starting the watcher, it is set by the --safe-mode flag of start
+	SafeMode bool `mapstructure:"safe-mode"`
+
 	WithSyncDB bool `mapstructure:"with-syncdb"`
+
+	// the indexes of moeingdb, they only take effect on the blocks committed after being changed
+	NoTxFromIndex    bool `mapstructure:"no-tx-from-index"`
+	NoTxToIndex      bool `mapstructure:"no-tx-to-index"`
+	IndexedLogTopics int  `mapstructure:"indexed-log-topics"`
+
+	// the URL to which validator voting power change events are POSTed, empty means disabled
+	ValidatorWebhookUrl string `mapstructure:"validator-webhook-url"`
+
+	// the number of recent blocks whose state access witnesses are kept in memory, zero means disabled
+	WitnessKeptBlocks int64 `mapstructure:"witness-kept-blocks"`
+
+	// the file saving the heights at which the state entries were last accessed, empty means disabled
+	StateAccessTrackingPath string `mapstructure:"state-access-tracking-path"`
+
+	// the verbosity of the tx events emitted to tendermint, one of "none", "compact" and "full"
+	TxEventVerbosity string `mapstructure:"tx-event-verbosity"`
+
+	// before opening RPC, read the recent blocks and the hot contracts touched by them into the
+	// caches, zero blocks means disabled
+	WarmUpBlocks    int64 `mapstructure:"warmup-blocks"`
+	WarmUpContracts int   `mapstructure:"warmup-contracts"`
+
+	// log an error when the watcher has delivered more epochs than this number which are not
+	// applied by the staking module, zero means disabled
+	EpochGapThreshold int64 `mapstructure:"epoch-gap-threshold"`
+
+	// in archive mode, serve the read RPC queries from the last committed version of moeingads,
+	// instead of the version which is being written by Commit
+	RpcSnapshotReads bool `mapstructure:"rpc-snapshot-reads"`
+
+	// the object store to which the blocks of moeingdb are copied, such that the ones pruned by
+	// blocks_kept_modb can still be served, empty means disabled
+	ColdStoreUrl         string `mapstructure:"cold-store-url"`
+	ColdStoreCacheBlocks int    `mapstructure:"cold-store-cache-blocks"`
+
+	// in archive mode, cache the results of eth_call and eth_estimateGas at the last n heights in
+	// memory, at most call-result-cache-size ones for each height, zero blocks means disabled
+	CallResultCacheBlocks int64 `mapstructure:"call-result-cache-blocks"`
+	CallResultCacheSize   int   `mapstructure:"call-result-cache-size"`
+
+	// the message queue to which the committed blocks, txs and logs are published, empty means
+	// disabled
+	ExporterUrl string `mapstructure:"exporter-url"`
+
+	// adjust the number of the goroutines executing the txs by the available CPUs and the measured
+	// conflict rate, instead of using EbpParallelNum
+	AdaptiveParallelism bool `mapstructure:"adaptive-parallelism"`
+
+	// the fraction of the blocks executed again with a single goroutine, whose results are compared
+	// with the parallel execution's, zero means disabled
+	DeterminismCheckRatio float64 `mapstructure:"determinism-check-ratio"`
+	// the file to which the differences found by the check are appended
+	DeterminismReportPath string `mapstructure:"determinism-report-path"`
+	// stop the node when a difference is found
+	DeterminismCheckHalt bool `mapstructure:"determinism-check-halt"`
+
+	// the comma-separated addresses of the operators, at least admin-threshold of whom must sign
+	// the privileged RPC operations, empty means the privileged RPC can be called directly
+	AdminSigners   string `mapstructure:"admin-signers"`
+	AdminThreshold int    `mapstructure:"admin-threshold"`
+
+	// refuse to propose blocks while the watcher lags the BCH mainnet by more than this number of
+	// blocks, zero means disabled
+	ProposeMaxWatcherLag int64 `mapstructure:"propose-max-watcher-lag"`
+
+	// the number of the BCH blocks on top of a block before the watcher regards it as finalized,
+	// zero means the default of the network (9 on mainnet, 1 on the others)
+	BlockFinalizeNumber int64 `mapstructure:"block-finalize-number"`
+
+	// a peer is disconnected and banned for an hour after it sends this many malformed or invalid
+	// txs in a minute, zero means disabled
+	PeerBanInvalidTxs int `mapstructure:"peer-ban-invalid-txs"`
 }
 
 type ChainConfig struct {
@@ -77,23 +259,47 @@ func DefaultAppConfigWithHome(home string) *AppConfig {
 		home = defaultHome
 	}
 	return &AppConfig{
-		AppDataPath:             filepath.Join(home, "data", AppDataPath),
-		ModbDataPath:            filepath.Join(home, "data", ModbDataPath),
-		SyncdbDataPath:          filepath.Join(home, "data", SyncdbDataPath),
-		RpcEthGetLogsMaxResults: DefaultRpcEthGetLogsMaxResults,
-		RetainBlocks:            DefaultRetainBlocks,
-		NumKeptBlocks:           DefaultNumKeptBlocks,
-		NumKeptBlocksInMoDB:     DefaultNumKeptBlocksInMoDB,
-		SigCacheSize:            DefaultSignatureCache,
-		RecheckThreshold:        DefaultRecheckThreshold,
-		TrunkCacheSize:          DefaultTrunkCacheSize,
-		ChangeRetainEveryN:      DefaultChangeRetainEveryN,
-		PruneEveryN:             DefaultPruneEveryN,
-		MainnetRPCPassword:      "123456",
-		FrontierGasLimit:        uint64(BlockMaxGas / 200), //5Million gas
+		AppDataPath:                filepath.Join(home, "data", AppDataPath),
+		ModbDataPath:               filepath.Join(home, "data", ModbDataPath),
+		SyncdbDataPath:             filepath.Join(home, "data", SyncdbDataPath),
+		AuditLogPath:               filepath.Join(home, "data", AuditLogPath),
+		WatcherEpochSpillPath:      filepath.Join(home, "data", WatcherEpochSpillPath),
+		WatcherSpeedupCursorPath:   filepath.Join(home, "data", WatcherSpeedupCursorPath),
+		CcInfoJournalPath:          filepath.Join(home, "data", CcInfoJournalPath),
+		WatcherAnalyticsPath:       filepath.Join(home, "data", WatcherAnalyticsPath),
+		WatcherAnalyticsRetainDays: DefaultWatcherAnalyticsRetainDays,
+		WatcherEpochArchivePath:    filepath.Join(home, "data", WatcherEpochArchivePath),
+		DeterminismReportPath:      filepath.Join(home, "data", DeterminismReportPath),
+		WatcherSpeedupBatchSize:    DefaultWatcherSpeedupBatchSize,
+		CcCollectInterval:          DefaultCcCollectInterval,
+		MempoolScanInterval:        DefaultMempoolScanInterval,
+		EpochGossipFallbackAfter:   DefaultEpochGossipFallbackAfter,
+		RpcEthGetLogsMaxResults:    DefaultRpcEthGetLogsMaxResults,
+		RetainBlocks:               DefaultRetainBlocks,
+		NumKeptBlocks:              DefaultNumKeptBlocks,
+		NumKeptBlocksInMoDB:        DefaultNumKeptBlocksInMoDB,
+		SigCacheSize:               DefaultSignatureCache,
+		RecheckThreshold:           DefaultRecheckThreshold,
+		TrunkCacheSize:             DefaultTrunkCacheSize,
+		ChangeRetainEveryN:         DefaultChangeRetainEveryN,
+		PruneEveryN:                DefaultPruneEveryN,
+		IndexedLogTopics:           DefaultIndexedLogTopics,
+		TxEventVerbosity:           TxEventsNone,
+		WarmUpContracts:            DefaultWarmUpContracts,
+		EpochGapThreshold:          DefaultEpochGapThreshold,
+		ColdStoreCacheBlocks:       DefaultColdStoreCacheBlocks,
+		CallResultCacheSize:        DefaultCallResultCacheSize,
+		MainnetRPCPassword:         "123456",
+		MainnetRPCMaxRetryInterval: DefaultMainnetRPCMaxRetryInterval,
+		FrontierGasLimit:           uint64(BlockMaxGas / 200), //5Million gas
 	}
 }
 
+// IsValidTxEventVerbosity returns whether v is a valid value of tx-event-verbosity
+func IsValidTxEventVerbosity(v string) bool {
+	return v == TxEventsNone || v == TxEventsCompact || v == TxEventsFull
+}
+
 func DefaultConfig() *ChainConfig {
 	c := &ChainConfig{
 		NodeConfig: config.DefaultConfig(),
@@ -102,3 +308,63 @@ func DefaultConfig() *ChainConfig {
 	c.NodeConfig.TxIndex.Indexer = "null"
 	return c
 }
+
+// ModbIndexes describes which indexes are maintained by moeingdb, the log addresses are always indexed
+type ModbIndexes struct {
+	TxFrom bool
+	TxTo   bool
+	// the topics at the positions [0, LogTopics) are indexed
+	LogTopics int
+}
+
+// MainnetRPCEndpoints returns the urls of the BCH nodes used by the watcher
+func (c *AppConfig) MainnetRPCEndpoints() []string {
+	if len(c.MainnetRPCUrls) != 0 {
+		return c.MainnetRPCUrls
+	}
+	if c.MainnetRPCUrl == "" {
+		return nil
+	}
+	return []string{c.MainnetRPCUrl}
+}
+
+// GetBlockFinalizeNumber returns block-finalize-number, or the default of the selected network if it is zero
+func (c *AppConfig) GetBlockFinalizeNumber(chainId uint64) int64 {
+	if c.BlockFinalizeNumber != 0 {
+		return c.BlockFinalizeNumber
+	}
+	if chainId == MainnetChainId {
+		return DefaultMainnetBlockFinalizeNumber
+	}
+	return CurrBchNetwork().BlockFinalizeNumber
+}
+
+// CheckBlockFinalizeNumber refuses the values which may let a reorg of BCH make the nodes see
+// different epochs on mainnet, and the ones delaying the epochs too long on any network
+func CheckBlockFinalizeNumber(n int64, chainId uint64) error {
+	if n < 1 || n > MaxBlockFinalizeNumber {
+		return fmt.Errorf("block-finalize-number must be between 1 and %d, got %d", MaxBlockFinalizeNumber, n)
+	}
+	if chainId == MainnetChainId && n < MinMainnetBlockFinalizeNumber {
+		return fmt.Errorf("block-finalize-number must be at least %d on mainnet, got %d", MinMainnetBlockFinalizeNumber, n)
+	}
+	return nil
+}
+
+func (c *AppConfig) ModbIndexes() ModbIndexes {
+	logTopics := c.IndexedLogTopics
+	if logTopics < 0 {
+		logTopics = 0
+	} else if logTopics > DefaultIndexedLogTopics {
+		logTopics = DefaultIndexedLogTopics
+	}
+	return ModbIndexes{
+		TxFrom:    !c.NoTxFromIndex,
+		TxTo:      !c.NoTxToIndex,
+		LogTopics: logTopics,
+	}
+}
+
+func (idx ModbIndexes) IsFull() bool {
+	return idx.TxFrom && idx.TxTo && idx.LogTopics == DefaultIndexedLogTopics
+}
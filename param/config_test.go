@@ -0,0 +1,21 @@
+package param
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlockFinalizeNumber(t *testing.T) {
+	c := DefaultAppConfig()
+	require.EqualValues(t, DefaultMainnetBlockFinalizeNumber, c.GetBlockFinalizeNumber(MainnetChainId))
+	require.EqualValues(t, DefaultBlockFinalizeNumber, c.GetBlockFinalizeNumber(0x2711))
+	c.BlockFinalizeNumber = 3
+	require.EqualValues(t, 3, c.GetBlockFinalizeNumber(MainnetChainId))
+
+	require.NoError(t, CheckBlockFinalizeNumber(1, 0x2711))
+	require.NoError(t, CheckBlockFinalizeNumber(MinMainnetBlockFinalizeNumber, MainnetChainId))
+	require.Error(t, CheckBlockFinalizeNumber(MinMainnetBlockFinalizeNumber-1, MainnetChainId))
+	require.Error(t, CheckBlockFinalizeNumber(0, 0x2711))
+	require.Error(t, CheckBlockFinalizeNumber(MaxBlockFinalizeNumber+1, 0x2711))
+}
@@ -0,0 +1,118 @@
+package param
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync/atomic"
+)
+
+const (
+	MainnetBchNetwork  = "mainnet"
+	Testnet3BchNetwork = "testnet3"
+	Testnet4BchNetwork = "testnet4"
+	ChipnetBchNetwork  = "chipnet"
+	RegtestBchNetwork  = "regtest"
+
+	// regtest blocks are mined on demand, the epochs switch soon after they end
+	regtestEpochSwitchDelay = 10
+)
+
+// BchNetwork is the BCH network followed by the watcher, selected by bch_network of the app state in
+// genesis.json. It is not read from app.toml, because it changes which nominations are counted and
+// where the epochs end, so all the nodes of a chain must select the same one. Only regtest differs from the consensus params, such that a devnet can run against a
+// regtest BCH node with 1-block epochs.
+type BchNetwork struct {
+	Name string
+	// the BCH height the cc txs are collected from
+	StartHeightForCC int64
+	// the default of block-finalize-number on the chains other than mainnet
+	BlockFinalizeNumber int64
+	NumBlocksInEpoch    int64
+	EpochSwitchDelay    int64 // in seconds
+	// the coinbase txs mined by generatetoaddress cannot carry OP_RETURN outputs, so the
+	// nominations are read from all the txs of a block, instead of the coinbase tx only
+	NominationsInAllTxs bool
+}
+
+var bchNetworks = map[string]*BchNetwork{
+	MainnetBchNetwork:  newBchNetwork(MainnetBchNetwork),
+	Testnet3BchNetwork: newBchNetwork(Testnet3BchNetwork),
+	Testnet4BchNetwork: newBchNetwork(Testnet4BchNetwork),
+	ChipnetBchNetwork:  newBchNetwork(ChipnetBchNetwork),
+	RegtestBchNetwork: {
+		Name:                RegtestBchNetwork,
+		StartHeightForCC:    1,
+		BlockFinalizeNumber: 1,
+		NumBlocksInEpoch:    1,
+		EpochSwitchDelay:    regtestEpochSwitchDelay,
+		NominationsInAllTxs: true,
+	},
+}
+
+// newBchNetwork returns a network following the consensus params
+func newBchNetwork(name string) *BchNetwork {
+	return &BchNetwork{
+		Name:                name,
+		StartHeightForCC:    StartMainnetHeightForCC,
+		BlockFinalizeNumber: DefaultBlockFinalizeNumber,
+		NumBlocksInEpoch:    StakingNumBlocksInEpoch,
+		EpochSwitchDelay:    StakingEpochSwitchDelay,
+	}
+}
+
+// currBchNetwork is accessed atomically, it is CcBchNetwork until SelectBchNetwork is called
+var currBchNetwork atomic.Value
+
+func init() {
+	currBchNetwork.Store(bchNetworks[CcBchNetwork])
+}
+
+// GetBchNetwork returns the network named name, or the one of CcBchNetwork if name is empty
+func GetBchNetwork(name string) (*BchNetwork, error) {
+	if name == "" {
+		name = CcBchNetwork
+	}
+	if n, ok := bchNetworks[name]; ok {
+		return n, nil
+	}
+	return nil, fmt.Errorf("unknown bch_network %q, must be one of %s", name, strings.Join(BchNetworkNames(), ", "))
+}
+
+// BchNetworkNames returns the names of the known networks, sorted
+func BchNetworkNames() []string {
+	names := make([]string, 0, len(bchNetworks))
+	for name := range bchNetworks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// CheckBchNetwork refuses the unknown networks, and regtest on mainnet
+func CheckBchNetwork(name string, chainId uint64) error {
+	n, err := GetBchNetwork(name)
+	if err != nil {
+		return err
+	}
+	if chainId == MainnetChainId && n.Name == RegtestBchNetwork {
+		return fmt.Errorf("bch_network cannot be %s on mainnet", RegtestBchNetwork)
+	}
+	return nil
+}
+
+// SelectBchNetwork makes the network named name the one returned by CurrBchNetwork, it is called
+// with the one of genesis.json before the app starts
+func SelectBchNetwork(name string) error {
+	n, err := GetBchNetwork(name)
+	if err != nil {
+		return err
+	}
+	currBchNetwork.Store(n)
+	return nil
+}
+
+// CurrBchNetwork returns the network selected
+func CurrBchNetwork() *BchNetwork {
+	return currBchNetwork.Load().(*BchNetwork)
+}
@@ -0,0 +1,34 @@
+package param
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBchNetwork(t *testing.T) {
+	n, err := GetBchNetwork("")
+	require.NoError(t, err)
+	require.Equal(t, CcBchNetwork, n.Name)
+	require.EqualValues(t, StakingNumBlocksInEpoch, n.NumBlocksInEpoch)
+	require.EqualValues(t, StartMainnetHeightForCC, n.StartHeightForCC)
+	require.Equal(t, CcBchNetwork, CurrBchNetwork().Name)
+
+	n, err = GetBchNetwork(RegtestBchNetwork)
+	require.NoError(t, err)
+	require.Equal(t, RegtestBchNetwork, n.Name)
+	require.EqualValues(t, 1, n.NumBlocksInEpoch)
+	require.True(t, n.NominationsInAllTxs)
+	_, err = GetBchNetwork("testnet5")
+	require.Error(t, err)
+
+	require.NoError(t, CheckBchNetwork(RegtestBchNetwork, 0x2711))
+	require.Error(t, CheckBchNetwork(RegtestBchNetwork, MainnetChainId))
+	require.NoError(t, CheckBchNetwork(ChipnetBchNetwork, MainnetChainId))
+
+	require.NoError(t, SelectBchNetwork(RegtestBchNetwork))
+	defer func() { require.NoError(t, SelectBchNetwork("")) }()
+	require.Equal(t, RegtestBchNetwork, CurrBchNetwork().Name)
+	require.Error(t, SelectBchNetwork("testnet5"))
+	require.Equal(t, RegtestBchNetwork, CurrBchNetwork().Name)
+}
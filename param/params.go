@@ -76,4 +76,44 @@ const (
 	ShaGateForkBlock       int64  = math.MaxInt64
 	ShaGateSwitch          bool   = false
 	StakingForkHeight      int64  = math.MaxInt64
+	FreezeForkHeight       int64  = math.MaxInt64 // the freeze contract is disabled unless a network opts in
+
+	// redeem() of the cc contract rejects the malformed target addresses since this height
+	RedeemTargetCheckForkHeight int64 = math.MaxInt64
+
+	// the deposits which cannot be minted are refunded to their senders since this height
+	PegInRefundForkHeight int64 = math.MaxInt64
+
+	// the call specified in the memo of a peg-in is executed after minting since this height
+	PegInCallForkHeight int64 = math.MaxInt64
+	// the gas limit of a peg-in call, whose fee is PegInCallGasLimit*PegInCallGasPrice no matter how
+	// much gas it uses
+	PegInCallGasLimit uint64 = 500_000
+	PegInCallGasPrice uint64 = 1_050_000_000
+	// no more peg-in calls are executed when handling a rescan window, the ones beyond are skipped
+	MaxPegInCallsPerWindow int = 20
+
+	// the validators are recorded by their consensus addresses since this height, so the blocks they
+	// proposed can be attributed after they retire, see staking.SaveProposers
+	ProposerRecordForkHeight int64 = math.MaxInt64
+
+	// the covenant generations are recorded, and the deposits to any generation but the current one
+	// are kept as lost-and-found since this height
+	CovenantGenerationsForkHeight int64 = math.MaxInt64
+
+	// the anti-sybil rules of the nominations apply to the epochs starting at or after this BCH
+	// mainnet height, see watcher.NominationCountRules
+	NominationRulesForkMainnetHeight int64 = math.MaxInt64
+	// the cap is per miner, which is told by the payout script of its coinbase txs, so the support of
+	// many miners is not flattened to ties. A miner can rotate its payout scripts to bypass it, this
+	// is a known limitation, and MinNominationBlocks still applies.
+	MaxNominationsPerCoinbase        int64 = 504 // in an epoch, zero means no cap
+	MinNominationBlocks              int64 = 6
+	NominationHashpowerWeighting     bool  = false
+
+	// since this height, the elected cc operators and monitors are topped up from the gas grant pool
+	// after every block, see crosschain.GrantGas
+	GasGrantForkHeight int64  = math.MaxInt64
+	GasGrantFloor      uint64 = 10_000_000_000_000_000  // 0.01 BCH
+	GasGrantDailyQuota uint64 = 100_000_000_000_000_000 // 0.1 BCH for each account
 )
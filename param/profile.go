@@ -0,0 +1,122 @@
+package param
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	ProfileValidator = "validator"
+	ProfileRpc       = "rpc"
+	ProfileArchive   = "archive"
+
+	DefaultMempoolSize = 10000
+)
+
+// Profile presets the options for one of the common roles of a node. The fields
+// stored in app.toml are written by "init --profile"; RpcAPI and MempoolSize are
+// used by "start" unless the corresponding flags are given explicitly.
+type Profile struct {
+	Name                    string
+	ArchiveMode             bool
+	WithSyncDB              bool
+	NumKeptBlocks           int64
+	NumKeptBlocksInMoDB     int64
+	RpcEthGetLogsMaxResults int
+	Speedup                 bool
+	NoTxFromIndex           bool
+	NoTxToIndex             bool
+	IndexedLogTopics        int
+	RpcAPI                  string
+	MempoolSize             int
+}
+
+var profiles = map[string]*Profile{
+	// validators do not serve public queries, so the history is pruned and the watcher
+	// must verify the BCH mainnet blocks by itself
+	ProfileValidator: {
+		Name:                    ProfileValidator,
+		NumKeptBlocks:           DefaultNumKeptBlocks,
+		NumKeptBlocksInMoDB:     DefaultNumKeptBlocks,
+		RpcEthGetLogsMaxResults: 1000,
+		NoTxFromIndex:           true,
+		NoTxToIndex:             true,
+		IndexedLogTopics:        1,
+		RpcAPI:                  "eth,web3,net",
+		MempoolSize:             DefaultMempoolSize,
+	},
+	ProfileRpc: {
+		Name:                    ProfileRpc,
+		NumKeptBlocks:           DefaultNumKeptBlocks,
+		NumKeptBlocksInMoDB:     DefaultNumKeptBlocksInMoDB,
+		RpcEthGetLogsMaxResults: DefaultRpcEthGetLogsMaxResults,
+		Speedup:                 true,
+		IndexedLogTopics:        DefaultIndexedLogTopics,
+		RpcAPI:                  "eth,web3,net,txpool,sbch",
+		MempoolSize:             DefaultMempoolSize * 2,
+	},
+	ProfileArchive: {
+		Name:                    ProfileArchive,
+		ArchiveMode:             true,
+		WithSyncDB:              true,
+		NumKeptBlocks:           DefaultNumKeptBlocks,
+		NumKeptBlocksInMoDB:     DefaultNumKeptBlocksInMoDB,
+		RpcEthGetLogsMaxResults: DefaultRpcEthGetLogsMaxResults,
+		Speedup:                 true,
+		IndexedLogTopics:        DefaultIndexedLogTopics,
+		RpcAPI:                  "eth,web3,net,txpool,sbch,debug",
+		MempoolSize:             DefaultMempoolSize,
+	},
+}
+
+// GetProfile returns nil for an empty name, which means no profile is used
+func GetProfile(name string) (*Profile, error) {
+	if name == "" {
+		return nil, nil
+	}
+	p, ok := profiles[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown profile %q, must be one of: %s", name, strings.Join(ProfileNames(), ", "))
+	}
+	return p, nil
+}
+
+func ProfileNames() []string {
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (p *Profile) ApplyTo(conf *AppConfig) {
+	conf.Profile = p.Name
+	conf.ArchiveMode = p.ArchiveMode
+	conf.WithSyncDB = p.WithSyncDB
+	conf.NumKeptBlocks = p.NumKeptBlocks
+	conf.NumKeptBlocksInMoDB = p.NumKeptBlocksInMoDB
+	conf.RpcEthGetLogsMaxResults = p.RpcEthGetLogsMaxResults
+	conf.Speedup = p.Speedup
+	conf.NoTxFromIndex = p.NoTxFromIndex
+	conf.NoTxToIndex = p.NoTxToIndex
+	conf.IndexedLogTopics = p.IndexedLogTopics
+}
+
+// Check finds the options which conflict with the role of this profile
+func (p *Profile) Check(conf *AppConfig) error {
+	if p.Name != ProfileValidator {
+		return nil
+	}
+	if conf.ArchiveMode {
+		return fmt.Errorf("archive-mode must not be enabled with the %s profile", p.Name)
+	}
+	if conf.WithSyncDB {
+		return fmt.Errorf("with-syncdb must not be enabled with the %s profile", p.Name)
+	}
+	if conf.Speedup {
+		return fmt.Errorf("watcher-speedup must not be enabled with the %s profile", p.Name)
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package param
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestProfiles(t *testing.T) {
+	p, err := GetProfile("")
+	require.NoError(t, err)
+	require.Nil(t, p)
+	_, err = GetProfile("miner")
+	require.Error(t, err)
+	require.Equal(t, []string{ProfileArchive, ProfileRpc, ProfileValidator}, ProfileNames())
+
+	conf := DefaultAppConfig()
+	archive, err := GetProfile(ProfileArchive)
+	require.NoError(t, err)
+	archive.ApplyTo(conf)
+	require.Equal(t, ProfileArchive, conf.Profile)
+	require.True(t, conf.ArchiveMode)
+	require.True(t, conf.ModbIndexes().IsFull())
+	require.NoError(t, archive.Check(conf))
+
+	validator, err := GetProfile(ProfileValidator)
+	require.NoError(t, err)
+	require.Error(t, validator.Check(conf))
+	validator.ApplyTo(conf)
+	require.False(t, conf.ArchiveMode)
+	require.False(t, conf.WithSyncDB)
+	require.False(t, conf.ModbIndexes().IsFull())
+	require.NoError(t, validator.Check(conf))
+}
@@ -0,0 +1,93 @@
+package api
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	gethcmn "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+
+	"github.com/smartbch/smartbch/internal/audit"
+	"github.com/smartbch/smartbch/internal/multisig"
+	sbchrpctypes "github.com/smartbch/smartbch/rpc/types"
+)
+
+var errAdminOpNeedsSigs = sbchrpctypes.NewError(sbchrpctypes.ErrCodeUnauthorized, sbchrpctypes.ReasonAdminSigsRequired,
+	"admin operations must be signed by the operators of this node, use sbch_submitAdminOp")
+
+// SubmitAdminOp takes the privileged operation in payload after checking it is signed by enough
+// operators of this node. The operation is recorded in the audit log once it is verified, even if
+// it fails to be taken, so its nonce can not be used again after restarting.
+func (sbch sbchAPI) SubmitAdminOp(payload string, sigs []hexutil.Bytes) (bool, error) {
+	sbch.logger.Debug("sbch_submitAdminOp")
+	sigList := make([][]byte, len(sigs))
+	for i, sig := range sigs {
+		sigList[i] = sig
+	}
+	op, signers, err := sbch.backend.VerifyAdminOp([]byte(payload), sigList)
+	if err != nil {
+		return false, newInvalidAdminOpError(err.Error())
+	}
+	done, details, err := sbch.takeAdminOp(op)
+	signerList := make([]string, len(signers))
+	for i, signer := range signers {
+		signerList[i] = signer.Hex()
+	}
+	details["nonce"] = strconv.FormatUint(op.Nonce, 10)
+	details["signers"] = strings.Join(signerList, ",")
+	if err != nil {
+		details["error"] = err.Error()
+	}
+	sbch.backend.RecordAudit(op.Action, audit.SourceMultisig, details)
+	return done, err
+}
+
+// takeAdminOp returns whether the operation changed anything, and the details to be recorded
+func (sbch sbchAPI) takeAdminOp(op *multisig.Op) (bool, map[string]string, error) {
+	details := make(map[string]string)
+	switch op.Action {
+	case audit.ActionSetRpcKey:
+		// the private key itself is never recorded
+		pubkey, err := sbch.setRpcKey(op.Params["key"])
+		details["pubkey"] = pubkey
+		return err == nil, details, err
+	case audit.ActionAddTracedAddress, audit.ActionRemoveTracedAddress:
+		addrStr := op.Params["address"]
+		details["address"] = addrStr
+		if !gethcmn.IsHexAddress(addrStr) {
+			return false, details, newInvalidAdminOpError(fmt.Sprintf("invalid address: %q", addrStr))
+		}
+		addr := gethcmn.HexToAddress(addrStr)
+		if op.Action == audit.ActionAddTracedAddress {
+			return sbch.backend.AddTracedAddress(addr), details, nil
+		}
+		return sbch.backend.RemoveTracedAddress(addr), details, nil
+	case audit.ActionRescanCc:
+		details["begin"], details["end"] = op.Params["begin"], op.Params["end"]
+		begin, err := strconv.ParseInt(op.Params["begin"], 10, 64)
+		if err != nil {
+			return false, details, newInvalidAdminOpError("invalid begin: " + err.Error())
+		}
+		end, err := strconv.ParseInt(op.Params["end"], 10, 64)
+		if err != nil {
+			return false, details, newInvalidAdminOpError("invalid end: " + err.Error())
+		}
+		err = sbch.backend.ForceCcRescan(begin, end)
+		return err == nil, details, err
+	case audit.ActionPauseCcCollect:
+		return sbch.backend.SetCcCollectPaused(true), details, nil
+	case audit.ActionResumeCcCollect:
+		return sbch.backend.SetCcCollectPaused(false), details, nil
+	default:
+		return false, details, newInvalidAdminOpError(fmt.Sprintf("unknown admin action: %q", op.Action))
+	}
+}
+
+// GetAdminOpNonce returns the nonce of the last accepted admin operation, the next one must use
+// a larger nonce
+func (sbch sbchAPI) GetAdminOpNonce() (hexutil.Uint64, error) {
+	sbch.logger.Debug("sbch_getAdminOpNonce")
+	nonce, err := sbch.backend.GetAdminOpNonce()
+	return hexutil.Uint64(nonce), err
+}
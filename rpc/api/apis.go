@@ -15,6 +15,7 @@ const (
 	namespaceTxPool = "txpool"
 	namespaceSBCH   = "sbch"
 	namespaceDebug  = "debug"
+	namespaceEvm    = "evm"
 
 	apiVersion = "1.0"
 )
@@ -29,9 +30,9 @@ func GetAPIs(backend sbchapi.BackendService,
 	_filterAPI := filters.NewAPI(backend, logger)
 	_web3API := newWeb3API(logger)
 	_txPoolAPI := newTxPoolAPI(logger)
-	_sbchAPI := newSbchAPI(backend, logger)
+	_sbchAPI := newSbchAPI(backend, _ethAPI.accounts, logger)
 	_debugAPI := newDebugAPI(_ethAPI, logger)
-	//_evmAPI := newEvmAPI(backend)
+	_evmAPI := newEvmAPI(backend, logger)
 
 	return []rpc.API{
 		{
@@ -76,5 +77,11 @@ func GetAPIs(backend sbchapi.BackendService,
 			Service:   _debugAPI,
 			Public:    true,
 		},
+		{
+			Namespace: namespaceEvm,
+			Version:   apiVersion,
+			Service:   _evmAPI,
+			Public:    true,
+		},
 	}
 }
@@ -0,0 +1,150 @@
+package api
+
+import (
+	"bytes"
+	"math/big"
+	"sort"
+
+	gethcmn "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	gethrpc "github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/smartbch/moeingevm/ebp"
+	motypes "github.com/smartbch/moeingevm/types"
+
+	"github.com/smartbch/smartbch/internal/ethutils"
+	sbchrpctypes "github.com/smartbch/smartbch/rpc/types"
+)
+
+const (
+	// the max number of blocks whose Approval events are scanned by one sbch_getApprovals call
+	maxApprovalsBlockRange = 100000
+	// the max number of the (token, spender) pairs found by one call, each of which calls allowance()
+	maxApprovalPairs = 500
+)
+
+var (
+	approvalEventSig  = crypto.Keccak256Hash([]byte("Approval(address,address,uint256)"))
+	allowanceSelector = crypto.Keccak256([]byte("allowance(address,address)"))[:4]
+
+	errApprovalsNotIndexed = sbchrpctypes.NewError(sbchrpctypes.ErrCodeNotSupported, sbchrpctypes.ReasonNotIndexed,
+		"the first 2 log topics must be indexed to query approvals").WithHint("set indexed-log-topics to at least 2")
+	errTooManyApprovals = sbchrpctypes.NewError(sbchrpctypes.ErrCodeLimitExceeded, sbchrpctypes.ReasonTooManyResults,
+		"too many approvals").WithHint("use a shorter block range")
+)
+
+// sep20ApprovalFilter drops the Approval events of SEP721 tokens, which have 4 topics
+func sep20ApprovalFilter(_ gethcmn.Address, topics []gethcmn.Hash, _ []gethcmn.Address, _ [][]gethcmn.Hash) bool {
+	return len(topics) == 3
+}
+
+// latestApprovals picks the latest Approval event of each (token, spender) pair, sorted by tokens
+// and then spenders
+func latestApprovals(logs []*gethtypes.Log) []*sbchrpctypes.Approval {
+	type key struct{ token, spender gethcmn.Address }
+	type latest struct {
+		log    *gethtypes.Log
+		result *sbchrpctypes.Approval
+	}
+	m := make(map[key]*latest)
+	for _, log := range logs {
+		if len(log.Topics) != 3 || len(log.Data) != 32 {
+			continue
+		}
+		k := key{log.Address, gethcmn.BytesToAddress(log.Topics[2][:])}
+		if l, ok := m[k]; ok && !isLogAfter(log, l.log) {
+			continue
+		}
+		m[k] = &latest{log: log, result: &sbchrpctypes.Approval{
+			Token:              k.token,
+			Spender:            k.spender,
+			LastApprovedAmount: (*hexutil.Big)(new(big.Int).SetBytes(log.Data)),
+			LastApprovalBlock:  hexutil.Uint64(log.BlockNumber),
+			LastApprovalTx:     log.TxHash,
+		}}
+	}
+	result := make([]*sbchrpctypes.Approval, 0, len(m))
+	for _, l := range m {
+		result = append(result, l.result)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if c := bytes.Compare(result[i].Token[:], result[j].Token[:]); c != 0 {
+			return c < 0
+		}
+		return bytes.Compare(result[i].Spender[:], result[j].Spender[:]) < 0
+	})
+	return result
+}
+
+func isLogAfter(a, b *gethtypes.Log) bool {
+	if a.BlockNumber != b.BlockNumber {
+		return a.BlockNumber > b.BlockNumber
+	}
+	if a.TxIndex != b.TxIndex {
+		return a.TxIndex > b.TxIndex
+	}
+	return a.Index > b.Index
+}
+
+func allowanceCallData(owner, spender gethcmn.Address) []byte {
+	data := make([]byte, 0, 4+64)
+	data = append(data, allowanceSelector...)
+	data = append(data, gethcmn.LeftPadBytes(owner[:], 32)...)
+	return append(data, gethcmn.LeftPadBytes(spender[:], 32)...)
+}
+
+// GetApprovals returns the SEP20 allowances granted by owner, found from the Approval events in
+// [fromBlock, toBlock] and read with allowance() at the latest block. The allowances which are used
+// up or revoked are zero, they are excluded unless includeRevoked is true. The block range and the
+// number of the events are limited as eth_getLogs, so the whole history is scanned with consecutive
+// ranges.
+func (sbch sbchAPI) GetApprovals(owner gethcmn.Address, fromBlock, toBlock gethrpc.BlockNumber,
+	includeRevoked *bool) ([]*sbchrpctypes.Approval, error) {
+
+	sbch.logger.Debug("sbch_getApprovals")
+	if fromBlock == gethrpc.LatestBlockNumber {
+		fromBlock = gethrpc.BlockNumber(sbch.backend.LatestHeight())
+	}
+	if toBlock == gethrpc.LatestBlockNumber {
+		toBlock = gethrpc.BlockNumber(sbch.backend.LatestHeight())
+	}
+	if fromBlock < 0 || toBlock < fromBlock {
+		return nil, errInvalidBlockRange
+	}
+	if toBlock-fromBlock >= maxApprovalsBlockRange {
+		return nil, errBlockRangeTooLong
+	}
+	if sbch.backend.GetModbIndexes().LogTopics < 2 {
+		return nil, errApprovalsNotIndexed
+	}
+	topics := [][]gethcmn.Hash{{approvalEventSig}, {gethcmn.BytesToHash(owner[:])}}
+	logs, err := sbch.backend.QueryLogs(nil, topics, uint32(fromBlock), uint32(toBlock+1), sep20ApprovalFilter)
+	if err != nil {
+		return nil, err
+	}
+	if len(logs) > sbch.backend.GetRpcMaxLogResults() {
+		return nil, errTooManyApprovals
+	}
+	approvals := latestApprovals(motypes.ToGethLogs(logs))
+	if len(approvals) > maxApprovalPairs {
+		return nil, errTooManyApprovals
+	}
+	result := make([]*sbchrpctypes.Approval, 0, len(approvals))
+	for _, approval := range approvals {
+		token := approval.Token
+		tx := ethutils.NewTx(0, &token, big.NewInt(0), DefaultRPCGasLimit, big.NewInt(0),
+			allowanceCallData(owner, approval.Spender))
+		statusCode, retData := sbch.backend.Call(tx, owner, gethrpc.LatestBlockNumber.Int64())
+		if !ebp.StatusIsFailure(statusCode) && len(retData) == 32 {
+			approval.Allowance = (*hexutil.Big)(new(big.Int).SetBytes(retData))
+		}
+		if approval.Allowance != nil && approval.Allowance.ToInt().Sign() == 0 &&
+			(includeRevoked == nil || !*includeRevoked) {
+			continue
+		}
+		result = append(result, approval)
+	}
+	return result, nil
+}
@@ -0,0 +1,45 @@
+package api
+
+import (
+	"testing"
+
+	gethcmn "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLatestApprovals(t *testing.T) {
+	owner := gethcmn.BytesToHash([]byte{0xaa})
+	spender1 := gethcmn.BytesToHash([]byte{0x01})
+	spender2 := gethcmn.BytesToHash([]byte{0x02})
+	token := gethcmn.Address{0x10}
+	approval := func(spender gethcmn.Hash, amount byte, height uint64, logIdx uint) *gethtypes.Log {
+		return &gethtypes.Log{
+			Address:     token,
+			Topics:      []gethcmn.Hash{approvalEventSig, owner, spender},
+			Data:        gethcmn.LeftPadBytes([]byte{amount}, 32),
+			BlockNumber: height,
+			Index:       logIdx,
+		}
+	}
+	nft := approval(spender1, 9, 100, 0)
+	nft.Topics = append(nft.Topics, gethcmn.Hash{})
+
+	result := latestApprovals([]*gethtypes.Log{
+		approval(spender2, 5, 20, 0),
+		approval(spender1, 7, 30, 1),
+		approval(spender1, 3, 30, 0),
+		approval(spender1, 1, 10, 0),
+		nft,
+	})
+	require.Len(t, result, 2)
+	require.Equal(t, gethcmn.BytesToAddress(spender1[:]), result[0].Spender)
+	require.Equal(t, (*hexutil.Big)(hexutil.MustDecodeBig("0x7")), result[0].LastApprovedAmount)
+	require.EqualValues(t, 30, result[0].LastApprovalBlock)
+	require.Equal(t, gethcmn.BytesToAddress(spender2[:]), result[1].Spender)
+
+	data := allowanceCallData(gethcmn.Address{0xaa}, gethcmn.Address{0x01})
+	require.Equal(t, "dd62ed3e", gethcmn.Bytes2Hex(data[:4]))
+	require.Len(t, data, 68)
+}
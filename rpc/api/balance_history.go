@@ -0,0 +1,88 @@
+package api
+
+import (
+	"math/big"
+
+	gethcmn "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	gethrpc "github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/smartbch/moeingevm/types"
+
+	sbchrpctypes "github.com/smartbch/smartbch/rpc/types"
+)
+
+// the max number of the heights sampled by one sbch_getBalanceHistory call, each of which reads the
+// archived state of a block
+const maxBalanceSamples = 500
+
+var (
+	errArchiveModeRequired = sbchrpctypes.NewError(sbchrpctypes.ErrCodeNotSupported, sbchrpctypes.ReasonNotSupported,
+		"historical balances are only kept in archive mode").WithHint("use an archive node")
+	errTooManyBalanceSamples = sbchrpctypes.NewError(sbchrpctypes.ErrCodeLimitExceeded, sbchrpctypes.ReasonTooManyResults,
+		"too many heights to sample").WithHint("use a larger step or a shorter block range")
+)
+
+// GetBalanceHistory returns the balances of addr at fromBlock, fromBlock+step, ... and toBlock,
+// so the history of an account can be charted without one eth_getBalance call per height.
+// A zero step means every block.
+func (sbch sbchAPI) GetBalanceHistory(addr gethcmn.Address, fromBlock, toBlock gethrpc.BlockNumber,
+	step hexutil.Uint64) ([]*sbchrpctypes.BalanceSample, error) {
+
+	sbch.logger.Debug("sbch_getBalanceHistory")
+	if !sbch.backend.IsArchiveMode() {
+		return nil, errArchiveModeRequired
+	}
+	latest := sbch.backend.LatestHeight()
+	if fromBlock == gethrpc.LatestBlockNumber {
+		fromBlock = gethrpc.BlockNumber(latest)
+	}
+	if toBlock == gethrpc.LatestBlockNumber {
+		toBlock = gethrpc.BlockNumber(latest)
+	}
+	if fromBlock < 0 || toBlock < fromBlock {
+		return nil, errInvalidBlockRange
+	}
+	if toBlock.Int64() > latest {
+		return nil, errFutureBlockNum
+	}
+	heights, err := balanceSampleHeights(fromBlock.Int64(), toBlock.Int64(), uint64(step))
+	if err != nil {
+		return nil, err
+	}
+
+	samples := make([]*sbchrpctypes.BalanceSample, len(heights))
+	for i, height := range heights {
+		b, err := sbch.backend.GetBalance(addr, height)
+		if err == types.ErrAccNotFound {
+			b, err = big.NewInt(0), nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		samples[i] = &sbchrpctypes.BalanceSample{
+			Height:  hexutil.Uint64(height),
+			Balance: (*hexutil.Big)(b),
+		}
+	}
+	return samples, nil
+}
+
+// balanceSampleHeights returns from, from+step, ... and to, which is always sampled
+func balanceSampleHeights(from, to int64, step uint64) ([]int64, error) {
+	if step == 0 {
+		step = 1
+	}
+	count := uint64(to-from)/step + 1
+	if uint64(to-from)%step != 0 {
+		count++
+	}
+	if count > maxBalanceSamples {
+		return nil, errTooManyBalanceSamples
+	}
+	heights := make([]int64, 0, count)
+	for h := from; h < to; h += int64(step) {
+		heights = append(heights, h)
+	}
+	return append(heights, to), nil
+}
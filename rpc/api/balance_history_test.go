@@ -0,0 +1,32 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBalanceSampleHeights(t *testing.T) {
+	heights, err := balanceSampleHeights(10, 10, 0)
+	require.NoError(t, err)
+	require.Equal(t, []int64{10}, heights)
+
+	heights, err = balanceSampleHeights(10, 15, 0)
+	require.NoError(t, err)
+	require.Equal(t, []int64{10, 11, 12, 13, 14, 15}, heights)
+
+	heights, err = balanceSampleHeights(10, 20, 5)
+	require.NoError(t, err)
+	require.Equal(t, []int64{10, 15, 20}, heights)
+
+	heights, err = balanceSampleHeights(10, 22, 5)
+	require.NoError(t, err)
+	require.Equal(t, []int64{10, 15, 20, 22}, heights)
+
+	heights, err = balanceSampleHeights(0, 99800, 200)
+	require.NoError(t, err)
+	require.Len(t, heights, maxBalanceSamples)
+
+	_, err = balanceSampleHeights(0, 99801, 200)
+	require.Equal(t, errTooManyBalanceSamples, err)
+}
@@ -0,0 +1,63 @@
+package api
+
+import (
+	"math/big"
+
+	gethcmn "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+
+	"github.com/smartbch/smartbch/txcodec"
+)
+
+type BlockPreviewTx struct {
+	Hash     gethcmn.Hash     `json:"hash"`
+	From     gethcmn.Address  `json:"from"`
+	To       *gethcmn.Address `json:"to"`
+	Nonce    hexutil.Uint64   `json:"nonce"`
+	Gas      hexutil.Uint64   `json:"gas"`
+	GasPrice *hexutil.Big     `json:"gasPrice"`
+	MaxFee   *hexutil.Big     `json:"maxFee"` // gas * gasPrice, the fee paid if all the gas is used
+	Size     hexutil.Uint64   `json:"size"`
+}
+
+// BlockPreview is the block this node would propose with its current mempool. The transactions
+// are listed in the order they are included, the execution engine reorders them by senders with
+// a random seed after the block is committed.
+type BlockPreview struct {
+	TxCount      hexutil.Uint64    `json:"txCount"`
+	TotalGas     hexutil.Uint64    `json:"totalGas"` // the sum of the gas limits, which is capped by the block gas limit
+	TotalMaxFees *hexutil.Big      `json:"totalMaxFees"`
+	TotalSize    hexutil.Uint64    `json:"totalSize"`
+	Undecodable  hexutil.Uint64    `json:"undecodable"` // the number of the reaped txs which cannot be decoded
+	Txs          []*BlockPreviewTx `json:"txs"`
+}
+
+func buildBlockPreview(rawTxs [][]byte, decoder *txcodec.Decoder) *BlockPreview {
+	preview := &BlockPreview{
+		TotalMaxFees: (*hexutil.Big)(new(big.Int)),
+		Txs:          make([]*BlockPreviewTx, 0, len(rawTxs)),
+	}
+	for _, rawTx := range rawTxs {
+		tx, sender, err := decoder.DecodeAndVerify(rawTx)
+		if err != nil {
+			preview.Undecodable++
+			continue
+		}
+		maxFee := new(big.Int).Mul(new(big.Int).SetUint64(tx.Gas()), tx.GasPrice())
+		preview.Txs = append(preview.Txs, &BlockPreviewTx{
+			Hash:     tx.Hash(),
+			From:     sender,
+			To:       tx.To(),
+			Nonce:    hexutil.Uint64(tx.Nonce()),
+			Gas:      hexutil.Uint64(tx.Gas()),
+			GasPrice: (*hexutil.Big)(tx.GasPrice()),
+			MaxFee:   (*hexutil.Big)(maxFee),
+			Size:     hexutil.Uint64(len(rawTx)),
+		})
+		preview.TxCount++
+		preview.TotalGas += hexutil.Uint64(tx.Gas())
+		preview.TotalSize += hexutil.Uint64(len(rawTx))
+		preview.TotalMaxFees.ToInt().Add(preview.TotalMaxFees.ToInt(), maxFee)
+	}
+	return preview
+}
@@ -0,0 +1,32 @@
+package api
+
+import (
+	"math/big"
+	"testing"
+
+	gethcmn "github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+
+	"github.com/smartbch/smartbch/internal/ethutils"
+	"github.com/smartbch/smartbch/internal/testutils"
+	"github.com/smartbch/smartbch/txcodec"
+)
+
+func TestBuildBlockPreview(t *testing.T) {
+	chainID := big.NewInt(0x2711)
+	key, addr := testutils.GenKeyAndAddr()
+	to := gethcmn.Address{0x01}
+	tx1 := testutils.MustSignTx(ethutils.NewTx(0, &to, big.NewInt(1), 21000, big.NewInt(10), nil), chainID, key)
+	tx2 := testutils.MustSignTx(ethutils.NewTx(1, nil, big.NewInt(0), 100000, big.NewInt(20), []byte{1}), chainID, key)
+	rawTxs := [][]byte{testutils.MustEncodeTx(tx1), {0x01, 0x02}, testutils.MustEncodeTx(tx2)}
+
+	preview := buildBlockPreview(rawTxs, txcodec.NewDecoder(txcodec.DefaultConfig(chainID)))
+	require.EqualValues(t, 2, preview.TxCount)
+	require.EqualValues(t, 1, preview.Undecodable)
+	require.EqualValues(t, 121000, preview.TotalGas)
+	require.EqualValues(t, 21000*10+100000*20, preview.TotalMaxFees.ToInt().Int64())
+	require.EqualValues(t, len(rawTxs[0])+len(rawTxs[2]), preview.TotalSize)
+	require.Equal(t, tx1.Hash(), preview.Txs[0].Hash)
+	require.Equal(t, addr, preview.Txs[1].From)
+	require.Nil(t, preview.Txs[1].To)
+}
@@ -0,0 +1,92 @@
+package api
+
+import (
+	"strconv"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+
+	"github.com/smartbch/smartbch/internal/audit"
+	sbchrpctypes "github.com/smartbch/smartbch/rpc/types"
+	watchertypes "github.com/smartbch/smartbch/watcher/types"
+)
+
+// GetCcRescanStatus returns the rescan window of the cross-chain contract and the progress of the
+// watcher collecting the transfer infos in it
+func (sbch sbchAPI) GetCcRescanStatus() *sbchrpctypes.CcRescanStatus {
+	sbch.logger.Debug("sbch_getCcRescanStatus")
+	status := buildCcRescanStatus(sbch.backend.GetCcCollectStatus())
+	if ctx := sbch.backend.GetCcContext(); ctx != nil {
+		status.LastRescannedHeight = ctx.LastRescannedHeight
+		status.RescanHeight = ctx.RescanHeight
+		status.RescanTime = ctx.RescanTime
+		status.UTXOAlreadyHandled = ctx.UTXOAlreadyHandled
+		status.Collecting = uint64(status.CollectedEnd) != ctx.RescanHeight
+	}
+	status.WatcherHeight = sbch.backend.GetWatcherHeight()
+	return status
+}
+
+func buildCcRescanStatus(s watchertypes.CcCollectStatus) *sbchrpctypes.CcRescanStatus {
+	return &sbchrpctypes.CcRescanStatus{
+		CollectedBegin:    s.Begin,
+		CollectedEnd:      s.End,
+		CollectedInfos:    s.Infos,
+		DuplicatesDropped: s.Duplicates,
+		PendingRescan:     toHeightRange(s.PendingRescan),
+		LastRescan:        toHeightRange(s.LastRescan),
+		LastRescanTime:    s.LastRescanTime,
+		CollectPaused:     s.Paused,
+	}
+}
+
+func toHeightRange(r *watchertypes.HeightRange) *sbchrpctypes.HeightRange {
+	if r == nil {
+		return nil
+	}
+	return &sbchrpctypes.HeightRange{Begin: r.Begin, End: r.End}
+}
+
+// RescanCc makes the watcher parse the BCH blocks in (begin, end] again, which must be inside the
+// rescan window not handled yet. It is used after a bug of the parser is fixed.
+func (api *debugAPI) RescanCc(begin, end hexutil.Uint64) (bool, error) {
+	api.logger.Debug("debug_rescanCc")
+	if api.ethAPI.backend.AdminOpsRequireSigs() {
+		return false, errAdminOpNeedsSigs
+	}
+	if err := api.ethAPI.backend.ForceCcRescan(int64(begin), int64(end)); err != nil {
+		return false, err
+	}
+	api.ethAPI.backend.RecordAudit(audit.ActionRescanCc, audit.SourceRpc, map[string]string{
+		"begin": strconv.FormatUint(uint64(begin), 10),
+		"end":   strconv.FormatUint(uint64(end), 10),
+	})
+	return true, nil
+}
+
+// PauseCcCollect stops the watcher collecting the cc-transfer infos, to throttle a node on a
+// resource-constrained machine. It returns false if the collection is paused already.
+func (api *debugAPI) PauseCcCollect() (bool, error) {
+	api.logger.Debug("debug_pauseCcCollect")
+	return api.setCcCollectPaused(true)
+}
+
+// ResumeCcCollect resumes the collection paused by debug_pauseCcCollect
+func (api *debugAPI) ResumeCcCollect() (bool, error) {
+	api.logger.Debug("debug_resumeCcCollect")
+	return api.setCcCollectPaused(false)
+}
+
+func (api *debugAPI) setCcCollectPaused(paused bool) (bool, error) {
+	if api.ethAPI.backend.AdminOpsRequireSigs() {
+		return false, errAdminOpNeedsSigs
+	}
+	if !api.ethAPI.backend.SetCcCollectPaused(paused) {
+		return false, nil
+	}
+	action := audit.ActionResumeCcCollect
+	if paused {
+		action = audit.ActionPauseCcCollect
+	}
+	api.ethAPI.backend.RecordAudit(action, audit.SourceRpc, nil)
+	return true, nil
+}
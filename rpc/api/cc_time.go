@@ -0,0 +1,155 @@
+package api
+
+import (
+	"encoding/binary"
+	"math"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+
+	"github.com/smartbch/smartbch/crosschain"
+	cctypes "github.com/smartbch/smartbch/crosschain/types"
+	sbchrpctypes "github.com/smartbch/smartbch/rpc/types"
+	"github.com/smartbch/smartbch/watcher"
+)
+
+// the stages of a cross-chain transfer returned by sbch_estimateCrossChainTime
+const (
+	ccStageConfirming    = "confirming"    // the peg-in tx is not finalized yet
+	ccStageWaitingRescan = "waitingRescan" // the peg-in tx is finalized but not in the rescan window
+	ccStageWaitingHandle = "waitingHandle" // the peg-in tx is rescanned, it is minted by handleUTXOs
+	ccStagePlanned       = "planned"       // the peg-out is not sent yet
+	ccStageImmature      = "immature"      // the UTXO to redeem is minted too recently
+	ccStageSigning       = "signing"       // the peg-out is waiting for the operators
+	ccStageDone          = "done"
+)
+
+var (
+	errCrossChainNotStarted = sbchrpctypes.NewError(sbchrpctypes.ErrCodeUnavailable, sbchrpctypes.ReasonNotSupported,
+		"cross chain is not started")
+	errInvalidCrossChainKind = sbchrpctypes.NewError(sbchrpctypes.ErrCodeInvalidParams, sbchrpctypes.ReasonInvalidParams,
+		`kind must be "pegIn" or "pegOut"`)
+	errPegInTxidMissing = sbchrpctypes.NewError(sbchrpctypes.ErrCodeInvalidParams, sbchrpctypes.ReasonInvalidParams,
+		"txid of the peg-in is missing")
+)
+
+// EstimateCrossChainTime estimates when a peg-in is minted or a peg-out is paid, from the BCH block
+// interval, the finalization depth, the cadence of the rescans and the latency of the operators
+// signing the recent redeems, which are all observed by this node
+func (sbch sbchAPI) EstimateCrossChainTime(args sbchrpctypes.CrossChainTimeArgs) (*sbchrpctypes.CrossChainTimeEstimate, error) {
+	sbch.logger.Debug("sbch_estimateCrossChainTime")
+	ccCtx := sbch.backend.GetCcContext()
+	if ccCtx == nil {
+		return nil, errCrossChainNotStarted
+	}
+	var record *cctypes.UTXORecord
+	if args.Txid != nil {
+		var utxoId [36]byte
+		copy(utxoId[:32], args.Txid[:])
+		binary.BigEndian.PutUint32(utxoId[32:], uint32(args.Index))
+		if records := sbch.backend.GetUtxos([][36]byte{utxoId}); len(records) != 0 {
+			record = records[0]
+		}
+	}
+	timing := sbch.backend.GetCcTiming()
+	now := time.Now().Unix()
+	var stage string
+	var completion int64
+	switch args.Kind {
+	case "pegIn":
+		if args.Txid == nil {
+			return nil, errPegInTxidMissing
+		}
+		if record != nil {
+			stage, completion = ccStageDone, now
+		} else {
+			stage, completion = estimatePegIn(now, int64(args.Height), ccCtx, timing)
+		}
+	case "pegOut":
+		stage, completion = estimatePegOut(now, args.Txid != nil, record, timing)
+	default:
+		return nil, errInvalidCrossChainKind
+	}
+	if completion < now {
+		completion = now
+	}
+	return &sbchrpctypes.CrossChainTimeEstimate{
+		Stage:                stage,
+		Seconds:              hexutil.Uint64(completion - now),
+		CompletionTime:       hexutil.Uint64(completion),
+		Paused:               sbch.backend.IsCrossChainPaused(),
+		MainnetBlockInterval: hexutil.Uint64(timing.MainnetBlockInterval),
+		BlockFinalizeNumber:  hexutil.Uint64(timing.BlockFinalizeNumber),
+		RescanInterval:       hexutil.Uint64(rescanInterval(ccCtx, timing)),
+		UTXOHandleDelay:      hexutil.Uint64(crosschain.UTXOHandleDelay),
+		SignLatency:          hexutil.Uint64(timing.MedianSignLatency()),
+		SignLatencySamples:   hexutil.Uint64(len(timing.SignLatencies)),
+	}, nil
+}
+
+// rescanInterval is the seconds between the rescans, assuming the monitors keep covering as many
+// BCH blocks in a rescan as they did last time
+func rescanInterval(ccCtx *cctypes.CCContext, timing watcher.CcTiming) int64 {
+	blocks := int64(1)
+	if ccCtx.RescanHeight > ccCtx.LastRescannedHeight && ccCtx.LastRescannedHeight != 0 {
+		blocks = int64(ccCtx.RescanHeight - ccCtx.LastRescannedHeight)
+	}
+	interval := blocks * timing.MainnetBlockInterval
+	if interval < crosschain.UTXOHandleDelay {
+		interval = crosschain.UTXOHandleDelay
+	}
+	return interval
+}
+
+// estimatePegIn returns the stage of the peg-in tx included in the BCH block at height, which is 0
+// for a tx in the mempool, and the time it is minted: after the block is finalized, the next
+// rescan covers it, and handleUTXOs mints it after UTXOHandleDelay
+func estimatePegIn(now, height int64, ccCtx *cctypes.CCContext, timing watcher.CcTiming) (string, int64) {
+	tip := timing.LatestFinalizedHeight + timing.BlockFinalizeNumber
+	if height == 0 {
+		height = tip + 1
+	}
+	if height <= int64(ccCtx.LastRescannedHeight) || (height <= int64(ccCtx.RescanHeight) && ccCtx.UTXOAlreadyHandled) {
+		// handled without a UTXO record, or redeemed already
+		return ccStageDone, now
+	}
+	if height <= int64(ccCtx.RescanHeight) {
+		return ccStageWaitingHandle, ccCtx.RescanTime + crosschain.UTXOHandleDelay
+	}
+	stage := ccStageWaitingRescan
+	finalized := now
+	if blocksLeft := height + timing.BlockFinalizeNumber - tip; blocksLeft > 0 {
+		stage = ccStageConfirming
+		finalized += blocksLeft * timing.MainnetBlockInterval
+	}
+	rescan := finalized
+	if ccCtx.RescanTime != math.MaxInt64 {
+		// the rescans happen every interval since the last one, the first one after finalization covers the tx
+		interval := rescanInterval(ccCtx, timing)
+		rescan = ccCtx.RescanTime + interval
+		if rescan < finalized {
+			rescan += (finalized - rescan + interval - 1) / interval * interval
+		}
+	}
+	return stage, rescan + crosschain.UTXOHandleDelay
+}
+
+// estimatePegOut returns the stage of the peg-out redeeming record and the time its BCH is paid on
+// the main chain, which is the expected sign time plus the median signing latency. A peg-out whose
+// record is not found is either paid already or unknown to this node.
+func estimatePegOut(now int64, hasTxid bool, record *cctypes.UTXORecord, timing watcher.CcTiming) (string, int64) {
+	latency := timing.MedianSignLatency()
+	if !hasTxid {
+		return ccStagePlanned, now + crosschain.ExpectedRedeemSignTimeDelay + latency
+	}
+	if record == nil {
+		return ccStageDone, now
+	}
+	if record.IsRedeemed {
+		return ccStageSigning, record.ExpectedSignTime + latency
+	}
+	if mature := record.BornTime + crosschain.MatureTime + 1; record.BornTime != 0 && mature > now {
+		return ccStageImmature, mature + crosschain.ExpectedRedeemSignTimeDelay + latency
+	}
+	return ccStagePlanned, now + crosschain.ExpectedRedeemSignTimeDelay + latency
+}
@@ -0,0 +1,64 @@
+package api
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/smartbch/smartbch/crosschain"
+	cctypes "github.com/smartbch/smartbch/crosschain/types"
+	"github.com/smartbch/smartbch/watcher"
+)
+
+func TestEstimatePegIn(t *testing.T) {
+	timing := watcher.CcTiming{LatestFinalizedHeight: 1000, BlockFinalizeNumber: 9, MainnetBlockInterval: 600}
+	ccCtx := &cctypes.CCContext{LastRescannedHeight: 990, RescanHeight: 996, RescanTime: 10000, UTXOAlreadyHandled: false}
+	require.EqualValues(t, 3600, rescanInterval(ccCtx, timing))
+
+	stage, completion := estimatePegIn(12000, 985, ccCtx, timing)
+	require.Equal(t, ccStageDone, stage)
+	require.EqualValues(t, 12000, completion)
+
+	stage, completion = estimatePegIn(10001, 995, ccCtx, timing)
+	require.Equal(t, ccStageWaitingHandle, stage)
+	require.EqualValues(t, 10000+crosschain.UTXOHandleDelay, completion)
+
+	// finalized but after the window, covered by the next rescan
+	stage, completion = estimatePegIn(12000, 1000, ccCtx, timing)
+	require.Equal(t, ccStageWaitingRescan, stage)
+	require.EqualValues(t, 13600+crosschain.UTXOHandleDelay, completion)
+
+	// in the mempool: 10 blocks to finalize, then the first rescan after 18000 is at 20800
+	stage, completion = estimatePegIn(12000, 0, ccCtx, timing)
+	require.Equal(t, ccStageConfirming, stage)
+	require.EqualValues(t, 20800+crosschain.UTXOHandleDelay, completion)
+
+	ccCtx.RescanTime = math.MaxInt64
+	_, completion = estimatePegIn(12000, 0, ccCtx, timing)
+	require.EqualValues(t, 18000+crosschain.UTXOHandleDelay, completion)
+}
+
+func TestEstimatePegOut(t *testing.T) {
+	timing := watcher.CcTiming{MainnetBlockInterval: 600}
+	require.EqualValues(t, 600, timing.MedianSignLatency())
+	timing.SignLatencies = []int64{100, 200, 900}
+	require.EqualValues(t, 200, timing.MedianSignLatency())
+
+	stage, completion := estimatePegOut(10000, false, nil, timing)
+	require.Equal(t, ccStagePlanned, stage)
+	require.EqualValues(t, 10200+crosschain.ExpectedRedeemSignTimeDelay, completion)
+
+	stage, completion = estimatePegOut(10000, true, nil, timing)
+	require.Equal(t, ccStageDone, stage)
+	require.EqualValues(t, 10000, completion)
+
+	stage, completion = estimatePegOut(10000, true, &cctypes.UTXORecord{IsRedeemed: true, ExpectedSignTime: 9900}, timing)
+	require.Equal(t, ccStageSigning, stage)
+	require.EqualValues(t, 10100, completion)
+
+	stage, _ = estimatePegOut(10000, true, &cctypes.UTXORecord{BornTime: 10000}, timing)
+	require.Equal(t, ccStageImmature, stage)
+	stage, _ = estimatePegOut(10000, true, &cctypes.UTXORecord{BornTime: 9000}, timing)
+	require.Equal(t, ccStagePlanned, stage)
+}
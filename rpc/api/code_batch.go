@@ -0,0 +1,61 @@
+package api
+
+import (
+	gethcmn "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	gethrpc "github.com/ethereum/go-ethereum/rpc"
+
+	sbchrpctypes "github.com/smartbch/smartbch/rpc/types"
+)
+
+// the max number of the addresses queried by one sbch_getCodeBatch call
+const maxCodeBatchAddresses = 1000
+
+var errTooManyCodeAddresses = sbchrpctypes.NewError(sbchrpctypes.ErrCodeLimitExceeded, sbchrpctypes.ReasonTooManyResults,
+	"too many addresses to get the code of").WithHint("split the addresses into batches of at most 1000")
+
+// GetCodeBatch returns the code hashes of the addresses, and the code of each distinct hash once,
+// so scanning thousands of contracts, most of which are clones, does not download the same code
+// again and again. The codes of knownCodeHashes, such as the ones got by the previous batches, are
+// not returned.
+func (sbch sbchAPI) GetCodeBatch(addrs []gethcmn.Address, blockNrOrHash gethrpc.BlockNumberOrHash,
+	knownCodeHashes *[]gethcmn.Hash) (*sbchrpctypes.CodeBatch, error) {
+
+	sbch.logger.Debug("sbch_getCodeBatch")
+	if len(addrs) > maxCodeBatchAddresses {
+		return nil, errTooManyCodeAddresses
+	}
+	height, err := getHeightArg(sbch.backend, blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+	var known []gethcmn.Hash
+	if knownCodeHashes != nil {
+		known = *knownCodeHashes
+	}
+	codes, codeHashes := sbch.backend.GetCodes(addrs, height)
+	return buildCodeBatch(addrs, codes, codeHashes, known), nil
+}
+
+func buildCodeBatch(addrs []gethcmn.Address, codes, codeHashes [][]byte, known []gethcmn.Hash) *sbchrpctypes.CodeBatch {
+	skipped := make(map[gethcmn.Hash]bool, len(known))
+	for _, h := range known {
+		skipped[h] = true
+	}
+	batch := &sbchrpctypes.CodeBatch{
+		CodeHashes: make(map[gethcmn.Address]*gethcmn.Hash, len(addrs)),
+		Codes:      make(map[gethcmn.Hash]hexutil.Bytes),
+	}
+	for i, addr := range addrs {
+		if len(codes[i]) == 0 {
+			batch.CodeHashes[addr] = nil
+			continue
+		}
+		h := gethcmn.BytesToHash(codeHashes[i])
+		batch.CodeHashes[addr] = &h
+		if !skipped[h] {
+			batch.Codes[h] = codes[i]
+		}
+	}
+	return batch
+}
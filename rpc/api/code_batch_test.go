@@ -0,0 +1,30 @@
+package api
+
+import (
+	"testing"
+
+	gethcmn "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildCodeBatch(t *testing.T) {
+	addrs := []gethcmn.Address{{1}, {2}, {3}, {4}}
+	codeA, codeB := []byte{0x60, 0x01}, []byte{0x60, 0x02}
+	hashA, hashB := crypto.Keccak256Hash(codeA), crypto.Keccak256Hash(codeB)
+	codes := [][]byte{codeA, codeA, nil, codeB}
+	codeHashes := [][]byte{hashA[:], hashA[:], nil, hashB[:]}
+
+	batch := buildCodeBatch(addrs, codes, codeHashes, nil)
+	require.Equal(t, &hashA, batch.CodeHashes[addrs[0]])
+	require.Equal(t, &hashA, batch.CodeHashes[addrs[1]])
+	require.Nil(t, batch.CodeHashes[addrs[2]])
+	require.Contains(t, batch.CodeHashes, addrs[2])
+	require.Equal(t, map[gethcmn.Hash]hexutil.Bytes{hashA: codeA, hashB: codeB}, batch.Codes)
+
+	// the known codes are only referenced
+	batch = buildCodeBatch(addrs, codes, codeHashes, []gethcmn.Hash{hashA})
+	require.Equal(t, &hashA, batch.CodeHashes[addrs[0]])
+	require.Equal(t, map[gethcmn.Hash]hexutil.Bytes{hashB: codeB}, batch.Codes)
+}
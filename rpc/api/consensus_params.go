@@ -0,0 +1,72 @@
+package api
+
+import (
+	"sort"
+	"strconv"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	tmproto "github.com/tendermint/tendermint/proto/tendermint/types"
+
+	"github.com/smartbch/smartbch/app"
+	"github.com/smartbch/smartbch/param"
+	sbchrpctypes "github.com/smartbch/smartbch/rpc/types"
+)
+
+// GetConsensusParams returns the current consensus params, and their changes known by this node.
+// Tendermint only keeps the height of the last change of its params in the latest state, and
+// the changes of the on-chain app params are recorded since the node started.
+func (sbch sbchAPI) GetConsensusParams() *sbchrpctypes.ConsensusParams {
+	sbch.logger.Debug("sbch_getConsensusParams")
+	tmParams, since := sbch.backend.ConsensusParams()
+	return buildConsensusParams(sbch.backend.LatestHeight(), tmParams, since, sbch.backend.GetParamChanges())
+}
+
+func buildConsensusParams(height int64, tmParams tmproto.ConsensusParams, since int64,
+	appChanges []*app.ParamChange) *sbchrpctypes.ConsensusParams {
+
+	result := &sbchrpctypes.ConsensusParams{
+		Height:                  hexutil.Uint64(height),
+		BlockMaxBytes:           hexutil.Uint64(tmParams.Block.MaxBytes),
+		BlockMaxGas:             hexutil.Uint64(tmParams.Block.MaxGas),
+		BlockTimeIotaMs:         hexutil.Uint64(tmParams.Block.TimeIotaMs),
+		EvidenceMaxAgeNumBlocks: hexutil.Uint64(tmParams.Evidence.MaxAgeNumBlocks),
+		EvidenceMaxAgeDuration:  tmParams.Evidence.MaxAgeDuration.String(),
+		EvidenceMaxBytes:        hexutil.Uint64(tmParams.Evidence.MaxBytes),
+		ValidatorPubKeyTypes:    tmParams.Validator.PubKeyTypes,
+		AppVersion:              hexutil.Uint64(tmParams.Version.AppVersion),
+		TendermintParamsSince:   hexutil.Uint64(since),
+		MaxTxGas:                hexutil.Uint64(param.MaxTxGasLimit),
+	}
+	tmValues := []struct {
+		name  string
+		value int64
+	}{
+		{"blockMaxBytes", tmParams.Block.MaxBytes},
+		{"blockMaxGas", tmParams.Block.MaxGas},
+		{"evidenceMaxAgeNumBlocks", tmParams.Evidence.MaxAgeNumBlocks},
+		{"evidenceMaxBytes", tmParams.Evidence.MaxBytes},
+	}
+	for _, v := range tmValues {
+		result.History = append(result.History, &sbchrpctypes.ParamChange{
+			Height:   hexutil.Uint64(since),
+			Name:     v.name,
+			NewValue: strconv.FormatInt(v.value, 10),
+		})
+	}
+	for _, c := range appChanges {
+		result.History = append(result.History, &sbchrpctypes.ParamChange{
+			Height:   hexutil.Uint64(c.Height),
+			Name:     c.Name,
+			OldValue: c.OldValue,
+			NewValue: c.NewValue,
+		})
+		if c.Name == app.ParamMinGasPrice {
+			price, _ := strconv.ParseUint(c.NewValue, 10, 64)
+			result.MinGasPrice = hexutil.Uint64(price)
+		}
+	}
+	sort.SliceStable(result.History, func(i, j int) bool {
+		return result.History[i].Height < result.History[j].Height
+	})
+	return result
+}
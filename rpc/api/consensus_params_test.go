@@ -0,0 +1,33 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	tmproto "github.com/tendermint/tendermint/proto/tendermint/types"
+
+	"github.com/smartbch/smartbch/app"
+	"github.com/smartbch/smartbch/param"
+)
+
+func TestBuildConsensusParams(t *testing.T) {
+	tmParams := tmproto.ConsensusParams{
+		Block:     tmproto.BlockParams{MaxBytes: param.BlockMaxBytes, MaxGas: param.BlockMaxGas, TimeIotaMs: 1000},
+		Evidence:  tmproto.EvidenceParams{MaxAgeNumBlocks: 100000, MaxAgeDuration: 48 * time.Hour, MaxBytes: 1048576},
+		Validator: tmproto.ValidatorParams{PubKeyTypes: []string{"ed25519"}},
+	}
+	changes := []*app.ParamChange{
+		{Height: 5, Name: app.ParamMinGasPrice, NewValue: "10000000000"},
+		{Height: 20, Name: app.ParamMinGasPrice, OldValue: "10000000000", NewValue: "20000000000"},
+	}
+	result := buildConsensusParams(30, tmParams, 10, changes)
+	require.EqualValues(t, param.BlockMaxGas, result.BlockMaxGas)
+	require.Equal(t, "48h0m0s", result.EvidenceMaxAgeDuration)
+	require.EqualValues(t, 10, result.TendermintParamsSince)
+	require.EqualValues(t, 20000000000, result.MinGasPrice)
+	require.Len(t, result.History, 6)
+	require.EqualValues(t, 5, result.History[0].Height)
+	require.Equal(t, "blockMaxBytes", result.History[1].Name)
+	require.EqualValues(t, 20, result.History[5].Height)
+}
@@ -8,16 +8,50 @@ import (
 
 	gethcmn "github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
+	gethrpc "github.com/ethereum/go-ethereum/rpc"
 	"github.com/mackerelio/go-osstat/memory"
 	"github.com/tendermint/tendermint/libs/log"
 
+	"github.com/smartbch/smartbch/app"
+	"github.com/smartbch/smartbch/internal/audit"
+	"github.com/smartbch/smartbch/internal/diag"
+	sbchrpctypes "github.com/smartbch/smartbch/rpc/types"
 	stakingtypes "github.com/smartbch/smartbch/staking/types"
+	watchertypes "github.com/smartbch/smartbch/watcher/types"
 )
 
 const (
 	StatusUpdateInterval = 60 // seconds
+	maxVoteInfoRange     = 100
+	maxIdleContracts     = 1000
 )
 
+var (
+	errInvalidBlockRange = sbchrpctypes.NewError(sbchrpctypes.ErrCodeInvalidParams, sbchrpctypes.ReasonInvalidBlockRange,
+		"invalid block range")
+	errBlockRangeTooLong = sbchrpctypes.NewError(sbchrpctypes.ErrCodeLimitExceeded, sbchrpctypes.ReasonBlockRangeTooLong,
+		"block range is too long")
+	errInvalidEpochRange = sbchrpctypes.NewError(sbchrpctypes.ErrCodeInvalidParams, sbchrpctypes.ReasonInvalidParams,
+		"invalid epoch range")
+	errWitnessNotRecorded = sbchrpctypes.NewError(sbchrpctypes.ErrCodeNotFound, sbchrpctypes.ReasonNotRecorded,
+		"the witness of this block is not recorded")
+	errStateAccessNotTracked = sbchrpctypes.NewError(sbchrpctypes.ErrCodeUnavailable, sbchrpctypes.ReasonNotSupported,
+		"state-access-tracking-path is not configured")
+	errTxNotFound = sbchrpctypes.NewError(sbchrpctypes.ErrCodeNotFound, sbchrpctypes.ReasonNotFound,
+		"transaction not found")
+	errInvalidTraceFormat = sbchrpctypes.NewError(sbchrpctypes.ErrCodeInvalidParams, sbchrpctypes.ReasonInvalidParams,
+		`format must be "callTree" or "flamegraph"`)
+)
+
+// PeerScore counts the invalid txs sent by a peer, see peer-ban-invalid-txs in app.toml
+type PeerScore struct {
+	PeerID      string         `json:"peerId"`
+	InvalidTxs  hexutil.Uint64 `json:"invalidTxs"`
+	RecentTxs   hexutil.Uint64 `json:"recentInvalidTxs"`
+	BannedUntil hexutil.Uint64 `json:"bannedUntil"`
+	BannedTimes hexutil.Uint64 `json:"bannedTimes"`
+}
+
 type Stats struct {
 	NumGoroutine     int    `json:"numGoroutine"`
 	NumGC            uint32 `json:"numGC"`
@@ -35,12 +69,57 @@ type Stats struct {
 	NumEthCall       uint64 `json:"numEthCall"`
 }
 
+// AddressTrace is the full trace of a transaction touching some traced addresses
+type AddressTrace struct {
+	BlockNumber hexutil.Uint64    `json:"blockNumber"`
+	TxHash      gethcmn.Hash      `json:"transactionHash"`
+	From        gethcmn.Address   `json:"from"`
+	To          gethcmn.Address   `json:"to"`
+	Touched     []gethcmn.Address `json:"touched"`
+	CallDetail  *CallDetail       `json:"callDetail"`
+}
+
+// BlockWitness is the state read by a block, with the content before the block was executed.
+// Each entry of MoeingADS is proved against parentStateRoot.
+type BlockWitness struct {
+	BlockNumber     hexutil.Uint64  `json:"blockNumber"`
+	ParentStateRoot gethcmn.Hash    `json:"parentStateRoot"`
+	Entries         []*WitnessEntry `json:"entries"`
+	Size            hexutil.Uint64  `json:"size"`
+}
+
+// WitnessEntry is an entry of MoeingADS read by a block, which keeps key and value under shortKey.
+// The value is null if the entry is a hole passed by the lookups of other keys.
+type WitnessEntry struct {
+	ShortKey   hexutil.Bytes `json:"shortKey"`
+	Key        hexutil.Bytes `json:"key"`
+	Value      hexutil.Bytes `json:"value"`
+	EntryBytes hexutil.Bytes `json:"entryBytes"`
+	Proof      hexutil.Bytes `json:"proof"`
+}
+
 type DebugAPI interface {
 	GetStats() Stats
 	GetSeq(addr gethcmn.Address) hexutil.Uint64
 	NodeInfo() json.RawMessage
 	ValidatorOnlineInfos() json.RawMessage
 	WatcherHeight() hexutil.Uint64
+	GasProfile(fromBlock, toBlock gethrpc.BlockNumber, limit *hexutil.Uint64) (*GasProfileReport, error)
+	AddTracedAddress(addr gethcmn.Address) (bool, error)
+	RemoveTracedAddress(addr gethcmn.Address) (bool, error)
+	RescanCc(begin, end hexutil.Uint64) (bool, error)
+	PauseCcCollect() (bool, error)
+	ResumeCcCollect() (bool, error)
+	GetTracedAddresses() []gethcmn.Address
+	GetAddressTraces(addr gethcmn.Address, fromBlock, toBlock gethrpc.BlockNumber) ([]*AddressTrace, error)
+	GetBlockWitness(blockNum gethrpc.BlockNumber) (*BlockWitness, error)
+	PreviewBlock() *BlockPreview
+	GetPeerScores() []*PeerScore
+	GetDiagnostics() *diag.Report
+	GetVoteInfos(start, end hexutil.Uint64) ([]*watchertypes.VoteInfo, error)
+	GetIdleStateReport(idleBlocks, top hexutil.Uint64) (*app.StateAccessReport, error)
+	SimulateStateRent(policies []app.StateRentPolicy) ([]*app.StateRentResult, error)
+	TraceTransaction(txHash gethcmn.Hash, config *TraceTxConfig) (interface{}, error)
 }
 
 type debugAPI struct {
@@ -74,6 +153,225 @@ func (api *debugAPI) WatcherHeight() hexutil.Uint64 {
 	return hexutil.Uint64(h)
 }
 
+// GasProfile aggregates the gas used in [fromBlock, toBlock] by contract and by selector
+func (api *debugAPI) GasProfile(fromBlock, toBlock gethrpc.BlockNumber, limit *hexutil.Uint64) (*GasProfileReport, error) {
+	api.logger.Debug("debug_gasProfile")
+	latest := api.ethAPI.backend.LatestHeight()
+	if fromBlock == gethrpc.LatestBlockNumber {
+		fromBlock = gethrpc.BlockNumber(latest)
+	}
+	if toBlock == gethrpc.LatestBlockNumber {
+		toBlock = gethrpc.BlockNumber(latest)
+	}
+	if fromBlock < 0 || toBlock < fromBlock || toBlock.Int64() > latest {
+		return nil, errInvalidBlockRange
+	}
+	if toBlock-fromBlock >= maxGasProfileBlockRange {
+		return nil, errBlockRangeTooLong
+	}
+	n := defaultGasProfileLimit
+	if limit != nil {
+		n = int(*limit)
+	}
+
+	profiler := newGasProfiler()
+	for h := fromBlock; h <= toBlock; h++ {
+		txs, _, err := api.ethAPI.backend.GetTxListByHeight(uint32(h))
+		if err != nil {
+			return nil, err
+		}
+		for _, tx := range txs {
+			profiler.addTx(tx)
+		}
+	}
+	return profiler.report(uint64(fromBlock), uint64(toBlock), n), nil
+}
+
+// AddTracedAddress makes the node record the full traces of the transactions touching addr
+func (api *debugAPI) AddTracedAddress(addr gethcmn.Address) (bool, error) {
+	api.logger.Debug("debug_addTracedAddress")
+	if api.ethAPI.backend.AdminOpsRequireSigs() {
+		return false, errAdminOpNeedsSigs
+	}
+	added := api.ethAPI.backend.AddTracedAddress(addr)
+	if added {
+		api.ethAPI.backend.RecordAudit(audit.ActionAddTracedAddress, audit.SourceRpc,
+			map[string]string{"address": addr.Hex()})
+	}
+	return added, nil
+}
+
+func (api *debugAPI) RemoveTracedAddress(addr gethcmn.Address) (bool, error) {
+	api.logger.Debug("debug_removeTracedAddress")
+	if api.ethAPI.backend.AdminOpsRequireSigs() {
+		return false, errAdminOpNeedsSigs
+	}
+	removed := api.ethAPI.backend.RemoveTracedAddress(addr)
+	if removed {
+		api.ethAPI.backend.RecordAudit(audit.ActionRemoveTracedAddress, audit.SourceRpc,
+			map[string]string{"address": addr.Hex()})
+	}
+	return removed, nil
+}
+
+func (api *debugAPI) GetTracedAddresses() []gethcmn.Address {
+	api.logger.Debug("debug_getTracedAddresses")
+	return api.ethAPI.backend.GetTracedAddresses()
+}
+
+// GetAddressTraces returns the traces recorded for addr in [fromBlock, toBlock]. Only the
+// transactions committed after addr was registered with debug_addTracedAddress are recorded.
+func (api *debugAPI) GetAddressTraces(addr gethcmn.Address, fromBlock, toBlock gethrpc.BlockNumber) ([]*AddressTrace, error) {
+	api.logger.Debug("debug_getAddressTraces")
+	latest := api.ethAPI.backend.LatestHeight()
+	if fromBlock == gethrpc.LatestBlockNumber {
+		fromBlock = gethrpc.BlockNumber(latest)
+	}
+	if toBlock == gethrpc.LatestBlockNumber {
+		toBlock = gethrpc.BlockNumber(latest)
+	}
+	if fromBlock < 0 || toBlock < fromBlock {
+		return nil, errInvalidBlockRange
+	}
+	tracedTxs := api.ethAPI.backend.GetAddressTraces(addr, fromBlock.Int64(), toBlock.Int64())
+	traces := make([]*AddressTrace, len(tracedTxs))
+	for i, tracedTx := range tracedTxs {
+		traces[i] = &AddressTrace{
+			BlockNumber: hexutil.Uint64(tracedTx.Height),
+			TxHash:      tracedTx.Tx.Hash,
+			From:        tracedTx.Tx.From,
+			To:          tracedTx.Tx.To,
+			Touched:     tracedTx.Touched,
+			CallDetail:  TxToRpcCallDetail(tracedTx.Tx),
+		}
+	}
+	return traces, nil
+}
+
+// GetBlockWitness returns the state read by the block. Only the witnesses of the recent
+// witness-kept-blocks blocks committed since the node started are kept.
+func (api *debugAPI) GetBlockWitness(blockNum gethrpc.BlockNumber) (*BlockWitness, error) {
+	api.logger.Debug("debug_getBlockWitness")
+	if blockNum == gethrpc.LatestBlockNumber {
+		blockNum = gethrpc.BlockNumber(api.ethAPI.backend.LatestHeight())
+	}
+	w := api.ethAPI.backend.GetBlockWitness(blockNum.Int64())
+	if w == nil {
+		return nil, errWitnessNotRecorded
+	}
+	witness := &BlockWitness{
+		BlockNumber:     hexutil.Uint64(w.Height),
+		ParentStateRoot: w.ParentStateRoot,
+		Entries:         make([]*WitnessEntry, len(w.Entries)),
+		Size:            hexutil.Uint64(w.Size()),
+	}
+	for i, e := range w.Entries {
+		witness.Entries[i] = &WitnessEntry{
+			ShortKey:   e.ShortKey[:],
+			Key:        e.Key,
+			Value:      e.Value,
+			EntryBytes: e.EntryBytes,
+			Proof:      e.Proof,
+		}
+	}
+	return witness, nil
+}
+
+// PreviewBlock returns the block this node would propose now, by reaping its mempool in the way
+// of the proposer
+func (api *debugAPI) PreviewBlock() *BlockPreview {
+	api.logger.Debug("debug_previewBlock")
+	return buildBlockPreview(api.ethAPI.backend.ReapBlockTxs(), api.ethAPI.txDecoder)
+}
+
+// GetPeerScores returns the peers which have sent invalid txs, it is empty if peer scoring is disabled
+func (api *debugAPI) GetPeerScores() []*PeerScore {
+	api.logger.Debug("debug_getPeerScores")
+	scores := api.ethAPI.backend.GetPeerScores()
+	result := make([]*PeerScore, len(scores))
+	for i, score := range scores {
+		result[i] = &PeerScore{
+			PeerID:      score.PeerID,
+			InvalidTxs:  hexutil.Uint64(score.InvalidTxs),
+			RecentTxs:   hexutil.Uint64(score.RecentTxs),
+			BannedUntil: hexutil.Uint64(score.BannedUntil),
+			BannedTimes: hexutil.Uint64(score.BannedTimes),
+		}
+	}
+	return result
+}
+
+// GetDiagnostics returns the goroutines by subsystem and the occupancy of the hot channels in the
+// last sample, with the suspected leaks and stalls flagged
+func (api *debugAPI) GetDiagnostics() *diag.Report {
+	api.logger.Debug("debug_getDiagnostics")
+	return diag.Latest()
+}
+
+// GetVoteInfos returns the epochs and the monitor votes in [start, end) from the state, at most
+// maxVoteInfoRange of them, which "smartbchd export-epoch-snapshot" saves as an epoch snapshot
+func (api *debugAPI) GetVoteInfos(start, end hexutil.Uint64) ([]*watchertypes.VoteInfo, error) {
+	api.logger.Debug("debug_getVoteInfos")
+	if end <= start {
+		return nil, errInvalidEpochRange
+	}
+	if end-start > maxVoteInfoRange {
+		end = start + maxVoteInfoRange
+	}
+	return api.ethAPI.backend.GetVoteInfos(uint64(start), uint64(end))
+}
+
+// TraceTransaction returns the internal calls of a committed transaction, as a list in pre-order
+// for the "callTree" format, or as a FlamegraphTrace weighted by gas for the "flamegraph" format
+func (api *debugAPI) TraceTransaction(txHash gethcmn.Hash, config *TraceTxConfig) (interface{}, error) {
+	api.logger.Debug("debug_traceTransaction")
+	format := traceFormatCallTree
+	if config != nil && config.Format != "" {
+		format = config.Format
+	}
+	if format != traceFormatCallTree && format != traceFormatFlamegraph {
+		return nil, errInvalidTraceFormat
+	}
+	tx, _, err := api.ethAPI.backend.GetTransaction(txHash)
+	if err != nil || tx == nil {
+		return nil, errTxNotFound
+	}
+	if format == traceFormatFlamegraph {
+		return &FlamegraphTrace{
+			TxHash:  txHash.Hex(),
+			GasUsed: hexutil.Uint64(tx.GasUsed),
+			Folded:  buildFoldedStacks(tx),
+		}, nil
+	}
+	return buildInternalCallList(tx.InternalTxCalls, tx.InternalTxReturns), nil
+}
+
+// GetIdleStateReport counts the accounts, bytecodes and storage slots not accessed in the last
+// idleBlocks blocks, and lists the top contracts with the most idle storage. The state not accessed
+// since the tracking started is unknown, so the report is only meaningful for an idleBlocks much
+// smaller than the blocks tracked.
+func (api *debugAPI) GetIdleStateReport(idleBlocks, top hexutil.Uint64) (*app.StateAccessReport, error) {
+	api.logger.Debug("debug_getIdleStateReport")
+	if top > maxIdleContracts {
+		top = maxIdleContracts
+	}
+	report := api.ethAPI.backend.GetIdleStateReport(int64(idleBlocks), int(top))
+	if report == nil {
+		return nil, errStateAccessNotTracked
+	}
+	return report, nil
+}
+
+// SimulateStateRent returns how much of the tracked state each rent policy would evict now
+func (api *debugAPI) SimulateStateRent(policies []app.StateRentPolicy) ([]*app.StateRentResult, error) {
+	api.logger.Debug("debug_simulateStateRent")
+	results := api.ethAPI.backend.SimulateStateRent(policies)
+	if results == nil {
+		return nil, errStateAccessNotTracked
+	}
+	return results, nil
+}
+
 func (api *debugAPI) GetStats() Stats {
 	api.logger.Debug("debug_getStats")
 
@@ -0,0 +1,59 @@
+package api
+
+import (
+	"github.com/ethereum/go-ethereum/common/hexutil"
+
+	sbchrpctypes "github.com/smartbch/smartbch/rpc/types"
+	"github.com/smartbch/smartbch/watcher"
+)
+
+var (
+	errEpochArchiveDisabled = sbchrpctypes.NewError(sbchrpctypes.ErrCodeNotSupported, sbchrpctypes.ReasonNotSupported,
+		"the epochs are not archived by this node").WithHint("set watcher-epoch-archive-path")
+)
+
+// GetArchivedEpochs returns the epochs numbered from start to end, inclusively, which are kept in
+// the epoch archive of the watcher, with their monitor votes
+func (sbch sbchAPI) GetArchivedEpochs(start, end hexutil.Uint64) ([]*sbchrpctypes.ArchivedEpoch, error) {
+	sbch.logger.Debug("sbch_getArchivedEpochs")
+	if end < start {
+		return nil, errInvalidEpochRange
+	}
+	if end-start >= maxEpochsPerPage {
+		return nil, errTooManyEpochs
+	}
+	epochs, ok := sbch.backend.GetArchivedEpochs(int64(start), int64(end))
+	if !ok {
+		return nil, errEpochArchiveDisabled
+	}
+	result := make([]*sbchrpctypes.ArchivedEpoch, len(epochs))
+	for i, e := range epochs {
+		result[i] = castArchivedEpoch(e)
+	}
+	return result, nil
+}
+
+// GetArchivedEpochByHeight returns the archived epoch containing the BCH block at height, or nil if
+// it is not archived
+func (sbch sbchAPI) GetArchivedEpochByHeight(height hexutil.Uint64) (*sbchrpctypes.ArchivedEpoch, error) {
+	sbch.logger.Debug("sbch_getArchivedEpochByHeight")
+	e, ok := sbch.backend.GetArchivedEpochByHeight(int64(height))
+	if !ok {
+		return nil, errEpochArchiveDisabled
+	}
+	if e == nil {
+		return nil, nil
+	}
+	return castArchivedEpoch(e), nil
+}
+
+func castArchivedEpoch(e *watcher.ArchivedEpoch) *sbchrpctypes.ArchivedEpoch {
+	result := &sbchrpctypes.ArchivedEpoch{Epoch: castEpochInfo(&e.Epoch, false)}
+	if e.MonitorVote != nil {
+		// the number is assigned when it is applied, see GetMonitorVoteInfos of the watcher
+		info := *e.MonitorVote
+		info.EndTime = e.Epoch.EndTime
+		result.MonitorVote = castMonitorVoteInfo(&info)
+	}
+	return result
+}
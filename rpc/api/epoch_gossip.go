@@ -0,0 +1,26 @@
+package api
+
+import (
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+
+	sbchrpctypes "github.com/smartbch/smartbch/rpc/types"
+	"github.com/smartbch/smartbch/watcher"
+	watchertypes "github.com/smartbch/smartbch/watcher/types"
+)
+
+var errEpochGossipNotServed = sbchrpctypes.NewError(sbchrpctypes.ErrCodeUnavailable, sbchrpctypes.ReasonNotSupported,
+	"this node does not serve the signed epochs").WithHint("set epoch-gossip-serve on a validator node")
+
+// GetSignedVoteInfos returns the vote infos published by the watcher of this validator, from the
+// epoch starting at the BCH height startHeight on, signed with its consensus key. The validators
+// whose BCH nodes are unreachable take the epochs signed by a quorum.
+func (sbch sbchAPI) GetSignedVoteInfos(startHeight hexutil.Uint64) (*watchertypes.SignedVoteInfos, error) {
+	sbch.logger.Debug("sbch_getSignedVoteInfos")
+	infos, err := sbch.backend.GetSignedVoteInfos(int64(startHeight))
+	if errors.Is(err, watcher.ErrEpochGossipNotServed) {
+		return nil, errEpochGossipNotServed
+	}
+	return infos, err
+}
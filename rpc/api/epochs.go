@@ -0,0 +1,100 @@
+package api
+
+import (
+	"github.com/ethereum/go-ethereum/common/hexutil"
+
+	"github.com/smartbch/smartbch/param"
+	sbchrpctypes "github.com/smartbch/smartbch/rpc/types"
+	stakingtypes "github.com/smartbch/smartbch/staking/types"
+)
+
+// the max number of the epochs returned by one sbch_getEpochs call
+const maxEpochsPerPage = 100
+
+var (
+	errInvalidEpochFilter = sbchrpctypes.NewError(sbchrpctypes.ErrCodeInvalidInput, sbchrpctypes.ReasonInvalidFilter,
+		"toHeight is before fromHeight")
+	errTooManyEpochs = sbchrpctypes.NewError(sbchrpctypes.ErrCodeLimitExceeded, sbchrpctypes.ReasonTooManyResults,
+		"too many epochs requested").WithHint("use a limit of at most 100 and page with the offset")
+)
+
+// GetEpochs returns the applied epochs overlapping a range of BCH heights, in the ascending order
+// of their numbers, and optionally the epoch being built by the watcher after them
+func (sbch sbchAPI) GetEpochs(filter sbchrpctypes.EpochFilter) (*sbchrpctypes.EpochPage, error) {
+	sbch.logger.Debug("sbch_getEpochs")
+	if filter.ToHeight != 0 && filter.ToHeight < filter.FromHeight {
+		return nil, errInvalidEpochFilter
+	}
+	if filter.Limit > maxEpochsPerPage {
+		return nil, errTooManyEpochs
+	}
+	var epochs []*sbchrpctypes.EpochInfo
+	if currEpochNum := sbch.backend.ValidatorsInfo().CurrEpochNum; currEpochNum > 0 {
+		infos, err := sbch.backend.GetVoteInfos(1, uint64(currEpochNum)+1)
+		if err != nil {
+			return nil, err
+		}
+		for _, info := range infos {
+			epochs = append(epochs, castEpochInfo(&info.Epoch, false))
+		}
+	}
+	if filter.IncludeCurrent {
+		curr, err := sbch.backend.GetCurrEpoch()
+		if err != nil {
+			return nil, err
+		}
+		epochs = append(epochs, castEpochInfo(curr, true))
+	}
+	return filterEpochs(epochs, filter), nil
+}
+
+// GetEpochByNumber returns the applied epoch with the number, or nil if it is not applied yet
+func (sbch sbchAPI) GetEpochByNumber(number hexutil.Uint64) (*sbchrpctypes.EpochInfo, error) {
+	sbch.logger.Debug("sbch_getEpochByNumber")
+	if number == 0 {
+		return nil, nil
+	}
+	infos, err := sbch.backend.GetVoteInfos(uint64(number), uint64(number)+1)
+	if err != nil || len(infos) == 0 {
+		return nil, err
+	}
+	return castEpochInfo(&infos[0].Epoch, false), nil
+}
+
+func castEpochInfo(epoch *stakingtypes.Epoch, current bool) *sbchrpctypes.EpochInfo {
+	info := &sbchrpctypes.EpochInfo{
+		StartHeight: hexutil.Uint64(epoch.StartHeight),
+		EndHeight:   hexutil.Uint64(epoch.StartHeight + param.CurrBchNetwork().NumBlocksInEpoch - 1),
+		EndTime:     epoch.EndTime,
+		Nominations: castNominations(epoch.Nominations),
+		Current:     current,
+	}
+	if !current {
+		info.Number = hexutil.Uint64(epoch.Number)
+	}
+	return info
+}
+
+func filterEpochs(epochs []*sbchrpctypes.EpochInfo, filter sbchrpctypes.EpochFilter) *sbchrpctypes.EpochPage {
+	var matched []*sbchrpctypes.EpochInfo
+	for _, e := range epochs {
+		if e.EndHeight < filter.FromHeight || (filter.ToHeight != 0 && e.StartHeight > filter.ToHeight) {
+			continue
+		}
+		matched = append(matched, e)
+	}
+	page := &sbchrpctypes.EpochPage{Epochs: []*sbchrpctypes.EpochInfo{}, Total: hexutil.Uint64(len(matched))}
+	if uint64(filter.Offset) >= uint64(len(matched)) {
+		return page
+	}
+	matched = matched[filter.Offset:]
+	limit := uint64(filter.Limit)
+	if limit == 0 {
+		limit = maxEpochsPerPage
+	}
+	if uint64(len(matched)) > limit {
+		matched = matched[:limit]
+	}
+	page.Epochs = matched
+	return page
+}
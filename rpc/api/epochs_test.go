@@ -0,0 +1,38 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/stretchr/testify/require"
+
+	sbchrpctypes "github.com/smartbch/smartbch/rpc/types"
+)
+
+func TestFilterEpochs(t *testing.T) {
+	var epochs []*sbchrpctypes.EpochInfo
+	for i := uint64(1); i <= 5; i++ {
+		epochs = append(epochs, &sbchrpctypes.EpochInfo{
+			Number:      hexutil.Uint64(i),
+			StartHeight: hexutil.Uint64(i*10 + 1),
+			EndHeight:   hexutil.Uint64(i*10 + 10),
+		})
+	}
+	page := filterEpochs(epochs, sbchrpctypes.EpochFilter{})
+	require.EqualValues(t, 5, page.Total)
+	require.Len(t, page.Epochs, 5)
+
+	// the epochs 2, 3 and 4 overlap [30, 41]
+	page = filterEpochs(epochs, sbchrpctypes.EpochFilter{FromHeight: 30, ToHeight: 41})
+	require.EqualValues(t, 3, page.Total)
+	require.EqualValues(t, 2, page.Epochs[0].Number)
+
+	page = filterEpochs(epochs, sbchrpctypes.EpochFilter{FromHeight: 30, Offset: 1, Limit: 2})
+	require.EqualValues(t, 4, page.Total)
+	require.Len(t, page.Epochs, 2)
+	require.EqualValues(t, 3, page.Epochs[0].Number)
+
+	page = filterEpochs(epochs, sbchrpctypes.EpochFilter{Offset: 5})
+	require.EqualValues(t, 5, page.Total)
+	require.Empty(t, page.Epochs)
+}
@@ -6,24 +6,103 @@ import (
 
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common/hexutil"
-	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/tendermint/tendermint/mempool"
+
+	sbchapi "github.com/smartbch/smartbch/api"
+	"github.com/smartbch/smartbch/app"
+	sbchrpctypes "github.com/smartbch/smartbch/rpc/types"
 )
 
-const defaultErrorCode = -32000
+func newInvalidTxError(err error) error {
+	return sbchrpctypes.NewError(sbchrpctypes.ErrCodeInvalidInput, sbchrpctypes.ReasonInvalidTx, err.Error())
+}
+
+func newInvalidTxArgsError(msg string) error {
+	return sbchrpctypes.NewError(sbchrpctypes.ErrCodeInvalidInput, sbchrpctypes.ReasonInvalidTxArgs, msg)
+}
 
-var _ rpc.Error = callError{}
+func newUnknownAccountError(msg string) error {
+	return sbchrpctypes.NewError(sbchrpctypes.ErrCodeInvalidInput, sbchrpctypes.ReasonUnknownAccount, msg).
+		WithHint("the account must be unlocked on this node with the --unlock flag")
+}
+
+func newInvalidAdminOpError(msg string) error {
+	return sbchrpctypes.NewError(sbchrpctypes.ErrCodeInvalidInput, sbchrpctypes.ReasonInvalidAdminOp, msg)
+}
 
-type callError struct {
-	msg  string
-	code int
+// checkTxReasons maps the CheckTx codes of the app to the reasons of the errors
+var checkTxReasons = map[uint32]string{
+	app.CannotDecodeTx:       sbchrpctypes.ReasonInvalidTx,
+	app.CannotRecoverSender:  sbchrpctypes.ReasonInvalidSender,
+	app.SenderNotFound:       sbchrpctypes.ReasonSenderNotFound,
+	app.AccountNonceMismatch: sbchrpctypes.ReasonNonceMismatch,
+	app.CannotPayGasFee:      sbchrpctypes.ReasonInsufficientFunds,
+	app.GasLimitInvalid:      sbchrpctypes.ReasonInvalidGasLimit,
+	app.InvalidMinGasPrice:   sbchrpctypes.ReasonGasPriceTooLow,
+	app.HasPendingTx:         sbchrpctypes.ReasonPendingTxExists,
+	app.MempoolBusy:          sbchrpctypes.ReasonMempoolBusy,
+	app.GasLimitTooSmall:     sbchrpctypes.ReasonInvalidGasLimit,
+	app.SenderFrozen:         sbchrpctypes.ReasonSenderFrozen,
+	app.TxCanceled:           sbchrpctypes.ReasonTxCanceled,
+	app.TxVetoed:             sbchrpctypes.ReasonTxVetoed,
 }
 
-func (err callError) Error() string {
-	return err.msg
+var checkTxHints = map[uint32]string{
+	app.AccountNonceMismatch: "get the nonce with eth_getTransactionCount or sbch_getNonceStatus",
+	app.CannotPayGasFee:      "the balance must cover gas*gasPrice+value",
+	app.InvalidMinGasPrice:   "get the min gas price with eth_gasPrice",
+	app.GasLimitInvalid:      "lower the gas limit, or wait for the pending txs of the sender to be committed",
+	app.GasLimitTooSmall:     "estimate the gas limit with eth_estimateGas",
+	app.HasPendingTx:         "wait for the pending tx of the sender to be committed",
+	app.MempoolBusy:          "retry later",
 }
 
-func (err callError) ErrorCode() int {
-	return err.code
+// toTxRejectedError turns the error of broadcasting a tx to the mempool into an error with the reason
+func toTxRejectedError(err error) error {
+	var checkTxErr *sbchapi.CheckTxError
+	if errors.As(err, &checkTxErr) {
+		reason, ok := checkTxReasons[checkTxErr.Code]
+		if !ok {
+			reason = sbchrpctypes.ReasonTxRejected
+		}
+		rpcErr := sbchrpctypes.NewError(sbchrpctypes.ErrCodeTxRejected, reason, checkTxErr.Info).
+			WithHint(checkTxHints[checkTxErr.Code])
+		rpcErr.Data.AppCode = checkTxErr.Code
+		return rpcErr
+	}
+	var fullErr mempool.ErrMempoolIsFull
+	var tooLargeErr mempool.ErrTxTooLarge
+	switch {
+	case errors.Is(err, sbchapi.ErrSafeMode):
+		return sbchrpctypes.NewError(sbchrpctypes.ErrCodeUnavailable, sbchrpctypes.ReasonSafeMode, err.Error()).
+			WithHint("send the transaction to another node")
+	case errors.Is(err, mempool.ErrTxInCache):
+		return sbchrpctypes.NewError(sbchrpctypes.ErrCodeTxRejected, sbchrpctypes.ReasonAlreadyKnown, err.Error())
+	case errors.As(err, &fullErr):
+		return sbchrpctypes.NewError(sbchrpctypes.ErrCodeTxRejected, sbchrpctypes.ReasonMempoolFull, err.Error()).
+			WithHint("retry later")
+	case errors.As(err, &tooLargeErr):
+		return sbchrpctypes.NewError(sbchrpctypes.ErrCodeTxRejected, sbchrpctypes.ReasonTxTooLarge, err.Error())
+	}
+	return sbchrpctypes.NewError(sbchrpctypes.ErrCodeTxRejected, sbchrpctypes.ReasonTxRejected, err.Error())
+}
+
+// toRpcError gives a reason to the known errors of the backend, the other errors are returned as is
+func toRpcError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, sbchapi.ErrNotLocalTx), errors.Is(err, sbchapi.ErrTxCommitted):
+		return sbchrpctypes.NewError(sbchrpctypes.ErrCodeNotFound, sbchrpctypes.ReasonNotFound, err.Error())
+	case errors.Is(err, sbchapi.ErrNotTxSender):
+		return sbchrpctypes.NewError(sbchrpctypes.ErrCodeInvalidParams, sbchrpctypes.ReasonInvalidParams, err.Error())
+	case errors.Is(err, app.ErrNotDevMode):
+		return sbchrpctypes.NewError(sbchrpctypes.ErrCodeNotSupported, sbchrpctypes.ReasonDevModeOnly, err.Error()).
+			WithHint("start the node with --dev")
+	case errors.Is(err, app.ErrNegativeTimeIncrease), errors.Is(err, app.ErrTimestampTooSmall):
+		return sbchrpctypes.NewError(sbchrpctypes.ErrCodeInvalidParams, sbchrpctypes.ReasonInvalidParams, err.Error())
+	}
+	return err
 }
 
 // revertError
@@ -52,7 +131,7 @@ type revertError struct {
 // ErrorCode returns the JSON error code for a revertal.
 // See: https://eth.wiki/json-rpc/json-rpc-error-codes-improvement-proposal
 func (e *revertError) ErrorCode() int {
-	return 3
+	return sbchrpctypes.ErrCodeExecutionReverted
 }
 
 // ErrorData returns the hex encoded revert reason.
@@ -0,0 +1,56 @@
+package api
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/mempool"
+
+	sbchapi "github.com/smartbch/smartbch/api"
+	"github.com/smartbch/smartbch/app"
+	sbchrpctypes "github.com/smartbch/smartbch/rpc/types"
+)
+
+func TestTxRejectedError(t *testing.T) {
+	err := toTxRejectedError(&sbchapi.CheckTxError{Code: app.AccountNonceMismatch, Info: "bad nonce"})
+	require.Equal(t, "bad nonce", err.Error())
+	require.Equal(t, sbchrpctypes.ErrCodeTxRejected, err.(rpc.Error).ErrorCode())
+	data, ok := sbchrpctypes.ParseErrorData(err)
+	require.True(t, ok)
+	require.Equal(t, sbchrpctypes.ReasonNonceMismatch, data.Reason)
+	require.Equal(t, app.AccountNonceMismatch, data.AppCode)
+	require.NotEmpty(t, data.Hint)
+
+	data, _ = sbchrpctypes.ParseErrorData(toTxRejectedError(&sbchapi.CheckTxError{Code: 999}))
+	require.Equal(t, sbchrpctypes.ReasonTxRejected, data.Reason)
+	data, _ = sbchrpctypes.ParseErrorData(toTxRejectedError(mempool.ErrTxInCache))
+	require.Equal(t, sbchrpctypes.ReasonAlreadyKnown, data.Reason)
+	data, _ = sbchrpctypes.ParseErrorData(toTxRejectedError(errors.New("other")))
+	require.Equal(t, sbchrpctypes.ReasonTxRejected, data.Reason)
+
+	err = toTxRejectedError(sbchapi.ErrSafeMode)
+	require.Equal(t, sbchrpctypes.ErrCodeUnavailable, err.(rpc.Error).ErrorCode())
+	data, _ = sbchrpctypes.ParseErrorData(err)
+	require.Equal(t, sbchrpctypes.ReasonSafeMode, data.Reason)
+}
+
+func TestRpcErrorReasons(t *testing.T) {
+	err := toRpcError(app.ErrNotDevMode)
+	require.Equal(t, app.ErrNotDevMode.Error(), err.Error())
+	data, ok := sbchrpctypes.ParseErrorData(err)
+	require.True(t, ok)
+	require.Equal(t, sbchrpctypes.ReasonDevModeOnly, data.Reason)
+
+	other := errors.New("other")
+	require.Equal(t, other, toRpcError(other))
+	require.Nil(t, toRpcError(nil))
+
+	require.True(t, errors.Is(errNoTxToIndex, errNoTxFromIndex)) // the same reason
+	require.False(t, errors.Is(errNoTxToIndex, errCrossChainPaused))
+
+	_, ok = sbchrpctypes.ParseErrorData(newRevertError([]byte{1, 2}))
+	require.False(t, ok)
+	require.Equal(t, "0x0102", newRevertError([]byte{1, 2}).ErrorData())
+}
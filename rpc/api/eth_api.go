@@ -3,7 +3,6 @@ package api
 import (
 	"crypto/ecdsa"
 	"encoding/hex"
-	"errors"
 	"fmt"
 	"math/big"
 	"sort"
@@ -15,6 +14,7 @@ import (
 	"github.com/ethereum/go-ethereum/crypto"
 	gethrpc "github.com/ethereum/go-ethereum/rpc"
 
+	"github.com/tendermint/tendermint/crypto/ed25519"
 	"github.com/tendermint/tendermint/libs/log"
 	tmrpc "github.com/tendermint/tendermint/rpc/core"
 
@@ -23,7 +23,9 @@ import (
 	sbchapi "github.com/smartbch/smartbch/api"
 	"github.com/smartbch/smartbch/internal/ethutils"
 	rpctypes "github.com/smartbch/smartbch/rpc/internal/ethapi"
+	sbchrpctypes "github.com/smartbch/smartbch/rpc/types"
 	"github.com/smartbch/smartbch/staking"
+	"github.com/smartbch/smartbch/txcodec"
 )
 
 const (
@@ -45,8 +47,10 @@ var fakeBlock0 = &types.Block{
 var _ PublicEthAPI = (*ethAPI)(nil)
 
 var (
-	errPendingBlockNum = errors.New("pending block is not supported")
-	errFutureBlockNum  = errors.New("block has not been mined")
+	errPendingBlockNum = sbchrpctypes.NewError(sbchrpctypes.ErrCodeNotSupported, sbchrpctypes.ReasonPendingBlock,
+		"pending block is not supported")
+	errFutureBlockNum = sbchrpctypes.NewError(sbchrpctypes.ErrCodeInvalidParams, sbchrpctypes.ReasonFutureBlock,
+		"block has not been mined")
 )
 
 type PublicEthAPI interface {
@@ -80,17 +84,19 @@ type PublicEthAPI interface {
 }
 
 type ethAPI struct {
-	backend  sbchapi.BackendService
-	accounts map[common.Address]*ecdsa.PrivateKey // only for test
-	logger   log.Logger
-	numCall  uint64
+	backend   sbchapi.BackendService
+	accounts  map[common.Address]*ecdsa.PrivateKey // only for test
+	txDecoder *txcodec.Decoder
+	logger    log.Logger
+	numCall   uint64
 }
 
 func newEthAPI(backend sbchapi.BackendService, testKeys []string, logger log.Logger) *ethAPI {
 	return &ethAPI{
-		backend:  backend,
-		accounts: loadTestAccounts(testKeys, logger),
-		logger:   logger,
+		backend:   backend,
+		accounts:  loadTestAccounts(testKeys, logger),
+		txDecoder: txcodec.NewDecoder(txcodec.DefaultConfig(backend.ChainId())),
+		logger:    logger,
 	}
 }
 
@@ -143,7 +149,15 @@ func (api *ethAPI) ChainId() hexutil.Uint64 {
 // https://eth.wiki/json-rpc/API#eth_coinbase
 func (api *ethAPI) Coinbase() (common.Address, error) {
 	api.logger.Debug("eth_coinbase")
-	// TODO: this is temporary implementation
+	// the coinbase of a validator node is the reward address of its validator
+	pubKey := api.backend.NodeInfo().ConsensusPubKey
+	if len(pubKey) != ed25519.PubKeySize {
+		return common.Address{}, nil
+	}
+	consAddr := common.BytesToAddress(ed25519.PubKey(pubKey).Address().Bytes())
+	if info := api.backend.GetProposerInfo(consAddr); info != nil {
+		return info.RewardTo, nil
+	}
 	return common.Address{}, nil
 }
 
@@ -217,7 +231,7 @@ func (api *ethAPI) GetBlockByHash(hash common.Hash, fullTx bool) (map[string]int
 	var txs []*types.Transaction
 	var sigs [][65]byte
 	if hash == zeroHash {
-		return blockToRpcResp(fakeBlock0, txs, sigs), nil
+		return blockToRpcResp(fakeBlock0, fakeBlock0.Miner, txs, sigs), nil
 	}
 	block, err := api.backend.BlockByHash(hash)
 	if err != nil {
@@ -234,7 +248,7 @@ func (api *ethAPI) GetBlockByHash(hash common.Hash, fullTx bool) (map[string]int
 		}
 	}
 
-	return blockToRpcResp(block, txs, sigs), nil
+	return blockToRpcResp(block, getMiner(api.backend, block.Miner), txs, sigs), nil
 }
 
 // https://eth.wiki/json-rpc/API#eth_getBlockByNumber
@@ -256,7 +270,7 @@ func (api *ethAPI) GetBlockByNumber(blockNum gethrpc.BlockNumber, fullTx bool) (
 			return nil, err
 		}
 	}
-	return blockToRpcResp(block, txs, sigs), nil
+	return blockToRpcResp(block, getMiner(api.backend, block.Miner), txs, sigs), nil
 }
 
 // https://eth.wiki/json-rpc/API#eth_getBlockTransactionCountByHash
@@ -406,15 +420,15 @@ func (api *ethAPI) ProtocolVersion() hexutil.Uint {
 // https://eth.wiki/json-rpc/API#eth_sendRawTransaction
 func (api *ethAPI) SendRawTransaction(data hexutil.Bytes) (common.Hash, error) {
 	api.logger.Debug("eth_sendRawTransaction")
-	tx, err := ethutils.DecodeTx(data)
+	tx, _, err := api.txDecoder.DecodeAndVerify(data)
 	if err != nil {
-		return common.Hash{}, err
+		return common.Hash{}, newInvalidTxError(err)
 	}
 
 	tmTxHash, err := api.backend.SendRawTx(data)
 	if err != nil {
 		fmt.Printf("eth_sendRawTransaction err:%s\n", err.Error())
-		return tmTxHash, err
+		return tmTxHash, toTxRejectedError(err)
 	}
 
 	return tx.Hash(), nil
@@ -425,7 +439,7 @@ func (api *ethAPI) SendTransaction(args rpctypes.SendTxArgs) (common.Hash, error
 	api.logger.Debug("eth_sendTransaction")
 	privKey, found := api.accounts[args.From]
 	if !found {
-		return common.Hash{}, errors.New("unknown account: " + args.From.Hex())
+		return common.Hash{}, newUnknownAccountError("unknown account: " + args.From.Hex())
 	}
 
 	if args.Nonce == nil {
@@ -452,7 +466,7 @@ func (api *ethAPI) SendTransaction(args rpctypes.SendTxArgs) (common.Hash, error
 
 	tmTxHash, err := api.backend.SendRawTx(txBytes)
 	if err != nil {
-		return tmTxHash, err
+		return tmTxHash, toTxRejectedError(err)
 	}
 
 	txHash := tx.Hash()
@@ -569,6 +583,16 @@ func createGethTxFromCallArgs(args rpctypes.CallArgs,
 func (api *ethAPI) getHeightArg(blockNum gethrpc.BlockNumberOrHash) (int64, error) {
 	return getHeightArg(api.backend, blockNum)
 }
+
+// getMiner returns the reward address of the validator whose consensus address is consAddr,
+// or consAddr itself if the validator is unknown
+func getMiner(backend sbchapi.BackendService, consAddr [20]byte) common.Address {
+	if info := backend.GetProposerInfo(consAddr); info != nil {
+		return info.RewardTo
+	}
+	return consAddr
+}
+
 func getHeightArg(backend sbchapi.BackendService, blockNrOrHash gethrpc.BlockNumberOrHash) (int64, error) {
 	if !backend.IsArchiveMode() {
 		return -1, nil
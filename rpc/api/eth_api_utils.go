@@ -3,8 +3,6 @@ package api
 import (
 	"bytes"
 	"encoding/hex"
-	"errors"
-	"fmt"
 	"math/big"
 
 	gethcmn "github.com/ethereum/go-ethereum/common"
@@ -17,6 +15,7 @@ import (
 	"github.com/smartbch/smartbch/internal/ethutils"
 	"github.com/smartbch/smartbch/param"
 	rpctypes "github.com/smartbch/smartbch/rpc/internal/ethapi"
+	sbchrpctypes "github.com/smartbch/smartbch/rpc/types"
 )
 
 func createGethTxFromSendTxArgs(args rpctypes.SendTxArgs) (*gethtypes.Transaction, error) {
@@ -33,13 +32,13 @@ func createGethTxFromSendTxArgs(args rpctypes.SendTxArgs) (*gethtypes.Transactio
 	}
 
 	if args.Nonce == nil {
-		return nil, errors.New("no nonce")
+		return nil, newInvalidTxArgsError("no nonce")
 	} else {
 		nonce = (uint64)(*args.Nonce)
 	}
 
 	if args.Data != nil && args.Input != nil && !bytes.Equal(*args.Data, *args.Input) {
-		return nil, errors.New(`both "data" and "input" are set and not equal. Please use "input" to pass transaction call data`)
+		return nil, newInvalidTxArgsError(`both "data" and "input" are set and not equal. Please use "input" to pass transaction call data`)
 	}
 
 	var input []byte
@@ -50,7 +49,7 @@ func createGethTxFromSendTxArgs(args rpctypes.SendTxArgs) (*gethtypes.Transactio
 	}
 
 	if args.To == nil && len(input) == 0 {
-		return nil, fmt.Errorf("contract creation without any data provided")
+		return nil, newInvalidTxArgsError("contract creation without any data provided")
 	}
 	if args.Gas == nil {
 		//return nil, errors.New("no gas limit")
@@ -69,7 +68,9 @@ func createGethTxFromSendTxArgs(args rpctypes.SendTxArgs) (*gethtypes.Transactio
 	return tx, nil
 }
 
-func blockToRpcResp(block *types.Block, txs []*types.Transaction, sigs [][65]byte) map[string]interface{} {
+// blockToRpcResp returns the block with miner as its "miner", the consensus address of the
+// proposer in block.Miner is returned as "proposer"
+func blockToRpcResp(block *types.Block, miner gethcmn.Address, txs []*types.Transaction, sigs [][65]byte) map[string]interface{} {
 	result := map[string]interface{}{
 		"number":           hexutil.Uint64(block.Number),
 		"hash":             hexutil.Bytes(block.Hash[:]),
@@ -79,7 +80,8 @@ func blockToRpcResp(block *types.Block, txs []*types.Transaction, sigs [][65]byt
 		"logsBloom":        gethtypes.Bloom{},
 		"transactionsRoot": hexutil.Bytes(block.TransactionsRoot[:]),
 		"stateRoot":        hexutil.Bytes(block.StateRoot[:]),
-		"miner":            hexutil.Bytes(block.Miner[:]),
+		"miner":            miner,
+		"proposer":         hexutil.Bytes(block.Miner[:]),
 		"mixHash":          gethcmn.Hash{},
 		"difficulty":       hexutil.Uint64(0),
 		"totalDifficulty":  hexutil.Uint64(0),
@@ -196,8 +198,8 @@ func toCallErr(statusCode int, retData []byte) error {
 	case "revert":
 		return newRevertError(retData)
 	case "invalid-instruction":
-		return callError{code: defaultErrorCode, msg: "invalid opcode"}
+		return sbchrpctypes.NewError(sbchrpctypes.ErrCodeInvalidInput, sbchrpctypes.ReasonInvalidOpcode, "invalid opcode")
 	default:
-		return callError{code: defaultErrorCode, msg: statusStr}
+		return sbchrpctypes.NewError(sbchrpctypes.ErrCodeInvalidInput, sbchrpctypes.ReasonExecutionFailed, statusStr)
 	}
 }
@@ -0,0 +1,120 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/tendermint/tendermint/libs/log"
+
+	sbchapi "github.com/smartbch/smartbch/api"
+	"github.com/smartbch/smartbch/app"
+	sbchrpctypes "github.com/smartbch/smartbch/rpc/types"
+)
+
+const (
+	// evm_mine gives up if no block is committed in this duration
+	evmMineTimeout      = time.Minute
+	evmMinePollInterval = 100 * time.Millisecond
+)
+
+// The committed blocks of smartBCH can not be reverted, because the state of moeingads, the history
+// of moeingdb and the blocks of Tendermint would all have to be rolled back.
+var errSnapshotNotSupported = sbchrpctypes.NewError(sbchrpctypes.ErrCodeNotSupported, sbchrpctypes.ReasonNotSupported,
+	"evm_snapshot and evm_revert are not supported").
+	WithHint("restart the dev chain with a new home directory to start over")
+
+// The ganache/hardhat test methods, for the test suites written for them to be run against the dev chain
+type EvmAPI interface {
+	IncreaseTime(seconds numberOrHex) (int64, error)
+	SetNextBlockTimestamp(timestamp numberOrHex) error
+	Mine(ctx context.Context, timestamp *numberOrHex) (string, error)
+	Snapshot() (hexutil.Uint64, error)
+	Revert(id hexutil.Uint64) (bool, error)
+}
+
+type evmAPI struct {
+	backend sbchapi.BackendService
+	logger  log.Logger
+}
+
+func newEvmAPI(backend sbchapi.BackendService, logger log.Logger) EvmAPI {
+	return evmAPI{
+		backend: backend,
+		logger:  logger,
+	}
+}
+
+// numberOrHex is a JSON number or a hex string, the test tools send both
+type numberOrHex int64
+
+func (n *numberOrHex) UnmarshalJSON(input []byte) error {
+	var s string
+	if json.Unmarshal(input, &s) == nil {
+		v, err := hexutil.DecodeUint64(s)
+		if err != nil {
+			return err
+		}
+		*n = numberOrHex(v)
+		return nil
+	}
+	v, err := strconv.ParseInt(string(input), 10, 64)
+	if err != nil {
+		return err
+	}
+	*n = numberOrHex(v)
+	return nil
+}
+
+// IncreaseTime shifts block.timestamp of the following blocks by seconds, and returns the total shift
+func (evm evmAPI) IncreaseTime(seconds numberOrHex) (int64, error) {
+	evm.logger.Debug("evm_increaseTime")
+	total, err := evm.backend.IncreaseTime(int64(seconds))
+	return total, toRpcError(err)
+}
+
+func (evm evmAPI) SetNextBlockTimestamp(timestamp numberOrHex) error {
+	evm.logger.Debug("evm_setNextBlockTimestamp")
+	return toRpcError(evm.backend.SetNextBlockTimestamp(int64(timestamp)))
+}
+
+// Mine waits until the next block is committed. The dev chain makes a block as soon as a tx is
+// received, and an empty one every second if there is none, so mining on demand is not needed.
+func (evm evmAPI) Mine(ctx context.Context, timestamp *numberOrHex) (string, error) {
+	evm.logger.Debug("evm_mine")
+	if !evm.backend.IsDevMode() {
+		return "", toRpcError(app.ErrNotDevMode)
+	}
+	if timestamp != nil {
+		if err := evm.backend.SetNextBlockTimestamp(int64(*timestamp)); err != nil {
+			return "", toRpcError(err)
+		}
+	}
+	height := evm.backend.LatestHeight()
+	ctx, cancel := context.WithTimeout(ctx, evmMineTimeout)
+	defer cancel()
+	ticker := time.NewTicker(evmMinePollInterval)
+	defer ticker.Stop()
+	for evm.backend.LatestHeight() <= height {
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-ticker.C:
+		}
+	}
+	return "0x0", nil
+}
+
+// Snapshot and Revert are registered for the test tools calling them to get errSnapshotNotSupported,
+// instead of the error of an unknown method
+func (evm evmAPI) Snapshot() (hexutil.Uint64, error) {
+	evm.logger.Debug("evm_snapshot")
+	return 0, errSnapshotNotSupported
+}
+
+func (evm evmAPI) Revert(id hexutil.Uint64) (bool, error) {
+	evm.logger.Debug("evm_revert")
+	return false, errSnapshotNotSupported
+}
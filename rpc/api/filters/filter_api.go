@@ -2,7 +2,6 @@ package filters
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"sync"
 	"time"
@@ -16,6 +15,7 @@ import (
 
 	motypes "github.com/smartbch/moeingevm/types"
 	mapi "github.com/smartbch/smartbch/api"
+	sbchrpctypes "github.com/smartbch/smartbch/rpc/types"
 )
 
 var _ PublicFilterAPI = (*filterAPI)(nil)
@@ -31,16 +31,17 @@ type PublicFilterAPI interface {
 	NewBlockFilter() rpc.ID
 	NewFilter(crit gethfilters.FilterCriteria) (rpc.ID, error)
 	UninstallFilter(id rpc.ID) bool
-	NewHeads(ctx context.Context) (*rpc.Subscription, error)
-	Logs(ctx context.Context, crit gethfilters.FilterCriteria) (*rpc.Subscription, error)
+	NewHeads(ctx context.Context, opts *SubscriptionOptions) (*rpc.Subscription, error)
+	Logs(ctx context.Context, crit gethfilters.FilterCriteria, opts *SubscriptionOptions) (*rpc.Subscription, error)
 }
 
 type filterAPI struct {
-	backend   mapi.BackendService
-	events    *EventSystem
-	filtersMu sync.Mutex
-	filters   map[rpc.ID]*filter
-	logger    log.Logger
+	backend    mapi.BackendService
+	events     *EventSystem
+	filtersMu  sync.Mutex
+	filters    map[rpc.ID]*filter
+	resumables *resumeRegistry
+	logger     log.Logger
 }
 
 // filter is a helper struct that holds meta information over the filter type
@@ -56,10 +57,11 @@ type filter struct {
 
 func NewAPI(backend mapi.BackendService, logger log.Logger) PublicFilterAPI {
 	_api := &filterAPI{
-		backend: backend,
-		filters: make(map[rpc.ID]*filter),
-		events:  NewEventSystem(backend, false),
-		logger:  logger,
+		backend:    backend,
+		filters:    make(map[rpc.ID]*filter),
+		events:     NewEventSystem(backend, false),
+		resumables: newResumeRegistry(),
+		logger:     logger,
 	}
 
 	go _api.timeoutLoop()
@@ -212,7 +214,8 @@ func (api *filterAPI) GetFilterChanges(id rpc.ID) (interface{}, error) {
 
 	f, found := api.filters[id]
 	if !found {
-		return nil, fmt.Errorf("filter %s not found", id)
+		return nil, sbchrpctypes.NewError(sbchrpctypes.ErrCodeNotFound, sbchrpctypes.ReasonNotFound,
+			fmt.Sprintf("filter %s not found", id))
 	}
 
 	if !f.deadline.Stop() {
@@ -233,10 +236,14 @@ func (api *filterAPI) GetFilterChanges(id rpc.ID) (interface{}, error) {
 		f.logs = []*gethtypes.Log{}
 		return returnLogs(logs), nil
 	default:
-		return nil, fmt.Errorf("invalid filter %s type %d", id, f.typ)
+		return nil, sbchrpctypes.NewError(sbchrpctypes.ErrCodeInvalidParams, sbchrpctypes.ReasonInvalidFilter,
+			fmt.Sprintf("invalid filter %s type %d", id, f.typ))
 	}
 }
 
+var errTooManyResults = sbchrpctypes.NewError(sbchrpctypes.ErrCodeLimitExceeded, sbchrpctypes.ReasonTooManyResults,
+	"too many potential results").WithHint("narrow the block range, or filter by more addresses and topics")
+
 // GetFilterLogs returns the logs for the filter with the given id.
 // If the filter could not be found an empty array of logs is returned.
 //
@@ -248,7 +255,7 @@ func (api *filterAPI) GetFilterLogs(id rpc.ID) ([]*gethtypes.Log, error) {
 	api.filtersMu.Unlock()
 
 	if !found || f.typ != LogsSubscription {
-		return nil, fmt.Errorf("filter not found")
+		return nil, sbchrpctypes.NewError(sbchrpctypes.ErrCodeNotFound, sbchrpctypes.ReasonNotFound, "filter not found")
 	}
 	return api.GetLogs(f.crit)
 }
@@ -286,17 +293,32 @@ func (api *filterAPI) GetLogs(crit gethfilters.FilterCriteria) ([]*gethtypes.Log
 		end = api.backend.LatestHeight()
 	}
 
-	if len(crit.Addresses) == 0 && len(crit.Topics) == 0 {
-		return api.getLogsByBlockNumberRange(begin, end+1)
+	addresses, topics := normalizeCriteria(crit.Addresses, crit.Topics)
+	// the positions which are not indexed are checked after querying moeingdb
+	indexedTopics := topics
+	if n := api.backend.GetModbIndexes().LogTopics; len(topics) > n {
+		indexedTopics = topics[:n]
+	}
+	if len(addresses) == 0 && !hasTopicRestriction(indexedTopics) {
+		logs, err := api.getLogsByBlockNumberRange(begin, end+1)
+		if err != nil || len(topics) == 0 {
+			return logs, err
+		}
+		// wildcard positions still require the logs to have enough topics
+		return append(make([]*gethtypes.Log, 0), filterLogs(logs, nil, nil, nil, topics)...), nil
 	}
 
-	logs, err := api.backend.QueryLogs(crit.Addresses, crit.Topics, uint32(begin), uint32(end+1), filterFunc)
+	logs, err := api.backend.QueryLogs(addresses, indexedTopics, uint32(begin), uint32(end+1), filterFunc)
 	if err != nil {
 		return nil, err
 	}
 	//fmt.Printf("Why? begin %d end %d logs %#v\n", begin, end, logs)
 
-	return motypes.ToGethLogs(logs), nil
+	result := sortAndDedupLogs(motypes.ToGethLogs(logs))
+	if len(indexedTopics) < len(topics) {
+		result = append(make([]*gethtypes.Log, 0), filterLogs(result, nil, nil, nil, topics)...)
+	}
+	return result, nil
 }
 
 func (api *filterAPI) getLogsByBlockNumberRange(begin, end int64) ([]*gethtypes.Log, error) {
@@ -314,7 +336,7 @@ func (api *filterAPI) getLogsByBlockNumberRange(begin, end int64) ([]*gethtypes.
 		}
 
 		if len(allLogs) > maxLogResults {
-			return nil, errors.New("too many potential results")
+			return nil, errTooManyResults
 		}
 	}
 
@@ -322,27 +344,48 @@ func (api *filterAPI) getLogsByBlockNumberRange(begin, end int64) ([]*gethtypes.
 }
 
 // NewHeads send a notification each time a new (header) block is appended to the chain.
-func (api *filterAPI) NewHeads(ctx context.Context) (*rpc.Subscription, error) {
+// A dropped subscription can be resumed with SubscriptionOptions.
+func (api *filterAPI) NewHeads(ctx context.Context, opts *SubscriptionOptions) (*rpc.Subscription, error) {
 	notifier, supported := rpc.NotifierFromContext(ctx)
 	if !supported {
 		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
 	}
+	lastHeight, err := api.resumeFrom(opts, BlocksSubscription)
+	if err != nil {
+		return nil, err
+	}
 
 	rpcSub := notifier.CreateSubscription()
 
 	go func() {
 		headers := make(chan *motypes.Header)
+		startHeight := api.backend.LatestHeight()
 		headersSub := api.events.SubscribeNewHeads(headers)
+		if lastHeight < 0 {
+			lastHeight = startHeight
+		} else {
+			lastHeight = api.replayHeads(notifier, rpcSub.ID, lastHeight, api.backend.LatestHeight())
+		}
 
 		for {
 			select {
 			case h := <-headers:
-				_ = notifier.Notify(rpcSub.ID, h)
+				height := int64(h.Number)
+				if height > lastHeight+1 { // the blocks committed while subscribing or replaying
+					lastHeight = api.replayHeads(notifier, rpcSub.ID, lastHeight, height-1)
+				}
+				if height <= lastHeight {
+					continue
+				}
+				if notifier.Notify(rpcSub.ID, h) == nil {
+					lastHeight = height
+				}
 			case <-rpcSub.Err():
 				headersSub.Unsubscribe()
 				return
 			case <-notifier.Closed():
 				headersSub.Unsubscribe()
+				api.resumables.drop(rpcSub.ID, BlocksSubscription, lastHeight)
 				return
 			}
 		}
@@ -352,34 +395,55 @@ func (api *filterAPI) NewHeads(ctx context.Context) (*rpc.Subscription, error) {
 }
 
 // Logs creates a subscription that fires for all new log that match the given filter criteria.
-func (api *filterAPI) Logs(ctx context.Context, crit gethfilters.FilterCriteria) (*rpc.Subscription, error) {
+// A dropped subscription can be resumed with SubscriptionOptions.
+func (api *filterAPI) Logs(ctx context.Context, crit gethfilters.FilterCriteria, opts *SubscriptionOptions) (*rpc.Subscription, error) {
 	notifier, supported := rpc.NotifierFromContext(ctx)
 	if !supported {
 		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
 	}
+	lastHeight, err := api.resumeFrom(opts, LogsSubscription)
+	if err != nil {
+		return nil, err
+	}
 
 	var (
 		rpcSub      = notifier.CreateSubscription()
 		matchedLogs = make(chan []*gethtypes.Log)
+		headers     = make(chan *motypes.Header)
+		startHeight = api.backend.LatestHeight()
 	)
 
 	logsSub, err := api.events.SubscribeLogs(ethereum.FilterQuery(crit), matchedLogs)
 	if err != nil {
 		return nil, err
 	}
+	// the headers tell the blocks whose logs are all delivered, even if none of them matches crit
+	headersSub := api.events.SubscribeNewHeads(headers)
 
 	go func() {
+		if lastHeight < 0 {
+			lastHeight = startHeight
+		} else {
+			lastHeight = api.replayLogs(notifier, rpcSub.ID, crit, lastHeight, api.backend.LatestHeight())
+		}
+
 		for {
 			select {
 			case logs := <-matchedLogs:
-				for _, _log := range logs {
-					_ = notifier.Notify(rpcSub.ID, &_log)
+				lastHeight = notifyLogs(notifier, rpcSub.ID, logs, lastHeight)
+			case h := <-headers:
+				// a block is published before its logs, so the logs of the previous block have been sent
+				if height := int64(h.Number) - 1; height > lastHeight {
+					lastHeight = height
 				}
 			case <-rpcSub.Err(): // client send an unsubscribe request
 				logsSub.Unsubscribe()
+				headersSub.Unsubscribe()
 				return
 			case <-notifier.Closed(): // connection dropped
 				logsSub.Unsubscribe()
+				headersSub.Unsubscribe()
+				api.resumables.drop(rpcSub.ID, LogsSubscription, lastHeight)
 				return
 			}
 		}
@@ -384,6 +384,60 @@ func TestGetLogs_addrFilter(t *testing.T) {
 	require.Len(t, logs, 4)
 }
 
+func TestGetLogs_addrListAndWildcards(t *testing.T) {
+	_app := testutils.CreateTestApp()
+	defer _app.Destroy()
+	_api := createFiltersAPI(_app)
+
+	addr1 := gethcmn.Address{0xA1}
+	addr2 := gethcmn.Address{0xA2}
+	block1 := testutils.NewMdbBlockBuilder().
+		Height(1).Hash(gethcmn.Hash{0xB1}).
+		Tx(gethcmn.Hash{0xC1}, types.Log{
+			Address: addr2,
+			Topics:  [][32]byte{{0xD1}, {0xD2}},
+		}).
+		Tx(gethcmn.Hash{0xC2}, types.Log{
+			Address: addr1,
+			Topics:  [][32]byte{{0xD1}},
+		}).
+		Build()
+	addBlock(_app, block1)
+	block2 := testutils.NewMdbBlockBuilder().
+		Height(2).Hash(gethcmn.Hash{0xB2}).
+		Tx(gethcmn.Hash{0xC3}, types.Log{
+			Address: addr1,
+			Topics:  [][32]byte{{0xD1}, {0xD3}},
+		}).
+		Build()
+	addBlock(_app, block2)
+
+	// duplicated addresses do not give duplicated logs, and logs are ordered by block
+	f1 := testutils.NewFilterBuilder().BlockRange(1, 2).Addresses(addr1, addr2, addr1).Build()
+	logs, err := _api.GetLogs(f1)
+	require.NoError(t, err)
+	require.Len(t, logs, 3)
+	require.Equal(t, gethcmn.Hash{0xC1}, logs[0].TxHash)
+	require.Equal(t, gethcmn.Hash{0xC2}, logs[1].TxHash)
+	require.Equal(t, gethcmn.Hash{0xC3}, logs[2].TxHash)
+
+	// [null, null] “at least two topics”
+	f2 := testutils.NewFilterBuilder().BlockRange(1, 2).Topics([][]gethcmn.Hash{{}, {}}).Build()
+	logs, err = _api.GetLogs(f2)
+	require.NoError(t, err)
+	require.Len(t, logs, 2)
+	require.Equal(t, gethcmn.Hash{0xC1}, logs[0].TxHash)
+	require.Equal(t, gethcmn.Hash{0xC3}, logs[1].TxHash)
+
+	// [[A, A], [B, C]] with duplicated topics
+	f3 := testutils.NewFilterBuilder().BlockRange(1, 2).
+		Topics([][]gethcmn.Hash{{{0xD1}, {0xD1}}, {{0xD2}, {0xD3}}}).
+		Build()
+	logs, err = _api.GetLogs(f3)
+	require.NoError(t, err)
+	require.Len(t, logs, 2)
+}
+
 func TestGetLogs_blockRangeFilter(t *testing.T) {
 	_app := testutils.CreateTestApp()
 	defer _app.Destroy()
@@ -19,7 +19,6 @@
 package filters
 
 import (
-	"fmt"
 	"sync"
 	"time"
 
@@ -32,6 +31,8 @@ import (
 	"github.com/ethereum/go-ethereum/rpc"
 
 	motypes "github.com/smartbch/moeingevm/types"
+	"github.com/smartbch/smartbch/internal/diag"
+	sbchrpctypes "github.com/smartbch/smartbch/rpc/types"
 )
 
 // Type determines the kind of filter and is used to put the filter in to
@@ -123,6 +124,11 @@ func NewEventSystem(backend Backend, lightMode bool) *EventSystem {
 		chainCh:       make(chan motypes.ChainEvent, chainEvChanSize),
 	}
 
+	// the queues from which the events are sent to the subscriptions, including the websocket ones
+	diag.RegisterChannel("filters.txs", func() (int, int) { return len(m.txsCh), cap(m.txsCh) })
+	diag.RegisterChannel("filters.logs", func() (int, int) { return len(m.logsCh), cap(m.logsCh) })
+	diag.RegisterChannel("filters.chain", func() (int, int) { return len(m.chainCh), cap(m.chainCh) })
+
 	// Subscribe events
 	m.txsSub = m.backend.SubscribeNewTxsEvent(m.txsCh)
 	m.logsSub = m.backend.SubscribeLogsEvent(m.logsCh)
@@ -220,7 +226,8 @@ func (es *EventSystem) SubscribeLogs(crit ethereum.FilterQuery, logs chan []*typ
 	if from >= 0 && to == rpc.LatestBlockNumber {
 		return es.subscribeLogs(crit, logs), nil
 	}
-	return nil, fmt.Errorf("invalid from and to block combination: from > to")
+	return nil, sbchrpctypes.NewError(sbchrpctypes.ErrCodeInvalidParams, sbchrpctypes.ReasonInvalidBlockRange,
+		"invalid from and to block combination: from > to")
 }
 
 // subscribeMinedPendingLogs creates a subscription that returned mined and
@@ -0,0 +1,76 @@
+package filters
+
+import (
+	"sort"
+
+	gethcmn "github.com/ethereum/go-ethereum/common"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+)
+
+// normalizeCriteria removes the duplicated addresses and topics, which would make the
+// indexer return the same log more than once. A position containing only duplicated
+// topics is still a restriction; an empty position is still a wildcard.
+func normalizeCriteria(addresses []gethcmn.Address, topics [][]gethcmn.Hash) ([]gethcmn.Address, [][]gethcmn.Hash) {
+	var addrList []gethcmn.Address
+	if len(addresses) != 0 {
+		addrList = make([]gethcmn.Address, 0, len(addresses))
+		seen := make(map[gethcmn.Address]struct{}, len(addresses))
+		for _, addr := range addresses {
+			if _, ok := seen[addr]; !ok {
+				seen[addr] = struct{}{}
+				addrList = append(addrList, addr)
+			}
+		}
+	}
+	var topicsList [][]gethcmn.Hash
+	if len(topics) != 0 {
+		topicsList = make([][]gethcmn.Hash, len(topics))
+		for i, sub := range topics {
+			seen := make(map[gethcmn.Hash]struct{}, len(sub))
+			for _, topic := range sub {
+				if _, ok := seen[topic]; !ok {
+					seen[topic] = struct{}{}
+					topicsList[i] = append(topicsList[i], topic)
+				}
+			}
+		}
+	}
+	return addrList, topicsList
+}
+
+// hasTopicRestriction returns false if all the positions are wildcards. Such a filter
+// cannot be served by the topic index, but it still requires logs to have enough topics.
+func hasTopicRestriction(topics [][]gethcmn.Hash) bool {
+	for _, sub := range topics {
+		if len(sub) != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// sortAndDedupLogs orders logs as geth does: by block, then by the index in block.
+// The indexer may return logs grouped by address or topic when the filter has OR lists.
+func sortAndDedupLogs(logs []*gethtypes.Log) []*gethtypes.Log {
+	sort.SliceStable(logs, func(i, j int) bool {
+		if logs[i].BlockNumber != logs[j].BlockNumber {
+			return logs[i].BlockNumber < logs[j].BlockNumber
+		}
+		if logs[i].TxIndex != logs[j].TxIndex {
+			return logs[i].TxIndex < logs[j].TxIndex
+		}
+		return logs[i].Index < logs[j].Index
+	})
+	result := logs[:0]
+	for _, log := range logs {
+		if len(result) > 0 && sameLog(result[len(result)-1], log) {
+			continue
+		}
+		result = append(result, log)
+	}
+	return result
+}
+
+func sameLog(a, b *gethtypes.Log) bool {
+	return a.BlockNumber == b.BlockNumber && a.TxHash == b.TxHash && a.Index == b.Index
+}
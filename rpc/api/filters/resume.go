@@ -0,0 +1,160 @@
+package filters
+
+import (
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	gethfilters "github.com/ethereum/go-ethereum/eth/filters"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	modbtypes "github.com/smartbch/moeingdb/types"
+	motypes "github.com/smartbch/moeingevm/types"
+	sbchrpctypes "github.com/smartbch/smartbch/rpc/types"
+)
+
+var (
+	resumeGracePeriod       = 2 * time.Minute // a subscription dropped with its connection can be resumed within this period
+	maxResumeBlocks   int64 = 1000            // the max number of blocks replayed when resuming a subscription
+
+	errUnknownResumeToken = sbchrpctypes.NewError(sbchrpctypes.ErrCodeNotFound, sbchrpctypes.ReasonNotFound,
+		"unknown or expired resume token")
+	errResumeTokenType = sbchrpctypes.NewError(sbchrpctypes.ErrCodeInvalidParams, sbchrpctypes.ReasonInvalidParams,
+		"the resume token belongs to another kind of subscription")
+)
+
+// SubscriptionOptions is the optional last parameter of eth_subscribe("newHeads") and
+// eth_subscribe("logs"). The id of a subscription is its resume token: when a client reconnects
+// within the grace period, it can pass the id of its dropped subscription as ResumeToken, then
+// the new subscription replays the blocks (or logs) after the last one delivered by the old
+// subscription, before streaming the new ones. The id of the new subscription is the next token.
+type SubscriptionOptions struct {
+	ResumeToken *rpc.ID `json:"resumeToken"`
+}
+
+// resumable is a subscription whose connection dropped
+type resumable struct {
+	typ        Type
+	lastHeight int64 // the height of the last block delivered
+	expiry     time.Time
+}
+
+type resumeRegistry struct {
+	mtx  sync.Mutex
+	subs map[rpc.ID]*resumable
+}
+
+func newResumeRegistry() *resumeRegistry {
+	return &resumeRegistry{subs: make(map[rpc.ID]*resumable)}
+}
+
+// drop keeps a subscription whose connection dropped for resumeGracePeriod
+func (r *resumeRegistry) drop(id rpc.ID, typ Type, lastHeight int64) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	now := time.Now()
+	for id, sub := range r.subs {
+		if now.After(sub.expiry) {
+			delete(r.subs, id)
+		}
+	}
+	r.subs[id] = &resumable{typ: typ, lastHeight: lastHeight, expiry: now.Add(resumeGracePeriod)}
+}
+
+// take returns the height of the last block delivered by a dropped subscription, a token can
+// only be used once
+func (r *resumeRegistry) take(token rpc.ID, typ Type) (int64, error) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	sub, ok := r.subs[token]
+	if !ok || time.Now().After(sub.expiry) {
+		return 0, errUnknownResumeToken
+	}
+	if sub.typ != typ {
+		return 0, errResumeTokenType
+	}
+	delete(r.subs, token)
+	return sub.lastHeight, nil
+}
+
+// resumeFrom returns the height of the last block delivered before, or -1 if the subscription
+// does not resume an old one
+func (api *filterAPI) resumeFrom(opts *SubscriptionOptions, typ Type) (int64, error) {
+	if opts == nil || opts.ResumeToken == nil {
+		return -1, nil
+	}
+	lastHeight, err := api.resumables.take(*opts.ResumeToken, typ)
+	if err != nil {
+		return 0, err
+	}
+	if n := api.backend.LatestHeight() - lastHeight; n > maxResumeBlocks {
+		return 0, sbchrpctypes.NewError(sbchrpctypes.ErrCodeLimitExceeded, sbchrpctypes.ReasonResumeGapTooLarge,
+			fmt.Sprintf("cannot resume the subscription, %d blocks are missed (max %d)", n, maxResumeBlocks)).
+			WithHint("subscribe again and fetch the missed blocks with eth_getLogs or eth_getBlockByNumber")
+	}
+	return lastHeight, nil
+}
+
+// replayHeads notifies the headers of the blocks (lastHeight, endHeight], it returns the height
+// of the last block delivered
+func (api *filterAPI) replayHeads(notifier *rpc.Notifier, id rpc.ID, lastHeight, endHeight int64) int64 {
+	for h := lastHeight + 1; h <= endHeight; h++ {
+		block, err := api.backend.BlockByNumber(h)
+		if err != nil {
+			break
+		}
+		blkInfo, err := block.MarshalMsg(nil)
+		if err != nil {
+			break
+		}
+		mdbBlock := &modbtypes.Block{Height: block.Number, BlockHash: block.Hash, BlockInfo: blkInfo}
+		if notifier.Notify(id, motypes.BlockToChainEvent(mdbBlock).BlockHeader) != nil {
+			break
+		}
+		lastHeight = h
+	}
+	return lastHeight
+}
+
+// replayLogs notifies the logs in the blocks (lastHeight, endHeight] which match crit, it
+// returns the height of the last block whose logs are delivered
+func (api *filterAPI) replayLogs(notifier *rpc.Notifier, id rpc.ID, crit gethfilters.FilterCriteria,
+	lastHeight, endHeight int64) int64 {
+
+	if endHeight <= lastHeight {
+		return lastHeight
+	}
+	crit.BlockHash = nil
+	crit.FromBlock = big.NewInt(lastHeight + 1)
+	crit.ToBlock = big.NewInt(endHeight)
+	logs, err := api.GetLogs(crit)
+	if err != nil {
+		api.logger.Debug("cannot replay logs", "from", lastHeight+1, "to", endHeight, "err", err)
+		return lastHeight
+	}
+	for _, _log := range logs {
+		if notifier.Notify(id, _log) != nil {
+			return int64(_log.BlockNumber) - 1
+		}
+	}
+	return endHeight
+}
+
+// notifyLogs notifies the logs in the blocks after lastHeight, it returns the height of the
+// last block whose logs are delivered
+func notifyLogs(notifier *rpc.Notifier, id rpc.ID, logs []*gethtypes.Log, lastHeight int64) int64 {
+	for _, _log := range logs {
+		if int64(_log.BlockNumber) <= lastHeight {
+			continue // delivered by replayLogs
+		}
+		if notifier.Notify(id, _log) != nil {
+			return int64(_log.BlockNumber) - 1
+		}
+	}
+	if len(logs) > 0 && int64(logs[len(logs)-1].BlockNumber) > lastHeight {
+		lastHeight = int64(logs[len(logs)-1].BlockNumber)
+	}
+	return lastHeight
+}
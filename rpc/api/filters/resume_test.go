@@ -0,0 +1,32 @@
+package filters
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResumeRegistry(t *testing.T) {
+	r := newResumeRegistry()
+	r.drop("0x1", BlocksSubscription, 100)
+	r.drop("0x2", LogsSubscription, 200)
+
+	_, err := r.take("0x3", BlocksSubscription)
+	require.Equal(t, errUnknownResumeToken, err)
+	_, err = r.take("0x2", BlocksSubscription)
+	require.Error(t, err)
+
+	h, err := r.take("0x1", BlocksSubscription)
+	require.NoError(t, err)
+	require.Equal(t, int64(100), h)
+	_, err = r.take("0x1", BlocksSubscription) // a token can only be used once
+	require.Equal(t, errUnknownResumeToken, err)
+
+	r.subs["0x2"].expiry = time.Now().Add(-time.Second)
+	_, err = r.take("0x2", LogsSubscription)
+	require.Equal(t, errUnknownResumeToken, err)
+	r.drop(rpc.ID("0x4"), LogsSubscription, 300) // removes the expired ones
+	require.Len(t, r.subs, 1)
+}
@@ -0,0 +1,86 @@
+package api
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+
+	motypes "github.com/smartbch/moeingevm/types"
+)
+
+// the formats of debug_traceTransaction
+const (
+	traceFormatCallTree   = "callTree"
+	traceFormatFlamegraph = "flamegraph"
+)
+
+// TraceTxConfig chooses the format of debug_traceTransaction, which is "callTree" by default
+type TraceTxConfig struct {
+	Format string `json:"format"`
+}
+
+// FlamegraphTrace is the call tree of a transaction weighted by gas, in the folded-stack format read
+// by flamegraph.pl, speedscope, inferno and the other flamegraph tools. Each line of Folded is a
+// call stack with its frames separated by ";", followed by a space and the gas used by the code of
+// the innermost frame itself, excluding its sub-calls.
+type FlamegraphTrace struct {
+	TxHash  string         `json:"txHash"`
+	GasUsed hexutil.Uint64 `json:"gasUsed"`
+	Folded  string         `json:"folded"`
+}
+
+// buildFoldedStacks returns the folded stacks of tx. The frames are "<callType>:<to>:<selector>",
+// the gas the transaction used outside the calls, such as the intrinsic gas, is attributed to a
+// "tx" frame at the bottom of all the stacks.
+func buildFoldedStacks(tx *motypes.Transaction) string {
+	calls := buildInternalCallList(tx.InternalTxCalls, tx.InternalTxReturns)
+	selfGas := getSelfGasUsed(calls)
+
+	var lines []string
+	weights := make(map[string]uint64)
+	add := func(stack string, gas uint64) {
+		if gas == 0 {
+			return
+		}
+		if _, ok := weights[stack]; !ok {
+			lines = append(lines, stack)
+		}
+		weights[stack] += gas
+	}
+
+	root := "tx"
+	var outerGas uint64
+	var stack []string
+	var depths []int32
+	for i, call := range calls {
+		for len(depths) > 0 && depths[len(depths)-1] >= call.depth {
+			stack, depths = stack[:len(stack)-1], depths[:len(depths)-1]
+		}
+		if len(depths) == 0 {
+			outerGas += uint64(call.GasUsed)
+		}
+		stack, depths = append(stack, flameFrame(call)), append(depths, call.depth)
+		add(root+";"+strings.Join(stack, ";"), selfGas[i])
+	}
+	if tx.GasUsed > outerGas {
+		add(root, tx.GasUsed-outerGas)
+	}
+
+	var sb strings.Builder
+	for _, line := range lines {
+		fmt.Fprintf(&sb, "%s %d\n", line, weights[line])
+	}
+	return sb.String()
+}
+
+func flameFrame(call *InternalTx) string {
+	callType := strings.SplitN(call.CallPath, "_", 2)[0]
+	if call.CreatedAddress != nil {
+		return callType + ":" + call.CreatedAddress.Hex()
+	}
+	if len(call.Input) < 4 {
+		return callType + ":" + call.To.Hex()
+	}
+	return callType + ":" + call.To.Hex() + ":" + hexutil.Encode(call.Input[:4])
+}
@@ -0,0 +1,33 @@
+package api
+
+import (
+	"testing"
+
+	gethcmn "github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+
+	motypes "github.com/smartbch/moeingevm/types"
+)
+
+func TestBuildFoldedStacks(t *testing.T) {
+	a := gethcmn.Address{0x0a}
+	b := gethcmn.Address{0x0b}
+	// A(1000) -> B(300), B(300) with the same selector
+	tx := &motypes.Transaction{
+		GasUsed: 22000,
+		InternalTxCalls: []motypes.InternalTxCall{
+			{Depth: 0, Gas: 10000, Destination: a, Input: []byte{1, 2, 3, 4, 5}},
+			{Depth: 1, Flags: callModeStaticCall, Gas: 5000, Destination: b, Input: []byte{0xaa, 0xbb, 0xcc, 0xdd}},
+			{Depth: 1, Flags: callModeStaticCall, Gas: 5000, Destination: b, Input: []byte{0xaa, 0xbb, 0xcc, 0xdd}},
+		},
+		InternalTxReturns: []motypes.InternalTxReturn{{GasLeft: 4700}, {GasLeft: 4700}, {GasLeft: 9000}},
+	}
+	frameA := "call:" + a.Hex() + ":0x01020304"
+	frameB := "staticcall:" + b.Hex() + ":0xaabbccdd"
+	require.Equal(t, "tx;"+frameA+" 400\n"+
+		"tx;"+frameA+";"+frameB+" 600\n"+
+		"tx 21000\n", buildFoldedStacks(tx))
+
+	// a native transfer has no internal calls
+	require.Equal(t, "tx 21000\n", buildFoldedStacks(&motypes.Transaction{To: a, GasUsed: 21000}))
+}
@@ -0,0 +1,158 @@
+package api
+
+import (
+	"bytes"
+	"sort"
+
+	gethcmn "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+
+	motypes "github.com/smartbch/moeingevm/types"
+)
+
+const (
+	maxGasProfileBlockRange = 10000
+	defaultGasProfileLimit  = 100
+)
+
+// GasProfileEntry shows how much gas was consumed by a contract, or by one selector of a contract
+type GasProfileEntry struct {
+	Contract    gethcmn.Address `json:"contract"`
+	Selector    hexutil.Bytes   `json:"selector,omitempty"`
+	TxCount     hexutil.Uint64  `json:"txCount"`     // how many transactions are sent to it directly
+	TxGasUsed   hexutil.Uint64  `json:"txGasUsed"`   // the gas used by the transactions sent to it directly
+	CallCount   hexutil.Uint64  `json:"callCount"`   // how many internal calls are made to it
+	SelfGasUsed hexutil.Uint64  `json:"selfGasUsed"` // the gas used by its own code in internal calls
+}
+
+type GasProfileReport struct {
+	FromBlock    hexutil.Uint64     `json:"fromBlock"`
+	ToBlock      hexutil.Uint64     `json:"toBlock"`
+	TxCount      hexutil.Uint64     `json:"txCount"`
+	TotalGasUsed hexutil.Uint64     `json:"totalGasUsed"`
+	Contracts    []*GasProfileEntry `json:"contracts"`
+	Selectors    []*GasProfileEntry `json:"selectors"`
+}
+
+type selectorKey struct {
+	contract gethcmn.Address
+	selector [4]byte
+}
+
+type gasProfiler struct {
+	txCount      uint64
+	totalGasUsed uint64
+	contracts    map[gethcmn.Address]*GasProfileEntry
+	selectors    map[selectorKey]*GasProfileEntry
+}
+
+func newGasProfiler() *gasProfiler {
+	return &gasProfiler{
+		contracts: make(map[gethcmn.Address]*GasProfileEntry),
+		selectors: make(map[selectorKey]*GasProfileEntry),
+	}
+}
+
+func (p *gasProfiler) getEntries(contract gethcmn.Address, input []byte) (*GasProfileEntry, *GasProfileEntry) {
+	c, ok := p.contracts[contract]
+	if !ok {
+		c = &GasProfileEntry{Contract: contract}
+		p.contracts[contract] = c
+	}
+	var key = selectorKey{contract: contract}
+	if len(input) >= 4 {
+		copy(key.selector[:], input[:4])
+	}
+	s, ok := p.selectors[key]
+	if !ok {
+		s = &GasProfileEntry{Contract: contract, Selector: append([]byte{}, key.selector[:]...)}
+		p.selectors[key] = s
+	}
+	return c, s
+}
+
+func (p *gasProfiler) addTx(tx *motypes.Transaction) {
+	p.txCount++
+	p.totalGasUsed += tx.GasUsed
+	if isZeroAddress(tx.To) {
+		return // contract creation or native transfer to zero address
+	}
+	c, s := p.getEntries(tx.To, tx.Input)
+	c.TxCount++
+	s.TxCount++
+	c.TxGasUsed += hexutil.Uint64(tx.GasUsed)
+	s.TxGasUsed += hexutil.Uint64(tx.GasUsed)
+
+	calls := buildInternalCallList(tx.InternalTxCalls, tx.InternalTxReturns)
+	selfGas := getSelfGasUsed(calls)
+	for i, call := range calls {
+		c, s := p.getEntries(call.To, call.Input)
+		c.CallCount++
+		s.CallCount++
+		c.SelfGasUsed += hexutil.Uint64(selfGas[i])
+		s.SelfGasUsed += hexutil.Uint64(selfGas[i])
+	}
+}
+
+// getSelfGasUsed subtracts the gas used by the direct sub-calls from each call's gas,
+// the calls must be in the pre-order returned by buildInternalCallList
+func getSelfGasUsed(calls []*InternalTx) []uint64 {
+	selfGas := make([]uint64, len(calls))
+	var stack []int
+	for i, call := range calls {
+		selfGas[i] = uint64(call.GasUsed)
+		for len(stack) > 0 && calls[stack[len(stack)-1]].depth >= call.depth {
+			stack = stack[:len(stack)-1]
+		}
+		if len(stack) > 0 {
+			parent := stack[len(stack)-1]
+			if selfGas[parent] >= uint64(call.GasUsed) {
+				selfGas[parent] -= uint64(call.GasUsed)
+			} else {
+				selfGas[parent] = 0
+			}
+		}
+		stack = append(stack, i)
+	}
+	return selfGas
+}
+
+func (p *gasProfiler) report(fromBlock, toBlock uint64, limit int) *GasProfileReport {
+	r := &GasProfileReport{
+		FromBlock:    hexutil.Uint64(fromBlock),
+		ToBlock:      hexutil.Uint64(toBlock),
+		TxCount:      hexutil.Uint64(p.txCount),
+		TotalGasUsed: hexutil.Uint64(p.totalGasUsed),
+		Contracts:    make([]*GasProfileEntry, 0, len(p.contracts)),
+		Selectors:    make([]*GasProfileEntry, 0, len(p.selectors)),
+	}
+	for _, e := range p.contracts {
+		r.Contracts = append(r.Contracts, e)
+	}
+	for _, e := range p.selectors {
+		r.Selectors = append(r.Selectors, e)
+	}
+	r.Contracts = rankGasProfileEntries(r.Contracts, limit)
+	r.Selectors = rankGasProfileEntries(r.Selectors, limit)
+	return r
+}
+
+// rankGasProfileEntries sorts the entries by TxGasUsed and then SelfGasUsed, larger first
+func rankGasProfileEntries(entries []*GasProfileEntry, limit int) []*GasProfileEntry {
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].TxGasUsed != entries[j].TxGasUsed {
+			return entries[i].TxGasUsed > entries[j].TxGasUsed
+		}
+		if entries[i].SelfGasUsed != entries[j].SelfGasUsed {
+			return entries[i].SelfGasUsed > entries[j].SelfGasUsed
+		}
+		if c := bytes.Compare(entries[i].Contract[:], entries[j].Contract[:]); c != 0 {
+			return c < 0
+		}
+		return bytes.Compare(entries[i].Selector, entries[j].Selector) < 0
+	})
+	if limit > 0 && len(entries) > limit {
+		entries = entries[:limit]
+	}
+	return entries
+}
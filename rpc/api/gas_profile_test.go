@@ -0,0 +1,49 @@
+package api
+
+import (
+	"testing"
+
+	gethcmn "github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+
+	motypes "github.com/smartbch/moeingevm/types"
+)
+
+func TestGetSelfGasUsed(t *testing.T) {
+	// A(1000) -> B(400) -> C(100)
+	//         -> D(300)
+	calls := []*InternalTx{
+		{depth: 0, GasUsed: 1000},
+		{depth: 1, GasUsed: 400},
+		{depth: 2, GasUsed: 100},
+		{depth: 1, GasUsed: 300},
+	}
+	require.Equal(t, []uint64{300, 300, 100, 300}, getSelfGasUsed(calls))
+}
+
+func TestGasProfileReport(t *testing.T) {
+	c1 := gethcmn.Address{0x01}
+	c2 := gethcmn.Address{0x02}
+	p := newGasProfiler()
+	p.addTx(&motypes.Transaction{To: c1, Input: []byte{1, 2, 3, 4, 5}, GasUsed: 100})
+	p.addTx(&motypes.Transaction{To: c1, Input: []byte{1, 2, 3, 4}, GasUsed: 200})
+	p.addTx(&motypes.Transaction{To: c1, Input: []byte{4, 3, 2, 1}, GasUsed: 50})
+	p.addTx(&motypes.Transaction{To: c2, GasUsed: 21000})
+	p.addTx(&motypes.Transaction{GasUsed: 90000}) // contract creation
+
+	r := p.report(1, 10, 0)
+	require.Equal(t, uint64(5), uint64(r.TxCount))
+	require.Equal(t, uint64(111350), uint64(r.TotalGasUsed))
+	require.Len(t, r.Contracts, 2)
+	require.Equal(t, c2, r.Contracts[0].Contract)
+	require.Equal(t, c1, r.Contracts[1].Contract)
+	require.Equal(t, uint64(350), uint64(r.Contracts[1].TxGasUsed))
+	require.Equal(t, uint64(3), uint64(r.Contracts[1].TxCount))
+	require.Len(t, r.Selectors, 3)
+	require.Equal(t, []byte{1, 2, 3, 4}, []byte(r.Selectors[1].Selector))
+	require.Equal(t, uint64(300), uint64(r.Selectors[1].TxGasUsed))
+
+	r = p.report(1, 10, 1)
+	require.Len(t, r.Contracts, 1)
+	require.Len(t, r.Selectors, 1)
+}
@@ -0,0 +1,67 @@
+package api
+
+import (
+	"github.com/ethereum/go-ethereum/common/hexutil"
+
+	cctypes "github.com/smartbch/smartbch/crosschain/types"
+	"github.com/smartbch/smartbch/param"
+	sbchrpctypes "github.com/smartbch/smartbch/rpc/types"
+)
+
+var errMonitorVotesNotSupported = sbchrpctypes.NewError(sbchrpctypes.ErrCodeNotSupported, sbchrpctypes.ReasonNotSupported,
+	"the monitors are not voted on this chain")
+
+// GetMonitorVoteInfo returns the monitor vote info saved when the epoch with the number was
+// applied, or nil if there is none
+func (sbch sbchAPI) GetMonitorVoteInfo(epochNumber hexutil.Uint64) (*sbchrpctypes.MonitorVoteInfo, error) {
+	sbch.logger.Debug("sbch_getMonitorVoteInfo")
+	if param.IsAmber {
+		return nil, errMonitorVotesNotSupported
+	}
+	if epochNumber == 0 {
+		return nil, nil
+	}
+	infos, err := sbch.backend.GetVoteInfos(uint64(epochNumber), uint64(epochNumber)+1)
+	if err != nil || len(infos) == 0 || infos[0].MonitorVote.StartHeight == 0 {
+		return nil, err
+	}
+	return castMonitorVoteInfo(&infos[0].MonitorVote), nil
+}
+
+// GetLatestMonitorVotes returns the monitor vote infos kept by the watcher, such that a monitor
+// can check its nominations are counted before the epochs are applied
+func (sbch sbchAPI) GetLatestMonitorVotes() (*sbchrpctypes.LatestMonitorVotes, error) {
+	sbch.logger.Debug("sbch_getLatestMonitorVotes")
+	if param.IsAmber {
+		return nil, errMonitorVotesNotSupported
+	}
+	recent, curr, err := sbch.backend.GetWatcherMonitorVoteInfos()
+	if err != nil {
+		return nil, err
+	}
+	result := &sbchrpctypes.LatestMonitorVotes{Recent: make([]*sbchrpctypes.MonitorVoteInfo, len(recent))}
+	for i, info := range recent {
+		result.Recent[i] = castMonitorVoteInfo(info)
+	}
+	if curr != nil {
+		result.Current = castMonitorVoteInfo(curr)
+	}
+	return result, nil
+}
+
+func castMonitorVoteInfo(info *cctypes.MonitorVoteInfo) *sbchrpctypes.MonitorVoteInfo {
+	nominations := make([]*sbchrpctypes.MonitorNomination, len(info.Nominations))
+	for i, n := range info.Nominations {
+		nominations[i] = &sbchrpctypes.MonitorNomination{
+			Pubkey:         n.Pubkey[:],
+			NominatedCount: n.NominatedCount,
+		}
+	}
+	return &sbchrpctypes.MonitorVoteInfo{
+		Number:      hexutil.Uint64(info.Number),
+		StartHeight: hexutil.Uint64(info.StartHeight),
+		EndHeight:   hexutil.Uint64(info.StartHeight + param.CurrBchNetwork().NumBlocksInEpoch - 1),
+		EndTime:     info.EndTime,
+		Nominations: nominations,
+	}
+}
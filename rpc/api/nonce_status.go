@@ -0,0 +1,89 @@
+package api
+
+import (
+	"fmt"
+	"sort"
+
+	gethcmn "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+
+	sbchrpctypes "github.com/smartbch/smartbch/rpc/types"
+)
+
+// the max number of the missing nonces listed in NonceStatus.Gaps
+const maxNonceGaps = 100
+
+// GetNonceStatus compares the nonce of addr in the latest state with its txs in the mempool of
+// this node, to find out why its txs are stuck
+func (sbch sbchAPI) GetNonceStatus(addr gethcmn.Address) (*sbchrpctypes.NonceStatus, error) {
+	sbch.logger.Debug("sbch_getNonceStatus")
+	confirmed, err := sbch.backend.GetNonce(addr, sbch.backend.LatestHeight())
+	if err != nil {
+		return nil, err
+	}
+	return buildNonceStatus(addr, confirmed, sbch.backend.PendingTxsOf(addr)), nil
+}
+
+func buildNonceStatus(addr gethcmn.Address, confirmed uint64, pending []*gethtypes.Transaction) *sbchrpctypes.NonceStatus {
+	status := &sbchrpctypes.NonceStatus{
+		Address:          addr,
+		ConfirmedNonce:   hexutil.Uint64(confirmed),
+		PendingCount:     hexutil.Uint64(len(pending)),
+		PendingNonces:    []hexutil.Uint64{},
+		Gaps:             []hexutil.Uint64{},
+		StaleNonces:      []hexutil.Uint64{},
+		DuplicatedNonces: []hexutil.Uint64{},
+	}
+	counts := make(map[uint64]int)
+	for _, tx := range pending {
+		counts[tx.Nonce()]++
+	}
+	nonces := make([]uint64, 0, len(counts))
+	for nonce := range counts {
+		nonces = append(nonces, nonce)
+	}
+	sort.Slice(nonces, func(i, j int) bool { return nonces[i] < nonces[j] })
+	for _, nonce := range nonces {
+		status.PendingNonces = append(status.PendingNonces, hexutil.Uint64(nonce))
+		if nonce < confirmed {
+			status.StaleNonces = append(status.StaleNonces, hexutil.Uint64(nonce))
+		}
+		if counts[nonce] > 1 {
+			status.DuplicatedNonces = append(status.DuplicatedNonces, hexutil.Uint64(nonce))
+		}
+	}
+
+	next := confirmed
+	for counts[next] > 0 {
+		next++
+	}
+	status.NextNonce = hexutil.Uint64(next)
+	if len(nonces) != 0 {
+		highest := hexutil.Uint64(nonces[len(nonces)-1])
+		status.HighestPendingNonce = &highest
+		for nonce := next; nonce < uint64(highest) && len(status.Gaps) < maxNonceGaps; nonce++ {
+			if counts[nonce] == 0 {
+				status.Gaps = append(status.Gaps, hexutil.Uint64(nonce))
+			}
+		}
+	}
+	status.Diagnosis = diagnoseNonceStatus(status)
+	return status
+}
+
+func diagnoseNonceStatus(status *sbchrpctypes.NonceStatus) string {
+	switch {
+	case status.PendingCount == 0:
+		return "no pending tx in the mempool of this node"
+	case len(status.Gaps) != 0:
+		return fmt.Sprintf("nonce %d is missing, the txs after it cannot be executed until a tx with this nonce is sent, "+
+			"if it was sent in the latest block, wait for one more block", status.Gaps[0])
+	case len(status.StaleNonces) != 0:
+		return "some pending txs use nonces smaller than the confirmed nonce, they will fail"
+	case len(status.DuplicatedNonces) != 0:
+		return "some nonces are used by more than one pending tx, only one of each will be executed"
+	default:
+		return "the pending txs are contiguous, they will be executed in the next blocks"
+	}
+}
@@ -0,0 +1,37 @@
+package api
+
+import (
+	"math/big"
+	"testing"
+
+	gethcmn "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildNonceStatus(t *testing.T) {
+	addr := gethcmn.Address{0x01}
+	txWithNonce := func(nonce uint64) *gethtypes.Transaction {
+		return gethtypes.NewTransaction(nonce, addr, big.NewInt(0), 21000, big.NewInt(1), nil)
+	}
+
+	status := buildNonceStatus(addr, 5, nil)
+	require.EqualValues(t, 5, status.NextNonce)
+	require.Nil(t, status.HighestPendingNonce)
+	require.Equal(t, "no pending tx in the mempool of this node", status.Diagnosis)
+
+	status = buildNonceStatus(addr, 5, []*gethtypes.Transaction{txWithNonce(6), txWithNonce(5), txWithNonce(9)})
+	require.EqualValues(t, 3, status.PendingCount)
+	require.EqualValues(t, 7, status.NextNonce)
+	require.EqualValues(t, 9, *status.HighestPendingNonce)
+	require.Equal(t, []hexutil.Uint64{5, 6, 9}, status.PendingNonces)
+	require.Equal(t, []hexutil.Uint64{7, 8}, status.Gaps)
+	require.Contains(t, status.Diagnosis, "nonce 7 is missing")
+
+	status = buildNonceStatus(addr, 5, []*gethtypes.Transaction{txWithNonce(4), txWithNonce(5), txWithNonce(5)})
+	require.EqualValues(t, 6, status.NextNonce)
+	require.Empty(t, status.Gaps)
+	require.Equal(t, []hexutil.Uint64{4}, status.StaleNonces)
+	require.Equal(t, []hexutil.Uint64{5}, status.DuplicatedNonces)
+}
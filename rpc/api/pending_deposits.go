@@ -0,0 +1,42 @@
+package api
+
+import (
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/holiman/uint256"
+
+	sbchrpctypes "github.com/smartbch/smartbch/rpc/types"
+	watchertypes "github.com/smartbch/smartbch/watcher/types"
+)
+
+var errMempoolNotScanned = sbchrpctypes.NewError(sbchrpctypes.ErrCodeUnavailable, sbchrpctypes.ReasonNotSupported,
+	"the BCH mempool is not scanned by this node").WithHint("set watcher-scan-mempool")
+
+// GetPendingCcDeposits returns the deposits to the covenant found in the BCH mempool, which are not
+// mined yet. They may never be minted if they are double-spent or evicted.
+func (sbch sbchAPI) GetPendingCcDeposits() ([]*sbchrpctypes.PendingCcDeposit, error) {
+	sbch.logger.Debug("sbch_getPendingCcDeposits")
+	deposits, ok := sbch.backend.GetPendingCcDeposits()
+	if !ok {
+		return nil, errMempoolNotScanned
+	}
+	result := make([]*sbchrpctypes.PendingCcDeposit, len(deposits))
+	for i, d := range deposits {
+		result[i] = castPendingCcDeposit(d)
+	}
+	return result, nil
+}
+
+func castPendingCcDeposit(d watchertypes.PendingCcDeposit) *sbchrpctypes.PendingCcDeposit {
+	amtWei := uint256.NewInt(0).SetBytes32(d.Info.UTXO.Amount[:])
+	return &sbchrpctypes.PendingCcDeposit{
+		Txid:            d.Info.UTXO.TxID,
+		Index:           d.Info.UTXO.Index,
+		Amount:          hexutil.Uint64(amtWei.Div(amtWei, uint256.NewInt(1e10)).Uint64()),
+		Receiver:        d.Info.Receiver,
+		CovenantAddress: d.Info.CovenantAddress,
+		CallTarget:      d.Info.CallTarget,
+		MalformedMemo:   d.Info.MalformedMemo,
+		NoReceiver:      d.Info.NoReceiver,
+		FirstSeen:       d.FirstSeen,
+	}
+}
@@ -1,11 +1,12 @@
 package api
 
 import (
+	"context"
+	"crypto/ecdsa"
 	"crypto/sha256"
 	"encoding/binary"
 	"encoding/hex"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"math/big"
 	"time"
@@ -14,6 +15,7 @@ import (
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	gethtypes "github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
 	gethrpc "github.com/ethereum/go-ethereum/rpc"
 	"github.com/gcash/bchutil"
 	"github.com/tendermint/tendermint/libs/log"
@@ -21,9 +23,12 @@ import (
 	motypes "github.com/smartbch/moeingevm/types"
 
 	sbchapi "github.com/smartbch/smartbch/api"
+	"github.com/smartbch/smartbch/app"
 	"github.com/smartbch/smartbch/crosschain"
+	"github.com/smartbch/smartbch/crosschain/bchtx"
 	"github.com/smartbch/smartbch/crosschain/covenant"
 	cctypes "github.com/smartbch/smartbch/crosschain/types"
+	"github.com/smartbch/smartbch/internal/audit"
 	"github.com/smartbch/smartbch/internal/ethutils"
 	rpctypes "github.com/smartbch/smartbch/rpc/internal/ethapi"
 	sbchrpctypes "github.com/smartbch/smartbch/rpc/types"
@@ -43,40 +48,95 @@ type SbchAPI interface {
 	GetTxListByHeightWithRange(height gethrpc.BlockNumber, start, end hexutil.Uint64) ([]map[string]interface{}, error)
 	GetAddressCount(kind string, addr gethcmn.Address) hexutil.Uint64
 	GetSep20AddressCount(kind string, contract, addr gethcmn.Address) hexutil.Uint64
+	GetApprovals(owner gethcmn.Address, fromBlock, toBlock gethrpc.BlockNumber, includeRevoked *bool) ([]*sbchrpctypes.Approval, error)
+	GetNonceStatus(addr gethcmn.Address) (*sbchrpctypes.NonceStatus, error)
+	GetBalanceHistory(addr gethcmn.Address, fromBlock, toBlock gethrpc.BlockNumber, step hexutil.Uint64) ([]*sbchrpctypes.BalanceSample, error)
 	getVoteInfos(start, end hexutil.Uint64) ([]*watchertypes.VoteInfo, error)
 	GetEpochList(from string) ([]*StakingEpoch, error)
 	GetCurrEpoch(includesPosVotes *bool) (*StakingEpoch, error)
+	GetEpochs(filter sbchrpctypes.EpochFilter) (*sbchrpctypes.EpochPage, error)
+	GetEpochByNumber(number hexutil.Uint64) (*sbchrpctypes.EpochInfo, error)
+	GetNominationStatus(pubkey gethcmn.Hash) (*sbchrpctypes.NominationStatus, error)
+	GetBlockSummary(blockNum gethrpc.BlockNumber) (*sbchrpctypes.BlockSummary, error)
+	GetBlockSummaries(startHeight, endHeight gethrpc.BlockNumber) ([]*sbchrpctypes.BlockSummary, error)
+	GetFrozenAddresses() []*sbchrpctypes.FrozenAddress
+	ComputeCreate2Address(deployer gethcmn.Address, salt, initCodeHash gethcmn.Hash) gethcmn.Address
+	IsAddressDeployed(addr gethcmn.Address, blockNrOrHash gethrpc.BlockNumberOrHash) (bool, error)
+	GetCodeBatch(addrs []gethcmn.Address, blockNrOrHash gethrpc.BlockNumberOrHash, knownCodeHashes *[]gethcmn.Hash) (*sbchrpctypes.CodeBatch, error)
+	GetCreate2Contract(addr gethcmn.Address) *sbchrpctypes.Create2Contract
+	GetCreate2ContractsByDeployer(deployer gethcmn.Address) []*sbchrpctypes.Create2Contract
+	GetTxFeeRecord(txHash gethcmn.Hash) *sbchrpctypes.TxFeeRecord
+	GetBlockFeeRecords(startHeight, endHeight gethrpc.BlockNumber) ([]*sbchrpctypes.BlockFeeRecord, error)
+	GetProposerInfo(consAddr gethcmn.Address) *sbchrpctypes.ProposerInfo
+	GetProposerInfos() []*sbchrpctypes.ProposerInfo
+	GetConsensusParams() *sbchrpctypes.ConsensusParams
+	GetTimeInfo() *sbchrpctypes.TimeInfo
+	GetCcRescanStatus() *sbchrpctypes.CcRescanStatus
+	GetWatcherStatus() *sbchrpctypes.WatcherStatus
+	GetWatcherAnalytics(limit *hexutil.Uint64) *sbchrpctypes.WatcherAnalytics
+	GetArchivedEpochs(start, end hexutil.Uint64) ([]*sbchrpctypes.ArchivedEpoch, error)
+	GetArchivedEpochByHeight(height hexutil.Uint64) (*sbchrpctypes.ArchivedEpoch, error)
+	EstimateCrossChainTime(args sbchrpctypes.CrossChainTimeArgs) (*sbchrpctypes.CrossChainTimeEstimate, error)
 	HealthCheck(latestBlockTooOldAge hexutil.Uint64) map[string]interface{}
 	GetTransactionReceipt(hash gethcmn.Hash) (map[string]interface{}, error)
 	Call(args rpctypes.CallArgs, blockNr gethrpc.BlockNumberOrHash) (*CallDetail, error)
 	ValidatorsInfo() json.RawMessage
 	GetSyncBlock(height hexutil.Uint64) (hexutil.Bytes, error)
 	GetCcInfo() *sbchrpctypes.CcInfo
+	ValidateRedeemTarget(addr string) *sbchrpctypes.RedeemTarget
+	CancelTransaction(hash gethcmn.Hash, sig hexutil.Bytes, replace *bool) (*sbchrpctypes.CanceledTx, error)
 	GetRedeemingUtxosForMonitors() (*sbchrpctypes.UtxoInfos, error)
 	GetRedeemingUtxosForOperators() (*sbchrpctypes.UtxoInfos, error)
 	GetToBeConvertedUtxosForMonitors() (*sbchrpctypes.UtxoInfos, error)
 	GetToBeConvertedUtxosForOperators() (*sbchrpctypes.UtxoInfos, error)
 	GetRedeemableUtxos() *sbchrpctypes.UtxoInfos
 	GetLostAndFoundUtxos() *sbchrpctypes.UtxoInfos
+	GetPegInRefunds() []*sbchrpctypes.PegInRefund
+	GetPendingCcDeposits() ([]*sbchrpctypes.PendingCcDeposit, error)
+	GetMonitorVoteInfo(epochNumber hexutil.Uint64) (*sbchrpctypes.MonitorVoteInfo, error)
+	GetLatestMonitorVotes() (*sbchrpctypes.LatestMonitorVotes, error)
+	GetSignedVoteInfos(startHeight hexutil.Uint64) (*watchertypes.SignedVoteInfos, error)
 	GetCcUtxo(txid hexutil.Bytes, idx uint32) *sbchrpctypes.UtxoInfos
 	GetCcInfosForTest() *cctypes.CCInfosForTest
 	SetRpcKey(key string) error
 	GetRpcPubkey() (string, error)
+	SubmitAdminOp(payload string, sigs []hexutil.Bytes) (bool, error)
+	GetAdminOpNonce() (hexutil.Uint64, error)
+	NewEpoch(ctx context.Context) (*gethrpc.Subscription, error)
+	ValidatorSetChange(ctx context.Context) (*gethrpc.Subscription, error)
 }
 
+const (
+	maxBlockSummaryRange = 1000
+)
+
 var (
-	errCrossChainPaused = errors.New("cross chain paused")
+	errCrossChainPaused = sbchrpctypes.NewError(sbchrpctypes.ErrCodeUnavailable, sbchrpctypes.ReasonCrossChainPaused,
+		"cross chain paused")
+	errNoTxFromIndex = sbchrpctypes.NewError(sbchrpctypes.ErrCodeNotSupported, sbchrpctypes.ReasonNotIndexed,
+		"transactions are not indexed by senders on this node").WithHint("use a node without no-tx-from-index")
+	errNoTxToIndex = sbchrpctypes.NewError(sbchrpctypes.ErrCodeNotSupported, sbchrpctypes.ReasonNotIndexed,
+		"transactions are not indexed by receivers on this node").WithHint("use a node without no-tx-to-index")
+	errConvertSignTooEarly = sbchrpctypes.NewError(sbchrpctypes.ErrCodeUnavailable, sbchrpctypes.ReasonTooEarly,
+		"not match expected convert sign delay")
+	errRpcKeyAlreadySet = sbchrpctypes.NewError(sbchrpctypes.ErrCodeInvalidInput, sbchrpctypes.ReasonRpcKeyAlreadySet,
+		"already set rpc key")
+	errRpcKeyNotSet = sbchrpctypes.NewError(sbchrpctypes.ErrCodeUnavailable, sbchrpctypes.ReasonRpcKeyNotSet,
+		"rpc pubkey not set")
 )
 
 type sbchAPI struct {
-	backend sbchapi.BackendService
-	logger  log.Logger
+	backend  sbchapi.BackendService
+	accounts map[gethcmn.Address]*ecdsa.PrivateKey // only for test
+	logger   log.Logger
 }
 
-func newSbchAPI(backend sbchapi.BackendService, logger log.Logger) SbchAPI {
+func newSbchAPI(backend sbchapi.BackendService, accounts map[gethcmn.Address]*ecdsa.PrivateKey,
+	logger log.Logger) SbchAPI {
 	return sbchAPI{
-		backend: backend,
-		logger:  logger,
+		backend:  backend,
+		accounts: accounts,
+		logger:   logger,
 	}
 }
 
@@ -112,6 +172,9 @@ func (sbch sbchAPI) QueryTxBySrc(addr gethcmn.Address,
 	startHeight, endHeight gethrpc.BlockNumber, limit hexutil.Uint64) ([]*rpctypes.Transaction, error) {
 
 	sbch.logger.Debug("sbch_queryTxBySrc")
+	if !sbch.backend.GetModbIndexes().TxFrom {
+		return nil, errNoTxFromIndex
+	}
 	_start, _end := sbch.prepareHeightRange(startHeight, endHeight)
 	txs, sigs, err := sbch.backend.QueryTxBySrc(addr, _start, _end, uint32(limit))
 	if err != nil {
@@ -125,6 +188,9 @@ func (sbch sbchAPI) QueryTxByDst(addr gethcmn.Address,
 	startHeight, endHeight gethrpc.BlockNumber, limit hexutil.Uint64) ([]*rpctypes.Transaction, error) {
 
 	sbch.logger.Debug("sbch_queryTxByDst")
+	if !sbch.backend.GetModbIndexes().TxTo {
+		return nil, errNoTxToIndex
+	}
 	_start, _end := sbch.prepareHeightRange(startHeight, endHeight)
 	txs, sigs, err := sbch.backend.QueryTxByDst(addr, _start, _end, uint32(limit))
 	if err != nil {
@@ -138,6 +204,11 @@ func (sbch sbchAPI) QueryTxByAddr(addr gethcmn.Address,
 	startHeight, endHeight gethrpc.BlockNumber, limit hexutil.Uint64) ([]*rpctypes.Transaction, error) {
 
 	sbch.logger.Debug("sbch_queryTxByAddr")
+	if indexes := sbch.backend.GetModbIndexes(); !indexes.TxFrom {
+		return nil, errNoTxFromIndex
+	} else if !indexes.TxTo {
+		return nil, errNoTxToIndex
+	}
 	_start, _end := sbch.prepareHeightRange(startHeight, endHeight)
 	txs, sigs, err := sbch.backend.QueryTxByAddr(addr, _start, _end, uint32(limit))
 	if err != nil {
@@ -166,6 +237,10 @@ func (sbch sbchAPI) QueryLogs(addr gethcmn.Address, topics []gethcmn.Hash,
 	startHeight, endHeight gethrpc.BlockNumber, limit hexutil.Uint64) ([]*gethtypes.Log, error) {
 
 	sbch.logger.Debug("sbch_queryLogs")
+	if n := sbch.backend.GetModbIndexes().LogTopics; len(topics) > n {
+		return nil, sbchrpctypes.NewError(sbchrpctypes.ErrCodeNotSupported, sbchrpctypes.ReasonNotIndexed,
+			fmt.Sprintf("only the first %d topics are indexed on this node", n))
+	}
 	if startHeight == gethrpc.LatestBlockNumber {
 		startHeight = gethrpc.BlockNumber(sbch.backend.LatestHeight())
 	}
@@ -234,7 +309,10 @@ func (sbch sbchAPI) GetEpochList(from string) ([]*StakingEpoch, error) {
 	return castStakingEpochs(epochs), nil
 }
 func (sbch sbchAPI) GetCurrEpoch(includesPosVotes *bool) (*StakingEpoch, error) {
-	epoch := sbch.backend.GetCurrEpoch()
+	epoch, err := sbch.backend.GetCurrEpoch()
+	if err != nil {
+		return nil, err
+	}
 	epoch.Number = sbch.backend.ValidatorsInfo().CurrEpochNum
 	ret := castStakingEpoch(epoch)
 
@@ -252,6 +330,163 @@ func (sbch sbchAPI) GetCurrEpoch(includesPosVotes *bool) (*StakingEpoch, error)
 	return ret, nil
 }
 
+// GetNominationStatus tells how many more nominations a validator needs to get elected in current epoch
+func (sbch sbchAPI) GetNominationStatus(pubkey gethcmn.Hash) (*sbchrpctypes.NominationStatus, error) {
+	sbch.logger.Debug("sbch_getNominationStatus")
+	status, err := sbch.backend.GetNominationStatus(pubkey)
+	if err != nil {
+		return nil, err
+	}
+	return castNominationStatus(pubkey, status), nil
+}
+
+// GetBlockSummary returns the header fields, tx count, gas used and fee total of a block, without tx bodies
+func (sbch sbchAPI) GetBlockSummary(blockNum gethrpc.BlockNumber) (*sbchrpctypes.BlockSummary, error) {
+	sbch.logger.Debug("sbch_getBlockSummary")
+	if blockNum == gethrpc.LatestBlockNumber {
+		blockNum = gethrpc.BlockNumber(sbch.backend.LatestHeight())
+	}
+	summary, err := sbch.getBlockSummary(blockNum.Int64())
+	if err == motypes.ErrBlockNotFound {
+		return nil, nil
+	}
+	return summary, err
+}
+
+// GetBlockSummaries returns the summaries of the blocks in [startHeight, endHeight],
+// the blocks not found (such as the ones after the latest block) are omitted
+func (sbch sbchAPI) GetBlockSummaries(startHeight, endHeight gethrpc.BlockNumber) ([]*sbchrpctypes.BlockSummary, error) {
+	sbch.logger.Debug("sbch_getBlockSummaries")
+	if startHeight == gethrpc.LatestBlockNumber {
+		startHeight = gethrpc.BlockNumber(sbch.backend.LatestHeight())
+	}
+	if endHeight == gethrpc.LatestBlockNumber {
+		endHeight = gethrpc.BlockNumber(sbch.backend.LatestHeight())
+	}
+	if startHeight < 0 || endHeight < startHeight {
+		return nil, errInvalidBlockRange
+	}
+	if endHeight-startHeight >= maxBlockSummaryRange {
+		return nil, errBlockRangeTooLong
+	}
+	summaries := make([]*sbchrpctypes.BlockSummary, 0, endHeight-startHeight+1)
+	for h := startHeight.Int64(); h <= endHeight.Int64(); h++ {
+		summary, err := sbch.getBlockSummary(h)
+		if err == motypes.ErrBlockNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		summaries = append(summaries, summary)
+	}
+	return summaries, nil
+}
+
+func (sbch sbchAPI) getBlockSummary(height int64) (*sbchrpctypes.BlockSummary, error) {
+	block, err := sbch.backend.BlockByNumber(height)
+	if err != nil {
+		return nil, err
+	}
+	var txs []*motypes.Transaction
+	if len(block.Transactions) > 0 {
+		txs, _, err = sbch.backend.GetTxListByHeight(uint32(height))
+		if err != nil {
+			return nil, err
+		}
+	}
+	summary := castBlockSummary(block, getMiner(sbch.backend, block.Miner), txs)
+	summary.GasGrants = castGasGrants(sbch.backend.GetGasGrants(height))
+	return summary, nil
+}
+
+// GetFrozenAddresses returns the addresses frozen by the governance of the freeze contract
+func (sbch sbchAPI) GetFrozenAddresses() []*sbchrpctypes.FrozenAddress {
+	sbch.logger.Debug("sbch_getFrozenAddresses")
+	return castFrozenAddresses(sbch.backend.GetFrozenAddresses())
+}
+
+// ComputeCreate2Address returns the address of the contract which will be created by deployer
+// with CREATE2, so it can be reserved before the deployment
+func (sbch sbchAPI) ComputeCreate2Address(deployer gethcmn.Address, salt, initCodeHash gethcmn.Hash) gethcmn.Address {
+	sbch.logger.Debug("sbch_computeCreate2Address")
+	return crypto.CreateAddress2(deployer, salt, initCodeHash[:])
+}
+
+// IsAddressDeployed returns whether there is code at addr. Only the latest state can be
+// queried if the node is not in archive mode.
+func (sbch sbchAPI) IsAddressDeployed(addr gethcmn.Address, blockNrOrHash gethrpc.BlockNumberOrHash) (bool, error) {
+	sbch.logger.Debug("sbch_isAddressDeployed")
+	height, err := getHeightArg(sbch.backend, blockNrOrHash)
+	if err != nil {
+		return false, err
+	}
+	code, _ := sbch.backend.GetCode(addr, height)
+	return len(code) > 0, nil
+}
+
+// GetCreate2Contract returns nil if addr is not created by CREATE2 after this node started
+func (sbch sbchAPI) GetCreate2Contract(addr gethcmn.Address) *sbchrpctypes.Create2Contract {
+	sbch.logger.Debug("sbch_getCreate2Contract")
+	c := sbch.backend.GetCreate2Contract(addr)
+	if c == nil {
+		return nil
+	}
+	return castCreate2Contracts([]*app.Create2Contract{c})[0]
+}
+
+func (sbch sbchAPI) GetCreate2ContractsByDeployer(deployer gethcmn.Address) []*sbchrpctypes.Create2Contract {
+	sbch.logger.Debug("sbch_getCreate2ContractsByDeployer")
+	return castCreate2Contracts(sbch.backend.GetCreate2ContractsByDeployer(deployer))
+}
+
+// GetTxFeeRecord returns nil if the tx is not committed in the recent blocks since this node started
+func (sbch sbchAPI) GetTxFeeRecord(txHash gethcmn.Hash) *sbchrpctypes.TxFeeRecord {
+	sbch.logger.Debug("sbch_getTxFeeRecord")
+	rec := sbch.backend.GetTxFeeRecord(txHash)
+	if rec == nil {
+		return nil
+	}
+	return castTxFeeRecord(rec)
+}
+
+// GetBlockFeeRecords returns the fee accounting of the blocks in [startHeight, endHeight], the blocks
+// committed before this node started are skipped
+func (sbch sbchAPI) GetBlockFeeRecords(startHeight, endHeight gethrpc.BlockNumber) ([]*sbchrpctypes.BlockFeeRecord, error) {
+	sbch.logger.Debug("sbch_getBlockFeeRecords")
+	if startHeight == gethrpc.LatestBlockNumber {
+		startHeight = gethrpc.BlockNumber(sbch.backend.LatestHeight())
+	}
+	if endHeight == gethrpc.LatestBlockNumber {
+		endHeight = gethrpc.BlockNumber(sbch.backend.LatestHeight())
+	}
+	if startHeight < 0 || endHeight < startHeight {
+		return nil, errInvalidBlockRange
+	}
+	if endHeight-startHeight >= maxBlockSummaryRange {
+		return nil, errBlockRangeTooLong
+	}
+	return castBlockFeeRecords(sbch.backend.GetBlockFeeRecords(startHeight.Int64(), endHeight.Int64())), nil
+}
+
+// GetProposerInfo returns the validator whose consensus address, which is the miner in the
+// tendermint block header, is consAddr. It returns nil if the validator is unknown.
+func (sbch sbchAPI) GetProposerInfo(consAddr gethcmn.Address) *sbchrpctypes.ProposerInfo {
+	sbch.logger.Debug("sbch_getProposerInfo")
+	info := sbch.backend.GetProposerInfo(consAddr)
+	if info == nil {
+		return nil
+	}
+	return castProposerInfos([]*app.ProposerInfo{info})[0]
+}
+
+// GetProposerInfos returns the validators in the staking info, and the retired ones recorded since
+// the proposer record fork
+func (sbch sbchAPI) GetProposerInfos() []*sbchrpctypes.ProposerInfo {
+	sbch.logger.Debug("sbch_getProposerInfos")
+	return castProposerInfos(sbch.backend.GetProposerInfos())
+}
+
 func coinDaysSlotToFloat(coindaysSlot *big.Int) float64 {
 	fCoinDays, _ := big.NewFloat(0).Quo(
 		big.NewFloat(0).SetInt(coindaysSlot),
@@ -329,6 +564,83 @@ func (sbch sbchAPI) GetSyncBlock(height hexutil.Uint64) (hexutil.Bytes, error) {
 	return sbch.backend.GetSyncBlock(int64(height))
 }
 
+// ValidateRedeemTarget checks the BCH address before redeeming, only the P2PKH addresses of the
+// BCH network of this chain are valid. It returns the target address which should be passed to
+// redeem() of the cc contract.
+func (sbch sbchAPI) ValidateRedeemTarget(addr string) *sbchrpctypes.RedeemTarget {
+	sbch.logger.Debug("sbch_validateRedeemTarget")
+	net, err := covenant.DefaultNet()
+	if err != nil {
+		return &sbchrpctypes.RedeemTarget{Error: err.Error()}
+	}
+	target, err := bchtx.DecodeRedeemTarget(addr, net)
+	if err != nil {
+		return &sbchrpctypes.RedeemTarget{Error: err.Error()}
+	}
+	return &sbchrpctypes.RedeemTarget{
+		Valid:         true,
+		Format:        target.Format,
+		TargetAddress: target.PubkeyHash,
+		CashAddr:      target.CashAddr,
+		LegacyAddr:    target.Legacy,
+	}
+}
+
+// CancelTransaction drops a pending transaction submitted through this node from its mempool, in
+// the recheck after the next block. sig is the personal_sign signature of "cancel transaction <hash>"
+// by the sender, such that only the sender can cancel its transaction. If replace is true and the sender is unlocked on this node,
+// a self-transfer with the same nonce and a higher gas price is submitted after two blocks, when
+// the canceled transaction and the later ones of the sender have left the mempool.
+func (sbch sbchAPI) CancelTransaction(hash gethcmn.Hash, sig hexutil.Bytes, replace *bool) (*sbchrpctypes.CanceledTx, error) {
+	sbch.logger.Debug("sbch_cancelTransaction")
+	tx, sender, err := sbch.backend.CancelTx(hash, sig)
+	if err != nil {
+		return nil, toRpcError(err)
+	}
+	result := &sbchrpctypes.CanceledTx{Hash: hash}
+	if replace == nil || !*replace {
+		return result, nil
+	}
+	privKey, found := sbch.accounts[sender]
+	if !found {
+		return nil, newUnknownAccountError("the tx is canceled, but cannot be replaced, unknown account: " + sender.Hex())
+	}
+	gasPrice := new(big.Int).Div(new(big.Int).Mul(tx.GasPrice(), big.NewInt(11)), big.NewInt(10))
+	if gasPrice.Cmp(tx.GasPrice()) <= 0 {
+		gasPrice.Add(tx.GasPrice(), big.NewInt(1))
+	}
+	replacement := ethutils.NewTx(tx.Nonce(), &sender, big.NewInt(0), params.TxGas, gasPrice, nil)
+	replacement, err = ethutils.SignTx(replacement, sbch.backend.ChainId(), privKey)
+	if err != nil {
+		return nil, err
+	}
+	txBytes, err := ethutils.EncodeTx(replacement)
+	if err != nil {
+		return nil, err
+	}
+	go sbch.sendAfterBlocks(txBytes, 2)
+	replacementHash := replacement.Hash()
+	result.ReplacementHash = &replacementHash
+	return result, nil
+}
+
+func (sbch sbchAPI) sendAfterBlocks(txBytes []byte, n int) {
+	ch := make(chan motypes.ChainEvent, n)
+	sub := sbch.backend.SubscribeChainEvent(ch)
+	defer sub.Unsubscribe()
+	for i := 0; i < n; i++ {
+		select {
+		case <-ch:
+		case err := <-sub.Err():
+			sbch.logger.Error("failed to wait for blocks", "err", err)
+			return
+		}
+	}
+	if _, err := sbch.backend.SendRawTx(txBytes); err != nil {
+		sbch.logger.Error("failed to send the replacement tx", "err", err.Error())
+	}
+}
+
 func (sbch sbchAPI) GetCcInfo() *sbchrpctypes.CcInfo {
 	sbch.logger.Debug("sbch_getCcInfo")
 
@@ -516,7 +828,7 @@ func (sbch sbchAPI) getToBeConvertedUtxos(forOperators bool) (*sbchrpctypes.Utxo
 
 		currTS := currBlock.Timestamp
 		if lastCovenantAddrChangeTime+crosschain.ExpectedConvertSignTimeDelay > currTS {
-			return nil, errors.New("not match expected convert sign delay")
+			return nil, errConvertSignTooEarly
 		}
 	}
 
@@ -600,6 +912,22 @@ func (sbch sbchAPI) GetLostAndFoundUtxos() *sbchrpctypes.UtxoInfos {
 	return &infos
 }
 
+// GetPegInRefunds returns the lost-and-found deposits which are not redeemed by their owners, and
+// can be refunded to their senders on the main chain with refund() of the cc contract
+func (sbch sbchAPI) GetPegInRefunds() []*sbchrpctypes.PegInRefund {
+	sbch.logger.Debug("sbch_getPegInRefunds")
+	utxoRecords, refundRecords := sbch.backend.GetPegInRefunds()
+	refunds := make([]*sbchrpctypes.PegInRefund, len(utxoRecords))
+	for i, r := range utxoRecords {
+		refunds[i] = &sbchrpctypes.PegInRefund{
+			UtxoInfo:       *castUtxoRecord(r),
+			RefundTarget:   refundRecords[i].Target,
+			RefundableTime: refundRecords[i].RefundableTime,
+		}
+	}
+	return refunds
+}
+
 func (sbch sbchAPI) GetCcUtxo(txid hexutil.Bytes, idx uint32) *sbchrpctypes.UtxoInfos {
 	sbch.logger.Debug("sbch_getCcUtxo")
 
@@ -618,15 +946,30 @@ func (sbch sbchAPI) GetCcUtxo(txid hexutil.Bytes, idx uint32) *sbchrpctypes.Utxo
 
 func (sbch sbchAPI) SetRpcKey(key string) error {
 	sbch.logger.Debug("sbch_setRpcKey")
-	ecdsaKey, _, err := ethutils.HexToPrivKey(key)
+	if sbch.backend.AdminOpsRequireSigs() {
+		return errAdminOpNeedsSigs
+	}
+	pubkey, err := sbch.setRpcKey(key)
 	if err != nil {
 		return err
 	}
+	sbch.backend.RecordAudit(audit.ActionSetRpcKey, audit.SourceRpc, map[string]string{
+		"pubkey": pubkey,
+	})
+	return nil
+}
+
+// setRpcKey returns the compressed public key of key in hex
+func (sbch sbchAPI) setRpcKey(key string) (string, error) {
+	ecdsaKey, _, err := ethutils.HexToPrivKey(key)
+	if err != nil {
+		return "", err
+	}
 	success := sbch.backend.SetRpcPrivateKey(ecdsaKey)
 	if !success {
-		return errors.New("already set rpc key")
+		return "", errRpcKeyAlreadySet
 	}
-	return nil
+	return hex.EncodeToString(crypto.CompressPubkey(&ecdsaKey.PublicKey)), nil
 }
 
 func (sbch sbchAPI) GetRpcPubkey() (string, error) {
@@ -636,5 +979,5 @@ func (sbch sbchAPI) GetRpcPubkey() (string, error) {
 		pubkey := crypto.FromECDSAPub(&key.PublicKey)
 		return hex.EncodeToString(pubkey), nil
 	}
-	return "", errors.New("rpc pubkey not set")
+	return "", errRpcKeyNotSet
 }
@@ -270,6 +270,60 @@ func TestGetTxListByHeightWithRange(t *testing.T) {
 	require.Len(t, txs, 0)
 }
 
+func TestGetBlockSummary(t *testing.T) {
+	_app := testutils.CreateTestApp()
+	defer _app.Destroy()
+	_api := createSbchAPI(_app)
+
+	blk1 := testutils.NewMdbBlockBuilder().
+		Height(1).Hash(gethcmn.Hash{0xB1, 0x23}).
+		Tx(gethcmn.Hash{0xC1}).
+		FailedTx(gethcmn.Hash{0xC2}, "revert", nil).
+		Build()
+	blk2 := testutils.NewMdbBlockBuilder().
+		Height(2).Hash(gethcmn.Hash{0xB2, 0x34}).
+		Build()
+	_app.StoreBlocks(blk1, blk2)
+	_app.WaitMS(100)
+
+	summary, err := _api.GetBlockSummary(1)
+	require.NoError(t, err)
+	require.Equal(t, hexutil.Uint64(1), summary.Number)
+	require.Equal(t, gethcmn.Hash{0xB1, 0x23}, summary.Hash)
+	require.Equal(t, hexutil.Uint64(2), summary.TxCount)
+	require.Equal(t, hexutil.Uint64(1), summary.FailedTxCount)
+	require.Equal(t, "0x0", summary.TotalFee.String())
+
+	summary, err = _api.GetBlockSummary(100)
+	require.NoError(t, err)
+	require.Nil(t, summary)
+
+	summaries, err := _api.GetBlockSummaries(1, 5)
+	require.NoError(t, err)
+	require.Len(t, summaries, 2)
+	require.Equal(t, hexutil.Uint64(2), summaries[1].Number)
+	require.Equal(t, hexutil.Uint64(0), summaries[1].TxCount)
+
+	_, err = _api.GetBlockSummaries(2, 1)
+	require.Equal(t, errInvalidBlockRange, err)
+	_, err = _api.GetBlockSummaries(1, maxBlockSummaryRange+1)
+	require.Equal(t, errBlockRangeTooLong, err)
+}
+
+func TestGetApprovalsLimits(t *testing.T) {
+	_app := testutils.CreateTestApp()
+	defer _app.Destroy()
+	_api := createSbchAPI(_app)
+
+	_, err := _api.GetApprovals(gethcmn.Address{0x01}, 2, 1, nil)
+	require.Equal(t, errInvalidBlockRange, err)
+	_, err = _api.GetApprovals(gethcmn.Address{0x01}, 0, maxApprovalsBlockRange, nil)
+	require.Equal(t, errBlockRangeTooLong, err)
+	approvals, err := _api.GetApprovals(gethcmn.Address{0x01}, 0, gethrpc.LatestBlockNumber, nil)
+	require.NoError(t, err)
+	require.Empty(t, approvals)
+}
+
 func TestGetToAddressCount(t *testing.T) {
 	key1, addr1 := testutils.GenKeyAndAddr()
 	key2, addr2 := testutils.GenKeyAndAddr()
@@ -542,5 +596,5 @@ func TestCc(t *testing.T) {
 
 func createSbchAPI(_app *testutils.TestApp) SbchAPI {
 	backend := api.NewBackend(nil, _app.App)
-	return newSbchAPI(backend, _app.Logger())
+	return newSbchAPI(backend, nil, _app.Logger())
 }
@@ -6,35 +6,29 @@ import (
 
 	gethcmn "github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
 	"github.com/holiman/uint256"
 
 	"github.com/smartbch/moeingevm/ebp"
 	motypes "github.com/smartbch/moeingevm/types"
 	sbchapi "github.com/smartbch/smartbch/api"
+	"github.com/smartbch/smartbch/app"
 	"github.com/smartbch/smartbch/crosschain"
 	cctypes "github.com/smartbch/smartbch/crosschain/types"
+	"github.com/smartbch/smartbch/freeze"
+	"github.com/smartbch/smartbch/param"
 	sbchrpctypes "github.com/smartbch/smartbch/rpc/types"
+	"github.com/smartbch/smartbch/staking"
 	stakingtypes "github.com/smartbch/smartbch/staking/types"
 )
 
 // StakingEpoch
 
-type StakingEpoch struct {
-	Number      hexutil.Uint64 `json:"number"`
-	StartHeight hexutil.Uint64 `json:"startHeight"`
-	EndTime     int64          `json:"endTime"`
-	Nominations []*Nomination  `json:"nominations"`
-	PosVotes    []*PosVote     `json:"posVotes"`
-}
-type Nomination struct {
-	Pubkey         gethcmn.Hash `json:"pubkey"`
-	NominatedCount int64        `json:"nominatedCount"`
-}
-type PosVote struct {
-	Pubkey       gethcmn.Hash `json:"pubkey"`
-	CoinDaysSlot *hexutil.Big `json:"coinDaysSlot"`
-	CoinDays     float64      `json:"coinDays"`
-}
+type (
+	StakingEpoch = sbchrpctypes.StakingEpoch
+	Nomination   = sbchrpctypes.Nomination
+	PosVote      = sbchrpctypes.PosVote
+)
 
 func castStakingEpochs(epochs []*stakingtypes.Epoch) []*StakingEpoch {
 	rpcEpochs := make([]*StakingEpoch, len(epochs))
@@ -62,6 +56,145 @@ func castNominations(nominations []*stakingtypes.Nomination) []*Nomination {
 	return rpcNominations
 }
 
+func castNominationStatus(pubkey gethcmn.Hash, status *staking.NominationStatus) *sbchrpctypes.NominationStatus {
+	return &sbchrpctypes.NominationStatus{
+		Pubkey:                pubkey,
+		IsValidator:           status.IsValidator,
+		IsRetiring:            status.IsRetiring,
+		HasEnoughStake:        status.HasEnoughStake,
+		NominatedCount:        hexutil.Uint64(status.NominatedCount),
+		Rank:                  hexutil.Uint64(status.Rank),
+		ElectionThreshold:     hexutil.Uint64(status.ElectionThreshold),
+		NominationsNeeded:     hexutil.Uint64(status.NominationsNeeded),
+		TotalValidNominations: hexutil.Uint64(status.TotalValidNominations),
+		MinTotalNominations:   hexutil.Uint64(status.MinTotalNominations),
+		BlocksScanned:         hexutil.Uint64(status.BlocksScanned),
+		BlocksLeft:            hexutil.Uint64(status.BlocksLeft),
+	}
+}
+
+func castFrozenAddresses(frozenAddrs []*freeze.FrozenAddress) []*sbchrpctypes.FrozenAddress {
+	result := make([]*sbchrpctypes.FrozenAddress, len(frozenAddrs))
+	for i, frozen := range frozenAddrs {
+		result[i] = &sbchrpctypes.FrozenAddress{
+			Address:    frozen.Address,
+			Since:      hexutil.Uint64(frozen.Since),
+			ProposalID: hexutil.Uint64(frozen.ProposalID),
+		}
+	}
+	return result
+}
+
+func castCreate2Contracts(contracts []*app.Create2Contract) []*sbchrpctypes.Create2Contract {
+	result := make([]*sbchrpctypes.Create2Contract, len(contracts))
+	for i, c := range contracts {
+		result[i] = &sbchrpctypes.Create2Contract{
+			Address:      c.Address,
+			Deployer:     c.Deployer,
+			InitCodeHash: c.InitCodeHash,
+			TxHash:       c.TxHash,
+			Height:       hexutil.Uint64(c.Height),
+		}
+	}
+	return result
+}
+
+func castTxFeeRecord(rec *app.TxFeeRecord) *sbchrpctypes.TxFeeRecord {
+	return &sbchrpctypes.TxFeeRecord{
+		Height:      hexutil.Uint64(rec.Height),
+		TxHash:      rec.TxHash,
+		From:        rec.From,
+		GasUsed:     hexutil.Uint64(rec.GasUsed),
+		GasPrice:    (*hexutil.Big)(rec.GasPrice.ToBig()),
+		Fee:         (*hexutil.Big)(rec.Fee.ToBig()),
+		Refund:      (*hexutil.Big)(rec.Refund.ToBig()),
+		BurnedShare: (*hexutil.Big)(rec.BurnedShare.ToBig()),
+	}
+}
+
+func castBlockFeeRecords(records []*app.BlockFeeRecord) []*sbchrpctypes.BlockFeeRecord {
+	result := make([]*sbchrpctypes.BlockFeeRecord, len(records))
+	for i, rec := range records {
+		txs := make([]*sbchrpctypes.TxFeeRecord, len(rec.Txs))
+		for j, tx := range rec.Txs {
+			txs[j] = castTxFeeRecord(tx)
+		}
+		result[i] = &sbchrpctypes.BlockFeeRecord{
+			Height:      hexutil.Uint64(rec.Height),
+			Txs:         txs,
+			TotalFee:    (*hexutil.Big)(rec.TotalFee.ToBig()),
+			TotalRefund: (*hexutil.Big)(rec.TotalRefund.ToBig()),
+		}
+		if dist := rec.Distribution; dist != nil {
+			result[i].Distribution = &sbchrpctypes.FeeDistribution{
+				Collected:      (*hexutil.Big)(dist.Collected.ToBig()),
+				Burned:         (*hexutil.Big)(dist.Burned.ToBig()),
+				ProposerShare:  (*hexutil.Big)(dist.ProposerShare.ToBig()),
+				CollectorShare: (*hexutil.Big)(dist.CollectorShare.ToBig()),
+				VotersShare:    (*hexutil.Big)(dist.VotersShare.ToBig()),
+				Proposer:       dist.Proposer,
+				Collector:      dist.Collector,
+			}
+		}
+	}
+	return result
+}
+
+func castProposerInfos(infos []*app.ProposerInfo) []*sbchrpctypes.ProposerInfo {
+	result := make([]*sbchrpctypes.ProposerInfo, len(infos))
+	for i, info := range infos {
+		result[i] = &sbchrpctypes.ProposerInfo{
+			ConsAddress: info.ConsAddress,
+			Address:     info.Address,
+			RewardTo:    info.RewardTo,
+			Pubkey:      info.Pubkey,
+		}
+	}
+	return result
+}
+
+func castGasGrants(grants []*app.GasGrant) []*sbchrpctypes.GasGrant {
+	if len(grants) == 0 {
+		return nil
+	}
+	result := make([]*sbchrpctypes.GasGrant, len(grants))
+	for i, grant := range grants {
+		result[i] = &sbchrpctypes.GasGrant{
+			Address: grant.Address,
+			Amount:  (*hexutil.Big)(grant.Amount.ToBig()),
+		}
+	}
+	return result
+}
+
+// castBlockSummary counts the transactions and fees in a block, txs must be all the transactions in it
+func castBlockSummary(block *motypes.Block, miner gethcmn.Address, txs []*motypes.Transaction) *sbchrpctypes.BlockSummary {
+	summary := &sbchrpctypes.BlockSummary{
+		Number:           hexutil.Uint64(block.Number),
+		Hash:             block.Hash,
+		ParentHash:       block.ParentHash,
+		Miner:            miner,
+		Proposer:         block.Miner,
+		StateRoot:        block.StateRoot,
+		TransactionsRoot: block.TransactionsRoot,
+		Timestamp:        hexutil.Uint64(block.Timestamp),
+		Size:             hexutil.Uint64(block.Size),
+		GasLimit:         hexutil.Uint64(param.BlockMaxGas),
+		GasUsed:          hexutil.Uint64(block.GasUsed),
+		TxCount:          hexutil.Uint64(len(block.Transactions)),
+	}
+	totalFee := uint256.NewInt(0)
+	for _, tx := range txs {
+		if tx.Status != gethtypes.ReceiptStatusSuccessful {
+			summary.FailedTxCount++
+		}
+		fee := uint256.NewInt(0).SetBytes32(tx.GasPrice[:])
+		totalFee.Add(totalFee, fee.Mul(fee, uint256.NewInt(tx.GasUsed)))
+	}
+	summary.TotalFee = (*hexutil.Big)(totalFee.ToBig())
+	return summary
+}
+
 type CCTransferInfo struct {
 	UTXO         hexutil.Bytes  `json:"utxo"`
 	Amount       hexutil.Uint64 `json:"amount"`
@@ -91,44 +224,15 @@ type CallDetail struct {
 	InternalTxs            []*InternalTx   `json:"internalTransactions"`
 	RwLists                *RWLists        `json:"rwLists"`
 }
-type CallLog struct {
-	Address gethcmn.Address `json:"address"`
-	Topics  []gethcmn.Hash  `json:"topics"`
-	Data    hexutil.Bytes   `json:"data"`
-}
-type RWLists struct {
-	CreationCounterRList []CreationCounterRWOp `json:"creationCounterRList"`
-	CreationCounterWList []CreationCounterRWOp `json:"creationCounterWList"`
-	AccountRList         []AccountRWOp         `json:"accountRList"`
-	AccountWList         []AccountRWOp         `json:"accountWList"`
-	BytecodeRList        []BytecodeRWOp        `json:"bytecodeRList"`
-	BytecodeWList        []BytecodeRWOp        `json:"bytecodeWList"`
-	StorageRList         []StorageRWOp         `json:"storageRList"`
-	StorageWList         []StorageRWOp         `json:"storageWList"`
-	BlockHashList        []BlockHashOp         `json:"blockHashList"`
-}
-type CreationCounterRWOp struct {
-	Lsb     uint8  `json:"lsb"`
-	Counter uint64 `json:"counter"`
-}
-type AccountRWOp struct {
-	Addr    gethcmn.Address `json:"address"`
-	Nonce   hexutil.Uint64  `json:"nonce"`
-	Balance *uint256.Int    `json:"balance"`
-}
-type BytecodeRWOp struct {
-	Addr     gethcmn.Address `json:"address"`
-	Bytecode hexutil.Bytes   `json:"bytecode"`
-}
-type StorageRWOp struct {
-	Seq   hexutil.Uint64 `json:"seq"`
-	Key   hexutil.Bytes  `json:"key"`
-	Value hexutil.Bytes  `json:"value"`
-}
-type BlockHashOp struct {
-	Height hexutil.Uint64 `json:"height"`
-	Hash   gethcmn.Hash   `json:"hash"`
-}
+type (
+	CallLog             = sbchrpctypes.CallLog
+	RWLists             = sbchrpctypes.RWLists
+	CreationCounterRWOp = sbchrpctypes.CreationCounterRWOp
+	AccountRWOp         = sbchrpctypes.AccountRWOp
+	BytecodeRWOp        = sbchrpctypes.BytecodeRWOp
+	StorageRWOp         = sbchrpctypes.StorageRWOp
+	BlockHashOp         = sbchrpctypes.BlockHashOp
+)
 
 func toRpcCallDetail(detail *sbchapi.CallDetail) *CallDetail {
 	callDetail := &CallDetail{
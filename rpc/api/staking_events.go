@@ -0,0 +1,91 @@
+package api
+
+import (
+	"context"
+
+	gethcmn "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	gethrpc "github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/smartbch/smartbch/app"
+	sbchrpctypes "github.com/smartbch/smartbch/rpc/types"
+)
+
+// the staking events are sent while committing the blocks, the ones not read yet are buffered
+const stakingEventBufferSize = 16
+
+// NewEpoch pushes a notification at each block switching to a new epoch, with the resulting
+// validator set and the changes of the voting powers, which may be empty
+func (sbch sbchAPI) NewEpoch(ctx context.Context) (*gethrpc.Subscription, error) {
+	sbch.logger.Debug("sbch_subscribe newEpoch")
+	return sbch.subscribeStakingEvents(ctx, func(e *app.StakingEvent) bool {
+		return e.Epoch != nil
+	})
+}
+
+// ValidatorSetChange pushes a notification at each block changing the voting powers, by epochs,
+// slashing, retiring or the others
+func (sbch sbchAPI) ValidatorSetChange(ctx context.Context) (*gethrpc.Subscription, error) {
+	sbch.logger.Debug("sbch_subscribe validatorSetChange")
+	return sbch.subscribeStakingEvents(ctx, func(e *app.StakingEvent) bool {
+		return len(e.PowerChanges) != 0
+	})
+}
+
+func (sbch sbchAPI) subscribeStakingEvents(ctx context.Context, match func(e *app.StakingEvent) bool) (*gethrpc.Subscription, error) {
+	notifier, supported := gethrpc.NotifierFromContext(ctx)
+	if !supported {
+		return &gethrpc.Subscription{}, gethrpc.ErrNotificationsUnsupported
+	}
+	rpcSub := notifier.CreateSubscription()
+	ch := make(chan *app.StakingEvent, stakingEventBufferSize)
+	sub := sbch.backend.SubscribeStakingEvent(ch)
+	go func() {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case e := <-ch:
+				if match(e) {
+					_ = notifier.Notify(rpcSub.ID, castStakingEvent(e))
+				}
+			case <-sub.Err():
+				return
+			case <-rpcSub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			}
+		}
+	}()
+	return rpcSub, nil
+}
+
+func castStakingEvent(e *app.StakingEvent) *sbchrpctypes.ValidatorSetChange {
+	result := &sbchrpctypes.ValidatorSetChange{
+		Height:          hexutil.Uint64(e.Height),
+		EffectiveHeight: hexutil.Uint64(e.EffectiveHeight),
+		Validators:      make([]*sbchrpctypes.ValidatorPower, 0, len(e.Validators)),
+		Deltas:          make([]*sbchrpctypes.PowerDelta, 0, len(e.PowerChanges)),
+	}
+	if e.Epoch != nil {
+		result.Epoch = castEpochInfo(e.Epoch, false)
+	}
+	for _, v := range e.Validators {
+		result.Validators = append(result.Validators, &sbchrpctypes.ValidatorPower{
+			Address:     v.Address,
+			Pubkey:      v.Pubkey,
+			VotingPower: v.VotingPower,
+		})
+	}
+	for _, c := range e.PowerChanges {
+		result.Deltas = append(result.Deltas, &sbchrpctypes.PowerDelta{
+			Address:  c.Address,
+			Pubkey:   gethcmn.BytesToHash(c.Pubkey),
+			OldPower: c.OldPower,
+			NewPower: c.NewPower,
+			Delta:    c.NewPower - c.OldPower,
+			Reason:   c.Reason,
+		})
+	}
+	return result
+}
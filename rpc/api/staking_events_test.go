@@ -0,0 +1,38 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/smartbch/smartbch/app"
+	stakingtypes "github.com/smartbch/smartbch/staking/types"
+)
+
+func TestCastStakingEvent(t *testing.T) {
+	val := &stakingtypes.Validator{VotingPower: 7}
+	val.Address[19] = 1
+	val.Pubkey[31] = 2
+	e := &app.StakingEvent{
+		Height:          100,
+		EffectiveHeight: 103,
+		Epoch:           &stakingtypes.Epoch{Number: 5, StartHeight: 2017},
+		Validators:      []*stakingtypes.Validator{val},
+		PowerChanges: []*app.ValidatorPowerChangeEvent{
+			{Height: 100, Address: val.Address, Pubkey: val.Pubkey[:], OldPower: 10, NewPower: 7, Reason: app.PowerChangeByEpoch},
+		},
+	}
+	result := castStakingEvent(e)
+	require.EqualValues(t, 103, result.EffectiveHeight)
+	require.EqualValues(t, 5, result.Epoch.Number)
+	require.Len(t, result.Validators, 1)
+	require.EqualValues(t, 7, result.Validators[0].VotingPower)
+	require.EqualValues(t, val.Pubkey, result.Validators[0].Pubkey)
+	require.Len(t, result.Deltas, 1)
+	require.EqualValues(t, -3, result.Deltas[0].Delta)
+	require.EqualValues(t, val.Pubkey, result.Deltas[0].Pubkey)
+	require.Equal(t, "epoch", result.Deltas[0].Reason)
+
+	e.Epoch = nil
+	require.Nil(t, castStakingEvent(e).Epoch)
+}
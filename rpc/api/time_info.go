@@ -0,0 +1,37 @@
+package api
+
+import (
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+
+	"github.com/smartbch/smartbch/app"
+	sbchrpctypes "github.com/smartbch/smartbch/rpc/types"
+)
+
+// GetTimeInfo returns the Tendermint time, the EVM-visible timestamp of the latest block and the
+// timestamp of the latest BCH block seen by the watcher, for the time-dependent contracts to
+// check how much they differ
+func (sbch sbchAPI) GetTimeInfo() *sbchrpctypes.TimeInfo {
+	sbch.logger.Debug("sbch_getTimeInfo")
+	tmParams, _ := sbch.backend.ConsensusParams()
+	return buildTimeInfo(sbch.backend.GetTimeInfo(), tmParams.Block.TimeIotaMs)
+}
+
+func buildTimeInfo(info app.TimeInfo, timeIotaMs int64) *sbchrpctypes.TimeInfo {
+	result := &sbchrpctypes.TimeInfo{
+		Height:             hexutil.Uint64(info.Height),
+		BlockTimestamp:     hexutil.Uint64(info.BlockTimestamp),
+		BlockTimeIotaMs:    hexutil.Uint64(timeIotaMs),
+		StrictlyIncreasing: timeIotaMs >= 1000,
+		BchBlockTimestamp:  hexutil.Uint64(info.BchBlockTimestamp),
+		BchHeight:          hexutil.Uint64(info.BchHeight),
+		BchTimeLag:         info.BchTimeLag,
+		MaxBchTimeLag:      app.MaxBchTimeLag,
+		NodeTime:           hexutil.Uint64(info.NodeTime.Unix()),
+	}
+	if !info.TendermintTime.IsZero() {
+		result.TendermintTime = info.TendermintTime.UTC().Format(time.RFC3339Nano)
+	}
+	return result
+}
@@ -0,0 +1,33 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/smartbch/smartbch/app"
+)
+
+func TestBuildTimeInfo(t *testing.T) {
+	tmTime := time.Unix(1650000000, 123456789)
+	info := buildTimeInfo(app.TimeInfo{
+		Height:            100,
+		TendermintTime:    tmTime,
+		BlockTimestamp:    tmTime.Unix(),
+		BchBlockTimestamp: 1649999400,
+		BchHeight:         740000,
+		BchTimeLag:        600,
+		NodeTime:          tmTime.Add(3 * time.Second),
+	}, 1000)
+	require.Equal(t, "2022-04-15T05:20:00.123456789Z", info.TendermintTime)
+	require.EqualValues(t, 1650000000, info.BlockTimestamp)
+	require.True(t, info.StrictlyIncreasing)
+	require.EqualValues(t, 600, info.BchTimeLag)
+	require.EqualValues(t, app.MaxBchTimeLag, info.MaxBchTimeLag)
+	require.EqualValues(t, 1650000003, info.NodeTime)
+
+	info = buildTimeInfo(app.TimeInfo{Height: 100}, 1)
+	require.Equal(t, "", info.TendermintTime)
+	require.False(t, info.StrictlyIncreasing)
+}
@@ -0,0 +1,55 @@
+package api
+
+import (
+	"github.com/ethereum/go-ethereum/common/hexutil"
+
+	sbchrpctypes "github.com/smartbch/smartbch/rpc/types"
+	"github.com/smartbch/smartbch/watcher"
+)
+
+const defaultReportedObservations = 100
+
+// GetWatcherAnalytics returns the latencies with which the watcher saw the new BCH blocks and the
+// reorgs it met within watcher-analytics-retain-days, with at most limit latest observations. A
+// block-finalize-number deeper than the forks met, plus a margin, is safe.
+func (sbch sbchAPI) GetWatcherAnalytics(limit *hexutil.Uint64) *sbchrpctypes.WatcherAnalytics {
+	sbch.logger.Debug("sbch_getWatcherAnalytics")
+	n := defaultReportedObservations
+	if limit != nil {
+		n = watcher.MaxReportedObservations
+		if uint64(*limit) < uint64(n) {
+			n = int(*limit)
+		}
+	}
+	return castWatcherAnalytics(sbch.backend.GetWatcherAnalytics(n))
+}
+
+func castWatcherAnalytics(r watcher.AnalyticsReport) *sbchrpctypes.WatcherAnalytics {
+	result := &sbchrpctypes.WatcherAnalytics{
+		Since:          hexutil.Uint64(r.Since),
+		ObservedBlocks: hexutil.Uint64(r.ObservedBlocks),
+		LatencyP50:     r.LatencyP50,
+		LatencyP90:     r.LatencyP90,
+		LatencyP99:     r.LatencyP99,
+		LatencyMax:     r.LatencyMax,
+		Forks:          make([]*sbchrpctypes.WatcherForkEvent, len(r.Forks)),
+		MaxForkDepth:   hexutil.Uint64(r.MaxForkDepth),
+		RecentBlocks:   make([]*sbchrpctypes.WatcherObservedBlock, len(r.RecentBlocks)),
+	}
+	for i, f := range r.Forks {
+		result.Forks[i] = &sbchrpctypes.WatcherForkEvent{
+			Time:       hexutil.Uint64(f.Time),
+			ForkHeight: hexutil.Uint64(f.ForkHeight),
+			Depth:      hexutil.Uint64(f.Depth),
+		}
+	}
+	for i, o := range r.RecentBlocks {
+		result.RecentBlocks[i] = &sbchrpctypes.WatcherObservedBlock{
+			Height:    hexutil.Uint64(o.Height),
+			Timestamp: hexutil.Uint64(o.Timestamp),
+			SeenAt:    hexutil.Uint64(o.SeenAt),
+			Latency:   o.Latency(),
+		}
+	}
+	return result
+}
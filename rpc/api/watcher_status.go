@@ -0,0 +1,33 @@
+package api
+
+import (
+	"github.com/ethereum/go-ethereum/common/hexutil"
+
+	sbchrpctypes "github.com/smartbch/smartbch/rpc/types"
+)
+
+// GetWatcherStatus returns the progress of the watcher and the backlogs between it and the app, a
+// growing backlog or a stale mainnet block timestamp means the watcher is stuck
+func (sbch sbchAPI) GetWatcherStatus() *sbchrpctypes.WatcherStatus {
+	sbch.logger.Debug("sbch_getWatcherStatus")
+	s := sbch.backend.GetWatcherStatus()
+	appEpochs, _ := sbch.backend.GetEpochList("app")
+	return &sbchrpctypes.WatcherStatus{
+		LatestFinalizedHeight:     hexutil.Uint64(s.LatestFinalizedHeight),
+		LastEpochEndHeight:        hexutil.Uint64(s.LastEpochEndHeight),
+		LastKnownEpochNum:         hexutil.Uint64(s.LastKnownEpochNum),
+		DeliveredEpochNum:         hexutil.Uint64(s.DeliveredEpochNum),
+		CurrMainnetBlockTimestamp: hexutil.Uint64(s.CurrMainnetBlockTimestamp),
+		PendingEpochs:             hexutil.Uint64(s.PendingEpochs),
+		EpochChanBacklog:          hexutil.Uint64(s.EpochChanBacklog),
+		MonitorVoteChanBacklog:    hexutil.Uint64(s.MonitorVoteChanBacklog),
+		SpilledEpochs:             hexutil.Uint64(s.SpilledEpochs),
+		AppEpochBacklog:           hexutil.Uint64(len(appEpochs)),
+		CcCollect:                 buildCcRescanStatus(s.CcCollect),
+		AvailableEndpoints:        hexutil.Uint64(s.AvailableEndpoints),
+		TotalEndpoints:            hexutil.Uint64(s.TotalEndpoints),
+		ZmqConnected:              s.ZmqConnected,
+		EpochGossipFallback:       s.EpochGossipFallback,
+		Stopped:                   s.Stopped,
+	}
+}
@@ -7,13 +7,17 @@ import (
 	"encoding/json"
 	"errors"
 	"net/http"
+	"time"
 
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/ethereum/go-ethereum/rpc"
 
 	cctypes "github.com/smartbch/smartbch/crosschain/types"
 	"github.com/smartbch/smartbch/rpc/types"
+	watchertypes "github.com/smartbch/smartbch/watcher/types"
 )
 
 // Client extends ethclient.Client and adds smartBCH specific APIs.
@@ -21,6 +25,14 @@ type Client struct {
 	*ethclient.Client
 	rpcClient *rpc.Client
 	rpcPubkey []byte
+	opts      Options
+}
+
+// Options controls how the sbch_ calls are retried. Errors returned by the server
+// (such as invalid arguments) are never retried, only the transport errors are.
+type Options struct {
+	MaxRetries    int
+	RetryInterval time.Duration
 }
 
 func Dial(rawUrl string) (*Client, error) {
@@ -28,15 +40,26 @@ func Dial(rawUrl string) (*Client, error) {
 }
 
 func DialContext(ctx context.Context, rawUrl string) (*Client, error) {
+	return DialWithOptions(ctx, rawUrl, Options{})
+}
+
+// DialWithOptions connects to a http or websocket endpoint. With a ws:// endpoint,
+// SubscribeNewHead and SubscribeFilterLogs of the embedded ethclient.Client can be used.
+func DialWithOptions(ctx context.Context, rawUrl string, opts Options) (*Client, error) {
 	c, err := rpc.DialContext(ctx, rawUrl)
 	if err != nil {
 		return nil, err
 	}
 
+	return NewClient(c, opts), nil
+}
+
+func NewClient(c *rpc.Client, opts Options) *Client {
 	return &Client{
 		Client:    ethclient.NewClient(c),
 		rpcClient: c,
-	}, nil
+		opts:      opts,
+	}
 }
 
 func DialHTTP(endpoint string) (*Client, error) {
@@ -49,15 +72,40 @@ func DialHTTPWithClient(endpoint string, client *http.Client) (*Client, error) {
 		return nil, err
 	}
 
-	return &Client{
-		Client:    ethclient.NewClient(c),
-		rpcClient: c,
-	}, nil
+	return NewClient(c, Options{}), nil
+}
+
+// RPCClient returns the underlying rpc client, for the methods not wrapped here
+func (c *Client) RPCClient() *rpc.Client {
+	return c.rpcClient
+}
+
+// call is like rpc.Client.CallContext, but retries on transport errors
+func (c *Client) call(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	for i := 0; ; i++ {
+		err := c.rpcClient.CallContext(ctx, result, method, args...)
+		if err == nil || i >= c.opts.MaxRetries || !isRetryable(err) {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(c.opts.RetryInterval):
+		}
+	}
+}
+
+func isRetryable(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var rpcErr rpc.Error
+	return !errors.As(err, &rpcErr)
 }
 
 func (c *Client) CcInfosForTest(ctx context.Context) (*cctypes.CCInfosForTest, error) {
 	var result cctypes.CCInfosForTest
-	err := c.rpcClient.CallContext(ctx, &result, "sbch_getCcInfosForTest")
+	err := c.call(ctx, &result, "sbch_getCcInfosForTest")
 	if err != nil {
 		return nil, err
 	}
@@ -66,7 +114,7 @@ func (c *Client) CcInfosForTest(ctx context.Context) (*cctypes.CCInfosForTest, e
 
 func (c *Client) CcInfo(ctx context.Context) (*types.CcInfo, error) {
 	var result types.CcInfo
-	err := c.rpcClient.CallContext(ctx, &result, "sbch_getCcInfo")
+	err := c.call(ctx, &result, "sbch_getCcInfo")
 	if err != nil {
 		return nil, err
 	}
@@ -87,6 +135,47 @@ func (c *Client) CcInfo(ctx context.Context) (*types.CcInfo, error) {
 	return &result, nil
 }
 
+func (c *Client) NominationStatus(ctx context.Context, pubkey common.Hash) (*types.NominationStatus, error) {
+	var result types.NominationStatus
+	err := c.call(ctx, &result, "sbch_getNominationStatus", pubkey)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *Client) BlockSummary(ctx context.Context, blockNum int64) (*types.BlockSummary, error) {
+	var result *types.BlockSummary
+	err := c.call(ctx, &result, "sbch_getBlockSummary", hexutil.Uint64(blockNum))
+	return result, err
+}
+
+func (c *Client) BlockSummaries(ctx context.Context, startHeight, endHeight int64) ([]*types.BlockSummary, error) {
+	var result []*types.BlockSummary
+	err := c.call(ctx, &result, "sbch_getBlockSummaries",
+		hexutil.Uint64(startHeight), hexutil.Uint64(endHeight))
+	return result, err
+}
+
+func (c *Client) TxFeeRecord(ctx context.Context, txHash common.Hash) (*types.TxFeeRecord, error) {
+	var result *types.TxFeeRecord
+	err := c.call(ctx, &result, "sbch_getTxFeeRecord", txHash)
+	return result, err
+}
+
+func (c *Client) BlockFeeRecords(ctx context.Context, startHeight, endHeight int64) ([]*types.BlockFeeRecord, error) {
+	var result []*types.BlockFeeRecord
+	err := c.call(ctx, &result, "sbch_getBlockFeeRecords",
+		hexutil.Uint64(startHeight), hexutil.Uint64(endHeight))
+	return result, err
+}
+
+func (c *Client) FrozenAddresses(ctx context.Context) ([]*types.FrozenAddress, error) {
+	var result []*types.FrozenAddress
+	err := c.call(ctx, &result, "sbch_getFrozenAddresses")
+	return result, err
+}
+
 func (c *Client) verifySigInUtxoInfos(ctx context.Context, infos *types.UtxoInfos) error {
 	if infos == nil {
 		return errors.New("infos is nil")
@@ -104,7 +193,7 @@ func (c *Client) verifySigInUtxoInfos(ctx context.Context, infos *types.UtxoInfo
 
 func (c *Client) RedeemingUtxosForMonitors(ctx context.Context) (*types.UtxoInfos, error) {
 	var result *types.UtxoInfos
-	err := c.rpcClient.CallContext(ctx, &result, "sbch_getRedeemingUtxosForMonitors")
+	err := c.call(ctx, &result, "sbch_getRedeemingUtxosForMonitors")
 	if err != nil {
 		return nil, err
 	}
@@ -113,7 +202,7 @@ func (c *Client) RedeemingUtxosForMonitors(ctx context.Context) (*types.UtxoInfo
 
 func (c *Client) RedeemingUtxosForOperators(ctx context.Context) (*types.UtxoInfos, error) {
 	var result *types.UtxoInfos
-	err := c.rpcClient.CallContext(ctx, &result, "sbch_getRedeemingUtxosForOperators")
+	err := c.call(ctx, &result, "sbch_getRedeemingUtxosForOperators")
 	if err != nil {
 		return nil, err
 	}
@@ -122,7 +211,7 @@ func (c *Client) RedeemingUtxosForOperators(ctx context.Context) (*types.UtxoInf
 
 func (c *Client) RedeemableUtxos(ctx context.Context) (*types.UtxoInfos, error) {
 	var result *types.UtxoInfos
-	err := c.rpcClient.CallContext(ctx, &result, "sbch_getRedeemableUtxos")
+	err := c.call(ctx, &result, "sbch_getRedeemableUtxos")
 	if err != nil {
 		return nil, err
 	}
@@ -131,16 +220,66 @@ func (c *Client) RedeemableUtxos(ctx context.Context) (*types.UtxoInfos, error)
 
 func (c *Client) LostAndFoundUtxos(ctx context.Context) (*types.UtxoInfos, error) {
 	var result *types.UtxoInfos
-	err := c.rpcClient.CallContext(ctx, &result, "sbch_getLostAndFoundUtxos")
+	err := c.call(ctx, &result, "sbch_getLostAndFoundUtxos")
 	if err != nil {
 		return nil, err
 	}
 	return result, c.verifySigInUtxoInfos(ctx, result)
 }
 
+func (c *Client) PegInRefunds(ctx context.Context) ([]*types.PegInRefund, error) {
+	var result []*types.PegInRefund
+	err := c.call(ctx, &result, "sbch_getPegInRefunds")
+	return result, err
+}
+
+func (c *Client) TimeInfo(ctx context.Context) (*types.TimeInfo, error) {
+	var result types.TimeInfo
+	err := c.call(ctx, &result, "sbch_getTimeInfo")
+	return &result, err
+}
+
+func (c *Client) WatcherStatus(ctx context.Context) (*types.WatcherStatus, error) {
+	var result types.WatcherStatus
+	err := c.call(ctx, &result, "sbch_getWatcherStatus")
+	return &result, err
+}
+
+// VoteInfos returns the epochs and the monitor votes in [start, end), the node must enable the
+// debug namespace
+func (c *Client) VoteInfos(ctx context.Context, start, end uint64) ([]*watchertypes.VoteInfo, error) {
+	var result []*watchertypes.VoteInfo
+	err := c.call(ctx, &result, "debug_getVoteInfos", hexutil.Uint64(start), hexutil.Uint64(end))
+	return result, err
+}
+
+func (c *Client) EstimateCrossChainTime(ctx context.Context, args types.CrossChainTimeArgs) (*types.CrossChainTimeEstimate, error) {
+	var result types.CrossChainTimeEstimate
+	err := c.call(ctx, &result, "sbch_estimateCrossChainTime", args)
+	return &result, err
+}
+
+func (c *Client) CcRescanStatus(ctx context.Context) (*types.CcRescanStatus, error) {
+	var result types.CcRescanStatus
+	err := c.call(ctx, &result, "sbch_getCcRescanStatus")
+	return &result, err
+}
+
+func (c *Client) SubmitAdminOp(ctx context.Context, payload string, sigs []hexutil.Bytes) (bool, error) {
+	var result bool
+	err := c.call(ctx, &result, "sbch_submitAdminOp", payload, sigs)
+	return result, err
+}
+
+func (c *Client) AdminOpNonce(ctx context.Context) (uint64, error) {
+	var result hexutil.Uint64
+	err := c.call(ctx, &result, "sbch_getAdminOpNonce")
+	return uint64(result), err
+}
+
 func (c *Client) ToBeConvertedUtxosForMonitors(ctx context.Context) (*types.UtxoInfos, error) {
 	var result *types.UtxoInfos
-	err := c.rpcClient.CallContext(ctx, &result, "sbch_getToBeConvertedUtxosForMonitors")
+	err := c.call(ctx, &result, "sbch_getToBeConvertedUtxosForMonitors")
 	if err != nil {
 		return nil, err
 	}
@@ -149,7 +288,7 @@ func (c *Client) ToBeConvertedUtxosForMonitors(ctx context.Context) (*types.Utxo
 
 func (c *Client) ToBeConvertedUtxosForOperators(ctx context.Context) (*types.UtxoInfos, error) {
 	var result *types.UtxoInfos
-	err := c.rpcClient.CallContext(ctx, &result, "sbch_getToBeConvertedUtxosForOperators")
+	err := c.call(ctx, &result, "sbch_getToBeConvertedUtxosForOperators")
 	if err != nil {
 		return nil, err
 	}
@@ -158,7 +297,7 @@ func (c *Client) ToBeConvertedUtxosForOperators(ctx context.Context) (*types.Utx
 
 func (c *Client) GetRpcPubkey(ctx context.Context) ([]byte, error) {
 	var results string
-	err := c.rpcClient.CallContext(ctx, &results, "sbch_getRpcPubkey")
+	err := c.call(ctx, &results, "sbch_getRpcPubkey")
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,54 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newFlakyServer(failures int, response string) (*httptest.Server, *int) {
+	count := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.ReadAll(r.Body)
+		count++
+		if count <= failures {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(response))
+	}))
+	return server, &count
+}
+
+func TestCallRetry(t *testing.T) {
+	server, count := newFlakyServer(2, `{"jsonrpc":"2.0","id":1,"result":"0x10"}`)
+	defer server.Close()
+
+	c, err := DialWithOptions(context.Background(), server.URL, Options{MaxRetries: 1, RetryInterval: time.Millisecond})
+	require.NoError(t, err)
+	_, err = c.AddressCount(context.Background(), "both", [20]byte{})
+	require.Error(t, err)
+	require.Equal(t, 2, *count)
+
+	n, err := c.AddressCount(context.Background(), "both", [20]byte{})
+	require.NoError(t, err)
+	require.Equal(t, uint64(16), n)
+	require.Equal(t, 3, *count)
+}
+
+func TestNoRetryOnServerError(t *testing.T) {
+	server, count := newFlakyServer(0, `{"jsonrpc":"2.0","id":1,"error":{"code":-32000,"message":"invalid kind"}}`)
+	defer server.Close()
+
+	c, err := DialWithOptions(context.Background(), server.URL, Options{MaxRetries: 3, RetryInterval: time.Millisecond})
+	require.NoError(t, err)
+	_, err = c.AddressCount(context.Background(), "bad", [20]byte{})
+	require.EqualError(t, err, "invalid kind")
+	require.Equal(t, 1, *count)
+}
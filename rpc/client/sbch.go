@@ -0,0 +1,155 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/smartbch/smartbch/rpc/types"
+)
+
+// The heights in the following methods can be -1 for the latest block. The query results
+// are in descending order when startHeight > endHeight, and limit=0 means no limit.
+
+func toBlockNumArg(height int64) string {
+	if height < 0 {
+		return "latest"
+	}
+	return hexutil.EncodeUint64(uint64(height))
+}
+
+func (c *Client) QueryTxBySrc(ctx context.Context, addr common.Address,
+	startHeight, endHeight int64, limit uint64) ([]*types.Transaction, error) {
+
+	var result []*types.Transaction
+	err := c.call(ctx, &result, "sbch_queryTxBySrc", addr, toBlockNumArg(startHeight), toBlockNumArg(endHeight), hexutil.Uint64(limit))
+	return result, err
+}
+
+func (c *Client) QueryTxByDst(ctx context.Context, addr common.Address,
+	startHeight, endHeight int64, limit uint64) ([]*types.Transaction, error) {
+
+	var result []*types.Transaction
+	err := c.call(ctx, &result, "sbch_queryTxByDst", addr, toBlockNumArg(startHeight), toBlockNumArg(endHeight), hexutil.Uint64(limit))
+	return result, err
+}
+
+func (c *Client) QueryTxByAddr(ctx context.Context, addr common.Address,
+	startHeight, endHeight int64, limit uint64) ([]*types.Transaction, error) {
+
+	var result []*types.Transaction
+	err := c.call(ctx, &result, "sbch_queryTxByAddr", addr, toBlockNumArg(startHeight), toBlockNumArg(endHeight), hexutil.Uint64(limit))
+	return result, err
+}
+
+func (c *Client) QueryLogs(ctx context.Context, addr common.Address, topics []common.Hash,
+	startHeight, endHeight int64, limit uint64) ([]gethtypes.Log, error) {
+
+	var result []gethtypes.Log
+	err := c.call(ctx, &result, "sbch_queryLogs", addr, topics, toBlockNumArg(startHeight), toBlockNumArg(endHeight), hexutil.Uint64(limit))
+	return result, err
+}
+
+// TxListByHeight returns the receipts of all the transactions in a block
+func (c *Client) TxListByHeight(ctx context.Context, height int64) ([]map[string]interface{}, error) {
+	var result []map[string]interface{}
+	err := c.call(ctx, &result, "sbch_getTxListByHeight", toBlockNumArg(height))
+	return result, err
+}
+
+func (c *Client) TxListByHeightWithRange(ctx context.Context, height int64,
+	start, end uint64) ([]map[string]interface{}, error) {
+
+	var result []map[string]interface{}
+	err := c.call(ctx, &result, "sbch_getTxListByHeightWithRange", toBlockNumArg(height), hexutil.Uint64(start), hexutil.Uint64(end))
+	return result, err
+}
+
+// AddressCount returns how many transactions are sent from ("from"), sent to ("to") or both ("both") addr
+func (c *Client) AddressCount(ctx context.Context, kind string, addr common.Address) (uint64, error) {
+	var result hexutil.Uint64
+	err := c.call(ctx, &result, "sbch_getAddressCount", kind, addr)
+	return uint64(result), err
+}
+
+func (c *Client) Sep20AddressCount(ctx context.Context, kind string, contract, addr common.Address) (uint64, error) {
+	var result hexutil.Uint64
+	err := c.call(ctx, &result, "sbch_getSep20AddressCount", kind, contract, addr)
+	return uint64(result), err
+}
+
+func (c *Client) ComputeCreate2Address(ctx context.Context, deployer common.Address, salt, initCodeHash common.Hash) (common.Address, error) {
+	var result common.Address
+	err := c.call(ctx, &result, "sbch_computeCreate2Address", deployer, salt, initCodeHash)
+	return result, err
+}
+
+// IsAddressDeployed queries the latest state if height is negative
+func (c *Client) IsAddressDeployed(ctx context.Context, addr common.Address, height int64) (bool, error) {
+	var result bool
+	err := c.call(ctx, &result, "sbch_isAddressDeployed", addr, toBlockNumArg(height))
+	return result, err
+}
+
+func (c *Client) Create2Contract(ctx context.Context, addr common.Address) (*types.Create2Contract, error) {
+	var result *types.Create2Contract
+	err := c.call(ctx, &result, "sbch_getCreate2Contract", addr)
+	return result, err
+}
+
+func (c *Client) Create2ContractsByDeployer(ctx context.Context, deployer common.Address) ([]*types.Create2Contract, error) {
+	var result []*types.Create2Contract
+	err := c.call(ctx, &result, "sbch_getCreate2ContractsByDeployer", deployer)
+	return result, err
+}
+
+func (c *Client) EpochList(ctx context.Context, from string) ([]*types.StakingEpoch, error) {
+	var result []*types.StakingEpoch
+	err := c.call(ctx, &result, "sbch_getEpochList", from)
+	return result, err
+}
+
+func (c *Client) CurrEpoch(ctx context.Context, includesPosVotes bool) (*types.StakingEpoch, error) {
+	var result *types.StakingEpoch
+	err := c.call(ctx, &result, "sbch_getCurrEpoch", includesPosVotes)
+	return result, err
+}
+
+// HealthCheck reports the node as unhealthy if its latest block is older than latestBlockTooOldAge seconds
+func (c *Client) HealthCheck(ctx context.Context, latestBlockTooOldAge uint64) (*types.HealthStatus, error) {
+	var result types.HealthStatus
+	err := c.call(ctx, &result, "sbch_healthCheck", hexutil.Uint64(latestBlockTooOldAge))
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// SbchTransactionReceipt returns the receipt with the internal transactions
+func (c *Client) SbchTransactionReceipt(ctx context.Context, hash common.Hash) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := c.call(ctx, &result, "sbch_getTransactionReceipt", hash)
+	return result, err
+}
+
+// SbchCall is like eth_call, but also returns the logs, internal transactions and rwlists
+func (c *Client) SbchCall(ctx context.Context, args types.CallArgs, blockNum int64) (*types.CallDetail, error) {
+	var result *types.CallDetail
+	err := c.call(ctx, &result, "sbch_call", args, toBlockNumArg(blockNum))
+	return result, err
+}
+
+func (c *Client) ValidatorsInfo(ctx context.Context) (json.RawMessage, error) {
+	var result json.RawMessage
+	err := c.call(ctx, &result, "sbch_validatorsInfo")
+	return result, err
+}
+
+func (c *Client) SyncBlock(ctx context.Context, height uint64) ([]byte, error) {
+	var result hexutil.Bytes
+	err := c.call(ctx, &result, "sbch_getSyncBlock", hexutil.Uint64(height))
+	return result, err
+}
@@ -0,0 +1,124 @@
+package rpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	tmlog "github.com/tendermint/tendermint/libs/log"
+	tmrpcserver "github.com/tendermint/tendermint/rpc/jsonrpc/server"
+)
+
+const (
+	DefaultReadHeaderTimeout = 5 * time.Second
+	DefaultIdleTimeout       = 60 * time.Second
+	DefaultMaxBatchSize      = 100
+
+	errCodeInvalidRequest = -32600
+)
+
+// Limits complements tmrpcserver.Config, whose MaxBodyBytes, MaxHeaderBytes, ReadTimeout
+// and WriteTimeout are also applied. The size of each websocket message is limited by
+// go-ethereum (15MB), MaxBodyBytes and MaxBatchSize only apply to the handshake of websocket.
+type Limits struct {
+	// the time allowed to read the request headers, it stops the clients which send headers slowly
+	ReadHeaderTimeout time.Duration
+	// the time to keep an idle keep-alive connection
+	IdleTimeout time.Duration
+	// the max number of requests in a batch, zero means no limit
+	MaxBatchSize int
+}
+
+func DefaultLimits() Limits {
+	return Limits{
+		ReadHeaderTimeout: DefaultReadHeaderTimeout,
+		IdleTimeout:       DefaultIdleTimeout,
+		MaxBatchSize:      DefaultMaxBatchSize,
+	}
+}
+
+func newHttpServer(handler http.Handler, config *tmrpcserver.Config, limits Limits, logger tmlog.Logger) *http.Server {
+	return &http.Server{
+		Handler:           tmrpcserver.RecoverAndLogHandler(newLimitHandler(handler, config.MaxBodyBytes, limits.MaxBatchSize), logger),
+		ReadHeaderTimeout: limits.ReadHeaderTimeout,
+		ReadTimeout:       config.ReadTimeout,
+		WriteTimeout:      config.WriteTimeout,
+		IdleTimeout:       limits.IdleTimeout,
+		MaxHeaderBytes:    config.MaxHeaderBytes,
+	}
+}
+
+// limitHandler rejects the oversized requests and batches with JSON-RPC errors, instead of
+// letting them reach the decoder of go-ethereum
+type limitHandler struct {
+	next         http.Handler
+	maxBodyBytes int64
+	maxBatchSize int
+}
+
+func newLimitHandler(next http.Handler, maxBodyBytes int64, maxBatchSize int) http.Handler {
+	return &limitHandler{next: next, maxBodyBytes: maxBodyBytes, maxBatchSize: maxBatchSize}
+}
+
+func (h *limitHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.next.ServeHTTP(w, r)
+		return
+	}
+	if h.maxBodyBytes > 0 {
+		if r.ContentLength > h.maxBodyBytes {
+			writeJsonRpcError(w, http.StatusRequestEntityTooLarge, errBodyTooLarge(h.maxBodyBytes))
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, h.maxBodyBytes)
+	}
+	if h.maxBatchSize > 0 {
+		bz, err := io.ReadAll(r.Body)
+		if err != nil {
+			// MaxBytesReader does not have a typed error in this go version
+			writeJsonRpcError(w, http.StatusRequestEntityTooLarge, errBodyTooLarge(h.maxBodyBytes))
+			return
+		}
+		if n := getBatchSize(bz); n > h.maxBatchSize {
+			writeJsonRpcError(w, http.StatusBadRequest,
+				fmt.Errorf("batch too large (%d>%d)", n, h.maxBatchSize))
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(bz))
+	}
+	h.next.ServeHTTP(w, r)
+}
+
+func errBodyTooLarge(max int64) error {
+	return fmt.Errorf("request body too large (max %d bytes)", max)
+}
+
+// getBatchSize returns 0 if bz is not a batch, the malformed ones are left to the decoder
+func getBatchSize(bz []byte) int {
+	bz = bytes.TrimLeft(bz, " \t\r\n")
+	if len(bz) == 0 || bz[0] != '[' {
+		return 0
+	}
+	var batch []json.RawMessage
+	if err := json.Unmarshal(bz, &batch); err != nil {
+		return 0
+	}
+	return len(batch)
+}
+
+func writeJsonRpcError(w http.ResponseWriter, statusCode int, err error) {
+	bz, _ := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      nil,
+		"error": map[string]interface{}{
+			"code":    errCodeInvalidRequest,
+			"message": err.Error(),
+		},
+	})
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	_, _ = w.Write(bz)
+}
@@ -0,0 +1,42 @@
+package rpc
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimitHandler(t *testing.T) {
+	var received string
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		bz, _ := io.ReadAll(r.Body)
+		received = string(bz)
+	})
+	handler := newLimitHandler(next, 100, 2)
+
+	post := func(body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body)))
+		return w
+	}
+
+	w := post(`[{"id":1},{"id":2}]`)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, `[{"id":1},{"id":2}]`, received)
+
+	w = post(` [{"id":1},{"id":2},{"id":3}]`)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	require.Equal(t, `{"error":{"code":-32600,"message":"batch too large (3\u003e2)"},"id":null,"jsonrpc":"2.0"}`, w.Body.String())
+
+	w = post(`{"method":"` + strings.Repeat("x", 100) + `"}`)
+	require.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	require.Contains(t, w.Body.String(), "request body too large")
+
+	// malformed batches are left to the decoder
+	w = post(`[{"id":1},`)
+	require.Equal(t, http.StatusOK, w.Code)
+}
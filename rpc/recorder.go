@@ -0,0 +1,247 @@
+package rpc
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"reflect"
+	"sync"
+	"time"
+
+	tmlog "github.com/tendermint/tendermint/libs/log"
+)
+
+const (
+	// the recording stops when the file reaches this size, even if the time window has not ended
+	MaxRecordBytes = 256 << 20
+
+	redactedParams = `"redacted"`
+)
+
+// the params of these methods carry secrets, they are replaced with "redacted" in the records
+var redactedMethods = map[string]bool{
+	"sbch_setRpcKey":     true,
+	"sbch_submitAdminOp": true,
+}
+
+// RecordedCall is a request/response pair of the HTTP JSON-RPC server. Nothing about the client,
+// such as its address or headers, is recorded.
+type RecordedCall struct {
+	Time      int64           `json:"time"` // unix milliseconds
+	ElapsedMs int64           `json:"elapsedMs"`
+	Status    int             `json:"status"`
+	Request   json.RawMessage `json:"request"`
+	Response  json.RawMessage `json:"response"`
+	// the params of some requests are redacted, replaying them is meaningless
+	Redacted bool `json:"redacted,omitempty"`
+}
+
+// Recorder writes the JSON-RPC requests over HTTP and their responses into a file, one JSON object
+// per line, until the time window ends. The requests rejected by the limits are not recorded.
+// The file is re-issued against a test node with 'smartbchd rpc-replay'.
+type Recorder struct {
+	mtx      sync.Mutex
+	file     *os.File
+	writer   *bufio.Writer
+	written  int64
+	count    int
+	deadline time.Time
+	logger   tmlog.Logger
+}
+
+func NewRecorder(path string, window time.Duration, logger tmlog.Logger) (*Recorder, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return nil, err
+	}
+	logger.Info("recording RPC requests", "file", path, "until", time.Now().Add(window))
+	return &Recorder{
+		file:     file,
+		writer:   bufio.NewWriter(file),
+		deadline: time.Now().Add(window),
+		logger:   logger,
+	}, nil
+}
+
+// Wrap returns a handler recording the POST requests handled by next
+func (r *Recorder) Wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodPost || !r.recording() {
+			next.ServeHTTP(w, req)
+			return
+		}
+		bz, err := io.ReadAll(req.Body)
+		if err != nil {
+			writeJsonRpcError(w, http.StatusRequestEntityTooLarge, err)
+			return
+		}
+		req.Body = io.NopCloser(bytes.NewReader(bz))
+		rw := &recordingWriter{ResponseWriter: w, status: http.StatusOK}
+		start := time.Now()
+		next.ServeHTTP(rw, req)
+		request, redacted := anonymizeRequest(bz)
+		r.write(&RecordedCall{
+			Time:      start.UnixMilli(),
+			ElapsedMs: time.Since(start).Milliseconds(),
+			Status:    rw.status,
+			Request:   request,
+			Response:  toRawJson(rw.body.Bytes()),
+			Redacted:  redacted,
+		})
+	})
+}
+
+func (r *Recorder) recording() bool {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	if r.file == nil {
+		return false
+	}
+	if time.Now().After(r.deadline) {
+		r.closeLocked()
+		return false
+	}
+	return true
+}
+
+func (r *Recorder) write(call *RecordedCall) {
+	bz, err := json.Marshal(call)
+	if err != nil {
+		return
+	}
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	if r.file == nil {
+		return
+	}
+	if _, err = r.writer.Write(append(bz, '\n')); err != nil {
+		r.logger.Error("failed to record RPC request", "err", err)
+		r.closeLocked()
+		return
+	}
+	r.count++
+	r.written += int64(len(bz)) + 1
+	if r.written >= MaxRecordBytes {
+		r.closeLocked()
+	}
+}
+
+// Close stops the recording, it is also stopped automatically when the time window ends
+func (r *Recorder) Close() {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.closeLocked()
+}
+
+func (r *Recorder) closeLocked() {
+	if r.file == nil {
+		return
+	}
+	err := r.writer.Flush()
+	if err2 := r.file.Close(); err == nil {
+		err = err2
+	}
+	r.file = nil
+	r.logger.Info("RPC recording finished", "requests", r.count, "bytes", r.written, "err", err)
+}
+
+type recordingWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (w *recordingWriter) WriteHeader(statusCode int) {
+	w.status = statusCode
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *recordingWriter) Write(bz []byte) (int, error) {
+	w.body.Write(bz)
+	return w.ResponseWriter.Write(bz)
+}
+
+type jsonRpcRequest struct {
+	JsonRpc string          `json:"jsonrpc,omitempty"`
+	Id      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+// anonymizeRequest replaces the params of the methods carrying secrets. The malformed requests are
+// kept as they are, because they may be what triggers a bug.
+func anonymizeRequest(bz []byte) (json.RawMessage, bool) {
+	trimmed := bytes.TrimLeft(bz, " \t\r\n")
+	var reqs []*jsonRpcRequest
+	isBatch := len(trimmed) > 0 && trimmed[0] == '['
+	if isBatch {
+		if json.Unmarshal(trimmed, &reqs) != nil {
+			return toRawJson(bz), false
+		}
+	} else {
+		var req jsonRpcRequest
+		if json.Unmarshal(trimmed, &req) != nil {
+			return toRawJson(bz), false
+		}
+		reqs = append(reqs, &req)
+	}
+	redacted := false
+	for _, req := range reqs {
+		if req != nil && redactedMethods[req.Method] {
+			req.Params = json.RawMessage(redactedParams)
+			redacted = true
+		}
+	}
+	if !redacted {
+		return toRawJson(bz), false
+	}
+	var out []byte
+	if isBatch {
+		out, _ = json.Marshal(reqs)
+	} else {
+		out, _ = json.Marshal(reqs[0])
+	}
+	return out, true
+}
+
+// toRawJson returns bz as a JSON string if it is not valid JSON
+func toRawJson(bz []byte) json.RawMessage {
+	bz = bytes.TrimSpace(bz)
+	if json.Valid(bz) {
+		return append(json.RawMessage(nil), bz...)
+	}
+	s, _ := json.Marshal(string(bz))
+	return s
+}
+
+// ReadRecords reads the file written by a Recorder
+func ReadRecords(path string) ([]*RecordedCall, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	var calls []*RecordedCall
+	decoder := json.NewDecoder(file)
+	for {
+		var call RecordedCall
+		if err := decoder.Decode(&call); err == io.EOF {
+			return calls, nil
+		} else if err != nil {
+			return calls, err
+		}
+		calls = append(calls, &call)
+	}
+}
+
+// SameResponse compares two JSON-RPC responses semantically, ignoring the formatting
+func SameResponse(a, b []byte) bool {
+	var va, vb interface{}
+	if json.Unmarshal(a, &va) != nil || json.Unmarshal(b, &vb) != nil {
+		return bytes.Equal(bytes.TrimSpace(a), bytes.TrimSpace(b))
+	}
+	return reflect.DeepEqual(va, vb)
+}
@@ -0,0 +1,57 @@
+package rpc
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/libs/log"
+)
+
+func TestRecorder(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.jsonl")
+	recorder, err := NewRecorder(path, time.Minute, log.NewNopLogger())
+	require.NoError(t, err)
+	handler := recorder.Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		bz, _ := io.ReadAll(r.Body)
+		if strings.Contains(string(bz), "bad") {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":"0x1"}` + "\n"))
+	}))
+	post := func(body string) {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		req.Header.Set("User-Agent", "secret-agent")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	post(`{"jsonrpc":"2.0","id":1,"method":"eth_blockNumber","params":[]}`)
+	post(`[{"id":2,"method":"eth_chainId"},{"id":3,"method":"sbch_setRpcKey","params":["0xabcd"]}]`)
+	post(`bad request`)
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	recorder.Close()
+	post(`{"id":4,"method":"eth_blockNumber"}`)
+
+	calls, err := ReadRecords(path)
+	require.NoError(t, err)
+	require.Len(t, calls, 3)
+	require.JSONEq(t, `{"jsonrpc":"2.0","id":1,"method":"eth_blockNumber","params":[]}`, string(calls[0].Request))
+	require.JSONEq(t, `{"jsonrpc":"2.0","id":1,"result":"0x1"}`, string(calls[0].Response))
+	require.Equal(t, http.StatusOK, calls[0].Status)
+	require.False(t, calls[0].Redacted)
+	require.JSONEq(t, `[{"id":2,"method":"eth_chainId"},{"id":3,"method":"sbch_setRpcKey","params":"redacted"}]`,
+		string(calls[1].Request))
+	require.True(t, calls[1].Redacted)
+	require.Equal(t, `"bad request"`, string(calls[2].Request))
+	require.Equal(t, http.StatusBadRequest, calls[2].Status)
+}
+
+func TestSameResponse(t *testing.T) {
+	require.True(t, SameResponse([]byte(`{"id":1,"result":"0x1"}`), []byte(`{ "result": "0x1", "id": 1 }`+"\n")))
+	require.False(t, SameResponse([]byte(`{"id":1,"result":"0x1"}`), []byte(`{"id":1,"result":"0x2"}`)))
+	require.False(t, SameResponse([]byte(`not json`), []byte(`{}`)))
+}
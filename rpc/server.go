@@ -39,6 +39,8 @@ type Server struct {
 	httpAPIs     []string
 	wsAPIs       []string
 	serverConfig *tmrpcserver.Config
+	limits       Limits
+	recorder     *Recorder // nil if the requests are not recorded
 
 	logger  tmlog.Logger
 	backend api.BackendService
@@ -55,7 +57,7 @@ type Server struct {
 }
 
 func NewServer(rpcAddr, wsAddr, rpcAddrSecure, wsAddrSecure, corsDomain, certFile, keyFile string,
-	serverCfg *tmrpcserver.Config, backend api.BackendService,
+	serverCfg *tmrpcserver.Config, limits Limits, recorder *Recorder, backend api.BackendService,
 	logger tmlog.Logger, unlockedKeys []string,
 	httpAPI string, wsAPI string) tmservice.Service {
 
@@ -66,6 +68,8 @@ func NewServer(rpcAddr, wsAddr, rpcAddrSecure, wsAddrSecure, corsDomain, certFil
 		certFile:     certFile,
 		keyFile:      keyFile,
 		serverConfig: serverCfg,
+		limits:       limits,
+		recorder:     recorder,
 		backend:      backend,
 		logger:       logger,
 		unlockedKeys: unlockedKeys,
@@ -103,6 +107,9 @@ func (server *Server) startHTTPAndHTTPS(apis []gethrpc.API) (err error) {
 
 	allowedOrigins := strings.Split(server.corsDomain, ",")
 	handler := newCorsHandler(server.httpServer, allowedOrigins)
+	if server.recorder != nil {
+		handler = server.recorder.Wrap(handler)
+	}
 
 	server.httpListener, err = tmrpcserver.Listen(
 		server.rpcAddr, server.serverConfig)
@@ -110,8 +117,7 @@ func (server *Server) startHTTPAndHTTPS(apis []gethrpc.API) (err error) {
 		return err
 	}
 	go func() {
-		err := tmrpcserver.Serve(server.httpListener, handler, server.logger,
-			server.serverConfig)
+		err := server.serve(server.httpListener, handler, "", "")
 		if err != nil {
 			server.logger.Error(err.Error())
 		}
@@ -131,16 +137,15 @@ func (server *Server) startHTTPAndHTTPS(apis []gethrpc.API) (err error) {
 					server.StopHttpsListener()
 				}()
 				err := ServeTLSWithSelfSignedCertificate(server.httpsListener, handler,
-					server.serverConfig, server.logger)
+					server.serverConfig, server.limits, server.logger)
 				if err != nil {
 					server.logger.Error(err.Error())
 				}
 			}()
 		} else {
 			go func() {
-				err := tmrpcserver.ServeTLS(server.httpsListener, handler,
-					server.certFile, server.keyFile, server.logger,
-					server.serverConfig)
+				err := server.serve(server.httpsListener, handler,
+					server.certFile, server.keyFile)
 				if err != nil {
 					server.logger.Error(err.Error())
 				}
@@ -154,15 +159,11 @@ func ServeTLSWithSelfSignedCertificate(
 	listener net.Listener,
 	handler http.Handler,
 	config *tmrpcserver.Config,
+	limits Limits,
 	logger tmlog.Logger,
 ) error {
-	s := &http.Server{
-		Handler:        tmrpcserver.RecoverAndLogHandler(handler, logger),
-		ReadTimeout:    config.ReadTimeout,
-		WriteTimeout:   config.WriteTimeout,
-		MaxHeaderBytes: config.MaxHeaderBytes,
-		TLSConfig:      CreateCertificate("smartbch"),
-	}
+	s := newHttpServer(handler, config, limits, logger)
+	s.TLSConfig = CreateCertificate("smartbch")
 	err := s.ServeTLS(listener, "", "")
 
 	logger.Error("RPC HTTPS server stopped", "err", err)
@@ -204,8 +205,7 @@ func (server *Server) startWSAndWSS(apis []gethrpc.API) (err error) {
 		return err
 	}
 	go func() {
-		err := tmrpcserver.Serve(server.wsListener, wsh, server.logger,
-			server.serverConfig)
+		err := server.serve(server.wsListener, wsh, "", "")
 		if err != nil {
 			server.logger.Error(err.Error())
 		}
@@ -218,9 +218,8 @@ func (server *Server) startWSAndWSS(apis []gethrpc.API) (err error) {
 			return err
 		}
 		go func() {
-			err := tmrpcserver.ServeTLS(server.wssListener, wsh,
-				server.certFile, server.keyFile, server.logger,
-				server.serverConfig)
+			err := server.serve(server.wssListener, wsh,
+				server.certFile, server.keyFile)
 			if err != nil {
 				server.logger.Error(err.Error())
 			}
@@ -229,9 +228,22 @@ func (server *Server) startWSAndWSS(apis []gethrpc.API) (err error) {
 	return nil
 }
 
+// serve is like tmrpcserver.Serve and tmrpcserver.ServeTLS, with the limits applied.
+// TLS is used when certFile is not empty.
+func (server *Server) serve(listener net.Listener, handler http.Handler, certFile, keyFile string) error {
+	s := newHttpServer(handler, server.serverConfig, server.limits, server.logger)
+	if certFile != "" {
+		return s.ServeTLS(listener, certFile, keyFile)
+	}
+	return s.Serve(listener)
+}
+
 func (server *Server) OnStop() {
 	server.stopHTTP()
 	server.stopWS()
+	if server.recorder != nil {
+		server.recorder.Close()
+	}
 }
 
 func (server *Server) stopHTTP() {
@@ -0,0 +1,92 @@
+package types
+
+import (
+	gethcmn "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// BlockSummary contains the header fields of a block and some statistics of its transactions,
+// without the transaction bodies
+type BlockSummary struct {
+	Number           hexutil.Uint64  `json:"number"`
+	Hash             gethcmn.Hash    `json:"hash"`
+	ParentHash       gethcmn.Hash    `json:"parentHash"`
+	Miner            gethcmn.Address `json:"miner"`    // the reward address of the proposer
+	Proposer         gethcmn.Address `json:"proposer"` // the consensus address of the proposer
+	StateRoot        gethcmn.Hash    `json:"stateRoot"`
+	TransactionsRoot gethcmn.Hash    `json:"transactionsRoot"`
+	Timestamp        hexutil.Uint64  `json:"timestamp"`
+	Size             hexutil.Uint64  `json:"size"`
+	GasLimit         hexutil.Uint64  `json:"gasLimit"`
+	GasUsed          hexutil.Uint64  `json:"gasUsed"`
+	TxCount          hexutil.Uint64  `json:"txCount"`
+	FailedTxCount    hexutil.Uint64  `json:"failedTxCount"`
+	TotalFee         *hexutil.Big    `json:"totalFee"` // sum of gasUsed*gasPrice, in wei
+	// the top-ups from the gas grant pool made after the txs, only known for the recent blocks
+	// since the node started
+	GasGrants []*GasGrant `json:"gasGrants,omitempty"`
+}
+
+// GasGrant is a top-up of an elected cc operator or monitor from the gas grant pool
+type GasGrant struct {
+	Address gethcmn.Address `json:"address"`
+	Amount  *hexutil.Big    `json:"amount"`
+}
+
+// ProposerInfo links the consensus address of a validator, which proposes blocks, to its
+// addresses in smartBCH
+type ProposerInfo struct {
+	ConsAddress gethcmn.Address `json:"consAddress"`
+	Address     gethcmn.Address `json:"address"`
+	RewardTo    gethcmn.Address `json:"rewardTo"`
+	Pubkey      gethcmn.Hash    `json:"pubkey"`
+}
+
+// ConsensusParams are the parameters limiting the capacity of the chain. The tendermint ones
+// are read from tendermint's latest state, the app ones are set by smartBCH.
+type ConsensusParams struct {
+	Height                  hexutil.Uint64 `json:"height"`
+	BlockMaxBytes           hexutil.Uint64 `json:"blockMaxBytes"`
+	BlockMaxGas             hexutil.Uint64 `json:"blockMaxGas"`
+	BlockTimeIotaMs         hexutil.Uint64 `json:"blockTimeIotaMs"`
+	EvidenceMaxAgeNumBlocks hexutil.Uint64 `json:"evidenceMaxAgeNumBlocks"`
+	EvidenceMaxAgeDuration  string         `json:"evidenceMaxAgeDuration"`
+	EvidenceMaxBytes        hexutil.Uint64 `json:"evidenceMaxBytes"`
+	ValidatorPubKeyTypes    []string       `json:"validatorPubKeyTypes"`
+	AppVersion              hexutil.Uint64 `json:"appVersion"`
+	// the height since which the tendermint params take effect
+	TendermintParamsSince hexutil.Uint64 `json:"tendermintParamsSince"`
+	MaxTxGas              hexutil.Uint64 `json:"maxTxGas"`
+	MinGasPrice           hexutil.Uint64 `json:"minGasPrice"`
+	History               []*ParamChange `json:"history"`
+}
+
+// ParamChange is a change of a consensus parameter, OldValue is empty if it is unknown
+type ParamChange struct {
+	Height   hexutil.Uint64 `json:"height"`
+	Name     string         `json:"name"`
+	OldValue string         `json:"oldValue"`
+	NewValue string         `json:"newValue"`
+}
+
+// TimeInfo compares the timestamps of the latest block seen by Tendermint and the EVM with the
+// one of the latest BCH block seen by the watcher, all in seconds except TendermintTime
+type TimeInfo struct {
+	Height hexutil.Uint64 `json:"height"`
+	// RFC3339 with nanoseconds, empty if no block is committed after the node started
+	TendermintTime string `json:"tendermintTime"`
+	// block.timestamp in the EVM, the Tendermint time truncated to seconds
+	BlockTimestamp hexutil.Uint64 `json:"blockTimestamp"`
+	// the min interval between the Tendermint times of two blocks
+	BlockTimeIotaMs hexutil.Uint64 `json:"blockTimeIotaMs"`
+	// whether block.timestamp strictly increases, otherwise it only never decreases and
+	// consecutive blocks may share the same block.timestamp
+	StrictlyIncreasing bool           `json:"strictlyIncreasing"`
+	BchBlockTimestamp  hexutil.Uint64 `json:"bchBlockTimestamp"`
+	BchHeight          hexutil.Uint64 `json:"bchHeight"`
+	// blockTimestamp - bchBlockTimestamp, the node stops producing blocks when it exceeds maxBchTimeLag
+	BchTimeLag    int64 `json:"bchTimeLag"`
+	MaxBchTimeLag int64 `json:"maxBchTimeLag"`
+	// the local clock of the node
+	NodeTime hexutil.Uint64 `json:"nodeTime"`
+}
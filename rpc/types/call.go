@@ -0,0 +1,87 @@
+package types
+
+import (
+	gethcmn "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/holiman/uint256"
+
+	"github.com/smartbch/smartbch/rpc/internal/ethapi"
+)
+
+// CallDetail is the result of sbch_call, as decoded by clients
+type CallDetail struct {
+	Status                 int             `json:"status"`
+	GasUsed                hexutil.Uint64  `json:"gasUsed"`
+	OutData                hexutil.Bytes   `json:"returnData"`
+	Logs                   []*CallLog      `json:"logs"`
+	CreatedContractAddress gethcmn.Address `json:"contractAddress"`
+	InternalTxs            []*InternalTx   `json:"internalTransactions"`
+	RwLists                *RWLists        `json:"rwLists"`
+}
+
+// InternalTx is an internal call in sbch_call's result and in the receipts returned by sbch_ methods
+type InternalTx struct {
+	CallPath       string           `json:"callPath"`
+	From           gethcmn.Address  `json:"from"`
+	To             gethcmn.Address  `json:"to"`
+	GasLimit       hexutil.Uint64   `json:"gas"`
+	Value          *hexutil.Big     `json:"value"`
+	Input          hexutil.Bytes    `json:"input"`
+	StatusCode     hexutil.Uint64   `json:"status"`
+	GasUsed        hexutil.Uint64   `json:"gasUsed"`
+	Output         hexutil.Bytes    `json:"output"`
+	CreatedAddress *gethcmn.Address `json:"contractAddress,omitempty"`
+}
+
+type CallLog struct {
+	Address gethcmn.Address `json:"address"`
+	Topics  []gethcmn.Hash  `json:"topics"`
+	Data    hexutil.Bytes   `json:"data"`
+}
+type RWLists struct {
+	CreationCounterRList []CreationCounterRWOp `json:"creationCounterRList"`
+	CreationCounterWList []CreationCounterRWOp `json:"creationCounterWList"`
+	AccountRList         []AccountRWOp         `json:"accountRList"`
+	AccountWList         []AccountRWOp         `json:"accountWList"`
+	BytecodeRList        []BytecodeRWOp        `json:"bytecodeRList"`
+	BytecodeWList        []BytecodeRWOp        `json:"bytecodeWList"`
+	StorageRList         []StorageRWOp         `json:"storageRList"`
+	StorageWList         []StorageRWOp         `json:"storageWList"`
+	BlockHashList        []BlockHashOp         `json:"blockHashList"`
+}
+type CreationCounterRWOp struct {
+	Lsb     uint8  `json:"lsb"`
+	Counter uint64 `json:"counter"`
+}
+type AccountRWOp struct {
+	Addr    gethcmn.Address `json:"address"`
+	Nonce   hexutil.Uint64  `json:"nonce"`
+	Balance *uint256.Int    `json:"balance"`
+}
+type BytecodeRWOp struct {
+	Addr     gethcmn.Address `json:"address"`
+	Bytecode hexutil.Bytes   `json:"bytecode"`
+}
+type StorageRWOp struct {
+	Seq   hexutil.Uint64 `json:"seq"`
+	Key   hexutil.Bytes  `json:"key"`
+	Value hexutil.Bytes  `json:"value"`
+}
+type BlockHashOp struct {
+	Height hexutil.Uint64 `json:"height"`
+	Hash   gethcmn.Hash   `json:"hash"`
+}
+
+// HealthStatus is the result of sbch_healthCheck
+type HealthStatus struct {
+	LatestBlockHeight    hexutil.Uint64 `json:"latestBlockHeight"`
+	LatestBlockTimestamp hexutil.Uint64 `json:"latestBlockTimestamp"`
+	OK                   bool           `json:"ok"`
+	Error                string         `json:"error"`
+}
+
+// Transaction is the transaction returned by sbch_queryTxBySrc, sbch_queryTxByDst and sbch_queryTxByAddr
+type Transaction = ethapi.Transaction
+
+// CallArgs is the argument of sbch_call
+type CallArgs = ethapi.CallArgs
@@ -47,7 +47,114 @@ type UtxoInfo struct {
 	TxSigHash        hexutil.Bytes   `json:"txSigHash"`
 }
 
+// PegInRefund is a lost-and-found deposit which can be refunded to its sender after RefundableTime
+type PegInRefund struct {
+	UtxoInfo
+	RefundTarget   gethcmn.Address `json:"refundTarget"` // the pubkey hash of a P2PKH address, zero until a monitor sets it if the deposit has no receiver
+	RefundableTime int64           `json:"refundableTime"`
+}
+
+// PendingCcDeposit is a deposit to the covenant found in the BCH mempool, it is minted only after
+// it is mined and the rescan window including its block is handled
+type PendingCcDeposit struct {
+	Txid            gethcmn.Hash    `json:"txid"`
+	Index           uint32          `json:"index"`
+	Amount          hexutil.Uint64  `json:"amount"` // in satoshi
+	Receiver        gethcmn.Address `json:"receiver"`
+	CovenantAddress gethcmn.Address `json:"covenantAddress"`
+	CallTarget      gethcmn.Address `json:"callTarget"`
+	MalformedMemo   bool            `json:"malformedMemo"`
+	NoReceiver      bool            `json:"noReceiver"`
+	FirstSeen       int64           `json:"firstSeen"`
+}
+
 type UtxoInfos struct {
 	Infos     []*UtxoInfo   `json:"infos"`
 	Signature hexutil.Bytes `json:"signature"`
 }
+
+// RedeemTarget is the result of validating the BCH address to which the redeemed BCH is paid
+type RedeemTarget struct {
+	Valid         bool            `json:"valid"`
+	Error         string          `json:"error,omitempty"`
+	Format        string          `json:"format,omitempty"`
+	TargetAddress gethcmn.Address `json:"targetAddress"` // the pubkey hash passed to redeem()
+	CashAddr      string          `json:"cashAddr,omitempty"`
+	LegacyAddr    string          `json:"legacyAddr,omitempty"`
+}
+
+// CcRescanStatus compares the rescan window of the cc context with the infos collected for it
+// by the watcher, which are handled by the next handleUTXOs
+type CcRescanStatus struct {
+	LastRescannedHeight uint64 `json:"lastRescannedHeight"`
+	RescanHeight        uint64 `json:"rescanHeight"`
+	RescanTime          int64  `json:"rescanTime"`
+	UTXOAlreadyHandled  bool   `json:"utxoAlreadyHandled"`
+	// the window (collectedBegin, collectedEnd] collected by the watcher, it catches up with the
+	// rescan window after the collection finishes
+	CollectedBegin    int64        `json:"collectedBegin"`
+	CollectedEnd      int64        `json:"collectedEnd"`
+	CollectedInfos    int          `json:"collectedInfos"`
+	DuplicatesDropped int          `json:"duplicatesDropped"`
+	Collecting        bool         `json:"collecting"`
+	WatcherHeight     int64        `json:"watcherHeight"`
+	PendingRescan     *HeightRange `json:"pendingRescan"`
+	LastRescan        *HeightRange `json:"lastRescan"`
+	LastRescanTime    int64        `json:"lastRescanTime"`
+	CollectPaused     bool         `json:"collectPaused"`
+}
+
+// HeightRange is the BCH blocks in (begin, end]
+type HeightRange struct {
+	Begin int64 `json:"begin"`
+	End   int64 `json:"end"`
+}
+
+// CrossChainTimeArgs describes the peg-in or the peg-out whose time to complete is estimated
+type CrossChainTimeArgs struct {
+	Kind string `json:"kind"` // "pegIn" or "pegOut"
+	// the peg-in tx and its output paid to the covenant, or the UTXO redeemed by a peg-out, which is
+	// nil for a planned peg-out
+	Txid  *gethcmn.Hash  `json:"txid"`
+	Index hexutil.Uint64 `json:"index"`
+	// the BCH block including the peg-in tx, 0 if it is still in the mempool
+	Height hexutil.Uint64 `json:"height"`
+}
+
+// CrossChainTimeEstimate is the expected time for a peg-in to be minted on smartBCH, or for the
+// BCH of a peg-out to be paid on the main chain, along with the statistics it is based on
+type CrossChainTimeEstimate struct {
+	Stage          string         `json:"stage"`
+	Seconds        hexutil.Uint64 `json:"seconds"`
+	CompletionTime hexutil.Uint64 `json:"completionTime"`
+	// the cross chain is paused by the monitors, nothing completes until it is resumed
+	Paused               bool           `json:"paused"`
+	MainnetBlockInterval hexutil.Uint64 `json:"mainnetBlockInterval"`
+	BlockFinalizeNumber  hexutil.Uint64 `json:"blockFinalizeNumber"`
+	RescanInterval       hexutil.Uint64 `json:"rescanInterval"`
+	UTXOHandleDelay      hexutil.Uint64 `json:"utxoHandleDelay"`
+	SignLatency          hexutil.Uint64 `json:"signLatency"` // the median of the samples
+	SignLatencySamples   hexutil.Uint64 `json:"signLatencySamples"`
+}
+
+type MonitorNomination struct {
+	Pubkey         hexutil.Bytes `json:"pubkey"` // the compressed pubkey used on BCH
+	NominatedCount int64         `json:"nominatedCount"`
+}
+
+// MonitorVoteInfo counts the nominations of the monitors in the coinbase txs of the BCH blocks of
+// an epoch, sorted by the count and then by the pubkey
+type MonitorVoteInfo struct {
+	Number      hexutil.Uint64       `json:"number"` // zero if the epoch is not applied yet
+	StartHeight hexutil.Uint64       `json:"startHeight"`
+	EndHeight   hexutil.Uint64       `json:"endHeight"`
+	EndTime     int64                `json:"endTime"`
+	Nominations []*MonitorNomination `json:"nominations"`
+}
+
+type LatestMonitorVotes struct {
+	// built recently by the watcher, some of which may not be applied yet
+	Recent []*MonitorVoteInfo `json:"recent"`
+	// being built from the BCH blocks scanned so far
+	Current *MonitorVoteInfo `json:"current"`
+}
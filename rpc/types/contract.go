@@ -0,0 +1,32 @@
+package types
+
+import (
+	gethcmn "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+type Create2Contract struct {
+	Address      gethcmn.Address `json:"address"`
+	Deployer     gethcmn.Address `json:"deployer"`
+	InitCodeHash gethcmn.Hash    `json:"initCodeHash"`
+	TxHash       gethcmn.Hash    `json:"txHash"`
+	Height       hexutil.Uint64  `json:"height"`
+}
+
+// Approval is the latest SEP20 Approval event of an owner to a spender, with the current allowance
+type Approval struct {
+	Token              gethcmn.Address `json:"token"`
+	Spender            gethcmn.Address `json:"spender"`
+	Allowance          *hexutil.Big    `json:"allowance"` // nil if allowance() failed
+	LastApprovedAmount *hexutil.Big    `json:"lastApprovedAmount"`
+	LastApprovalBlock  hexutil.Uint64  `json:"lastApprovalBlock"`
+	LastApprovalTx     gethcmn.Hash    `json:"lastApprovalTx"`
+}
+
+// CodeBatch is the code of many addresses, each distinct code is returned once
+type CodeBatch struct {
+	// the code hash of each address, which is null if there is no code at it
+	CodeHashes map[gethcmn.Address]*gethcmn.Hash `json:"codeHashes"`
+	// the code of each hash referenced, except the ones known by the caller
+	Codes map[gethcmn.Hash]hexutil.Bytes `json:"codes"`
+}
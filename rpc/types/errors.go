@@ -0,0 +1,144 @@
+package types
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// The codes of the JSON-RPC errors, the ones of EIP-1474 are used where they apply. A code only
+// tells the class of an error, the exact kind is told by the reason in the data field.
+const (
+	ErrCodeExecutionReverted = 3 // the data field is the hex encoded revert data, as Ethereum clients expect
+	ErrCodeInvalidInput      = -32000
+	ErrCodeNotFound          = -32001
+	ErrCodeUnavailable       = -32002
+	ErrCodeTxRejected        = -32003
+	ErrCodeNotSupported      = -32004
+	ErrCodeLimitExceeded     = -32005
+	ErrCodeUnauthorized      = -32006
+	ErrCodeInvalidParams     = -32602
+)
+
+// The reasons in the data field of the errors. They are stable across releases, the messages are not.
+const (
+	// ErrCodeInvalidInput
+	ReasonExecutionFailed  = "execution_failed"
+	ReasonInvalidOpcode    = "invalid_opcode"
+	ReasonInvalidTx        = "invalid_tx"
+	ReasonInvalidTxArgs    = "invalid_tx_args"
+	ReasonUnknownAccount   = "unknown_account"
+	ReasonInvalidAdminOp   = "invalid_admin_op"
+	ReasonRpcKeyAlreadySet = "rpc_key_already_set"
+
+	// ErrCodeInvalidParams
+	ReasonInvalidParams     = "invalid_params"
+	ReasonInvalidBlockRange = "invalid_block_range"
+	ReasonFutureBlock       = "block_not_mined"
+	ReasonInvalidFilter     = "invalid_filter"
+
+	// ErrCodeNotFound
+	ReasonNotFound    = "not_found"
+	ReasonNotRecorded = "not_recorded" // only kept for the recent blocks since the node started
+
+	// ErrCodeUnavailable
+	ReasonCrossChainPaused = "cross_chain_paused"
+	ReasonRpcKeyNotSet     = "rpc_key_not_set"
+	ReasonTooEarly         = "too_early"
+	ReasonSafeMode         = "safe_mode"
+
+	// ErrCodeNotSupported
+	ReasonPendingBlock = "pending_block_not_supported"
+	ReasonNotIndexed   = "not_indexed"
+	ReasonDevModeOnly  = "dev_mode_only"
+	ReasonNotSupported = "not_supported"
+
+	// ErrCodeLimitExceeded
+	ReasonBlockRangeTooLong = "block_range_too_long"
+	ReasonTooManyResults    = "too_many_results"
+	ReasonResumeGapTooLarge = "resume_gap_too_large"
+
+	// ErrCodeUnauthorized
+	ReasonAdminSigsRequired = "admin_signatures_required"
+
+	// ErrCodeTxRejected, the CheckTx code of the app is also given for the ones rejected by CheckTx
+	ReasonTxRejected        = "tx_rejected"
+	ReasonAlreadyKnown      = "already_known"
+	ReasonMempoolFull       = "mempool_full"
+	ReasonMempoolBusy       = "mempool_busy"
+	ReasonTxTooLarge        = "tx_too_large"
+	ReasonInvalidSender     = "invalid_sender"
+	ReasonSenderNotFound    = "sender_not_found"
+	ReasonSenderFrozen      = "sender_frozen"
+	ReasonNonceMismatch     = "nonce_mismatch"
+	ReasonInsufficientFunds = "insufficient_funds"
+	ReasonGasPriceTooLow    = "gas_price_too_low"
+	ReasonInvalidGasLimit   = "invalid_gas_limit"
+	ReasonPendingTxExists   = "pending_tx_exists"
+	ReasonTxCanceled        = "tx_canceled"
+	ReasonTxVetoed          = "tx_vetoed"
+)
+
+// ErrorData is the data field of the errors, except the reverts
+type ErrorData struct {
+	Reason  string `json:"reason"`
+	Hint    string `json:"hint,omitempty"`
+	AppCode uint32 `json:"appCode,omitempty"` // the CheckTx code, if the tx is rejected by CheckTx
+}
+
+var _ rpc.DataError = (*Error)(nil)
+
+// Error is a JSON-RPC error with a code and a machine-readable data field
+type Error struct {
+	Code    int
+	Message string
+	Data    ErrorData
+}
+
+func NewError(code int, reason, msg string) *Error {
+	return &Error{Code: code, Message: msg, Data: ErrorData{Reason: reason}}
+}
+
+// WithHint returns a copy of e with a hint about how to fix the request
+func (e *Error) WithHint(hint string) *Error {
+	err := *e
+	err.Data.Hint = hint
+	return &err
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) ErrorCode() int {
+	return e.Code
+}
+
+func (e *Error) ErrorData() interface{} {
+	return e.Data
+}
+
+// Is makes errors.Is compare the errors by their reasons
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code && t.Data.Reason == e.Data.Reason
+}
+
+// ParseErrorData returns the data field of an error returned by a JSON-RPC client, it returns
+// false if the error has none, such as the reverts and the errors of the old nodes
+func ParseErrorData(err error) (*ErrorData, bool) {
+	var dataErr rpc.DataError
+	if !errors.As(err, &dataErr) {
+		return nil, false
+	}
+	bz, err := json.Marshal(dataErr.ErrorData())
+	if err != nil {
+		return nil, false
+	}
+	data := &ErrorData{}
+	if json.Unmarshal(bz, data) != nil || data.Reason == "" {
+		return nil, false
+	}
+	return data, true
+}
@@ -0,0 +1,36 @@
+package types
+
+import (
+	gethcmn "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+type TxFeeRecord struct {
+	Height      hexutil.Uint64  `json:"height"`
+	TxHash      gethcmn.Hash    `json:"txHash"`
+	From        gethcmn.Address `json:"from"`
+	GasUsed     hexutil.Uint64  `json:"gasUsed"`
+	GasPrice    *hexutil.Big    `json:"gasPrice"`
+	Fee         *hexutil.Big    `json:"fee"`
+	Refund      *hexutil.Big    `json:"refund"`
+	BurnedShare *hexutil.Big    `json:"burnedShare"`
+}
+
+// FeeDistribution is how the fee of a block is shared, it is made when the next block is committed
+type FeeDistribution struct {
+	Collected      *hexutil.Big    `json:"collected"`
+	Burned         *hexutil.Big    `json:"burned"`
+	ProposerShare  *hexutil.Big    `json:"proposerShare"`
+	CollectorShare *hexutil.Big    `json:"collectorShare"`
+	VotersShare    *hexutil.Big    `json:"votersShare"`
+	Proposer       gethcmn.Address `json:"proposer"`
+	Collector      gethcmn.Address `json:"collector"`
+}
+
+type BlockFeeRecord struct {
+	Height       hexutil.Uint64   `json:"height"`
+	Txs          []*TxFeeRecord   `json:"txs"`
+	TotalFee     *hexutil.Big     `json:"totalFee"`
+	TotalRefund  *hexutil.Big     `json:"totalRefund"`
+	Distribution *FeeDistribution `json:"distribution"`
+}
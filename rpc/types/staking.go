@@ -0,0 +1,103 @@
+package types
+
+import (
+	gethcmn "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+type FrozenAddress struct {
+	Address    gethcmn.Address `json:"address"`
+	Since      hexutil.Uint64  `json:"since"`
+	ProposalID hexutil.Uint64  `json:"proposalId"`
+}
+
+type NominationStatus struct {
+	Pubkey                gethcmn.Hash   `json:"pubkey"`
+	IsValidator           bool           `json:"isValidator"`
+	IsRetiring            bool           `json:"isRetiring"`
+	HasEnoughStake        bool           `json:"hasEnoughStake"`
+	NominatedCount        hexutil.Uint64 `json:"nominatedCount"`
+	Rank                  hexutil.Uint64 `json:"rank"`
+	ElectionThreshold     hexutil.Uint64 `json:"electionThreshold"`
+	NominationsNeeded     hexutil.Uint64 `json:"nominationsNeeded"`
+	TotalValidNominations hexutil.Uint64 `json:"totalValidNominations"`
+	MinTotalNominations   hexutil.Uint64 `json:"minTotalNominations"`
+	BlocksScanned         hexutil.Uint64 `json:"blocksScanned"`
+	BlocksLeft            hexutil.Uint64 `json:"blocksLeft"`
+}
+
+type StakingEpoch struct {
+	Number      hexutil.Uint64 `json:"number"`
+	StartHeight hexutil.Uint64 `json:"startHeight"`
+	EndTime     int64          `json:"endTime"`
+	Nominations []*Nomination  `json:"nominations"`
+	PosVotes    []*PosVote     `json:"posVotes"`
+}
+type Nomination struct {
+	Pubkey         gethcmn.Hash `json:"pubkey"`
+	NominatedCount int64        `json:"nominatedCount"`
+}
+type PosVote struct {
+	Pubkey       gethcmn.Hash `json:"pubkey"`
+	CoinDaysSlot *hexutil.Big `json:"coinDaysSlot"`
+	CoinDays     float64      `json:"coinDays"`
+}
+
+// EpochFilter selects the epochs overlapping the BCH heights [FromHeight, ToHeight], a zero
+// ToHeight means no upper bound
+type EpochFilter struct {
+	FromHeight hexutil.Uint64 `json:"fromHeight"`
+	ToHeight   hexutil.Uint64 `json:"toHeight"`
+	Offset     hexutil.Uint64 `json:"offset"`
+	Limit      hexutil.Uint64 `json:"limit"` // zero means the max one
+	// also return the epoch still being built by the watcher
+	IncludeCurrent bool `json:"includeCurrent"`
+}
+
+type EpochInfo struct {
+	Number      hexutil.Uint64 `json:"number"` // zero for the epoch being built
+	StartHeight hexutil.Uint64 `json:"startHeight"`
+	EndHeight   hexutil.Uint64 `json:"endHeight"`
+	EndTime     int64          `json:"endTime"`
+	Nominations []*Nomination  `json:"nominations"`
+	Current     bool           `json:"current"`
+}
+
+// ArchivedEpoch is an epoch delivered by the watcher, with the monitor vote info sent along with it
+type ArchivedEpoch struct {
+	Epoch       *EpochInfo       `json:"epoch"`
+	MonitorVote *MonitorVoteInfo `json:"monitorVote"` // null before the cross chain starts
+}
+
+type EpochPage struct {
+	Epochs []*EpochInfo   `json:"epochs"`
+	Total  hexutil.Uint64 `json:"total"` // the number of the epochs matched before the pagination
+}
+
+// ValidatorPower is an active validator and its voting power
+type ValidatorPower struct {
+	Address     gethcmn.Address `json:"address"`
+	Pubkey      gethcmn.Hash    `json:"pubkey"`
+	VotingPower int64           `json:"votingPower"`
+}
+
+// PowerDelta is the change of a validator's voting power, Reason is one of "epoch", "slash",
+// "retire" and "other"
+type PowerDelta struct {
+	Address  gethcmn.Address `json:"address"`
+	Pubkey   gethcmn.Hash    `json:"pubkey"`
+	OldPower int64           `json:"oldPower"`
+	NewPower int64           `json:"newPower"`
+	Delta    int64           `json:"delta"`
+	Reason   string          `json:"reason"`
+}
+
+// ValidatorSetChange is pushed to the "validatorSetChange" subscriptions at the blocks changing
+// the voting powers, and to the "newEpoch" ones along with the epoch at the blocks switching epochs
+type ValidatorSetChange struct {
+	Height          hexutil.Uint64    `json:"height"`
+	EffectiveHeight hexutil.Uint64    `json:"effectiveHeight"` // the first block signed by Validators
+	Epoch           *EpochInfo        `json:"epoch,omitempty"`
+	Validators      []*ValidatorPower `json:"validators"`
+	Deltas          []*PowerDelta     `json:"deltas"`
+}
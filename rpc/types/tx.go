@@ -0,0 +1,38 @@
+package types
+
+import (
+	gethcmn "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+type CanceledTx struct {
+	Hash            gethcmn.Hash  `json:"hash"`
+	ReplacementHash *gethcmn.Hash `json:"replacementHash,omitempty"`
+}
+
+// NonceStatus explains why the txs of an account may be stuck
+type NonceStatus struct {
+	Address gethcmn.Address `json:"address"`
+	// the nonce of the account in the latest state, the txs of the latest block are executed
+	// after it is committed, so they may not be counted yet
+	ConfirmedNonce hexutil.Uint64 `json:"confirmedNonce"`
+	// the nonce the next tx of the account should use, counting the contiguous pending txs
+	NextNonce           hexutil.Uint64   `json:"nextNonce"`
+	PendingCount        hexutil.Uint64   `json:"pendingCount"`
+	HighestPendingNonce *hexutil.Uint64  `json:"highestPendingNonce"`
+	PendingNonces       []hexutil.Uint64 `json:"pendingNonces"`
+	// the nonces between ConfirmedNonce and HighestPendingNonce which have no pending tx, the txs
+	// after the first gap cannot be executed until it is filled
+	Gaps []hexutil.Uint64 `json:"gaps"`
+	// the pending nonces smaller than ConfirmedNonce, these txs will fail
+	StaleNonces []hexutil.Uint64 `json:"staleNonces"`
+	// the pending nonces used by more than one tx, only one of them can be executed
+	DuplicatedNonces []hexutil.Uint64 `json:"duplicatedNonces"`
+	Diagnosis        string           `json:"diagnosis"`
+}
+
+// BalanceSample is the balance of an account after the block at Height is executed
+type BalanceSample struct {
+	Height  hexutil.Uint64 `json:"height"`
+	Balance *hexutil.Big   `json:"balance"`
+}
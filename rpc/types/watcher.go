@@ -0,0 +1,55 @@
+package types
+
+import (
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// WatcherStatus is the progress of the watcher following the BCH mainnet
+type WatcherStatus struct {
+	LatestFinalizedHeight     hexutil.Uint64 `json:"latestFinalizedHeight"`
+	LastEpochEndHeight        hexutil.Uint64 `json:"lastEpochEndHeight"`
+	LastKnownEpochNum         hexutil.Uint64 `json:"lastKnownEpochNum"`
+	DeliveredEpochNum         hexutil.Uint64 `json:"deliveredEpochNum"`
+	CurrMainnetBlockTimestamp hexutil.Uint64 `json:"currMainnetBlockTimestamp"`
+	PendingEpochs             hexutil.Uint64 `json:"pendingEpochs"`
+	EpochChanBacklog          hexutil.Uint64 `json:"epochChanBacklog"`
+	MonitorVoteChanBacklog    hexutil.Uint64 `json:"monitorVoteChanBacklog"`
+	SpilledEpochs             hexutil.Uint64 `json:"spilledEpochs"`
+	// the epochs read from the channel but not switched to by the app yet
+	AppEpochBacklog    hexutil.Uint64  `json:"appEpochBacklog"`
+	CcCollect          *CcRescanStatus `json:"ccCollect"`
+	AvailableEndpoints hexutil.Uint64  `json:"availableEndpoints"`
+	TotalEndpoints     hexutil.Uint64  `json:"totalEndpoints"`
+	ZmqConnected       bool            `json:"zmqConnected"`
+	// the BCH node is unreachable and the epochs gossiped by the validators are taken
+	EpochGossipFallback bool `json:"epochGossipFallback"`
+	Stopped             bool `json:"stopped"`
+}
+
+// WatcherAnalytics tells how late the watcher sees the new BCH blocks and how deep the reorgs it
+// met are, the latencies are in seconds and may be negative because the block timestamps are set
+// by the miners
+type WatcherAnalytics struct {
+	Since          hexutil.Uint64          `json:"since"`
+	ObservedBlocks hexutil.Uint64          `json:"observedBlocks"`
+	LatencyP50     int64                   `json:"latencyP50"`
+	LatencyP90     int64                   `json:"latencyP90"`
+	LatencyP99     int64                   `json:"latencyP99"`
+	LatencyMax     int64                   `json:"latencyMax"`
+	Forks          []*WatcherForkEvent     `json:"forks"`
+	MaxForkDepth   hexutil.Uint64          `json:"maxForkDepth"`
+	RecentBlocks   []*WatcherObservedBlock `json:"recentBlocks"`
+}
+
+type WatcherObservedBlock struct {
+	Height    hexutil.Uint64 `json:"height"`
+	Timestamp hexutil.Uint64 `json:"timestamp"`
+	SeenAt    hexutil.Uint64 `json:"seenAt"`
+	Latency   int64          `json:"latency"`
+}
+
+type WatcherForkEvent struct {
+	Time       hexutil.Uint64 `json:"time"`
+	ForkHeight hexutil.Uint64 `json:"forkHeight"`
+	Depth      hexutil.Uint64 `json:"depth"`
+}
@@ -0,0 +1,95 @@
+package staking
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/holiman/uint256"
+	mevmtypes "github.com/smartbch/moeingevm/types"
+
+	"github.com/smartbch/smartbch/param"
+	"github.com/smartbch/smartbch/staking/types"
+)
+
+// NominationStatus tells a validator candidate how far it is from being elected by the
+// nominations in current epoch. Only the PoW nominations are considered, PoS votes are not.
+type NominationStatus struct {
+	IsValidator       bool  // whether the pubkey belongs to a registered validator
+	IsRetiring        bool  // retiring validators cannot be elected
+	HasEnoughStake    bool  // validators without enough staked coins get no voting power
+	NominatedCount    int64 // the nominations got in current epoch
+	Rank              int   // 1-based rank among the valid nominations, 0 for not nominated
+	ElectionThreshold int64 // the nominated count of the last elected candidate, 0 if there are free seats
+	NominationsNeeded int64 // how many more nominations are needed to get elected
+	// the epoch is valid only when the total valid nominations reaches MinTotalNominations
+	TotalValidNominations int64
+	MinTotalNominations   int64
+	BlocksScanned         int64 // how many BCH blocks of current epoch are scanned by the watcher
+	BlocksLeft            int64 // how many BCH blocks are left in current epoch
+}
+
+// GetNominationStatus checks pubkey's nominations in 'epoch', which is still being built by the watcher
+func GetNominationStatus(ctx *mevmtypes.Context, epoch *types.Epoch, pubkey [32]byte,
+	blocksScanned, numBlocksInEpoch int64) *NominationStatus {
+
+	info := LoadStakingInfo(ctx)
+	status := &NominationStatus{
+		MinTotalNominations: numBlocksInEpoch * int64(param.StakingMinVotingPercentPerEpoch) / 100,
+		BlocksScanned:       blocksScanned,
+		BlocksLeft:          numBlocksInEpoch - blocksScanned,
+	}
+	if status.BlocksLeft < 0 {
+		status.BlocksLeft = 0
+	}
+	if val := info.GetValidatorByPubkey(pubkey); val != nil {
+		status.IsValidator = true
+		status.IsRetiring = val.IsRetiring
+		minimumStakingAmount := MinimumStakingAmount
+		if ctx.IsStakingFork() {
+			minimumStakingAmount = MinimumStakingAmountAfterStakingFork
+		}
+		status.HasEnoughStake = uint256.NewInt(0).SetBytes32(val.StakedCoins[:]).Cmp(minimumStakingAmount) >= 0
+	}
+
+	validatorSet := make(map[[32]byte]bool, len(info.Validators))
+	for _, val := range info.Validators {
+		if !val.IsRetiring {
+			validatorSet[val.Pubkey] = true
+		}
+	}
+	validNominations := make([]*types.Nomination, 0, len(epoch.Nominations))
+	for _, n := range epoch.Nominations {
+		if n.Pubkey == pubkey {
+			status.NominatedCount = n.NominatedCount
+		}
+		if validatorSet[n.Pubkey] { // votes to non-validators are ignored
+			validNominations = append(validNominations, n)
+			status.TotalValidNominations += n.NominatedCount
+		}
+	}
+	// the same order as the NominationHeap used in election
+	sort.Slice(validNominations, types.NominationHeap(validNominations).Less)
+	for i, n := range validNominations {
+		if n.Pubkey == pubkey {
+			status.Rank = i + 1
+			break
+		}
+	}
+
+	if len(validNominations) < param.MaxActiveValidatorCount {
+		if status.NominatedCount == 0 {
+			status.NominationsNeeded = 1
+		}
+		return status
+	}
+	last := validNominations[param.MaxActiveValidatorCount-1]
+	status.ElectionThreshold = last.NominatedCount
+	if status.Rank != 0 && status.Rank <= param.MaxActiveValidatorCount {
+		return status
+	}
+	status.NominationsNeeded = last.NominatedCount - status.NominatedCount
+	if bytes.Compare(pubkey[:], last.Pubkey[:]) > 0 { // loses the tie
+		status.NominationsNeeded++
+	}
+	return status
+}
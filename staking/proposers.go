@@ -0,0 +1,84 @@
+package staking
+
+import (
+	"crypto/sha256"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/tendermint/tendermint/crypto/ed25519"
+
+	mevmtypes "github.com/smartbch/moeingevm/types"
+
+	"github.com/smartbch/smartbch/staking/types"
+)
+
+var proposerSlotHashPrefix = [4]byte{'p', 'r', 'o', 'p'}
+
+// Proposer is a validator recorded by its consensus address, which is the miner of the blocks it
+// proposes. The validators are removed from the staking info after retiring, but their records are
+// kept, so the blocks they proposed can still be attributed.
+type Proposer struct {
+	ConsAddress [20]byte
+	Address     [20]byte // the validator's operator address
+	RewardTo    [20]byte
+	Pubkey      [32]byte
+}
+
+func NewProposer(v *types.Validator) Proposer {
+	p := Proposer{Address: v.Address, RewardTo: v.RewardTo, Pubkey: v.Pubkey}
+	copy(p.ConsAddress[:], ed25519.PubKey(v.Pubkey[:]).Address())
+	return p
+}
+
+func getSlotForProposer(consAddr [20]byte) string {
+	key := sha256.Sum256(append(proposerSlotHashPrefix[:], consAddr[:]...))
+	return string(key[:])
+}
+
+// SaveProposers records the validators, and updates the records of the ones whose reward addresses
+// were changed by editValidator. The unchanged records are not written again.
+func SaveProposers(ctx *mevmtypes.Context, validators []*types.Validator) {
+	consAddrs := append([]byte(nil), ctx.GetStorageAt(StakingContractSequence, SlotProposers)...)
+	listChanged := false
+	for _, v := range validators {
+		p := NewProposer(v)
+		bz := make([]byte, 0, 72)
+		bz = append(append(append(bz, p.Address[:]...), p.RewardTo[:]...), p.Pubkey[:]...)
+		slot := getSlotForProposer(p.ConsAddress)
+		old := ctx.GetStorageAt(StakingContractSequence, slot)
+		if string(old) == string(bz) {
+			continue
+		}
+		if len(old) == 0 {
+			consAddrs = append(consAddrs, p.ConsAddress[:]...)
+			listChanged = true
+		}
+		ctx.SetStorageAt(StakingContractSequence, slot, bz)
+	}
+	if listChanged {
+		ctx.SetStorageAt(StakingContractSequence, SlotProposers, consAddrs)
+	}
+}
+
+func LoadProposer(ctx *mevmtypes.Context, consAddr [20]byte) (p Proposer, ok bool) {
+	bz := ctx.GetStorageAt(StakingContractSequence, getSlotForProposer(consAddr))
+	if len(bz) != 72 {
+		return
+	}
+	p.ConsAddress = consAddr
+	copy(p.Address[:], bz[:20])
+	copy(p.RewardTo[:], bz[20:40])
+	copy(p.Pubkey[:], bz[40:])
+	return p, true
+}
+
+// LoadProposers returns all the recorded validators, in the order they were recorded
+func LoadProposers(ctx *mevmtypes.Context) []Proposer {
+	consAddrs := ctx.GetStorageAt(StakingContractSequence, SlotProposers)
+	result := make([]Proposer, 0, len(consAddrs)/20)
+	for i := 0; i+20 <= len(consAddrs); i += 20 {
+		if p, ok := LoadProposer(ctx, common.BytesToAddress(consAddrs[i:i+20])); ok {
+			result = append(result, p)
+		}
+	}
+	return result
+}
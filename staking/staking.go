@@ -84,6 +84,7 @@ var (
 	SlotMinGasPriceProposalTarget = strings.Repeat(string([]byte{0}), 31) + string([]byte{4})
 	SlotVoters                    = strings.Repeat(string([]byte{0}), 31) + string([]byte{5})
 	SlotOnlineInfo                = strings.Repeat(string([]byte{0}), 31) + string([]byte{6})
+	SlotProposers                 = strings.Repeat(string([]byte{0}), 31) + string([]byte{7})
 
 	// slot in hex
 	SlotMinGasPriceHex = hex.EncodeToString([]byte(SlotLastMinGasPrice))
@@ -946,7 +947,7 @@ func HandleOnlineInfos(ctx *mevmtypes.Context, stakingInfo *types.StakingInfo, v
 // slashValidators and lastVoters are consensus addresses generated from validator consensus pubkey
 func SlashAndReward(ctx *mevmtypes.Context, duplicateSigSlashValidators [][20]byte,
 	currProposer, lastProposer [20]byte, lastVoters [][]byte, /*include proposer*/
-	blockReward *uint256.Int) (currValidators, newValidators []*types.Validator, currEpochNum int64) {
+	blockReward *uint256.Int) (currValidators, newValidators []*types.Validator, currEpochNum int64, feeDist *FeeDistribution) {
 
 	stakingAcc, info := LoadStakingAccAndInfo(ctx)
 	currEpochNum = info.CurrEpochNum
@@ -988,7 +989,7 @@ func SlashAndReward(ctx *mevmtypes.Context, duplicateSigSlashValidators [][20]by
 			voters = append(voters, voter)
 		}
 	}
-	DistributeFee(ctx, stakingAcc, &info, blockReward, pubkeyMapByConsAddr[currProposer],
+	feeDist = DistributeFee(ctx, stakingAcc, &info, blockReward, pubkeyMapByConsAddr[currProposer],
 		pubkeyMapByConsAddr[lastProposer], voters)
 	newValidators = GetActiveValidators(ctx, info.Validators)
 	SaveStakingInfo(ctx, info)
@@ -1032,11 +1033,28 @@ func incrAllBurnt(ctx *mevmtypes.Context, amount *uint256.Int) {
 	ctx.SetStorageAt(StakingContractSequence, SlotAllBurnt, bz32[:])
 }
 
+// FeeDistribution records how the collected gas fee of a block is shared, it is only used for accounting
+type FeeDistribution struct {
+	Collected      *uint256.Int
+	Burned         *uint256.Int // half of the collected fee, plus the shares without a proposer or collector
+	ProposerShare  *uint256.Int
+	CollectorShare *uint256.Int
+	VotersShare    *uint256.Int // the shares of the voters except the proposer
+	Proposer       [20]byte     // the validator address, zero if there is no proposer
+	Collector      [20]byte
+}
+
 // distribute the collected gas fee to validators who voted for current block, half fee burnt to blackHole Acc.
 func DistributeFee(ctx *mevmtypes.Context, stakingAcc *mevmtypes.AccountInfo, info *types.StakingInfo,
-	collectedFee *uint256.Int, collector, proposer [32]byte /*operator pubKey*/, voters [][32]byte) {
+	collectedFee *uint256.Int, collector, proposer [32]byte /*operator pubKey*/, voters [][32]byte) *FeeDistribution {
 	if collectedFee == nil {
-		return
+		return nil
+	}
+	dist := &FeeDistribution{
+		Collected:      collectedFee.Clone(),
+		ProposerShare:  uint256.NewInt(0),
+		CollectorShare: uint256.NewInt(0),
+		VotersShare:    uint256.NewInt(0),
 	}
 
 	// the collected fee is saved as stakingAcc's balance, just the same way as the staked coins
@@ -1049,6 +1067,7 @@ func DistributeFee(ctx *mevmtypes.Context, stakingAcc *mevmtypes.AccountInfo, in
 	halfFeeToBurn := uint256.NewInt(0).Rsh(collectedFee, 1)
 	collectedFee.Sub(collectedFee, halfFeeToBurn)
 	_ = ebp.TransferFromSenderAccToBlackHoleAcc(ctx, StakingContractAddress, halfFeeToBurn)
+	dist.Burned = halfFeeToBurn.Clone()
 
 	totalVotingPower, votedPower := int64(0), int64(0)
 	for _, val := range GetActiveValidators(ctx, info.Validators) {
@@ -1085,6 +1104,7 @@ func DistributeFee(ctx *mevmtypes.Context, stakingAcc *mevmtypes.AccountInfo, in
 		rwdCoins := uint256.NewInt(0).Mul(collectedFee, uint256.NewInt(uint64(val.VotingPower)))
 		rwdCoins.Div(rwdCoins, uint256.NewInt(uint64(votedPower)))
 		remainedFee.Sub(remainedFee, rwdCoins)
+		dist.VotersShare.Add(dist.VotersShare, rwdCoins)
 		distributeToValidator(info, rwdMapByAddr, rwdCoins, val)
 	}
 
@@ -1092,16 +1112,22 @@ func DistributeFee(ctx *mevmtypes.Context, stakingAcc *mevmtypes.AccountInfo, in
 		//distribute to the proposer
 		proposerVal := valMapByPubkey[proposer]
 		coins := uint256.NewInt(0).Add(proposerBaseFee, remainedFee)
+		dist.ProposerShare, dist.Proposer = coins.Clone(), proposerVal.Address
 		distributeToValidator(info, rwdMapByAddr, coins, proposerVal)
 	} else if !remainedFee.IsZero() {
+		dist.Burned.Add(dist.Burned, remainedFee)
 		_ = ebp.TransferFromSenderAccToBlackHoleAcc(ctx, StakingContractAddress, remainedFee)
 	}
 
 	if collector != [32]byte{} {
-		distributeToValidator(info, rwdMapByAddr, collectorFee, valMapByPubkey[collector])
+		collectorVal := valMapByPubkey[collector]
+		dist.CollectorShare, dist.Collector = collectorFee.Clone(), collectorVal.Address
+		distributeToValidator(info, rwdMapByAddr, collectorFee, collectorVal)
 	} else if !collectorFee.IsZero() {
+		dist.Burned.Add(dist.Burned, collectorFee)
 		_ = ebp.TransferFromSenderAccToBlackHoleAcc(ctx, StakingContractAddress, collectorFee)
 	}
+	return dist
 }
 
 func distributeToValidator(info *types.StakingInfo, rwdMapByAddr map[[20]byte]*types.PendingReward,
@@ -1193,7 +1219,7 @@ func checkEpoch(ctx *mevmtypes.Context, info types.StakingInfo, epoch *types.Epo
 	powTotalNomination, pubkey2power := getPubkey2Power(info, epoch, posVotes, logger)
 	activeValidators := GetActiveValidators(ctx, info.Validators)
 	if !(param.IsAmber && ctx.IsXHedgeFork()) {
-		if powTotalNomination < param.StakingNumBlocksInEpoch*int64(param.StakingMinVotingPercentPerEpoch)/100 {
+		if powTotalNomination < param.CurrBchNetwork().NumBlocksInEpoch*int64(param.StakingMinVotingPercentPerEpoch)/100 {
 			logger.Debug("PoWTotalNomination not big enough", "PoWTotalNomination", powTotalNomination)
 			return false, pubkey2power, activeValidators
 		}
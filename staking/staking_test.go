@@ -276,7 +276,10 @@ func TestSwitchEpoch(t *testing.T) {
 	voters[0] = pubkey
 	voters[1] = info.Validators[1].Pubkey
 	stakingAcc, info := staking.LoadStakingAccAndInfo(ctx)
-	staking.DistributeFee(ctx, stakingAcc, &info, collectedFee, pubkey, pubkey, voters)
+	dist := staking.DistributeFee(ctx, stakingAcc, &info, collectedFee, pubkey, pubkey, voters)
+	require.Equal(t, uint64(10000), dist.Collected.Uint64())
+	require.Equal(t, uint64(5000), dist.Burned.Uint64())
+	require.Equal(t, uint64(10000-5000), dist.ProposerShare.Uint64()+dist.CollectorShare.Uint64()+dist.VotersShare.Uint64())
 
 	var voterReward *types2.PendingReward
 	var proposerReward *types2.PendingReward
@@ -350,7 +353,7 @@ func TestSlashAndReward(t *testing.T) {
 	copy(valAddress1[:], ed25519.PubKey(validator1[:]).Address().Bytes())
 	copy(valAddress2[:], ed25519.PubKey(validator2[:]).Address().Bytes())
 	staking.BuildAndSaveStakingInfo(ctx, [][32]byte{validator1, validator2})
-	currValidators, newValidators, _ := staking.SlashAndReward(ctx, nil, valAddress1, valAddress2, [][]byte{valAddress1[:], valAddress2[:]}, nil)
+	currValidators, newValidators, _, _ := staking.SlashAndReward(ctx, nil, valAddress1, valAddress2, [][]byte{valAddress1[:], valAddress2[:]}, nil)
 	require.Equal(t, 2, len(currValidators))
 	require.Equal(t, 2, len(newValidators))
 	onlineInfos := staking.LoadOnlineInfo(ctx)
@@ -359,7 +362,7 @@ func TestSlashAndReward(t *testing.T) {
 	require.Equal(t, valAddress1, onlineInfos.OnlineInfos[0].ValidatorConsensusAddress)
 
 	ctx.SetCurrentHeight(600)
-	currValidators, newValidators, _ = staking.SlashAndReward(ctx, nil, valAddress1, valAddress2, [][]byte{valAddress1[:], valAddress2[:]}, nil)
+	currValidators, newValidators, _, _ = staking.SlashAndReward(ctx, nil, valAddress1, valAddress2, [][]byte{valAddress1[:], valAddress2[:]}, nil)
 	require.Equal(t, 2, len(currValidators))
 	require.Equal(t, 0, len(newValidators))
 	onlineInfos = staking.LoadOnlineInfo(ctx)
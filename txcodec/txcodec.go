@@ -0,0 +1,118 @@
+// Package txcodec decodes and validates the raw transactions received by smartBCH.
+// The mempool (CheckTx), the JSON-RPC server and the tools share this package, such
+// that a transaction accepted by one of them is never rejected by another one.
+//
+// DeliverTx does not use this package: it must keep accepting whatever has been
+// committed into blocks, so its decoding rules cannot be changed.
+package txcodec
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	gethcmn "github.com/ethereum/go-ethereum/common"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// DefaultMaxTxSize is the same as go-ethereum's txpool limit
+const DefaultMaxTxSize = 128 * 1024
+
+var (
+	ErrEmptyTx          = errors.New("empty transaction")
+	ErrTxTooLarge       = errors.New("transaction is too large")
+	ErrTxTypeNotAllowed = errors.New("transaction type not allowed")
+	ErrUnprotectedTx    = errors.New("transaction is not replay-protected")
+	ErrChainIdMismatch  = errors.New("chain id mismatch")
+)
+
+type Config struct {
+	ChainID      *big.Int
+	MaxTxSize    int
+	AllowedTypes []uint8
+	// the signatures of unprotected (pre-EIP155) transactions cannot be stored in
+	// the compact VRS format of smartBCH, so they are rejected by default
+	AllowUnprotected bool
+}
+
+// DefaultConfig only allows legacy transactions, the only type the executor supports now
+func DefaultConfig(chainID *big.Int) Config {
+	return Config{
+		ChainID:      chainID,
+		MaxTxSize:    DefaultMaxTxSize,
+		AllowedTypes: []uint8{gethtypes.LegacyTxType},
+	}
+}
+
+type Decoder struct {
+	cfg          Config
+	allowedTypes map[uint8]bool
+	signer       gethtypes.Signer
+}
+
+func NewDecoder(cfg Config) *Decoder {
+	d := &Decoder{
+		cfg:          cfg,
+		allowedTypes: make(map[uint8]bool, len(cfg.AllowedTypes)),
+		signer:       gethtypes.LatestSignerForChainID(cfg.ChainID),
+	}
+	for _, t := range cfg.AllowedTypes {
+		d.allowedTypes[t] = true
+	}
+	return d
+}
+
+func (d *Decoder) Signer() gethtypes.Signer {
+	return d.signer
+}
+
+// Decode accepts both the canonical encoding (typed transactions are 'type || payload')
+// and the RLP encoding produced by Transaction.EncodeRLP (typed transactions are wrapped
+// in an RLP string). Trailing bytes and non-canonical RLP are not allowed in either case.
+func (d *Decoder) Decode(data []byte) (*gethtypes.Transaction, error) {
+	if len(data) == 0 {
+		return nil, ErrEmptyTx
+	}
+	if d.cfg.MaxTxSize > 0 && len(data) > d.cfg.MaxTxSize {
+		return nil, fmt.Errorf("%w: %d > %d", ErrTxTooLarge, len(data), d.cfg.MaxTxSize)
+	}
+	tx := &gethtypes.Transaction{}
+	var err error
+	if data[0] > 0x7f { // a legacy RLP list or an RLP-wrapped typed envelope
+		err = rlp.DecodeBytes(data, tx)
+	} else {
+		err = tx.UnmarshalBinary(data)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !d.allowedTypes[tx.Type()] {
+		return nil, fmt.Errorf("%w: %d", ErrTxTypeNotAllowed, tx.Type())
+	}
+	return tx, nil
+}
+
+// Verify checks the chain id and the signature of tx, and returns its sender
+func (d *Decoder) Verify(tx *gethtypes.Transaction) (gethcmn.Address, error) {
+	if !tx.Protected() {
+		if !d.cfg.AllowUnprotected {
+			return gethcmn.Address{}, ErrUnprotectedTx
+		}
+	} else if tx.ChainId().Cmp(d.cfg.ChainID) != 0 {
+		return gethcmn.Address{}, fmt.Errorf("%w: have %s, want %s", ErrChainIdMismatch, tx.ChainId(), d.cfg.ChainID)
+	}
+	return gethtypes.Sender(d.signer, tx)
+}
+
+func (d *Decoder) DecodeAndVerify(data []byte) (*gethtypes.Transaction, gethcmn.Address, error) {
+	tx, err := d.Decode(data)
+	if err != nil {
+		return nil, gethcmn.Address{}, err
+	}
+	sender, err := d.Verify(tx)
+	if err != nil {
+		return nil, gethcmn.Address{}, err
+	}
+	return tx, sender, nil
+}
@@ -0,0 +1,85 @@
+package txcodec_test
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/require"
+
+	"github.com/smartbch/smartbch/internal/ethutils"
+	"github.com/smartbch/smartbch/internal/testutils"
+	"github.com/smartbch/smartbch/txcodec"
+)
+
+func TestDecodeAndVerify(t *testing.T) {
+	key, addr := testutils.GenKeyAndAddr()
+	_, to := testutils.GenKeyAndAddr()
+	chainID := big.NewInt(10000)
+	d := txcodec.NewDecoder(txcodec.DefaultConfig(chainID))
+
+	tx := ethutils.NewTx(1, &to, big.NewInt(100), 100000, big.NewInt(1), nil)
+	tx = testutils.MustSignTx(tx, chainID, key)
+	data, err := ethutils.EncodeTx(tx)
+	require.NoError(t, err)
+
+	tx2, sender, err := d.DecodeAndVerify(data)
+	require.NoError(t, err)
+	require.Equal(t, tx.Hash(), tx2.Hash())
+	require.Equal(t, addr, sender)
+
+	_, err = d.Decode(nil)
+	require.Equal(t, txcodec.ErrEmptyTx, err)
+	_, err = d.Decode(append(data, 0x00))
+	require.Error(t, err)
+	_, err = d.Decode(make([]byte, txcodec.DefaultMaxTxSize+1))
+	require.True(t, errors.Is(err, txcodec.ErrTxTooLarge))
+
+	// wrong chain id
+	_, _, err = txcodec.NewDecoder(txcodec.DefaultConfig(big.NewInt(10001))).DecodeAndVerify(data)
+	require.True(t, errors.Is(err, txcodec.ErrChainIdMismatch))
+
+	// unprotected tx
+	tx3, err := gethtypes.SignTx(ethutils.NewTx(1, &to, big.NewInt(100), 100000, big.NewInt(1), nil),
+		gethtypes.HomesteadSigner{}, testutils.MustHexToPrivKey(key))
+	require.NoError(t, err)
+	data3, _ := ethutils.EncodeTx(tx3)
+	_, _, err = d.DecodeAndVerify(data3)
+	require.Equal(t, txcodec.ErrUnprotectedTx, err)
+}
+
+func TestDecodeTypedTx(t *testing.T) {
+	key, addr := testutils.GenKeyAndAddr()
+	_, to := testutils.GenKeyAndAddr()
+	chainID := big.NewInt(10000)
+	signer := gethtypes.NewLondonSigner(chainID)
+	tx, err := gethtypes.SignNewTx(testutils.MustHexToPrivKey(key), signer, &gethtypes.DynamicFeeTx{
+		ChainID:   chainID,
+		Nonce:     1,
+		GasTipCap: big.NewInt(1),
+		GasFeeCap: big.NewInt(1),
+		Gas:       100000,
+		To:        &to,
+		Value:     big.NewInt(100),
+	})
+	require.NoError(t, err)
+	binData, err := tx.MarshalBinary()
+	require.NoError(t, err)
+	rlpData, err := ethutils.EncodeTx(tx)
+	require.NoError(t, err)
+
+	// not allowed by default
+	_, err = txcodec.NewDecoder(txcodec.DefaultConfig(chainID)).Decode(binData)
+	require.True(t, errors.Is(err, txcodec.ErrTxTypeNotAllowed))
+
+	cfg := txcodec.DefaultConfig(chainID)
+	cfg.AllowedTypes = append(cfg.AllowedTypes, gethtypes.AccessListTxType, gethtypes.DynamicFeeTxType)
+	d := txcodec.NewDecoder(cfg)
+	for _, data := range [][]byte{binData, rlpData} {
+		tx2, sender, err := d.DecodeAndVerify(data)
+		require.NoError(t, err)
+		require.Equal(t, tx.Hash(), tx2.Hash())
+		require.Equal(t, addr, sender)
+	}
+}
@@ -0,0 +1,101 @@
+// Package txhook lets the modules compiled into smartbchd observe or veto the transactions, for
+// example to run compliance filters on private deployments. A module registers its hook in an
+// init function, and is linked into smartbchd by a blank import in cmd/smartbchd.
+//
+// Determinism: the hooks must never change what a block commits. CheckTx only decides which
+// transactions enter the mempool of this node, so a veto may depend on local data such as a
+// config file or an external service, but it is not enforced on the blocks proposed by the other
+// validators. DeliverTx and TxResults are observers, they are called after the consensus decisions
+// are made and cannot affect them. The hooks must not access the world state, and they run on the
+// critical path of the node, so the slow work should be done asynchronously.
+package txhook
+
+import (
+	"fmt"
+	"sync"
+
+	gethcmn "github.com/ethereum/go-ethereum/common"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+
+	motypes "github.com/smartbch/moeingevm/types"
+)
+
+type TxHook interface {
+	// Name identifies the hook in the logs and in the vetoed CheckTx responses
+	Name() string
+	// CheckTx is called for the new and rechecked transactions whose senders are verified, a
+	// non-nil error vetoes the transaction from the mempool of this node
+	CheckTx(tx *gethtypes.Transaction, sender gethcmn.Address) error
+	// DeliverTx is called for each transaction of the block at height, before it is executed,
+	// its sender is not verified yet
+	DeliverTx(height int64, tx *gethtypes.Transaction)
+	// TxResults is called with the executed transactions of the block at height, after the
+	// block is committed
+	TxResults(height int64, txs []*motypes.Transaction)
+}
+
+// BaseTxHook can be embedded by the hooks which only implement some of the methods
+type BaseTxHook struct{}
+
+func (BaseTxHook) CheckTx(*gethtypes.Transaction, gethcmn.Address) error { return nil }
+func (BaseTxHook) DeliverTx(int64, *gethtypes.Transaction)               {}
+func (BaseTxHook) TxResults(int64, []*motypes.Transaction)               {}
+
+var (
+	mtx   sync.Mutex
+	hooks []TxHook
+)
+
+// Register adds a hook, it panics if a hook with the same name is registered
+func Register(hook TxHook) {
+	mtx.Lock()
+	defer mtx.Unlock()
+	for _, h := range hooks {
+		if h.Name() == hook.Name() {
+			panic("duplicated tx hook: " + hook.Name())
+		}
+	}
+	hooks = append(hooks, hook)
+}
+
+// Hooks returns the registered hooks in the order of registration
+func Hooks() []TxHook {
+	mtx.Lock()
+	defer mtx.Unlock()
+	return append([]TxHook(nil), hooks...)
+}
+
+// Check calls CheckTx of the hooks until one of them vetoes the transaction, a panicking hook
+// vetoes it too
+func Check(hooks []TxHook, tx *gethtypes.Transaction, sender gethcmn.Address) (vetoedBy string, err error) {
+	for _, h := range hooks {
+		if err = safeCheck(h, tx, sender); err != nil {
+			return h.Name(), err
+		}
+	}
+	return "", nil
+}
+
+func safeCheck(h TxHook, tx *gethtypes.Transaction, sender gethcmn.Address) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return h.CheckTx(tx, sender)
+}
+
+// Observe calls fn with each hook, the panics are recovered and passed to onPanic, such that a
+// faulty observer cannot halt the node
+func Observe(hooks []TxHook, fn func(h TxHook), onPanic func(h TxHook, r interface{})) {
+	for _, h := range hooks {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					onPanic(h, r)
+				}
+			}()
+			fn(h)
+		}()
+	}
+}
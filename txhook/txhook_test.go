@@ -0,0 +1,68 @@
+package txhook
+
+import (
+	"errors"
+	"testing"
+
+	gethcmn "github.com/ethereum/go-ethereum/common"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/require"
+)
+
+type denyHook struct {
+	BaseTxHook
+	name    string
+	denied  gethcmn.Address
+	panicky bool
+	seen    []int64
+}
+
+func (h *denyHook) Name() string { return h.name }
+
+func (h *denyHook) CheckTx(tx *gethtypes.Transaction, sender gethcmn.Address) error {
+	if h.panicky {
+		panic("oops")
+	}
+	if sender == h.denied {
+		return errors.New("denied")
+	}
+	return nil
+}
+
+func (h *denyHook) DeliverTx(height int64, tx *gethtypes.Transaction) {
+	if h.panicky {
+		panic("oops")
+	}
+	h.seen = append(h.seen, height)
+}
+
+func TestCheckAndObserve(t *testing.T) {
+	h1 := &denyHook{name: "h1", denied: gethcmn.Address{1}}
+	h2 := &denyHook{name: "h2", panicky: true}
+	tx := gethtypes.NewTx(&gethtypes.LegacyTx{})
+
+	name, err := Check([]TxHook{h1}, tx, gethcmn.Address{2})
+	require.NoError(t, err)
+	require.Equal(t, "", name)
+	name, err = Check([]TxHook{h1, h2}, tx, gethcmn.Address{1})
+	require.EqualError(t, err, "denied")
+	require.Equal(t, "h1", name)
+	name, err = Check([]TxHook{h1, h2}, tx, gethcmn.Address{2})
+	require.EqualError(t, err, "panic: oops")
+	require.Equal(t, "h2", name)
+
+	var panicked []string
+	Observe([]TxHook{h2, h1}, func(h TxHook) { h.DeliverTx(7, tx) }, func(h TxHook, r interface{}) {
+		panicked = append(panicked, h.Name())
+	})
+	require.Equal(t, []string{"h2"}, panicked)
+	require.Equal(t, []int64{7}, h1.seen)
+}
+
+func TestRegister(t *testing.T) {
+	defer func() { hooks = nil }()
+	Register(&denyHook{name: "a"})
+	Register(&denyHook{name: "b"})
+	require.Len(t, Hooks(), 2)
+	require.Panics(t, func() { Register(&denyHook{name: "a"}) })
+}
@@ -0,0 +1,279 @@
+package watcher
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/tendermint/tendermint/libs/log"
+
+	"github.com/smartbch/smartbch/param"
+)
+
+const (
+	secondsPerDay = 24 * 3600
+	// the tips seen but not finalized yet are forgotten when they are this deep
+	maxSeenTipDepth = 2 * param.MaxBlockFinalizeNumber
+	// the max number of the observations in an analytics report
+	MaxReportedObservations = 1000
+)
+
+// BlockObservation is when the watcher first saw a BCH block as the tip of the main chain
+type BlockObservation struct {
+	Height    int64 `json:"height"`
+	Timestamp int64 `json:"timestamp"` // set by the miner, so the latency may even be negative
+	SeenAt    int64 `json:"seenAt"`
+}
+
+func (o BlockObservation) Latency() int64 {
+	return o.SeenAt - o.Timestamp
+}
+
+// ForkEvent is a reorg which discards the finalized blocks above ForkHeight
+type ForkEvent struct {
+	Time       int64 `json:"time"`
+	ForkHeight int64 `json:"forkHeight"`
+	Depth      int64 `json:"depth"`
+}
+
+// AnalyticsReport summarizes the observations kept, to choose a safe block-finalize-number
+type AnalyticsReport struct {
+	Since          int64 // the time of the oldest observation or fork kept
+	ObservedBlocks int
+	// the percentiles of the latencies in seconds
+	LatencyP50, LatencyP90, LatencyP99, LatencyMax int64
+	Forks                                          []ForkEvent
+	MaxForkDepth                                   int64
+	RecentBlocks                                   []BlockObservation // the latest ones
+}
+
+type analyticsRecord struct {
+	Block *BlockObservation `json:"block,omitempty"`
+	Fork  *ForkEvent        `json:"fork,omitempty"`
+}
+
+// analyticsStore keeps the observations within the retention in memory, a nil one records nothing.
+// If path is set, they are also appended to it as JSON lines, which are loaded at startup and
+// compacted on the first write.
+type analyticsStore struct {
+	mtx       sync.Mutex
+	logger    log.Logger
+	path      string
+	file      *os.File
+	retention int64 // in seconds
+	blocks    []BlockObservation
+	forks     []ForkEvent
+	lastTip   int64
+	seenTips  map[int64]int64 // height -> seenAt
+}
+
+func newAnalyticsStore(path string, retainDays uint64, now int64, logger log.Logger) *analyticsStore {
+	s := &analyticsStore{
+		logger:    logger,
+		path:      path,
+		retention: int64(retainDays) * secondsPerDay,
+		seenTips:  make(map[int64]int64),
+	}
+	if path == "" {
+		return s
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logger.Error("cannot load the watcher analytics", "err", err)
+		}
+		return s
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var r analyticsRecord
+		if json.Unmarshal(scanner.Bytes(), &r) != nil {
+			continue // the last line may be written partially
+		}
+		s.add(r)
+	}
+	s.prune(now)
+	return s
+}
+
+func (s *analyticsStore) add(r analyticsRecord) {
+	if r.Block != nil {
+		s.blocks = append(s.blocks, *r.Block)
+	}
+	if r.Fork != nil {
+		s.forks = append(s.forks, *r.Fork)
+	}
+}
+
+func (s *analyticsStore) prune(now int64) {
+	if s.retention == 0 {
+		return
+	}
+	i := 0
+	for i < len(s.blocks) && s.blocks[i].SeenAt < now-s.retention {
+		i++
+	}
+	s.blocks = s.blocks[i:]
+	i = 0
+	for i < len(s.forks) && s.forks[i].Time < now-s.retention {
+		i++
+	}
+	s.forks = s.forks[i:]
+}
+
+// seeTip is called with the latest height of the BCH node, the first tip seen after startup is
+// skipped because it may have been mined long before, and so are the blocks skipped over
+func (s *analyticsStore) seeTip(height, now int64) {
+	if s == nil {
+		return
+	}
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if height <= s.lastTip {
+		return
+	}
+	if s.lastTip != 0 {
+		s.seenTips[height] = now
+	}
+	s.lastTip = height
+	for h := range s.seenTips {
+		if h < height-maxSeenTipDepth {
+			delete(s.seenTips, h)
+		}
+	}
+}
+
+// finalize records the observation of a finalized block, if it was seen as a tip
+func (s *analyticsStore) finalize(height, timestamp, now int64) {
+	if s == nil {
+		return
+	}
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	seenAt, ok := s.seenTips[height]
+	if !ok {
+		return
+	}
+	delete(s.seenTips, height)
+	s.write(analyticsRecord{Block: &BlockObservation{Height: height, Timestamp: timestamp, SeenAt: seenAt}}, now)
+}
+
+func (s *analyticsStore) addFork(forkHeight, depth, now int64) {
+	if s == nil {
+		return
+	}
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.write(analyticsRecord{Fork: &ForkEvent{Time: now, ForkHeight: forkHeight, Depth: depth}}, now)
+}
+
+// write adds the record, s.mtx must be held by the caller
+func (s *analyticsStore) write(r analyticsRecord, now int64) {
+	s.prune(now)
+	if s.path != "" && s.file == nil && !s.compact() {
+		s.path = "" // only kept in memory from now on
+	}
+	s.add(r)
+	if s.path == "" {
+		return
+	}
+	bz, _ := json.Marshal(r)
+	if _, err := s.file.Write(append(bz, '\n')); err != nil {
+		s.logger.Error("cannot write the watcher analytics", "err", err)
+	}
+}
+
+// compact rewrites the file with the records kept, and opens it for appending
+func (s *analyticsStore) compact() bool {
+	tmp := s.path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err == nil {
+		w := bufio.NewWriter(f)
+		for i := range s.blocks {
+			bz, _ := json.Marshal(analyticsRecord{Block: &s.blocks[i]})
+			_, _ = w.Write(append(bz, '\n'))
+		}
+		for i := range s.forks {
+			bz, _ := json.Marshal(analyticsRecord{Fork: &s.forks[i]})
+			_, _ = w.Write(append(bz, '\n'))
+		}
+		err = w.Flush()
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err == nil {
+		err = os.Rename(tmp, s.path)
+	}
+	if err == nil {
+		s.file, err = os.OpenFile(s.path, os.O_WRONLY|os.O_APPEND, 0600)
+	}
+	if err != nil {
+		s.logger.Error("cannot write the watcher analytics, keep them in memory", "err", err)
+		return false
+	}
+	return true
+}
+
+func (s *analyticsStore) close() {
+	if s == nil {
+		return
+	}
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if s.file != nil {
+		_ = s.file.Close()
+		s.file = nil
+	}
+}
+
+func (s *analyticsStore) report(limit int) AnalyticsReport {
+	if s == nil {
+		return AnalyticsReport{}
+	}
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	r := AnalyticsReport{
+		ObservedBlocks: len(s.blocks),
+		Forks:          append([]ForkEvent{}, s.forks...),
+	}
+	if len(s.blocks) != 0 {
+		r.Since = s.blocks[0].SeenAt
+	}
+	if len(s.forks) != 0 && (r.Since == 0 || s.forks[0].Time < r.Since) {
+		r.Since = s.forks[0].Time
+	}
+	for _, f := range s.forks {
+		if f.Depth > r.MaxForkDepth {
+			r.MaxForkDepth = f.Depth
+		}
+	}
+	latencies := make([]int64, len(s.blocks))
+	for i, o := range s.blocks {
+		latencies[i] = o.Latency()
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	if n := len(latencies); n != 0 {
+		r.LatencyP50 = latencies[n*50/100]
+		r.LatencyP90 = latencies[n*90/100]
+		r.LatencyP99 = latencies[n*99/100]
+		r.LatencyMax = latencies[n-1]
+	}
+	if limit > MaxReportedObservations {
+		limit = MaxReportedObservations
+	}
+	if limit > len(s.blocks) {
+		limit = len(s.blocks)
+	}
+	r.RecentBlocks = append([]BlockObservation{}, s.blocks[len(s.blocks)-limit:]...)
+	return r
+}
+
+// GetAnalytics returns the latencies of the BCH blocks seen and the reorgs since the retention,
+// with at most limit latest observations
+func (watcher *Watcher) GetAnalytics(limit int) AnalyticsReport {
+	return watcher.analytics.report(limit)
+}
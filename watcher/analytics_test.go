@@ -0,0 +1,51 @@
+package watcher
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/libs/log"
+)
+
+func TestAnalyticsStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "analytics.jsonl")
+	s := newAnalyticsStore(path, 1, 1000, log.NewNopLogger())
+	// the first tip is skipped, and so are the heights jumped over
+	s.seeTip(100, 1000)
+	s.seeTip(102, 1010)
+	s.seeTip(103, 1020)
+	s.finalize(100, 990, 1100)
+	s.finalize(101, 995, 1100)
+	s.finalize(102, 1000, 1100)
+	s.finalize(103, 1025, 1100) // the timestamp set by the miner is ahead
+	s.addFork(101, 2, 1200)
+	r := s.report(1)
+	require.Equal(t, 2, r.ObservedBlocks)
+	require.EqualValues(t, 1010, r.Since)
+	require.EqualValues(t, 10, r.LatencyP50)
+	require.EqualValues(t, 10, r.LatencyMax)
+	require.Equal(t, []BlockObservation{{Height: 103, Timestamp: 1025, SeenAt: 1020}}, r.RecentBlocks)
+	require.EqualValues(t, -5, r.RecentBlocks[0].Latency())
+	require.Equal(t, []ForkEvent{{Time: 1200, ForkHeight: 101, Depth: 2}}, r.Forks)
+	require.EqualValues(t, 2, r.MaxForkDepth)
+	s.close()
+
+	// loaded from the file, without the records older than a day
+	s = newAnalyticsStore(path, 1, 1015+secondsPerDay, log.NewNopLogger())
+	r = s.report(10)
+	require.Equal(t, 1, r.ObservedBlocks)
+	require.EqualValues(t, 103, r.RecentBlocks[0].Height)
+	require.Len(t, r.Forks, 1)
+	s.seeTip(104, 1020+secondsPerDay)
+	s.addFork(103, 1, 1020+secondsPerDay)
+	s.close()
+	s = newAnalyticsStore(path, 1, 1020+secondsPerDay, log.NewNopLogger())
+	r = s.report(10)
+	require.Equal(t, 1, r.ObservedBlocks)
+	require.Len(t, r.Forks, 2)
+
+	var disabled *analyticsStore
+	disabled.seeTip(1, 1)
+	require.Zero(t, disabled.report(10).ObservedBlocks)
+}
@@ -0,0 +1,107 @@
+package watcher
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+const ReqStrBlockchainInfo = `{"jsonrpc": "1.0", "id":"smartbch", "method": "getblockchaininfo", "params": [] }`
+
+// the prune height of the BCH node is queried again after this duration
+const pruneHeightRefreshInterval = 10 * time.Minute
+
+// the blocks this close to the prune height are also read from the archive node, because the
+// BCH node may prune them before the request arrives
+const pruneHeightMargin = 6
+
+type blockchainInfo struct {
+	Blocks      int64 `json:"blocks"`
+	Pruned      bool  `json:"pruned"`
+	PruneHeight int64 `json:"pruneheight"`
+}
+
+// archiveRouter sends the requests for the blocks pruned by the BCH nodes to an archive node
+type archiveRouter struct {
+	archive *RpcClient
+
+	mtx         sync.Mutex
+	pruneHeight int64 // the lowest height whose block is kept by the BCH nodes, 0 if not pruned
+	checkedAt   time.Time
+}
+
+// SetArchive lets the blocks pruned by the BCH nodes be read from archive, which is a BCH node
+// keeping all the blocks, such as a remote public node used only when catching up
+func (client *RpcClient) SetArchive(archive *RpcClient) {
+	if client == nil || archive == nil {
+		return
+	}
+	client.archive = &archiveRouter{archive: archive}
+}
+
+// archiveFor returns the archive client if the block at height is pruned by the BCH nodes,
+// otherwise it returns nil
+func (client *RpcClient) archiveFor(height int64) *RpcClient {
+	r := client.archive
+	if r == nil {
+		return nil
+	}
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	if now := time.Now(); now.Sub(r.checkedAt) > pruneHeightRefreshInterval {
+		r.checkedAt = now
+		r.pruneHeight = client.queryPruneHeight()
+	}
+	if r.pruneHeight > 0 && height < r.pruneHeight+pruneHeightMargin {
+		return r.archive
+	}
+	return nil
+}
+
+// queryPruneHeight returns the max prune height of the BCH nodes, or 0 if none prunes
+func (client *RpcClient) queryPruneHeight() int64 {
+	var maxPruneHeight int64
+	for _, e := range client.endpoints.endpoints {
+		var info blockchainInfo
+		if err := client.callEndpoint(e, ReqStrBlockchainInfo, &info); err != nil {
+			client.logger.Info("failed to get the prune height of the BCH node", "url", redactUrl(e.url), "err", err.Error())
+			continue
+		}
+		if info.Pruned && info.PruneHeight > maxPruneHeight {
+			maxPruneHeight = info.PruneHeight
+		}
+	}
+	return maxPruneHeight
+}
+
+func (client *RpcClient) callEndpoint(e *endpoint, reqStr string, result interface{}) error {
+	respData, err := client.sendRequestTo(e, reqStr)
+	if err != nil {
+		return err
+	}
+	var m smartBchJsonrpcMessage
+	if err = json.Unmarshal(respData, &m); err != nil {
+		return err
+	}
+	if m.Error != nil {
+		return fmt.Errorf("rpc error, code:%d, msg:%s", m.Error.Code, m.Error.Message)
+	}
+	return json.Unmarshal(m.Result, result)
+}
+
+// onPrunedBlock makes the following requests for the blocks at or below height go to the
+// archive, when a BCH node reports the block is pruned before the prune height is refreshed
+func (client *RpcClient) onPrunedBlock(height int64, err error) *RpcClient {
+	r := client.archive
+	if r == nil || err == nil || !strings.Contains(err.Error(), "pruned") {
+		return nil
+	}
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	if height >= r.pruneHeight {
+		r.pruneHeight = height + 1
+	}
+	return r.archive
+}
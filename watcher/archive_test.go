@@ -0,0 +1,62 @@
+package watcher
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/libs/log"
+)
+
+// fakeBchNode responds getblockchaininfo, getblockhash and getblock, the blocks below pruneHeight
+// are reported as pruned
+func fakeBchNode(pruneHeight int64, requested map[string]int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Method string        `json:"method"`
+			Params []interface{} `json:"params"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		requested[req.Method]++
+		switch req.Method {
+		case "getblockchaininfo":
+			fmt.Fprintf(w, `{"result":{"blocks":200,"pruned":%v,"pruneheight":%d},"error":null}`, pruneHeight > 0, pruneHeight)
+		case "getblockhash":
+			fmt.Fprintf(w, `{"result":"%064x","error":null}`, int64(req.Params[0].(float64)))
+		case "getblock":
+			var height int64
+			_, _ = fmt.Sscanf(req.Params[0].(string), "%x", &height)
+			if height < pruneHeight {
+				fmt.Fprint(w, `{"result":null,"error":{"code":-1,"message":"Block not available (pruned data)"}}`)
+				return
+			}
+			fmt.Fprintf(w, `{"result":{"hash":"%s","height":%d},"error":null}`, req.Params[0], height)
+		}
+	}))
+}
+
+func TestArchiveClient(t *testing.T) {
+	prunedRequests, archiveRequests := map[string]int{}, map[string]int{}
+	prunedNode := fakeBchNode(100, prunedRequests)
+	defer prunedNode.Close()
+	archiveNode := fakeBchNode(0, archiveRequests)
+	defer archiveNode.Close()
+
+	client := NewRpcClient(prunedNode.URL, "", "", "text/plain;", log.NewNopLogger())
+	client.SetArchive(NewRpcClient(archiveNode.URL, "", "", "text/plain;", log.NewNopLogger()))
+	require.EqualValues(t, 50, client.GetBlockInfoByHeight(50, false).Height)
+	require.Equal(t, 1, archiveRequests["getblock"])
+	require.Zero(t, prunedRequests["getblock"])
+	require.EqualValues(t, 150, client.GetBlockInfoByHeight(150, false).Height)
+	require.Equal(t, 1, prunedRequests["getblock"])
+	require.Equal(t, 1, prunedRequests["getblockchaininfo"])
+
+	// the blocks pruned after the prune height is queried are also read from the archive
+	client.archive.pruneHeight = 80
+	require.EqualValues(t, 90, client.GetBlockInfoByHeight(90, false).Height)
+	require.Equal(t, 2, archiveRequests["getblock"])
+	require.EqualValues(t, 91, client.archive.pruneHeight)
+}
@@ -0,0 +1,180 @@
+package watcher
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/smartbch/smartbch/crosschain"
+	cctypes "github.com/smartbch/smartbch/crosschain/types"
+	"github.com/smartbch/smartbch/watcher/types"
+)
+
+var (
+	ErrNoPendingCcWindow     = errors.New("the cc infos of the rescan window have been handled")
+	ErrCcRescanOutOfWindow   = errors.New("the re-scan range must be inside the rescan window which is not handled yet")
+	ErrCcRescanAlreadyQueued = errors.New("another re-scan is waiting to be taken")
+)
+
+// ccCollectState is shared by the collecting goroutine and the RPC goroutines
+type ccCollectState struct {
+	mtx    sync.Mutex
+	status types.CcCollectStatus
+	// the signing latencies of the recent redeems and their UTXOs, see recordSignLatencies
+	signLatencies  []int64
+	sampleOrder    [][36]byte
+	sampledRedeems map[[36]byte]bool
+}
+
+// ForceCcRescan makes the watcher parse the BCH blocks in (begin, end] again, after a bug of the
+// parser is fixed. Only the window not handled yet can be re-scanned: the infos of the handled
+// windows have been applied to the state, emitting them again would mint the deposits twice.
+// The infos of the window are rebuilt in the order of the blocks with each UTXO appearing once,
+// exactly as a node collecting the window for the first time would do, so the nodes re-scanning
+// and the ones not re-scanning still agree if their parsers agree.
+func (watcher *Watcher) ForceCcRescan(begin, end int64) error {
+	collectParam := watcher.getUTXOCollectParam()
+	if collectParam == nil {
+		return ErrNoPendingCcWindow
+	}
+	if err := checkCcRescanRange(begin, end, collectParam, watcher.ccUTXOAlreadyHandled()); err != nil {
+		return err
+	}
+	watcher.ccState.mtx.Lock()
+	defer watcher.ccState.mtx.Unlock()
+	if watcher.ccState.status.PendingRescan != nil {
+		return ErrCcRescanAlreadyQueued
+	}
+	watcher.ccState.status.PendingRescan = &types.HeightRange{Begin: begin, End: end}
+	watcher.logger.Info("cc re-scan queued", "begin", begin, "end", end)
+	return nil
+}
+
+func checkCcRescanRange(begin, end int64, collectParam *cctypes.UTXOCollectParam, handled bool) error {
+	if handled {
+		return ErrNoPendingCcWindow
+	}
+	if begin >= end || begin < collectParam.BeginHeight || end > collectParam.EndHeight {
+		return fmt.Errorf("%w: (%d, %d]", ErrCcRescanOutOfWindow, collectParam.BeginHeight, collectParam.EndHeight)
+	}
+	return nil
+}
+
+func (watcher *Watcher) ccUTXOAlreadyHandled() bool {
+	ctx := watcher.contextGetter.GetRpcContext()
+	defer ctx.Close(false)
+	ccContext := crosschain.LoadCCContext(ctx)
+	return ccContext == nil || ccContext.UTXOAlreadyHandled
+}
+
+// takeCcRescan returns the queued re-scan if it is still inside the window being collected
+func (watcher *Watcher) takeCcRescan(collectParam *cctypes.UTXOCollectParam) *types.HeightRange {
+	watcher.ccState.mtx.Lock()
+	defer watcher.ccState.mtx.Unlock()
+	r := watcher.ccState.status.PendingRescan
+	if r == nil {
+		return nil
+	}
+	watcher.ccState.status.PendingRescan = nil
+	if err := checkCcRescanRange(r.Begin, r.End, collectParam, false); err != nil {
+		watcher.logger.Error("cc re-scan dropped", "begin", r.Begin, "end", r.End, "err", err)
+		return nil
+	}
+	return r
+}
+
+// SetCcCollectPaused pauses or resumes collecting the cc-transfer infos, it returns false if the
+// collection is paused or resumed already. The round in progress is finished before the pause
+// takes effect. Note that handleUTXOs waits for the rescan window to be collected, so a long pause
+// stalls the blocks after it.
+func (watcher *Watcher) SetCcCollectPaused(paused bool) bool {
+	watcher.ccState.mtx.Lock()
+	defer watcher.ccState.mtx.Unlock()
+	if watcher.ccState.status.Paused == paused {
+		return false
+	}
+	watcher.ccState.status.Paused = paused
+	watcher.logger.Info("cc collection paused", "paused", paused)
+	return true
+}
+
+func (watcher *Watcher) ccCollectPaused() bool {
+	watcher.ccState.mtx.Lock()
+	defer watcher.ccState.mtx.Unlock()
+	return watcher.ccState.status.Paused
+}
+
+func (watcher *Watcher) GetCcCollectStatus() types.CcCollectStatus {
+	watcher.ccState.mtx.Lock()
+	defer watcher.ccState.mtx.Unlock()
+	return watcher.ccState.status
+}
+
+// the number of the BCH blocks fetched at once by a collect round, whose infos are appended to the
+// queue before the next blocks are fetched
+const ccCollectBatchSize = 100
+
+// ccCollectRoundEnd returns the end of the blocks collected in a round from resumeFrom on, which
+// parses at most maxBlocks blocks of the window ending at windowEnd
+func ccCollectRoundEnd(resumeFrom, windowEnd int64, maxBlocks uint64) int64 {
+	if maxBlocks != 0 && windowEnd-resumeFrom > int64(maxBlocks) {
+		return resumeFrom + int64(maxBlocks)
+	}
+	return windowEnd
+}
+
+// collectCcInfos parses the BCH blocks in (begin, end] and appends their infos to the queue, it
+// returns false if the watcher is stopped before all the blocks are fetched
+func (watcher *Watcher) collectCcInfos(queue *crosschain.CcInfoQueue, begin, end int64) (duplicates int, ok bool) {
+	for from := begin; from < end; from += ccCollectBatchSize {
+		to := from + ccCollectBatchSize
+		if to > end {
+			to = end
+		}
+		blocks := watcher.getFinalizedBCHBlockInfos(from, to)
+		if watcher.stopped() {
+			return duplicates, false
+		}
+		for i, bi := range blocks {
+			infos := watcher.txParser.GetCCUTXOTransferInfo(bi)
+			watcher.recordSignLatencies(bi, infos)
+			dup, err := queue.Append(from+1+int64(i), infos)
+			if err != nil {
+				watcher.logger.Error("failed to journal cc infos", "err", err)
+			}
+			duplicates += dup
+		}
+	}
+	return duplicates, true
+}
+
+// rescanCcInfos parses the BCH blocks in (begin, end] again and replaces their infos in the queue
+func (watcher *Watcher) rescanCcInfos(queue *crosschain.CcInfoQueue, begin, end int64) (duplicates int, ok bool) {
+	blocks := watcher.getFinalizedBCHBlockInfos(begin, end)
+	if watcher.stopped() {
+		return 0, false
+	}
+	byHeight := make(map[int64][]*cctypes.CCTransferInfo, len(blocks))
+	for i, bi := range blocks {
+		infos := watcher.txParser.GetCCUTXOTransferInfo(bi)
+		byHeight[begin+1+int64(i)] = infos
+		watcher.recordSignLatencies(bi, infos)
+	}
+	duplicates, err := queue.Replace(begin, end, byHeight)
+	if err != nil {
+		watcher.logger.Error("failed to journal cc infos", "err", err)
+	}
+	return duplicates, true
+}
+
+func (watcher *Watcher) setCcCollectStatus(begin, end int64, infos, duplicates int, rescan *types.HeightRange) {
+	watcher.ccState.mtx.Lock()
+	defer watcher.ccState.mtx.Unlock()
+	s := &watcher.ccState.status
+	s.Begin, s.End, s.Infos, s.Duplicates = begin, end, infos, duplicates
+	if rescan != nil {
+		s.LastRescan = rescan
+		s.LastRescanTime = time.Now().Unix()
+	}
+}
@@ -0,0 +1,50 @@
+package watcher
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/libs/log"
+
+	cctypes "github.com/smartbch/smartbch/crosschain/types"
+	"github.com/smartbch/smartbch/watcher/types"
+)
+
+func TestCheckCcRescanRange(t *testing.T) {
+	window := &cctypes.UTXOCollectParam{BeginHeight: 100, EndHeight: 110}
+	require.NoError(t, checkCcRescanRange(100, 110, window, false))
+	require.NoError(t, checkCcRescanRange(103, 105, window, false))
+	require.Equal(t, ErrNoPendingCcWindow, checkCcRescanRange(103, 105, window, true))
+	require.True(t, errors.Is(checkCcRescanRange(99, 105, window, false), ErrCcRescanOutOfWindow))
+	require.True(t, errors.Is(checkCcRescanRange(105, 111, window, false), ErrCcRescanOutOfWindow))
+	require.True(t, errors.Is(checkCcRescanRange(105, 105, window, false), ErrCcRescanOutOfWindow))
+}
+
+func TestTakeCcRescan(t *testing.T) {
+	w := &Watcher{logger: log.NewNopLogger()}
+	w.ccState.status.PendingRescan = &types.HeightRange{Begin: 100, End: 105}
+	require.Nil(t, w.takeCcRescan(&cctypes.UTXOCollectParam{BeginHeight: 110, EndHeight: 120}))
+	require.Nil(t, w.GetCcCollectStatus().PendingRescan)
+
+	w.ccState.status.PendingRescan = &types.HeightRange{Begin: 100, End: 105}
+	r := w.takeCcRescan(&cctypes.UTXOCollectParam{BeginHeight: 100, EndHeight: 120})
+	require.Equal(t, &types.HeightRange{Begin: 100, End: 105}, r)
+}
+
+func TestSetCcCollectPaused(t *testing.T) {
+	w := &Watcher{logger: log.NewNopLogger()}
+	require.False(t, w.SetCcCollectPaused(false))
+	require.True(t, w.SetCcCollectPaused(true))
+	require.False(t, w.SetCcCollectPaused(true))
+	require.True(t, w.GetCcCollectStatus().Paused)
+	require.True(t, w.SetCcCollectPaused(false))
+	require.False(t, w.ccCollectPaused())
+}
+
+func TestCcCollectRoundEnd(t *testing.T) {
+	require.EqualValues(t, 120, ccCollectRoundEnd(100, 120, 0))
+	require.EqualValues(t, 110, ccCollectRoundEnd(100, 120, 10))
+	require.EqualValues(t, 120, ccCollectRoundEnd(110, 120, 10))
+	require.EqualValues(t, 120, ccCollectRoundEnd(115, 120, 10))
+}
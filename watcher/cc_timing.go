@@ -0,0 +1,140 @@
+package watcher
+
+import (
+	"encoding/binary"
+	"sort"
+
+	"github.com/smartbch/smartbch/crosschain"
+	cctypes "github.com/smartbch/smartbch/crosschain/types"
+	"github.com/smartbch/smartbch/watcher/types"
+)
+
+const (
+	// the number of the recent finalized blocks used to average the block interval, about one day
+	blockIntervalWindow = 144
+	// used before enough blocks are finalized
+	defaultMainnetBlockInterval = 600
+	// the number of the recent redeems whose signing latencies are kept
+	maxSignLatencySamples = 100
+)
+
+// CcTiming is what the watcher observes about the pace of the cross-chain transfers, it is used
+// to estimate how long a peg-in or a peg-out takes
+type CcTiming struct {
+	LatestFinalizedHeight int64
+	BlockFinalizeNumber   int64
+	MainnetBlockInterval  int64 // in seconds, averaged over the recent finalized blocks
+	// the seconds from the expected sign time of the recent redeems to the timestamp of the BCH
+	// block including the tx signed by the operators, sorted in ascending order
+	SignLatencies []int64
+}
+
+// MedianSignLatency returns the median of SignLatencies, or one block interval, which is the time
+// to mine a tx signed at once, if no redeem is seen yet
+func (timing CcTiming) MedianSignLatency() int64 {
+	if len(timing.SignLatencies) == 0 {
+		return timing.MainnetBlockInterval
+	}
+	return timing.SignLatencies[len(timing.SignLatencies)/2]
+}
+
+func (watcher *Watcher) GetCcTiming() CcTiming {
+	watcher.state.mtx.RLock()
+	timing := CcTiming{
+		LatestFinalizedHeight: watcher.state.latestFinalizedHeight,
+		BlockFinalizeNumber:   watcher.blockFinalizeNumber,
+		MainnetBlockInterval:  averageBlockInterval(watcher.state.heightToFinalizedBlock, watcher.state.latestFinalizedHeight),
+	}
+	watcher.state.mtx.RUnlock()
+	watcher.ccState.mtx.Lock()
+	timing.SignLatencies = append([]int64{}, watcher.ccState.signLatencies...)
+	watcher.ccState.mtx.Unlock()
+	sort.Slice(timing.SignLatencies, func(i, j int) bool { return timing.SignLatencies[i] < timing.SignLatencies[j] })
+	return timing
+}
+
+// averageBlockInterval averages the intervals of the consecutive finalized blocks ending at latest
+func averageBlockInterval(blocks map[int64]*types.BCHBlock, latest int64) int64 {
+	last, ok := blocks[latest]
+	if !ok {
+		return defaultMainnetBlockInterval
+	}
+	first := last
+	for h := latest - 1; h >= latest-blockIntervalWindow; h-- {
+		blk, ok := blocks[h]
+		if !ok {
+			break
+		}
+		first = blk
+	}
+	// BCH's timestamps are not always increasing, which only matters for very few blocks
+	if first.Height == last.Height || last.Timestamp <= first.Timestamp {
+		return defaultMainnetBlockInterval
+	}
+	return (last.Timestamp - first.Timestamp) / (last.Height - first.Height)
+}
+
+// recordSignLatencies samples the signing latencies of the redeem txs in a collected block, the
+// UTXO records are not deleted until the infos are handled, so their expected sign times can be
+// read from the latest state
+func (watcher *Watcher) recordSignLatencies(bi *types.BlockInfo, infos []*cctypes.CCTransferInfo) {
+	if watcher.contextGetter == nil || !hasRedeemInfo(infos) {
+		return
+	}
+	ctx := watcher.contextGetter.GetRpcContext()
+	defer ctx.Close(false)
+	var keys [][36]byte
+	var latencies []int64
+	for _, info := range infos {
+		if info.Type != cctypes.RedeemOrLostAndFoundType {
+			continue
+		}
+		r := crosschain.LoadUTXORecord(ctx, info.PrevUTXO.TxID, info.PrevUTXO.Index)
+		if r == nil || !r.IsRedeemed || r.ExpectedSignTime == 0 {
+			continue
+		}
+		latency := bi.Time - r.ExpectedSignTime
+		if latency < 0 {
+			latency = 0
+		}
+		var key [36]byte
+		copy(key[:32], info.PrevUTXO.TxID[:])
+		binary.BigEndian.PutUint32(key[32:], info.PrevUTXO.Index)
+		keys = append(keys, key)
+		latencies = append(latencies, latency)
+	}
+	watcher.addSignLatencies(keys, latencies)
+}
+
+func hasRedeemInfo(infos []*cctypes.CCTransferInfo) bool {
+	for _, info := range infos {
+		if info.Type == cctypes.RedeemOrLostAndFoundType {
+			return true
+		}
+	}
+	return false
+}
+
+// addSignLatencies keeps the latest maxSignLatencySamples samples, the redeems sampled before are
+// skipped, because the same window may be collected again by a re-scan
+func (watcher *Watcher) addSignLatencies(keys [][36]byte, latencies []int64) {
+	watcher.ccState.mtx.Lock()
+	defer watcher.ccState.mtx.Unlock()
+	s := &watcher.ccState
+	if s.sampledRedeems == nil {
+		s.sampledRedeems = make(map[[36]byte]bool)
+	}
+	for i, key := range keys {
+		if s.sampledRedeems[key] {
+			continue
+		}
+		s.sampledRedeems[key] = true
+		s.sampleOrder = append(s.sampleOrder, key)
+		s.signLatencies = append(s.signLatencies, latencies[i])
+		if len(s.signLatencies) > maxSignLatencySamples {
+			delete(s.sampledRedeems, s.sampleOrder[0])
+			s.sampleOrder = s.sampleOrder[1:]
+			s.signLatencies = s.signLatencies[1:]
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package watcher
+
+import (
+	"net/url"
+	"sync"
+	"time"
+)
+
+const (
+	// a failed endpoint is not used in this duration, unless all the endpoints have failed
+	endpointBackoff    = 30 * time.Second
+	maxEndpointBackoff = 10 * time.Minute
+	// an endpoint whose height is more than maxEndpointLag blocks behind the best one is lagging,
+	// so its blocks are not read until it catches up
+	maxEndpointLag = 2
+	// the interval of the health checks, which query the heights of all the endpoints
+	HealthCheckInterval = time.Minute
+)
+
+type endpoint struct {
+	url       string
+	failures  int // the consecutive failures
+	downUntil time.Time
+	height    int64
+	lagging   bool
+	limiter   *rateLimiter // nil if the requests are not limited
+}
+
+func (e *endpoint) available(now time.Time) bool {
+	return !e.lagging && !now.Before(e.downUntil)
+}
+
+// endpointPool balances the requests among the BCH nodes in round robin, and skips the ones which
+// have failed recently or are lagging behind. It is safe for concurrent use.
+type endpointPool struct {
+	mtx       sync.Mutex
+	endpoints []*endpoint
+	next      int
+}
+
+func newEndpointPool(urls []string) *endpointPool {
+	p := &endpointPool{}
+	for _, u := range urls {
+		p.endpoints = append(p.endpoints, &endpoint{url: u})
+	}
+	return p
+}
+
+// candidates returns the endpoints in the order to be tried: the available ones starting from the
+// next one in round robin, then the unavailable ones as the last resort, so a request is never
+// dropped only because all the endpoints are marked down
+func (p *endpointPool) candidates(now time.Time) []*endpoint {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	n := len(p.endpoints)
+	result := make([]*endpoint, 0, n)
+	var unavailable []*endpoint
+	for i := 0; i < n; i++ {
+		e := p.endpoints[(p.next+i)%n]
+		if e.available(now) {
+			result = append(result, e)
+		} else {
+			unavailable = append(unavailable, e)
+		}
+	}
+	p.next = (p.next + 1) % n
+	return append(result, unavailable...)
+}
+
+func (p *endpointPool) reportSuccess(e *endpoint) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	e.failures = 0
+	e.downUntil = time.Time{}
+}
+
+// reportFailure backs off the endpoint exponentially with its consecutive failures
+func (p *endpointPool) reportFailure(e *endpoint, now time.Time) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	backoff := endpointBackoff << e.failures
+	if backoff > maxEndpointBackoff || backoff <= 0 {
+		backoff = maxEndpointBackoff
+	}
+	e.failures++
+	e.downUntil = now.Add(backoff)
+}
+
+// reportRateLimited keeps the endpoint from being used until it allows the requests again
+func (p *endpointPool) reportRateLimited(e *endpoint, now time.Time, retryAfter time.Duration) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	e.failures++
+	if until := now.Add(retryAfter); until.After(e.downUntil) {
+		e.downUntil = until
+	}
+}
+
+// setRateLimit limits the requests sent to each endpoint, zero means unlimited
+func (p *endpointPool) setRateLimit(perSecond float64) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	for _, e := range p.endpoints {
+		e.limiter = newRateLimiter(perSecond)
+	}
+}
+
+// updateHeights records the heights got by a health check, -1 means the endpoint failed
+func (p *endpointPool) updateHeights(heights []int64) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	var best int64 = -1
+	for _, h := range heights {
+		if h > best {
+			best = h
+		}
+	}
+	for i, e := range p.endpoints {
+		e.height = heights[i]
+		e.lagging = heights[i] >= 0 && heights[i]+maxEndpointLag < best
+	}
+}
+
+// EndpointStatus is the health of a BCH RPC endpoint
+type EndpointStatus struct {
+	Url       string `json:"url"`
+	Height    int64  `json:"height"`
+	Failures  int    `json:"failures"`
+	Lagging   bool   `json:"lagging"`
+	Available bool   `json:"available"`
+}
+
+func (p *endpointPool) status(now time.Time) []EndpointStatus {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	result := make([]EndpointStatus, len(p.endpoints))
+	for i, e := range p.endpoints {
+		result[i] = EndpointStatus{
+			Url:       redactUrl(e.url),
+			Height:    e.height,
+			Failures:  e.failures,
+			Lagging:   e.lagging,
+			Available: e.available(now),
+		}
+	}
+	return result
+}
+
+// redactUrl hides the password in rawUrl
+func redactUrl(rawUrl string) string {
+	u, err := url.Parse(rawUrl)
+	if err != nil {
+		return ""
+	}
+	return u.Redacted()
+}
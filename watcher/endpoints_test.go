@@ -0,0 +1,77 @@
+package watcher
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/libs/log"
+)
+
+func TestEndpointPool(t *testing.T) {
+	p := newEndpointPool([]string{"a", "b", "c"})
+	now := time.Now()
+	urls := func(endpoints []*endpoint) (result []string) {
+		for _, e := range endpoints {
+			result = append(result, e.url)
+		}
+		return
+	}
+	require.Equal(t, []string{"a", "b", "c"}, urls(p.candidates(now)))
+	require.Equal(t, []string{"b", "c", "a"}, urls(p.candidates(now)))
+
+	// the failed one is tried last until its backoff ends
+	p.reportFailure(p.endpoints[2], now)
+	require.Equal(t, []string{"a", "b", "c"}, urls(p.candidates(now)))
+	require.Equal(t, []string{"a", "b", "c"}, urls(p.candidates(now.Add(endpointBackoff-time.Second))))
+	require.Equal(t, []string{"b", "c", "a"}, urls(p.candidates(now.Add(endpointBackoff))))
+	p.reportFailure(p.endpoints[2], now)
+	require.Equal(t, now.Add(2*endpointBackoff), p.endpoints[2].downUntil)
+	p.reportSuccess(p.endpoints[2])
+
+	// b is lagging, c failed to respond the health check
+	p.updateHeights([]int64{100, 97, -1})
+	p.next = 0
+	require.Equal(t, []string{"a", "c", "b"}, urls(p.candidates(now)))
+	status := p.status(now)
+	require.True(t, status[0].Available)
+	require.True(t, status[1].Lagging)
+	require.EqualValues(t, -1, status[2].Height)
+	p.updateHeights([]int64{100, 98, 100})
+	require.False(t, p.endpoints[1].lagging)
+}
+
+func TestRpcClientFailover(t *testing.T) {
+	var goodCalls int32
+	good := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&goodCalls, 1)
+		user, password, _ := r.BasicAuth()
+		require.Equal(t, "u2", user)
+		require.Equal(t, "p2", password)
+		_, _ = fmt.Fprint(w, `{"result":123,"error":null,"id":"smartbch"}`)
+	}))
+	defer good.Close()
+	bad := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer bad.Close()
+
+	goodUrl := "http://u2:p2@" + good.Listener.Addr().String()
+	client := NewRpcClientWithUrls([]string{bad.URL, goodUrl}, "u1", "p1", "text/plain;", log.NewNopLogger())
+	require.EqualValues(t, 123, client.GetLatestHeight(false))
+	require.EqualValues(t, 1, client.endpoints.endpoints[0].failures)
+	// the bad one is skipped in its backoff
+	require.EqualValues(t, 123, client.GetLatestHeight(false))
+	require.EqualValues(t, 1, client.endpoints.endpoints[0].failures)
+	require.EqualValues(t, 2, atomic.LoadInt32(&goodCalls))
+
+	client.checkHealth()
+	status := client.EndpointStatus()
+	require.False(t, status[0].Available)
+	require.EqualValues(t, 123, status[1].Height)
+	require.Equal(t, "http://u2:xxxxx@"+good.Listener.Addr().String(), status[1].Url)
+}
@@ -0,0 +1,190 @@
+package watcher
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/tendermint/tendermint/libs/log"
+
+	cctypes "github.com/smartbch/smartbch/crosschain/types"
+	stakingtypes "github.com/smartbch/smartbch/staking/types"
+	"github.com/smartbch/smartbch/watcher/types"
+)
+
+// ArchivedEpoch is an epoch delivered to the app, with the monitor vote info sent along with it
+type ArchivedEpoch struct {
+	Number      int64                    `json:"number"`
+	Epoch       stakingtypes.Epoch       `json:"epoch"`
+	MonitorVote *cctypes.MonitorVoteInfo `json:"monitorVote,omitempty"`
+}
+
+// epochArchive keeps all the epochs delivered by the watcher, while voteInfoList only keeps the
+// recent ones. They are appended to a file as JSON lines and loaded when the watcher starts. Only
+// the latest keep epochs are kept if keep is not zero. A nil archive keeps nothing.
+type epochArchive struct {
+	mtx    sync.RWMutex
+	logger log.Logger
+	path   string
+	keep   int
+	file   *os.File
+	epochs []*ArchivedEpoch // in the order of the numbers, which are consecutive
+}
+
+func newEpochArchive(path string, keep uint64, logger log.Logger) *epochArchive {
+	if path == "" {
+		return nil
+	}
+	a := &epochArchive{logger: logger, path: path, keep: int(keep)}
+	f, err := os.Open(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logger.Error("cannot load the epoch archive", "err", err)
+		}
+		return a
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 16*1024*1024)
+	for scanner.Scan() {
+		e := &ArchivedEpoch{}
+		if json.Unmarshal(scanner.Bytes(), e) != nil {
+			continue // the last line may be written partially
+		}
+		a.insert(e)
+	}
+	return a
+}
+
+// insert appends e, the epochs with the same or larger numbers are replaced, which were delivered
+// but not applied by the app before the node restarted
+func (a *epochArchive) insert(e *ArchivedEpoch) (replaced bool) {
+	if n := len(a.epochs); n != 0 && e.Number != a.epochs[n-1].Number+1 {
+		i := sort.Search(n, func(i int) bool { return a.epochs[i].Number >= e.Number })
+		a.epochs = a.epochs[:i]
+		replaced = true
+	}
+	a.epochs = append(a.epochs, e)
+	if a.keep != 0 && len(a.epochs) > a.keep {
+		a.epochs = append([]*ArchivedEpoch{}, a.epochs[len(a.epochs)-a.keep:]...)
+		replaced = true
+	}
+	return
+}
+
+// monitorVoteOf returns the monitor vote info in the vote info, or nil before the cross chain starts
+func monitorVoteOf(in *types.VoteInfo) *cctypes.MonitorVoteInfo {
+	if in.MonitorVote.StartHeight == 0 {
+		return nil
+	}
+	return &in.MonitorVote
+}
+
+// add archives the delivered epoch with its number
+func (a *epochArchive) add(number int64, epoch *stakingtypes.Epoch, info *cctypes.MonitorVoteInfo) {
+	if a == nil {
+		return
+	}
+	e := &ArchivedEpoch{Number: number, Epoch: *stakingtypes.CopyEpoch(*epoch)}
+	e.Epoch.Number = number
+	if info != nil {
+		in := *info
+		e.MonitorVote = &in
+	}
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+	// the file is rewritten when it is opened the first time, or when the epochs are replaced
+	if a.insert(e) || a.file == nil {
+		a.rewrite()
+		return
+	}
+	bz, _ := json.Marshal(e)
+	if _, err := a.file.Write(append(bz, '\n')); err != nil {
+		a.logger.Error("cannot write the epoch archive", "err", err)
+	}
+}
+
+// rewrite writes all the epochs kept to the file, and opens it for appending
+func (a *epochArchive) rewrite() {
+	if a.file != nil {
+		_ = a.file.Close()
+		a.file = nil
+	}
+	tmp := a.path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err == nil {
+		w := bufio.NewWriter(f)
+		for _, e := range a.epochs {
+			bz, _ := json.Marshal(e)
+			_, _ = w.Write(append(bz, '\n'))
+		}
+		err = w.Flush()
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err == nil {
+		err = os.Rename(tmp, a.path)
+	}
+	if err == nil {
+		a.file, err = os.OpenFile(a.path, os.O_WRONLY|os.O_APPEND, 0600)
+	}
+	if err != nil {
+		a.logger.Error("cannot write the epoch archive", "err", err)
+	}
+}
+
+func (a *epochArchive) close() {
+	if a == nil {
+		return
+	}
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+	if a.file != nil {
+		_ = a.file.Close()
+		a.file = nil
+	}
+}
+
+// byNumber returns the archived epochs numbered from start to end, inclusively
+func (a *epochArchive) byNumber(start, end int64) []*ArchivedEpoch {
+	a.mtx.RLock()
+	defer a.mtx.RUnlock()
+	i := sort.Search(len(a.epochs), func(i int) bool { return a.epochs[i].Number >= start })
+	j := sort.Search(len(a.epochs), func(i int) bool { return a.epochs[i].Number > end })
+	if i >= j {
+		return nil
+	}
+	return append([]*ArchivedEpoch{}, a.epochs[i:j]...)
+}
+
+// byHeight returns the archived epoch containing the BCH block at height, or nil
+func (a *epochArchive) byHeight(height, numBlocksInEpoch int64) *ArchivedEpoch {
+	a.mtx.RLock()
+	defer a.mtx.RUnlock()
+	i := sort.Search(len(a.epochs), func(i int) bool { return a.epochs[i].Epoch.StartHeight > height })
+	if i == 0 || height >= a.epochs[i-1].Epoch.StartHeight+numBlocksInEpoch {
+		return nil
+	}
+	return a.epochs[i-1]
+}
+
+// GetArchivedEpochs returns the epochs numbered from start to end which are kept by the epoch
+// archive, and false if watcher-epoch-archive-path is not set
+func (watcher *Watcher) GetArchivedEpochs(start, end int64) ([]*ArchivedEpoch, bool) {
+	if watcher.epochArchive == nil {
+		return nil, false
+	}
+	return watcher.epochArchive.byNumber(start, end), true
+}
+
+// GetArchivedEpochByHeight returns the archived epoch containing the BCH block at height, and false
+// if watcher-epoch-archive-path is not set
+func (watcher *Watcher) GetArchivedEpochByHeight(height int64) (*ArchivedEpoch, bool) {
+	if watcher.epochArchive == nil {
+		return nil, false
+	}
+	return watcher.epochArchive.byHeight(height, watcher.numBlocksInEpoch), true
+}
@@ -0,0 +1,50 @@
+package watcher
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/libs/log"
+
+	cctypes "github.com/smartbch/smartbch/crosschain/types"
+	stakingtypes "github.com/smartbch/smartbch/staking/types"
+)
+
+func TestEpochArchive(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "epochs.archive")
+	a := newEpochArchive(path, 3, log.NewNopLogger())
+	for n := int64(1); n <= 4; n++ {
+		var info *cctypes.MonitorVoteInfo
+		if n == 4 {
+			info = &cctypes.MonitorVoteInfo{StartHeight: 31}
+		}
+		a.add(n, &stakingtypes.Epoch{StartHeight: n*10 - 9, EndTime: n * 100}, info)
+	}
+	// only the latest 3 epochs are kept
+	epochs := a.byNumber(1, 10)
+	require.Len(t, epochs, 3)
+	require.EqualValues(t, 2, epochs[0].Number)
+	require.EqualValues(t, 2, epochs[0].Epoch.Number)
+	require.Nil(t, epochs[0].MonitorVote)
+	require.EqualValues(t, 31, epochs[2].MonitorVote.StartHeight)
+	require.EqualValues(t, 3, a.byHeight(25, 10).Number)
+	require.Nil(t, a.byHeight(5, 10))
+	require.Nil(t, a.byHeight(41, 10))
+	a.close()
+
+	// the epochs delivered again after restarting replace the archived ones
+	a = newEpochArchive(path, 3, log.NewNopLogger())
+	require.Len(t, a.byNumber(1, 10), 3)
+	a.add(3, &stakingtypes.Epoch{StartHeight: 21, EndTime: 301}, nil)
+	a.close()
+	a = newEpochArchive(path, 3, log.NewNopLogger())
+	epochs = a.byNumber(1, 10)
+	require.Len(t, epochs, 2)
+	require.EqualValues(t, 301, epochs[1].Epoch.EndTime)
+	require.Empty(t, a.byNumber(4, 10))
+
+	var disabled *epochArchive
+	disabled.add(1, &stakingtypes.Epoch{}, nil)
+	disabled.close()
+}
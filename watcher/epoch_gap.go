@@ -0,0 +1,67 @@
+package watcher
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/smartbch/smartbch/staking"
+)
+
+var epochGapCheckInterval = 10 * time.Minute
+
+// EpochGap compares the epochs delivered through EpochChan with the ones applied by the staking
+// module. The app switches to a delivered epoch after the EpochSwitchDelay of the BCH network, so a
+// small gap is normal, but a growing one means the epochs are stuck in the channel.
+type EpochGap struct {
+	Delivered int64 // the number of the latest epoch delivered through EpochChan
+	Applied   int64 // the number of the latest epoch applied by the staking module
+	Queued    int64 // the number of epochs in EpochChan or spilled, which are not read by the app yet
+}
+
+func (gap EpochGap) Size() int64 {
+	return gap.Delivered - gap.Applied
+}
+
+// GetEpochGap returns the current gap, the applied epoch is read from the latest state
+func (watcher *Watcher) GetEpochGap() EpochGap {
+	ctx := watcher.contextGetter.GetRpcContext()
+	defer ctx.Close(false)
+	return EpochGap{
+		Delivered: atomic.LoadInt64(&watcher.deliveredEpochNum),
+		Applied:   staking.LoadStakingInfo(ctx).CurrEpochNum,
+		Queued:    int64(len(watcher.EpochChan) + watcher.epochQueue.size()),
+	}
+}
+
+// epochGapChecker raises an alert when the gap is larger than the threshold and no epoch was
+// applied since the last check, the latter excludes the nodes catching up with the chain
+type epochGapChecker struct {
+	threshold   int64
+	lastApplied int64
+	alerting    bool
+}
+
+// check returns whether the gap should be alerted
+func (c *epochGapChecker) check(gap EpochGap) bool {
+	stuck := gap.Size() > c.threshold && gap.Applied == c.lastApplied
+	c.lastApplied = gap.Applied
+	c.alerting = stuck
+	return stuck
+}
+
+// checkEpochGap runs until the watcher is stopped, it logs an error every epochGapCheckInterval
+// while the epochs are stuck
+func (watcher *Watcher) checkEpochGap(threshold int64) {
+	checker := &epochGapChecker{threshold: threshold, lastApplied: -1}
+	for watcher.suspended(epochGapCheckInterval) {
+		gap := watcher.GetEpochGap()
+		recordEpochGapMetrics(gap)
+		wasAlerting := checker.alerting
+		if checker.check(gap) {
+			watcher.logger.Error("epochs are delivered by the watcher but not applied by staking",
+				"delivered", gap.Delivered, "applied", gap.Applied, "queued", gap.Queued, "threshold", threshold)
+		} else if wasAlerting {
+			watcher.logger.Info("epoch gap recovered", "delivered", gap.Delivered, "applied", gap.Applied)
+		}
+	}
+}
@@ -0,0 +1,248 @@
+package watcher
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/tendermint/tendermint/crypto"
+	"github.com/tendermint/tendermint/crypto/ed25519"
+
+	"github.com/smartbch/smartbch/param"
+	"github.com/smartbch/smartbch/staking"
+	"github.com/smartbch/smartbch/watcher/types"
+)
+
+const (
+	epochGossipSignPrefix = "smartbch-epoch-gossip:"
+	// the max number of vote infos in a response of sbch_getSignedVoteInfos
+	MaxGossipVoteInfos = 100
+)
+
+var (
+	epochGossipCheckInterval = 30 * time.Second
+
+	ErrEpochGossipNotServed = errors.New("this node does not serve the signed epochs")
+)
+
+// epochGossipSignBytes is the hash signed by the validators, the prefix keeps it from being a
+// valid consensus message
+func epochGossipSignBytes(infos []*types.VoteInfo) []byte {
+	bz, _ := json.Marshal(infos)
+	hash := sha256.Sum256(append([]byte(epochGossipSignPrefix), bz...))
+	return hash[:]
+}
+
+// SignVoteInfos signs the vote infos with the consensus key of a validator
+func SignVoteInfos(infos []*types.VoteInfo, key crypto.PrivKey) (*types.SignedVoteInfos, error) {
+	sig, err := key.Sign(epochGossipSignBytes(infos))
+	if err != nil {
+		return nil, err
+	}
+	return &types.SignedVoteInfos{Infos: infos, Pubkey: key.PubKey().Bytes(), Signature: sig}, nil
+}
+
+func verifySignedVoteInfos(s *types.SignedVoteInfos) bool {
+	if len(s.Pubkey) != ed25519.PubKeySize {
+		return false
+	}
+	return ed25519.PubKey(s.Pubkey).VerifySignature(epochGossipSignBytes(s.Infos), s.Signature)
+}
+
+// publishedVoteInfoCount returns the number of the vote infos published, the ones of the pending
+// epochs are at the end of voteInfoList. state.mtx must be held by the caller.
+func (watcher *Watcher) publishedVoteInfoCount() int {
+	n := len(watcher.state.voteInfoList) - len(watcher.state.pendingEpochs)
+	if n < 0 {
+		return 0
+	}
+	return n
+}
+
+// GetPublishedVoteInfos returns at most max vote infos published by the watcher, from the epoch
+// starting at the BCH height startHeight on. The epoch numbers are cleared, because they are only
+// assigned when the epochs are applied.
+func (watcher *Watcher) GetPublishedVoteInfos(startHeight int64, max int) []*types.VoteInfo {
+	watcher.state.mtx.RLock()
+	defer watcher.state.mtx.RUnlock()
+	n := watcher.publishedVoteInfoCount()
+	var infos []*types.VoteInfo
+	for i := 0; i < n && len(infos) < max; i++ {
+		v := watcher.state.voteInfoList[i]
+		if v.Epoch.StartHeight < startHeight {
+			continue
+		}
+		info := *v
+		info.Epoch.Number = 0
+		info.MonitorVote.Number = 0
+		infos = append(infos, &info)
+	}
+	return infos
+}
+
+// quorumVoteInfos returns the consecutive vote infos from the epoch starting at startHeight on,
+// each of which is signed by the validators holding more than 2/3 of the voting power
+func quorumVoteInfos(responses []*types.SignedVoteInfos, powers map[[32]byte]int64, startHeight, numBlocksInEpoch int64) []*types.VoteInfo {
+	var total int64
+	for _, power := range powers {
+		total += power
+	}
+	signed := make(map[[32]byte]*types.SignedVoteInfos)
+	for _, r := range responses {
+		if r == nil || !verifySignedVoteInfos(r) {
+			continue
+		}
+		var pubkey [32]byte
+		copy(pubkey[:], r.Pubkey)
+		if powers[pubkey] > 0 {
+			signed[pubkey] = r
+		}
+	}
+	var result []*types.VoteInfo
+	for height := startHeight; ; height += numBlocksInEpoch {
+		votes := make(map[[32]byte]int64)
+		infos := make(map[[32]byte]*types.VoteInfo)
+		for pubkey, r := range signed {
+			for _, info := range r.Infos {
+				if info.Epoch.StartHeight == height {
+					bz, _ := json.Marshal(info)
+					hash := sha256.Sum256(bz)
+					votes[hash] += powers[pubkey]
+					infos[hash] = info
+					break
+				}
+			}
+		}
+		var agreed *types.VoteInfo
+		for hash, power := range votes {
+			if power*3 > total*2 {
+				agreed = infos[hash]
+			}
+		}
+		if agreed == nil {
+			return result
+		}
+		result = append(result, agreed)
+	}
+}
+
+// runEpochGossipFallback runs until the watcher is stopped. It probes the BCH node every
+// epochGossipCheckInterval, and after the node has been unreachable for
+// epoch-gossip-fallback-after, it takes the epochs signed by a quorum of the validators from
+// epoch-gossip-peers, such that the block production does not stall on a regional BCH outage.
+// Once the BCH node is reachable again, the epochs are built from the BCH blocks as before.
+func (watcher *Watcher) runEpochGossipFallback() {
+	fallbackAfter := time.Duration(watcher.chainConfig.AppConfig.EpochGossipFallbackAfter) * time.Second
+	if fallbackAfter == 0 {
+		fallbackAfter = param.DefaultEpochGossipFallbackAfter * time.Second
+	}
+	lastContact := time.Now()
+	for watcher.suspended(epochGossipCheckInterval) {
+		if watcher.rpcClient.GetLatestHeight(false) > 0 {
+			lastContact = time.Now()
+			if atomic.CompareAndSwapInt32(&watcher.gossipFallback, 1, 0) {
+				watcher.logger.Info("the BCH node is reachable again, stop taking the gossiped epochs")
+			}
+			continue
+		}
+		if time.Since(lastContact) < fallbackAfter {
+			continue
+		}
+		if atomic.CompareAndSwapInt32(&watcher.gossipFallback, 0, 1) {
+			watcher.logger.Error("the BCH node is unreachable, take the epochs gossiped by the validators",
+				"lastContact", lastContact)
+		}
+		if !watcher.pullGossipedEpochs() {
+			return
+		}
+	}
+}
+
+// pullGossipedEpochs takes the epochs after the published ones which are agreed by a quorum of
+// the peers. It returns false if the watcher is stopped.
+func (watcher *Watcher) pullGossipedEpochs() bool {
+	startHeight := watcher.nextEpochStartHeight()
+	var responses []*types.SignedVoteInfos
+	for _, peer := range watcher.gossipPeers {
+		r, err := peer.GetSignedVoteInfos(startHeight)
+		if err != nil {
+			watcher.logger.Debug("failed to get the gossiped epochs", "err", err)
+			continue
+		}
+		responses = append(responses, r)
+	}
+	infos := quorumVoteInfos(responses, watcher.validatorPowers(), startHeight, watcher.numBlocksInEpoch)
+	if len(infos) == 0 {
+		return true
+	}
+	watcher.logger.Info("take the gossiped epochs", "startHeight", startHeight, "epochs", len(infos))
+	return watcher.adoptVoteInfos(infos)
+}
+
+// nextEpochStartHeight returns the start height of the first epoch not published yet
+func (watcher *Watcher) nextEpochStartHeight() int64 {
+	watcher.state.mtx.RLock()
+	defer watcher.state.mtx.RUnlock()
+	if len(watcher.state.pendingEpochs) != 0 {
+		return watcher.state.pendingEpochs[0].epoch.StartHeight
+	}
+	return watcher.state.lastEpochEndHeight + 1
+}
+
+// validatorPowers returns the voting powers of the active validators by their consensus pubkeys
+func (watcher *Watcher) validatorPowers() map[[32]byte]int64 {
+	ctx := watcher.contextGetter.GetRpcContext()
+	defer ctx.Close(false)
+	info := staking.LoadStakingInfo(ctx)
+	powers := make(map[[32]byte]int64)
+	for _, val := range staking.GetActiveValidators(ctx, info.Validators) {
+		powers[val.Pubkey] = val.VotingPower
+	}
+	return powers
+}
+
+// adoptVoteInfos replaces the epochs not published yet with the gossiped ones, and sends them to
+// the app. The blocks after them are fetched again when the BCH node is reachable. It returns
+// false if the watcher is stopped.
+func (watcher *Watcher) adoptVoteInfos(infos []*types.VoteInfo) bool {
+	endHeight := infos[len(infos)-1].Epoch.StartHeight + watcher.numBlocksInEpoch - 1
+	watcher.state.mtx.Lock()
+	n := watcher.publishedVoteInfoCount()
+	watcher.state.voteInfoList = append(watcher.state.voteInfoList[:n], infos...)
+	watcher.state.pendingEpochs = nil
+	for h := endHeight + 1; h <= watcher.state.latestFinalizedHeight; h++ {
+		delete(watcher.state.heightToFinalizedBlock, h)
+	}
+	watcher.state.latestFinalizedHeight = endHeight
+	watcher.state.lastEpochEndHeight = endHeight
+	watcher.state.mtx.Unlock()
+	for _, in := range infos {
+		recordEpochMetrics(&in.Epoch)
+		if !watcher.sendEpoch(&in.Epoch) {
+			return false
+		}
+		watcher.epochArchive.add(atomic.AddInt64(&watcher.deliveredEpochNum, 1), &in.Epoch, monitorVoteOf(in))
+		// the monitor vote info built by the watcher has no EndTime
+		if !param.IsAmber && in.MonitorVote.StartHeight != 0 {
+			recordMonitorVoteMetrics(&in.MonitorVote)
+			if !watcher.sendMonitorVoteInfo(&in.MonitorVote) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// GetSignedVoteInfos fetches the vote infos signed by the smartBCH node, from the epoch starting
+// at the BCH height startHeight on
+func (client *RpcClient) GetSignedVoteInfos(startHeight int64) (*types.SignedVoteInfos, error) {
+	var result types.SignedVoteInfos
+	if err := client.call(fmt.Sprintf(ReqStrSignedVoteInfos, hexutil.Uint64(startHeight).String()), &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
@@ -0,0 +1,83 @@
+package watcher
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/crypto/ed25519"
+	"github.com/tendermint/tendermint/libs/log"
+
+	"github.com/smartbch/smartbch/param"
+	stakingtypes "github.com/smartbch/smartbch/staking/types"
+	"github.com/smartbch/smartbch/watcher/types"
+)
+
+func gossipVoteInfo(startHeight, endTime int64) *types.VoteInfo {
+	return &types.VoteInfo{Epoch: stakingtypes.Epoch{StartHeight: startHeight, EndTime: endTime}}
+}
+
+func TestQuorumVoteInfos(t *testing.T) {
+	var keys []ed25519.PrivKey
+	powers := make(map[[32]byte]int64)
+	for i := 0; i < 4; i++ {
+		key := ed25519.GenPrivKey()
+		keys = append(keys, key)
+		var pubkey [32]byte
+		copy(pubkey[:], key.PubKey().Bytes())
+		powers[pubkey] = 1
+	}
+	sign := func(key ed25519.PrivKey, infos ...*types.VoteInfo) *types.SignedVoteInfos {
+		s, err := SignVoteInfos(infos, key)
+		require.NoError(t, err)
+		return s
+	}
+	// 3 of the 4 validators agree on the epochs starting at 11 and 21, but only 2 on the one at 31
+	responses := []*types.SignedVoteInfos{
+		sign(keys[0], gossipVoteInfo(11, 100), gossipVoteInfo(21, 200), gossipVoteInfo(31, 300)),
+		sign(keys[1], gossipVoteInfo(11, 100), gossipVoteInfo(21, 200), gossipVoteInfo(31, 300)),
+		sign(keys[2], gossipVoteInfo(11, 100), gossipVoteInfo(21, 200), gossipVoteInfo(31, 301)),
+		sign(keys[3], gossipVoteInfo(11, 101)),
+	}
+	infos := quorumVoteInfos(responses, powers, 11, 10)
+	require.Len(t, infos, 2)
+	require.EqualValues(t, 100, infos[0].Epoch.EndTime)
+	require.EqualValues(t, 200, infos[1].Epoch.EndTime)
+
+	// a forged signature and a signer which is not a validator are not counted
+	forged := sign(keys[2], gossipVoteInfo(11, 100))
+	forged.Infos[0] = gossipVoteInfo(11, 999)
+	outsider := sign(ed25519.GenPrivKey(), gossipVoteInfo(11, 100))
+	require.Empty(t, quorumVoteInfos([]*types.SignedVoteInfos{responses[0], responses[1], forged, outsider}, powers, 11, 10))
+	require.Empty(t, quorumVoteInfos(responses, powers, 16, 10))
+}
+
+func TestAdoptVoteInfos(t *testing.T) {
+	node := buildMockBCHNodeWithOnlyValidator1()
+	w := NewWatcher(log.NewNopLogger(), nil, 0, 0, param.DefaultConfig())
+	w.SetBlockFinalizeNumber(1)
+	w.SetNumBlocksInEpoch(10)
+	w.rpcClient = MockRpcClient{node: node}
+	for h := int64(1); h <= 25; h++ {
+		require.NoError(t, w.addFinalizedBlock(node.blocks[h-1]))
+	}
+	w.publishEpochs(21)
+	require.Len(t, w.EpochChan, 1)
+	require.Len(t, w.state.pendingEpochs, 1)
+	require.Equal(t, int64(11), w.nextEpochStartHeight())
+	require.Len(t, w.GetPublishedVoteInfos(1, MaxGossipVoteInfos), 1)
+
+	// the pending epoch is replaced by the gossiped ones
+	require.True(t, w.adoptVoteInfos([]*types.VoteInfo{gossipVoteInfo(11, 100), gossipVoteInfo(21, 200)}))
+	require.Len(t, w.EpochChan, 3)
+	require.Empty(t, w.state.pendingEpochs)
+	require.Equal(t, int64(30), w.GetLatestFinalizedHeight())
+	require.Equal(t, int64(30), w.state.lastEpochEndHeight)
+	require.Equal(t, int64(31), w.nextEpochStartHeight())
+	infos := w.GetPublishedVoteInfos(11, MaxGossipVoteInfos)
+	require.Len(t, infos, 2)
+	require.EqualValues(t, 200, infos[1].Epoch.EndTime)
+
+	// the blocks fetched before the epochs are taken are dropped
+	require.Equal(t, errBlockNotConsecutive, w.addFinalizedBlock(node.blocks[25]))
+	require.NoError(t, w.addFinalizedBlock(node.blocks[30]))
+}
@@ -0,0 +1,76 @@
+package watcher
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+var errBlockNotConsecutive = errors.New("the block does not follow the latest finalized block")
+
+// MissingBlockError is returned when an epoch is built while a finalized block of it is not stored
+type MissingBlockError struct {
+	Height int64
+}
+
+func (e *MissingBlockError) Error() string {
+	return fmt.Sprintf("missing finalized block at height %d", e.Height)
+}
+
+// missingHeights returns the heights of the finalized blocks missing in the epoch being built,
+// state.mtx must be held by the caller
+func (watcher *Watcher) missingHeights() []int64 {
+	s := &watcher.state
+	if s.lastEpochEndHeight > s.latestFinalizedHeight {
+		// no block fetched again can fix it
+		panic(fmt.Sprintf("corrupted watcher state: lastEpochEndHeight %d is after latestFinalizedHeight %d",
+			s.lastEpochEndHeight, s.latestFinalizedHeight))
+	}
+	var heights []int64
+	for h := s.lastEpochEndHeight + 1; h <= s.latestFinalizedHeight; h++ {
+		if _, ok := s.heightToFinalizedBlock[h]; !ok {
+			heights = append(heights, h)
+		}
+	}
+	return heights
+}
+
+// healMissingBlocks fetches the finalized blocks missing in the epoch being built again. If a
+// fetched block does not link to the stored ones around it, they are from a stale branch, so the
+// blocks from the gap on are discarded, to be fetched again by fetchBlocks, whose parent check
+// handles the reorg. It returns false if the watcher is stopped.
+func (watcher *Watcher) healMissingBlocks(missing *MissingBlockError) bool {
+	watcher.state.mtx.RLock()
+	heights := watcher.missingHeights()
+	watcher.state.mtx.RUnlock()
+	watcher.logger.Error("finalized blocks are missing in the epoch being built, fetch them again",
+		"height", missing.Height, "count", len(heights))
+	for _, h := range heights {
+		blk := watcher.rpcClient.GetBlockByHeight(h, true)
+		if blk == nil || blk.Height != h {
+			watcher.logger.Info("invalid block fetched, retry later", "height", h)
+			return watcher.suspended(refetchDelayTime)
+		}
+		watcher.state.mtx.Lock()
+		s := &watcher.state
+		if h <= s.lastEpochEndHeight || h > s.latestFinalizedHeight {
+			// the gossiped epochs are taken or a reorg is handled meanwhile
+			watcher.state.mtx.Unlock()
+			continue
+		}
+		prev, next := s.heightToFinalizedBlock[h-1], s.heightToFinalizedBlock[h+1]
+		if (prev != nil && blk.ParentBlk != prev.HashId) || (next != nil && next.ParentBlk != blk.HashId) {
+			watcher.logger.Error("the blocks around the missing one are from a stale branch, discard them",
+				"height", h, "discardedBlocks", s.latestFinalizedHeight-h+1)
+			discardedBlocks.Add(float64(s.latestFinalizedHeight - h + 1))
+			watcher.analytics.addFork(h-1, s.latestFinalizedHeight-h+1, time.Now().Unix())
+			watcher.rollbackTo(h - 1)
+			watcher.state.mtx.Unlock()
+			return true
+		}
+		s.heightToFinalizedBlock[h] = blk
+		watcher.state.mtx.Unlock()
+		healedBlocks.Inc()
+	}
+	return true
+}
@@ -0,0 +1,45 @@
+package watcher
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/libs/log"
+
+	"github.com/smartbch/smartbch/param"
+)
+
+func TestHealMissingBlocks(t *testing.T) {
+	node := buildMockBCHNodeWithOnlyValidator1()
+	w := NewWatcher(log.NewNopLogger(), nil, 0, 0, param.DefaultConfig())
+	w.SetNumBlocksInEpoch(10)
+	w.rpcClient = MockRpcClient{node: node}
+	for h := int64(1); h <= 9; h++ {
+		require.NoError(t, w.addFinalizedBlock(node.blocks[h-1]))
+	}
+	delete(w.state.heightToFinalizedBlock, 5)
+	_, err := w.GetCurrEpoch()
+	require.Equal(t, &MissingBlockError{Height: 5}, err)
+
+	// the last block of the epoch is not added until the missing one is fetched again
+	err = w.addFinalizedBlock(node.blocks[9])
+	var missing *MissingBlockError
+	require.True(t, errors.As(err, &missing))
+	require.Equal(t, int64(9), w.GetLatestFinalizedHeight())
+	require.True(t, w.healMissingBlocks(missing))
+	require.NoError(t, w.addFinalizedBlock(node.blocks[9]))
+	require.Len(t, w.state.pendingEpochs, 1)
+
+	// the blocks after a missing block of another branch are discarded
+	for h := int64(11); h <= 18; h++ {
+		require.NoError(t, w.addFinalizedBlock(node.blocks[h-1]))
+	}
+	delete(w.state.heightToFinalizedBlock, 15)
+	reorgFrom(node, 15, 0xaa)
+	require.True(t, w.healMissingBlocks(&MissingBlockError{Height: 15}))
+	require.Equal(t, int64(14), w.GetLatestFinalizedHeight())
+	require.Nil(t, w.getFinalizedBlock(16))
+	_, err = w.GetCurrEpoch()
+	require.NoError(t, err)
+}
@@ -0,0 +1,196 @@
+package watcher
+
+import (
+	"encoding/binary"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	stakingtypes "github.com/smartbch/smartbch/staking/types"
+)
+
+// epochChanSize is the capacity of EpochChan, the epochs sent when it is full are spilled to disk
+const epochChanSize = 100
+
+// the spilled epochs are retried after this delay when the spill file cannot be written or read
+const spillRetryDelay = time.Second
+
+// epochQueue lets the watcher send the epochs without blocking on a stalled app. The epochs go to
+// EpochChan while it has room, and to the spill file otherwise, from which a forwarding goroutine
+// moves them back to EpochChan in order. The spill file is truncated when the watcher starts,
+// because the epochs not applied by the staking module are rebuilt from the BCH blocks anyway.
+type epochQueue struct {
+	ch   chan *stakingtypes.Epoch
+	path string // empty means the epochs are spilled to memory
+
+	mtx        sync.Mutex
+	file       *os.File // opened on the first spill
+	readOffset int64
+	spilled    []*stakingtypes.Epoch // used when path is empty
+	numSpilled int
+	forwarding bool // whether the forwarding goroutine is running
+}
+
+func newEpochQueue(ch chan *stakingtypes.Epoch, path string) *epochQueue {
+	return &epochQueue{ch: ch, path: path}
+}
+
+// push sends epoch to the channel or spills it, it returns true if the forwarding goroutine must
+// be started
+func (q *epochQueue) push(epoch *stakingtypes.Epoch) (startForwarding bool, err error) {
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+	if q.numSpilled == 0 {
+		select {
+		case q.ch <- epoch:
+			return false, nil
+		default:
+		}
+	}
+	if err = q.spill(epoch); err != nil {
+		return false, err
+	}
+	q.numSpilled++
+	spilledEpochs.Set(float64(q.numSpilled))
+	startForwarding = !q.forwarding
+	q.forwarding = true
+	return startForwarding, nil
+}
+
+// size returns the number of the spilled epochs, which are not in the channel yet
+func (q *epochQueue) size() int {
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+	return q.numSpilled
+}
+
+func (q *epochQueue) spill(epoch *stakingtypes.Epoch) error {
+	if q.path == "" {
+		q.spilled = append(q.spilled, epoch)
+		return nil
+	}
+	if q.file == nil {
+		f, err := os.OpenFile(q.path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
+		if err != nil {
+			return err
+		}
+		q.file = f
+	}
+	bz, err := epoch.MarshalMsg(nil)
+	if err != nil {
+		return err
+	}
+	var buf [4]byte
+	binary.BigEndian.PutUint32(buf[:], uint32(len(bz)))
+	end, err := q.file.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+	if _, err = q.file.Write(append(buf[:], bz...)); err != nil {
+		// drop the partial record, such that the following ones can be read
+		_ = q.file.Truncate(end)
+		return err
+	}
+	return nil
+}
+
+// peek returns the oldest spilled epoch and the size of its record, or nil if nothing is spilled,
+// in which case the forwarding goroutine must exit
+func (q *epochQueue) peek() (*stakingtypes.Epoch, int64, error) {
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+	if q.numSpilled == 0 {
+		q.forwarding = false
+		return nil, 0, nil
+	}
+	if q.path == "" {
+		return q.spilled[0], 0, nil
+	}
+	var buf [4]byte
+	if _, err := q.file.ReadAt(buf[:], q.readOffset); err != nil {
+		return nil, 0, err
+	}
+	bz := make([]byte, binary.BigEndian.Uint32(buf[:]))
+	if _, err := q.file.ReadAt(bz, q.readOffset+4); err != nil {
+		return nil, 0, err
+	}
+	epoch := &stakingtypes.Epoch{}
+	if _, err := epoch.UnmarshalMsg(bz); err != nil {
+		return nil, 0, err
+	}
+	return epoch, int64(4 + len(bz)), nil
+}
+
+// pop removes the oldest spilled epoch after it is sent to the channel
+func (q *epochQueue) pop(recordSize int64) {
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+	q.numSpilled--
+	spilledEpochs.Set(float64(q.numSpilled))
+	if q.path == "" {
+		q.spilled[0] = nil
+		q.spilled = q.spilled[1:]
+		return
+	}
+	q.readOffset += recordSize
+	if q.numSpilled == 0 {
+		q.readOffset = 0
+		_ = q.file.Truncate(0)
+	}
+}
+
+// close removes the spill file, the spilled epochs are rebuilt after restarting
+func (q *epochQueue) close() {
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+	if q.file != nil {
+		_ = q.file.Close()
+		_ = os.Remove(q.path)
+		q.file = nil
+	}
+}
+
+// sendEpoch sends the epoch without waiting for the app, it only waits when the epoch can be
+// neither sent nor spilled, and returns false if the watcher is stopped
+func (watcher *Watcher) sendEpoch(epoch *stakingtypes.Epoch) bool {
+	for !watcher.stopped() {
+		startForwarding, err := watcher.epochQueue.push(epoch)
+		if err == nil {
+			if startForwarding {
+				watcher.logger.Info("EpochChan is full, spilling the epochs", "path", watcher.epochQueue.path)
+				watcher.goRun(watcher.forwardSpilledEpochs)
+			}
+			return true
+		}
+		watcher.logger.Error("cannot spill the epoch", "number", epoch.Number, "error", err)
+		if !watcher.suspended(spillRetryDelay) {
+			return false
+		}
+	}
+	return false
+}
+
+// forwardSpilledEpochs moves the spilled epochs to EpochChan in order, until none is left
+func (watcher *Watcher) forwardSpilledEpochs() {
+	for {
+		epoch, recordSize, err := watcher.epochQueue.peek()
+		if err != nil {
+			watcher.logger.Error("cannot read the spilled epoch", "error", err)
+			if !watcher.suspended(spillRetryDelay) {
+				return
+			}
+			continue
+		}
+		if epoch == nil {
+			watcher.logger.Info("all the spilled epochs are sent to EpochChan")
+			return
+		}
+		select {
+		case watcher.epochQueue.ch <- epoch:
+			watcher.epochQueue.pop(recordSize)
+		case <-watcher.life.ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package watcher
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/libs/log"
+
+	"github.com/smartbch/smartbch/param"
+	stakingtypes "github.com/smartbch/smartbch/staking/types"
+)
+
+func TestEpochQueueSpill(t *testing.T) {
+	for _, path := range []string{"", filepath.Join(t.TempDir(), param.WatcherEpochSpillPath)} {
+		w := NewWatcher(log.NewNopLogger(), nil, 0, 0, param.DefaultConfig())
+		w.EpochChan = make(chan *stakingtypes.Epoch, 2)
+		w.epochQueue = newEpochQueue(w.EpochChan, path)
+
+		// the app does not read, the watcher is not blocked
+		for i := 1; i <= 5; i++ {
+			require.True(t, w.sendEpoch(&stakingtypes.Epoch{
+				Number:      int64(i),
+				Nominations: []*stakingtypes.Nomination{{Pubkey: [32]byte{byte(i)}, NominatedCount: int64(i)}},
+			}))
+		}
+		require.Len(t, w.EpochChan, 2)
+		require.Equal(t, 3, w.epochQueue.size())
+		require.Equal(t, 3, w.GetStatus().SpilledEpochs)
+		if path != "" {
+			_, err := os.Stat(path)
+			require.NoError(t, err)
+		}
+
+		for i := 1; i <= 5; i++ {
+			select {
+			case epoch := <-w.EpochChan:
+				require.EqualValues(t, i, epoch.Number)
+				require.EqualValues(t, i, epoch.Nominations[0].NominatedCount)
+			case <-time.After(5 * time.Second):
+				require.Fail(t, "spilled epoch not forwarded")
+			}
+		}
+		require.Eventually(t, func() bool { return w.epochQueue.size() == 0 }, time.Second, 10*time.Millisecond)
+
+		// sent directly again after the spilled ones are drained
+		require.True(t, w.sendEpoch(&stakingtypes.Epoch{Number: 6}))
+		require.Len(t, w.EpochChan, 1)
+		require.True(t, w.Stop())
+		if path != "" {
+			_, err := os.Stat(path)
+			require.True(t, os.IsNotExist(err))
+		}
+	}
+}
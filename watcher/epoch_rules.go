@@ -0,0 +1,163 @@
+package watcher
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"sort"
+
+	"github.com/smartbch/smartbch/param"
+	stakingtypes "github.com/smartbch/smartbch/staking/types"
+	"github.com/smartbch/smartbch/watcher/types"
+)
+
+// the tie-breaking orders of the nominations with the same count
+const (
+	TieBreakPubkeyAsc  = "pubkeyAsc" // the current rule of sortEpochNominations
+	TieBreakPubkeyDesc = "pubkeyDesc"
+)
+
+// NominationRule is an alternative to the current way of picking the validators from the
+// nominations of an epoch, which sorts them by sortEpochNominations and takes the first
+// MaxActiveValidatorCount ones
+type NominationRule struct {
+	Name string `json:"name"`
+	// the nominations with fewer blocks are dropped
+	MinNominatedCount int64 `json:"minNominatedCount"`
+	// the nominations with a smaller share of the nominated blocks of the epoch are dropped
+	MinShare float64 `json:"minShare"`
+	// zero means MaxActiveValidatorCount
+	MaxValidators int `json:"maxValidators"`
+	// TieBreakPubkeyAsc or TieBreakPubkeyDesc, empty means TieBreakPubkeyAsc
+	TieBreak string `json:"tieBreak"`
+}
+
+func (rule NominationRule) check() error {
+	if rule.TieBreak != "" && rule.TieBreak != TieBreakPubkeyAsc && rule.TieBreak != TieBreakPubkeyDesc {
+		return fmt.Errorf("rule %s: invalid tieBreak %s", rule.Name, rule.TieBreak)
+	}
+	if rule.MinShare < 0 || rule.MinShare > 1 || rule.MaxValidators < 0 {
+		return fmt.Errorf("rule %s: invalid minShare or maxValidators", rule.Name)
+	}
+	return nil
+}
+
+// selectValidators returns the pubkeys picked by the rule from the nominations, in order
+func (rule NominationRule) selectValidators(nominations []*stakingtypes.Nomination) [][32]byte {
+	var total int64
+	for _, n := range nominations {
+		total += n.NominatedCount
+	}
+	kept := make([]*stakingtypes.Nomination, 0, len(nominations))
+	for _, n := range nominations {
+		if n.NominatedCount < rule.MinNominatedCount {
+			continue
+		}
+		if total != 0 && float64(n.NominatedCount) < rule.MinShare*float64(total) {
+			continue
+		}
+		kept = append(kept, n)
+	}
+	desc := rule.TieBreak == TieBreakPubkeyDesc
+	sort.Slice(kept, func(i, j int) bool {
+		if kept[i].NominatedCount != kept[j].NominatedCount {
+			return kept[i].NominatedCount > kept[j].NominatedCount
+		}
+		c := bytes.Compare(kept[i].Pubkey[:], kept[j].Pubkey[:])
+		if desc {
+			return c > 0
+		}
+		return c < 0
+	})
+	max := rule.MaxValidators
+	if max == 0 {
+		max = param.MaxActiveValidatorCount
+	}
+	if len(kept) > max {
+		kept = kept[:max]
+	}
+	pubkeys := make([][32]byte, len(kept))
+	for i, n := range kept {
+		pubkeys[i] = n.Pubkey
+	}
+	return pubkeys
+}
+
+// EpochDiff is how the validators picked by a rule differ from the current rule in an epoch
+type EpochDiff struct {
+	Number  int64    `json:"number"`
+	Added   []string `json:"added"`
+	Removed []string `json:"removed"`
+}
+
+// RuleOutcome summarizes the epochs simulated under a rule
+type RuleOutcome struct {
+	Rule                 NominationRule `json:"rule"`
+	Epochs               int            `json:"epochs"`
+	ChangedEpochs        int            `json:"changedEpochs"`
+	TotalAdded           int            `json:"totalAdded"`
+	TotalRemoved         int            `json:"totalRemoved"`
+	AvgValidators        float64        `json:"avgValidators"`
+	AvgCurrentValidators float64        `json:"avgCurrentValidators"`
+	Diffs                []*EpochDiff   `json:"diffs"`
+}
+
+// SimulateNominationRules recomputes the validators of the epochs under each rule and compares them
+// with the current rule. Only the PoW nominations are simulated, the staking module also drops the
+// nominations of the pubkeys which are not validators and merges the PoS votes after the staking fork.
+func SimulateNominationRules(infos []*types.VoteInfo, rules []NominationRule) ([]*RuleOutcome, error) {
+	for _, rule := range rules {
+		if err := rule.check(); err != nil {
+			return nil, err
+		}
+	}
+	current := NominationRule{}
+	outcomes := make([]*RuleOutcome, len(rules))
+	for i, rule := range rules {
+		outcomes[i] = &RuleOutcome{Rule: rule, Diffs: []*EpochDiff{}}
+	}
+	for _, info := range infos {
+		base := current.selectValidators(info.Epoch.Nominations)
+		for i, rule := range rules {
+			o := outcomes[i]
+			picked := rule.selectValidators(info.Epoch.Nominations)
+			o.Epochs++
+			o.AvgValidators += float64(len(picked))
+			o.AvgCurrentValidators += float64(len(base))
+			diff := &EpochDiff{
+				Number:  info.Epoch.Number,
+				Added:   pubkeysNotIn(picked, base),
+				Removed: pubkeysNotIn(base, picked),
+			}
+			if len(diff.Added) == 0 && len(diff.Removed) == 0 {
+				continue
+			}
+			o.ChangedEpochs++
+			o.TotalAdded += len(diff.Added)
+			o.TotalRemoved += len(diff.Removed)
+			o.Diffs = append(o.Diffs, diff)
+		}
+	}
+	for _, o := range outcomes {
+		if o.Epochs != 0 {
+			o.AvgValidators /= float64(o.Epochs)
+			o.AvgCurrentValidators /= float64(o.Epochs)
+		}
+	}
+	return outcomes, nil
+}
+
+// pubkeysNotIn returns the hex pubkeys in a but not in b
+func pubkeysNotIn(a, b [][32]byte) []string {
+	set := make(map[[32]byte]bool, len(b))
+	for _, pubkey := range b {
+		set[pubkey] = true
+	}
+	result := []string{}
+	for _, pubkey := range a {
+		if !set[pubkey] {
+			result = append(result, hex.EncodeToString(pubkey[:]))
+		}
+	}
+	return result
+}
@@ -0,0 +1,47 @@
+package watcher
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	stakingtypes "github.com/smartbch/smartbch/staking/types"
+	"github.com/smartbch/smartbch/watcher/types"
+)
+
+func TestSimulateNominationRules(t *testing.T) {
+	nominations := []*stakingtypes.Nomination{
+		{Pubkey: [32]byte{1}, NominatedCount: 50},
+		{Pubkey: [32]byte{2}, NominatedCount: 30},
+		{Pubkey: [32]byte{3}, NominatedCount: 10},
+		{Pubkey: [32]byte{4}, NominatedCount: 10},
+	}
+	infos := []*types.VoteInfo{
+		{Epoch: stakingtypes.Epoch{Number: 1, Nominations: nominations}},
+		{Epoch: stakingtypes.Epoch{Number: 2, Nominations: nominations[:2]}},
+	}
+	outcomes, err := SimulateNominationRules(infos, []NominationRule{
+		{Name: "same"},
+		{Name: "min-20%", MinShare: 0.2},
+		{Name: "top-3-desc", MaxValidators: 3, TieBreak: TieBreakPubkeyDesc},
+	})
+	require.NoError(t, err)
+
+	require.Zero(t, outcomes[0].ChangedEpochs)
+	require.Empty(t, outcomes[0].Diffs)
+
+	require.Equal(t, 1, outcomes[1].ChangedEpochs)
+	require.Equal(t, 2, outcomes[1].TotalRemoved)
+	require.EqualValues(t, 1, outcomes[1].Diffs[0].Number)
+	require.Empty(t, outcomes[1].Diffs[0].Added)
+	require.InDelta(t, 2.0, outcomes[1].AvgValidators, 1e-9)
+	require.InDelta(t, 3.0, outcomes[1].AvgCurrentValidators, 1e-9)
+
+	// the tie between 3 and 4 is broken the other way
+	require.Equal(t, []*EpochDiff{{Number: 1, Added: []string{}, Removed: []string{hex.EncodeToString(nominations[2].Pubkey[:])}}},
+		outcomes[2].Diffs)
+
+	_, err = SimulateNominationRules(infos, []NominationRule{{Name: "bad", TieBreak: "random"}})
+	require.Error(t, err)
+}
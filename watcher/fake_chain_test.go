@@ -0,0 +1,57 @@
+package watcher
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/libs/log"
+
+	"github.com/smartbch/smartbch/param"
+	stakingtypes "github.com/smartbch/smartbch/staking/types"
+	"github.com/smartbch/smartbch/watcher/testutils"
+)
+
+func nominatedPubkeys(e *stakingtypes.Epoch) map[[32]byte]bool {
+	pubkeys := make(map[[32]byte]bool)
+	for _, n := range e.Nominations {
+		pubkeys[n.Pubkey] = true
+	}
+	return pubkeys
+}
+
+func TestEpochsOnFakeChain(t *testing.T) {
+	a, b, c := [32]byte{0xa}, [32]byte{0xb}, [32]byte{0xc}
+	chain := testutils.NewFakeChain(0, 0)
+	chain.MineN(9, testutils.NominateValidator(a))
+	// BCH timestamps are not always increasing
+	chain.Mine(testutils.NominateValidator(a), testutils.WithTimestampSkew(-2*testutils.DefaultBlockInterval))
+	chain.MineN(11, testutils.NominateValidator(b))
+
+	w := NewWatcher(log.NewNopLogger(), nil, 0, 0, param.DefaultConfig())
+	w.SetBlockFinalizeNumber(1)
+	w.SetNumBlocksInEpoch(10)
+	w.SetRpcClient(chain)
+	for h := int64(1); h <= 21; h++ {
+		require.NoError(t, w.addFinalizedBlock(chain.GetBlockByHeight(h, false)))
+	}
+	w.publishEpochs(chain.GetLatestHeight(false))
+	require.Len(t, w.EpochChan, 1)
+	epoch := <-w.EpochChan
+	require.Equal(t, map[[32]byte]bool{a: true}, nominatedPubkeys(epoch))
+	require.Equal(t, chain.GetBlockByHeight(9, false).Timestamp, epoch.EndTime)
+
+	// the blocks since 18 are reorged before the second epoch is published
+	require.NoError(t, chain.Reorg(4, 5, testutils.NominateValidator(c)))
+	w.publishEpochs(chain.GetLatestHeight(false))
+	require.Empty(t, w.EpochChan)
+	require.EqualValues(t, 17, w.GetLatestFinalizedHeight())
+	for h := int64(18); h <= 22; h++ {
+		require.NoError(t, w.addFinalizedBlock(chain.GetBlockByHeight(h, false)))
+	}
+	w.publishEpochs(chain.GetLatestHeight(false))
+	require.Len(t, w.EpochChan, 1)
+	epoch = <-w.EpochChan
+	require.EqualValues(t, 11, epoch.StartHeight)
+	require.Equal(t, map[[32]byte]bool{b: true, c: true}, nominatedPubkeys(epoch))
+	require.Equal(t, chain.GetBlockByHeight(20, false).Timestamp, epoch.EndTime)
+}
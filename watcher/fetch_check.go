@@ -0,0 +1,109 @@
+package watcher
+
+import (
+	"time"
+
+	"github.com/smartbch/smartbch/watcher/types"
+)
+
+const (
+	maxRefetchRounds = 5
+	refetchDelayTime = 2 * time.Second
+)
+
+// findBadBlocks returns the indexes of the blocks which must be fetched again: the nil ones, the ones
+// at a wrong height, and the two ends of a broken parent link. blocks[i] is expected to be at
+// heightStart+i, and prev (if not nil) is the block at heightStart-1.
+func findBadBlocks(blocks []*types.BCHBlock, heightStart int64, prev *types.BCHBlock) []int {
+	bad := make(map[int]bool)
+	for i, blk := range blocks {
+		if blk == nil || blk.Height != heightStart+int64(i) {
+			bad[i] = true
+			continue
+		}
+		if i == 0 {
+			if prev != nil && blk.ParentBlk != prev.HashId {
+				bad[i] = true
+			}
+		} else if !bad[i-1] && blk.ParentBlk != blocks[i-1].HashId {
+			bad[i-1] = true
+			bad[i] = true
+		}
+	}
+	return sortedIndexes(bad, len(blocks))
+}
+
+// findBadBlockInfos is like findBadBlocks, but the parent links are checked only when the hashes are known
+func findBadBlockInfos(infos []*types.BlockInfo, heightStart int64) []int {
+	bad := make(map[int]bool)
+	for i, info := range infos {
+		if info == nil || info.Height != heightStart+int64(i) {
+			bad[i] = true
+			continue
+		}
+		if i > 0 && !bad[i-1] && info.PreviousBlockhash != "" && infos[i-1].Hash != "" &&
+			info.PreviousBlockhash != infos[i-1].Hash {
+			bad[i-1] = true
+			bad[i] = true
+		}
+	}
+	return sortedIndexes(bad, len(infos))
+}
+
+func sortedIndexes(set map[int]bool, n int) []int {
+	indexes := make([]int, 0, len(set))
+	for i := 0; i < n; i++ {
+		if set[i] {
+			indexes = append(indexes, i)
+		}
+	}
+	return indexes
+}
+
+// refetchBadBlocks re-fetches the bad blocks for at most maxRefetchRounds, and returns how many
+// blocks at the beginning of 'blocks' are valid and can be added as finalized blocks
+func (watcher *Watcher) refetchBadBlocks(blocks []*types.BCHBlock, heightStart int64) int {
+	prev := watcher.getFinalizedBlock(heightStart - 1)
+	for round := 0; ; round++ {
+		bad := findBadBlocks(blocks, heightStart, prev)
+		if len(bad) == 0 {
+			return len(blocks)
+		}
+		if round == maxRefetchRounds {
+			watcher.logger.Error("cannot fetch valid blocks", "height", heightStart+int64(bad[0]))
+			return bad[0]
+		}
+		watcher.logger.Info("re-fetch bad blocks", "round", round, "count", len(bad), "firstHeight", heightStart+int64(bad[0]))
+		if !watcher.suspended(refetchDelayTime) {
+			return bad[0]
+		}
+		for _, i := range bad {
+			blocks[i] = watcher.rpcClient.GetBlockByHeight(heightStart+int64(i), true)
+		}
+	}
+}
+
+// refetchBadBlockInfos re-fetches the bad block infos until all of them are valid, because
+// the cc transfer infos must not be collected from a partial block range. It returns false if
+// the watcher is stopped before that.
+func (watcher *Watcher) refetchBadBlockInfos(infos []*types.BlockInfo, heightStart int64) bool {
+	for round := 0; ; round++ {
+		bad := findBadBlockInfos(infos, heightStart)
+		if len(bad) == 0 {
+			return true
+		}
+		watcher.logger.Info("re-fetch bad block infos", "round", round, "count", len(bad), "firstHeight", heightStart+int64(bad[0]))
+		if !watcher.suspended(refetchDelayTime) {
+			return false
+		}
+		for _, i := range bad {
+			infos[i] = watcher.rpcClient.GetBlockInfoByHeight(heightStart+int64(i), true)
+		}
+	}
+}
+
+func (watcher *Watcher) getFinalizedBlock(height int64) *types.BCHBlock {
+	watcher.state.mtx.RLock()
+	defer watcher.state.mtx.RUnlock()
+	return watcher.state.heightToFinalizedBlock[height]
+}
@@ -0,0 +1,26 @@
+package watcher
+
+import (
+	"sync/atomic"
+)
+
+// GetLag returns how many BCH blocks the watcher is behind the BCH mainnet at the unix time now,
+// the blockFinalizeNumber blocks waiting for confirmations are not counted. It is the larger one
+// of the blocks between the latest finalized block and the latest height reported by the BCH node,
+// and the blocks expected to be mined since the latest finalized block by the time. The latter
+// keeps growing when the BCH node is unreachable or stuck, in which case the former does not, but
+// it is only an estimation, because the intervals of the BCH blocks vary a lot.
+func (watcher *Watcher) GetLag(now int64) int64 {
+	watcher.state.mtx.RLock()
+	finalized := watcher.state.latestFinalizedHeight
+	timestamp := watcher.state.currentMainnetBlockTimestamp
+	watcher.state.mtx.RUnlock()
+	lag := atomic.LoadInt64(&watcher.latestMainnetHeight) - watcher.blockFinalizeNumber - finalized
+	if byTime := (now-timestamp)/defaultMainnetBlockInterval - watcher.blockFinalizeNumber; byTime > lag {
+		lag = byTime
+	}
+	if lag < 0 {
+		return 0
+	}
+	return lag
+}
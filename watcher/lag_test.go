@@ -0,0 +1,26 @@
+package watcher
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/libs/log"
+
+	"github.com/smartbch/smartbch/param"
+)
+
+func TestGetLag(t *testing.T) {
+	w := NewWatcher(log.NewNopLogger(), nil, 100, 3, param.DefaultConfig())
+	w.SetBlockFinalizeNumber(2)
+	w.state.latestFinalizedHeight = 120
+	w.state.currentMainnetBlockTimestamp = 1650000000
+	w.latestMainnetHeight = 122
+	require.Zero(t, w.GetLag(1650000000+600))
+
+	// the BCH node reports more blocks than the finalized ones
+	w.latestMainnetHeight = 127
+	require.EqualValues(t, 5, w.GetLag(1650000000+1800))
+
+	// the BCH node is stuck, the lag grows with the time
+	require.EqualValues(t, 8, w.GetLag(1650000000+10*600))
+}
@@ -0,0 +1,103 @@
+package watcher
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	cctypes "github.com/smartbch/smartbch/crosschain/types"
+)
+
+// Stop waits at most this duration for the goroutines of the watcher to exit
+const stopTimeout = 30 * time.Second
+
+// lifecycle tracks the goroutines of the watcher, such that they can be stopped together
+type lifecycle struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	mtx    sync.Mutex // makes sure no goroutine is added after Stop begins waiting
+	wg     sync.WaitGroup
+}
+
+func newLifecycle() *lifecycle {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &lifecycle{ctx: ctx, cancel: cancel}
+}
+
+// Stop cancels the loops of the watcher and the requests to the BCH nodes, and waits for the
+// goroutines to exit. The epochs already in EpochChan are kept for the app, but the spilled and
+// pending ones are not published any more. It returns false if some goroutines are still running after
+// stopTimeout.
+func (watcher *Watcher) Stop() bool {
+	watcher.life.mtx.Lock()
+	watcher.life.cancel()
+	watcher.life.mtx.Unlock()
+	done := make(chan struct{})
+	go func() {
+		watcher.life.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		watcher.epochQueue.close()
+		watcher.analytics.close()
+		watcher.epochArchive.close()
+		watcher.logger.Info("watcher stopped")
+		return true
+	case <-time.After(stopTimeout):
+		watcher.logger.Error("watcher goroutines did not exit in time", "timeout", stopTimeout)
+		return false
+	}
+}
+
+func (watcher *Watcher) stopped() bool {
+	return watcher.life.ctx.Err() != nil
+}
+
+// enter registers a running goroutine, which must call watcher.life.wg.Done when it exits. It
+// returns false if the watcher has been stopped.
+func (watcher *Watcher) enter() bool {
+	watcher.life.mtx.Lock()
+	defer watcher.life.mtx.Unlock()
+	if watcher.stopped() {
+		return false
+	}
+	watcher.life.wg.Add(1)
+	return true
+}
+
+// goRun runs f in a goroutine tracked by Stop
+func (watcher *Watcher) goRun(f func()) {
+	if !watcher.enter() {
+		return
+	}
+	go func() {
+		defer watcher.life.wg.Done()
+		f()
+	}()
+}
+
+// suspended sleeps for delayDuration, it returns false if the watcher is stopped meanwhile
+func (watcher *Watcher) suspended(delayDuration time.Duration) bool {
+	return sleepWithContext(watcher.life.ctx, delayDuration)
+}
+
+func sleepWithContext(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+func (watcher *Watcher) sendMonitorVoteInfo(info *cctypes.MonitorVoteInfo) bool {
+	select {
+	case watcher.MonitorVoteChan <- info:
+		return true
+	case <-watcher.life.ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,47 @@
+package watcher
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/libs/log"
+
+	"github.com/smartbch/smartbch/param"
+	stakingtypes "github.com/smartbch/smartbch/staking/types"
+)
+
+func TestStop(t *testing.T) {
+	w := NewWatcher(log.NewNopLogger(), nil, 0, 0, param.DefaultConfig())
+	w.SetBlockFinalizeNumber(9)
+	w.rpcClient = MockRpcClient{node: buildMockBCHNodeWithOnlyValidator1()}
+	w.SetNumBlocksInEpoch(10)
+	w.SetWaitingBlockDelayTime(3600)
+	returned := make(chan struct{})
+	go func() {
+		w.Run()
+		close(returned)
+	}()
+	w.WaitCatchup()
+	// nobody reads EpochChan, the ones already sent are kept
+	queued := len(w.EpochChan)
+	start := time.Now()
+	require.True(t, w.Stop())
+	require.Less(t, time.Since(start), 5*time.Second)
+	<-returned
+	require.Equal(t, queued, len(w.EpochChan))
+	require.True(t, w.stopped())
+
+	// nothing runs after Stop
+	require.False(t, w.suspended(time.Hour))
+	require.False(t, w.enter())
+	w.Run()
+}
+
+func TestSendEpochAfterStop(t *testing.T) {
+	w := NewWatcher(log.NewNopLogger(), nil, 0, 0, param.DefaultConfig())
+	require.True(t, w.sendEpoch(&stakingtypes.Epoch{}))
+	require.True(t, w.Stop())
+	require.False(t, w.sendEpoch(&stakingtypes.Epoch{}))
+	require.Len(t, w.EpochChan, 1)
+}
@@ -0,0 +1,155 @@
+package watcher
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/smartbch/smartbch/param"
+	"github.com/smartbch/smartbch/watcher/types"
+)
+
+// mempoolClient is implemented by RpcClient, the replayed blocks have no mempool
+type mempoolClient interface {
+	GetRawMempool() ([]string, error)
+	GetMempoolTx(txid string) (*types.TxInfo, error)
+}
+
+// mempoolScanner keeps the deposits to the covenant found in the BCH mempool. They are only
+// informational: an unconfirmed deposit may be double-spent or evicted, and it is not minted until
+// it is collected from a finalized block.
+type mempoolScanner struct {
+	// the following fields are only accessed by the scanning goroutine
+	parser    types.CcTxParser
+	covenants []common.Address // the addresses the parser recognizes, in the order of setCovenants
+	checked   map[common.Hash]bool
+
+	mtx     sync.RWMutex
+	pending map[common.Hash]types.PendingCcDeposit
+}
+
+func newMempoolScanner() *mempoolScanner {
+	return &mempoolScanner{
+		checked: make(map[common.Hash]bool),
+		pending: make(map[common.Hash]types.PendingCcDeposit),
+	}
+}
+
+// setCovenants updates the recognized covenant addresses, the mempool is parsed again if they
+// are changed
+func (s *mempoolScanner) setCovenants(prev, curr common.Address, older ...common.Address) {
+	covenants := append([]common.Address{prev, curr}, older...)
+	if addressesEqual(covenants, s.covenants) {
+		return
+	}
+	s.covenants = covenants
+	s.parser.SetCovenantAddresses(prev, curr, older...)
+	s.checked = make(map[common.Hash]bool)
+	s.mtx.Lock()
+	s.pending = make(map[common.Hash]types.PendingCcDeposit)
+	s.mtx.Unlock()
+}
+
+func addressesEqual(a, b []common.Address) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// update parses the txs in the mempool which are not parsed yet, and drops the deposits which
+// left the mempool because they are mined, double-spent or evicted. A tx failed to fetch is
+// retried in the next update.
+func (s *mempoolScanner) update(txids []string, getTx func(txid string) (*types.TxInfo, error), now int64) {
+	inMempool := make(map[common.Hash]bool, len(txids))
+	var txs []types.TxInfo
+	for _, txid := range txids {
+		hash := common.HexToHash(txid)
+		inMempool[hash] = true
+		if s.checked[hash] {
+			continue
+		}
+		tx, err := getTx(txid)
+		if err != nil {
+			continue
+		}
+		s.checked[hash] = true
+		txs = append(txs, *tx)
+	}
+	for hash := range s.checked {
+		if !inMempool[hash] {
+			delete(s.checked, hash)
+		}
+	}
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	for hash := range s.pending {
+		if !inMempool[hash] {
+			delete(s.pending, hash)
+		}
+	}
+	for _, info := range s.parser.FindDeposits(txs) {
+		s.pending[info.UTXO.TxID] = types.PendingCcDeposit{Info: info, FirstSeen: now}
+	}
+}
+
+// list returns the pending deposits in the order they were found
+func (s *mempoolScanner) list() []types.PendingCcDeposit {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	deposits := make([]types.PendingCcDeposit, 0, len(s.pending))
+	for _, d := range s.pending {
+		deposits = append(deposits, d)
+	}
+	sort.Slice(deposits, func(i, j int) bool {
+		if deposits[i].FirstSeen != deposits[j].FirstSeen {
+			return deposits[i].FirstSeen < deposits[j].FirstSeen
+		}
+		return string(deposits[i].Info.UTXO.TxID[:]) < string(deposits[j].Info.UTXO.TxID[:])
+	})
+	return deposits
+}
+
+// scanMempool runs until the watcher is stopped, it refreshes the pending deposits every
+// mempool-scan-interval
+func (watcher *Watcher) scanMempool() {
+	client, ok := watcher.rpcClient.(mempoolClient)
+	if !ok {
+		watcher.logger.Info("the BCH mempool is not scanned, the BCH client has no mempool")
+		return
+	}
+	interval := watcher.chainConfig.AppConfig.MempoolScanInterval
+	if interval == 0 {
+		interval = param.DefaultMempoolScanInterval
+	}
+	for watcher.suspended(time.Duration(interval) * time.Second) {
+		collectParam := watcher.getUTXOCollectParam()
+		if collectParam == nil {
+			continue
+		}
+		watcher.mempool.setCovenants(collectParam.PrevCovenantAddress, collectParam.CurrentCovenantAddress,
+			watcher.olderCovenantAddrs()...)
+		txids, err := client.GetRawMempool()
+		if err != nil {
+			watcher.logger.Error("failed to get the BCH mempool", "err", err)
+			continue
+		}
+		watcher.mempool.update(txids, client.GetMempoolTx, time.Now().Unix())
+	}
+}
+
+// GetPendingCcDeposits returns the deposits to the covenant found in the BCH mempool, and false if
+// watcher-scan-mempool is not set
+func (watcher *Watcher) GetPendingCcDeposits() ([]types.PendingCcDeposit, bool) {
+	if watcher.mempool == nil {
+		return nil, false
+	}
+	return watcher.mempool.list(), true
+}
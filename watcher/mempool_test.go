@@ -0,0 +1,73 @@
+package watcher
+
+import (
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+
+	"github.com/smartbch/smartbch/watcher/types"
+)
+
+func TestMempoolScanner(t *testing.T) {
+	covenant := common.HexToAddress("0x6ad3f81523c87aa17f1dfa08271cf57b6277c98e")
+	receiver := "c370743331b37d3c6d0ee798b3918f6561af2c92"
+	deposit := func(txid string) *types.TxInfo {
+		return &types.TxInfo{
+			TxID: txid,
+			Hash: txid,
+			VoutList: []types.Vout{
+				{Value: 0.1, ScriptPubKey: map[string]interface{}{"asm": "OP_HASH160 " + hex.EncodeToString(covenant[:]) + " OP_EQUAL"}},
+				{ScriptPubKey: map[string]interface{}{"asm": "OP_RETURN " + hex.EncodeToString([]byte(receiver))}},
+			},
+		}
+	}
+	txA := "c01ab2bfa4a7f64cf781e886844de836e7b45f2c6150de380cb891045e8353c9"
+	txB := "9ce17bd5f7e4a1b9b7d2f5ad6d4f1cb4c3a3b8b3b0e3f2b1a1c9d9e8f7e6d5c4"
+	txC := "0000000000000000000000000000000000000000000000000000000000000003"
+	txs := map[string]*types.TxInfo{txA: deposit(txA), txB: deposit(txB), txC: {TxID: txC, Hash: txC}}
+	fetched := 0
+	getTx := func(txid string) (*types.TxInfo, error) {
+		fetched++
+		if tx, ok := txs[txid]; ok {
+			return tx, nil
+		}
+		return nil, errors.New("not found")
+	}
+
+	s := newMempoolScanner()
+	s.setCovenants(common.Address{}, covenant)
+	s.update([]string{txA, txC}, getTx, 100)
+	require.Equal(t, 2, fetched)
+	deposits := s.list()
+	require.Len(t, deposits, 1)
+	require.Equal(t, common.HexToHash(txA), common.Hash(deposits[0].Info.UTXO.TxID))
+	require.Equal(t, common.HexToAddress(receiver), common.Address(deposits[0].Info.Receiver))
+	require.EqualValues(t, 100, deposits[0].FirstSeen)
+
+	// the parsed txs are not fetched again, and the ones left the mempool are dropped
+	s.update([]string{txB, txC}, getTx, 200)
+	require.Equal(t, 3, fetched)
+	deposits = s.list()
+	require.Len(t, deposits, 1)
+	require.Equal(t, common.HexToHash(txB), common.Hash(deposits[0].Info.UTXO.TxID))
+	require.EqualValues(t, 200, deposits[0].FirstSeen)
+
+	// a tx failed to fetch is retried
+	s.update([]string{txB, txC, "ff"}, getTx, 300)
+	s.update([]string{txB, txC, "ff"}, getTx, 400)
+	require.Equal(t, 5, fetched)
+
+	// the mempool is parsed again with the new covenant
+	s.setCovenants(covenant, common.HexToAddress("0x02"))
+	require.Empty(t, s.list())
+	s.update([]string{txB, txC}, getTx, 500)
+	require.Equal(t, 7, fetched)
+	require.Len(t, s.list(), 1)
+
+	s.setCovenants(common.Address{}, common.HexToAddress("0x03"))
+	s.update([]string{txB, txC}, getTx, 600)
+	require.Empty(t, s.list())
+}
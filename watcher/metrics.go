@@ -0,0 +1,158 @@
+package watcher
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	cctypes "github.com/smartbch/smartbch/crosschain/types"
+	stakingtypes "github.com/smartbch/smartbch/staking/types"
+)
+
+const (
+	metricsNamespace = "smartbch"
+	metricsSubsystem = "watcher"
+)
+
+// The metrics are registered to the default registry of prometheus, which is served by tendermint
+// when instrumentation.prometheus is enabled, and by the listener of --metrics.addr.
+var (
+	latestFinalizedHeight = newGauge("latest_finalized_height",
+		"The height of the latest finalized BCH block.")
+	mainnetHeight = newGauge("mainnet_height",
+		"The latest height of BCH mainnet reported by the BCH nodes.")
+	mainnetLag = newGauge("mainnet_lag",
+		"The number of BCH blocks after the latest finalized one, including the ones waiting to be finalized.")
+
+	// the nomination metrics of the latest epoch
+	epochStartHeight = newGauge("epoch_start_height",
+		"The BCH mainnet height where the latest epoch starts.")
+	nominatedValidators = newGauge("nominated_validators",
+		"The number of distinct validator pubkeys nominated in the latest epoch.")
+	topValidatorNominationShare = newGauge("top_validator_nomination_share",
+		"The share of the validator nominations received by the top nominee in the latest epoch.")
+	validatorNominations = newGauge("validator_nominations",
+		"The number of validator nominations in the latest epoch.")
+	nominatedMonitors = newGauge("nominated_monitors",
+		"The number of distinct monitor pubkeys nominated in the latest epoch.")
+	topMonitorNominationShare = newGauge("top_monitor_nomination_share",
+		"The share of the monitor nominations received by the top nominee in the latest epoch.")
+	monitorNominations = newGauge("monitor_nominations",
+		"The number of monitor nominations in the latest epoch.")
+
+	epochGap = newGauge("epoch_gap",
+		"The number of epochs delivered to the app but not applied by the staking module yet.")
+	queuedEpochs = newGauge("queued_epochs",
+		"The number of epochs in the channel which are not read by the app yet.")
+	spilledEpochs = newGauge("spilled_epochs",
+		"The number of epochs spilled to disk because the channel is full.")
+
+	discardedBlocks = newGauge("discarded_blocks",
+		"The number of finalized BCH blocks discarded because of reorgs since the node started.")
+	healedBlocks = newGauge("healed_blocks",
+		"The number of finalized BCH blocks missing when building an epoch and fetched again since the node started.")
+
+	ccCollectRoundSeconds = newHistogram("cc_collect_round_seconds",
+		"The time spent by a round of collecting the cross-chain transfers, including the re-scans.",
+		[]float64{0.1, 0.5, 1, 5, 10, 30, 60, 300})
+	fetchWorkers = newGauge("fetch_workers",
+		"The number of the workers fetching the BCH blocks in parallel when catching up.")
+	zmqConnected = newGauge("zmq_connected",
+		"Whether the watcher is subscribed to the ZMQ notifications of the BCH node, 1 for yes.")
+	rpcErrors = newCounterVec("rpc_errors_total",
+		"The number of failed requests to the BCH RPC endpoints, including the health checks.", "endpoint")
+)
+
+func newGauge(name, help string) prometheus.Gauge {
+	g := prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      name,
+		Help:      help,
+	})
+	prometheus.MustRegister(g)
+	return g
+}
+
+func newHistogram(name, help string, buckets []float64) prometheus.Histogram {
+	h := prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      name,
+		Help:      help,
+		Buckets:   buckets,
+	})
+	prometheus.MustRegister(h)
+	return h
+}
+
+func newCounterVec(name, help string, labels ...string) *prometheus.CounterVec {
+	c := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      name,
+		Help:      help,
+	}, labels)
+	prometheus.MustRegister(c)
+	return c
+}
+
+// nominationStats returns the number of nominees, the share of the top one and the sum of counts
+func nominationStats(counts []int64) (nominees int, topShare float64, total int64) {
+	var top int64
+	for _, count := range counts {
+		total += count
+		if count > top {
+			top = count
+		}
+	}
+	if total > 0 {
+		topShare = float64(top) / float64(total)
+	}
+	return len(counts), topShare, total
+}
+
+func recordEpochMetrics(epoch *stakingtypes.Epoch) {
+	counts := make([]int64, len(epoch.Nominations))
+	for i, n := range epoch.Nominations {
+		counts[i] = n.NominatedCount
+	}
+	nominees, topShare, total := nominationStats(counts)
+	epochStartHeight.Set(float64(epoch.StartHeight))
+	nominatedValidators.Set(float64(nominees))
+	topValidatorNominationShare.Set(topShare)
+	validatorNominations.Set(float64(total))
+}
+
+func recordMonitorVoteMetrics(info *cctypes.MonitorVoteInfo) {
+	counts := make([]int64, len(info.Nominations))
+	for i, n := range info.Nominations {
+		counts[i] = n.NominatedCount
+	}
+	nominees, topShare, total := nominationStats(counts)
+	nominatedMonitors.Set(float64(nominees))
+	topMonitorNominationShare.Set(topShare)
+	monitorNominations.Set(float64(total))
+}
+
+func recordEpochGapMetrics(gap EpochGap) {
+	epochGap.Set(float64(gap.Size()))
+	queuedEpochs.Set(float64(gap.Queued))
+}
+
+func recordHeightMetrics(finalized, latestMainnet int64, queued int) {
+	latestFinalizedHeight.Set(float64(finalized))
+	if latestMainnet > 0 {
+		mainnetHeight.Set(float64(latestMainnet))
+		mainnetLag.Set(float64(latestMainnet - finalized))
+	}
+	queuedEpochs.Set(float64(queued))
+}
+
+func recordCcCollectRound(start time.Time) {
+	ccCollectRoundSeconds.Observe(time.Since(start).Seconds())
+}
+
+func recordRpcError(url string) {
+	rpcErrors.WithLabelValues(redactUrl(url)).Inc()
+}
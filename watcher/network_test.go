@@ -0,0 +1,44 @@
+package watcher
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/libs/log"
+
+	"github.com/smartbch/smartbch/param"
+	"github.com/smartbch/smartbch/watcher/types"
+)
+
+func nominatingTx(kind, pubkey string) types.TxInfo {
+	return types.TxInfo{VoutList: []types.Vout{{
+		ScriptPubKey: map[string]interface{}{"asm": "OP_RETURN " + types.Identifier + kind + pubkey},
+	}}}
+}
+
+func TestToBCHBlockOnRegtest(t *testing.T) {
+	validator := "11111111111111111111111111111111111111111111111111111111111111aa"
+	monitor := "02" + validator
+	bi := &types.BlockInfo{
+		Height: 2,
+		Tx: []types.TxInfo{
+			{}, // the coinbase tx of generatetoaddress
+			nominatingTx(types.Validator, validator),
+			nominatingTx(types.Monitor, monitor),
+		},
+	}
+
+	blk, err := toBCHBlock(bi, log.NewNopLogger())
+	require.NoError(t, err)
+	require.Empty(t, blk.Nominations)
+	require.Empty(t, blk.CCNominations)
+
+	require.NoError(t, param.SelectBchNetwork(param.RegtestBchNetwork))
+	defer func() { require.NoError(t, param.SelectBchNetwork("")) }()
+	blk, err = toBCHBlock(bi, log.NewNopLogger())
+	require.NoError(t, err)
+	require.Len(t, blk.Nominations, 1)
+	require.Equal(t, byte(0xaa), blk.Nominations[0].Pubkey[31])
+	require.Len(t, blk.CCNominations, 1)
+	require.Equal(t, byte(0x02), blk.CCNominations[0].Pubkey[0])
+}
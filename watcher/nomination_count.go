@@ -0,0 +1,114 @@
+package watcher
+
+import (
+	"math/big"
+
+	"github.com/smartbch/smartbch/param"
+	stakingtypes "github.com/smartbch/smartbch/staking/types"
+	"github.com/smartbch/smartbch/watcher/types"
+)
+
+// NominationCountRules are the anti-sybil rules applied when the nominations of the BCH blocks in an
+// epoch are summed, such that the elected validators reflect the sustained support of the miners
+// instead of a burst of blocks from a single one
+type NominationCountRules struct {
+	// the max nominations counted for the blocks whose coinbase txs pay to the same script, the
+	// later ones are ignored. Zero means no cap, see param.MaxNominationsPerCoinbase for why it is
+	// per miner.
+	MaxPerCoinbase int64
+	// the pubkeys nominated by fewer blocks are not counted
+	MinBlocks int64
+	// redistribute the counted nominations in proportion to the work of the blocks, keeping their
+	// sum except for the rounding down
+	HashpowerWeighting bool
+}
+
+// CurrNominationCountRules returns the rules of this network, which apply to the epochs starting at or
+// after param.NominationRulesForkMainnetHeight
+func CurrNominationCountRules() NominationCountRules {
+	return NominationCountRules{
+		MaxPerCoinbase:     param.MaxNominationsPerCoinbase,
+		MinBlocks:          param.MinNominationBlocks,
+		HashpowerWeighting: param.NominationHashpowerWeighting,
+	}
+}
+
+type nominationTally struct {
+	count      int64
+	blocks     int64
+	lastHeight int64
+	work       *big.Int
+}
+
+// Count sums the nominations of the blocks, which must be in the order of their heights
+func (r NominationCountRules) Count(blocks []*types.BCHBlock) []*stakingtypes.Nomination {
+	perCoinbase := make(map[string]int64)
+	tallies := make(map[[32]byte]*nominationTally)
+	for _, blk := range blocks {
+		work := blockWork(blk.Bits)
+		for _, n := range blk.Nominations {
+			count := n.NominatedCount
+			if r.MaxPerCoinbase > 0 && count > r.MaxPerCoinbase-perCoinbase[blk.Coinbase] {
+				count = r.MaxPerCoinbase - perCoinbase[blk.Coinbase]
+			}
+			if count <= 0 {
+				continue
+			}
+			perCoinbase[blk.Coinbase] += count
+			t, ok := tallies[n.Pubkey]
+			if !ok {
+				t = &nominationTally{work: new(big.Int)}
+				tallies[n.Pubkey] = t
+			}
+			t.count += count
+			if t.lastHeight != blk.Height {
+				t.blocks++
+				t.lastHeight = blk.Height
+			}
+			t.work.Add(t.work, new(big.Int).Mul(work, big.NewInt(count)))
+		}
+	}
+	totalCount, totalWork := int64(0), new(big.Int)
+	for pubkey, t := range tallies {
+		if t.blocks < r.MinBlocks {
+			delete(tallies, pubkey)
+			continue
+		}
+		totalCount += t.count
+		totalWork.Add(totalWork, t.work)
+	}
+	nominations := make([]*stakingtypes.Nomination, 0, len(tallies))
+	for pubkey, t := range tallies {
+		count := t.count
+		if r.HashpowerWeighting && totalWork.Sign() > 0 {
+			weighted := new(big.Int).Mul(t.work, big.NewInt(totalCount))
+			count = weighted.Div(weighted, totalWork).Int64()
+		}
+		if count > 0 {
+			nominations = append(nominations, &stakingtypes.Nomination{Pubkey: pubkey, NominatedCount: count})
+		}
+	}
+	return nominations
+}
+
+var maxWork = new(big.Int).Lsh(big.NewInt(1), 256)
+
+// blockWork returns the expected number of hashes to mine a block with the compact target bits,
+// which is zero if bits is invalid
+func blockWork(bits uint32) *big.Int {
+	mantissa := int64(bits & 0x007fffff)
+	exponent := uint(bits >> 24)
+	if bits&0x00800000 != 0 || mantissa == 0 {
+		return new(big.Int)
+	}
+	target := big.NewInt(mantissa)
+	if exponent <= 3 {
+		target.Rsh(target, 8*(3-exponent))
+	} else {
+		target.Lsh(target, 8*(exponent-3))
+	}
+	if target.Sign() == 0 || target.Cmp(maxWork) >= 0 {
+		return new(big.Int)
+	}
+	return target.Div(maxWork, target.Add(target, big.NewInt(1)))
+}
@@ -0,0 +1,79 @@
+package watcher
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	stakingtypes "github.com/smartbch/smartbch/staking/types"
+	"github.com/smartbch/smartbch/watcher/types"
+)
+
+func nominatingBlock(height int64, coinbase string, bits uint32, pubkey byte) *types.BCHBlock {
+	return &types.BCHBlock{
+		Height:      height,
+		Coinbase:    coinbase,
+		Bits:        bits,
+		Nominations: []stakingtypes.Nomination{{Pubkey: [32]byte{pubkey}, NominatedCount: 1}},
+	}
+}
+
+func countsByPubkey(nominations []*stakingtypes.Nomination) map[byte]int64 {
+	counts := make(map[byte]int64)
+	for _, n := range nominations {
+		counts[n.Pubkey[0]] = n.NominatedCount
+	}
+	return counts
+}
+
+func TestNominationCountRules(t *testing.T) {
+	var blocks []*types.BCHBlock
+	// miner a floods pubkey 1, miners b and c support pubkey 2, and miner d nominates pubkey 3 once
+	for h := int64(1); h <= 6; h++ {
+		blocks = append(blocks, nominatingBlock(h, "a", 0x1d00ffff, 1))
+	}
+	blocks = append(blocks, nominatingBlock(7, "b", 0x1d00ffff, 2), nominatingBlock(8, "c", 0x1d00ffff, 2),
+		nominatingBlock(9, "b", 0x1d00ffff, 2), nominatingBlock(10, "d", 0x1d00ffff, 3))
+
+	require.Equal(t, map[byte]int64{1: 6, 2: 3, 3: 1}, countsByPubkey(NominationCountRules{}.Count(blocks)))
+	require.Equal(t, map[byte]int64{1: 2, 2: 3, 3: 1},
+		countsByPubkey(NominationCountRules{MaxPerCoinbase: 2}.Count(blocks)))
+	require.Equal(t, map[byte]int64{1: 2, 2: 3},
+		countsByPubkey(NominationCountRules{MaxPerCoinbase: 2, MinBlocks: 2}.Count(blocks)))
+
+	// the blocks of miner a are mined with half of the work of the others
+	for _, blk := range blocks[:6] {
+		blk.Bits = 0x1d01fffe
+	}
+	// 5 nominations are counted, pubkey 1 has the work of 1 block and pubkey 2 has 3
+	require.Equal(t, map[byte]int64{1: 1, 2: 3},
+		countsByPubkey(NominationCountRules{MaxPerCoinbase: 2, MinBlocks: 2, HashpowerWeighting: true}.Count(blocks)))
+}
+
+func TestNominationCountWellSupported(t *testing.T) {
+	var blocks []*types.BCHBlock
+	// pubkey 1 is nominated by three miners and pubkey 2 by two, each miner mines 4 blocks
+	h := int64(0)
+	for _, miner := range []struct {
+		coinbase string
+		pubkey   byte
+	}{{"a", 1}, {"b", 1}, {"c", 1}, {"d", 2}, {"e", 2}} {
+		for i := 0; i < 4; i++ {
+			h++
+			blocks = append(blocks, nominatingBlock(h, miner.coinbase, 0x1d00ffff, miner.pubkey))
+		}
+	}
+	// the cap is above what a single miner contributes, the pubkeys keep their support instead of
+	// tying at the cap
+	require.Equal(t, map[byte]int64{1: 12, 2: 8},
+		countsByPubkey(NominationCountRules{MaxPerCoinbase: 4, MinBlocks: 2}.Count(blocks)))
+	// a lower cap scales the support of every miner down alike
+	require.Equal(t, map[byte]int64{1: 6, 2: 4},
+		countsByPubkey(NominationCountRules{MaxPerCoinbase: 2, MinBlocks: 2}.Count(blocks)))
+}
+
+func TestBlockWork(t *testing.T) {
+	require.EqualValues(t, 0x100010001, blockWork(0x1d00ffff).Int64())
+	require.Zero(t, blockWork(0).Sign())
+	require.Zero(t, blockWork(0x1d80ffff).Sign()) // negative
+}
@@ -0,0 +1,130 @@
+package watcher
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/smartbch/moeingads/datatree"
+
+	"github.com/smartbch/smartbch/watcher/types"
+)
+
+const (
+	minParallelNum = 2
+	maxParallelNum = 32
+	// the workers are halved when the average latency of a batch is above this duration, and one
+	// more is added when it is below the half of it
+	targetFetchLatency = 2 * time.Second
+	// each batch has this many blocks per worker, such that the latency is measured often
+	fetchBatchPerWorker = 4
+	// a worker fetches a block at most this many times, the blocks still missing are re-fetched
+	// one by one by refetchBadBlocks
+	maxFetchAttempts = 3
+	// the retries of a worker wait at most this duration
+	maxFetchRetryInterval = 8 * time.Second
+)
+
+// fetchStats measures the fetches of a batch, it is safe for concurrent use
+type fetchStats struct {
+	mtx      sync.Mutex
+	count    int
+	total    time.Duration
+	failures int
+}
+
+func (s *fetchStats) record(latency time.Duration, ok bool) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.count++
+	s.total += latency
+	if !ok {
+		s.failures++
+	}
+}
+
+func (s *fetchStats) average() time.Duration {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if s.count == 0 {
+		return 0
+	}
+	return s.total / time.Duration(s.count)
+}
+
+// nextParallelNum returns the number of the workers for the next batch: halved when the BCH nodes
+// are slow or failing, because more concurrent requests only make it worse, and increased by one
+// when they are fast
+func nextParallelNum(n int, avgLatency time.Duration, failures int) int {
+	switch {
+	case failures > 0 || avgLatency > targetFetchLatency:
+		n /= 2
+	case avgLatency < targetFetchLatency/2:
+		n++
+	}
+	if n < minParallelNum {
+		return minParallelNum
+	}
+	if n > maxParallelNum {
+		return maxParallelNum
+	}
+	return n
+}
+
+// parallelFetchBlocks fetches the blocks in [heightStart, heightEnd] in batches, adjusting the
+// number of the workers by the latency of the previous batch, and adds the valid ones before the
+// first hole as finalized blocks
+func (watcher *Watcher) parallelFetchBlocks(heightStart, heightEnd int64) {
+	var blockSet = make([]*types.BCHBlock, heightEnd-heightStart+1)
+	parallelNum := watcher.parallelNum
+	for batchStart := int64(0); batchStart < int64(len(blockSet)) && !watcher.stopped(); {
+		batchEnd := batchStart + int64(parallelNum*fetchBatchPerWorker)
+		if batchEnd > int64(len(blockSet)) {
+			batchEnd = int64(len(blockSet))
+		}
+		stats := &fetchStats{}
+		sharedIdx := batchStart - 1
+		datatree.ParallelRun(parallelNum, func(_ int) {
+			for {
+				index := atomic.AddInt64(&sharedIdx, 1)
+				if index >= batchEnd || watcher.stopped() {
+					break
+				}
+				blockSet[index] = watcher.fetchBlockWithRetry(heightStart+index, stats)
+			}
+		})
+		batchStart = batchEnd
+		if n := nextParallelNum(parallelNum, stats.average(), stats.failures); n != parallelNum {
+			watcher.logger.Debug("adjust parallel fetch workers", "from", parallelNum, "to", n,
+				"avgLatency", stats.average(), "failures", stats.failures)
+			parallelNum = n
+		}
+		fetchWorkers.Set(float64(parallelNum))
+	}
+	// the holes are filled one by one, the blocks after a hole which cannot be re-fetched are
+	// left to the normal catchup
+	validCount := watcher.refetchBadBlocks(blockSet, heightStart)
+	for _, blk := range blockSet[:validCount] {
+		if err := watcher.addFinalizedBlock(blk); err != nil {
+			// left to the normal catchup, which fetches the missing blocks again
+			watcher.logger.Error("cannot add the fetched block", "height", blk.Height, "err", err)
+			break
+		}
+	}
+	watcher.logger.Debug("Get bch mainnet blocks parallel", "latestFinalizedHeight", watcher.GetLatestFinalizedHeight())
+}
+
+// fetchBlockWithRetry fetches the block at height for at most maxFetchAttempts times, it does not
+// let the RPC client retry forever, such that a failing block does not hold a worker
+func (watcher *Watcher) fetchBlockWithRetry(height int64, stats *fetchStats) *types.BCHBlock {
+	b := newRetryBackoff(maxFetchRetryInterval)
+	for attempt := 1; ; attempt++ {
+		start := time.Now()
+		blk := watcher.rpcClient.GetBlockByHeight(height, false)
+		ok := blk != nil && blk.Height == height
+		stats.record(time.Since(start), ok)
+		if ok || attempt == maxFetchAttempts || !watcher.suspended(b.next(0)) {
+			return blk
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package watcher
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/libs/log"
+
+	"github.com/smartbch/smartbch/param"
+)
+
+func TestNextParallelNum(t *testing.T) {
+	require.Equal(t, 11, nextParallelNum(10, 100*time.Millisecond, 0))
+	require.Equal(t, 10, nextParallelNum(10, 1500*time.Millisecond, 0))
+	require.Equal(t, 5, nextParallelNum(10, 3*time.Second, 0))
+	require.Equal(t, 5, nextParallelNum(10, 100*time.Millisecond, 1))
+	require.Equal(t, minParallelNum, nextParallelNum(minParallelNum, 3*time.Second, 0))
+	require.Equal(t, maxParallelNum, nextParallelNum(maxParallelNum, 0, 0))
+}
+
+func TestFetchBlockWithRetry(t *testing.T) {
+	w := NewWatcher(log.NewNopLogger(), nil, 0, 0, param.DefaultConfig())
+	w.rpcClient = &flakyRpcClient{
+		MockRpcClient: MockRpcClient{node: buildMockBCHNodeWithOnlyValidator1()},
+		failHeights:   map[int64]bool{5: true},
+	}
+	stats := &fetchStats{}
+	blk := w.fetchBlockWithRetry(5, stats)
+	require.EqualValues(t, 5, blk.Height)
+	require.Equal(t, 2, stats.count)
+	require.Equal(t, 1, stats.failures)
+
+	// gives up after maxFetchAttempts, the block is left to refetchBadBlocks
+	stats = &fetchStats{}
+	require.Nil(t, w.fetchBlockWithRetry(200, stats))
+	require.Equal(t, maxFetchAttempts, stats.count)
+}
@@ -0,0 +1,65 @@
+package watcher
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// NewProxiedHttpClient returns the http client used to connect the RPC endpoints through proxyUrl,
+// which can be an HTTP(S) proxy, or a SOCKS5 proxy such as Tor (socks5://127.0.0.1:9050). The host
+// names are resolved by the SOCKS5 proxy, so .onion endpoints can be reached. When proxyUrl is
+// empty, the proxies specified by the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables
+// are used, the same as http.DefaultClient.
+func NewProxiedHttpClient(proxyUrl string) (*http.Client, error) {
+	if proxyUrl == "" {
+		return http.DefaultClient, nil
+	}
+	u, err := parseProxyUrl(proxyUrl)
+	if err != nil {
+		return nil, err
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = http.ProxyURL(u)
+	return &http.Client{Transport: transport}, nil
+}
+
+func parseProxyUrl(proxyUrl string) (*url.URL, error) {
+	u, err := url.Parse(proxyUrl)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy url: %w", err)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5":
+	default:
+		return nil, fmt.Errorf("unsupported proxy scheme %q, it must be http, https or socks5", u.Scheme)
+	}
+	if u.Host == "" {
+		return nil, errors.New("proxy url has no host: " + proxyUrl)
+	}
+	return u, nil
+}
+
+// CheckProxyConfig returns an error if an .onion endpoint is not reached through a SOCKS5 proxy
+func CheckProxyConfig(proxyUrl string, endpoints ...string) error {
+	var isSocks5 bool
+	if proxyUrl != "" {
+		u, err := parseProxyUrl(proxyUrl)
+		if err != nil {
+			return err
+		}
+		isSocks5 = u.Scheme == "socks5"
+	}
+	for _, endpoint := range endpoints {
+		u, err := url.Parse(endpoint)
+		if err != nil || !strings.HasSuffix(u.Hostname(), ".onion") {
+			continue
+		}
+		if !isSocks5 {
+			return errors.New("a SOCKS5 proxy (such as Tor) is needed for the .onion endpoint: " + u.Hostname())
+		}
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package watcher
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewProxiedHttpClient(t *testing.T) {
+	c, err := NewProxiedHttpClient("")
+	require.NoError(t, err)
+	require.Equal(t, http.DefaultClient, c)
+
+	c, err = NewProxiedHttpClient("socks5://127.0.0.1:9050")
+	require.NoError(t, err)
+	req, _ := http.NewRequest("POST", "http://abcdefg.onion:8332", nil)
+	proxyUrl, err := c.Transport.(*http.Transport).Proxy(req)
+	require.NoError(t, err)
+	require.Equal(t, "socks5://127.0.0.1:9050", proxyUrl.String())
+
+	_, err = NewProxiedHttpClient("ftp://127.0.0.1:21")
+	require.Error(t, err)
+	_, err = NewProxiedHttpClient("http://")
+	require.Error(t, err)
+}
+
+func TestCheckProxyConfig(t *testing.T) {
+	require.NoError(t, CheckProxyConfig("", "http://127.0.0.1:8332", ""))
+	require.Error(t, CheckProxyConfig("", "http://abcdefg.onion:8332"))
+	require.Error(t, CheckProxyConfig("http://127.0.0.1:3128", "http://abcdefg.onion:8332"))
+	require.NoError(t, CheckProxyConfig("socks5://127.0.0.1:9050", "http://abcdefg.onion:8332"))
+}
@@ -0,0 +1,134 @@
+package watcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// the first retry of a failed request waits about this duration, and each following retry
+	// waits twice as long, up to the max retry interval
+	minRetryInterval        = 2 * time.Second
+	defaultMaxRetryInterval = time.Minute
+	// a BCH node responding 429 without Retry-After is not used in this duration
+	defaultRetryAfter = 10 * time.Second
+)
+
+// rateLimiter is a token bucket which limits the requests sent to a BCH node. It allows a burst
+// of one second of requests. It is safe for concurrent use.
+type rateLimiter struct {
+	mtx       sync.Mutex
+	perSecond float64
+	tokens    float64
+	last      time.Time
+}
+
+// newRateLimiter returns nil if perSecond is not positive, which means unlimited
+func newRateLimiter(perSecond float64) *rateLimiter {
+	if perSecond <= 0 {
+		return nil
+	}
+	return &rateLimiter{perSecond: perSecond, tokens: burstOf(perSecond)}
+}
+
+func burstOf(perSecond float64) float64 {
+	if perSecond < 1 {
+		return 1
+	}
+	return perSecond
+}
+
+// reserve takes a token, and returns how long the caller must wait before sending the request
+func (l *rateLimiter) reserve(now time.Time) time.Duration {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	if !l.last.IsZero() {
+		l.tokens += now.Sub(l.last).Seconds() * l.perSecond
+		if burst := burstOf(l.perSecond); l.tokens > burst {
+			l.tokens = burst
+		}
+	}
+	l.last = now
+	l.tokens--
+	if l.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-l.tokens / l.perSecond * float64(time.Second))
+}
+
+// wait blocks until a request can be sent, it returns false if ctx is done meanwhile
+func (l *rateLimiter) wait(ctx context.Context) bool {
+	if l == nil {
+		return ctx.Err() == nil
+	}
+	d := l.reserve(time.Now())
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+	return sleepWithContext(ctx, d)
+}
+
+// retryBackoff is the exponential backoff with jitter of retrying a request, the jitter keeps the
+// watchers of many nodes from retrying a recovered BCH node at the same time
+type retryBackoff struct {
+	max     time.Duration
+	attempt uint
+}
+
+func newRetryBackoff(max time.Duration) *retryBackoff {
+	if max < minRetryInterval {
+		max = minRetryInterval
+	}
+	return &retryBackoff{max: max}
+}
+
+// next returns the duration to wait before the next retry, which is at least minWait
+func (b *retryBackoff) next(minWait time.Duration) time.Duration {
+	d := minRetryInterval << b.attempt
+	if d > b.max || d <= 0 {
+		d = b.max
+	} else {
+		b.attempt++
+	}
+	// a random duration in [d/2, d]
+	d = d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+	if d < minWait {
+		d = minWait
+	}
+	return d
+}
+
+// rateLimitedError is returned when a BCH node responds 429
+type rateLimitedError struct {
+	retryAfter time.Duration
+}
+
+func (e *rateLimitedError) Error() string {
+	return fmt.Sprintf("rate limited by the BCH node, retry after %s", e.retryAfter)
+}
+
+// retryAfterOf returns the duration the BCH node asks to wait before retrying, or zero
+func retryAfterOf(err error) time.Duration {
+	var rateLimited *rateLimitedError
+	if errors.As(err, &rateLimited) {
+		return rateLimited.retryAfter
+	}
+	return 0
+}
+
+// parseRetryAfter parses the Retry-After header, in seconds or as an http date
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil && t.After(now) {
+		return t.Sub(now)
+	}
+	return defaultRetryAfter
+}
@@ -0,0 +1,56 @@
+package watcher
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/libs/log"
+)
+
+func TestRateLimiter(t *testing.T) {
+	require.Nil(t, newRateLimiter(0))
+	l := newRateLimiter(2)
+	now := time.Now()
+	require.Zero(t, l.reserve(now))
+	require.Zero(t, l.reserve(now))
+	require.Equal(t, 500*time.Millisecond, l.reserve(now))
+	require.Equal(t, time.Second, l.reserve(now))
+	// the tokens are refilled, but no more than the burst
+	require.Zero(t, l.reserve(now.Add(time.Hour)))
+	require.Zero(t, l.reserve(now.Add(time.Hour)))
+	require.Equal(t, 500*time.Millisecond, l.reserve(now.Add(time.Hour)))
+}
+
+func TestRetryBackoff(t *testing.T) {
+	b := newRetryBackoff(10 * time.Second)
+	for _, max := range []time.Duration{2, 4, 8, 10, 10} {
+		d := b.next(0)
+		require.True(t, d >= max*time.Second/2 && d <= max*time.Second, d)
+	}
+	require.Equal(t, time.Minute, b.next(time.Minute))
+
+	require.Equal(t, 30*time.Second, parseRetryAfter("30", time.Now()))
+	now := time.Now()
+	require.Equal(t, 2*time.Minute, parseRetryAfter(now.Add(2*time.Minute).UTC().Format(http.TimeFormat),
+		now.Truncate(time.Second)))
+	require.Equal(t, defaultRetryAfter, parseRetryAfter("", now))
+}
+
+func TestRateLimitedEndpoint(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "120")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+	client := NewRpcClient(server.URL, "", "", "text/plain;", log.NewNopLogger())
+	client.SetRateLimit(100)
+	now := time.Now()
+	_, err := client.sendRequest(ReqStrBlockCount)
+	require.Equal(t, 2*time.Minute, retryAfterOf(err))
+	e := client.endpoints.endpoints[0]
+	require.False(t, e.available(now.Add(time.Minute)))
+	require.True(t, e.available(now.Add(3*time.Minute)))
+}
@@ -0,0 +1,146 @@
+package watcher
+
+import (
+	"sync/atomic"
+	"time"
+
+	cctypes "github.com/smartbch/smartbch/crosschain/types"
+	stakingtypes "github.com/smartbch/smartbch/staking/types"
+)
+
+// an epoch is published after its last block is buried under this number of blocks more than a
+// finalized block, and the last block is still found on the main chain at that time
+const epochPublishExtraDepth = 1
+
+type pendingEpoch struct {
+	epoch     *stakingtypes.Epoch
+	info      *cctypes.MonitorVoteInfo
+	endHeight int64
+}
+
+// publishEpochs sends the pending epochs deep enough to the app. When the last block of an epoch
+// is not on the main chain anymore, the reorg is handled and the epoch is discarded without being
+// published.
+func (watcher *Watcher) publishEpochs(latestMainnetHeight int64) {
+	for {
+		watcher.state.mtx.RLock()
+		if len(watcher.state.pendingEpochs) == 0 {
+			watcher.state.mtx.RUnlock()
+			return
+		}
+		p := watcher.state.pendingEpochs[0]
+		stored := watcher.state.heightToFinalizedBlock[p.endHeight]
+		watcher.state.mtx.RUnlock()
+
+		if latestMainnetHeight < p.endHeight+watcher.blockFinalizeNumber+epochPublishExtraDepth {
+			return
+		}
+		canonical := watcher.rpcClient.GetBlockByHeight(p.endHeight, true)
+		if canonical == nil || canonical.Height != p.endHeight {
+			return // try again later
+		}
+		if stored != nil && canonical.HashId != stored.HashId {
+			watcher.handleReorg(p.endHeight)
+			return
+		}
+
+		watcher.state.mtx.Lock()
+		if len(watcher.state.pendingEpochs) == 0 || watcher.state.pendingEpochs[0] != p {
+			// replaced by the gossiped epochs meanwhile
+			watcher.state.mtx.Unlock()
+			continue
+		}
+		watcher.state.pendingEpochs = watcher.state.pendingEpochs[1:]
+		watcher.state.mtx.Unlock()
+		// send outside the lock, because the readers must not wait for the consumer of the channels
+		recordEpochMetrics(p.epoch)
+		if !watcher.sendEpoch(p.epoch) {
+			return
+		}
+		watcher.epochArchive.add(atomic.AddInt64(&watcher.deliveredEpochNum, 1), p.epoch, p.info)
+		if p.info != nil {
+			recordMonitorVoteMetrics(p.info)
+			if !watcher.sendMonitorVoteInfo(p.info) {
+				return
+			}
+		}
+	}
+}
+
+// handleReorg is called when the finalized block at height may have been reorged out of the main
+// chain. It walks back to the highest finalized block which is still on the main chain, discards
+// the ones above it with the epochs built from them, and returns its height, from which the main
+// chain is fetched again. It returns height if the block at height is still on the main chain,
+// which means the conflicting block was served from a stale branch.
+//
+// The published epochs can not be taken back, because they may have been applied by the app. If
+// a reorg is deeper than the last published epoch, the new blocks are accepted after it and an
+// error is logged.
+func (watcher *Watcher) handleReorg(height int64) int64 {
+	publishedEnd := watcher.publishedEpochEndHeight()
+	fork := height
+	for ; fork > 0; fork-- {
+		stored := watcher.getFinalizedBlock(fork)
+		if stored == nil {
+			break // cleared or before startup, the check can not go deeper
+		}
+		canonical := watcher.rpcClient.GetBlockByHeight(fork, true)
+		if canonical == nil || canonical.Height != fork {
+			return height // the BCH node is not available, try again later
+		}
+		if canonical.HashId == stored.HashId {
+			break
+		}
+		if fork <= publishedEnd {
+			watcher.logger.Error("BCH reorg is deeper than the published epoch, accepting the main chain",
+				"height", fork, "publishedEpochEndHeight", publishedEnd)
+			watcher.state.mtx.Lock()
+			watcher.state.heightToFinalizedBlock[fork] = canonical
+			watcher.state.mtx.Unlock()
+			break
+		}
+	}
+	if fork == height {
+		return height
+	}
+	watcher.logger.Error("BCH reorg detected, discarding finalized blocks", "forkHeight", fork,
+		"discardedBlocks", height-fork)
+	discardedBlocks.Add(float64(height - fork))
+	watcher.analytics.addFork(fork, height-fork, time.Now().Unix())
+	watcher.state.mtx.Lock()
+	watcher.rollbackTo(fork)
+	watcher.state.mtx.Unlock()
+	return fork
+}
+
+// publishedEpochEndHeight returns the last height of the blocks used by the published epochs
+func (watcher *Watcher) publishedEpochEndHeight() int64 {
+	watcher.state.mtx.RLock()
+	defer watcher.state.mtx.RUnlock()
+	if len(watcher.state.pendingEpochs) != 0 {
+		return watcher.state.pendingEpochs[0].epoch.StartHeight - 1
+	}
+	return watcher.state.lastEpochEndHeight
+}
+
+// rollbackTo discards the finalized blocks above height and the pending epochs built from them,
+// state.mtx must be held by the caller
+func (watcher *Watcher) rollbackTo(height int64) {
+	s := &watcher.state
+	for h := s.latestFinalizedHeight; h > height; h-- {
+		delete(s.heightToFinalizedBlock, h)
+	}
+	s.latestFinalizedHeight = height
+	if blk, ok := s.heightToFinalizedBlock[height]; ok {
+		s.currentMainnetBlockTimestamp = blk.Timestamp
+	}
+	for n := len(s.pendingEpochs); n > 0 && s.pendingEpochs[n-1].endHeight > height; n-- {
+		p := s.pendingEpochs[n-1]
+		watcher.logger.Info("discard the epoch built from reorged blocks", "startHeight", p.epoch.StartHeight)
+		s.pendingEpochs = s.pendingEpochs[:n-1]
+		s.lastEpochEndHeight = p.epoch.StartHeight - 1
+		if k := len(s.voteInfoList); k > 0 && s.voteInfoList[k-1].Epoch.StartHeight == p.epoch.StartHeight {
+			s.voteInfoList = s.voteInfoList[:k-1]
+		}
+	}
+}
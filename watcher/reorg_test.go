@@ -0,0 +1,70 @@
+package watcher
+
+import (
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/libs/log"
+
+	"github.com/smartbch/smartbch/param"
+)
+
+// reorgFrom replaces the blocks at and above height with the ones of another branch
+func reorgFrom(node *MockBCHNode, height int64, branch byte) {
+	for h := height; h <= node.height; h++ {
+		blk := *node.blocks[h-1]
+		blk.HashId = [32]byte{byte(h), branch}
+		if h > height {
+			blk.ParentBlk = node.blocks[h-2].HashId
+		}
+		node.blocks[h-1] = &blk
+	}
+}
+
+func TestHandleReorg(t *testing.T) {
+	node := buildMockBCHNodeWithOnlyValidator1()
+	w := NewWatcher(log.NewNopLogger(), nil, 0, 0, param.DefaultConfig())
+	w.SetBlockFinalizeNumber(1)
+	w.SetNumBlocksInEpoch(10)
+	w.rpcClient = MockRpcClient{node: node}
+	for h := int64(1); h <= 25; h++ {
+		w.addFinalizedBlock(node.blocks[h-1])
+	}
+	// the epoch ending at 20 is not deep enough
+	w.publishEpochs(21)
+	require.Len(t, w.EpochChan, 1)
+	require.Len(t, w.state.pendingEpochs, 1)
+	require.Equal(t, int64(10), w.publishedEpochEndHeight())
+
+	// the blocks since 18 are reorged, the pending epoch is discarded
+	reorgFrom(node, 18, 0xaa)
+	w.publishEpochs(30)
+	require.Len(t, w.EpochChan, 1)
+	require.Empty(t, w.state.pendingEpochs)
+	numVoteInfos, _, latestFinalizedHeight := getStateForTest(w)
+	require.Equal(t, 1, numVoteInfos)
+	require.Equal(t, int64(17), latestFinalizedHeight)
+	require.Equal(t, int64(10), w.state.lastEpochEndHeight)
+	require.Nil(t, w.getFinalizedBlock(18))
+
+	// the block of a stale branch is ignored
+	require.Equal(t, int64(17), w.handleReorg(17))
+
+	// the new branch builds the epoch again
+	for h := int64(18); h <= 20; h++ {
+		w.addFinalizedBlock(node.blocks[h-1])
+	}
+	w.publishEpochs(30)
+	require.Len(t, w.EpochChan, 2)
+	require.EqualValues(t, 2, atomic.LoadInt64(&w.deliveredEpochNum))
+
+	// a reorg deeper than the published epochs only discards the blocks after them
+	for h := int64(21); h <= 25; h++ {
+		w.addFinalizedBlock(node.blocks[h-1])
+	}
+	reorgFrom(node, 19, 0xbb)
+	require.Equal(t, int64(20), w.handleReorg(25))
+	require.Equal(t, int64(20), w.GetLatestFinalizedHeight())
+	require.Equal(t, [32]byte{20, 0xbb}, w.getFinalizedBlock(20).HashId)
+}
@@ -0,0 +1,119 @@
+package watcher
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/tendermint/tendermint/libs/log"
+
+	"github.com/smartbch/smartbch/watcher/types"
+)
+
+const replayFileExt = ".json"
+
+// ExportBlock saves the verbose block to dir as <height>.json, for ReplayClient. Only the coinbase
+// tx, from which the nominations are parsed, is kept unless full is true, and the full blocks
+// are needed to replay the collection of the cross-chain transfers.
+func ExportBlock(dir string, bi *types.BlockInfo, full bool) error {
+	if !full && len(bi.Tx) > 1 {
+		trimmed := *bi
+		trimmed.Tx = bi.Tx[:1]
+		trimmed.RawTx = nil
+		bi = &trimmed
+	}
+	bz, err := json.Marshal(bi)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, strconv.FormatInt(bi.Height, 10)+replayFileExt), bz, 0644)
+}
+
+// ReplayClient serves the blocks exported by ExportBlock instead of a BCH node, such that the
+// epochs and the monitor votes built from them can be reproduced offline. The exported blocks
+// must be at consecutive heights, and the latest one is regarded as the tip of the chain.
+type ReplayClient struct {
+	dir    string
+	first  int64
+	latest int64
+	logger log.Logger
+}
+
+var _ types.RpcClient = (*ReplayClient)(nil)
+
+func NewReplayClient(dir string, logger log.Logger) (*ReplayClient, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var heights []int64
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, replayFileExt) {
+			continue
+		}
+		h, err := strconv.ParseInt(strings.TrimSuffix(name, replayFileExt), 10, 64)
+		if err != nil {
+			continue
+		}
+		heights = append(heights, h)
+	}
+	if len(heights) == 0 {
+		return nil, fmt.Errorf("no block is exported in %s", dir)
+	}
+	sort.Slice(heights, func(i, j int) bool { return heights[i] < heights[j] })
+	for i := 1; i < len(heights); i++ {
+		if heights[i] != heights[i-1]+1 {
+			return nil, fmt.Errorf("the block at height %d is missing in %s", heights[i-1]+1, dir)
+		}
+	}
+	return &ReplayClient{dir: dir, first: heights[0], latest: heights[len(heights)-1], logger: logger}, nil
+}
+
+// FirstHeight returns the height of the first exported block
+func (client *ReplayClient) FirstHeight() int64 {
+	return client.first
+}
+
+func (client *ReplayClient) GetLatestHeight(_ bool) int64 {
+	return client.latest
+}
+
+func (client *ReplayClient) GetBlockByHeight(height int64, _ bool) *types.BCHBlock {
+	bi := client.GetBlockInfoByHeight(height, false)
+	if bi == nil {
+		return nil
+	}
+	blk, err := toBCHBlock(bi, client.logger)
+	if err != nil {
+		client.logger.Error("cannot parse the exported block", "height", height, "error", err)
+		return nil
+	}
+	return blk
+}
+
+func (client *ReplayClient) GetBlockInfoByHeight(height int64, _ bool) *types.BlockInfo {
+	if height < client.first || height > client.latest {
+		return nil
+	}
+	bz, err := os.ReadFile(filepath.Join(client.dir, strconv.FormatInt(height, 10)+replayFileExt))
+	if err != nil {
+		client.logger.Error("cannot read the exported block", "height", height, "error", err)
+		return nil
+	}
+	var bi types.BlockInfo
+	if err = json.Unmarshal(bz, &bi); err != nil {
+		client.logger.Error("cannot decode the exported block", "height", height, "error", err)
+		return nil
+	}
+	return &bi
+}
+
+// GetVoteInfoByEpochNumber returns nothing, the epochs are always rebuilt from the blocks
+func (client *ReplayClient) GetVoteInfoByEpochNumber(_, _ uint64) []*types.VoteInfo {
+	return nil
+}
@@ -0,0 +1,68 @@
+package watcher
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/libs/log"
+
+	"github.com/smartbch/smartbch/param"
+	"github.com/smartbch/smartbch/watcher/types"
+)
+
+func buildReplayBlock(height int64, pubkey []byte) *types.BlockInfo {
+	coinbase := types.TxInfo{TxID: "00", VoutList: []types.Vout{
+		{N: 0, ScriptPubKey: map[string]interface{}{"asm": "OP_RETURN " + types.Identifier + types.Validator + hex.EncodeToString(pubkey)}},
+	}}
+	return &types.BlockInfo{
+		Hash:              hex.EncodeToString(bytes.Repeat([]byte{byte(height)}, 32)),
+		PreviousBlockhash: hex.EncodeToString(bytes.Repeat([]byte{byte(height - 1)}, 32)),
+		Height:            height,
+		Time:              height * 600,
+		Tx:                []types.TxInfo{coinbase, {TxID: "01"}},
+	}
+}
+
+func TestReplayClient(t *testing.T) {
+	dir := t.TempDir()
+	pubkey := bytes.Repeat([]byte{0xab}, 32)
+	for h := int64(1); h <= 30; h++ {
+		require.NoError(t, ExportBlock(dir, buildReplayBlock(h, pubkey), h == 30))
+	}
+	client, err := NewReplayClient(dir, log.NewNopLogger())
+	require.NoError(t, err)
+	require.EqualValues(t, 1, client.FirstHeight())
+	require.EqualValues(t, 30, client.GetLatestHeight(true))
+	require.Len(t, client.GetBlockInfoByHeight(1, true).Tx, 1)
+	require.Len(t, client.GetBlockInfoByHeight(30, true).Tx, 2)
+	require.Nil(t, client.GetBlockByHeight(31, true))
+	blk := client.GetBlockByHeight(5, true)
+	require.EqualValues(t, 5, blk.Height)
+	require.EqualValues(t, 3000, blk.Timestamp)
+	require.Equal(t, bytes.Repeat([]byte{4}, 32), blk.ParentBlk[:])
+	require.Equal(t, pubkey, blk.Nominations[0].Pubkey[:])
+
+	config := param.DefaultConfig()
+	config.AppConfig.WatcherReplayDir = dir
+	config.AppConfig.WatcherEpochSpillPath = ""
+	w := NewWatcher(log.NewNopLogger(), nil, 0, 0, config)
+	w.SetNumBlocksInEpoch(10)
+	w.SetBlockFinalizeNumber(1)
+	go w.Run()
+	w.WaitCatchup()
+	require.True(t, w.Stop())
+	require.EqualValues(t, 29, w.GetLatestFinalizedHeight())
+	require.Len(t, w.EpochChan, 2)
+	for _, startHeight := range []int64{1, 11} {
+		epoch := <-w.EpochChan
+		require.Equal(t, startHeight, epoch.StartHeight)
+		require.Equal(t, pubkey, epoch.Nominations[0].Pubkey[:])
+	}
+
+	// the exported blocks must be consecutive
+	require.NoError(t, ExportBlock(dir, buildReplayBlock(32, pubkey), false))
+	_, err = NewReplayClient(dir, log.NewNopLogger())
+	require.Error(t, err)
+}
@@ -1,12 +1,15 @@
 package watcher
 
 import (
+	"context"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common/hexutil"
@@ -25,15 +28,30 @@ const (
 	ReqStrBlock     = `{"jsonrpc": "1.0", "id":"smartbch", "method": "getblock", "params": ["%s",2] }`
 	ReqStrTx        = `{"jsonrpc": "1.0", "id":"smartbch", "method": "getrawtransaction", "params": ["%s", true, "%s"] }`
 	ReqStrVoteInfos = `{"jsonrpc": "2.0", "method": "sbch_getVoteInfos", "params": ["%s","%s"], "id":1}`
+	ReqStrTxOut     = `{"jsonrpc": "1.0", "id":"smartbch", "method": "gettxout", "params": ["%s", %d, false] }`
+	ReqStrRawTx     = `{"jsonrpc": "1.0", "id":"smartbch", "method": "getrawtransaction", "params": ["%s", false] }`
+	ReqStrSendRawTx = `{"jsonrpc": "1.0", "id":"smartbch", "method": "sendrawtransaction", "params": ["%s"] }`
+	ReqStrRawBlock  = `{"jsonrpc": "1.0", "id":"smartbch", "method": "getblock", "params": ["%s",0] }`
+	ReqStrMempool   = `{"jsonrpc": "1.0", "id":"smartbch", "method": "getrawmempool", "params": [] }`
+	ReqStrMempoolTx = `{"jsonrpc": "1.0", "id":"smartbch", "method": "getrawtransaction", "params": ["%s", true] }`
+
+	// the vote infos signed by a validator, see epoch_gossip.go
+	ReqStrSignedVoteInfos = `{"jsonrpc": "2.0", "method": "sbch_getSignedVoteInfos", "params": ["%s"], "id":1}`
 )
 
 type RpcClient struct {
-	url         string
+	endpoints   *endpointPool
 	user        string
 	password    string
 	err         error
 	contentType string
 	logger      log.Logger
+	httpClient  *http.Client
+	ctx         context.Context // the requests and the retries are canceled when it is done
+	// the failed requests are retried with exponential backoff up to this interval
+	maxRetryInterval time.Duration
+	// reads the blocks pruned by the BCH nodes, nil if not set
+	archive *archiveRouter
 }
 
 var _ types.RpcClient = (*RpcClient)(nil)
@@ -42,17 +60,71 @@ func NewRpcClient(url, user, password, contentType string, logger log.Logger) *R
 	if url == "" {
 		return nil
 	}
+	return NewRpcClientWithUrls([]string{url}, user, password, contentType, logger)
+}
+
+// NewRpcClientWithUrls returns a client which balances the requests among urls and fails over
+// to another url when one fails. The credentials in a url override user and password.
+func NewRpcClientWithUrls(urls []string, user, password, contentType string, logger log.Logger) *RpcClient {
+	if len(urls) == 0 {
+		return nil
+	}
 	return &RpcClient{
-		url:         url,
+		endpoints:   newEndpointPool(urls),
 		user:        user,
 		password:    password,
 		contentType: contentType,
 		logger:      logger,
+		httpClient:  http.DefaultClient,
+		ctx:         context.Background(),
+
+		maxRetryInterval: defaultMaxRetryInterval,
+	}
+}
+
+// SetContext lets the requests and the retries be canceled, such as when the watcher stops
+func (client *RpcClient) SetContext(ctx context.Context) {
+	if client == nil {
+		return
+	}
+	client.ctx = ctx
+}
+
+// SetRateLimit limits the requests sent to each BCH node, zero means unlimited
+func (client *RpcClient) SetRateLimit(perSecond float64) {
+	if client != nil {
+		client.endpoints.setRateLimit(perSecond)
+	}
+}
+
+// SetMaxRetryInterval sets the max interval of retrying a failed request
+func (client *RpcClient) SetMaxRetryInterval(d time.Duration) {
+	if client != nil && d > 0 {
+		client.maxRetryInterval = d
+	}
+}
+
+func (client *RpcClient) newRetryBackoff() *retryBackoff {
+	return newRetryBackoff(client.maxRetryInterval)
+}
+
+// retryLater waits before retrying a request failed with err, it returns false if the client is
+// canceled
+func (client *RpcClient) retryLater(b *retryBackoff, err error) bool {
+	return sleepWithContext(client.ctx, b.next(retryAfterOf(err)))
+}
+
+// SetHttpClient changes the http client used to send the requests, such as the one connecting
+// through a proxy
+func (client *RpcClient) SetHttpClient(httpClient *http.Client) {
+	if client != nil {
+		client.httpClient = httpClient
 	}
 }
 
 func (client *RpcClient) GetLatestHeight(retry bool) (height int64) {
 	height = -1
+	b := client.newRetryBackoff()
 	for height == -1 {
 		height = client.getCurrHeight()
 		if !retry {
@@ -60,7 +132,9 @@ func (client *RpcClient) GetLatestHeight(retry bool) (height int64) {
 		}
 		if client.err != nil {
 			client.logger.Debug("GetLatestHeight failed", client.err.Error())
-			time.Sleep(10 * time.Second)
+			if !client.retryLater(b, client.err) {
+				return -1
+			}
 		}
 	}
 	return
@@ -70,8 +144,12 @@ func (client *RpcClient) GetBlockByHeight(height int64, retry bool) *types.BCHBl
 	//if height == 1529565 {
 	//	return &types.BCHBlock{Height: height, Timestamp: 1670225100}
 	//}
+	if archive := client.archiveFor(height); archive != nil {
+		return archive.GetBlockByHeight(height, retry)
+	}
 	var hash string
 	var err error
+	b := client.newRetryBackoff()
 	var blk *types.BCHBlock
 	for hash == "" {
 		hash, err = client.getBlockHashOfHeight(height)
@@ -80,19 +158,26 @@ func (client *RpcClient) GetBlockByHeight(height int64, retry bool) *types.BCHBl
 				return nil
 			}
 			client.logger.Debug(fmt.Sprintf("getBlockHashOfHeight %d failed", height), err.Error())
-			time.Sleep(10 * time.Second)
+			if !client.retryLater(b, err) {
+				return nil
+			}
 			continue
 		}
 		fmt.Printf("get bch block hash\n")
 	}
 	for blk == nil {
 		blk, err = client.getBCHBlock(hash)
+		if archive := client.onPrunedBlock(height, err); archive != nil {
+			return archive.GetBlockByHeight(height, retry)
+		}
 		if !retry {
 			return blk
 		}
 		if err != nil {
 			client.logger.Debug(fmt.Sprintf("getBCHBlock %d failed", height), err.Error())
-			time.Sleep(10 * time.Second)
+			if !client.retryLater(b, err) {
+				return nil
+			}
 			continue
 		}
 		fmt.Printf("get bch block: %d\n", height)
@@ -104,8 +189,12 @@ func (client *RpcClient) GetBlockInfoByHeight(height int64, retry bool) *types.B
 	//if height == 1529565 {
 	//	return &types.BlockInfo{}
 	//}
+	if archive := client.archiveFor(height); archive != nil {
+		return archive.GetBlockInfoByHeight(height, retry)
+	}
 	var hash string
 	var err error
+	b := client.newRetryBackoff()
 	var blk *types.BlockInfo
 	for hash == "" {
 		hash, err = client.getBlockHashOfHeight(height)
@@ -114,19 +203,26 @@ func (client *RpcClient) GetBlockInfoByHeight(height int64, retry bool) *types.B
 				return nil
 			}
 			client.logger.Debug(fmt.Sprintf("GetBlockInfoByHeight %d failed", height), err.Error())
-			time.Sleep(10 * time.Second)
+			if !client.retryLater(b, err) {
+				return nil
+			}
 			continue
 		}
 		fmt.Printf("get bch block info hash\n")
 	}
 	for blk == nil {
 		blk, err = client.getBlock(hash)
+		if archive := client.onPrunedBlock(height, err); archive != nil {
+			return archive.GetBlockInfoByHeight(height, retry)
+		}
 		if !retry {
 			return blk
 		}
 		if err != nil {
 			client.logger.Debug(fmt.Sprintf("getBCHBlockInfo %d failed", height), err.Error())
-			time.Sleep(10 * time.Second)
+			if !client.retryLater(b, err) {
+				return nil
+			}
 			continue
 		}
 		fmt.Printf("get bch block info: %d\n", height)
@@ -136,43 +232,142 @@ func (client *RpcClient) GetBlockInfoByHeight(height int64, retry bool) *types.B
 
 func (client *RpcClient) GetVoteInfoByEpochNumber(start, end uint64) []*types.VoteInfo {
 	var infos []*types.VoteInfo
+	b := client.newRetryBackoff()
 	for infos == nil {
 		infos = client.getVoteInfos(start, end)
 		if client.err != nil {
 			client.logger.Debug("GetVoteInfoByEpochNumber failed", client.err.Error())
-			time.Sleep(10 * time.Second)
+			if !client.retryLater(b, client.err) {
+				return nil
+			}
 		}
 	}
 	return infos
 }
 
+// sendRequest tries the endpoints one by one until one of them responds
 func (client *RpcClient) sendRequest(reqStr string) ([]byte, error) {
+	var err error
+	for _, e := range client.endpoints.candidates(time.Now()) {
+		var respData []byte
+		respData, err = client.sendRequestTo(e, reqStr)
+		if err == nil {
+			client.endpoints.reportSuccess(e)
+			return respData, nil
+		}
+		client.reportFailure(e, err)
+		recordRpcError(e.url)
+		client.logger.Debug("BCH RPC endpoint failed", "url", redactUrl(e.url), "err", err.Error())
+	}
+	return nil, err
+}
+
+func (client *RpcClient) reportFailure(e *endpoint, err error) {
+	if retryAfter := retryAfterOf(err); retryAfter > 0 {
+		client.endpoints.reportRateLimited(e, time.Now(), retryAfter)
+	} else {
+		client.endpoints.reportFailure(e, time.Now())
+	}
+}
+
+func (client *RpcClient) sendRequestTo(e *endpoint, reqStr string) ([]byte, error) {
+	if !e.limiter.wait(client.ctx) {
+		return nil, client.ctx.Err()
+	}
 	body := strings.NewReader(reqStr)
-	req, err := http.NewRequest("POST", client.url, body)
+	req, err := http.NewRequestWithContext(client.ctx, "POST", e.url, body)
 	if err != nil {
 		return nil, err
 	}
-	req.SetBasicAuth(client.user, client.password)
+	if req.URL.User != nil {
+		password, _ := req.URL.User.Password()
+		req.SetBasicAuth(req.URL.User.Username(), password)
+	} else {
+		req.SetBasicAuth(client.user, client.password)
+	}
 	req.Header.Set("Content-Type", client.contentType)
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := client.httpClient.Do(req)
 	if err != nil {
 		return nil, err
 	}
-	respData, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
+	defer resp.Body.Close()
+	// bitcoind responds the RPC errors with 404 or 500, which are parsed by the callers, while
+	// these ones mean the endpoint itself does not work
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests:
+		return nil, &rateLimitedError{retryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())}
+	case http.StatusUnauthorized, http.StatusForbidden, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return nil, fmt.Errorf("http status %d", resp.StatusCode)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// StartHealthCheck queries the heights of all the endpoints every interval in the background,
+// such that the ones lagging behind are skipped and the failed ones are found before a request
+// is sent to them. It is only useful when there are more than one endpoints.
+func (client *RpcClient) StartHealthCheck(interval time.Duration) {
+	if client == nil || len(client.endpoints.endpoints) < 2 {
+		return
+	}
+	go func() {
+		for {
+			client.checkHealth()
+			if !sleepWithContext(client.ctx, interval) {
+				return
+			}
+		}
+	}()
+}
+
+func (client *RpcClient) checkHealth() {
+	endpoints := client.endpoints.endpoints
+	heights := make([]int64, len(endpoints))
+	var wg sync.WaitGroup
+	for i, e := range endpoints {
+		wg.Add(1)
+		go func(i int, e *endpoint) {
+			defer wg.Done()
+			heights[i] = -1
+			respData, err := client.sendRequestTo(e, ReqStrBlockCount)
+			var blockCountResp types.BlockCountResp
+			if err == nil {
+				err = json.Unmarshal(respData, &blockCountResp)
+			}
+			if err == nil && blockCountResp.Error != nil {
+				err = fmt.Errorf("code:%d, msg:%s", blockCountResp.Error.Code, blockCountResp.Error.Message)
+			}
+			if err != nil {
+				client.reportFailure(e, err)
+				recordRpcError(e.url)
+				client.logger.Info("BCH RPC endpoint is unhealthy", "url", redactUrl(e.url), "err", err.Error())
+				return
+			}
+			client.endpoints.reportSuccess(e)
+			heights[i] = blockCountResp.Result
+		}(i, e)
 	}
-	resp.Body.Close()
-	return respData, nil
+	wg.Wait()
+	client.endpoints.updateHeights(heights)
+}
+
+// EndpointStatus returns the health of the endpoints, with the passwords hidden
+func (client *RpcClient) EndpointStatus() []EndpointStatus {
+	return client.endpoints.status(time.Now())
 }
 
 func (client *RpcClient) getBCHBlock(hash string) (*types.BCHBlock, error) {
-	var bi *types.BlockInfo
-	var err error
-	bi, err = client.getBlock(hash)
+	bi, err := client.getBlock(hash)
 	if err != nil {
 		return nil, err
 	}
+	return toBCHBlock(bi, client.logger)
+}
+
+// toBCHBlock extracts the nominations from the coinbase tx of a verbose block, or from all of its
+// txs on the networks whose coinbase txs cannot carry them
+func toBCHBlock(bi *types.BlockInfo, logger log.Logger) (*types.BCHBlock, error) {
+	var err error
 	bchBlock := &types.BCHBlock{
 		Height:    bi.Height,
 		Timestamp: bi.Time,
@@ -188,16 +383,27 @@ func (client *RpcClient) getBCHBlock(hash string) (*types.BCHBlock, error) {
 	if err != nil {
 		return nil, err
 	}
+	if bits, err := strconv.ParseUint(bi.Bits, 16, 32); err == nil {
+		bchBlock.Bits = uint32(bits)
+	}
 	if bi.Height > 0 {
-		nomination := getNomination(bi.Tx[0])
-		if nomination != nil {
-			bchBlock.Nominations = append(bchBlock.Nominations, *nomination)
+		bchBlock.Coinbase = getCoinbaseScript(bi.Tx[0])
+		network := param.CurrBchNetwork()
+		txs := bi.Tx[:1]
+		if network.NominationsInAllTxs {
+			txs = bi.Tx
 		}
-		if bi.Height >= param.StartMainnetHeightForCC {
-			ccNomination := getCCNomination(bi.Tx[0])
-			if ccNomination != nil {
-				client.logger.Debug("get new cc nomination", "pubkey", hex.EncodeToString(ccNomination.Pubkey[:]))
-				bchBlock.CCNominations = append(bchBlock.CCNominations, *ccNomination)
+		for _, tx := range txs {
+			nomination := getNomination(tx)
+			if nomination != nil {
+				bchBlock.Nominations = append(bchBlock.Nominations, *nomination)
+			}
+			if bi.Height >= network.StartHeightForCC {
+				ccNomination := getCCNomination(tx)
+				if ccNomination != nil {
+					logger.Debug("get new cc nomination", "pubkey", hex.EncodeToString(ccNomination.Pubkey[:]))
+					bchBlock.CCNominations = append(bchBlock.CCNominations, *ccNomination)
+				}
 			}
 		}
 	}
@@ -215,6 +421,20 @@ func getNomination(coinbase types.TxInfo) *stakingtypes.Nomination {
 	return nil
 }
 
+// getCoinbaseScript returns the hex of the first output script of the coinbase tx which pays, the
+// nominations are capped per this script since NominationRulesForkMainnetHeight
+func getCoinbaseScript(coinbase types.TxInfo) string {
+	for _, vout := range coinbase.VoutList {
+		if vout.Value <= 0 {
+			continue
+		}
+		if script, ok := vout.ScriptPubKey["hex"].(string); ok {
+			return script
+		}
+	}
+	return ""
+}
+
 func getCCNomination(coinbase types.TxInfo) *cctypes.Nomination {
 	pubKey, ok := coinbase.GetMonitorPubKey()
 	if ok {
@@ -354,6 +574,81 @@ func (client *RpcClient) GetTxInfo(hash string, blockhash string) (*types.TxInfo
 	return client.getTx(hash, blockhash)
 }
 
+// GetTxOut returns nil if the output is spent or does not exist
+func (client *RpcClient) GetTxOut(txid string, vout uint32) (*types.TxOut, error) {
+	respData, err := client.sendRequest(fmt.Sprintf(ReqStrTxOut, txid, vout))
+	if err != nil {
+		return nil, err
+	}
+	var txOutResp types.TxOutResp
+	err = json.Unmarshal(respData, &txOutResp)
+	if err != nil {
+		return nil, err
+	}
+	if txOutResp.Error != nil && txOutResp.Error.Code < 0 {
+		return nil, fmt.Errorf("getTxOut error, code:%d, msg:%s\n",
+			txOutResp.Error.Code, txOutResp.Error.Message)
+	}
+	return txOutResp.Result, nil
+}
+
+// GetRawTx returns the serialized transaction, the node must have txindex enabled to find the
+// confirmed transactions
+func (client *RpcClient) GetRawTx(txid string) ([]byte, error) {
+	var txHex string
+	if err := client.call(fmt.Sprintf(ReqStrRawTx, txid), &txHex); err != nil {
+		return nil, err
+	}
+	return hex.DecodeString(txHex)
+}
+
+// GetRawBlock returns the serialized block
+func (client *RpcClient) GetRawBlock(hash string) ([]byte, error) {
+	var blockHex string
+	if err := client.call(fmt.Sprintf(ReqStrRawBlock, hash), &blockHex); err != nil {
+		return nil, err
+	}
+	return hex.DecodeString(blockHex)
+}
+
+// SendRawTx broadcasts a serialized transaction and returns its txid
+func (client *RpcClient) SendRawTx(tx []byte) (string, error) {
+	var txid string
+	err := client.call(fmt.Sprintf(ReqStrSendRawTx, hex.EncodeToString(tx)), &txid)
+	return txid, err
+}
+
+// GetRawMempool returns the txids in the mempool of the BCH node
+func (client *RpcClient) GetRawMempool() ([]string, error) {
+	var txids []string
+	err := client.call(ReqStrMempool, &txids)
+	return txids, err
+}
+
+// GetMempoolTx returns the decoded tx in the mempool, which has no blockhash
+func (client *RpcClient) GetMempoolTx(txid string) (*types.TxInfo, error) {
+	var tx types.TxInfo
+	if err := client.call(fmt.Sprintf(ReqStrMempoolTx, txid), &tx); err != nil {
+		return nil, err
+	}
+	return &tx, nil
+}
+
+func (client *RpcClient) call(reqStr string, result interface{}) error {
+	respData, err := client.sendRequest(reqStr)
+	if err != nil {
+		return err
+	}
+	var m smartBchJsonrpcMessage
+	if err = json.Unmarshal(respData, &m); err != nil {
+		return err
+	}
+	if m.Error != nil {
+		return fmt.Errorf("rpc error, code:%d, msg:%s", m.Error.Code, m.Error.Message)
+	}
+	return json.Unmarshal(m.Result, result)
+}
+
 type MockClient struct {
 	BlockInfos map[int64]*types.BlockInfo
 }
@@ -0,0 +1,185 @@
+package watcher
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/gcash/bchd/txscript"
+	"github.com/gcash/bchd/wire"
+
+	"github.com/smartbch/smartbch/param"
+	"github.com/smartbch/smartbch/watcher/types"
+)
+
+// SelfTestVector is the known result of parsing a BCH block
+type SelfTestVector struct {
+	Height           int64    `json:"height"`
+	Hash             string   `json:"hash"`
+	ValidatorPubkeys []string `json:"validatorPubkeys"` // hex, without 0x
+	MonitorPubkeys   []string `json:"monitorPubkeys"`
+}
+
+// SelfTestResult lists the differences found in a block, it passes if Errors is empty
+type SelfTestResult struct {
+	Height      int64    `json:"height"`
+	Hash        string   `json:"hash"`
+	Txs         int      `json:"txs"`
+	OpReturns   int      `json:"opReturns"`
+	Nominations int      `json:"nominations"`
+	Errors      []string `json:"errors"`
+}
+
+func (r *SelfTestResult) fail(format string, args ...interface{}) {
+	r.Errors = append(r.Errors, fmt.Sprintf(format, args...))
+}
+
+// SelfTest parses the block at height in the way the watcher does, from the verbose getblock
+// result, and compares the result with the one parsed independently from the serialized block,
+// so the incompatibilities of the BCH node, such as a different asm format, are found before they
+// corrupt the epochs. The result is also checked against vector if it is not nil.
+func (client *RpcClient) SelfTest(height int64, vector *SelfTestVector) *SelfTestResult {
+	result := &SelfTestResult{Height: height}
+	hash, err := client.getBlockHashOfHeight(height)
+	if err != nil {
+		result.fail("getblockhash: %s", err)
+		return result
+	}
+	result.Hash = hash
+	bi, err := client.getBlock(hash)
+	if err != nil {
+		result.fail("getblock verbose: %s", err)
+		return result
+	}
+	rawBlock, err := client.GetRawBlock(hash)
+	if err != nil {
+		result.fail("getblock raw: %s", err)
+		return result
+	}
+	msgBlock := &wire.MsgBlock{}
+	if err = msgBlock.Deserialize(bytes.NewReader(rawBlock)); err != nil {
+		result.fail("cannot deserialize the raw block: %s", err)
+		return result
+	}
+	compareBlocks(result, bi, msgBlock)
+	bchBlock, err := toBCHBlock(bi, client.logger)
+	if err != nil {
+		result.fail("cannot parse the verbose block: %s", err)
+		return result
+	}
+	if vector != nil {
+		checkVector(result, bchBlock, vector)
+	}
+	return result
+}
+
+// compareBlocks checks the verbose block against the raw one: the header, the txs and the
+// OP_RETURN outputs, which carry the nominations and the cross-chain memos
+func compareBlocks(result *SelfTestResult, bi *types.BlockInfo, msgBlock *wire.MsgBlock) {
+	if bi.Hash != msgBlock.BlockHash().String() {
+		result.fail("hash mismatch: verbose %s, raw %s", bi.Hash, msgBlock.BlockHash())
+	}
+	if bi.Height > 0 && bi.PreviousBlockhash != msgBlock.Header.PrevBlock.String() {
+		result.fail("parent hash mismatch: verbose %s, raw %s", bi.PreviousBlockhash, msgBlock.Header.PrevBlock)
+	}
+	if bi.Time != msgBlock.Header.Timestamp.Unix() {
+		result.fail("time mismatch: verbose %d, raw %d", bi.Time, msgBlock.Header.Timestamp.Unix())
+	}
+	result.Txs = len(msgBlock.Transactions)
+	if len(bi.Tx) != len(msgBlock.Transactions) {
+		result.fail("tx count mismatch: verbose %d, raw %d", len(bi.Tx), len(msgBlock.Transactions))
+		return
+	}
+	for i, tx := range msgBlock.Transactions {
+		txInfo := bi.Tx[i]
+		if txInfo.TxID != tx.TxHash().String() {
+			result.fail("txid mismatch at tx %d: verbose %s, raw %s", i, txInfo.TxID, tx.TxHash())
+			continue
+		}
+		if len(txInfo.VoutList) != len(tx.TxOut) {
+			result.fail("vout count mismatch in tx %s", txInfo.TxID)
+			continue
+		}
+		for j, out := range tx.TxOut {
+			if txscript.GetScriptClass(out.PkScript) != txscript.NullDataTy {
+				continue
+			}
+			result.OpReturns++
+			pushes, err := txscript.PushedData(out.PkScript)
+			if err != nil || len(pushes) != 1 {
+				continue // the watcher only parses the OP_RETURN outputs with a single push
+			}
+			asm, _ := txInfo.VoutList[j].ScriptPubKey["asm"].(string)
+			if !strings.HasPrefix(asm, "OP_RETURN ") {
+				result.fail("unexpected asm of OP_RETURN output %s:%d: %q", txInfo.TxID, j, asm)
+				continue
+			}
+			data, err := hex.DecodeString(asm[len("OP_RETURN "):])
+			if err != nil || !bytes.Equal(data, pushes[0]) {
+				result.fail("asm of OP_RETURN output %s:%d does not match the script: %q", txInfo.TxID, j, asm)
+			}
+		}
+	}
+	if bi.Height <= 0 {
+		return
+	}
+	validator, monitor := nominationsInCoinbase(msgBlock.Transactions[0])
+	pubkey, ok := bi.Tx[0].GetValidatorPubKey()
+	if ok != (validator != nil) || (ok && !bytes.Equal(pubkey[:], validator)) {
+		result.fail("validator nomination mismatch: verbose %x, raw %x", pubkey, validator)
+	}
+	if ok {
+		result.Nominations++
+	}
+	if bi.Height >= param.CurrBchNetwork().StartHeightForCC {
+		monitorPubkey, ok := bi.Tx[0].GetMonitorPubKey()
+		if ok != (monitor != nil) || (ok && !bytes.Equal(monitorPubkey[:], monitor)) {
+			result.fail("monitor nomination mismatch: verbose %x, raw %x", monitorPubkey, monitor)
+		}
+		if ok {
+			result.Nominations++
+		}
+	}
+}
+
+// nominationsInCoinbase returns the first validator and monitor nominated by the coinbase tx,
+// parsed from the scripts
+func nominationsInCoinbase(coinbase *wire.MsgTx) (validator, monitor []byte) {
+	identifier, _ := hex.DecodeString(types.Identifier)
+	for _, out := range coinbase.TxOut {
+		if txscript.GetScriptClass(out.PkScript) != txscript.NullDataTy {
+			continue
+		}
+		pushes, err := txscript.PushedData(out.PkScript)
+		if err != nil || len(pushes) != 1 || !bytes.HasPrefix(pushes[0], identifier) {
+			continue
+		}
+		data := pushes[0][len(identifier):]
+		if len(data) == 1+32 && data[0] == 0 && validator == nil {
+			validator = data[1:]
+		} else if len(data) == 1+33 && data[0] == 1 && monitor == nil {
+			monitor = data[1:]
+		}
+	}
+	return
+}
+
+func checkVector(result *SelfTestResult, blk *types.BCHBlock, vector *SelfTestVector) {
+	if vector.Hash != "" && !strings.EqualFold(vector.Hash, hex.EncodeToString(blk.HashId[:])) {
+		result.fail("hash is %x, expected %s", blk.HashId, vector.Hash)
+	}
+	var validators, monitors []string
+	for _, n := range blk.Nominations {
+		validators = append(validators, hex.EncodeToString(n.Pubkey[:]))
+	}
+	for _, n := range blk.CCNominations {
+		monitors = append(monitors, hex.EncodeToString(n.Pubkey[:]))
+	}
+	if strings.Join(validators, ",") != strings.ToLower(strings.Join(vector.ValidatorPubkeys, ",")) {
+		result.fail("validator nominations are %v, expected %v", validators, vector.ValidatorPubkeys)
+	}
+	if strings.Join(monitors, ",") != strings.ToLower(strings.Join(vector.MonitorPubkeys, ",")) {
+		result.fail("monitor nominations are %v, expected %v", monitors, vector.MonitorPubkeys)
+	}
+}
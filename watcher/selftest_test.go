@@ -0,0 +1,93 @@
+package watcher
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/gcash/bchd/chaincfg/chainhash"
+	"github.com/gcash/bchd/txscript"
+	"github.com/gcash/bchd/wire"
+	"github.com/stretchr/testify/require"
+
+	stakingtypes "github.com/smartbch/smartbch/staking/types"
+	"github.com/smartbch/smartbch/watcher/types"
+)
+
+func buildSelfTestBlock(t *testing.T, height int64, pubkey []byte) (*types.BlockInfo, *wire.MsgBlock) {
+	data, _ := hex.DecodeString(types.Identifier + types.Validator)
+	script, err := txscript.NewScriptBuilder().AddOp(txscript.OP_RETURN).AddData(append(data, pubkey...)).Script()
+	require.NoError(t, err)
+	coinbase := wire.NewMsgTx(1)
+	coinbase.AddTxIn(wire.NewTxIn(&wire.OutPoint{Index: 0xffffffff}, []byte{0x51}))
+	coinbase.AddTxOut(wire.NewTxOut(625000000, []byte{txscript.OP_TRUE}))
+	coinbase.AddTxOut(wire.NewTxOut(0, script))
+	msgBlock := wire.NewMsgBlock(&wire.BlockHeader{
+		Version:   1,
+		PrevBlock: chainhash.Hash{1, 2, 3},
+		Timestamp: time.Unix(1650000000, 0),
+	})
+	require.NoError(t, msgBlock.AddTransaction(coinbase))
+
+	var buf bytes.Buffer
+	require.NoError(t, msgBlock.Serialize(&buf))
+	bi := &types.BlockInfo{
+		Hash:              msgBlock.BlockHash().String(),
+		Height:            height,
+		Time:              msgBlock.Header.Timestamp.Unix(),
+		PreviousBlockhash: msgBlock.Header.PrevBlock.String(),
+	}
+	txInfo := types.TxInfo{TxID: coinbase.TxHash().String()}
+	for i, out := range coinbase.TxOut {
+		asm, err := txscript.DisasmString(out.PkScript)
+		require.NoError(t, err)
+		txInfo.VoutList = append(txInfo.VoutList, types.Vout{N: i, ScriptPubKey: map[string]interface{}{"asm": asm}})
+	}
+	bi.Tx = append(bi.Tx, txInfo)
+	return bi, msgBlock
+}
+
+func TestCompareBlocks(t *testing.T) {
+	pubkey := bytes.Repeat([]byte{0xab}, 32)
+	bi, msgBlock := buildSelfTestBlock(t, 100, pubkey)
+	result := &SelfTestResult{}
+	compareBlocks(result, bi, msgBlock)
+	require.Empty(t, result.Errors)
+	require.Equal(t, 1, result.OpReturns)
+	require.Equal(t, 1, result.Nominations)
+
+	validator, monitor := nominationsInCoinbase(msgBlock.Transactions[0])
+	require.Equal(t, pubkey, validator)
+	require.Nil(t, monitor)
+
+	// a BCH node which formats the asm differently must be caught
+	bi.Tx[0].VoutList[1].ScriptPubKey["asm"] = "OP_RETURN 32 " + hex.EncodeToString(pubkey)
+	result = &SelfTestResult{}
+	compareBlocks(result, bi, msgBlock)
+	require.Len(t, result.Errors, 2)
+
+	bi, msgBlock = buildSelfTestBlock(t, 100, pubkey)
+	bi.Time++
+	result = &SelfTestResult{}
+	compareBlocks(result, bi, msgBlock)
+	require.Len(t, result.Errors, 1)
+}
+
+func TestCheckVector(t *testing.T) {
+	blk := &types.BCHBlock{HashId: [32]byte{0x12}}
+	nomination := stakingtypes.Nomination{NominatedCount: 1}
+	copy(nomination.Pubkey[:], bytes.Repeat([]byte{0xab}, 32))
+	blk.Nominations = append(blk.Nominations, nomination)
+	vector := &SelfTestVector{
+		Hash:             hex.EncodeToString(blk.HashId[:]),
+		ValidatorPubkeys: []string{hex.EncodeToString(bytes.Repeat([]byte{0xAB}, 32))},
+	}
+	result := &SelfTestResult{}
+	checkVector(result, blk, vector)
+	require.Empty(t, result.Errors)
+
+	vector.MonitorPubkeys = []string{"02"}
+	checkVector(result, blk, vector)
+	require.Len(t, result.Errors, 1)
+}
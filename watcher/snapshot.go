@@ -0,0 +1,114 @@
+package watcher
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/smartbch/smartbch/watcher/types"
+)
+
+// An epoch snapshot is the JSON array of the vote infos of the consecutive epochs, exported by a
+// synced node. It is trusted by the sha256 hash of the file configured as watcher-snapshot-hash,
+// which the operator gets from a source they trust, so the snapshot itself needs no signature.
+
+// WriteEpochSnapshot saves the vote infos to path and returns the hash of the file
+func WriteEpochSnapshot(path string, infos []*types.VoteInfo) ([32]byte, error) {
+	if err := checkSnapshotEpochs(infos); err != nil {
+		return [32]byte{}, err
+	}
+	bz, err := json.Marshal(infos)
+	if err != nil {
+		return [32]byte{}, err
+	}
+	if err = os.WriteFile(path, bz, 0644); err != nil {
+		return [32]byte{}, err
+	}
+	return sha256.Sum256(bz), nil
+}
+
+// LoadEpochSnapshot reads the vote infos saved by WriteEpochSnapshot, after checking the hash of
+// the file against hexHash
+func LoadEpochSnapshot(path, hexHash string) ([]*types.VoteInfo, error) {
+	expected, err := hex.DecodeString(strings.TrimPrefix(hexHash, "0x"))
+	if err != nil || len(expected) != sha256.Size {
+		return nil, fmt.Errorf("invalid snapshot hash: %s", hexHash)
+	}
+	bz, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if hash := sha256.Sum256(bz); !bytes.Equal(hash[:], expected) {
+		return nil, fmt.Errorf("the hash of the snapshot is %s, not %s", hex.EncodeToString(hash[:]), hexHash)
+	}
+	var infos []*types.VoteInfo
+	if err = json.Unmarshal(bz, &infos); err != nil {
+		return nil, err
+	}
+	if err = checkSnapshotEpochs(infos); err != nil {
+		return nil, err
+	}
+	return infos, nil
+}
+
+// ReadEpochSnapshot reads the vote infos saved by WriteEpochSnapshot without checking its hash,
+// which is only fine for the offline analyses
+func ReadEpochSnapshot(path string) ([]*types.VoteInfo, error) {
+	bz, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var infos []*types.VoteInfo
+	if err = json.Unmarshal(bz, &infos); err != nil {
+		return nil, err
+	}
+	return infos, checkSnapshotEpochs(infos)
+}
+
+// checkSnapshotEpochs makes sure the epochs are consecutive, both by number and by height
+func checkSnapshotEpochs(infos []*types.VoteInfo) error {
+	if len(infos) == 0 {
+		return fmt.Errorf("no epoch in the snapshot")
+	}
+	for i := 1; i < len(infos); i++ {
+		prev, curr := &infos[i-1].Epoch, &infos[i].Epoch
+		if curr.Number != prev.Number+1 || curr.StartHeight <= prev.StartHeight {
+			return fmt.Errorf("the epoch %d does not follow the epoch %d", curr.Number, prev.Number)
+		}
+	}
+	return nil
+}
+
+// loadEpochSnapshot applies the epochs in the snapshot which follow the last known epoch, it returns
+// the number of them, and false if the watcher is stopped
+func (watcher *Watcher) loadEpochSnapshot(path, hexHash string, start uint64) (int, bool) {
+	infos, err := LoadEpochSnapshot(path, hexHash)
+	if err != nil {
+		watcher.logger.Error("cannot load the epoch snapshot", "path", path, "error", err.Error())
+		return 0, true
+	}
+	first, last := infos[0].Epoch.Number, infos[len(infos)-1].Epoch.Number
+	if int64(start) < first || int64(start) > last {
+		watcher.logger.Info("the epoch snapshot is not used", "first", first, "last", last, "wanted", start)
+		return 0, true
+	}
+	infos = infos[int64(start)-first:]
+	watcher.state.mtx.RLock()
+	lastEnd := watcher.state.lastEpochEndHeight
+	watcher.state.mtx.RUnlock()
+	if infos[0].Epoch.StartHeight != lastEnd+1 {
+		watcher.logger.Error("the epoch snapshot does not follow the last epoch",
+			"startHeight", infos[0].Epoch.StartHeight, "lastEpochEndHeight", lastEnd)
+		return 0, true
+	}
+	if !watcher.applyVoteInfos(infos) {
+		return 0, false
+	}
+	watcher.logger.Info("loaded the epoch snapshot", "epochs", len(infos),
+		"latestFinalizedHeight", watcher.GetLatestFinalizedHeight())
+	return len(infos), true
+}
@@ -0,0 +1,61 @@
+package watcher
+
+import (
+	"encoding/hex"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/libs/log"
+
+	"github.com/smartbch/smartbch/param"
+	stakingtypes "github.com/smartbch/smartbch/staking/types"
+	"github.com/smartbch/smartbch/watcher/types"
+)
+
+func buildSnapshotInfos(n int) []*types.VoteInfo {
+	infos := make([]*types.VoteInfo, n)
+	for i := range infos {
+		infos[i] = &types.VoteInfo{Epoch: stakingtypes.Epoch{
+			Number:      int64(i + 1),
+			StartHeight: int64(i*10 + 1),
+			EndTime:     int64(i+1) * 6000,
+			Nominations: []*stakingtypes.Nomination{{Pubkey: [32]byte{byte(i)}, NominatedCount: 10}},
+		}}
+	}
+	return infos
+}
+
+func TestEpochSnapshot(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "epochs.json")
+	hash, err := WriteEpochSnapshot(path, buildSnapshotInfos(3))
+	require.NoError(t, err)
+	hexHash := hex.EncodeToString(hash[:])
+
+	infos, err := LoadEpochSnapshot(path, "0x"+hexHash)
+	require.NoError(t, err)
+	require.Len(t, infos, 3)
+	require.EqualValues(t, 21, infos[2].Epoch.StartHeight)
+	_, err = LoadEpochSnapshot(path, hex.EncodeToString(make([]byte, 32)))
+	require.Error(t, err)
+	_, err = LoadEpochSnapshot(path, "1234")
+	require.Error(t, err)
+
+	gapped := buildSnapshotInfos(3)
+	gapped[2].Epoch.Number = 4
+	_, err = WriteEpochSnapshot(path, gapped)
+	require.Error(t, err)
+
+	w := NewWatcher(log.NewNopLogger(), nil, 0, 0, param.DefaultConfig())
+	w.SetNumBlocksInEpoch(10)
+	// the snapshot does not follow the last epoch
+	n, ok := w.loadEpochSnapshot(path, hexHash, 2)
+	require.True(t, ok)
+	require.Equal(t, 0, n)
+	n, ok = w.loadEpochSnapshot(path, hexHash, 1)
+	require.True(t, ok)
+	require.Equal(t, 3, n)
+	require.EqualValues(t, 30, w.GetLatestFinalizedHeight())
+	require.Len(t, w.EpochChan, 3)
+	require.EqualValues(t, 3, w.GetStatus().DeliveredEpochNum)
+}
@@ -0,0 +1,186 @@
+package watcher
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/smartbch/smartbch/param"
+	"github.com/smartbch/smartbch/watcher/types"
+)
+
+// the speedup gives up after this number of consecutive failed requests, and the watcher builds
+// the remaining epochs from the BCH blocks
+const maxSpeedupFailures = 10
+
+// voteInfoFetcher is implemented by the clients which can fetch the vote infos without retrying,
+// such that the speedup decides how to retry by itself
+type voteInfoFetcher interface {
+	FetchVoteInfos(start, end uint64) ([]*types.VoteInfo, error)
+}
+
+// FetchVoteInfos sends a single request for the vote infos of the epochs in [start, end)
+func (client *RpcClient) FetchVoteInfos(start, end uint64) ([]*types.VoteInfo, error) {
+	infos := client.getVoteInfos(start, end)
+	if client.err != nil {
+		return nil, client.err
+	}
+	return infos, nil
+}
+
+// speedupFromRpc fetches the epochs from start on from smartbch-rpc-url, in batches of at most
+// watcher-speedup-batch-size epochs. A failed request halves the batch, which grows back after
+// the successful ones. The fetched epochs are kept in the cursor file before they are applied, so
+// a restarted speedup resumes from the cursor. It returns false if the watcher is stopped.
+func (watcher *Watcher) speedupFromRpc(start uint64) bool {
+	appConfig := watcher.chainConfig.AppConfig
+	cursor := newSpeedupCursor(appConfig.WatcherSpeedupCursorPath)
+	if cached, err := cursor.load(start); err != nil {
+		watcher.logger.Error("cannot load the speedup cursor", "path", cursor.path, "error", err.Error())
+	} else if len(cached) != 0 {
+		watcher.logger.Info("resume the speedup from the cursor", "epochs", len(cached), "start", start)
+		if !watcher.applyVoteInfos(cached) {
+			return false
+		}
+		start += uint64(len(cached))
+	}
+
+	maxBatch := uint64(appConfig.WatcherSpeedupBatchSize)
+	if maxBatch == 0 {
+		maxBatch = param.DefaultWatcherSpeedupBatchSize
+	}
+	batch := maxBatch
+	failures := 0
+	maxRetryInterval := time.Duration(appConfig.MainnetRPCMaxRetryInterval) * time.Second
+	b := newRetryBackoff(maxRetryInterval)
+	for {
+		infos, err := watcher.fetchVoteInfos(start, start+batch)
+		if err == nil {
+			infos, err = consecutiveVoteInfos(infos, start)
+		}
+		if err != nil {
+			failures++
+			watcher.logger.Info("speedup request failed", "start", start, "batch", batch,
+				"failures", failures, "error", err.Error())
+			if failures >= maxSpeedupFailures {
+				watcher.logger.Error("give up the speedup, build the epochs from the BCH blocks", "start", start)
+				return true
+			}
+			if batch > 1 {
+				batch /= 2
+			}
+			if !sleepWithContext(watcher.life.ctx, b.next(0)) {
+				return false
+			}
+			continue
+		}
+		if len(infos) == 0 {
+			return true
+		}
+		if err = cursor.append(infos); err != nil {
+			watcher.logger.Error("cannot save the speedup cursor", "path", cursor.path, "error", err.Error())
+		}
+		if !watcher.applyVoteInfos(infos) {
+			return false
+		}
+		start += uint64(len(infos))
+		failures = 0
+		b = newRetryBackoff(maxRetryInterval)
+		if batch < maxBatch {
+			batch *= 2
+			if batch > maxBatch {
+				batch = maxBatch
+			}
+		}
+	}
+}
+
+func (watcher *Watcher) fetchVoteInfos(start, end uint64) ([]*types.VoteInfo, error) {
+	if f, ok := watcher.smartBchRpcClient.(voteInfoFetcher); ok {
+		return f.FetchVoteInfos(start, end)
+	}
+	return watcher.smartBchRpcClient.GetVoteInfoByEpochNumber(start, end), nil
+}
+
+// consecutiveVoteInfos keeps the leading vote infos numbered start, start+1 and so on, a partial
+// response is fine, but one not starting from start is an error
+func consecutiveVoteInfos(infos []*types.VoteInfo, start uint64) ([]*types.VoteInfo, error) {
+	for i, info := range infos {
+		if info == nil || info.Epoch.Number != int64(start)+int64(i) {
+			if i == 0 {
+				return nil, fmt.Errorf("the response starts from a wrong epoch, want %d", start)
+			}
+			return infos[:i], nil
+		}
+	}
+	return infos, nil
+}
+
+// speedupCursor is a file of the fetched vote infos, one JSON object per line
+type speedupCursor struct {
+	path string // empty means disabled
+}
+
+func newSpeedupCursor(path string) *speedupCursor {
+	return &speedupCursor{path: path}
+}
+
+// load returns the consecutive vote infos from the epoch start on, and rewrites the file without
+// the ones before start, which are committed already
+func (c *speedupCursor) load(start uint64) ([]*types.VoteInfo, error) {
+	if c.path == "" {
+		return nil, nil
+	}
+	f, err := os.Open(c.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var infos []*types.VoteInfo
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 16*1024*1024)
+	for scanner.Scan() {
+		var info types.VoteInfo
+		if err = json.Unmarshal(scanner.Bytes(), &info); err != nil {
+			break // the last line may be written partially
+		}
+		next := int64(start) + int64(len(infos))
+		if info.Epoch.Number == next {
+			infos = append(infos, &info)
+		} else if info.Epoch.Number > next {
+			break
+		}
+	}
+	_ = f.Close()
+	if err = os.Remove(c.path); err != nil {
+		return nil, err
+	}
+	return infos, c.append(infos)
+}
+
+func (c *speedupCursor) append(infos []*types.VoteInfo) error {
+	if c.path == "" || len(infos) == 0 {
+		return nil
+	}
+	f, err := os.OpenFile(c.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	for _, info := range infos {
+		bz, err := json.Marshal(info)
+		if err != nil {
+			_ = f.Close()
+			return err
+		}
+		_, _ = w.Write(append(bz, '\n'))
+	}
+	if err = w.Flush(); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,79 @@
+package watcher
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/libs/log"
+
+	"github.com/smartbch/smartbch/param"
+	"github.com/smartbch/smartbch/watcher/types"
+)
+
+// flakyVoteInfoClient fails the first request, and returns at most maxReturn infos per request
+type flakyVoteInfoClient struct {
+	MockClient
+	infos     []*types.VoteInfo
+	maxReturn uint64
+	requests  []uint64 // the batch sizes requested
+}
+
+func (c *flakyVoteInfoClient) FetchVoteInfos(start, end uint64) ([]*types.VoteInfo, error) {
+	c.requests = append(c.requests, end-start)
+	if len(c.requests) == 1 {
+		return nil, errors.New("connection reset")
+	}
+	if end > start+c.maxReturn {
+		end = start + c.maxReturn
+	}
+	if end > uint64(len(c.infos))+1 {
+		end = uint64(len(c.infos)) + 1
+	}
+	if start > end {
+		return nil, nil
+	}
+	return c.infos[start-1 : end-1], nil
+}
+
+func TestSpeedupFromRpc(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "speedup.cursor")
+	config := param.DefaultConfig()
+	config.AppConfig.WatcherSpeedupBatchSize = 4
+	config.AppConfig.WatcherSpeedupCursorPath = path
+	w := NewWatcher(log.NewNopLogger(), nil, 0, 0, config)
+	w.SetNumBlocksInEpoch(10)
+	client := &flakyVoteInfoClient{infos: buildSnapshotInfos(7), maxReturn: 3}
+	w.smartBchRpcClient = client
+
+	require.True(t, w.speedupFromRpc(1))
+	require.Equal(t, []uint64{4, 2, 4, 4, 4}, client.requests)
+	require.EqualValues(t, 70, w.GetLatestFinalizedHeight())
+	require.Len(t, w.EpochChan, 7)
+
+	// the restarted speedup resumes from the epochs not committed
+	cursor := newSpeedupCursor(path)
+	infos, err := cursor.load(5)
+	require.NoError(t, err)
+	require.Len(t, infos, 3)
+	require.EqualValues(t, 5, infos[0].Epoch.Number)
+	infos, err = cursor.load(5)
+	require.NoError(t, err)
+	require.Len(t, infos, 3)
+	infos, err = cursor.load(8)
+	require.NoError(t, err)
+	require.Empty(t, infos)
+}
+
+func TestConsecutiveVoteInfos(t *testing.T) {
+	infos := buildSnapshotInfos(4)
+	got, err := consecutiveVoteInfos(infos[1:], 2)
+	require.NoError(t, err)
+	require.Len(t, got, 3)
+	got, err = consecutiveVoteInfos([]*types.VoteInfo{infos[0], infos[1], infos[3]}, 1)
+	require.NoError(t, err)
+	require.Len(t, got, 2)
+	_, err = consecutiveVoteInfos(infos, 2)
+	require.Error(t, err)
+}
@@ -0,0 +1,99 @@
+package watcher
+
+import (
+	"sync"
+
+	"github.com/smartbch/smartbch/crosschain"
+	cctypes "github.com/smartbch/smartbch/crosschain/types"
+	stakingtypes "github.com/smartbch/smartbch/staking/types"
+	"github.com/smartbch/smartbch/watcher/types"
+)
+
+// watcherState holds the fields written by the block fetching goroutine and read by
+// the app and RPC goroutines. All of them must be accessed with mtx held.
+type watcherState struct {
+	mtx sync.RWMutex
+
+	latestFinalizedHeight        int64
+	lastEpochEndHeight           int64
+	currentMainnetBlockTimestamp int64
+
+	heightToFinalizedBlock map[int64]*types.BCHBlock
+	voteInfoList           []*types.VoteInfo
+	// the epochs built but not published yet, see publishEpochs
+	pendingEpochs []*pendingEpoch
+
+	ccContractExecutor *crosschain.CcContractExecutor
+}
+
+func (watcher *Watcher) GetLatestFinalizedHeight() int64 {
+	watcher.state.mtx.RLock()
+	defer watcher.state.mtx.RUnlock()
+	return watcher.state.latestFinalizedHeight
+}
+
+func (watcher *Watcher) GetCurrMainnetBlockTimestamp() int64 {
+	watcher.state.mtx.RLock()
+	defer watcher.state.mtx.RUnlock()
+	return watcher.state.currentMainnetBlockTimestamp
+}
+
+// GetEpochProgress returns how many BCH blocks of the epoch being built have been finalized
+func (watcher *Watcher) GetEpochProgress() (blocksScanned, numBlocksInEpoch int64) {
+	watcher.state.mtx.RLock()
+	defer watcher.state.mtx.RUnlock()
+	return watcher.state.latestFinalizedHeight - watcher.state.lastEpochEndHeight, watcher.numBlocksInEpoch
+}
+
+// GetCurrEpoch returns the epoch being built, or a *MissingBlockError while a block of it is
+// being fetched again
+func (watcher *Watcher) GetCurrEpoch() (*stakingtypes.Epoch, error) {
+	watcher.state.mtx.RLock()
+	defer watcher.state.mtx.RUnlock()
+	return watcher.buildNewEpoch()
+}
+
+func (watcher *Watcher) GetEpochList() ([]*stakingtypes.Epoch, error) {
+	watcher.state.mtx.RLock()
+	defer watcher.state.mtx.RUnlock()
+	epochList := make([]*stakingtypes.Epoch, len(watcher.state.voteInfoList))
+	for i, v := range watcher.state.voteInfoList {
+		epochList[i] = stakingtypes.CopyEpoch(v.Epoch)
+	}
+	currEpoch, err := watcher.buildNewEpoch()
+	if err != nil {
+		return nil, err
+	}
+	return append(epochList, currEpoch), nil
+}
+
+func (watcher *Watcher) GetCCExecutor() *crosschain.CcContractExecutor {
+	watcher.state.mtx.RLock()
+	defer watcher.state.mtx.RUnlock()
+	return watcher.state.ccContractExecutor
+}
+
+func (watcher *Watcher) SetCCExecutor(exe *crosschain.CcContractExecutor) {
+	watcher.state.mtx.Lock()
+	defer watcher.state.mtx.Unlock()
+	watcher.state.ccContractExecutor = exe
+}
+
+// GetMonitorVoteInfos returns the monitor vote infos of the epochs built recently, which are
+// published or still pending, and the one being built, which is nil before the cross chain
+// starts. Their numbers are zero, because they are assigned when the epochs are applied.
+func (watcher *Watcher) GetMonitorVoteInfos() (recent []*cctypes.MonitorVoteInfo, curr *cctypes.MonitorVoteInfo, err error) {
+	watcher.state.mtx.RLock()
+	defer watcher.state.mtx.RUnlock()
+	for _, v := range watcher.state.voteInfoList {
+		if v.MonitorVote.StartHeight == 0 {
+			continue
+		}
+		info := v.MonitorVote
+		info.Number = 0
+		info.EndTime = v.Epoch.EndTime
+		recent = append(recent, &info)
+	}
+	curr, err = watcher.buildMonitorVoteInfo()
+	return
+}
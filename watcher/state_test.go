@@ -0,0 +1,34 @@
+package watcher
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/libs/log"
+
+	cctypes "github.com/smartbch/smartbch/crosschain/types"
+	"github.com/smartbch/smartbch/param"
+	stakingtypes "github.com/smartbch/smartbch/staking/types"
+	"github.com/smartbch/smartbch/watcher/types"
+)
+
+func TestGetMonitorVoteInfos(t *testing.T) {
+	w := NewWatcher(log.NewNopLogger(), nil, 0, 0, param.DefaultConfig())
+	w.SetNumBlocksInEpoch(10)
+	nomination := &cctypes.Nomination{Pubkey: [33]byte{0x02, 0x01}, NominatedCount: 7}
+	w.state.voteInfoList = []*types.VoteInfo{
+		{Epoch: stakingtypes.Epoch{Number: 3, StartHeight: 1, EndTime: 100}},
+		{
+			Epoch:       stakingtypes.Epoch{Number: 4, StartHeight: 11, EndTime: 200},
+			MonitorVote: cctypes.MonitorVoteInfo{Number: 4, StartHeight: 11, Nominations: []*cctypes.Nomination{nomination}},
+		},
+	}
+	recent, curr, err := w.GetMonitorVoteInfos()
+	require.NoError(t, err)
+	require.Nil(t, curr) // before the cross chain starts
+	require.Len(t, recent, 1)
+	require.EqualValues(t, 0, recent[0].Number)
+	require.EqualValues(t, 200, recent[0].EndTime)
+	require.Equal(t, []*cctypes.Nomination{nomination}, recent[0].Nominations)
+	require.EqualValues(t, 4, w.state.voteInfoList[1].MonitorVote.Number)
+}
@@ -0,0 +1,60 @@
+package watcher
+
+import (
+	"sync/atomic"
+
+	"github.com/smartbch/smartbch/watcher/types"
+)
+
+// Status is a snapshot of the progress of the watcher, for the operators to tell whether it is
+// healthy without reading the logs
+type Status struct {
+	LatestFinalizedHeight     int64
+	LastEpochEndHeight        int64
+	LastKnownEpochNum         int64 // the number of the last epoch known when the watcher started
+	DeliveredEpochNum         int64 // the number of the latest epoch sent to EpochChan
+	CurrMainnetBlockTimestamp int64
+	// the epochs built but waiting for more confirmations before being published
+	PendingEpochs int
+	// the epochs and monitor vote infos sent but not read by the app yet
+	EpochChanBacklog       int
+	MonitorVoteChanBacklog int
+	SpilledEpochs          int // the epochs waiting for room in EpochChan
+	CcCollect              types.CcCollectStatus
+	// the BCH nodes not skipped by the failover, out of all the configured ones
+	AvailableEndpoints int
+	TotalEndpoints     int
+	ZmqConnected       bool
+	// the BCH node is unreachable and the epochs gossiped by the validators are taken
+	EpochGossipFallback bool
+	Stopped             bool
+}
+
+func (watcher *Watcher) GetStatus() Status {
+	watcher.state.mtx.RLock()
+	status := Status{
+		LatestFinalizedHeight:     watcher.state.latestFinalizedHeight,
+		LastEpochEndHeight:        watcher.state.lastEpochEndHeight,
+		CurrMainnetBlockTimestamp: watcher.state.currentMainnetBlockTimestamp,
+		PendingEpochs:             len(watcher.state.pendingEpochs),
+	}
+	watcher.state.mtx.RUnlock()
+	status.LastKnownEpochNum = watcher.lastKnownEpochNum
+	status.DeliveredEpochNum = atomic.LoadInt64(&watcher.deliveredEpochNum)
+	status.EpochChanBacklog = len(watcher.EpochChan)
+	status.MonitorVoteChanBacklog = len(watcher.MonitorVoteChan)
+	status.SpilledEpochs = watcher.epochQueue.size()
+	status.CcCollect = watcher.GetCcCollectStatus()
+	if client, ok := watcher.rpcClient.(*RpcClient); ok && client != nil {
+		for _, e := range client.EndpointStatus() {
+			status.TotalEndpoints++
+			if e.Available && !e.Lagging {
+				status.AvailableEndpoints++
+			}
+		}
+	}
+	status.ZmqConnected = watcher.zmq != nil && watcher.zmq.isConnected()
+	status.EpochGossipFallback = atomic.LoadInt32(&watcher.gossipFallback) == 1
+	status.Stopped = watcher.stopped()
+	return status
+}
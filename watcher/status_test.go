@@ -0,0 +1,27 @@
+package watcher
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/libs/log"
+
+	"github.com/smartbch/smartbch/param"
+	stakingtypes "github.com/smartbch/smartbch/staking/types"
+)
+
+func TestGetStatus(t *testing.T) {
+	w := NewWatcher(log.NewNopLogger(), nil, 100, 3, param.DefaultConfig())
+	w.state.latestFinalizedHeight = 120
+	w.state.currentMainnetBlockTimestamp = 1650000000
+	require.True(t, w.sendEpoch(&stakingtypes.Epoch{Number: 4, EndTime: 1650000000}))
+	status := w.GetStatus()
+	require.EqualValues(t, 120, status.LatestFinalizedHeight)
+	require.EqualValues(t, 100, status.LastEpochEndHeight)
+	require.EqualValues(t, 3, status.LastKnownEpochNum)
+	require.EqualValues(t, 1650000000, status.CurrMainnetBlockTimestamp)
+	require.Equal(t, 1, status.EpochChanBacklog)
+	require.Zero(t, status.MonitorVoteChanBacklog)
+	require.False(t, status.ZmqConnected)
+	require.False(t, status.Stopped)
+}
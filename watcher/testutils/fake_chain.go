@@ -0,0 +1,336 @@
+package testutils
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	cctypes "github.com/smartbch/smartbch/crosschain/types"
+	"github.com/smartbch/smartbch/param"
+	stakingtypes "github.com/smartbch/smartbch/staking/types"
+	"github.com/smartbch/smartbch/watcher/types"
+)
+
+const (
+	// DefaultBlockInterval is the seconds between the blocks mined without a timestamp skew
+	DefaultBlockInterval = 600
+	// DefaultBits is the compact target of the blocks mined without WithBits
+	DefaultBits = 0x1d00ffff
+
+	coinbaseScript = "76a914000000000000000000000000000000000000000088ac"
+)
+
+// FakeChain is an in-memory BCH chain implementing types.RpcClient, which is scripted by the tests
+// to mine blocks with nominations and cc deposits, skew their timestamps and reorg them, such that
+// the epoch and the cc collection logic of the watcher can be covered deterministically. A mined
+// block is returned both as types.BlockInfo, whose txs are the ones a BCH node would return, and as
+// types.BCHBlock with the nominations found in its coinbase tx.
+type FakeChain struct {
+	mtx sync.Mutex
+
+	baseHeight int64 // the blocks at and below it do not exist
+	baseTime   int64
+	interval   int64
+	blocks     []*fakeBlock // the main chain, blocks[i] is at baseHeight+1+i
+	orphans    []*fakeBlock // the blocks reorged out
+	salt       uint64       // makes the hashes of the blocks at the same height differ
+
+	voteInfos []*types.VoteInfo
+}
+
+type fakeBlock struct {
+	bch  *types.BCHBlock
+	info *types.BlockInfo
+}
+
+var _ types.RpcClient = (*FakeChain)(nil)
+
+// NewFakeChain returns a chain without blocks, the first block mined is at baseHeight+1, whose
+// timestamp is baseTime+DefaultBlockInterval
+func NewFakeChain(baseHeight, baseTime int64) *FakeChain {
+	return &FakeChain{baseHeight: baseHeight, baseTime: baseTime, interval: DefaultBlockInterval}
+}
+
+// SetBlockInterval sets the seconds between the blocks mined later
+func (c *FakeChain) SetBlockInterval(seconds int64) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.interval = seconds
+}
+
+// BlockOption scripts the content of a block to be mined
+type BlockOption func(b *blockScript)
+
+type blockScript struct {
+	skew       int64
+	bits       uint32
+	coinbase   string
+	validators [][32]byte
+	monitors   [][33]byte
+	txs        []types.TxInfo
+}
+
+// NominateValidator adds an OP_RETURN output nominating the validator to the coinbase tx, it can
+// be given more than once, but only the first one is counted by the watcher
+func NominateValidator(pubkey [32]byte) BlockOption {
+	return func(b *blockScript) {
+		b.validators = append(b.validators, pubkey)
+	}
+}
+
+// NominateMonitor adds an OP_RETURN output nominating the monitor to the coinbase tx
+func NominateMonitor(pubkey [33]byte) BlockOption {
+	return func(b *blockScript) {
+		b.monitors = append(b.monitors, pubkey)
+	}
+}
+
+// Deposit adds a tx paying sats to the covenant, whose OP_RETURN output names the receiver on
+// smartBCH
+func Deposit(covenant common.Address, receiver common.Address, sats int64) BlockOption {
+	return func(b *blockScript) {
+		b.txs = append(b.txs, types.TxInfo{
+			VoutList: []types.Vout{
+				{
+					Value:        float64(sats) / 1e8,
+					ScriptPubKey: asm("OP_HASH160 " + hex.EncodeToString(covenant[:]) + " OP_EQUAL"),
+				},
+				{
+					N:            1,
+					ScriptPubKey: asm("OP_RETURN " + hex.EncodeToString([]byte(receiver.Hex()))),
+				},
+			},
+		})
+	}
+}
+
+// WithTx adds a tx, whose hash is set if it is empty
+func WithTx(tx types.TxInfo) BlockOption {
+	return func(b *blockScript) {
+		b.txs = append(b.txs, tx)
+	}
+}
+
+// WithTimestampSkew moves the timestamp of the block from the one of its parent plus the block
+// interval, a negative skew may make it earlier than its parent, which is valid on BCH
+func WithTimestampSkew(seconds int64) BlockOption {
+	return func(b *blockScript) {
+		b.skew = seconds
+	}
+}
+
+// WithBits sets the compact target of the block
+func WithBits(bits uint32) BlockOption {
+	return func(b *blockScript) {
+		b.bits = bits
+	}
+}
+
+// WithCoinbase sets the hex of the output script paying the block reward, which tells the miner
+func WithCoinbase(script string) BlockOption {
+	return func(b *blockScript) {
+		b.coinbase = script
+	}
+}
+
+// Mine appends a block to the main chain and returns it
+func (c *FakeChain) Mine(opts ...BlockOption) *types.BCHBlock {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return c.mine(opts).bch
+}
+
+// MineN appends n blocks with the same options, and returns the height of the last one
+func (c *FakeChain) MineN(n int, opts ...BlockOption) int64 {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	for i := 0; i < n; i++ {
+		c.mine(opts)
+	}
+	return c.height()
+}
+
+// Reorg replaces the top depth blocks of the main chain with length blocks of another branch, which
+// are mined with the options. The replaced blocks can still be found by their hashes.
+func (c *FakeChain) Reorg(depth, length int, opts ...BlockOption) error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if depth > len(c.blocks) {
+		return fmt.Errorf("cannot reorg %d blocks, the chain has %d", depth, len(c.blocks))
+	}
+	keep := len(c.blocks) - depth
+	c.orphans = append(c.orphans, c.blocks[keep:]...)
+	c.blocks = c.blocks[:keep]
+	for i := 0; i < length; i++ {
+		c.mine(opts)
+	}
+	return nil
+}
+
+// SetVoteInfos sets the vote infos returned by GetVoteInfoByEpochNumber, whose epoch numbers
+// are their indexes plus one
+func (c *FakeChain) SetVoteInfos(infos []*types.VoteInfo) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.voteInfos = infos
+}
+
+// GetBlockByHash returns the block on the main chain or reorged out with the hash
+func (c *FakeChain) GetBlockByHash(hash [32]byte) *types.BCHBlock {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	for _, list := range [][]*fakeBlock{c.blocks, c.orphans} {
+		for _, b := range list {
+			if b.bch.HashId == hash {
+				return b.bch
+			}
+		}
+	}
+	return nil
+}
+
+func (c *FakeChain) GetLatestHeight(_ bool) int64 {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return c.height()
+}
+
+// GetBlockByHeight returns the block on the main chain at height, or nil if it does not exist
+func (c *FakeChain) GetBlockByHeight(height int64, _ bool) *types.BCHBlock {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if b := c.blockAt(height); b != nil {
+		return b.bch
+	}
+	return nil
+}
+
+// GetBlockInfoByHeight returns the verbose block on the main chain at height, or nil if it does not
+// exist
+func (c *FakeChain) GetBlockInfoByHeight(height int64, _ bool) *types.BlockInfo {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if b := c.blockAt(height); b != nil {
+		return b.info
+	}
+	return nil
+}
+
+// GetVoteInfoByEpochNumber returns the vote infos set of the epochs in [start, end)
+func (c *FakeChain) GetVoteInfoByEpochNumber(start, end uint64) []*types.VoteInfo {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if start < 1 {
+		start = 1
+	}
+	var infos []*types.VoteInfo
+	for n := start; n < end && n <= uint64(len(c.voteInfos)); n++ {
+		infos = append(infos, c.voteInfos[n-1])
+	}
+	return infos
+}
+
+// mtx must be held by the caller
+func (c *FakeChain) height() int64 {
+	return c.baseHeight + int64(len(c.blocks))
+}
+
+// mtx must be held by the caller
+func (c *FakeChain) blockAt(height int64) *fakeBlock {
+	i := height - c.baseHeight - 1
+	if i < 0 || i >= int64(len(c.blocks)) {
+		return nil
+	}
+	return c.blocks[i]
+}
+
+// mtx must be held by the caller
+func (c *FakeChain) mine(opts []BlockOption) *fakeBlock {
+	script := &blockScript{bits: DefaultBits, coinbase: coinbaseScript}
+	for _, opt := range opts {
+		opt(script)
+	}
+	var parentHash [32]byte
+	timestamp := c.baseTime
+	if len(c.blocks) != 0 {
+		parent := c.blocks[len(c.blocks)-1].bch
+		parentHash = parent.HashId
+		timestamp = parent.Timestamp
+	}
+	height := c.height() + 1
+	timestamp += c.interval + script.skew
+	c.salt++
+	hash := blockHash(height, parentHash, c.salt)
+
+	bch := &types.BCHBlock{
+		Height:    height,
+		Timestamp: timestamp,
+		HashId:    hash,
+		ParentBlk: parentHash,
+		Coinbase:  script.coinbase,
+		Bits:      script.bits,
+	}
+	coinbase := types.TxInfo{
+		VinList:  []map[string]interface{}{{"coinbase": fmt.Sprintf("%08x", height)}},
+		VoutList: []types.Vout{{Value: 6.25, ScriptPubKey: map[string]interface{}{"hex": script.coinbase}}},
+	}
+	for _, pubkey := range script.validators {
+		coinbase.VoutList = append(coinbase.VoutList, types.Vout{
+			ScriptPubKey: asm("OP_RETURN " + types.Identifier + types.Validator + hex.EncodeToString(pubkey[:])),
+		})
+	}
+	for _, pubkey := range script.monitors {
+		coinbase.VoutList = append(coinbase.VoutList, types.Vout{
+			ScriptPubKey: asm("OP_RETURN " + types.Identifier + types.Monitor + hex.EncodeToString(pubkey[:])),
+		})
+	}
+	if len(script.validators) != 0 {
+		bch.Nominations = []stakingtypes.Nomination{{Pubkey: script.validators[0], NominatedCount: 1}}
+	}
+	if len(script.monitors) != 0 && height >= param.CurrBchNetwork().StartHeightForCC {
+		bch.CCNominations = []cctypes.Nomination{{Pubkey: script.monitors[0], NominatedCount: 1}}
+	}
+
+	txs := append([]types.TxInfo{coinbase}, script.txs...)
+	for i := range txs {
+		if txs[i].Hash == "" {
+			txid := sha256.Sum256(append(hash[:], byte(i), byte(i>>8)))
+			txs[i].TxID = hex.EncodeToString(txid[:])
+			txs[i].Hash = txs[i].TxID
+		}
+		for n := range txs[i].VoutList {
+			txs[i].VoutList[n].N = n
+		}
+		txs[i].Blockhash = hex.EncodeToString(hash[:])
+		txs[i].Time = timestamp
+		txs[i].BlockTime = timestamp
+	}
+	info := &types.BlockInfo{
+		Hash:              hex.EncodeToString(hash[:]),
+		Height:            height,
+		Tx:                txs,
+		Time:              timestamp,
+		MedianTime:        timestamp,
+		Bits:              fmt.Sprintf("%08x", script.bits),
+		PreviousBlockhash: hex.EncodeToString(parentHash[:]),
+	}
+	b := &fakeBlock{bch: bch, info: info}
+	c.blocks = append(c.blocks, b)
+	return b
+}
+
+func blockHash(height int64, parent [32]byte, salt uint64) [32]byte {
+	var bz [48]byte
+	binary.BigEndian.PutUint64(bz[:8], uint64(height))
+	copy(bz[8:40], parent[:])
+	binary.BigEndian.PutUint64(bz[40:], salt)
+	return sha256.Sum256(bz[:])
+}
+
+func asm(script string) map[string]interface{} {
+	return map[string]interface{}{"asm": script}
+}
@@ -0,0 +1,65 @@
+package testutils
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+
+	"github.com/smartbch/smartbch/watcher/types"
+)
+
+func TestFakeChainMine(t *testing.T) {
+	c := NewFakeChain(100, 1_600_000_000)
+	require.EqualValues(t, 100, c.GetLatestHeight(false))
+	require.Nil(t, c.GetBlockByHeight(100, false))
+
+	validator := [32]byte{0x01}
+	blk := c.Mine(NominateValidator(validator))
+	require.EqualValues(t, 101, blk.Height)
+	require.EqualValues(t, 1_600_000_000+DefaultBlockInterval, blk.Timestamp)
+	require.Len(t, blk.Nominations, 1)
+	require.Equal(t, validator, blk.Nominations[0].Pubkey)
+
+	// the coinbase tx nominates the same validator as the BCHBlock
+	info := c.GetBlockInfoByHeight(101, false)
+	pubkey, ok := info.Tx[0].GetValidatorPubKey()
+	require.True(t, ok)
+	require.Equal(t, validator, pubkey)
+
+	require.EqualValues(t, 103, c.MineN(2))
+	skewed := c.Mine(WithTimestampSkew(-2 * DefaultBlockInterval))
+	require.Equal(t, c.GetBlockByHeight(103, false).HashId, skewed.ParentBlk)
+	require.Less(t, skewed.Timestamp, c.GetBlockByHeight(103, false).Timestamp)
+}
+
+func TestFakeChainDeposit(t *testing.T) {
+	covenant := common.Address{0xcc}
+	receiver := common.Address{0xaa}
+	c := NewFakeChain(0, 0)
+	c.Mine(Deposit(covenant, receiver, 100_000))
+
+	parser := &types.CcTxParser{}
+	parser.SetCovenantAddresses(common.Address{}, covenant)
+	deposits := parser.FindDeposits(c.GetBlockInfoByHeight(1, false).Tx)
+	require.Len(t, deposits, 1)
+	require.Equal(t, [20]byte(receiver), deposits[0].Receiver)
+	require.Equal(t, [20]byte(covenant), deposits[0].CovenantAddress)
+	require.EqualValues(t, 0, deposits[0].UTXO.Index)
+}
+
+func TestFakeChainReorg(t *testing.T) {
+	c := NewFakeChain(0, 0)
+	c.MineN(10)
+	old := c.GetBlockByHeight(9, false)
+	require.Error(t, c.Reorg(11, 1))
+	require.NoError(t, c.Reorg(2, 3))
+	require.EqualValues(t, 11, c.GetLatestHeight(false))
+	require.NotEqual(t, old.HashId, c.GetBlockByHeight(9, false).HashId)
+	require.Equal(t, c.GetBlockByHeight(8, false).HashId, c.GetBlockByHeight(9, false).ParentBlk)
+	require.Equal(t, old, c.GetBlockByHash(old.HashId))
+
+	c.SetVoteInfos([]*types.VoteInfo{{}, {}, {}})
+	require.Len(t, c.GetVoteInfoByEpochNumber(0, 3), 2)
+	require.Len(t, c.GetVoteInfoByEpochNumber(2, 10), 2)
+}
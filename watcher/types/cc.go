@@ -1,6 +1,7 @@
 package types
 
 import (
+	"bytes"
 	"encoding/binary"
 	"encoding/hex"
 	"encoding/json"
@@ -23,6 +24,13 @@ import (
 // p2pkh lock script: 76 + a9(OP_HASH160) + 14 + 20-byte-length-pubkey-hash + 88(OP_EQUALVERIFY) + ac(OP_CHECKSIG)
 // p2sh lock script:  a9(OP_HASH160) + 14 + 20-byte-redeem-script-hash + 87(OP_EQUAL)
 // cc related op return: 6a(OP_RETURN) + 1c(8 + 20) + 7342434841646472(sBCHAddr) + 20-byte-side-address
+// peg-in call op return: 6a(OP_RETURN) + push(8 + 20 + len(calldata)) + 7342434863616c6c(sBCHcall) + 20-byte-target + calldata
+
+const (
+	PegInCallMagic = "sBCHcall"
+	// the standard OP_RETURN output of BCH carries at most 223 bytes, including the opcodes
+	MaxPegInMemoLen = 220
+)
 
 //type ScriptSig struct {
 //	Asm string `json:"asm"`
@@ -43,6 +51,9 @@ type CcTxParser struct {
 	DB                     types.DB
 	CurrentCovenantAddress string
 	PrevCovenantAddress    string
+	// the generations before PrevCovenantAddress, the deposits to which are still recognized during
+	// a long operator churn, and kept as lost-and-found
+	OlderCovenantAddresses []string
 	UtxoSet                map[[32]byte]uint32
 }
 
@@ -57,7 +68,7 @@ func (cc *CcTxParser) GetCCUTXOTransferInfo(bi *BlockInfo) (infos []*cctypes.CCT
 	return
 }
 
-func (cc *CcTxParser) Refresh(prevCovenantAddr, currCovenantAddr common.Address) {
+func (cc *CcTxParser) Refresh(prevCovenantAddr, currCovenantAddr common.Address, olderCovenantAddrs ...common.Address) {
 	var outpointSet = make(map[[32]byte]uint32 /*txid => vout*/)
 	for _, id := range cc.DB.GetAllUtxoIds() {
 		var txid [32]byte
@@ -66,8 +77,23 @@ func (cc *CcTxParser) Refresh(prevCovenantAddr, currCovenantAddr common.Address)
 		outpointSet[txid] = index
 	}
 	cc.UtxoSet = outpointSet
+	cc.SetCovenantAddresses(prevCovenantAddr, currCovenantAddr, olderCovenantAddrs...)
+}
+
+// SetCovenantAddresses sets the covenant addresses the deposits to which are recognized, without
+// loading the UTXO set
+func (cc *CcTxParser) SetCovenantAddresses(prevCovenantAddr, currCovenantAddr common.Address, olderCovenantAddrs ...common.Address) {
 	cc.PrevCovenantAddress = ethAddrToBchAddr(prevCovenantAddr)
 	cc.CurrentCovenantAddress = ethAddrToBchAddr(currCovenantAddr)
+	cc.OlderCovenantAddresses = cc.OlderCovenantAddresses[:0]
+	for _, addr := range olderCovenantAddrs {
+		cc.OlderCovenantAddresses = append(cc.OlderCovenantAddresses, ethAddrToBchAddr(addr))
+	}
+}
+
+// FindDeposits returns the deposits to the covenant addresses in txs, which may be unconfirmed
+func (cc *CcTxParser) FindDeposits(txs []TxInfo) []*cctypes.CCTransferInfo {
+	return cc.findRedeemableTx(txs)
 }
 func ethAddrToBchAddr(ethAddr common.Address) string {
 	return hex.EncodeToString(ethAddr[:])
@@ -94,6 +120,7 @@ func (cc *CcTxParser) findRedeemableTx(txs []TxInfo) (infos []*cctypes.CCTransfe
 					covenantAddressMatched = cc.PrevCovenantAddress
 				}
 			default:
+				covenantAddressMatched = cc.matchOlderCovenant(script)
 			}
 			if covenantAddressMatched != "" {
 				info.UTXO.Amount = uint256.NewInt(0).Mul(uint256.NewInt(uint64(math.Round(vOut.Value*1e8))), uint256.NewInt(1e10)).Bytes32()
@@ -106,8 +133,24 @@ func (cc *CcTxParser) findRedeemableTx(txs []TxInfo) (infos []*cctypes.CCTransfe
 		}
 		if isRedeemableTx {
 			receiver := findReceiver(ti)
-			if receiver != nil {
+			if receiver == nil {
+				// the operators' txs spending the covenant, such as the convert txs, have no receiver
+				if cc.spendsCcUTXO(ti) {
+					continue
+				}
+				// kept as a lost-and-found without owner, instead of being left untracked in the covenant
+				info.NoReceiver = true
+				infos = append(infos, &info)
+			} else {
 				copy(info.Receiver[:], receiver)
+				if target, data, ok := findPegInCall(ti); ok {
+					copy(info.CallTarget[:], target)
+					info.CallData = data
+				}
+				if refundTarget := findRefundTarget(ti); refundTarget != nil {
+					copy(info.RefundTarget[:], refundTarget)
+				}
+				info.MalformedMemo = hasMalformedMemo(ti)
 				infos = append(infos, &info)
 			}
 		}
@@ -115,6 +158,15 @@ func (cc *CcTxParser) findRedeemableTx(txs []TxInfo) (infos []*cctypes.CCTransfe
 	return
 }
 
+func (cc *CcTxParser) matchOlderCovenant(script string) string {
+	for _, addr := range cc.OlderCovenantAddresses {
+		if script == "OP_HASH160 "+addr+" OP_EQUAL" {
+			return addr
+		}
+	}
+	return ""
+}
+
 func (cc *CcTxParser) findConvertTx(txs []TxInfo) (infos []*cctypes.CCTransferInfo) {
 	for _, ti := range txs {
 		if len(ti.VoutList) != 1 || len(ti.VinList) != 1 {
@@ -201,6 +253,16 @@ func (cc *CcTxParser) isCcUXTOSpent(txid [32]byte, vout uint32) bool {
 	return false
 }
 
+func (cc *CcTxParser) spendsCcUTXO(ti TxInfo) bool {
+	for _, vIn := range ti.VinList {
+		txid, vout, err := getSpentTxInfo(vIn)
+		if err == nil && cc.isCcUXTOSpent(txid, vout) {
+			return true
+		}
+	}
+	return false
+}
+
 //util functions
 func getSpentTxInfo(vIn map[string]interface{}) (txid [32]byte, index uint32, err error) {
 	txidV, exist := vIn["txid"]
@@ -251,6 +313,42 @@ func findReceiver(tx TxInfo) []byte {
 	return nil
 }
 
+// findRefundTarget returns the pubkey hash of the first P2PKH input
+func findRefundTarget(tx TxInfo) []byte {
+	for _, vIn := range tx.VinList {
+		if pubkeyBytes, ok := getP2PKHPubkey(vIn); ok {
+			return bchutil.Hash160(pubkeyBytes)
+		}
+	}
+	return nil
+}
+
+// hasMalformedMemo returns whether the tx has no valid peg-in memo but has an OP_RETURN output which
+// looks like one, such as a truncated address, so the receiver found is not the intended one
+func hasMalformedMemo(tx TxInfo) bool {
+	var malformed bool
+	for _, vOut := range tx.VoutList {
+		script, ok := getPubkeyScript(vOut)
+		if !ok || !strings.HasPrefix(script, "OP_RETURN ") {
+			continue
+		}
+		if _, ok = findReceiverInOPReturn(script); ok {
+			return false
+		}
+		if _, _, ok = findCallInOPReturn(script); ok {
+			return false
+		}
+		bz, err := hex.DecodeString(script[len("OP_RETURN "):])
+		if err != nil {
+			continue
+		}
+		if bytes.HasPrefix(bz, []byte("0x")) || bytes.HasPrefix(bz, []byte(PegInCallMagic)) {
+			malformed = true
+		}
+	}
+	return malformed
+}
+
 func getPubkeyScript(v Vout) (script string, ok bool) {
 	asm, done := v.ScriptPubKey["asm"]
 	if !done || asm == nil {
@@ -276,7 +374,54 @@ func findReceiverInOPReturn(script string) ([]byte, bool) {
 	return common.HexToAddress(string(bz)).Bytes(), true
 }
 
+func findPegInCall(tx TxInfo) (target, data []byte, ok bool) {
+	for _, vOut := range tx.VoutList {
+		script, exist := getPubkeyScript(vOut)
+		if !exist {
+			continue
+		}
+		if target, data, ok = findCallInOPReturn(script); ok {
+			return
+		}
+	}
+	return nil, nil, false
+}
+
+// The extended memo of peg-in is: OP_RETURN + "sBCHcall" + 20-byte-target-contract + calldata
+func findCallInOPReturn(script string) (target, data []byte, ok bool) {
+	prefix := "OP_RETURN "
+	if !strings.HasPrefix(script, prefix) {
+		return nil, nil, false
+	}
+	bz, err := hex.DecodeString(script[len(prefix):])
+	if err != nil {
+		return nil, nil, false
+	}
+	if len(bz) < len(PegInCallMagic)+20 || len(bz) > MaxPegInMemoLen ||
+		string(bz[:len(PegInCallMagic)]) != PegInCallMagic {
+		return nil, nil, false
+	}
+	bz = bz[len(PegInCallMagic):]
+	if bytes.Equal(bz[:20], make([]byte, 20)) {
+		return nil, nil, false
+	}
+	return bz[:20], bz[20:], true
+}
+
 func getP2PKHAddress(vIn map[string]interface{}) ([]byte, bool) {
+	pubkeyBytes, ok := getP2PKHPubkey(vIn)
+	if !ok {
+		return nil, false
+	}
+	pubkey, err := bchutil.NewAddressPubKey(pubkeyBytes, &chaincfg.MainNetParams)
+	if err != nil {
+		return nil, false
+	}
+	return crypto.PubkeyToAddress(*pubkey.PubKey().ToECDSA()).Bytes(), true
+}
+
+// getP2PKHPubkey returns the pubkey in the unlocking script of a P2PKH input
+func getP2PKHPubkey(vIn map[string]interface{}) ([]byte, bool) {
 	script, exist := vIn["scriptSig"]
 	if !exist || script == nil {
 		return nil, false
@@ -314,9 +459,5 @@ func getP2PKHAddress(vIn map[string]interface{}) ([]byte, bool) {
 	if len(pubkeyBytes) != 65 && len(pubkeyBytes) != 33 {
 		return nil, false
 	}
-	pubkey, err := bchutil.NewAddressPubKey(pubkeyBytes, &chaincfg.MainNetParams)
-	if err != nil {
-		return nil, false
-	}
-	return crypto.PubkeyToAddress(*pubkey.PubKey().ToECDSA()).Bytes(), true
+	return pubkeyBytes, true
 }
@@ -65,6 +65,51 @@ func TestOpReturnReceiverParse(t *testing.T) {
 	require.False(t, ok)
 }
 
+func TestOpReturnPegInCallParse(t *testing.T) {
+	target := gethcmn.HexToAddress("c370743331b37d3c6d0ee798b3918f6561af2c92")
+	calldata := []byte{0xd0, 0xe3, 0x0d, 0xb0}
+	memo := append([]byte(PegInCallMagic), target.Bytes()...)
+	memo = append(memo, calldata...)
+	r, data, ok := findCallInOPReturn("OP_RETURN " + hex.EncodeToString(memo))
+	require.True(t, ok)
+	require.Equal(t, target.Bytes(), r)
+	require.Equal(t, calldata, data)
+
+	// the receiver memo is not a call memo
+	_, _, ok = findCallInOPReturn("OP_RETURN " + hex.EncodeToString([]byte(target.Hex())))
+	require.False(t, ok)
+
+	// zero target
+	memo = append([]byte(PegInCallMagic), make([]byte, 20)...)
+	_, _, ok = findCallInOPReturn("OP_RETURN " + hex.EncodeToString(memo))
+	require.False(t, ok)
+
+	// too long
+	memo = append([]byte(PegInCallMagic), target.Bytes()...)
+	memo = append(memo, make([]byte, MaxPegInMemoLen)...)
+	_, _, ok = findCallInOPReturn("OP_RETURN " + hex.EncodeToString(memo))
+	require.False(t, ok)
+}
+
+func TestHasMalformedMemo(t *testing.T) {
+	newTx := func(memos ...string) TxInfo {
+		var tx TxInfo
+		for _, memo := range memos {
+			tx.VoutList = append(tx.VoutList, Vout{ScriptPubKey: map[string]interface{}{
+				"asm": "OP_RETURN " + hex.EncodeToString([]byte(memo)),
+			}})
+		}
+		return tx
+	}
+	address := "0xc370743331b37d3c6d0ee798b3918f6561af2c92"
+	require.False(t, hasMalformedMemo(newTx()))
+	require.False(t, hasMalformedMemo(newTx("hello")))
+	require.False(t, hasMalformedMemo(newTx(address)))
+	require.True(t, hasMalformedMemo(newTx(address[:41])))
+	require.True(t, hasMalformedMemo(newTx(PegInCallMagic+"short")))
+	require.False(t, hasMalformedMemo(newTx(address[:41], address)))
+}
+
 func TestGetAddrFromOpReturn(t *testing.T) {
 	// https://www.blockchain.com/bch-testnet/block/1517179
 	blockJson := `{
@@ -1104,3 +1149,65 @@ func TestFindConvertTx2(t *testing.T) {
 	infos := parser.findConvertTx(txs)
 	require.Len(t, infos, 3)
 }
+
+func TestFindRedeemableTxOfOlderCovenant(t *testing.T) {
+	receiver := "c370743331b37d3c6d0ee798b3918f6561af2c92"
+	older := "6ad3f81523c87aa17f1dfa08271cf57b6277c98e"
+	newTx := func(covenant string) TxInfo {
+		return TxInfo{
+			Hash: "c01ab2bfa4a7f64cf781e886844de836e7b45f2c6150de380cb891045e8353c9",
+			VoutList: []Vout{
+				{Value: 0.1, ScriptPubKey: map[string]interface{}{"asm": "OP_HASH160 " + covenant + " OP_EQUAL"}},
+				{ScriptPubKey: map[string]interface{}{"asm": "OP_RETURN " + hex.EncodeToString([]byte(receiver))}},
+			},
+		}
+	}
+	cc := &CcTxParser{
+		CurrentCovenantAddress: "0000000000000000000000000000000000000002",
+		PrevCovenantAddress:    "0000000000000000000000000000000000000001",
+	}
+	require.Empty(t, cc.findRedeemableTx([]TxInfo{newTx(older)}))
+
+	cc.OlderCovenantAddresses = []string{older}
+	infos := cc.findRedeemableTx([]TxInfo{newTx(older)})
+	require.Len(t, infos, 1)
+	require.Equal(t, gethcmn.HexToAddress(older), gethcmn.Address(infos[0].CovenantAddress))
+	require.Equal(t, gethcmn.HexToAddress(receiver), gethcmn.Address(infos[0].Receiver))
+}
+
+func TestFindRedeemableTxWithPegInCall(t *testing.T) {
+	receiver := "c370743331b37d3c6d0ee798b3918f6561af2c92"
+	target := gethcmn.HexToAddress("6ad3f81523c87aa17f1dfa08271cf57b6277c98e")
+	calldata := []byte{0xd0, 0xe3, 0x0d, 0xb0}
+	covenant := "0000000000000000000000000000000000000002"
+	newTx := func(memos ...[]byte) TxInfo {
+		tx := TxInfo{
+			Hash:     "c01ab2bfa4a7f64cf781e886844de836e7b45f2c6150de380cb891045e8353c9",
+			VoutList: []Vout{{Value: 0.1, ScriptPubKey: map[string]interface{}{"asm": "OP_HASH160 " + covenant + " OP_EQUAL"}}},
+		}
+		for _, memo := range memos {
+			tx.VoutList = append(tx.VoutList, Vout{ScriptPubKey: map[string]interface{}{"asm": "OP_RETURN " + hex.EncodeToString(memo)}})
+		}
+		return tx
+	}
+	callMemo := append(append([]byte(PegInCallMagic), target.Bytes()...), calldata...)
+	cc := &CcTxParser{CurrentCovenantAddress: covenant}
+
+	infos := cc.findRedeemableTx([]TxInfo{newTx([]byte(receiver), callMemo)})
+	require.Len(t, infos, 1)
+	require.Equal(t, gethcmn.HexToAddress(receiver), gethcmn.Address(infos[0].Receiver))
+	require.Equal(t, target, gethcmn.Address(infos[0].CallTarget))
+	require.Equal(t, calldata, infos[0].CallData)
+	require.True(t, infos[0].HasCall())
+
+	// a call memo without a receiver memo is not a peg-in, the deposit is kept without receiver
+	infos = cc.findRedeemableTx([]TxInfo{newTx(callMemo)})
+	require.Len(t, infos, 1)
+	require.True(t, infos[0].NoReceiver)
+	require.False(t, infos[0].HasCall())
+
+	// without a call memo
+	infos = cc.findRedeemableTx([]TxInfo{newTx([]byte(receiver))})
+	require.Len(t, infos, 1)
+	require.False(t, infos[0].HasCall())
+}
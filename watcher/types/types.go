@@ -27,6 +27,14 @@ type VoteInfo struct {
 	MonitorVote cctypes.MonitorVoteInfo
 }
 
+// SignedVoteInfos are the vote infos published by the watcher of a validator, signed with its
+// consensus key, which are gossiped to the validators whose BCH nodes are unreachable
+type SignedVoteInfos struct {
+	Infos     []*VoteInfo `json:"infos"`
+	Pubkey    []byte      `json:"pubkey"` // the ed25519 consensus pubkey of the validator
+	Signature []byte      `json:"signature"`
+}
+
 // This struct contains the useful information of a BCH block
 type BCHBlock struct {
 	Height        int64
@@ -35,6 +43,9 @@ type BCHBlock struct {
 	ParentBlk     [32]byte
 	CCNominations []cctypes.Nomination
 	Nominations   []stakingtypes.Nomination
+	// the hex of the first paying output script of the coinbase tx, which tells the miner
+	Coinbase string
+	Bits     uint32 // the compact target, from which the work of the block is computed
 }
 
 //not check Nominations
@@ -176,3 +187,46 @@ type TxInfoResp struct {
 	Error  *JsonRpcError `json:"error"`
 	Id     string        `json:"id"`
 }
+
+// TxOut is the result of gettxout, which is null if the output is spent
+type TxOut struct {
+	BestBlock     string                 `json:"bestblock"`
+	Confirmations int64                  `json:"confirmations"`
+	Value         float64                `json:"value"`
+	ScriptPubKey  map[string]interface{} `json:"scriptPubKey"`
+}
+
+type TxOutResp struct {
+	Result *TxOut        `json:"result"`
+	Error  *JsonRpcError `json:"error"`
+	Id     string        `json:"id"`
+}
+
+// CcCollectStatus describes the cc transfer infos collected by the watcher for the rescan window
+// (Begin, End] of the cc context, which are handled by the next handleUTXOs
+type CcCollectStatus struct {
+	Begin int64
+	End   int64
+	Infos int
+	// the duplicated infos dropped when the window was collected
+	Duplicates int
+	// the forced re-scan waiting to be taken by the collecting goroutine, nil if none
+	PendingRescan *HeightRange
+	// the last forced re-scan taken, and the unix time it finished
+	LastRescan     *HeightRange
+	LastRescanTime int64
+	// set by SetCcCollectPaused
+	Paused bool
+}
+
+// PendingCcDeposit is a deposit to the covenant found in the BCH mempool, which is not mined yet
+type PendingCcDeposit struct {
+	Info      *cctypes.CCTransferInfo
+	FirstSeen int64 // the unix time it was found in the mempool
+}
+
+// HeightRange is the BCH blocks in (Begin, End]
+type HeightRange struct {
+	Begin int64
+	End   int64
+}
@@ -2,12 +2,14 @@ package watcher
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"math"
 	"sort"
 	"sync/atomic"
 	"time"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/smartbch/moeingads/datatree"
 	modbtypes "github.com/smartbch/moeingdb/types"
 	evmtypes "github.com/smartbch/moeingevm/types"
@@ -27,8 +29,6 @@ const (
 	monitorInfoCleanThreshold = 5
 )
 
-var blockFinalizeNumber = int64(1) // 1 for test, 9 for product
-
 type IContextGetter interface {
 	GetRpcContext() *evmtypes.Context
 }
@@ -41,79 +41,155 @@ type Watcher struct {
 	rpcClient         types.RpcClient
 	smartBchRpcClient types.RpcClient
 
-	latestFinalizedHeight int64
-
-	heightToFinalizedBlock map[int64]*types.BCHBlock
+	state watcherState
 
 	catchupChan chan bool
 
-	EpochChan chan *stakingtypes.Epoch
+	EpochChan    chan *stakingtypes.Epoch
+	epochQueue   *epochQueue
+	epochArchive *epochArchive // nil if watcher-epoch-archive-path is not set
 	// new monitor vote info always sent to app same time with epoch
 	MonitorVoteChan     chan *cctypes.MonitorVoteInfo
 	monitorVoteInfoList []*cctypes.MonitorVoteInfo
 
-	voteInfoList []*types.VoteInfo
-
-	numBlocksInEpoch   int64
-	lastEpochEndHeight int64
-	lastKnownEpochNum  int64
-
+	// the following fields are set before Run and never changed after that
+	numBlocksInEpoch      int64
+	lastKnownEpochNum     int64
+	blockFinalizeNumber   int64
 	waitingBlockDelayTime int
 	parallelNum           int
 
 	chainConfig *param.ChainConfig
 
-	currentMainnetBlockTimestamp int64
-
-	//executors
-	CcContractExecutor *crosschain.CcContractExecutor
-	txParser           types.CcTxParser
+	txParser types.CcTxParser
 
 	contextGetter IContextGetter
+
+	deliveredEpochNum int64 // accessed atomically
+	// the latest height reported by the BCH node, accessed atomically
+	latestMainnetHeight int64
+
+	ccState ccCollectState
+
+	zmq *zmqSubscriber // nil if mainnet-zmq-url is not set
+
+	mempool *mempoolScanner // nil if watcher-scan-mempool is not set
+
+	analytics *analyticsStore
+
+	// the smartBCH nodes of the validators serving the signed epochs, see epoch_gossip.go
+	gossipPeers    []*RpcClient
+	gossipFallback int32 // accessed atomically, 1 when the gossiped epochs are taken
+
+	life *lifecycle
 }
 
 func NewWatcher(logger log.Logger, historyDB modbtypes.DB, lastHeight, lastKnownEpochNum int64, chainConfig *param.ChainConfig) *Watcher {
+	appConfig := chainConfig.AppConfig
+	httpClient, err := NewProxiedHttpClient(appConfig.WatcherProxy)
+	if err == nil {
+		err = CheckProxyConfig(appConfig.WatcherProxy, append(append([]string{appConfig.SmartBchRPCUrl, appConfig.MainnetArchiveRPCUrl}, appConfig.MainnetRPCEndpoints()...), appConfig.EpochGossipPeers...)...)
+	}
+	if err != nil {
+		panic("invalid watcher-proxy: " + err.Error())
+	}
+	life := newLifecycle()
+	rpcClient := NewRpcClientWithUrls(appConfig.MainnetRPCEndpoints(), appConfig.MainnetRPCUsername, appConfig.MainnetRPCPassword, "text/plain;", logger)
+	rpcClient.SetHttpClient(httpClient)
+	rpcClient.SetContext(life.ctx)
+	rpcClient.SetRateLimit(appConfig.MainnetRPCRateLimit)
+	rpcClient.SetMaxRetryInterval(time.Duration(appConfig.MainnetRPCMaxRetryInterval) * time.Second)
+	rpcClient.StartHealthCheck(HealthCheckInterval)
+	archiveRpcClient := NewRpcClient(appConfig.MainnetArchiveRPCUrl, appConfig.MainnetRPCUsername, appConfig.MainnetRPCPassword, "text/plain;", logger)
+	archiveRpcClient.SetHttpClient(httpClient)
+	archiveRpcClient.SetContext(life.ctx)
+	archiveRpcClient.SetRateLimit(appConfig.MainnetRPCRateLimit)
+	archiveRpcClient.SetMaxRetryInterval(time.Duration(appConfig.MainnetRPCMaxRetryInterval) * time.Second)
+	rpcClient.SetArchive(archiveRpcClient)
+	smartBchRpcClient := NewRpcClient(appConfig.SmartBchRPCUrl, "", "", "application/json", logger)
+	smartBchRpcClient.SetHttpClient(httpClient)
+	smartBchRpcClient.SetContext(life.ctx)
+	var zmq *zmqSubscriber
+	if appConfig.MainnetZmqUrl != "" {
+		addr, err := CheckZmqUrl(appConfig.MainnetZmqUrl)
+		if err != nil {
+			panic("invalid mainnet-zmq-url: " + err.Error())
+		}
+		zmq = newZmqSubscriber(addr, logger)
+	}
+	var mempool *mempoolScanner
+	if appConfig.WatcherScanMempool {
+		mempool = newMempoolScanner()
+	}
+	analytics := newAnalyticsStore(appConfig.WatcherAnalyticsPath, appConfig.WatcherAnalyticsRetainDays,
+		time.Now().Unix(), logger)
+	var gossipPeers []*RpcClient
+	for _, url := range appConfig.EpochGossipPeers {
+		peer := NewRpcClient(url, "", "", "application/json", logger)
+		peer.SetHttpClient(httpClient)
+		peer.SetContext(life.ctx)
+		gossipPeers = append(gossipPeers, peer)
+	}
+	var bchClient types.RpcClient = rpcClient
+	if appConfig.WatcherReplayDir != "" {
+		replayClient, err := NewReplayClient(appConfig.WatcherReplayDir, logger)
+		if err != nil {
+			panic("invalid watcher-replay-dir: " + err.Error())
+		}
+		logger.Info("replay the exported BCH blocks", "dir", appConfig.WatcherReplayDir,
+			"first", replayClient.FirstHeight(), "latest", replayClient.GetLatestHeight(false))
+		bchClient = replayClient
+	}
+	epochChan := make(chan *stakingtypes.Epoch, epochChanSize)
 	return &Watcher{
 		logger: logger,
 
-		rpcClient:         NewRpcClient(chainConfig.AppConfig.MainnetRPCUrl, chainConfig.AppConfig.MainnetRPCUsername, chainConfig.AppConfig.MainnetRPCPassword, "text/plain;", logger),
-		smartBchRpcClient: NewRpcClient(chainConfig.AppConfig.SmartBchRPCUrl, "", "", "application/json", logger),
+		rpcClient:         bchClient,
+		smartBchRpcClient: smartBchRpcClient,
 
-		lastEpochEndHeight:    lastHeight,
-		latestFinalizedHeight: lastHeight,
-		lastKnownEpochNum:     lastKnownEpochNum,
+		state: watcherState{
+			lastEpochEndHeight:     lastHeight,
+			latestFinalizedHeight:  lastHeight,
+			heightToFinalizedBlock: make(map[int64]*types.BCHBlock),
+			voteInfoList:           make([]*types.VoteInfo, 0, 10),
+			// set big enough for single node startup when no BCH node connected. it will be updated when mainnet block finalize.
+			currentMainnetBlockTimestamp: math.MaxInt64 - 14*24*3600,
+		},
+		lastKnownEpochNum: lastKnownEpochNum,
+		deliveredEpochNum: lastKnownEpochNum,
 
 		catchupChan: make(chan bool, 1),
 
-		heightToFinalizedBlock: make(map[int64]*types.BCHBlock),
-
-		EpochChan:           make(chan *stakingtypes.Epoch, 10000),
+		EpochChan:           epochChan,
+		epochQueue:          newEpochQueue(epochChan, appConfig.WatcherEpochSpillPath),
+		epochArchive:        newEpochArchive(appConfig.WatcherEpochArchivePath, appConfig.WatcherEpochArchiveKeep, logger),
 		MonitorVoteChan:     make(chan *cctypes.MonitorVoteInfo, 5000),
 		monitorVoteInfoList: make([]*cctypes.MonitorVoteInfo, 0, 10),
 
-		voteInfoList: make([]*types.VoteInfo, 0, 10),
-
-		numBlocksInEpoch:      param.StakingNumBlocksInEpoch,
+		numBlocksInEpoch:      param.CurrBchNetwork().NumBlocksInEpoch,
+		blockFinalizeNumber:   param.DefaultBlockFinalizeNumber,
 		waitingBlockDelayTime: waitingBlockDelayTime,
 
 		parallelNum: 10,
 		chainConfig: chainConfig,
-		// set big enough for single node startup when no BCH node connected. it will be updated when mainnet block finalize.
-		currentMainnetBlockTimestamp: math.MaxInt64 - 14*24*3600,
 		txParser: types.CcTxParser{
 			DB: historyDB,
 		},
+		zmq:         zmq,
+		mempool:     mempool,
+		analytics:   analytics,
+		gossipPeers: gossipPeers,
+		life:        life,
 	}
 }
 
 func (watcher *Watcher) SetRpcClient(client types.RpcClient) {
+	if c, ok := client.(*RpcClient); ok {
+		c.SetContext(watcher.life.ctx)
+	}
 	watcher.rpcClient = client
 }
 
-func (watcher *Watcher) SetCCExecutor(exe *crosschain.CcContractExecutor) {
-	watcher.CcContractExecutor = exe
-}
-
 func (watcher *Watcher) SetContextGetter(getter IContextGetter) {
 	watcher.contextGetter = getter
 }
@@ -122,6 +198,11 @@ func (watcher *Watcher) SetNumBlocksInEpoch(n int64) {
 	watcher.numBlocksInEpoch = n
 }
 
+// SetBlockFinalizeNumber sets the number of the blocks on top of a BCH block before it is finalized
+func (watcher *Watcher) SetBlockFinalizeNumber(n int64) {
+	watcher.blockFinalizeNumber = n
+}
+
 func (watcher *Watcher) SetWaitingBlockDelayTime(n int) {
 	watcher.waitingBlockDelayTime = n
 }
@@ -130,15 +211,31 @@ func (watcher *Watcher) WaitCatchup() {
 	<-watcher.catchupChan
 }
 
-// The main function to do a watcher's job. It must be run as a goroutine
+// The main function to do a watcher's job. It must be run as a goroutine, and returns after Stop
 func (watcher *Watcher) Run() {
 	if watcher.rpcClient == (*RpcClient)(nil) {
 		watcher.catchupChan <- true // for ut
 		return
 	}
+	if !watcher.enter() {
+		return
+	}
+	defer watcher.life.wg.Done()
 	watcher.speedup()
+	if watcher.zmq != nil {
+		watcher.goRun(func() { watcher.zmq.run(watcher.life.ctx) })
+	}
+	if len(watcher.gossipPeers) != 0 && watcher.contextGetter != nil {
+		watcher.goRun(watcher.runEpochGossipFallback)
+	}
 	if !param.IsAmber {
-		go watcher.CollectCCTransferInfos()
+		watcher.goRun(watcher.CollectCCTransferInfos)
+		if n := watcher.chainConfig.AppConfig.EpochGapThreshold; n > 0 && watcher.contextGetter != nil {
+			watcher.goRun(func() { watcher.checkEpochGap(n) })
+		}
+		if watcher.mempool != nil && watcher.contextGetter != nil {
+			watcher.goRun(watcher.scanMempool)
+		}
 	}
 	watcher.fetchBlocks()
 }
@@ -146,25 +243,61 @@ func (watcher *Watcher) Run() {
 func (watcher *Watcher) fetchBlocks() {
 	catchedUp := false
 	latestMainnetHeight := watcher.rpcClient.GetLatestHeight(true)
-	heightWanted := watcher.latestFinalizedHeight + 1
+	atomic.StoreInt64(&watcher.latestMainnetHeight, latestMainnetHeight)
+	heightWanted := watcher.GetLatestFinalizedHeight() + 1
 	// parallel fetch blocks when startup
-	if heightWanted+blockFinalizeNumber+int64(watcher.parallelNum) <= latestMainnetHeight {
-		watcher.logger.Debug("block parallel fetch info", "latestFinalizedHeight", watcher.latestFinalizedHeight, "latestMainnetHeight", latestMainnetHeight)
-		watcher.parallelFetchBlocks(heightWanted, latestMainnetHeight-blockFinalizeNumber)
-		heightWanted = watcher.latestFinalizedHeight + 1
+	if heightWanted+watcher.blockFinalizeNumber+int64(watcher.parallelNum) <= latestMainnetHeight {
+		watcher.logger.Debug("block parallel fetch info", "latestFinalizedHeight", heightWanted-1, "latestMainnetHeight", latestMainnetHeight)
+		watcher.parallelFetchBlocks(heightWanted, latestMainnetHeight-watcher.blockFinalizeNumber)
+		watcher.publishEpochs(latestMainnetHeight)
+		heightWanted = watcher.GetLatestFinalizedHeight() + 1
 	}
 	// normal catchup
-	for {
+	for !watcher.stopped() {
 		latestMainnetHeight = watcher.rpcClient.GetLatestHeight(true)
-		for heightWanted+blockFinalizeNumber <= latestMainnetHeight {
-			watcher.addFinalizedBlock(watcher.rpcClient.GetBlockByHeight(heightWanted, true))
-			heightWanted++
-			latestMainnetHeight = watcher.rpcClient.GetLatestHeight(true)
+		atomic.StoreInt64(&watcher.latestMainnetHeight, latestMainnetHeight)
+		watcher.analytics.seeTip(latestMainnetHeight, time.Now().Unix())
+		for heightWanted+watcher.blockFinalizeNumber <= latestMainnetHeight {
+			blk := watcher.rpcClient.GetBlockByHeight(heightWanted, true)
+			if blk == nil || blk.Height != heightWanted {
+				watcher.logger.Info("invalid block fetched, retry later", "height", heightWanted)
+				if !watcher.suspended(refetchDelayTime) {
+					return
+				}
+				continue
+			}
+			if prev := watcher.getFinalizedBlock(heightWanted - 1); prev != nil && blk.ParentBlk != prev.HashId {
+				fork := watcher.handleReorg(prev.Height)
+				// the new block is from a stale branch, or the BCH node is not available
+				if fork == prev.Height && !watcher.suspended(refetchDelayTime) {
+					return
+				}
+				heightWanted = fork + 1
+				continue
+			}
+			if err := watcher.addFinalizedBlock(blk); err != nil {
+				var missing *MissingBlockError
+				if errors.As(err, &missing) && !watcher.healMissingBlocks(missing) {
+					return
+				}
+				// the gossiped epochs are taken meanwhile, continue after them, or the missing
+				// blocks are fetched again
+				heightWanted = watcher.GetLatestFinalizedHeight() + 1
+				continue
+			}
+			watcher.publishEpochs(latestMainnetHeight)
+			heightWanted = watcher.GetLatestFinalizedHeight() + 1
+			// the latest height is only queried again after the known blocks are fetched, to send
+			// fewer requests to the BCH node when catching up
+			recordHeightMetrics(heightWanted-1, latestMainnetHeight, len(watcher.EpochChan))
 		}
+		watcher.publishEpochs(latestMainnetHeight)
+		heightWanted = watcher.GetLatestFinalizedHeight() + 1
+		recordHeightMetrics(heightWanted-1, latestMainnetHeight, len(watcher.EpochChan))
 		if catchedUp {
 			watcher.logger.Debug("waiting BCH mainnet", "height now is", latestMainnetHeight)
-			watcher.suspended(time.Duration(watcher.waitingBlockDelayTime) * time.Second) //delay half of bch mainnet block intervals
-		} else {
+			watcher.waitForNewBlock()
+		} else if !watcher.stopped() {
 			watcher.logger.Debug("AlreadyCaughtUp")
 			catchedUp = true
 			close(watcher.catchupChan)
@@ -172,96 +305,118 @@ func (watcher *Watcher) fetchBlocks() {
 	}
 }
 
-func (watcher *Watcher) parallelFetchBlocks(heightStart, heightEnd int64) {
-	var blockSet = make([]*types.BCHBlock, heightEnd-heightStart+1)
-	sharedIdx := int64(-1)
-	datatree.ParallelRun(watcher.parallelNum, func(_ int) {
-		for {
-			index := atomic.AddInt64(&sharedIdx, 1)
-			if heightStart+index > heightEnd {
-				break
-			}
-			blockSet[index] = watcher.rpcClient.GetBlockByHeight(heightStart+index, true)
+// speedup loads the epochs after the last known one from the epoch snapshot, and then from the
+// smartBCH node, instead of building them from the BCH blocks
+func (watcher *Watcher) speedup() {
+	appConfig := watcher.chainConfig.AppConfig
+	start := uint64(watcher.lastKnownEpochNum) + 1
+	if appConfig.WatcherSnapshotPath != "" {
+		n, ok := watcher.loadEpochSnapshot(appConfig.WatcherSnapshotPath, appConfig.WatcherSnapshotHash, start)
+		if !ok {
+			return
 		}
-	})
-	for _, blk := range blockSet {
-		watcher.addFinalizedBlock(blk)
+		start += uint64(n)
+	}
+	if appConfig.Speedup {
+		if !watcher.speedupFromRpc(start) {
+			return
+		}
+		watcher.logger.Debug("After speedup", "latestFinalizedHeight", watcher.GetLatestFinalizedHeight())
 	}
-	watcher.logger.Debug("Get bch mainnet blocks parallel", "latestFinalizedHeight", watcher.latestFinalizedHeight)
 }
 
-func (watcher *Watcher) speedup() {
-	if watcher.chainConfig.AppConfig.Speedup {
-		start := uint64(watcher.lastKnownEpochNum) + 1
-		for {
-			infos := watcher.smartBchRpcClient.GetVoteInfoByEpochNumber(start, start+100)
-			if len(infos) == 0 {
-				break
+// applyVoteInfos takes the epochs built by others as finalized, and sends them to the app. It
+// returns false if the watcher is stopped.
+func (watcher *Watcher) applyVoteInfos(infos []*types.VoteInfo) bool {
+	watcher.state.mtx.Lock()
+	watcher.state.voteInfoList = append(watcher.state.voteInfoList, infos...)
+	watcher.state.latestFinalizedHeight += int64(len(infos)) * watcher.numBlocksInEpoch
+	watcher.state.lastEpochEndHeight = watcher.state.latestFinalizedHeight
+	watcher.state.mtx.Unlock()
+	for _, in := range infos {
+		if in.Epoch.EndTime != 0 {
+			recordEpochMetrics(&in.Epoch)
+			if !watcher.sendEpoch(&in.Epoch) {
+				return false
 			}
-			watcher.voteInfoList = append(watcher.voteInfoList, infos...)
-			for _, in := range infos {
-				if in.Epoch.EndTime != 0 {
-					watcher.EpochChan <- &in.Epoch
-				}
-				if !param.IsAmber && in.MonitorVote.EndTime != 0 {
-					watcher.MonitorVoteChan <- &in.MonitorVote
-				}
+			watcher.epochArchive.add(atomic.AddInt64(&watcher.deliveredEpochNum, 1), &in.Epoch, monitorVoteOf(in))
+		}
+		if !param.IsAmber && in.MonitorVote.EndTime != 0 {
+			recordMonitorVoteMetrics(&in.MonitorVote)
+			if !watcher.sendMonitorVoteInfo(&in.MonitorVote) {
+				return false
 			}
-			watcher.latestFinalizedHeight += int64(len(infos)) * watcher.numBlocksInEpoch
-			start = start + uint64(len(infos))
 		}
-		watcher.lastEpochEndHeight = watcher.latestFinalizedHeight
-		watcher.logger.Debug("After speedup", "latestFinalizedHeight", watcher.latestFinalizedHeight)
 	}
+	return true
 }
 
-func (watcher *Watcher) suspended(delayDuration time.Duration) {
-	time.Sleep(delayDuration)
-}
-
-// Record new block and if the blocks for a new epoch is all ready, output the new epoch
-func (watcher *Watcher) addFinalizedBlock(blk *types.BCHBlock) {
-	watcher.heightToFinalizedBlock[blk.Height] = blk
-	watcher.latestFinalizedHeight++
-	watcher.currentMainnetBlockTimestamp = blk.Timestamp
-
-	if watcher.latestFinalizedHeight-watcher.lastEpochEndHeight == watcher.numBlocksInEpoch {
-		watcher.generateNewEpoch()
+// Record new block and if the blocks for a new epoch is all ready, build the new epoch, which is
+// published by publishEpochs later. It returns errBlockNotConsecutive if blk does not follow the
+// latest finalized block, which happens after the gossiped epochs are taken, and a
+// *MissingBlockError if the epoch can not be built, in which case blk is not added.
+func (watcher *Watcher) addFinalizedBlock(blk *types.BCHBlock) error {
+	watcher.state.mtx.Lock()
+	defer watcher.state.mtx.Unlock()
+	if blk.Height != watcher.state.latestFinalizedHeight+1 {
+		return errBlockNotConsecutive
 	}
+	watcher.state.heightToFinalizedBlock[blk.Height] = blk
+	watcher.state.latestFinalizedHeight++
+	if watcher.state.latestFinalizedHeight-watcher.state.lastEpochEndHeight == watcher.numBlocksInEpoch {
+		epoch, info, err := watcher.generateNewEpoch()
+		if err != nil {
+			delete(watcher.state.heightToFinalizedBlock, blk.Height)
+			watcher.state.latestFinalizedHeight--
+			return err
+		}
+		watcher.logger.Debug("Generate new epoch", "epochNumber", epoch.Number, "startHeight", epoch.StartHeight)
+		watcher.state.pendingEpochs = append(watcher.state.pendingEpochs, &pendingEpoch{
+			epoch:     epoch,
+			info:      info,
+			endHeight: watcher.state.latestFinalizedHeight,
+		})
+	}
+	watcher.state.currentMainnetBlockTimestamp = blk.Timestamp
+	watcher.analytics.finalize(blk.Height, blk.Timestamp, time.Now().Unix())
+	return nil
 }
 
-// Generate a new block's information
-func (watcher *Watcher) generateNewEpoch() {
-	epoch := watcher.buildNewEpoch()
-	watcher.logger.Debug("Generate new epoch", "epochNumber", epoch.Number, "startHeight", epoch.StartHeight)
-	watcher.EpochChan <- epoch
-	info := watcher.buildMonitorVoteInfo()
-	if info != nil {
-		watcher.MonitorVoteChan <- info
+// Generate a new block's information, state.mtx must be held by the caller
+func (watcher *Watcher) generateNewEpoch() (*stakingtypes.Epoch, *cctypes.MonitorVoteInfo, error) {
+	epoch, err := watcher.buildNewEpoch()
+	if err != nil {
+		return nil, nil, err
+	}
+	info, err := watcher.buildMonitorVoteInfo()
+	if err != nil {
+		return nil, nil, err
 	}
 	var voteInfo types.VoteInfo
 	voteInfo.Epoch = *epoch
 	if info != nil {
 		voteInfo.MonitorVote = *info
 	}
-	watcher.voteInfoList = append(watcher.voteInfoList, &voteInfo)
-	watcher.lastEpochEndHeight = watcher.latestFinalizedHeight
-	watcher.ClearOldData()
+	watcher.state.voteInfoList = append(watcher.state.voteInfoList, &voteInfo)
+	watcher.state.lastEpochEndHeight = watcher.state.latestFinalizedHeight
+	watcher.clearOldData()
+	return epoch, info, nil
 }
 
-func (watcher *Watcher) buildMonitorVoteInfo() *cctypes.MonitorVoteInfo {
-	startHeight := watcher.lastEpochEndHeight + 1
-	if startHeight < param.StartMainnetHeightForCC {
-		return nil
+// state.mtx must be held by the caller
+func (watcher *Watcher) buildMonitorVoteInfo() (*cctypes.MonitorVoteInfo, error) {
+	startHeight := watcher.state.lastEpochEndHeight + 1
+	if startHeight < param.CurrBchNetwork().StartHeightForCC {
+		return nil, nil
 	}
 	var info cctypes.MonitorVoteInfo
 	info.StartHeight = startHeight
 	var monitorMapByPubkey = make(map[[33]byte]*cctypes.Nomination)
 
-	for i := startHeight; i <= watcher.latestFinalizedHeight; i++ {
-		blk, ok := watcher.heightToFinalizedBlock[i]
+	for i := startHeight; i <= watcher.state.latestFinalizedHeight; i++ {
+		blk, ok := watcher.state.heightToFinalizedBlock[i]
 		if !ok {
-			panic("Missing Block")
+			return nil, &MissingBlockError{Height: i}
 		}
 		for _, ccNomination := range blk.CCNominations {
 			if _, ok := monitorMapByPubkey[ccNomination.Pubkey]; !ok {
@@ -274,7 +429,7 @@ func (watcher *Watcher) buildMonitorVoteInfo() *cctypes.MonitorVoteInfo {
 		info.Nominations = append(info.Nominations, v)
 	}
 	sortMonitorVoteNominations(info.Nominations)
-	return &info
+	return &info, nil
 }
 
 func sortMonitorVoteNominations(nominations []*cctypes.Nomination) {
@@ -286,21 +441,28 @@ func sortMonitorVoteNominations(nominations []*cctypes.Nomination) {
 	})
 }
 
-func (watcher *Watcher) buildNewEpoch() *stakingtypes.Epoch {
+// state.mtx must be held by the caller
+func (watcher *Watcher) buildNewEpoch() (*stakingtypes.Epoch, error) {
 	epoch := &stakingtypes.Epoch{
-		StartHeight: watcher.lastEpochEndHeight + 1,
+		StartHeight: watcher.state.lastEpochEndHeight + 1,
 		Nominations: make([]*stakingtypes.Nomination, 0, 10),
 	}
+	withRules := epoch.StartHeight >= param.NominationRulesForkMainnetHeight
+	var blocks []*types.BCHBlock
 	var valMapByPubkey = make(map[[32]byte]*stakingtypes.Nomination)
-	for i := epoch.StartHeight; i <= watcher.latestFinalizedHeight; i++ {
-		blk, ok := watcher.heightToFinalizedBlock[i]
+	for i := epoch.StartHeight; i <= watcher.state.latestFinalizedHeight; i++ {
+		blk, ok := watcher.state.heightToFinalizedBlock[i]
 		if !ok {
-			panic("Missing Block")
+			return nil, &MissingBlockError{Height: i}
 		}
 		//Please note that BCH's timestamp is not always linearly increasing
 		if epoch.EndTime < blk.Timestamp {
 			epoch.EndTime = blk.Timestamp
 		}
+		if withRules {
+			blocks = append(blocks, blk)
+			continue
+		}
 		for _, nomination := range blk.Nominations {
 			if _, ok := valMapByPubkey[nomination.Pubkey]; !ok {
 				valMapByPubkey[nomination.Pubkey] = &nomination
@@ -308,31 +470,14 @@ func (watcher *Watcher) buildNewEpoch() *stakingtypes.Epoch {
 			valMapByPubkey[nomination.Pubkey].NominatedCount += nomination.NominatedCount
 		}
 	}
+	if withRules {
+		epoch.Nominations = append(epoch.Nominations, CurrNominationCountRules().Count(blocks)...)
+	}
 	for _, v := range valMapByPubkey {
 		epoch.Nominations = append(epoch.Nominations, v)
 	}
 	sortEpochNominations(epoch)
-	return epoch
-}
-
-func (watcher *Watcher) GetCurrEpoch() *stakingtypes.Epoch {
-	return watcher.buildNewEpoch()
-}
-func (watcher *Watcher) GetEpochList() []*stakingtypes.Epoch {
-	epochList := make([]*stakingtypes.Epoch, len(watcher.voteInfoList))
-	for i, v := range watcher.voteInfoList {
-		epochList[i] = stakingtypes.CopyEpoch(v.Epoch)
-	}
-	currEpoch := watcher.buildNewEpoch()
-	return append(epochList, currEpoch)
-}
-
-func (watcher *Watcher) GetCurrMainnetBlockTimestamp() int64 {
-	return watcher.currentMainnetBlockTimestamp
-}
-
-func (watcher *Watcher) GetLatestFinalizedHeight() int64 {
-	return watcher.latestFinalizedHeight
+	return epoch, nil
 }
 
 func (watcher *Watcher) CheckSanity(skipCheck bool) {
@@ -359,26 +504,27 @@ func sortEpochNominations(epoch *stakingtypes.Epoch) {
 	})
 }
 
-func (watcher *Watcher) ClearOldData() {
-	vLen := len(watcher.voteInfoList)
+// state.mtx must be held by the caller
+func (watcher *Watcher) clearOldData() {
+	vLen := len(watcher.state.voteInfoList)
 	if vLen == 0 {
 		return
 	}
-	height := watcher.voteInfoList[vLen-1].Epoch.StartHeight
+	height := watcher.state.voteInfoList[vLen-1].Epoch.StartHeight
 	height -= 5 * watcher.numBlocksInEpoch
 	if height <= 0 {
 		return
 	}
 	for {
-		_, ok := watcher.heightToFinalizedBlock[height]
+		_, ok := watcher.state.heightToFinalizedBlock[height]
 		if !ok {
 			break
 		}
-		delete(watcher.heightToFinalizedBlock, height)
+		delete(watcher.state.heightToFinalizedBlock, height)
 		height--
 	}
 	if vLen > monitorInfoCleanThreshold /*param it*/ {
-		watcher.voteInfoList = append([]*types.VoteInfo{}, watcher.voteInfoList[vLen-monitorInfoCleanThreshold:]...)
+		watcher.state.voteInfoList = append([]*types.VoteInfo{}, watcher.state.voteInfoList[vLen-monitorInfoCleanThreshold:]...)
 	}
 }
 
@@ -397,40 +543,106 @@ func (watcher *Watcher) getUTXOCollectParam() *cctypes.UTXOCollectParam {
 	}
 }
 
+// olderCovenantAddrs returns the covenant addresses before PrevCovenantAddress, the deposits to
+// which are still recognized
+func (watcher *Watcher) olderCovenantAddrs() []common.Address {
+	ctx := watcher.contextGetter.GetRpcContext()
+	defer ctx.Close(false)
+	ccContext := crosschain.LoadCCContext(ctx)
+	if ccContext == nil {
+		return nil
+	}
+	var addrs []common.Address
+	for _, addr := range crosschain.OlderCovenantAddrs(ctx, ccContext) {
+		addrs = append(addrs, addr)
+	}
+	return addrs
+}
+
 func (watcher *Watcher) CollectCCTransferInfos() {
+	appConfig := watcher.chainConfig.AppConfig
 	var latestEndHeight int64
 	var initCollect = true
-	collectInterval := int64(1)
-	for {
-		time.Sleep(time.Duration(collectInterval) * time.Second)
-		if watcher.latestFinalizedHeight < param.StartMainnetHeightForCC {
+	var journalOpened bool
+	// the duplicates dropped in the rounds collecting the current window
+	var duplicates int
+	collectInterval := int64(appConfig.CcCollectInterval)
+	if collectInterval <= 0 {
+		collectInterval = param.DefaultCcCollectInterval
+	}
+	for watcher.suspended(time.Duration(collectInterval) * time.Second) {
+		if watcher.GetLatestFinalizedHeight() < param.CurrBchNetwork().StartHeightForCC {
+			continue
+		}
+		if watcher.ccCollectPaused() {
 			continue
 		}
-		if watcher.CcContractExecutor == nil {
+		executor := watcher.GetCCExecutor()
+		if executor == nil {
 			continue
 		}
 		collectParam := watcher.getUTXOCollectParam()
 		if collectParam == nil {
 			continue
 		}
-		if collectParam.EndHeight == latestEndHeight || collectParam.BeginHeight == 0 {
+		if collectParam.BeginHeight == 0 {
 			continue
 		}
-		watcher.CcContractExecutor.Lock.Lock()
-		fmt.Printf("new collect round, beign:%d,end:%d\n", collectParam.BeginHeight, collectParam.EndHeight)
-		latestEndHeight = collectParam.EndHeight
-		var infos []*cctypes.CCTransferInfo
-		blocks := watcher.getFinalizedBCHBlockInfos(collectParam.BeginHeight, collectParam.EndHeight)
-		watcher.txParser.Refresh(collectParam.PrevCovenantAddress, collectParam.CurrentCovenantAddress)
-		for _, bi := range blocks {
-			infos = append(infos, watcher.txParser.GetCCUTXOTransferInfo(bi)...)
-		}
-		watcher.logger.Debug("collect cc infos", "BeginHeight", collectParam.BeginHeight, "EndHeight", collectParam.EndHeight, "length", len(infos))
-		watcher.CcContractExecutor.Infos = infos
-		watcher.CcContractExecutor.LastEndRescanBlock = uint64(latestEndHeight)
-		watcher.CcContractExecutor.Lock.Unlock()
+		if !journalOpened {
+			if err := executor.Queue.OpenJournal(appConfig.CcInfoJournalPath); err != nil {
+				watcher.logger.Error("failed to restore the cc info journal", "err", err)
+			}
+			journalOpened = true
+		}
+		// a forced re-scan only re-parses its range of the window collected last time
+		var rescan *types.HeightRange
+		if collectParam.EndHeight == latestEndHeight {
+			if rescan = watcher.takeCcRescan(collectParam); rescan == nil {
+				continue
+			}
+		}
+		roundStart := time.Now()
+		watcher.txParser.Refresh(collectParam.PrevCovenantAddress, collectParam.CurrentCovenantAddress, watcher.olderCovenantAddrs()...)
+		var ok bool
+		if rescan != nil {
+			watcher.logger.Info("cc re-scan", "begin", rescan.Begin, "end", rescan.End)
+			duplicates, ok = watcher.rescanCcInfos(&executor.Queue, rescan.Begin, rescan.End)
+		} else {
+			fmt.Printf("new collect round, beign:%d,end:%d\n", collectParam.BeginHeight, collectParam.EndHeight)
+			resumeFrom, err := executor.Queue.Start(collectParam.BeginHeight, collectParam.EndHeight)
+			if err != nil {
+				watcher.logger.Error("failed to journal cc infos", "err", err)
+			}
+			if resumeFrom == collectParam.BeginHeight {
+				duplicates = 0
+			} else {
+				watcher.logger.Info("resume collecting cc infos", "from", resumeFrom)
+			}
+			roundEnd := ccCollectRoundEnd(resumeFrom, collectParam.EndHeight, appConfig.CcCollectMaxBlocksPerRound)
+			var dup int
+			dup, ok = watcher.collectCcInfos(&executor.Queue, resumeFrom, roundEnd)
+			duplicates += dup
+			if ok && roundEnd != collectParam.EndHeight {
+				// the rest of the window is left to the next rounds
+				recordCcCollectRound(roundStart)
+				continue
+			}
+			latestEndHeight = collectParam.EndHeight
+		}
+		if !ok {
+			// stopped in the middle of the round, the window is not sealed
+			return
+		}
+		if err := executor.Queue.Seal(); err != nil {
+			watcher.logger.Error("failed to journal cc infos", "err", err)
+		}
+		infos := executor.Queue.Len()
+		watcher.logger.Debug("collect cc infos", "BeginHeight", collectParam.BeginHeight, "EndHeight", collectParam.EndHeight,
+			"length", infos, "duplicates", duplicates)
+		recordCcCollectRound(roundStart)
+		watcher.setCcCollectStatus(collectParam.BeginHeight, collectParam.EndHeight, infos, duplicates, rescan)
 		if initCollect {
-			close(watcher.CcContractExecutor.UTXOInitCollectDoneChan)
+			close(executor.UTXOInitCollectDoneChan)
 			initCollect = false
 		}
 	}
@@ -442,8 +654,10 @@ func (watcher *Watcher) getFinalizedBCHBlockInfos(startHeight, endHeight int64)
 		return nil
 	}
 	latestHeight := watcher.rpcClient.GetLatestHeight(true)
-	for latestHeight < endHeight+blockFinalizeNumber {
-		time.Sleep(30 * time.Second)
+	for latestHeight < endHeight+watcher.blockFinalizeNumber {
+		if !watcher.suspended(30 * time.Second) {
+			return nil
+		}
 		latestHeight = watcher.rpcClient.GetLatestHeight(true)
 	}
 	return watcher.getBCHBlockInfos(startHeight, endHeight)
@@ -462,5 +676,8 @@ func (watcher *Watcher) getBCHBlockInfos(startHeight, endHeight int64) (blocks [
 			blocks[myIdx-startHeight-1] = watcher.rpcClient.GetBlockInfoByHeight(myIdx, true)
 		}
 	})
+	if !watcher.refetchBadBlockInfos(blocks, startHeight+1) {
+		return nil
+	}
 	return
 }
@@ -0,0 +1,258 @@
+package watcher
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/tendermint/tendermint/libs/log"
+)
+
+const (
+	// the topic published by bitcoind with -zmqpubhashblock
+	zmqTopicHashBlock = "hashblock"
+	// the watcher still polls the BCH node at this interval when the ZMQ notifications work, in
+	// case one is lost
+	zmqFallbackPollInterval = time.Minute
+	zmqDialTimeout          = 10 * time.Second
+	zmqMaxReconnectInterval = time.Minute
+	zmqMaxFrameSize         = 4 << 20 // a raw block can be 32MB, but only hashblock is subscribed
+)
+
+// CheckZmqUrl checks the url of a ZMQ publisher, only tcp is supported
+func CheckZmqUrl(zmqUrl string) (string, error) {
+	addr := strings.TrimPrefix(zmqUrl, "tcp://")
+	if addr == zmqUrl {
+		return "", fmt.Errorf("invalid ZMQ url %q, it must be like tcp://127.0.0.1:28332", zmqUrl)
+	}
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		return "", fmt.Errorf("invalid ZMQ url %q: %w", zmqUrl, err)
+	}
+	return addr, nil
+}
+
+// zmqSubscriber receives the hashblock notifications published by bitcoind through ZMQ. It speaks
+// just enough ZMTP 3.0 to be a SUB socket with the NULL security mechanism, which is what bitcoind
+// offers, and reconnects when the connection is broken.
+type zmqSubscriber struct {
+	addr      string
+	logger    log.Logger
+	notify    chan struct{} // has a value if a block is notified since the last read
+	connected int32         // accessed atomically
+}
+
+func newZmqSubscriber(addr string, logger log.Logger) *zmqSubscriber {
+	return &zmqSubscriber{
+		addr:   addr,
+		logger: logger,
+		notify: make(chan struct{}, 1),
+	}
+}
+
+func (s *zmqSubscriber) isConnected() bool {
+	return atomic.LoadInt32(&s.connected) == 1
+}
+
+// run keeps subscribing until ctx is done
+func (s *zmqSubscriber) run(ctx context.Context) {
+	b := newRetryBackoff(zmqMaxReconnectInterval)
+	for {
+		err := s.subscribe(ctx, func() { b = newRetryBackoff(zmqMaxReconnectInterval) })
+		atomic.StoreInt32(&s.connected, 0)
+		zmqConnected.Set(0)
+		if ctx.Err() != nil {
+			return
+		}
+		s.logger.Info("ZMQ subscription is down, polling the BCH node instead", "addr", s.addr, "err", err)
+		if !sleepWithContext(ctx, b.next(0)) {
+			return
+		}
+	}
+}
+
+// subscribe connects to the publisher and receives the notifications until the connection is
+// broken, onConnected is called after the handshake
+func (s *zmqSubscriber) subscribe(ctx context.Context, onConnected func()) error {
+	dialer := net.Dialer{Timeout: zmqDialTimeout, KeepAlive: 30 * time.Second}
+	conn, err := dialer.DialContext(ctx, "tcp", s.addr)
+	if err != nil {
+		return err
+	}
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-done:
+		}
+		conn.Close()
+	}()
+
+	r := bufio.NewReader(conn)
+	if err = zmqHandshake(conn, r); err != nil {
+		return err
+	}
+	// a subscription is a message starting with 1 in ZMTP 3.0
+	if err = zmqWriteFrame(conn, 0, append([]byte{1}, zmqTopicHashBlock...)); err != nil {
+		return err
+	}
+	atomic.StoreInt32(&s.connected, 1)
+	zmqConnected.Set(1)
+	onConnected()
+	s.logger.Info("subscribed to the ZMQ notifications of BCH blocks", "addr", s.addr)
+	for {
+		frames, err := zmqReadMessage(r)
+		if err != nil {
+			return err
+		}
+		// bitcoind sends the topic, the block hash and a sequence number
+		if len(frames) >= 2 && string(frames[0]) == zmqTopicHashBlock {
+			s.logger.Debug("BCH block notified by ZMQ", "hash", fmt.Sprintf("%x", frames[1]))
+			select {
+			case s.notify <- struct{}{}:
+			default:
+			}
+		}
+	}
+}
+
+const (
+	zmqFlagMore    = 0x01
+	zmqFlagLong    = 0x02
+	zmqFlagCommand = 0x04
+)
+
+func zmqHandshake(w io.Writer, r *bufio.Reader) error {
+	greeting := make([]byte, 64)
+	greeting[0], greeting[9] = 0xff, 0x7f
+	greeting[10], greeting[11] = 3, 0 // ZMTP 3.0
+	copy(greeting[12:32], "NULL")
+	if _, err := w.Write(greeting); err != nil {
+		return err
+	}
+	peerGreeting := make([]byte, 64)
+	if _, err := io.ReadFull(r, peerGreeting); err != nil {
+		return err
+	}
+	if peerGreeting[0] != 0xff || peerGreeting[9] != 0x7f || peerGreeting[10] < 3 {
+		return errors.New("the peer does not speak ZMTP 3")
+	}
+	if mechanism := strings.TrimRight(string(peerGreeting[12:32]), "\x00"); mechanism != "NULL" {
+		return fmt.Errorf("unsupported ZMQ security mechanism %q", mechanism)
+	}
+
+	var ready []byte
+	ready = append(ready, 5)
+	ready = append(ready, "READY"...)
+	ready = append(ready, byte(len("Socket-Type")))
+	ready = append(ready, "Socket-Type"...)
+	ready = append(ready, 0, 0, 0, byte(len("SUB")))
+	ready = append(ready, "SUB"...)
+	if err := zmqWriteFrame(w, zmqFlagCommand, ready); err != nil {
+		return err
+	}
+	flags, body, err := zmqReadFrame(r)
+	if err != nil {
+		return err
+	}
+	if flags&zmqFlagCommand == 0 || len(body) < 6 || string(body[1:6]) != "READY" {
+		return errors.New("the ZMQ peer did not send READY")
+	}
+	return nil
+}
+
+func zmqWriteFrame(w io.Writer, flags byte, body []byte) error {
+	var header []byte
+	if len(body) > 255 {
+		header = make([]byte, 9)
+		header[0] = flags | zmqFlagLong
+		binary.BigEndian.PutUint64(header[1:], uint64(len(body)))
+	} else {
+		header = []byte{flags, byte(len(body))}
+	}
+	_, err := w.Write(append(header, body...))
+	return err
+}
+
+func zmqReadFrame(r *bufio.Reader) (flags byte, body []byte, err error) {
+	if flags, err = r.ReadByte(); err != nil {
+		return
+	}
+	var size uint64
+	if flags&zmqFlagLong != 0 {
+		var bz [8]byte
+		if _, err = io.ReadFull(r, bz[:]); err != nil {
+			return
+		}
+		size = binary.BigEndian.Uint64(bz[:])
+	} else {
+		var b byte
+		if b, err = r.ReadByte(); err != nil {
+			return
+		}
+		size = uint64(b)
+	}
+	if size > zmqMaxFrameSize {
+		return 0, nil, fmt.Errorf("ZMQ frame is too large: %d bytes", size)
+	}
+	body = make([]byte, size)
+	_, err = io.ReadFull(r, body)
+	return
+}
+
+// zmqReadMessage returns the frames of the next message, the commands are skipped
+func zmqReadMessage(r *bufio.Reader) ([][]byte, error) {
+	var frames [][]byte
+	for {
+		flags, body, err := zmqReadFrame(r)
+		if err != nil {
+			return nil, err
+		}
+		if flags&zmqFlagCommand != 0 {
+			continue
+		}
+		frames = append(frames, body)
+		if flags&zmqFlagMore == 0 {
+			return frames, nil
+		}
+	}
+}
+
+// waitForNewBlock waits for the next BCH block when the watcher has caught up. It is notified
+// by ZMQ if enabled, otherwise it polls after waitingBlockDelayTime. It returns false if the
+// watcher is stopped meanwhile.
+func (watcher *Watcher) waitForNewBlock() bool {
+	pollInterval := time.Duration(watcher.waitingBlockDelayTime) * time.Second
+	if watcher.zmq == nil || !watcher.zmq.isConnected() {
+		return watcher.suspended(pollInterval)
+	}
+	if pollInterval <= 0 {
+		pollInterval = time.Second
+	}
+	timer := time.NewTimer(zmqFallbackPollInterval)
+	defer timer.Stop()
+	// falls back to polling if the subscription is down meanwhile
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-watcher.zmq.notify:
+			return true
+		case <-timer.C:
+			return true
+		case <-ticker.C:
+			if !watcher.zmq.isConnected() {
+				return true
+			}
+		case <-watcher.life.ctx.Done():
+			return false
+		}
+	}
+}
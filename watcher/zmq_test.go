@@ -0,0 +1,65 @@
+package watcher
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/libs/log"
+)
+
+// fakeZmqPublisher accepts one subscriber and publishes a hashblock notification to it
+func fakeZmqPublisher(t *testing.T, ln net.Listener, subscribed chan<- []byte) {
+	conn, err := ln.Accept()
+	require.NoError(t, err)
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	require.NoError(t, zmqHandshake(conn, r))
+	_, sub, err := zmqReadFrame(r)
+	require.NoError(t, err)
+	subscribed <- sub
+	require.NoError(t, zmqWriteFrame(conn, zmqFlagMore, []byte(zmqTopicHashBlock)))
+	require.NoError(t, zmqWriteFrame(conn, zmqFlagMore, make([]byte, 32)))
+	require.NoError(t, zmqWriteFrame(conn, 0, []byte{1, 0, 0, 0}))
+	// keep the connection until the subscriber closes it
+	_, _ = r.ReadByte()
+}
+
+func TestZmqSubscriber(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+	subscribed := make(chan []byte, 1)
+	go fakeZmqPublisher(t, ln, subscribed)
+
+	s := newZmqSubscriber(ln.Addr().String(), log.NewNopLogger())
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		s.run(ctx)
+		close(done)
+	}()
+	require.Equal(t, append([]byte{1}, zmqTopicHashBlock...), <-subscribed)
+	select {
+	case <-s.notify:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no notification")
+	}
+	require.True(t, s.isConnected())
+	cancel()
+	<-done
+	require.False(t, s.isConnected())
+}
+
+func TestCheckZmqUrl(t *testing.T) {
+	addr, err := CheckZmqUrl("tcp://127.0.0.1:28332")
+	require.NoError(t, err)
+	require.Equal(t, "127.0.0.1:28332", addr)
+	_, err = CheckZmqUrl("127.0.0.1:28332")
+	require.Error(t, err)
+	_, err = CheckZmqUrl("tcp://127.0.0.1")
+	require.Error(t, err)
+}